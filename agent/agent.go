@@ -0,0 +1,288 @@
+// Package agent inspects the health of the Ops Agent on Compute Engine VMs
+// from the metrics and logs it writes, so that missing telemetry can be told
+// apart from an agent that is not installed or has stopped.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+// UptimeMetric is written by the metrics module of the agent every minute,
+// with the agent version as its version label
+const UptimeMetric = "agent.googleapis.com/agent/uptime"
+
+// Statuses of a Report
+const (
+	StatusHealthy      = "healthy"       // metrics are recent and no problems were logged
+	StatusDegraded     = "degraded"      // metrics are recent, but the agent logged problems or ships no logs
+	StatusStopped      = "stopped"       // the agent wrote metrics in the window, but none recently
+	StatusNotInstalled = "not_installed" // no metrics or logs of the agent in the window
+)
+
+const (
+	defaultLookback   = time.Hour
+	defaultStaleAfter = 10 * time.Minute
+	// maxProblems bounds the warnings of the agent's own logs that are reported
+	maxProblems = 10
+)
+
+// Request represents a request to check the agent of a VM
+type Request struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the clients' project
+	// Instance is the name or the numeric ID of the Compute Engine instance
+	Instance   string        `json:"instance"`
+	EndTime    time.Time     `json:"end_time"`              // defaults to now
+	Lookback   time.Duration `json:"lookback,omitempty"`    // defaults to one hour
+	StaleAfter time.Duration `json:"stale_after,omitempty"` // defaults to 10 minutes
+}
+
+// Report represents the health of the agent of a VM
+type Report struct {
+	Instance       string            `json:"instance"`
+	Status         string            `json:"status"`
+	Installed      bool              `json:"installed"`
+	Running        bool              `json:"running"`
+	ShippingLogs   bool              `json:"shipping_logs"`
+	Versions       []string          `json:"versions,omitempty"` // e.g. "google-cloud-ops-agent-metrics/2.46.0"
+	LastMetricTime time.Time         `json:"last_metric_time,omitzero"`
+	LastLogTime    time.Time         `json:"last_log_time,omitzero"`
+	Problems       []Problem         `json:"problems,omitempty"` // warnings and errors logged by the agent, newest first
+	Findings       []string          `json:"findings"`           // one line per conclusion
+	Errors         map[string]string `json:"errors,omitempty"`   // section to the error that prevented checking it
+}
+
+// Problem represents a warning or an error logged by the agent itself
+type Problem struct {
+	Time     time.Time `json:"time"`
+	Severity string    `json:"severity"`
+	Message  string    `json:"message"`
+}
+
+// Checker checks the agents of VMs with the telemetry clients
+type Checker struct {
+	logging    logging.LoggingClient
+	monitoring monitoring.MonitoringClient
+}
+
+// NewChecker creates a new Checker
+func NewChecker(loggingClient logging.LoggingClient, monitoringClient monitoring.MonitoringClient) *Checker {
+	return &Checker{
+		logging:    loggingClient,
+		monitoring: monitoringClient,
+	}
+}
+
+// Check looks for the uptime metric of the agent, the logs shipped from the
+// VM, and the problems logged by the agent in the window ending at EndTime,
+// concurrently. A section that fails is reported in Report.Errors instead of
+// failing the whole report.
+func (c *Checker) Check(ctx context.Context, req Request) (Report, error) {
+	if req.Instance == "" {
+		return Report{}, fmt.Errorf("instance is required")
+	}
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+	if req.Lookback <= 0 {
+		req.Lookback = defaultLookback
+	}
+	if req.StaleAfter <= 0 {
+		req.StaleAfter = defaultStaleAfter
+	}
+
+	report := Report{Instance: req.Instance}
+	var mu sync.Mutex
+	addError := func(section string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if report.Errors == nil {
+			report.Errors = make(map[string]string)
+		}
+		report.Errors[section] = err.Error()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		if err := c.checkMetrics(ctx, req, &report); err != nil {
+			addError("metrics", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		last, err := c.lastLogTime(ctx, req)
+		if err != nil {
+			addError("logs", err)
+			return
+		}
+		report.LastLogTime = last
+	}()
+	go func() {
+		defer wg.Done()
+		problems, err := c.problems(ctx, req)
+		if err != nil {
+			addError("problems", err)
+			return
+		}
+		report.Problems = problems
+	}()
+	wg.Wait()
+
+	report.ShippingLogs = !report.LastLogTime.IsZero()
+	report.Running = !report.LastMetricTime.IsZero() && req.EndTime.Sub(report.LastMetricTime) <= req.StaleAfter
+	report.Installed = !report.LastMetricTime.IsZero() || report.ShippingLogs || len(report.Problems) > 0
+	report.Status, report.Findings = assess(req, report)
+	return report, nil
+}
+
+// checkMetrics sets the time of the last uptime point of the agent and the
+// versions it reported
+func (c *Checker) checkMetrics(ctx context.Context, req Request, report *Report) error {
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    fmt.Sprintf(`metric.type=%q AND resource.type="gce_instance" AND %s`, UptimeMetric, metricInstanceFilter(req.Instance)),
+		PageSize:  100,
+	}
+	listReq.Interval.StartTime = req.EndTime.Add(-req.Lookback)
+	listReq.Interval.EndTime = req.EndTime
+
+	resp, err := c.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return err
+	}
+	for _, ts := range resp.TimeSeries {
+		if version := ts.MetricLabels["version"]; version != "" && !slices.Contains(report.Versions, version) {
+			report.Versions = append(report.Versions, version)
+		}
+		for _, v := range ts.Values {
+			if v.Timestamp.After(report.LastMetricTime) {
+				report.LastMetricTime = v.Timestamp
+			}
+		}
+	}
+	slices.Sort(report.Versions)
+	return nil
+}
+
+// lastLogTime returns the time of the newest entry shipped from the VM in
+// the window, leaving out the audit logs written by Google Cloud about it
+func (c *Checker) lastLogTime(ctx context.Context, req Request) (time.Time, error) {
+	filter := fmt.Sprintf(`resource.type="gce_instance" AND %s AND -logName:"cloudaudit.googleapis.com" AND %s`,
+		logInstanceFilter(req.Instance), timeFilter(req))
+	resp, err := c.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		OrderBy:   logging.OrderByTimestampDesc,
+		Limit:     1,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(resp.Entries) == 0 {
+		return time.Time{}, nil
+	}
+	return resp.Entries[0].Timestamp, nil
+}
+
+// problems lists the warnings and errors the agent logged about itself, e.g.
+// failed health checks or API permission errors, in the ops-agent-* logs
+func (c *Checker) problems(ctx context.Context, req Request) ([]Problem, error) {
+	filter := fmt.Sprintf(`resource.type="gce_instance" AND %s AND logName:"ops-agent" AND severity>=WARNING AND %s`,
+		logInstanceFilter(req.Instance), timeFilter(req))
+	resp, err := c.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		OrderBy:   logging.OrderByTimestampDesc,
+		Limit:     maxProblems,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	for _, entry := range resp.Entries {
+		problems = append(problems, Problem{
+			Time:     entry.Timestamp,
+			Severity: entry.Severity,
+			Message:  entry.Message,
+		})
+	}
+	return problems, nil
+}
+
+// assess returns the status of the agent and the findings explaining it
+func assess(req Request, report Report) (string, []string) {
+	findings := []string{}
+	if report.Errors != nil {
+		findings = append(findings, "Some checks failed, so the status may be incomplete; see errors")
+	}
+
+	switch {
+	case !report.Installed:
+		findings = append(findings, fmt.Sprintf("No agent metrics or logs from %s in the last %s: the Ops Agent is likely not installed, or the VM does not exist or is stopped", req.Instance, req.Lookback))
+		return StatusNotInstalled, findings
+	case report.LastMetricTime.IsZero():
+		findings = append(findings, fmt.Sprintf("No %s points in the last %s: the metrics module of the agent is not running", UptimeMetric, req.Lookback))
+	case !report.Running:
+		findings = append(findings, fmt.Sprintf("The last %s point is from %s, over %s before the end of the window: the agent stopped writing metrics", UptimeMetric, report.LastMetricTime.Format(time.RFC3339), req.StaleAfter))
+	default:
+		findings = append(findings, fmt.Sprintf("The agent is writing metrics (last point at %s)", report.LastMetricTime.Format(time.RFC3339)))
+	}
+	if report.ShippingLogs {
+		findings = append(findings, fmt.Sprintf("Logs are shipped from the VM (last entry at %s)", report.LastLogTime.Format(time.RFC3339)))
+	} else {
+		findings = append(findings, fmt.Sprintf("No logs from the VM in the last %s: the logging module of the agent may not be running", req.Lookback))
+	}
+	if len(report.Problems) > 0 {
+		findings = append(findings, fmt.Sprintf("The agent logged %d warnings or errors, the newest: %s", len(report.Problems), report.Problems[0].Message))
+	}
+
+	switch {
+	case !report.Running:
+		return StatusStopped, findings
+	case !report.ShippingLogs || len(report.Problems) > 0:
+		return StatusDegraded, findings
+	default:
+		return StatusHealthy, findings
+	}
+}
+
+// metricInstanceFilter selects the time series of the instance, given by
+// numeric ID or by name
+func metricInstanceFilter(instance string) string {
+	if isInstanceID(instance) {
+		return fmt.Sprintf("resource.labels.instance_id=%q", instance)
+	}
+	return fmt.Sprintf("metadata.system_labels.name=%q", instance)
+}
+
+// logInstanceFilter selects the log entries of the instance, given by
+// numeric ID or by name
+func logInstanceFilter(instance string) string {
+	if isInstanceID(instance) {
+		return fmt.Sprintf("resource.labels.instance_id=%q", instance)
+	}
+	return fmt.Sprintf(`labels."compute.googleapis.com/resource_name"=%q`, instance)
+}
+
+// isInstanceID reports whether instance is a numeric instance ID rather than
+// a name, which must start with a letter
+func isInstanceID(instance string) bool {
+	_, err := strconv.ParseUint(instance, 10, 64)
+	return err == nil
+}
+
+// timeFilter selects the log entries of the window of req
+func timeFilter(req Request) string {
+	return fmt.Sprintf("timestamp>=%q AND timestamp<=%q",
+		req.EndTime.Add(-req.Lookback).UTC().Format(time.RFC3339Nano), req.EndTime.UTC().Format(time.RFC3339Nano))
+}
@@ -0,0 +1,173 @@
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/agent"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	loggingmocks "github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	monitoringmocks "github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// clients returns mocks answering with uptime points at the given times, the
+// entries shipped from the VM, and the problems logged by the agent
+func clients(t *testing.T, uptime []time.Time, shipped, problems []logging.LogEntry, loggingErr error) (*loggingmocks.MockLoggingClient, *monitoringmocks.MockMonitoringClient) {
+	ctrl := gomock.NewController(t)
+	monitoringClient := monitoringmocks.NewMockMonitoringClient(ctrl)
+	monitoringClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			if !strings.Contains(req.Filter, `metric.type="agent.googleapis.com/agent/uptime"`) || !strings.Contains(req.Filter, `metadata.system_labels.name="web-1"`) {
+				t.Errorf("Unexpected filter %s", req.Filter)
+			}
+			var values []monitoring.MetricValue
+			for _, ts := range uptime {
+				values = append(values, monitoring.MetricValue{Value: 60, Timestamp: ts})
+			}
+			if len(values) == 0 {
+				return monitoring.ListTimeSeriesResponse{}, nil
+			}
+			return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{{
+				MetricType:   agent.UptimeMetric,
+				MetricLabels: map[string]string{"version": "google-cloud-ops-agent-metrics/2.46.0"},
+				Values:       values,
+			}}}, nil
+		}).
+		Times(1)
+
+	loggingClient := loggingmocks.NewMockLoggingClient(ctrl)
+	loggingClient.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+			if !strings.Contains(req.Filter, `labels."compute.googleapis.com/resource_name"="web-1"`) {
+				t.Errorf("Unexpected filter %s", req.Filter)
+			}
+			if loggingErr != nil {
+				return logging.ListEntriesResponse{}, loggingErr
+			}
+			if strings.Contains(req.Filter, `logName:"ops-agent"`) {
+				return logging.ListEntriesResponse{Entries: problems}, nil
+			}
+			return logging.ListEntriesResponse{Entries: shipped}, nil
+		}).
+		Times(2)
+	return loggingClient, monitoringClient
+}
+
+func TestChecker_Check(t *testing.T) {
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	shipped := []logging.LogEntry{{Timestamp: end.Add(-time.Minute), Message: "sshd started"}}
+	problem := logging.LogEntry{Timestamp: end.Add(-2 * time.Minute), Severity: "ERROR", Message: "Permission denied: monitoring.timeSeries.create"}
+
+	tests := []struct {
+		name         string
+		uptime       []time.Time
+		shipped      []logging.LogEntry
+		problems     []logging.LogEntry
+		loggingErr   error
+		wantStatus   string
+		wantRunning  bool
+		wantFinding  string
+		wantErrorKey string
+	}{
+		{
+			name:        "healthy",
+			uptime:      []time.Time{end.Add(-3 * time.Minute), end.Add(-time.Minute)},
+			shipped:     shipped,
+			wantStatus:  agent.StatusHealthy,
+			wantRunning: true,
+			wantFinding: "The agent is writing metrics",
+		},
+		{
+			name:        "problems logged",
+			uptime:      []time.Time{end.Add(-time.Minute)},
+			shipped:     shipped,
+			problems:    []logging.LogEntry{problem},
+			wantStatus:  agent.StatusDegraded,
+			wantRunning: true,
+			wantFinding: "Permission denied",
+		},
+		{
+			name:        "no logs shipped",
+			uptime:      []time.Time{end.Add(-time.Minute)},
+			wantStatus:  agent.StatusDegraded,
+			wantRunning: true,
+			wantFinding: "No logs from the VM",
+		},
+		{
+			name:        "stopped",
+			uptime:      []time.Time{end.Add(-40 * time.Minute)},
+			shipped:     shipped,
+			wantStatus:  agent.StatusStopped,
+			wantFinding: "stopped writing metrics",
+		},
+		{
+			name:        "not installed",
+			wantStatus:  agent.StatusNotInstalled,
+			wantFinding: "likely not installed",
+		},
+		{
+			name:         "logging error",
+			uptime:       []time.Time{end.Add(-time.Minute)},
+			loggingErr:   errors.New("permission denied"),
+			wantStatus:   agent.StatusDegraded,
+			wantRunning:  true,
+			wantFinding:  "Some checks failed",
+			wantErrorKey: "logs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loggingClient, monitoringClient := clients(t, tt.uptime, tt.shipped, tt.problems, tt.loggingErr)
+			report, err := agent.NewChecker(loggingClient, monitoringClient).Check(context.Background(), agent.Request{
+				Instance: "web-1",
+				EndTime:  end,
+			})
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if report.Status != tt.wantStatus || report.Running != tt.wantRunning {
+				t.Errorf("Expected status %s and running %v, got %s and %v", tt.wantStatus, tt.wantRunning, report.Status, report.Running)
+			}
+			if findings := strings.Join(report.Findings, "\n"); !strings.Contains(findings, tt.wantFinding) {
+				t.Errorf("Expected a finding containing %q, got %s", tt.wantFinding, findings)
+			}
+			if tt.wantErrorKey != "" && report.Errors[tt.wantErrorKey] == "" {
+				t.Errorf("Expected an error for %s, got %v", tt.wantErrorKey, report.Errors)
+			}
+		})
+	}
+}
+
+func TestChecker_CheckInstanceID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	monitoringClient := monitoringmocks.NewMockMonitoringClient(ctrl)
+	monitoringClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			if !strings.Contains(req.Filter, `resource.labels.instance_id="1234567890"`) {
+				t.Errorf("Expected the instance ID in the filter, got %s", req.Filter)
+			}
+			return monitoring.ListTimeSeriesResponse{}, nil
+		})
+	loggingClient := loggingmocks.NewMockLoggingClient(ctrl)
+	loggingClient.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+			if !strings.Contains(req.Filter, `resource.labels.instance_id="1234567890"`) {
+				t.Errorf("Expected the instance ID in the filter, got %s", req.Filter)
+			}
+			return logging.ListEntriesResponse{}, nil
+		}).
+		Times(2)
+
+	if _, err := agent.NewChecker(loggingClient, monitoringClient).Check(context.Background(), agent.Request{Instance: "1234567890"}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+// Package apiusage counts the Google Cloud API calls made for each MCP
+// session, the bytes they returned, and the quota categories they roughly
+// consume, so that agents can see what their investigations cost.
+package apiusage
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Quota categories of the calls. They approximate the quotas of the APIs,
+// which count some methods separately, e.g. the time series queries of
+// Cloud Monitoring.
+const (
+	QuotaRead                = "read requests"
+	QuotaWrite               = "write requests"
+	QuotaTimeSeriesQueries   = "time series queries"
+	QuotaTimeSeriesIngestion = "time series ingestion requests"
+	QuotaLogEntriesRead      = "log entries read requests"
+	QuotaDLPContent          = "content inspection requests"
+)
+
+// methodQuotas are the quota categories of the methods counted separately
+// by their APIs, by gRPC method name
+var methodQuotas = map[string]string{
+	"ListTimeSeries":   QuotaTimeSeriesQueries,
+	"QueryTimeSeries":  QuotaTimeSeriesQueries,
+	"CreateTimeSeries": QuotaTimeSeriesIngestion,
+	"ListLogEntries":   QuotaLogEntriesRead,
+	"TailLogEntries":   QuotaLogEntriesRead,
+}
+
+// readVerbs start the names of the methods that only read
+var readVerbs = []string{"List", "Get", "Query", "Search", "Tail"}
+
+// ServiceStats are the calls made to one API
+type ServiceStats struct {
+	Calls  int `json:"calls"`
+	Errors int `json:"errors"`
+	// BytesReturned is the size of the responses, as protocol buffers for
+	// gRPC APIs and as JSON for REST APIs
+	BytesReturned int64 `json:"bytes_returned"`
+	// Quotas counts the calls by quota category
+	Quotas map[string]int `json:"quotas"`
+}
+
+// SessionStats are the API calls made for one session
+type SessionStats struct {
+	Calls         int   `json:"calls"`
+	Errors        int   `json:"errors"`
+	BytesReturned int64 `json:"bytes_returned"`
+	// Services are the stats of each API, by host name, e.g.
+	// monitoring.googleapis.com
+	Services map[string]ServiceStats `json:"services"`
+}
+
+// Tracker counts the API calls of the clients it is applied to, by session.
+// Calls made outside of tool calls, e.g. by watches, are counted for the
+// session "".
+type Tracker struct {
+	mu       sync.Mutex
+	sessions map[string]*SessionStats
+}
+
+// NewTracker creates a Tracker without calls
+func NewTracker() *Tracker {
+	return &Tracker{sessions: make(map[string]*SessionStats)}
+}
+
+// Stats returns the calls made for a session so far
+func (t *Tracker) Stats(sessionID string) SessionStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := SessionStats{Services: make(map[string]ServiceStats)}
+	s, ok := t.sessions[sessionID]
+	if !ok {
+		return stats
+	}
+	stats = *s
+	stats.Services = make(map[string]ServiceStats, len(s.Services))
+	for service, serviceStats := range s.Services {
+		serviceStats.Quotas = maps.Clone(serviceStats.Quotas)
+		stats.Services[service] = serviceStats
+	}
+	return stats
+}
+
+// Delete forgets the calls of a session, e.g. when it ends
+func (t *Tracker) Delete(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sessions, sessionID)
+}
+
+// record counts a call of the session of ctx
+func (t *Tracker) record(ctx context.Context, service, quota string, failed bool, bytes int64) {
+	var sessionID string
+	if clientSession := server.ClientSessionFromContext(ctx); clientSession != nil {
+		sessionID = clientSession.SessionID()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[sessionID]
+	if !ok {
+		s = &SessionStats{Services: make(map[string]ServiceStats)}
+		t.sessions[sessionID] = s
+	}
+	serviceStats := s.Services[service]
+	if serviceStats.Quotas == nil {
+		serviceStats.Quotas = make(map[string]int)
+	}
+	s.Calls++
+	serviceStats.Calls++
+	serviceStats.Quotas[quota]++
+	if failed {
+		s.Errors++
+		serviceStats.Errors++
+	}
+	s.BytesReturned += bytes
+	serviceStats.BytesReturned += bytes
+	s.Services[service] = serviceStats
+}
+
+// addBytes counts bytes returned after a call was recorded, e.g. by the
+// messages of a stream
+func (t *Tracker) addBytes(ctx context.Context, service string, bytes int64) {
+	var sessionID string
+	if clientSession := server.ClientSessionFromContext(ctx); clientSession != nil {
+		sessionID = clientSession.SessionID()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.sessions[sessionID]; ok {
+		serviceStats := s.Services[service]
+		s.BytesReturned += bytes
+		serviceStats.BytesReturned += bytes
+		s.Services[service] = serviceStats
+	}
+}
+
+// ClientOptions returns the options counting the calls of gRPC clients. The
+// calls of REST clients are counted with Transport instead.
+func (t *Tracker) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(t.unaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(t.streamInterceptor)),
+	}
+}
+
+// unaryInterceptor counts unary calls and the size of their responses
+func (t *Tracker) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	var bytes int64
+	if m, ok := reply.(proto.Message); ok && err == nil {
+		bytes = int64(proto.Size(m))
+	}
+	t.record(ctx, grpcService(cc), grpcQuota(method), err != nil, bytes)
+	return err
+}
+
+// streamInterceptor counts the opening of streams, and the size of the
+// messages they receive
+func (t *Tracker) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	service := grpcService(cc)
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	t.record(ctx, service, grpcQuota(method), err != nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return countedStream{ClientStream: stream, tracker: t, ctx: ctx, service: service}, nil
+}
+
+// countedStream counts the size of the messages received on a stream
+type countedStream struct {
+	grpc.ClientStream
+	tracker *Tracker
+	ctx     context.Context
+	service string
+}
+
+// RecvMsg implements grpc.ClientStream
+func (s countedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if msg, ok := m.(proto.Message); ok && err == nil {
+		s.tracker.addBytes(s.ctx, s.service, int64(proto.Size(msg)))
+	}
+	return err
+}
+
+// grpcService returns the host name of the API cc is connected to
+func grpcService(cc *grpc.ClientConn) string {
+	target := cc.Target()
+	if _, rest, ok := strings.Cut(target, "///"); ok {
+		target = rest
+	}
+	host, _, _ := strings.Cut(target, ":")
+	return host
+}
+
+// grpcQuota returns the quota category of a method, given as
+// /package.Service/Method
+func grpcQuota(fullMethod string) string {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	if quota, ok := methodQuotas[method]; ok {
+		return quota
+	}
+	for _, verb := range readVerbs {
+		if strings.HasPrefix(method, verb) {
+			return QuotaRead
+		}
+	}
+	return QuotaWrite
+}
+
+// Transport returns a transport counting the requests sent with base and
+// the bytes of their response bodies
+func (t *Tracker) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{tracker: t, base: base}
+}
+
+// roundTripper counts the requests of base
+type roundTripper struct {
+	tracker *Tracker
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	service := req.URL.Hostname()
+	quota := QuotaWrite
+	switch {
+	case strings.HasPrefix(service, "dlp."):
+		quota = QuotaDLPContent
+	case req.Method == http.MethodGet:
+		quota = QuotaRead
+	}
+	resp, err := rt.base.RoundTrip(req)
+	rt.tracker.record(req.Context(), service, quota, err != nil || resp.StatusCode >= 400, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &countedBody{ReadCloser: resp.Body, tracker: rt.tracker, ctx: req.Context(), service: service}
+	return resp, nil
+}
+
+// countedBody counts the bytes read from a response body
+type countedBody struct {
+	io.ReadCloser
+	tracker *Tracker
+	ctx     context.Context
+	service string
+}
+
+// Read implements io.Reader
+func (b *countedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.tracker.addBytes(b.ctx, b.service, int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,138 @@
+package apiusage_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	monitoringapi "cloud.google.com/go/monitoring/apiv3/v2"
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/kitagry/gcp-telemetry-mcp/apiusage"
+	"github.com/kitagry/gcp-telemetry-mcp/internal/testserver"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/api/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testSession is a client session with a fixed ID
+type testSession struct {
+	id string
+}
+
+func (s testSession) Initialize()                                         {}
+func (s testSession) Initialized() bool                                   { return true }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s testSession) SessionID() string                                   { return s.id }
+
+// sessionContext returns a context of the calls of a session
+func sessionContext(id string) context.Context {
+	return server.NewMCPServer("test", "dev").WithContext(context.Background(), testSession{id: id})
+}
+
+func TestTracker_GRPC(t *testing.T) {
+	srv, err := testserver.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	tracker := apiusage.NewTracker()
+	opts := append([]option.ClientOption{
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}, tracker.ClientOptions()...)
+	client, err := monitoringapi.NewMetricClient(context.Background(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := sessionContext("a")
+	_, err = client.CreateMetricDescriptor(ctx, &monitoringpb.CreateMetricDescriptorRequest{
+		Name: "projects/test-project",
+		MetricDescriptor: &metric.MetricDescriptor{
+			Type:       "custom.googleapis.com/queue_depth",
+			MetricKind: metric.MetricDescriptor_GAUGE,
+			ValueType:  metric.MetricDescriptor_DOUBLE,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	descriptors := client.ListMetricDescriptors(ctx, &monitoringpb.ListMetricDescriptorsRequest{Name: "projects/test-project"})
+	if _, err := descriptors.Next(); err != nil {
+		t.Fatal(err)
+	}
+	series := client.ListTimeSeries(ctx, &monitoringpb.ListTimeSeriesRequest{
+		Name:     "projects/test-project",
+		Filter:   `metric.type="custom.googleapis.com/queue_depth"`,
+		Interval: &monitoringpb.TimeInterval{EndTime: timestamppb.Now()},
+	})
+	if _, err := series.Next(); err != iterator.Done {
+		t.Fatalf("Expected no time series, got %v", err)
+	}
+
+	stats := tracker.Stats("a")
+	host, _, _ := strings.Cut(srv.Addr, ":")
+	service, ok := stats.Services[host]
+	if !ok || stats.Calls != 3 || service.Calls != 3 || service.BytesReturned == 0 {
+		t.Fatalf("Expected 3 calls to %s, got %+v", host, stats)
+	}
+	if service.Quotas[apiusage.QuotaWrite] != 1 || service.Quotas[apiusage.QuotaRead] != 1 || service.Quotas[apiusage.QuotaTimeSeriesQueries] != 1 {
+		t.Errorf("Unexpected quota categories %v", service.Quotas)
+	}
+	if other := tracker.Stats("b"); other.Calls != 0 || len(other.Services) != 0 {
+		t.Errorf("Expected no calls for another session, got %+v", other)
+	}
+	tracker.Delete("a")
+	if stats := tracker.Stats("a"); stats.Calls != 0 {
+		t.Errorf("Expected the calls of a deleted session to be forgotten, got %+v", stats)
+	}
+}
+
+func TestTracker_Transport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"profiles":[]}`))
+	}))
+	defer server.Close()
+
+	tracker := apiusage.NewTracker()
+	client := &http.Client{Transport: tracker.Transport(nil)}
+	ctx := sessionContext("a")
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req, err := http.NewRequestWithContext(ctx, method, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadAll(resp.Body); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	stats := tracker.Stats("a")
+	service := stats.Services["127.0.0.1"]
+	if service.Calls != 2 || service.Errors != 1 || service.BytesReturned != int64(len(`{"profiles":[]}`)) {
+		t.Errorf("Unexpected stats %+v", service)
+	}
+	if service.Quotas[apiusage.QuotaRead] != 1 || service.Quotas[apiusage.QuotaWrite] != 1 {
+		t.Errorf("Unexpected quota categories %v", service.Quotas)
+	}
+}
@@ -0,0 +1,251 @@
+// Package asset lists the resources that exist in a project according to
+// Cloud Asset Inventory, to match telemetry against the infrastructure it
+// claims to come from.
+package asset
+
+//go:generate go tool mockgen -destination=mocks/mock_client.go -package=mocks github.com/kitagry/gcp-telemetry-mcp/asset AssetClient,AssetClientInterface
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/cloudasset/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	// maxAssets bounds the assets of a type FindAssets reads
+	maxAssets = 5000
+	// assetsPageSize is the largest page size of Cloud Asset Inventory
+	assetsPageSize = 1000
+	// defaultAssetsLimit is the number of assets FindAssets returns by default
+	defaultAssetsLimit = 100
+)
+
+// Asset represents a resource in Cloud Asset Inventory
+type Asset struct {
+	// Name is the full resource name, e.g.
+	// //compute.googleapis.com/projects/PROJECT/zones/ZONE/instances/NAME
+	Name      string `json:"name"`
+	AssetType string `json:"asset_type"` // e.g. compute.googleapis.com/Instance
+	// ID and DisplayName are the id and name of the resource, e.g. the
+	// instance_id and the name of a VM
+	ID          string            `json:"id,omitempty"`
+	DisplayName string            `json:"display_name,omitempty"`
+	Location    string            `json:"location,omitempty"`
+	State       string            `json:"state,omitempty"` // e.g. RUNNING or TERMINATED
+	Labels      map[string]string `json:"labels,omitempty"`
+	UpdateTime  time.Time         `json:"update_time,omitzero"`
+}
+
+// ListAssetsRequest represents a request for a page of the assets of a project
+type ListAssetsRequest struct {
+	ProjectID  string   `json:"project_id"`
+	AssetTypes []string `json:"asset_types,omitempty"` // all types when empty
+	PageSize   int      `json:"page_size,omitempty"`
+	PageToken  string   `json:"page_token,omitempty"`
+}
+
+// ListAssetsResponse represents a page of assets and pagination info
+type ListAssetsResponse struct {
+	Assets        []Asset   `json:"assets"`
+	NextPageToken string    `json:"next_page_token,omitempty"`
+	ReadTime      time.Time `json:"read_time,omitzero"` // of the snapshot the assets were read from
+}
+
+// FindAssetsRequest represents a request for the assets of a type, or a
+// cross-check of resource IDs seen in telemetry against them
+type FindAssetsRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	AssetType string `json:"asset_type"`
+	// IDs are checked against the id, name, and full resource name of the
+	// assets, e.g. the instance_id labels of time series
+	IDs   []string `json:"ids,omitempty"`
+	Limit int      `json:"limit,omitempty"` // assets returned, defaults to 100
+}
+
+// FindAssetsResponse represents the assets of a type. With IDs, Assets are
+// the assets matching them and Missing the IDs without an asset.
+type FindAssetsResponse struct {
+	AssetType string   `json:"asset_type"`
+	Total     int      `json:"total"` // assets of the type in the project
+	Assets    []Asset  `json:"assets"`
+	Missing   []string `json:"missing,omitempty"`
+	// Truncated is set when more than Limit assets matched, or the type has
+	// more than maxAssets assets, of which the rest were not checked
+	Truncated bool      `json:"truncated,omitempty"`
+	ReadTime  time.Time `json:"read_time,omitzero"`
+}
+
+// AssetClient defines the interface for Cloud Asset Inventory operations
+type AssetClient interface {
+	FindAssets(ctx context.Context, req FindAssetsRequest) (FindAssetsResponse, error)
+}
+
+// AssetClientInterface abstracts the Cloud Asset API for testing
+type AssetClientInterface interface {
+	ListAssets(ctx context.Context, req ListAssetsRequest) (ListAssetsResponse, error)
+}
+
+// CloudAssetClient implements AssetClient using Cloud Asset Inventory
+type CloudAssetClient struct {
+	client    AssetClientInterface
+	projectID string
+}
+
+// New creates a new CloudAssetClient
+func New(projectID string, opts ...option.ClientOption) (*CloudAssetClient, error) {
+	client, err := NewAPIClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithClient(client, projectID), nil
+}
+
+// NewAPIClient creates the AssetClientInterface calling the Cloud Asset API,
+// e.g. to be wrapped before it is passed to NewWithClient
+func NewAPIClient(opts ...option.ClientOption) (AssetClientInterface, error) {
+	service, err := cloudasset.NewService(context.Background(), append([]option.ClientOption{option.WithScopes(cloudasset.CloudPlatformScope)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Asset service: %w", err)
+	}
+	return &realAssetClient{service: service}, nil
+}
+
+// NewWithClient creates a CloudAssetClient with a custom interface for testing
+func NewWithClient(client AssetClientInterface, projectID string) *CloudAssetClient {
+	return &CloudAssetClient{client: client, projectID: projectID}
+}
+
+// FindAssets reads the assets of a type in a project. Telemetry outlives the
+// resources it comes from, so IDs seen in time series or logs that are
+// Missing belong to resources that were deleted, or live in another project.
+func (c *CloudAssetClient) FindAssets(ctx context.Context, req FindAssetsRequest) (FindAssetsResponse, error) {
+	if req.AssetType == "" {
+		return FindAssetsResponse{}, fmt.Errorf("asset_type is required")
+	}
+	if req.ProjectID == "" {
+		req.ProjectID = c.projectID
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultAssetsLimit
+	}
+
+	resp := FindAssetsResponse{AssetType: req.AssetType, Assets: []Asset{}}
+	var assets []Asset
+	listReq := ListAssetsRequest{ProjectID: req.ProjectID, AssetTypes: []string{req.AssetType}, PageSize: assetsPageSize}
+	for {
+		page, err := c.client.ListAssets(ctx, listReq)
+		if err != nil {
+			return FindAssetsResponse{}, fmt.Errorf("failed to list assets: %w", err)
+		}
+		assets = append(assets, page.Assets...)
+		resp.ReadTime = page.ReadTime
+		if page.NextPageToken == "" {
+			break
+		}
+		if len(assets) >= maxAssets {
+			resp.Truncated = true
+			break
+		}
+		listReq.PageToken = page.NextPageToken
+	}
+	resp.Total = len(assets)
+
+	if len(req.IDs) > 0 {
+		var matched []Asset
+		for _, id := range req.IDs {
+			i := slices.IndexFunc(assets, func(a Asset) bool { return a.matches(id) })
+			if i < 0 {
+				resp.Missing = append(resp.Missing, id)
+				continue
+			}
+			if !slices.ContainsFunc(matched, func(a Asset) bool { return a.Name == assets[i].Name }) {
+				matched = append(matched, assets[i])
+			}
+		}
+		assets = matched
+	}
+
+	if len(assets) > req.Limit {
+		assets = assets[:req.Limit]
+		resp.Truncated = true
+	}
+	resp.Assets = append(resp.Assets, assets...)
+	return resp, nil
+}
+
+// matches reports whether id is the id, name, or full resource name of the asset
+func (a Asset) matches(id string) bool {
+	return id != "" && (id == a.ID || id == a.DisplayName || id == a.Name || strings.HasSuffix(a.Name, "/"+id))
+}
+
+// realAssetClient implements AssetClientInterface with the Cloud Asset API
+type realAssetClient struct {
+	service *cloudasset.Service
+}
+
+// ListAssets implements AssetClientInterface
+func (r *realAssetClient) ListAssets(ctx context.Context, req ListAssetsRequest) (ListAssetsResponse, error) {
+	call := r.service.Assets.List("projects/" + req.ProjectID).Context(ctx).ContentType("RESOURCE")
+	if len(req.AssetTypes) > 0 {
+		call = call.AssetTypes(req.AssetTypes...)
+	}
+	if req.PageSize > 0 {
+		call = call.PageSize(int64(req.PageSize))
+	}
+	if req.PageToken != "" {
+		call = call.PageToken(req.PageToken)
+	}
+	list, err := call.Do()
+	if err != nil {
+		return ListAssetsResponse{}, err
+	}
+
+	resp := ListAssetsResponse{Assets: []Asset{}, NextPageToken: list.NextPageToken}
+	if readTime, err := time.Parse(time.RFC3339Nano, list.ReadTime); err == nil {
+		resp.ReadTime = readTime
+	}
+	for _, a := range list.Assets {
+		resp.Assets = append(resp.Assets, convertAsset(a))
+	}
+	return resp, nil
+}
+
+// resourceData are the fields of the resource data of an asset common to
+// most asset types
+type resourceData struct {
+	ID     json.RawMessage   `json:"id"` // a string or a number
+	Name   string            `json:"name"`
+	Status string            `json:"status"`
+	State  string            `json:"state"`
+	Labels map[string]string `json:"labels"`
+}
+
+// convertAsset converts an asset of the Cloud Asset API
+func convertAsset(a *cloudasset.Asset) Asset {
+	asset := Asset{Name: a.Name, AssetType: a.AssetType}
+	if updateTime, err := time.Parse(time.RFC3339Nano, a.UpdateTime); err == nil {
+		asset.UpdateTime = updateTime
+	}
+	if a.Resource == nil {
+		return asset
+	}
+	asset.Location = a.Resource.Location
+
+	var data resourceData
+	if err := json.Unmarshal(a.Resource.Data, &data); err == nil {
+		asset.ID = strings.Trim(string(data.ID), `"`)
+		asset.DisplayName = data.Name
+		asset.State = data.Status
+		if asset.State == "" {
+			asset.State = data.State
+		}
+		asset.Labels = data.Labels
+	}
+	return asset
+}
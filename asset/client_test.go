@@ -0,0 +1,108 @@
+package asset_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+	"github.com/kitagry/gcp-telemetry-mcp/asset/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudAssetClient_FindAssets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockAssetClientInterface(ctrl)
+	client := asset.NewWithClient(mockClient, "test-project")
+
+	web1 := asset.Asset{
+		Name:        "//compute.googleapis.com/projects/test-project/zones/us-central1-b/instances/web-1",
+		AssetType:   "compute.googleapis.com/Instance",
+		ID:          "1111",
+		DisplayName: "web-1",
+		State:       "RUNNING",
+	}
+	web2 := asset.Asset{
+		Name:        "//compute.googleapis.com/projects/test-project/zones/us-central1-b/instances/web-2",
+		AssetType:   "compute.googleapis.com/Instance",
+		ID:          "2222",
+		DisplayName: "web-2",
+		State:       "TERMINATED",
+	}
+	gomock.InOrder(
+		mockClient.EXPECT().
+			ListAssets(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req asset.ListAssetsRequest) (asset.ListAssetsResponse, error) {
+				if req.ProjectID != "test-project" || !slices.Equal(req.AssetTypes, []string{"compute.googleapis.com/Instance"}) {
+					t.Errorf("Unexpected request %+v", req)
+				}
+				return asset.ListAssetsResponse{Assets: []asset.Asset{web1}, NextPageToken: "next"}, nil
+			}),
+		mockClient.EXPECT().
+			ListAssets(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req asset.ListAssetsRequest) (asset.ListAssetsResponse, error) {
+				if req.PageToken != "next" {
+					t.Errorf("Expected the next page, got %q", req.PageToken)
+				}
+				return asset.ListAssetsResponse{Assets: []asset.Asset{web2}}, nil
+			}),
+	)
+
+	// The metrics of a deleted VM keep its instance_id
+	resp, err := client.FindAssets(context.Background(), asset.FindAssetsRequest{
+		AssetType: "compute.googleapis.com/Instance",
+		IDs:       []string{"2222", "web-1", "instances/web-1", "3333"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Total != 2 || resp.Truncated {
+		t.Errorf("Expected 2 assets, got %+v", resp)
+	}
+	if len(resp.Assets) != 2 || resp.Assets[0].Name != web2.Name || resp.Assets[1].Name != web1.Name {
+		t.Errorf("Expected each matched asset once, in the order of the IDs, got %+v", resp.Assets)
+	}
+	if !slices.Equal(resp.Missing, []string{"3333"}) {
+		t.Errorf("Expected 3333 to be missing, got %v", resp.Missing)
+	}
+}
+
+func TestCloudAssetClient_FindAssets_Limit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockAssetClientInterface(ctrl)
+	mockClient.EXPECT().
+		ListAssets(gomock.Any(), gomock.Any()).
+		Return(asset.ListAssetsResponse{Assets: []asset.Asset{{Name: "a"}, {Name: "b"}, {Name: "c"}}}, nil)
+	client := asset.NewWithClient(mockClient, "test-project")
+
+	resp, err := client.FindAssets(context.Background(), asset.FindAssetsRequest{AssetType: "storage.googleapis.com/Bucket", Limit: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Total != 3 || len(resp.Assets) != 2 || !resp.Truncated || resp.Missing != nil {
+		t.Errorf("Expected the first 2 of 3 assets, got %+v", resp)
+	}
+}
+
+func TestCloudAssetClient_FindAssets_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockAssetClientInterface(ctrl)
+	mockClient.EXPECT().
+		ListAssets(gomock.Any(), gomock.Any()).
+		Return(asset.ListAssetsResponse{}, errors.New("permission denied"))
+	client := asset.NewWithClient(mockClient, "test-project")
+
+	if _, err := client.FindAssets(context.Background(), asset.FindAssetsRequest{}); err == nil {
+		t.Error("Expected an error without an asset type")
+	}
+	if _, err := client.FindAssets(context.Background(), asset.FindAssetsRequest{AssetType: "compute.googleapis.com/Instance"}); err == nil {
+		t.Error("Expected the error of the API")
+	}
+}
@@ -0,0 +1,68 @@
+package asset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/cloudasset/v1"
+)
+
+func TestConvertAsset(t *testing.T) {
+	tests := []struct {
+		name  string
+		asset *cloudasset.Asset
+		want  Asset
+	}{
+		{
+			name: "compute instance",
+			asset: &cloudasset.Asset{
+				Name:       "//compute.googleapis.com/projects/p/zones/us-central1-b/instances/web-1",
+				AssetType:  "compute.googleapis.com/Instance",
+				UpdateTime: "2024-01-01T10:00:00.123Z",
+				Resource: &cloudasset.Resource{
+					Location: "us-central1-b",
+					Data:     []byte(`{"id": "1234567890", "name": "web-1", "status": "RUNNING", "labels": {"team": "checkout"}}`),
+				},
+			},
+			want: Asset{
+				Name:        "//compute.googleapis.com/projects/p/zones/us-central1-b/instances/web-1",
+				AssetType:   "compute.googleapis.com/Instance",
+				ID:          "1234567890",
+				DisplayName: "web-1",
+				Location:    "us-central1-b",
+				State:       "RUNNING",
+				Labels:      map[string]string{"team": "checkout"},
+				UpdateTime:  time.Date(2024, 1, 1, 10, 0, 0, 123000000, time.UTC),
+			},
+		},
+		{
+			name: "numeric id and state",
+			asset: &cloudasset.Asset{
+				Name:      "//sqladmin.googleapis.com/projects/p/instances/db",
+				AssetType: "sqladmin.googleapis.com/Instance",
+				Resource:  &cloudasset.Resource{Data: []byte(`{"id": 42, "name": "db", "state": "RUNNABLE"}`)},
+			},
+			want: Asset{
+				Name:        "//sqladmin.googleapis.com/projects/p/instances/db",
+				AssetType:   "sqladmin.googleapis.com/Instance",
+				ID:          "42",
+				DisplayName: "db",
+				State:       "RUNNABLE",
+			},
+		},
+		{
+			name:  "no resource",
+			asset: &cloudasset.Asset{Name: "//storage.googleapis.com/b", AssetType: "storage.googleapis.com/Bucket"},
+			want:  Asset{Name: "//storage.googleapis.com/b", AssetType: "storage.googleapis.com/Bucket"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertAsset(tt.asset); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertAsset() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
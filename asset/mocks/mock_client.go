@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/kitagry/gcp-telemetry-mcp/asset (interfaces: AssetClient,AssetClientInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_client.go -package=mocks github.com/kitagry/gcp-telemetry-mcp/asset AssetClient,AssetClientInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	asset "github.com/kitagry/gcp-telemetry-mcp/asset"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAssetClient is a mock of AssetClient interface.
+type MockAssetClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockAssetClientMockRecorder
+	isgomock struct{}
+}
+
+// MockAssetClientMockRecorder is the mock recorder for MockAssetClient.
+type MockAssetClientMockRecorder struct {
+	mock *MockAssetClient
+}
+
+// NewMockAssetClient creates a new mock instance.
+func NewMockAssetClient(ctrl *gomock.Controller) *MockAssetClient {
+	mock := &MockAssetClient{ctrl: ctrl}
+	mock.recorder = &MockAssetClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAssetClient) EXPECT() *MockAssetClientMockRecorder {
+	return m.recorder
+}
+
+// FindAssets mocks base method.
+func (m *MockAssetClient) FindAssets(ctx context.Context, req asset.FindAssetsRequest) (asset.FindAssetsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAssets", ctx, req)
+	ret0, _ := ret[0].(asset.FindAssetsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAssets indicates an expected call of FindAssets.
+func (mr *MockAssetClientMockRecorder) FindAssets(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAssets", reflect.TypeOf((*MockAssetClient)(nil).FindAssets), ctx, req)
+}
+
+// MockAssetClientInterface is a mock of AssetClientInterface interface.
+type MockAssetClientInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAssetClientInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAssetClientInterfaceMockRecorder is the mock recorder for MockAssetClientInterface.
+type MockAssetClientInterfaceMockRecorder struct {
+	mock *MockAssetClientInterface
+}
+
+// NewMockAssetClientInterface creates a new mock instance.
+func NewMockAssetClientInterface(ctrl *gomock.Controller) *MockAssetClientInterface {
+	mock := &MockAssetClientInterface{ctrl: ctrl}
+	mock.recorder = &MockAssetClientInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAssetClientInterface) EXPECT() *MockAssetClientInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ListAssets mocks base method.
+func (m *MockAssetClientInterface) ListAssets(ctx context.Context, req asset.ListAssetsRequest) (asset.ListAssetsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAssets", ctx, req)
+	ret0, _ := ret[0].(asset.ListAssetsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAssets indicates an expected call of ListAssets.
+func (mr *MockAssetClientInterfaceMockRecorder) ListAssets(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAssets", reflect.TypeOf((*MockAssetClientInterface)(nil).ListAssets), ctx, req)
+}
@@ -0,0 +1,319 @@
+// Package billing collects cost-related metrics and Cloud Billing budget alerts
+// so that cost anomalies can be investigated alongside performance ones.
+package billing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/chart"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+// DefaultMetricPrefixes are the metric type prefixes cost metrics are commonly
+// exported under, since Cloud Billing does not write metrics to Cloud Monitoring itself
+var DefaultMetricPrefixes = []string{
+	"custom.googleapis.com/billing/",
+	"custom.googleapis.com/cost/",
+	"workload.googleapis.com/billing/",
+}
+
+// DefaultBudgetAlertFilter matches budget notifications that were written to
+// Cloud Logging, e.g., by a function subscribed to the budget's Pub/Sub topic
+const DefaultBudgetAlertFilter = `jsonPayload.budgetDisplayName:*`
+
+const (
+	defaultAlignmentPeriod = 24 * time.Hour
+	defaultMaxMetrics      = 20
+	defaultMaxBudgetAlerts = 100
+	// anomalyStdDevs is how many standard deviations above the mean of the
+	// earlier points the last point must be to count as an anomaly
+	anomalyStdDevs = 3
+	// minBaselinePoints is the number of earlier points needed to judge an anomaly
+	minBaselinePoints = 4
+)
+
+// Request represents a request for billing metrics
+type Request struct {
+	ProjectID         string        `json:"project_id,omitempty"` // defaults to the clients' project
+	StartTime         time.Time     `json:"start_time"`
+	EndTime           time.Time     `json:"end_time"`
+	MetricPrefixes    []string      `json:"metric_prefixes,omitempty"`     // defaults to DefaultMetricPrefixes
+	AlignmentPeriod   time.Duration `json:"alignment_period,omitempty"`    // defaults to one day
+	BudgetAlertFilter string        `json:"budget_alert_filter,omitempty"` // defaults to DefaultBudgetAlertFilter
+	MaxMetrics        int           `json:"max_metrics,omitempty"`
+	MaxBudgetAlerts   int           `json:"max_budget_alerts,omitempty"`
+}
+
+// Report represents the cost signals found in a time window
+type Report struct {
+	StartTime    time.Time         `json:"start_time"`
+	EndTime      time.Time         `json:"end_time"`
+	CostMetrics  []CostMetric      `json:"cost_metrics"`
+	BudgetAlerts []BudgetAlert     `json:"budget_alerts"`
+	Anomalies    []string          `json:"anomalies,omitempty"` // one line per anomalous series
+	Errors       map[string]string `json:"errors,omitempty"`    // section or metric type to the error that prevented collecting it
+}
+
+// CostMetric summarizes the series of one cost metric
+type CostMetric struct {
+	Type        string       `json:"type"`
+	DisplayName string       `json:"display_name,omitempty"`
+	Series      []CostSeries `json:"series"`
+}
+
+// CostSeries summarizes one series of a cost metric per alignment period
+type CostSeries struct {
+	chart.SeriesSummary
+	Anomaly *Anomaly `json:"anomaly,omitempty"`
+}
+
+// Anomaly describes a last value far above the earlier values of its series
+type Anomaly struct {
+	Time     time.Time `json:"time"`
+	Value    float64   `json:"value"`
+	Baseline float64   `json:"baseline"` // mean of the earlier points
+	StdDev   float64   `json:"std_dev"`  // standard deviation of the earlier points
+}
+
+// BudgetAlert represents a Cloud Billing budget notification. Thresholds are
+// fractions of the budget amount (e.g., 0.9 for 90%).
+type BudgetAlert struct {
+	Time                      time.Time `json:"time"`
+	BudgetDisplayName         string    `json:"budget_display_name"`
+	CostAmount                float64   `json:"cost_amount"`
+	BudgetAmount              float64   `json:"budget_amount"`
+	CurrencyCode              string    `json:"currency_code,omitempty"`
+	AlertThresholdExceeded    float64   `json:"alert_threshold_exceeded,omitempty"`
+	ForecastThresholdExceeded float64   `json:"forecast_threshold_exceeded,omitempty"`
+	CostIntervalStart         string    `json:"cost_interval_start,omitempty"`
+}
+
+// Reader collects billing signals from the telemetry clients
+type Reader struct {
+	logging    logging.LoggingClient
+	monitoring monitoring.MonitoringClient
+}
+
+// NewReader creates a new Reader
+func NewReader(loggingClient logging.LoggingClient, monitoringClient monitoring.MonitoringClient) *Reader {
+	return &Reader{
+		logging:    loggingClient,
+		monitoring: monitoringClient,
+	}
+}
+
+// Collect gathers cost metrics and budget alerts concurrently. A section that
+// fails is reported in Report.Errors instead of failing the whole report.
+func (r *Reader) Collect(ctx context.Context, req Request) (Report, error) {
+	if !req.StartTime.Before(req.EndTime) {
+		return Report{}, fmt.Errorf("start_time must be before end_time")
+	}
+	if len(req.MetricPrefixes) == 0 {
+		req.MetricPrefixes = DefaultMetricPrefixes
+	}
+	if req.AlignmentPeriod <= 0 {
+		req.AlignmentPeriod = defaultAlignmentPeriod
+	}
+	if req.BudgetAlertFilter == "" {
+		req.BudgetAlertFilter = DefaultBudgetAlertFilter
+	}
+	if req.MaxMetrics <= 0 {
+		req.MaxMetrics = defaultMaxMetrics
+	}
+	if req.MaxBudgetAlerts <= 0 {
+		req.MaxBudgetAlerts = defaultMaxBudgetAlerts
+	}
+
+	report := Report{
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+		CostMetrics:  []CostMetric{},
+		BudgetAlerts: []BudgetAlert{},
+	}
+
+	var mu sync.Mutex
+	addError := func(key string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if report.Errors == nil {
+			report.Errors = make(map[string]string)
+		}
+		report.Errors[key] = err.Error()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		alerts, err := r.budgetAlerts(ctx, req)
+		if err != nil {
+			addError("budget_alerts", err)
+			return
+		}
+		report.BudgetAlerts = alerts
+	}()
+	go func() {
+		defer wg.Done()
+		metrics, err := r.costMetrics(ctx, req, addError)
+		if err != nil {
+			addError("cost_metrics", err)
+			return
+		}
+		report.CostMetrics = metrics
+	}()
+	wg.Wait()
+
+	for _, metric := range report.CostMetrics {
+		for _, series := range metric.Series {
+			if a := series.Anomaly; a != nil {
+				report.Anomalies = append(report.Anomalies, fmt.Sprintf("%s (%s): %g at %s, baseline %g ± %g",
+					metric.Type, series.Name, a.Value, a.Time.Format(time.RFC3339), a.Baseline, a.StdDev))
+			}
+		}
+	}
+	return report, nil
+}
+
+// costMetrics finds metric descriptors under the cost prefixes and summarizes
+// their time series. Errors for single metrics are passed to addError.
+func (r *Reader) costMetrics(ctx context.Context, req Request, addError func(string, error)) ([]CostMetric, error) {
+	var conditions []string
+	for _, prefix := range req.MetricPrefixes {
+		conditions = append(conditions, fmt.Sprintf(`metric.type = starts_with(%q)`, prefix))
+	}
+	descriptors, err := r.monitoring.ListMetricDescriptors(ctx, monitoring.ListMetricDescriptorsRequest{
+		ProjectID: req.ProjectID,
+		Filter:    strings.Join(conditions, " OR "),
+		PageSize:  req.MaxMetrics,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]CostMetric, len(descriptors.Descriptors))
+	var wg sync.WaitGroup
+	for i, descriptor := range descriptors.Descriptors {
+		metrics[i] = CostMetric{
+			Type:        descriptor.Type,
+			DisplayName: descriptor.DisplayName,
+			Series:      []CostSeries{},
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			series, err := r.costSeries(ctx, req, descriptor)
+			if err != nil {
+				addError(descriptor.Type, err)
+				return
+			}
+			metrics[i].Series = series
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Type < metrics[j].Type })
+	return metrics, nil
+}
+
+// costSeries summarizes the series of a cost metric per alignment period,
+// averaging gauges and summing deltas and cumulative totals
+func (r *Reader) costSeries(ctx context.Context, req Request, descriptor monitoring.MetricDescriptor) ([]CostSeries, error) {
+	aligner := "ALIGN_MEAN"
+	if descriptor.MetricKind == "DELTA" || descriptor.MetricKind == "CUMULATIVE" {
+		aligner = "ALIGN_DELTA"
+	}
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    fmt.Sprintf("metric.type=%q", descriptor.Type),
+		Aggregation: &monitoring.AggregationConfig{
+			AlignmentPeriod:  fmt.Sprintf("%ds", int64(req.AlignmentPeriod.Seconds())),
+			PerSeriesAligner: aligner,
+		},
+		PageSize: 100,
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	resp, err := r.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]CostSeries, 0, len(resp.TimeSeries))
+	for _, ts := range resp.TimeSeries {
+		series = append(series, CostSeries{
+			SeriesSummary: chart.Summarize(ts, 0),
+			Anomaly:       detectAnomaly(ts.Values),
+		})
+	}
+	return series, nil
+}
+
+// detectAnomaly reports the last point when it is more than anomalyStdDevs
+// standard deviations above the mean of the earlier points
+func detectAnomaly(values []monitoring.MetricValue) *Anomaly {
+	if len(values) < minBaselinePoints+1 {
+		return nil
+	}
+	points := make([]monitoring.MetricValue, len(values))
+	copy(points, values)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+
+	baseline, last := points[:len(points)-1], points[len(points)-1]
+	var mean float64
+	for _, p := range baseline {
+		mean += p.Value / float64(len(baseline))
+	}
+	var variance float64
+	for _, p := range baseline {
+		variance += (p.Value - mean) * (p.Value - mean) / float64(len(baseline))
+	}
+	stdDev := math.Sqrt(variance)
+
+	// A flat baseline makes any increase anomalous, so require a relative jump too
+	if last.Value <= mean+anomalyStdDevs*stdDev || last.Value <= mean*1.1 {
+		return nil
+	}
+	return &Anomaly{Time: last.Timestamp, Value: last.Value, Baseline: mean, StdDev: stdDev}
+}
+
+// budgetAlerts lists budget notifications written to Cloud Logging, newest first
+func (r *Reader) budgetAlerts(ctx context.Context, req Request) ([]BudgetAlert, error) {
+	filter := fmt.Sprintf("%s AND timestamp>=%q AND timestamp<=%q", req.BudgetAlertFilter,
+		req.StartTime.UTC().Format(time.RFC3339Nano), req.EndTime.UTC().Format(time.RFC3339Nano))
+	resp, err := r.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		OrderBy:   "timestamp desc",
+		Limit:     req.MaxBudgetAlerts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []BudgetAlert{}
+	for _, entry := range resp.Entries {
+		name, _ := entry.Payload["budgetDisplayName"].(string)
+		if name == "" {
+			continue
+		}
+		alert := BudgetAlert{Time: entry.Timestamp, BudgetDisplayName: name}
+		alert.CostAmount, _ = entry.Payload["costAmount"].(float64)
+		alert.BudgetAmount, _ = entry.Payload["budgetAmount"].(float64)
+		alert.CurrencyCode, _ = entry.Payload["currencyCode"].(string)
+		alert.AlertThresholdExceeded, _ = entry.Payload["alertThresholdExceeded"].(float64)
+		alert.ForecastThresholdExceeded, _ = entry.Payload["forecastThresholdExceeded"].(float64)
+		alert.CostIntervalStart, _ = entry.Payload["costIntervalStart"].(string)
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,144 @@
+package billing_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/billing"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	loggingmocks "github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	monitoringmocks "github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func daily(start time.Time, values ...float64) []monitoring.MetricValue {
+	var points []monitoring.MetricValue
+	for i := len(values) - 1; i >= 0; i-- {
+		points = append(points, monitoring.MetricValue{Value: values[i], Timestamp: start.Add(time.Duration(i) * 24 * time.Hour)})
+	}
+	return points
+}
+
+func TestReader_Collect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(7 * 24 * time.Hour)
+
+	monitoringClient := monitoringmocks.NewMockMonitoringClient(ctrl)
+	monitoringClient.EXPECT().
+		ListMetricDescriptors(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
+			if !strings.Contains(req.Filter, `metric.type = starts_with("custom.googleapis.com/billing/")`) {
+				t.Errorf("Expected default prefixes in filter, got %s", req.Filter)
+			}
+			return monitoring.ListMetricDescriptorsResponse{
+				Descriptors: []monitoring.MetricDescriptor{
+					{Type: "custom.googleapis.com/billing/daily_cost", MetricKind: "GAUGE"},
+					{Type: "custom.googleapis.com/billing/bytes_billed", MetricKind: "DELTA"},
+				},
+			}, nil
+		}).
+		Times(1)
+	monitoringClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			if req.Aggregation.AlignmentPeriod != "86400s" {
+				t.Errorf("Expected daily alignment, got %s", req.Aggregation.AlignmentPeriod)
+			}
+			switch req.Filter {
+			case `metric.type="custom.googleapis.com/billing/daily_cost"`:
+				if req.Aggregation.PerSeriesAligner != "ALIGN_MEAN" {
+					t.Errorf("Expected gauge to be averaged, got %s", req.Aggregation.PerSeriesAligner)
+				}
+				return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{
+					{
+						MetricType:   "custom.googleapis.com/billing/daily_cost",
+						MetricLabels: map[string]string{"service": "BigQuery"},
+						Values:       daily(start, 100, 104, 98, 101, 99, 350),
+					},
+					{
+						MetricType:   "custom.googleapis.com/billing/daily_cost",
+						MetricLabels: map[string]string{"service": "Cloud Run"},
+						Values:       daily(start, 10, 11, 9, 10, 12, 11),
+					},
+				}}, nil
+			default:
+				if req.Aggregation.PerSeriesAligner != "ALIGN_DELTA" {
+					t.Errorf("Expected delta to be summed, got %s", req.Aggregation.PerSeriesAligner)
+				}
+				return monitoring.ListTimeSeriesResponse{}, errors.New("permission denied")
+			}
+		}).
+		Times(2)
+
+	loggingClient := loggingmocks.NewMockLoggingClient(ctrl)
+	loggingClient.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+			if !strings.HasPrefix(req.Filter, billing.DefaultBudgetAlertFilter) || !strings.Contains(req.Filter, "timestamp>=") {
+				t.Errorf("Unexpected budget alert filter %s", req.Filter)
+			}
+			return logging.ListEntriesResponse{Entries: []logging.LogEntry{
+				{
+					Timestamp: end.Add(-time.Hour),
+					Payload: map[string]any{
+						"budgetDisplayName":      "monthly",
+						"costAmount":             950.5,
+						"budgetAmount":           1000.0,
+						"currencyCode":           "USD",
+						"alertThresholdExceeded": 0.9,
+						"costIntervalStart":      "2024-01-01T08:00:00Z",
+					},
+				},
+				{Timestamp: end, Message: "unrelated"},
+			}}, nil
+		}).
+		Times(1)
+
+	reader := billing.NewReader(loggingClient, monitoringClient)
+	report, err := reader.Collect(context.Background(), billing.Request{StartTime: start, EndTime: end})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(report.BudgetAlerts) != 1 {
+		t.Fatalf("Expected 1 budget alert, got %+v", report.BudgetAlerts)
+	}
+	alert := report.BudgetAlerts[0]
+	if alert.BudgetDisplayName != "monthly" || alert.CostAmount != 950.5 || alert.AlertThresholdExceeded != 0.9 || alert.CurrencyCode != "USD" {
+		t.Errorf("Unexpected budget alert %+v", alert)
+	}
+
+	if len(report.CostMetrics) != 2 {
+		t.Fatalf("Expected 2 cost metrics, got %+v", report.CostMetrics)
+	}
+	cost := report.CostMetrics[1]
+	if cost.Type != "custom.googleapis.com/billing/daily_cost" || len(cost.Series) != 2 {
+		t.Fatalf("Unexpected cost metric %+v", cost)
+	}
+	if cost.Series[0].Anomaly == nil || cost.Series[0].Anomaly.Value != 350 {
+		t.Errorf("Expected BigQuery spike to be an anomaly, got %+v", cost.Series[0].Anomaly)
+	}
+	if cost.Series[1].Anomaly != nil {
+		t.Errorf("Expected no anomaly for Cloud Run, got %+v", cost.Series[1].Anomaly)
+	}
+	if len(report.Anomalies) != 1 || !strings.Contains(report.Anomalies[0], "service=BigQuery") {
+		t.Errorf("Unexpected anomalies %v", report.Anomalies)
+	}
+	if report.Errors["custom.googleapis.com/billing/bytes_billed"] != "permission denied" {
+		t.Errorf("Expected metric error to be reported, got %v", report.Errors)
+	}
+}
+
+func TestReader_CollectInvalidRange(t *testing.T) {
+	now := time.Now()
+	if _, err := billing.NewReader(nil, nil).Collect(context.Background(), billing.Request{StartTime: now, EndTime: now}); err == nil {
+		t.Error("Expected error for an empty range")
+	}
+}
@@ -0,0 +1,324 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/chart"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+// Cloud Trace billing metrics
+const (
+	SpansIngestedMetric        = "cloudtrace.googleapis.com/billing/spans_ingested"
+	MonthlySpansIngestedMetric = "cloudtrace.googleapis.com/billing/monthly_spans_ingested"
+)
+
+const (
+	defaultTraceAlignmentPeriod = time.Hour
+	// freeSpansPerMonth is the number of spans each billing account can
+	// ingest for free every month
+	freeSpansPerMonth = 2_500_000
+	// pricePerMillionSpans is the list price in USD of ingesting a million
+	// chargeable spans beyond the free allotment
+	pricePerMillionSpans = 0.20
+	// monthlySpansLookback is how far before the end time the latest
+	// month-to-date point is looked for
+	monthlySpansLookback = 6 * time.Hour
+)
+
+// TraceIngestionRequest represents a request for Cloud Trace span ingestion statistics
+type TraceIngestionRequest struct {
+	ProjectID       string        `json:"project_id,omitempty"` // defaults to the clients' project
+	StartTime       time.Time     `json:"start_time"`
+	EndTime         time.Time     `json:"end_time"`
+	AlignmentPeriod time.Duration `json:"alignment_period,omitempty"` // defaults to one hour
+	// RequestMetricFilter selects a count of requests, e.g. of a load
+	// balancer, to compare the ingested spans with
+	RequestMetricFilter string `json:"request_metric_filter,omitempty"`
+}
+
+// TraceIngestionReport represents the spans ingested by Cloud Trace in a time
+// window and what they cost
+type TraceIngestionReport struct {
+	StartTime       time.Time            `json:"start_time"`
+	EndTime         time.Time            `json:"end_time"`
+	Spans           float64              `json:"spans"`            // ingested in the window
+	ChargeableSpans float64              `json:"chargeable_spans"` // of Spans
+	ByService       []ServiceSpans       `json:"by_service"`       // most spans first
+	Volume          *chart.SeriesSummary `json:"volume,omitempty"` // spans per alignment period
+	MonthToDate     *MonthlySpans        `json:"month_to_date,omitempty"`
+	Requests        *RequestSpans        `json:"requests,omitempty"`
+	Summary         []string             `json:"summary"`
+	Errors          map[string]string    `json:"errors,omitempty"` // section to the error that prevented collecting it
+}
+
+// ServiceSpans represents the spans one service sent to Cloud Trace
+type ServiceSpans struct {
+	Service         string  `json:"service"`
+	Spans           float64 `json:"spans"`
+	ChargeableSpans float64 `json:"chargeable_spans"`
+	Share           float64 `json:"share"` // fraction of all spans in the window
+}
+
+// MonthlySpans represents the spans ingested in the month up to the end time,
+// with an estimate of their cost at list price
+type MonthlySpans struct {
+	Time            time.Time `json:"time"` // of the latest month-to-date point
+	Spans           float64   `json:"spans"`
+	ChargeableSpans float64   `json:"chargeable_spans"`
+	// EstimatedCostUSD charges the chargeable spans beyond the monthly free
+	// allotment. The allotment is shared by the projects of the billing
+	// account, so the cost of one project may be higher.
+	EstimatedCostUSD float64 `json:"estimated_cost_usd"`
+}
+
+// RequestSpans compares the ingested spans with the number of requests served
+type RequestSpans struct {
+	Filter          string  `json:"filter"`
+	Requests        float64 `json:"requests"`
+	SpansPerRequest float64 `json:"spans_per_request"`
+}
+
+// TraceIngestion collects the Cloud Trace span ingestion metrics
+// concurrently. A section that fails is reported in
+// TraceIngestionReport.Errors instead of failing the whole report.
+func (r *Reader) TraceIngestion(ctx context.Context, req TraceIngestionRequest) (TraceIngestionReport, error) {
+	if !req.StartTime.Before(req.EndTime) {
+		return TraceIngestionReport{}, fmt.Errorf("start_time must be before end_time")
+	}
+	if req.AlignmentPeriod <= 0 {
+		req.AlignmentPeriod = defaultTraceAlignmentPeriod
+	}
+
+	report := TraceIngestionReport{
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		ByService: []ServiceSpans{},
+	}
+
+	var mu sync.Mutex
+	addError := func(section string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if report.Errors == nil {
+			report.Errors = make(map[string]string)
+		}
+		report.Errors[section] = err.Error()
+	}
+
+	// Each section writes only its own fields, so only Errors needs the mutex
+	var requests float64
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := r.spansIngested(ctx, req, &report); err != nil {
+			addError("spans_ingested", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		monthly, err := r.monthlySpans(ctx, req)
+		if err != nil {
+			addError("month_to_date", err)
+			return
+		}
+		report.MonthToDate = monthly
+	}()
+	if req.RequestMetricFilter != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			requests, err = r.sumMetric(ctx, req, req.RequestMetricFilter)
+			if err != nil {
+				addError("requests", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if req.RequestMetricFilter != "" && report.Errors["requests"] == "" {
+		report.Requests = &RequestSpans{Filter: req.RequestMetricFilter, Requests: requests}
+		if requests > 0 {
+			report.Requests.SpansPerRequest = report.Spans / requests
+		}
+	}
+
+	report.Summary = summarizeTraceIngestion(report)
+	return report, nil
+}
+
+// spansIngested sums the spans ingested in the window per service, and
+// summarizes their volume per alignment period
+func (r *Reader) spansIngested(ctx context.Context, req TraceIngestionRequest, report *TraceIngestionReport) error {
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    fmt.Sprintf("metric.type=%q", SpansIngestedMetric),
+		Aggregation: &monitoring.AggregationConfig{
+			AlignmentPeriod:    fmt.Sprintf("%ds", int64(req.AlignmentPeriod.Seconds())),
+			PerSeriesAligner:   "ALIGN_DELTA",
+			CrossSeriesReducer: "REDUCE_SUM",
+			GroupByFields:      []string{"metric.label.service", "metric.label.chargeable"},
+		},
+		PageSize: 100,
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	resp, err := r.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return err
+	}
+
+	services := map[string]*ServiceSpans{}
+	totals := map[time.Time]float64{}
+	for _, ts := range resp.TimeSeries {
+		service := ts.MetricLabels["service"]
+		if service == "" {
+			service = "unknown"
+		}
+		if services[service] == nil {
+			services[service] = &ServiceSpans{Service: service}
+		}
+		for _, point := range ts.Values {
+			totals[point.Timestamp] += point.Value
+			services[service].Spans += point.Value
+			report.Spans += point.Value
+			if ts.MetricLabels["chargeable"] == "true" {
+				services[service].ChargeableSpans += point.Value
+				report.ChargeableSpans += point.Value
+			}
+		}
+	}
+
+	for _, service := range services {
+		if report.Spans > 0 {
+			service.Share = service.Spans / report.Spans
+		}
+		report.ByService = append(report.ByService, *service)
+	}
+	sort.Slice(report.ByService, func(i, j int) bool {
+		if report.ByService[i].Spans != report.ByService[j].Spans {
+			return report.ByService[i].Spans > report.ByService[j].Spans
+		}
+		return report.ByService[i].Service < report.ByService[j].Service
+	})
+
+	if len(totals) > 0 {
+		volume := monitoring.TimeSeriesData{MetricType: SpansIngestedMetric}
+		for timestamp, value := range totals {
+			volume.Values = append(volume.Values, monitoring.MetricValue{Timestamp: timestamp, Value: value})
+		}
+		summary := chart.Summarize(volume, 0)
+		report.Volume = &summary
+	}
+	return nil
+}
+
+// monthlySpans reads the latest month-to-date span counts before the end time
+func (r *Reader) monthlySpans(ctx context.Context, req TraceIngestionRequest) (*MonthlySpans, error) {
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    fmt.Sprintf("metric.type=%q", MonthlySpansIngestedMetric),
+		PageSize:  100,
+	}
+	listReq.Interval.StartTime = req.EndTime.Add(-monthlySpansLookback)
+	listReq.Interval.EndTime = req.EndTime
+
+	resp, err := r.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return nil, err
+	}
+
+	var monthly *MonthlySpans
+	for _, ts := range resp.TimeSeries {
+		points := sortedValues(ts.Values)
+		if len(points) == 0 {
+			continue
+		}
+		latest := points[len(points)-1]
+		if monthly == nil {
+			monthly = &MonthlySpans{Time: latest.Timestamp}
+		}
+		if latest.Timestamp.After(monthly.Time) {
+			monthly.Time = latest.Timestamp
+		}
+		monthly.Spans += latest.Value
+		if ts.MetricLabels["chargeable"] == "true" {
+			monthly.ChargeableSpans += latest.Value
+		}
+	}
+	if monthly != nil {
+		monthly.EstimatedCostUSD = max(0, monthly.ChargeableSpans-freeSpansPerMonth) / 1e6 * pricePerMillionSpans
+	}
+	return monthly, nil
+}
+
+// sumMetric sums a count metric over the window
+func (r *Reader) sumMetric(ctx context.Context, req TraceIngestionRequest, filter string) (float64, error) {
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		Aggregation: &monitoring.AggregationConfig{
+			AlignmentPeriod:    fmt.Sprintf("%ds", int64(req.AlignmentPeriod.Seconds())),
+			PerSeriesAligner:   "ALIGN_DELTA",
+			CrossSeriesReducer: "REDUCE_SUM",
+		},
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	resp, err := r.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return 0, err
+	}
+	var sum float64
+	for _, ts := range resp.TimeSeries {
+		for _, point := range ts.Values {
+			sum += point.Value
+		}
+	}
+	return sum, nil
+}
+
+// sortedValues returns the points oldest first
+func sortedValues(values []monitoring.MetricValue) []monitoring.MetricValue {
+	sorted := append([]monitoring.MetricValue(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+	return sorted
+}
+
+// summarizeTraceIngestion describes the highlights of the report
+func summarizeTraceIngestion(report TraceIngestionReport) []string {
+	summary := []string{}
+	if report.Errors["spans_ingested"] == "" {
+		if report.Spans == 0 {
+			summary = append(summary, "no spans were ingested in the window")
+		} else {
+			line := fmt.Sprintf("%.0f spans ingested, %.0f of them chargeable", report.Spans, report.ChargeableSpans)
+			if top := report.ByService[0]; len(report.ByService) > 1 {
+				line += fmt.Sprintf("; %s sent %.0f%%", top.Service, top.Share*100)
+			}
+			summary = append(summary, line)
+		}
+	}
+	if m := report.MonthToDate; m != nil {
+		summary = append(summary, fmt.Sprintf("%.0f chargeable spans this month as of %s, about $%.2f at list price after the free %d",
+			m.ChargeableSpans, m.Time.Format(time.RFC3339), m.EstimatedCostUSD, freeSpansPerMonth))
+	}
+	if r := report.Requests; r != nil && r.Requests > 0 {
+		summary = append(summary, fmt.Sprintf("%.2f spans per request over %.0f requests; divide by the spans of a traced request to estimate the sampling rate",
+			r.SpansPerRequest, r.Requests))
+	}
+	for _, section := range slices.Sorted(maps.Keys(report.Errors)) {
+		summary = append(summary, fmt.Sprintf("%s could not be collected: %s", section, report.Errors[section]))
+	}
+	return summary
+}
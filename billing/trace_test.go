@@ -0,0 +1,128 @@
+package billing_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/billing"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	monitoringmocks "github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReader_TraceIngestion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	hourly := func(values ...float64) []monitoring.MetricValue {
+		var points []monitoring.MetricValue
+		for i, value := range values {
+			points = append(points, monitoring.MetricValue{Value: value, Timestamp: start.Add(time.Duration(i+1) * time.Hour)})
+		}
+		return points
+	}
+
+	requestFilter := `metric.type="loadbalancing.googleapis.com/https/request_count"`
+	monitoringClient := monitoringmocks.NewMockMonitoringClient(ctrl)
+	monitoringClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			switch req.Filter {
+			case `metric.type="cloudtrace.googleapis.com/billing/spans_ingested"`:
+				if req.Aggregation.AlignmentPeriod != "3600s" || req.Aggregation.PerSeriesAligner != "ALIGN_DELTA" {
+					t.Errorf("Expected hourly deltas, got %+v", req.Aggregation)
+				}
+				return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{
+					{MetricLabels: map[string]string{"service": "checkout", "chargeable": "true"}, Values: hourly(3000, 5000)},
+					{MetricLabels: map[string]string{"service": "billing", "chargeable": "true"}, Values: hourly(1000, 500)},
+					{MetricLabels: map[string]string{"service": "billing", "chargeable": "false"}, Values: hourly(500)},
+				}}, nil
+			case `metric.type="cloudtrace.googleapis.com/billing/monthly_spans_ingested"`:
+				if !req.Interval.EndTime.Equal(end) || req.Aggregation != nil {
+					t.Errorf("Expected the raw points before the end time, got %+v", req)
+				}
+				return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{
+					{MetricLabels: map[string]string{"chargeable": "true"}, Values: hourly(7_000_000, 7_500_000)},
+					{MetricLabels: map[string]string{"chargeable": "false"}, Values: hourly(100_000)},
+				}}, nil
+			case requestFilter:
+				return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{{Values: hourly(2000, 3000)}}}, nil
+			}
+			t.Errorf("Unexpected filter %s", req.Filter)
+			return monitoring.ListTimeSeriesResponse{}, nil
+		}).
+		Times(3)
+
+	reader := billing.NewReader(nil, monitoringClient)
+	report, err := reader.TraceIngestion(context.Background(), billing.TraceIngestionRequest{
+		StartTime:           start,
+		EndTime:             end,
+		RequestMetricFilter: requestFilter,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(report.Errors) > 0 {
+		t.Fatalf("Unexpected errors %v", report.Errors)
+	}
+
+	if report.Spans != 10000 || report.ChargeableSpans != 9500 {
+		t.Errorf("Expected 10000 spans, 9500 chargeable, got %g and %g", report.Spans, report.ChargeableSpans)
+	}
+	if len(report.ByService) != 2 || report.ByService[0].Service != "checkout" || report.ByService[0].Share != 0.8 || report.ByService[1].ChargeableSpans != 1500 {
+		t.Errorf("Unexpected spans by service %+v", report.ByService)
+	}
+	if report.Volume == nil || report.Volume.Points != 2 || report.Volume.Last != 5500 {
+		t.Errorf("Expected the hourly volume, got %+v", report.Volume)
+	}
+
+	monthly := report.MonthToDate
+	if monthly == nil || monthly.ChargeableSpans != 7_500_000 || monthly.Spans != 7_600_000 {
+		t.Fatalf("Expected the latest month-to-date spans, got %+v", monthly)
+	}
+	// The first 2.5 million spans are free
+	if math.Abs(monthly.EstimatedCostUSD-1.0) > 1e-9 {
+		t.Errorf("Expected an estimated cost of $1, got %g", monthly.EstimatedCostUSD)
+	}
+
+	if report.Requests == nil || report.Requests.Requests != 5000 || report.Requests.SpansPerRequest != 2 {
+		t.Errorf("Expected 2 spans per request, got %+v", report.Requests)
+	}
+	if !strings.Contains(strings.Join(report.Summary, "\n"), "checkout sent 80%") {
+		t.Errorf("Expected the summary to name the top service, got %v", report.Summary)
+	}
+}
+
+func TestReader_TraceIngestion_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitoringClient := monitoringmocks.NewMockMonitoringClient(ctrl)
+	monitoringClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		Return(monitoring.ListTimeSeriesResponse{}, errors.New("permission denied")).
+		Times(2)
+
+	reader := billing.NewReader(nil, monitoringClient)
+	end := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	if _, err := reader.TraceIngestion(context.Background(), billing.TraceIngestionRequest{StartTime: end, EndTime: end}); err == nil {
+		t.Error("Expected an error for an empty window")
+	}
+
+	report, err := reader.TraceIngestion(context.Background(), billing.TraceIngestionRequest{StartTime: end.Add(-time.Hour), EndTime: end})
+	if err != nil {
+		t.Fatalf("Expected the errors in the report, got %v", err)
+	}
+	if report.Errors["spans_ingested"] != "permission denied" || report.Errors["month_to_date"] != "permission denied" {
+		t.Errorf("Unexpected errors %v", report.Errors)
+	}
+	if len(report.Summary) != 2 || !strings.HasPrefix(report.Summary[0], "month_to_date could not be collected") {
+		t.Errorf("Unexpected summary %v", report.Summary)
+	}
+}
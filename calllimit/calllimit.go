@@ -0,0 +1,104 @@
+// Package calllimit limits the Google Cloud calls in flight at the same
+// time, so that many tools called in parallel cannot exhaust API quotas or
+// file descriptors. Calls over the limit wait for a call to finish.
+package calllimit
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultMax is the number of calls in flight allowed by default
+const DefaultMax = 32
+
+// Limiter limits the calls in flight of the clients it is applied to
+type Limiter struct {
+	sem chan struct{}
+}
+
+// New creates a Limiter allowing max calls in flight
+func New(max int) *Limiter {
+	return &Limiter{sem: make(chan struct{}, max)}
+}
+
+// Max returns the number of calls in flight allowed
+func (l *Limiter) Max() int {
+	return cap(l.sem)
+}
+
+// InFlight returns the number of calls in flight
+func (l *Limiter) InFlight() int {
+	return len(l.sem)
+}
+
+// Acquire waits until a call can be made, and counts it until Release is
+// called. It fails with the error of ctx when ctx is done first.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release ends a call counted by Acquire
+func (l *Limiter) Release() {
+	<-l.sem
+}
+
+// ClientOptions returns the options limiting the calls of gRPC clients. The
+// calls of REST clients are limited with Transport instead.
+func (l *Limiter) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(l.unaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(l.streamInterceptor)),
+	}
+}
+
+// unaryInterceptor holds a call for the duration of unary calls
+func (l *Limiter) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := l.Acquire(ctx); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	defer l.Release()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// streamInterceptor holds a call while streams are opened only, since
+// streams such as the tailing of log entries stay open for long
+func (l *Limiter) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if err := l.Acquire(ctx); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	defer l.Release()
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// Transport returns a transport holding a call for each request sent with
+// base, until its response headers are received
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{limiter: l, base: base}
+}
+
+// roundTripper limits the requests in flight of base
+type roundTripper struct {
+	limiter *Limiter
+	base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Acquire(req.Context()); err != nil {
+		return nil, err
+	}
+	defer t.limiter.Release()
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,186 @@
+// Package chart renders Cloud Monitoring time series as line chart images.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	gochart "github.com/wcharczuk/go-chart/v2"
+)
+
+// Image formats
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+const (
+	defaultWidth     = 1024
+	defaultHeight    = 512
+	defaultMaxSeries = 10
+	maxDimension     = 4096
+)
+
+// Options configures a rendered chart
+type Options struct {
+	Title     string `json:"title,omitempty"`
+	Format    string `json:"format,omitempty"`     // FormatPNG (default) or FormatSVG
+	Width     int    `json:"width,omitempty"`      // pixels, default 1024
+	Height    int    `json:"height,omitempty"`     // pixels, default 512
+	MaxSeries int    `json:"max_series,omitempty"` // series beyond this are dropped, default 10
+}
+
+// Image represents a rendered chart
+type Image struct {
+	Data          []byte
+	MIMEType      string
+	SeriesCount   int // number of series drawn
+	DroppedSeries int // number of series left out because of MaxSeries
+}
+
+// Render draws each time series as a line of a chart over time
+func Render(series []monitoring.TimeSeriesData, opts Options) (Image, error) {
+	if opts.Format == "" {
+		opts.Format = FormatPNG
+	}
+	if opts.Width <= 0 {
+		opts.Width = defaultWidth
+	}
+	if opts.Height <= 0 {
+		opts.Height = defaultHeight
+	}
+	if opts.MaxSeries <= 0 {
+		opts.MaxSeries = defaultMaxSeries
+	}
+	if opts.Width > maxDimension || opts.Height > maxDimension {
+		return Image{}, fmt.Errorf("width and height must be at most %d pixels", maxDimension)
+	}
+
+	var provider gochart.RendererProvider
+	var mimeType string
+	switch opts.Format {
+	case FormatPNG:
+		provider, mimeType = gochart.PNG, "image/png"
+	case FormatSVG:
+		provider, mimeType = gochart.SVG, "image/svg+xml"
+	default:
+		return Image{}, fmt.Errorf("unsupported format %q: must be %q or %q", opts.Format, FormatPNG, FormatSVG)
+	}
+
+	image := Image{MIMEType: mimeType}
+	var first, last time.Time
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	var lines []gochart.Series
+	for _, ts := range series {
+		if len(ts.Values) == 0 {
+			continue
+		}
+		if len(lines) == opts.MaxSeries {
+			image.DroppedSeries++
+			continue
+		}
+
+		points := make([]monitoring.MetricValue, len(ts.Values))
+		copy(points, ts.Values)
+		sort.Slice(points, func(i, j int) bool {
+			return points[i].Timestamp.Before(points[j].Timestamp)
+		})
+
+		line := gochart.TimeSeries{Name: SeriesName(ts)}
+		for _, p := range points {
+			line.XValues = append(line.XValues, p.Timestamp)
+			line.YValues = append(line.YValues, p.Value)
+			minValue, maxValue = math.Min(minValue, p.Value), math.Max(maxValue, p.Value)
+		}
+		if first.IsZero() || points[0].Timestamp.Before(first) {
+			first = points[0].Timestamp
+		}
+		if last.IsZero() || points[len(points)-1].Timestamp.After(last) {
+			last = points[len(points)-1].Timestamp
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return Image{}, fmt.Errorf("no data points to chart")
+	}
+	image.SeriesCount = len(lines)
+
+	// go-chart rejects empty ranges, which a single point or a flat line would produce
+	if !last.After(first) {
+		first, last = first.Add(-time.Minute), last.Add(time.Minute)
+	}
+	if maxValue == minValue {
+		minValue, maxValue = minValue-1, maxValue+1
+	}
+
+	layout := "15:04"
+	if last.Sub(first) > 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+
+	graph := gochart.Chart{
+		Title:  opts.Title,
+		Width:  opts.Width,
+		Height: opts.Height,
+		Background: gochart.Style{
+			Padding: gochart.Box{Top: 50, Left: 20, Right: 20, Bottom: 20},
+		},
+		XAxis: gochart.XAxis{
+			Name:           "UTC",
+			ValueFormatter: utcTimeFormatter(layout),
+			Range:          &gochart.ContinuousRange{Min: gochart.TimeToFloat64(first), Max: gochart.TimeToFloat64(last)},
+		},
+		YAxis: gochart.YAxis{
+			Range: &gochart.ContinuousRange{Min: minValue, Max: maxValue},
+		},
+		Series: lines,
+	}
+	graph.Elements = []gochart.Renderable{gochart.LegendThin(&graph)}
+
+	var buf bytes.Buffer
+	if err := graph.Render(provider, &buf); err != nil {
+		return Image{}, fmt.Errorf("failed to render chart: %w", err)
+	}
+	image.Data = buf.Bytes()
+	return image, nil
+}
+
+// SeriesName identifies a time series by its metric and resource labels,
+// falling back to the metric type when it has no labels
+func SeriesName(ts monitoring.TimeSeriesData) string {
+	var labels []string
+	for k, v := range ts.MetricLabels {
+		labels = append(labels, k+"="+v)
+	}
+	for k, v := range ts.ResourceLabels {
+		// Every series of a query shares the project, so it does not tell them apart
+		if k == "project_id" {
+			continue
+		}
+		labels = append(labels, k+"="+v)
+	}
+	if len(labels) == 0 {
+		return ts.MetricType
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, ", ")
+}
+
+// utcTimeFormatter formats axis values, which go-chart stores as nanoseconds, as UTC times
+func utcTimeFormatter(layout string) gochart.ValueFormatter {
+	return func(v any) string {
+		switch t := v.(type) {
+		case float64:
+			return time.Unix(0, int64(t)).UTC().Format(layout)
+		case time.Time:
+			return t.UTC().Format(layout)
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package chart_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/chart"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+func testSeries(n int) []monitoring.TimeSeriesData {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var series []monitoring.TimeSeriesData
+	for i := range n {
+		series = append(series, monitoring.TimeSeriesData{
+			MetricType:   "run.googleapis.com/request_count",
+			MetricLabels: map[string]string{"response_code_class": string(rune('1'+i)) + "xx"},
+			Values: []monitoring.MetricValue{
+				// Cloud Monitoring returns the newest point first
+				{Value: float64(10 * i), Timestamp: start.Add(2 * time.Minute)},
+				{Value: float64(5 * i), Timestamp: start.Add(time.Minute)},
+				{Value: 0, Timestamp: start},
+			},
+		})
+	}
+	return series
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		series   []monitoring.TimeSeriesData
+		opts     chart.Options
+		mimeType string
+		prefix   []byte
+		drawn    int
+		dropped  int
+	}{
+		{
+			name:     "png",
+			series:   testSeries(3),
+			opts:     chart.Options{Title: "Requests"},
+			mimeType: "image/png",
+			prefix:   []byte("\x89PNG"),
+			drawn:    3,
+		},
+		{
+			name:     "svg with dropped series",
+			series:   testSeries(3),
+			opts:     chart.Options{Format: chart.FormatSVG, MaxSeries: 2},
+			mimeType: "image/svg+xml",
+			prefix:   []byte("<svg"),
+			drawn:    2,
+			dropped:  1,
+		},
+		{
+			name: "single flat point",
+			series: []monitoring.TimeSeriesData{
+				{MetricType: "custom.googleapis.com/up", Values: []monitoring.MetricValue{{Value: 1, Timestamp: time.Now()}}},
+			},
+			opts:     chart.Options{Format: chart.FormatSVG},
+			mimeType: "image/svg+xml",
+			prefix:   []byte("<svg"),
+			drawn:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, err := chart.Render(tt.series, tt.opts)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if image.MIMEType != tt.mimeType {
+				t.Errorf("Expected MIME type %s, got %s", tt.mimeType, image.MIMEType)
+			}
+			if !bytes.HasPrefix(image.Data, tt.prefix) {
+				t.Errorf("Expected data starting with %q, got %q", tt.prefix, image.Data[:min(len(image.Data), 16)])
+			}
+			if image.SeriesCount != tt.drawn || image.DroppedSeries != tt.dropped {
+				t.Errorf("Expected %d drawn and %d dropped series, got %d and %d", tt.drawn, tt.dropped, image.SeriesCount, image.DroppedSeries)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []monitoring.TimeSeriesData
+		opts   chart.Options
+	}{
+		{name: "no data", series: []monitoring.TimeSeriesData{{MetricType: "custom.googleapis.com/up"}}},
+		{name: "unsupported format", series: testSeries(1), opts: chart.Options{Format: "gif"}},
+		{name: "too large", series: testSeries(1), opts: chart.Options{Width: 10000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := chart.Render(tt.series, tt.opts); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestSeriesName(t *testing.T) {
+	ts := monitoring.TimeSeriesData{
+		MetricType:     "run.googleapis.com/request_count",
+		MetricLabels:   map[string]string{"response_code_class": "5xx"},
+		ResourceLabels: map[string]string{"project_id": "my-project", "service_name": "checkout"},
+	}
+	if got, want := chart.SeriesName(ts), "response_code_class=5xx, service_name=checkout"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if got, want := chart.SeriesName(monitoring.TimeSeriesData{MetricType: "custom.googleapis.com/up"}), "custom.googleapis.com/up"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,109 @@
+package chart
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+// sparkBlocks are the unicode block elements used for sparkline levels, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+const defaultSparklineWidth = 60
+
+// SeriesSummary is a compact text rendering of a time series
+type SeriesSummary struct {
+	Name           string            `json:"name"`
+	MetricType     string            `json:"metric_type"`
+	MetricLabels   map[string]string `json:"metric_labels,omitempty"`
+	ResourceType   string            `json:"resource_type"`
+	ResourceLabels map[string]string `json:"resource_labels,omitempty"`
+	Points         int               `json:"points"`
+	Start          time.Time         `json:"start,omitzero"`
+	End            time.Time         `json:"end,omitzero"`
+	Min            float64           `json:"min"`
+	Max            float64           `json:"max"`
+	Avg            float64           `json:"avg"`
+	Last           float64           `json:"last"`
+	Sparkline      string            `json:"sparkline"`
+}
+
+// Summarize reduces a time series to its min/max/avg/last values and a unicode
+// sparkline of at most width characters, oldest point first. A width of zero
+// or less uses the default of 60.
+func Summarize(ts monitoring.TimeSeriesData, width int) SeriesSummary {
+	summary := SeriesSummary{
+		Name:           SeriesName(ts),
+		MetricType:     ts.MetricType,
+		MetricLabels:   ts.MetricLabels,
+		ResourceType:   ts.ResourceType,
+		ResourceLabels: ts.ResourceLabels,
+		Points:         len(ts.Values),
+	}
+	if len(ts.Values) == 0 {
+		return summary
+	}
+	if width <= 0 {
+		width = defaultSparklineWidth
+	}
+
+	points := make([]monitoring.MetricValue, len(ts.Values))
+	copy(points, ts.Values)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+
+	values := make([]float64, len(points))
+	summary.Min, summary.Max = math.Inf(1), math.Inf(-1)
+	var sum float64
+	for i, p := range points {
+		values[i] = p.Value
+		summary.Min = math.Min(summary.Min, p.Value)
+		summary.Max = math.Max(summary.Max, p.Value)
+		sum += p.Value
+	}
+	summary.Start = points[0].Timestamp
+	summary.End = points[len(points)-1].Timestamp
+	summary.Avg = sum / float64(len(values))
+	summary.Last = values[len(values)-1]
+	summary.Sparkline = Sparkline(values, width)
+	return summary
+}
+
+// Sparkline renders values as unicode block characters scaled between their
+// minimum and maximum. When there are more values than width, consecutive
+// values are averaged into buckets.
+func Sparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i := range buckets {
+			from, to := i*len(values)/width, (i+1)*len(values)/width
+			var sum float64
+			for _, v := range values[from:to] {
+				sum += v
+			}
+			buckets[i] = sum / float64(to-from)
+		}
+		values = buckets
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+
+	line := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		line[i] = sparkBlocks[level]
+	}
+	return string(line)
+}
@@ -0,0 +1,61 @@
+package chart_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/chart"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		width  int
+		want   string
+	}{
+		{name: "ascending", values: []float64{0, 1, 2, 3, 4, 5, 6, 7}, width: 10, want: "▁▂▃▄▅▆▇█"},
+		{name: "flat", values: []float64{3, 3, 3}, width: 10, want: "▁▁▁"},
+		{name: "bucketed", values: []float64{0, 0, 7, 7}, width: 2, want: "▁█"},
+		{name: "empty", values: nil, width: 10, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chart.Sparkline(tt.values, tt.width); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ts := monitoring.TimeSeriesData{
+		MetricType:   "run.googleapis.com/request_count",
+		MetricLabels: map[string]string{"response_code_class": "5xx"},
+		ResourceType: "cloud_run_revision",
+		// Cloud Monitoring returns the newest point first
+		Values: []monitoring.MetricValue{
+			{Value: 4, Timestamp: start.Add(3 * time.Minute)},
+			{Value: 8, Timestamp: start.Add(2 * time.Minute)},
+			{Value: 2, Timestamp: start.Add(time.Minute)},
+			{Value: 2, Timestamp: start},
+		},
+	}
+
+	got := chart.Summarize(ts, 0)
+	if got.Name != "response_code_class=5xx" || got.Points != 4 {
+		t.Errorf("Unexpected name or point count: %+v", got)
+	}
+	if got.Min != 2 || got.Max != 8 || got.Avg != 4 || got.Last != 4 {
+		t.Errorf("Expected min 2, max 8, avg 4, last 4, got %+v", got)
+	}
+	if !got.Start.Equal(start) || !got.End.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("Unexpected range %v - %v", got.Start, got.End)
+	}
+	if got.Sparkline != "▁▁█▃" {
+		t.Errorf("Expected sparkline ▁▁█▃, got %s", got.Sparkline)
+	}
+}
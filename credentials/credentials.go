@@ -0,0 +1,113 @@
+// Package credentials builds the client options that authenticate the server
+// to Google Cloud, so that it can run outside Google Cloud, e.g. on CI
+// runners or laptops with AWS credentials, without service account keys.
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"google.golang.org/api/option"
+)
+
+const (
+	// stsTokenURL is the Security Token Service endpoint exchanging external tokens
+	stsTokenURL = "https://sts.googleapis.com/v1/token"
+	// jwtTokenType is the type of OIDC tokens exchanged through workload identity federation
+	jwtTokenType = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// credentialTypes are the types of credentials JSON files accepted
+var credentialTypes = []string{
+	"external_account", "external_account_authorized_user", "impersonated_service_account",
+	"service_account", "authorized_user",
+}
+
+// Config represents how the server authenticates. When it is empty,
+// Application Default Credentials are used.
+type Config struct {
+	// CredentialsFile is a credentials JSON file, e.g. an external account
+	// configuration created with gcloud iam workload-identity-pools create-cred-config
+	CredentialsFile string
+	// OIDCTokenFile is a file containing an OIDC token, e.g. one issued by a
+	// CI runner, exchanged for Google credentials through Audience
+	OIDCTokenFile string
+	// Audience is the workload identity provider, e.g.
+	// //iam.googleapis.com/projects/NUMBER/locations/global/workloadIdentityPools/POOL/providers/PROVIDER
+	Audience string
+	// ServiceAccount is the email of the service account impersonated with
+	// the federated token. The federated identity is used directly when empty.
+	ServiceAccount string
+}
+
+// Validate checks that the configuration is complete
+func (c Config) Validate() error {
+	if c.CredentialsFile != "" && c.OIDCTokenFile != "" {
+		return fmt.Errorf("credentials file and OIDC token file are mutually exclusive")
+	}
+	if c.OIDCTokenFile != "" && c.Audience == "" {
+		return fmt.Errorf("OIDC token file requires a workload identity provider audience")
+	}
+	if c.OIDCTokenFile == "" && (c.Audience != "" || c.ServiceAccount != "") {
+		return fmt.Errorf("audience and service account require an OIDC token file")
+	}
+	return nil
+}
+
+// ClientOptions returns the options authenticating Google Cloud clients, or
+// nil to use Application Default Credentials
+func (c Config) ClientOptions() ([]option.ClientOption, error) {
+	data, err := c.credentialsJSON()
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return []option.ClientOption{option.WithCredentialsJSON(data)}, nil
+}
+
+// credentialsJSON returns the credentials JSON of the configuration, or nil
+// for Application Default Credentials
+func (c Config) credentialsJSON() ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c.CredentialsFile != "":
+		data, err := os.ReadFile(c.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials file: %w", err)
+		}
+		var file struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid credentials file %s: %w", c.CredentialsFile, err)
+		}
+		if !slices.Contains(credentialTypes, file.Type) {
+			return nil, fmt.Errorf("unsupported credentials type %q in %s", file.Type, c.CredentialsFile)
+		}
+		return data, nil
+
+	case c.OIDCTokenFile != "":
+		if _, err := os.Stat(c.OIDCTokenFile); err != nil {
+			return nil, fmt.Errorf("failed to read OIDC token file: %w", err)
+		}
+		// The token file is read on every exchange, so that tokens rotated by
+		// the runner are picked up
+		config := map[string]any{
+			"type":               "external_account",
+			"audience":           c.Audience,
+			"subject_token_type": jwtTokenType,
+			"token_url":          stsTokenURL,
+			"credential_source":  map[string]any{"file": c.OIDCTokenFile},
+		}
+		if c.ServiceAccount != "" {
+			config["service_account_impersonation_url"] = fmt.Sprintf(
+				"https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken", c.ServiceAccount)
+		}
+		return json.Marshal(config)
+	}
+	return nil, nil
+}
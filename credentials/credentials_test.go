@@ -0,0 +1,148 @@
+package credentials
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:   "application default credentials",
+			config: Config{},
+		},
+		{
+			name:   "credentials file",
+			config: Config{CredentialsFile: "/etc/gcp/credentials.json"},
+		},
+		{
+			name:   "OIDC token",
+			config: Config{OIDCTokenFile: "/var/run/token", Audience: "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/ci/providers/github"},
+		},
+		{
+			name:    "both files",
+			config:  Config{CredentialsFile: "/etc/gcp/credentials.json", OIDCTokenFile: "/var/run/token", Audience: "aud"},
+			wantErr: true,
+		},
+		{
+			name:    "OIDC token without audience",
+			config:  Config{OIDCTokenFile: "/var/run/token"},
+			wantErr: true,
+		},
+		{
+			name:    "service account without OIDC token",
+			config:  Config{ServiceAccount: "ci@test-project.iam.gserviceaccount.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_CredentialsJSON(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("eyJ..."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	externalAccount := filepath.Join(dir, "external.json")
+	if err := os.WriteFile(externalAccount, []byte(`{"type": "external_account", "audience": "aud"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	invalidType := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalidType, []byte(`{"type": "api_key"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	audience := "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/ci/providers/github"
+	tests := []struct {
+		name    string
+		config  Config
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:   "application default credentials",
+			config: Config{},
+		},
+		{
+			name:   "external account file",
+			config: Config{CredentialsFile: externalAccount},
+			want:   map[string]any{"type": "external_account", "audience": "aud"},
+		},
+		{
+			name:    "unsupported credentials type",
+			config:  Config{CredentialsFile: invalidType},
+			wantErr: true,
+		},
+		{
+			name:    "missing credentials file",
+			config:  Config{CredentialsFile: filepath.Join(dir, "missing.json")},
+			wantErr: true,
+		},
+		{
+			name:   "OIDC token",
+			config: Config{OIDCTokenFile: tokenFile, Audience: audience},
+			want: map[string]any{
+				"type":               "external_account",
+				"audience":           audience,
+				"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+				"token_url":          "https://sts.googleapis.com/v1/token",
+				"credential_source":  map[string]any{"file": tokenFile},
+			},
+		},
+		{
+			name:   "OIDC token with impersonation",
+			config: Config{OIDCTokenFile: tokenFile, Audience: audience, ServiceAccount: "ci@test-project.iam.gserviceaccount.com"},
+			want: map[string]any{
+				"type":                              "external_account",
+				"audience":                          audience,
+				"subject_token_type":                "urn:ietf:params:oauth:token-type:jwt",
+				"token_url":                         "https://sts.googleapis.com/v1/token",
+				"credential_source":                 map[string]any{"file": tokenFile},
+				"service_account_impersonation_url": "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/ci@test-project.iam.gserviceaccount.com:generateAccessToken",
+			},
+		},
+		{
+			name:    "missing OIDC token file",
+			config:  Config{OIDCTokenFile: filepath.Join(dir, "missing"), Audience: audience},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.config.credentialsJSON()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("credentialsJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				if data != nil {
+					t.Errorf("Expected no credentials, got %s", data)
+				}
+				return
+			}
+			var got map[string]any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Expected valid JSON, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package credentials
+
+import (
+	"os"
+
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// emulatorHostEnvs are the environment variables pointing the clients of a
+// service at an emulator such as internal/testserver, e.g. localhost:8085,
+// in the style of PUBSUB_EMULATOR_HOST
+var emulatorHostEnvs = map[string]string{
+	ServiceLogging:    "LOGGING_EMULATOR_HOST",
+	ServiceMonitoring: "MONITORING_EMULATOR_HOST",
+	ServiceTrace:      "TRACE_EMULATOR_HOST",
+}
+
+// EmulatorOptions returns the client options connecting the clients of a
+// service to the emulator set in its environment variable, without TLS or
+// authentication, and false when none is set. They replace the options
+// passed to the clients, so that credentials are never sent to an emulator.
+func EmulatorOptions(service string) ([]option.ClientOption, bool) {
+	env, ok := emulatorHostEnvs[service]
+	if !ok {
+		return nil, false
+	}
+	host := os.Getenv(env)
+	if host == "" {
+		return nil, false
+	}
+	return []option.ClientOption{
+		option.WithEndpoint(host),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		option.WithTelemetryDisabled(),
+	}, true
+}
+
+// EmulatorHost returns the emulator host set in the environment variable of a service, or ""
+func EmulatorHost(service string) string {
+	return os.Getenv(emulatorHostEnvs[service])
+}
@@ -0,0 +1,34 @@
+package credentials
+
+import "testing"
+
+func TestEmulatorOptions(t *testing.T) {
+	t.Setenv("LOGGING_EMULATOR_HOST", "localhost:8085")
+	t.Setenv("TRACE_EMULATOR_HOST", "")
+
+	tests := []struct {
+		name    string
+		service string
+		wantOK  bool
+	}{
+		{name: "emulator set", service: ServiceLogging, wantOK: true},
+		{name: "emulator not set", service: ServiceTrace, wantOK: false},
+		{name: "service without emulator", service: ServiceProfiler, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, ok := EmulatorOptions(tt.service)
+			if ok != tt.wantOK {
+				t.Fatalf("EmulatorOptions() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok != (len(opts) > 0) {
+				t.Errorf("EmulatorOptions() returned %d options with ok = %v", len(opts), ok)
+			}
+		})
+	}
+
+	if got := EmulatorHost(ServiceLogging); got != "localhost:8085" {
+		t.Errorf("EmulatorHost() = %q, want %q", got, "localhost:8085")
+	}
+}
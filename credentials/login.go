@@ -0,0 +1,171 @@
+package credentials
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// cloudPlatformScope is the scope requested for user credentials
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// authorizedUser is the credentials JSON of a user, as written by
+// gcloud auth application-default login
+type authorizedUser struct {
+	Type         string `json:"type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// UserCredentialsPath returns the path where Login caches user credentials
+func UserCredentialsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gcp-telemetry-mcp", "credentials.json"), nil
+}
+
+// LoadOAuthClient loads an OAuth client of the "Desktop app" type from the
+// client secrets JSON file downloaded from the Google Cloud console
+func LoadOAuthClient(clientSecretsFile string) (*oauth2.Config, error) {
+	data, err := os.ReadFile(clientSecretsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OAuth client secrets: %w", err)
+	}
+	config, err := google.ConfigFromJSON(data, cloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OAuth client secrets: %w", err)
+	}
+	return config, nil
+}
+
+// Login runs an OAuth flow in the local browser: openBrowser is called with
+// the consent page URL, and the authorization code is received on a loopback
+// address. The resulting user credentials are cached at path, so that the
+// server can run with the user's own identity.
+func Login(ctx context.Context, config *oauth2.Config, path string, openBrowser func(string) error) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen for the OAuth redirect: %w", err)
+	}
+	defer listener.Close()
+
+	loopback := *config
+	loopback.RedirectURL = fmt.Sprintf("http://%s/", listener.Addr())
+	state, err := randomState()
+	if err != nil {
+		return err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		}
+		var res result
+		if e := query.Get("error"); e != "" {
+			res.err = fmt.Errorf("authorization failed: %s", e)
+			fmt.Fprintln(w, "Authorization failed. You can close this window.")
+		} else {
+			res.code = query.Get("code")
+			fmt.Fprintln(w, "Authorized gcp-telemetry-mcp. You can close this window.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	authURL := loopback.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce, oauth2.S256ChallengeOption(verifier))
+	if err := openBrowser(authURL); err != nil {
+		return fmt.Errorf("failed to open the browser: %w", err)
+	}
+
+	var res result
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if res.err != nil {
+		return res.err
+	}
+
+	token, err := loopback.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return fmt.Errorf("failed to exchange the authorization code: %w", err)
+	}
+	if token.RefreshToken == "" {
+		return errors.New("no refresh token was returned")
+	}
+
+	data, err := json.MarshalIndent(authorizedUser{
+		Type:         "authorized_user",
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RefreshToken: token.RefreshToken,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	return nil
+}
+
+// OpenBrowser opens url in the default browser, and prints it for when no
+// browser can be opened, e.g. over SSH
+func OpenBrowser(url string) error {
+	fmt.Fprintf(os.Stderr, "Open the following URL in your browser if it does not open automatically:\n\n%s\n\n", url)
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	// The URL has been printed, so failing to start a browser is not an error
+	if err := cmd.Start(); err == nil {
+		go cmd.Wait()
+	}
+	return nil
+}
+
+// randomState returns an unguessable OAuth state
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate OAuth state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
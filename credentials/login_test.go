@@ -0,0 +1,114 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTokenServer returns a token endpoint exchanging the code "test-code"
+func newTokenServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse token request: %v", err)
+		}
+		if r.Form.Get("code") != "test-code" || r.Form.Get("code_verifier") == "" {
+			http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "access", "refresh_token": "refresh", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// redirectBrowser returns a browser that grants consent by following the
+// redirect URL with a code, or with an error when denied
+func redirectBrowser(t *testing.T, denied bool) func(string) error {
+	return func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		query := u.Query()
+		if query.Get("code_challenge") == "" || query.Get("access_type") != "offline" {
+			t.Errorf("Expected PKCE and offline access in %s", authURL)
+		}
+		redirect := query.Get("redirect_uri") + "?state=" + url.QueryEscape(query.Get("state"))
+		if denied {
+			redirect += "&error=access_denied"
+		} else {
+			redirect += "&code=test-code"
+		}
+		go func() {
+			resp, err := http.Get(redirect)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tokenServer := newTokenServer(t)
+	config := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     oauth2.Endpoint{AuthURL: "https://accounts.example.com/auth", TokenURL: tokenServer.URL},
+		Scopes:       []string{cloudPlatformScope},
+	}
+	path := filepath.Join(t.TempDir(), "gcp-telemetry-mcp", "credentials.json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := Login(ctx, config, path, redirectBrowser(t, false)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected cached credentials, got %v", err)
+	}
+	var got authorizedUser
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := authorizedUser{Type: "authorized_user", ClientID: "client-id", ClientSecret: "client-secret", RefreshToken: "refresh"}
+	if got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	// The cached credentials are accepted as a credentials file
+	if _, err := (Config{CredentialsFile: path}).credentialsJSON(); err != nil {
+		t.Errorf("Expected cached credentials to be accepted, got %v", err)
+	}
+}
+
+func TestLoginDenied(t *testing.T) {
+	tokenServer := newTokenServer(t)
+	config := &oauth2.Config{
+		ClientID: "client-id",
+		Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.example.com/auth", TokenURL: tokenServer.URL},
+	}
+	path := filepath.Join(t.TempDir(), "credentials.json")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := Login(ctx, config, path, redirectBrowser(t, true)); err == nil {
+		t.Fatal("Expected an error when consent is denied")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected no cached credentials, got %v", err)
+	}
+}
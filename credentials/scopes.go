@@ -0,0 +1,60 @@
+package credentials
+
+import "slices"
+
+// Services whose clients are created with the narrowest scopes they need
+const (
+	ServiceLogging    = "logging"
+	ServiceMonitoring = "monitoring"
+	ServiceTrace      = "trace"
+	ServiceProfiler   = "profiler"
+	ServiceStorage    = "storage"
+	ServiceDLP        = "dlp"
+	ServiceAsset      = "asset"
+)
+
+// readScopes are the scopes of the read-only tools of each service
+var readScopes = map[string][]string{
+	ServiceLogging:    {"https://www.googleapis.com/auth/logging.read"},
+	ServiceMonitoring: {"https://www.googleapis.com/auth/monitoring.read"},
+	ServiceTrace:      {"https://www.googleapis.com/auth/trace.readonly"},
+	// Cloud Profiler has no read-only scope, and accepts monitoring.write for listing
+	ServiceProfiler: {"https://www.googleapis.com/auth/monitoring.write"},
+	ServiceStorage:  {"https://www.googleapis.com/auth/devstorage.read_only"},
+	// Cloud DLP and Cloud Asset have no scopes of their own
+	ServiceDLP:   {"https://www.googleapis.com/auth/cloud-platform.read-only"},
+	ServiceAsset: {"https://www.googleapis.com/auth/cloud-platform.read-only"},
+}
+
+// writeScopes are the scopes added for the tools that write
+var writeScopes = map[string][]string{
+	ServiceLogging: {"https://www.googleapis.com/auth/logging.write"},
+	// Alert policies, dashboards, and metric descriptors need the full
+	// monitoring scope, which includes reads
+	ServiceMonitoring: {"https://www.googleapis.com/auth/monitoring"},
+	ServiceTrace:      {"https://www.googleapis.com/auth/trace.append"},
+	ServiceStorage:    {"https://www.googleapis.com/auth/devstorage.read_write"},
+	ServiceDLP:        {"https://www.googleapis.com/auth/cloud-platform"},
+	ServiceAsset:      {"https://www.googleapis.com/auth/cloud-platform"},
+}
+
+// Scopes returns the narrowest OAuth scopes the tools of a service need,
+// instead of cloud-platform, with only read access when readOnly. Nil is
+// returned for unknown services.
+func Scopes(service string, readOnly bool) []string {
+	scopes, ok := readScopes[service]
+	if !ok {
+		return nil
+	}
+	if readOnly {
+		return slices.Clone(scopes)
+	}
+	write := writeScopes[service]
+	switch service {
+	case ServiceMonitoring, ServiceStorage, ServiceDLP, ServiceAsset:
+		// The write scope includes reads
+		return slices.Clone(write)
+	default:
+		return append(slices.Clone(scopes), write...)
+	}
+}
@@ -0,0 +1,89 @@
+package credentials
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  string
+		readOnly bool
+		want     []string
+	}{
+		{
+			name:     "logging read-only",
+			service:  ServiceLogging,
+			readOnly: true,
+			want:     []string{"https://www.googleapis.com/auth/logging.read"},
+		},
+		{
+			name:    "logging",
+			service: ServiceLogging,
+			want:    []string{"https://www.googleapis.com/auth/logging.read", "https://www.googleapis.com/auth/logging.write"},
+		},
+		{
+			name:     "monitoring read-only",
+			service:  ServiceMonitoring,
+			readOnly: true,
+			want:     []string{"https://www.googleapis.com/auth/monitoring.read"},
+		},
+		{
+			name:    "monitoring",
+			service: ServiceMonitoring,
+			want:    []string{"https://www.googleapis.com/auth/monitoring"},
+		},
+		{
+			name:    "trace",
+			service: ServiceTrace,
+			want:    []string{"https://www.googleapis.com/auth/trace.readonly", "https://www.googleapis.com/auth/trace.append"},
+		},
+		{
+			name:     "profiler read-only",
+			service:  ServiceProfiler,
+			readOnly: true,
+			want:     []string{"https://www.googleapis.com/auth/monitoring.write"},
+		},
+		{
+			name:    "profiler",
+			service: ServiceProfiler,
+			want:    []string{"https://www.googleapis.com/auth/monitoring.write"},
+		},
+		{
+			name:     "storage read-only",
+			service:  ServiceStorage,
+			readOnly: true,
+			want:     []string{"https://www.googleapis.com/auth/devstorage.read_only"},
+		},
+		{
+			name:    "storage",
+			service: ServiceStorage,
+			want:    []string{"https://www.googleapis.com/auth/devstorage.read_write"},
+		},
+		{
+			name:     "dlp read-only",
+			service:  ServiceDLP,
+			readOnly: true,
+			want:     []string{"https://www.googleapis.com/auth/cloud-platform.read-only"},
+		},
+		{
+			name:    "asset",
+			service: ServiceAsset,
+			want:    []string{"https://www.googleapis.com/auth/cloud-platform"},
+		},
+		{
+			name:    "unknown service",
+			service: "bigquery",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Scopes(tt.service, tt.readOnly)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
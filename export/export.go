@@ -0,0 +1,388 @@
+// Package export renders alert policies, dashboards, and custom metric
+// descriptors as Terraform HCL or YAML, so that monitoring configuration
+// explored through the tools can be committed as infrastructure as code.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+// Output formats
+const (
+	// FormatTerraform renders google provider resources
+	FormatTerraform = "terraform"
+	// FormatYAML renders the API representation of each resource, one
+	// document per resource, as accepted by gcloud's --policy-from-file and
+	// --config-from-file flags
+	FormatYAML = "yaml"
+)
+
+// Resource kinds
+const (
+	KindAlertPolicy      = "alert_policy"
+	KindDashboard        = "dashboard"
+	KindMetricDescriptor = "metric_descriptor"
+)
+
+// AllKinds are the resource kinds exported by default
+var AllKinds = []string{KindAlertPolicy, KindDashboard, KindMetricDescriptor}
+
+// DefaultMetricTypePrefix selects the metric descriptors exported by default
+const DefaultMetricTypePrefix = "custom.googleapis.com/"
+
+// maxMetricDescriptors bounds the number of metric descriptors exported
+const maxMetricDescriptors = 1000
+
+// terraformTypes maps resource kinds to Terraform resource types
+var terraformTypes = map[string]string{
+	KindAlertPolicy:      "google_monitoring_alert_policy",
+	KindDashboard:        "google_monitoring_dashboard",
+	KindMetricDescriptor: "google_monitoring_metric_descriptor",
+}
+
+// Field is an attribute or nested block of a resource. Value is one of
+// string, bool, int, float64, []string, map[string]string, []Field for a
+// nested block, or [][]Field for repeated nested blocks.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Resource is a monitoring resource ready to be rendered
+type Resource struct {
+	Kind   string
+	Name   string // resource name in the API, e.g. projects/my-project/alertPolicies/123
+	Label  string // Terraform resource name, e.g. high_cpu
+	Fields []Field
+	// Document, when set, is the YAML form of the resource instead of Fields
+	Document json.RawMessage
+}
+
+// Request represents a request to export monitoring configuration
+type Request struct {
+	ProjectID         string   `json:"project_id,omitempty"` // defaults to the client's project
+	Kinds             []string `json:"kinds,omitempty"`      // defaults to AllKinds
+	Format            string   `json:"format,omitempty"`     // defaults to FormatTerraform
+	AlertPolicyFilter string   `json:"alert_policy_filter,omitempty"`
+	MetricTypePrefix  string   `json:"metric_type_prefix,omitempty"` // defaults to DefaultMetricTypePrefix
+}
+
+// Result represents exported monitoring configuration
+type Result struct {
+	Format   string         `json:"format"`
+	Counts   map[string]int `json:"counts"` // number of exported resources per kind
+	Warnings []string       `json:"warnings,omitempty"`
+	Content  string         `json:"content"`
+}
+
+// Exporter exports monitoring configuration
+type Exporter struct {
+	monitoring monitoring.MonitoringClient
+}
+
+// NewExporter creates a new Exporter
+func NewExporter(monitoringClient monitoring.MonitoringClient) *Exporter {
+	return &Exporter{monitoring: monitoringClient}
+}
+
+// Export fetches the requested resources and renders them in the requested format
+func (e *Exporter) Export(ctx context.Context, req Request) (Result, error) {
+	if req.Format == "" {
+		req.Format = FormatTerraform
+	}
+	if req.Format != FormatTerraform && req.Format != FormatYAML {
+		return Result{}, fmt.Errorf("unsupported format %q: use %q or %q", req.Format, FormatTerraform, FormatYAML)
+	}
+	kinds := req.Kinds
+	if len(kinds) == 0 {
+		kinds = AllKinds
+	}
+	for _, kind := range kinds {
+		if !slices.Contains(AllKinds, kind) {
+			return Result{}, fmt.Errorf("unsupported resource kind %q: use one of %s", kind, strings.Join(AllKinds, ", "))
+		}
+	}
+	prefix := req.MetricTypePrefix
+	if prefix == "" {
+		prefix = DefaultMetricTypePrefix
+	}
+
+	result := Result{Format: req.Format, Counts: make(map[string]int)}
+	var resources []Resource
+	if slices.Contains(kinds, KindAlertPolicy) {
+		policies, err := e.monitoring.ListAlertPolicies(ctx, monitoring.ListAlertPoliciesRequest{
+			ProjectID: req.ProjectID,
+			Filter:    req.AlertPolicyFilter,
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		for _, p := range policies {
+			resource, warnings := AlertPolicy(p)
+			resources = append(resources, resource)
+			result.Warnings = append(result.Warnings, warnings...)
+		}
+		result.Counts[KindAlertPolicy] = len(policies)
+	}
+	if slices.Contains(kinds, KindDashboard) {
+		dashboards, err := e.monitoring.ListDashboards(ctx, monitoring.ListDashboardsRequest{ProjectID: req.ProjectID})
+		if err != nil {
+			return Result{}, err
+		}
+		for _, d := range dashboards {
+			resource, err := Dashboard(d)
+			if err != nil {
+				return Result{}, err
+			}
+			resources = append(resources, resource)
+		}
+		result.Counts[KindDashboard] = len(dashboards)
+	}
+	if slices.Contains(kinds, KindMetricDescriptor) {
+		metrics, err := e.monitoring.ListAvailableMetrics(ctx, monitoring.ListAvailableMetricsRequest{
+			ProjectID: req.ProjectID,
+			Filter:    fmt.Sprintf(`metric.type = starts_with(%q)`, prefix),
+			PageSize:  maxMetricDescriptors,
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		for _, m := range metrics {
+			resources = append(resources, MetricDescriptor(m))
+		}
+		result.Counts[KindMetricDescriptor] = len(metrics)
+	}
+
+	content, err := Render(resources, req.Format)
+	if err != nil {
+		return Result{}, err
+	}
+	result.Content = content
+	return result, nil
+}
+
+// AlertPolicy converts an alerting policy, with a warning for each condition
+// that cannot be exported
+func AlertPolicy(p monitoring.AlertPolicy) (Resource, []string) {
+	combiner := p.Combiner
+	if combiner == "" {
+		combiner = "OR"
+	}
+	fields := []Field{
+		{"display_name", p.DisplayName},
+		{"combiner", combiner},
+		{"enabled", p.Enabled},
+	}
+	if p.Severity != "" {
+		fields = append(fields, Field{"severity", p.Severity})
+	}
+	if len(p.NotificationChannels) > 0 {
+		fields = append(fields, Field{"notification_channels", p.NotificationChannels})
+	}
+	if len(p.UserLabels) > 0 {
+		fields = append(fields, Field{"user_labels", p.UserLabels})
+	}
+
+	var warnings []string
+	var conditions [][]Field
+	for _, c := range p.Conditions {
+		var key string
+		var body []Field
+		switch c.Type {
+		case monitoring.ConditionTypeThreshold:
+			key = "condition_threshold"
+			body = []Field{{"filter", c.Filter}}
+			if c.DenominatorFilter != "" {
+				body = append(body, Field{"denominator_filter", c.DenominatorFilter})
+			}
+			body = append(body, Field{"duration", c.Duration})
+			if c.Comparison != "" {
+				body = append(body, Field{"comparison", c.Comparison})
+			}
+			body = append(body, Field{"threshold_value", c.ThresholdValue})
+			body = append(body, aggregationBlocks(c.Aggregations)...)
+			body = append(body, triggerBlock(c)...)
+		case monitoring.ConditionTypeAbsence:
+			key = "condition_absent"
+			body = []Field{{"filter", c.Filter}, {"duration", c.Duration}}
+			body = append(body, aggregationBlocks(c.Aggregations)...)
+			body = append(body, triggerBlock(c)...)
+		case monitoring.ConditionTypeLogMatch:
+			key = "condition_matched_log"
+			body = []Field{{"filter", c.Filter}}
+		case monitoring.ConditionTypeMQL:
+			key = "condition_monitoring_query_language"
+			body = []Field{{"query", c.Filter}, {"duration", c.Duration}}
+			body = append(body, triggerBlock(c)...)
+		case monitoring.ConditionTypePromQL:
+			key = "condition_prometheus_query_language"
+			body = []Field{{"query", c.Filter}, {"duration", c.Duration}}
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: condition %q of type %s was not exported", p.Name, c.DisplayName, c.Type))
+			continue
+		}
+		conditions = append(conditions, []Field{
+			{"display_name", c.DisplayName},
+			{key, body},
+		})
+	}
+	if len(conditions) > 0 {
+		fields = append(fields, Field{"conditions", conditions})
+	}
+	if p.Documentation != "" {
+		fields = append(fields, Field{"documentation", []Field{
+			{"content", p.Documentation},
+			{"mime_type", "text/markdown"},
+		}})
+	}
+
+	return Resource{
+		Kind:   KindAlertPolicy,
+		Name:   p.Name,
+		Label:  labelFor(p.DisplayName, p.Name),
+		Fields: fields,
+	}, warnings
+}
+
+// aggregationBlocks returns repeated aggregations blocks
+func aggregationBlocks(aggs []monitoring.AggregationConfig) []Field {
+	if len(aggs) == 0 {
+		return nil
+	}
+	var blocks [][]Field
+	for _, a := range aggs {
+		var block []Field
+		if a.AlignmentPeriod != "" {
+			block = append(block, Field{"alignment_period", a.AlignmentPeriod})
+		}
+		if a.PerSeriesAligner != "" {
+			block = append(block, Field{"per_series_aligner", a.PerSeriesAligner})
+		}
+		if a.CrossSeriesReducer != "" {
+			block = append(block, Field{"cross_series_reducer", a.CrossSeriesReducer})
+		}
+		if len(a.GroupByFields) > 0 {
+			block = append(block, Field{"group_by_fields", a.GroupByFields})
+		}
+		blocks = append(blocks, block)
+	}
+	return []Field{{"aggregations", blocks}}
+}
+
+// triggerBlock returns the trigger block of a condition, if it has one
+func triggerBlock(c monitoring.AlertCondition) []Field {
+	switch {
+	case c.TriggerCount > 0:
+		return []Field{{"trigger", []Field{{"count", c.TriggerCount}}}}
+	case c.TriggerPercent > 0:
+		return []Field{{"trigger", []Field{{"percent", c.TriggerPercent}}}}
+	}
+	return nil
+}
+
+// Dashboard converts a dashboard. The output-only name and etag are dropped
+// from its definition so that it can be created in any project.
+func Dashboard(d monitoring.Dashboard) (Resource, error) {
+	var definition map[string]json.RawMessage
+	if err := json.Unmarshal(d.Definition, &definition); err != nil {
+		return Resource{}, fmt.Errorf("failed to parse dashboard %s: %w", d.Name, err)
+	}
+	delete(definition, "name")
+	delete(definition, "etag")
+	document, err := json.MarshalIndent(definition, "", "  ")
+	if err != nil {
+		return Resource{}, fmt.Errorf("failed to marshal dashboard %s: %w", d.Name, err)
+	}
+
+	return Resource{
+		Kind:     KindDashboard,
+		Name:     d.Name,
+		Label:    labelFor(d.DisplayName, d.Name),
+		Fields:   []Field{{"dashboard_json", heredoc(document)}},
+		Document: document,
+	}, nil
+}
+
+// MetricDescriptor converts a metric descriptor
+func MetricDescriptor(m monitoring.AvailableMetric) Resource {
+	fields := []Field{
+		{"type", m.Type},
+		{"metric_kind", m.MetricKind},
+		{"value_type", m.ValueType},
+	}
+	if m.Unit != "" {
+		fields = append(fields, Field{"unit", m.Unit})
+	}
+	fields = append(fields, Field{"description", m.Description}, Field{"display_name", m.DisplayName})
+	if m.LaunchStage != "" {
+		fields = append(fields, Field{"launch_stage", m.LaunchStage})
+	}
+	if len(m.Labels) > 0 {
+		var labels [][]Field
+		for _, l := range m.Labels {
+			labels = append(labels, []Field{
+				{"key", l.Key},
+				{"value_type", l.ValueType},
+				{"description", l.Description},
+			})
+		}
+		fields = append(fields, Field{"labels", labels})
+	}
+
+	// Drop the domain, which is the same for all custom metrics
+	name := m.Type
+	if _, path, ok := strings.Cut(m.Type, "/"); ok {
+		name = path
+	}
+	return Resource{
+		Kind:   KindMetricDescriptor,
+		Name:   m.Type,
+		Label:  labelFor(name, m.Type),
+		Fields: fields,
+	}
+}
+
+var nonIdentifierPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// labelFor returns a Terraform resource name derived from a display name,
+// falling back to the last segment of the resource name
+func labelFor(displayName, name string) string {
+	label := strings.Trim(nonIdentifierPattern.ReplaceAllString(strings.ToLower(displayName), "_"), "_")
+	if label == "" {
+		label = strings.Trim(nonIdentifierPattern.ReplaceAllString(strings.ToLower(name[strings.LastIndex(name, "/")+1:]), "_"), "_")
+	}
+	if label == "" || (label[0] >= '0' && label[0] <= '9') {
+		label = "r_" + label
+	}
+	return label
+}
+
+// Render renders resources in the given format. Terraform resource names are
+// made unique by appending a number.
+func Render(resources []Resource, format string) (string, error) {
+	resources = slices.Clone(resources)
+	seen := make(map[string]int)
+	for i, r := range resources {
+		key := r.Kind + "." + r.Label
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			resources[i].Label = r.Label + "_" + strconv.Itoa(n)
+		}
+	}
+
+	switch format {
+	case FormatTerraform:
+		return renderHCL(resources), nil
+	case FormatYAML:
+		return renderYAML(resources)
+	}
+	return "", fmt.Errorf("unsupported format %q", format)
+}
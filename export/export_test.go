@@ -0,0 +1,248 @@
+package export_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/export"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+var (
+	cpuPolicy = monitoring.AlertPolicy{
+		Name:                 "projects/my-project/alertPolicies/123",
+		DisplayName:          "High CPU (prod)",
+		Enabled:              true,
+		Combiner:             "OR",
+		NotificationChannels: []string{"projects/my-project/notificationChannels/1"},
+		UserLabels:           map[string]string{"team": "web", "env": "prod"},
+		Documentation:        "Scale out the ${service} backend",
+		Conditions: []monitoring.AlertCondition{
+			{
+				DisplayName:    "CPU > 80%",
+				Type:           monitoring.ConditionTypeThreshold,
+				Filter:         `metric.type="compute.googleapis.com/instance/cpu/utilization" AND resource.type="gce_instance"`,
+				Duration:       "300s",
+				Comparison:     "COMPARISON_GT",
+				ThresholdValue: 0.8,
+				Aggregations:   []monitoring.AggregationConfig{{AlignmentPeriod: "60s", PerSeriesAligner: "ALIGN_MEAN"}},
+				TriggerCount:   1,
+			},
+			{DisplayName: "query", Type: monitoring.ConditionTypeSQL, Filter: "SELECT 1"},
+		},
+	}
+	dashboard = monitoring.Dashboard{
+		Name:        "projects/my-project/dashboards/abc",
+		DisplayName: "Web",
+		Definition:  []byte(`{"name":"projects/my-project/dashboards/abc","displayName":"Web","etag":"e1","mosaicLayout":{"columns":48,"tiles":[{"width":24,"widget":{"title":"true"}}]}}`),
+	}
+	metricDescriptor = monitoring.AvailableMetric{
+		Type:        "custom.googleapis.com/orders/count",
+		DisplayName: "Orders",
+		Description: "Orders placed",
+		MetricKind:  "CUMULATIVE",
+		ValueType:   "INT64",
+		Unit:        "1",
+		Labels:      []monitoring.MetricLabel{{Key: "region", ValueType: "STRING", Description: "Region"}},
+	}
+)
+
+func TestAlertPolicy(t *testing.T) {
+	resource, warnings := export.AlertPolicy(cpuPolicy)
+	if resource.Label != "high_cpu_prod" {
+		t.Errorf("Expected label high_cpu_prod, got %s", resource.Label)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"query"`) {
+		t.Errorf("Expected a warning for the SQL condition, got %v", warnings)
+	}
+}
+
+func TestRender_Terraform(t *testing.T) {
+	policy, _ := export.AlertPolicy(cpuPolicy)
+	dash, err := export.Dashboard(dashboard)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// A second policy with the same name gets a unique label
+	duplicate, _ := export.AlertPolicy(monitoring.AlertPolicy{Name: "projects/my-project/alertPolicies/456", DisplayName: "High CPU prod"})
+
+	got, err := export.Render([]export.Resource{policy, duplicate, dash, export.MetricDescriptor(metricDescriptor)}, export.FormatTerraform)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := `# terraform import google_monitoring_alert_policy.high_cpu_prod projects/my-project/alertPolicies/123
+resource "google_monitoring_alert_policy" "high_cpu_prod" {
+  display_name          = "High CPU (prod)"
+  combiner              = "OR"
+  enabled               = true
+  notification_channels = ["projects/my-project/notificationChannels/1"]
+  user_labels = {
+    "env"  = "prod"
+    "team" = "web"
+  }
+  conditions {
+    display_name = "CPU > 80%"
+    condition_threshold {
+      filter          = "metric.type=\"compute.googleapis.com/instance/cpu/utilization\" AND resource.type=\"gce_instance\""
+      duration        = "300s"
+      comparison      = "COMPARISON_GT"
+      threshold_value = 0.8
+      aggregations {
+        alignment_period   = "60s"
+        per_series_aligner = "ALIGN_MEAN"
+      }
+      trigger {
+        count = 1
+      }
+    }
+  }
+  documentation {
+    content   = "Scale out the $${service} backend"
+    mime_type = "text/markdown"
+  }
+}
+
+# terraform import google_monitoring_alert_policy.high_cpu_prod_2 projects/my-project/alertPolicies/456
+resource "google_monitoring_alert_policy" "high_cpu_prod_2" {
+  display_name = "High CPU prod"
+  combiner     = "OR"
+  enabled      = false
+}
+
+# terraform import google_monitoring_dashboard.web projects/my-project/dashboards/abc
+resource "google_monitoring_dashboard" "web" {
+  dashboard_json = <<-EOT
+    {
+      "displayName": "Web",
+      "mosaicLayout": {
+        "columns": 48,
+        "tiles": [
+          {
+            "width": 24,
+            "widget": {
+              "title": "true"
+            }
+          }
+        ]
+      }
+    }
+    EOT
+}
+
+# terraform import google_monitoring_metric_descriptor.orders_count custom.googleapis.com/orders/count
+resource "google_monitoring_metric_descriptor" "orders_count" {
+  type         = "custom.googleapis.com/orders/count"
+  metric_kind  = "CUMULATIVE"
+  value_type   = "INT64"
+  unit         = "1"
+  description  = "Orders placed"
+  display_name = "Orders"
+  labels {
+    key         = "region"
+    value_type  = "STRING"
+    description = "Region"
+  }
+}
+`
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRender_YAML(t *testing.T) {
+	policy, _ := export.AlertPolicy(cpuPolicy)
+	dash, err := export.Dashboard(dashboard)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got, err := export.Render([]export.Resource{policy, dash}, export.FormatYAML)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := `# alert_policy projects/my-project/alertPolicies/123
+displayName: High CPU (prod)
+combiner: OR
+enabled: true
+notificationChannels:
+  - projects/my-project/notificationChannels/1
+userLabels:
+  env: prod
+  team: web
+conditions:
+  - displayName: CPU > 80%
+    conditionThreshold:
+      filter: metric.type="compute.googleapis.com/instance/cpu/utilization" AND resource.type="gce_instance"
+      duration: 300s
+      comparison: COMPARISON_GT
+      thresholdValue: 0.8
+      aggregations:
+        - alignmentPeriod: 60s
+          perSeriesAligner: ALIGN_MEAN
+      trigger:
+        count: 1
+documentation:
+  content: Scale out the ${service} backend
+  mimeType: text/markdown
+---
+# dashboard projects/my-project/dashboards/abc
+displayName: Web
+mosaicLayout:
+  columns: 48
+  tiles:
+    - width: 24
+      widget:
+        title: "true"
+`
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExporter_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := mocks.NewMockMonitoringClient(ctrl)
+	client.EXPECT().
+		ListAlertPolicies(gomock.Any(), monitoring.ListAlertPoliciesRequest{Filter: `display_name="High CPU (prod)"`}).
+		Return([]monitoring.AlertPolicy{cpuPolicy}, nil).
+		Times(1)
+	client.EXPECT().
+		ListAvailableMetrics(gomock.Any(), monitoring.ListAvailableMetricsRequest{Filter: `metric.type = starts_with("custom.googleapis.com/orders/")`, PageSize: 1000}).
+		Return([]monitoring.AvailableMetric{metricDescriptor}, nil).
+		Times(1)
+
+	result, err := export.NewExporter(client).Export(context.Background(), export.Request{
+		Kinds:             []string{export.KindAlertPolicy, export.KindMetricDescriptor},
+		AlertPolicyFilter: `display_name="High CPU (prod)"`,
+		MetricTypePrefix:  "custom.googleapis.com/orders/",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Format != export.FormatTerraform || result.Counts[export.KindAlertPolicy] != 1 || result.Counts[export.KindMetricDescriptor] != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("Expected 1 warning, got %v", result.Warnings)
+	}
+	if !strings.Contains(result.Content, `resource "google_monitoring_metric_descriptor" "orders_count"`) {
+		t.Errorf("Expected the metric descriptor in the content, got\n%s", result.Content)
+	}
+}
+
+func TestExporter_ExportInvalid(t *testing.T) {
+	exporter := export.NewExporter(nil)
+	if _, err := exporter.Export(context.Background(), export.Request{Format: "json"}); err == nil {
+		t.Error("Expected error for an unsupported format")
+	}
+	if _, err := exporter.Export(context.Background(), export.Request{Kinds: []string{"uptime_check"}}); err == nil {
+		t.Error("Expected error for an unsupported kind")
+	}
+}
@@ -0,0 +1,141 @@
+package export
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// heredoc is a multi-line string rendered as an indented heredoc
+type heredoc []byte
+
+// renderHCL renders resources as Terraform resources, each preceded by the
+// command importing the existing resource into the state
+func renderHCL(resources []Resource) string {
+	var b strings.Builder
+	for i, r := range resources {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		address := terraformTypes[r.Kind] + "." + r.Label
+		fmt.Fprintf(&b, "# terraform import %s %s\n", address, r.Name)
+		fmt.Fprintf(&b, "resource %q %q {\n", terraformTypes[r.Kind], r.Label)
+		writeHCLBody(&b, r.Fields, 1)
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// writeHCLBody writes attributes and nested blocks, aligning the equals signs
+// of consecutive single-line attributes as terraform fmt does
+func writeHCLBody(b *strings.Builder, fields []Field, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	// Each run of single-line attributes is aligned to its longest key
+	widths := make([]int, len(fields))
+	for start := 0; start < len(fields); {
+		end := start
+		width := 0
+		for end < len(fields) && singleLineAttribute(fields[end]) {
+			width = max(width, len(fields[end].Key))
+			end++
+		}
+		for i := start; i < end; i++ {
+			widths[i] = width
+		}
+		if end == start {
+			widths[start] = len(fields[start].Key)
+			end++
+		}
+		start = end
+	}
+
+	for i, f := range fields {
+		switch v := f.Value.(type) {
+		case []Field:
+			fmt.Fprintf(b, "%s%s {\n", indent, f.Key)
+			writeHCLBody(b, v, depth+1)
+			fmt.Fprintf(b, "%s}\n", indent)
+			continue
+		case [][]Field:
+			for _, block := range v {
+				fmt.Fprintf(b, "%s%s {\n", indent, f.Key)
+				writeHCLBody(b, block, depth+1)
+				fmt.Fprintf(b, "%s}\n", indent)
+			}
+			continue
+		}
+		fmt.Fprintf(b, "%s%-*s = %s\n", indent, widths[i], f.Key, hclValue(f.Value, depth))
+	}
+}
+
+// singleLineAttribute reports whether the field is an attribute rendered on one line
+func singleLineAttribute(f Field) bool {
+	switch v := f.Value.(type) {
+	case []Field, [][]Field, heredoc:
+		return false
+	case map[string]string:
+		return len(v) == 0
+	}
+	return true
+}
+
+// hclValue renders an attribute value
+func hclValue(value any, depth int) string {
+	indent := strings.Repeat("  ", depth)
+	switch v := value.(type) {
+	case string:
+		return hclString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = hclString(s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	case map[string]string:
+		if len(v) == 0 {
+			return "{}"
+		}
+		keys := slices.Sorted(maps.Keys(v))
+		width := 0
+		for _, k := range keys {
+			width = max(width, len(hclString(k)))
+		}
+		var b strings.Builder
+		b.WriteString("{\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s  %-*s = %s\n", indent, width, hclString(k), hclString(v[k]))
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	case heredoc:
+		var b strings.Builder
+		b.WriteString("<<-EOT\n")
+		for _, line := range strings.Split(escapeTemplate(string(v)), "\n") {
+			b.WriteString(indent + "  " + line + "\n")
+		}
+		b.WriteString(indent + "  EOT")
+		return b.String()
+	}
+	return hclString(fmt.Sprint(value))
+}
+
+// hclString quotes a string, escaping template sequences
+func hclString(s string) string {
+	return escapeTemplate(strconv.Quote(s))
+}
+
+// escapeTemplate escapes the template sequences ${ and %{, which would
+// otherwise be interpolated by Terraform
+func escapeTemplate(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
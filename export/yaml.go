@@ -0,0 +1,100 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// renderYAML renders resources as YAML documents in the API representation,
+// with field names in lowerCamelCase
+func renderYAML(resources []Resource) (string, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, r := range resources {
+		var doc *yaml.Node
+		if r.Document != nil {
+			var parsed yaml.Node
+			// JSON is YAML, and parsing it as such keeps the order of the keys
+			if err := yaml.Unmarshal(r.Document, &parsed); err != nil {
+				return "", fmt.Errorf("failed to convert %s to YAML: %w", r.Name, err)
+			}
+			doc = parsed.Content[0]
+			blockStyle(doc)
+		} else {
+			var err error
+			if doc, err = yamlMapping(r.Fields); err != nil {
+				return "", fmt.Errorf("failed to convert %s to YAML: %w", r.Name, err)
+			}
+		}
+		doc.HeadComment = r.Kind + " " + r.Name
+		if err := enc.Encode(doc); err != nil {
+			return "", fmt.Errorf("failed to convert %s to YAML: %w", r.Name, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// yamlMapping converts fields to a mapping node
+func yamlMapping(fields []Field) (*yaml.Node, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range fields {
+		value, err := yamlValue(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: lowerCamel(f.Key)}, value)
+	}
+	return mapping, nil
+}
+
+// yamlValue converts a field value to a node
+func yamlValue(value any) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case []Field:
+		return yamlMapping(v)
+	case [][]Field:
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, block := range v {
+			mapping, err := yamlMapping(block)
+			if err != nil {
+				return nil, err
+			}
+			seq.Content = append(seq.Content, mapping)
+		}
+		return seq, nil
+	case heredoc:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(v), Style: yaml.LiteralStyle}, nil
+	}
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// blockStyle switches a node parsed from JSON to block style, quoting only
+// the strings that need it
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// lowerCamel converts a snake_case field name to lowerCamelCase
+func lowerCamel(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,47 @@
+package fake
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+)
+
+// AssetClient implements asset.AssetClientInterface in memory, with the
+// assets of the seeds loaded in the backend's project
+type AssetClient struct {
+	projectID string
+
+	mu     sync.Mutex
+	assets []asset.Asset
+}
+
+// NewAssetClient creates an empty AssetClient for projectID
+func NewAssetClient(projectID string) *AssetClient {
+	return &AssetClient{projectID: projectID}
+}
+
+// ListAssets implements asset.AssetClientInterface
+func (c *AssetClient) ListAssets(ctx context.Context, req asset.ListAssetsRequest) (asset.ListAssetsResponse, error) {
+	assets := []asset.Asset{}
+	c.mu.Lock()
+	if req.ProjectID == "" || req.ProjectID == c.projectID {
+		for _, a := range c.assets {
+			if len(req.AssetTypes) == 0 || slices.Contains(req.AssetTypes, a.AssetType) {
+				assets = append(assets, a)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	assets, next, err := page(assets, pageSize, req.PageToken)
+	if err != nil {
+		return asset.ListAssetsResponse{}, err
+	}
+	return asset.ListAssetsResponse{Assets: assets, NextPageToken: next}, nil
+}
@@ -0,0 +1,49 @@
+package fake
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+)
+
+// infoTypePatterns approximate the Cloud DLP detectors of common infoTypes
+var infoTypePatterns = []struct {
+	infoType string
+	re       *regexp.Regexp
+}{
+	{"EMAIL_ADDRESS", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	{"AUTH_TOKEN", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*|\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)},
+	{"GCP_API_KEY", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"CREDIT_CARD_NUMBER", regexp.MustCompile(`\b\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{4}\b`)},
+	{"US_SOCIAL_SECURITY_NUMBER", regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{"IP_ADDRESS", regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)},
+}
+
+// DLPClient implements redact.DLPClientInterface with regular expressions
+// for EMAIL_ADDRESS, AUTH_TOKEN, GCP_API_KEY, CREDIT_CARD_NUMBER,
+// US_SOCIAL_SECURITY_NUMBER, and IP_ADDRESS. Other infoTypes are not found.
+type DLPClient struct{}
+
+// Deidentify implements redact.DLPClientInterface
+func (DLPClient) Deidentify(ctx context.Context, projectID string, values []string, infoTypes []string) (redact.DeidentifyResult, error) {
+	requested := make(map[string]bool, len(infoTypes))
+	for _, infoType := range infoTypes {
+		requested[infoType] = true
+	}
+
+	result := redact.DeidentifyResult{Values: make([]string, len(values)), Findings: make(map[string]int)}
+	for i, v := range values {
+		for _, p := range infoTypePatterns {
+			if !requested[p.infoType] {
+				continue
+			}
+			v = p.re.ReplaceAllStringFunc(v, func(string) string {
+				result.Findings[p.infoType]++
+				return "[" + p.infoType + "]"
+			})
+		}
+		result.Values[i] = v
+	}
+	return result, nil
+}
@@ -0,0 +1,253 @@
+// Package fake implements the Cloud Logging, Cloud Monitoring, Cloud Trace,
+// and Cloud Profiler clients in memory, optionally seeded from a JSON file,
+// so that the MCP tools can be developed and demoed without a Google Cloud
+// project.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultProjectID is the project of the fake backend when none is configured
+const DefaultProjectID = "fake-project"
+
+// Seed represents the telemetry loaded into the fake backend, in the JSON
+// formats returned by the tools
+type Seed struct {
+	LogEntries             []SeedLogEntry                     `json:"log_entries,omitempty"`
+	MetricDescriptors      []monitoring.MetricDescriptor      `json:"metric_descriptors,omitempty"`
+	TimeSeries             []monitoring.TimeSeriesData        `json:"time_series,omitempty"`
+	Alerts                 []monitoring.Alert                 `json:"alerts,omitempty"`
+	AlertPolicies          []monitoring.AlertPolicy           `json:"alert_policies,omitempty"`
+	Dashboards             []monitoring.Dashboard             `json:"dashboards,omitempty"`
+	ServiceLevelObjectives []monitoring.ServiceLevelObjective `json:"service_level_objectives,omitempty"`
+	Traces                 []trace.Trace                      `json:"traces,omitempty"`
+	Profiles               []profiler.Profile                 `json:"profiles,omitempty"`
+	Assets                 []asset.Asset                      `json:"assets,omitempty"`
+	// ShiftToNow moves all the timestamps so that the latest one is the time
+	// the seed is loaded, so that the seed shows up in recent time ranges
+	ShiftToNow bool `json:"shift_to_now,omitempty"`
+}
+
+// SeedLogEntry is a log entry and the log it is written to, either a log ID
+// or a full resource name such as projects/PROJECT_ID/logs/LOG_ID
+type SeedLogEntry struct {
+	LogName string `json:"log_name"`
+	logging.LogEntry
+}
+
+// LoadSeed reads a seed from a JSON file
+func LoadSeed(path string) (Seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Seed{}, fmt.Errorf("failed to read seed: %w", err)
+	}
+	var seed Seed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return Seed{}, fmt.Errorf("invalid seed %s: %w", path, err)
+	}
+	return seed, nil
+}
+
+// Backend holds the in-memory clients of a project
+type Backend struct {
+	Logging    *LoggingClient
+	Monitoring *MonitoringClient
+	Trace      *TraceClient
+	Profiler   *ProfilerClient
+	DLP        DLPClient
+	Assets     *AssetClient
+	projectID  string
+}
+
+// New creates an empty backend for projectID
+func New(projectID string) *Backend {
+	return &Backend{
+		Logging:    NewLoggingClient(projectID),
+		Monitoring: NewMonitoringClient(projectID),
+		Trace:      NewTraceClient(projectID),
+		Profiler:   NewProfilerClient(projectID),
+		Assets:     NewAssetClient(projectID),
+		projectID:  projectID,
+	}
+}
+
+// Load adds the telemetry of a seed to the backend. Alerts, alerting
+// policies, and dashboards without a name are named in the backend's project.
+func (b *Backend) Load(seed Seed) error {
+	if seed.ShiftToNow {
+		shiftToNow(&seed, time.Now())
+	}
+
+	for i, e := range seed.LogEntries {
+		logName := e.LogName
+		if logName == "" {
+			return fmt.Errorf("log entry %d has no log_name", i)
+		}
+		if err := b.Logging.WriteEntries(context.Background(), logging.WriteEntriesRequest{LogName: logName, Entries: []logging.LogEntry{e.LogEntry}}); err != nil {
+			return fmt.Errorf("invalid log entry %d: %w", i, err)
+		}
+	}
+
+	m := b.Monitoring
+	m.mu.Lock()
+	for _, d := range seed.MetricDescriptors {
+		m.putDescriptor(b.projectID, d)
+	}
+	m.mu.Unlock()
+	if err := m.WriteTimeSeries(context.Background(), monitoring.WriteTimeSeriesRequest{TimeSeries: seed.TimeSeries}); err != nil {
+		return fmt.Errorf("invalid time series: %w", err)
+	}
+	m.mu.Lock()
+	for i, a := range seed.Alerts {
+		if a.Name == "" {
+			a.Name = fmt.Sprintf("projects/%s/alerts/seed-%d", b.projectID, i)
+		}
+		m.alerts = append(m.alerts, a)
+	}
+	for i, p := range seed.AlertPolicies {
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("projects/%s/alertPolicies/seed-%d", b.projectID, i)
+		}
+		m.policies = append(m.policies, p)
+	}
+	for i, d := range seed.Dashboards {
+		if d.Name == "" {
+			d.Name = fmt.Sprintf("projects/%s/dashboards/seed-%d", b.projectID, i)
+		}
+		m.dashboards = append(m.dashboards, d)
+	}
+	m.slos = append(m.slos, seed.ServiceLevelObjectives...)
+	m.mu.Unlock()
+
+	t := b.Trace
+	t.mu.Lock()
+	for _, tr := range seed.Traces {
+		if tr.ProjectID == "" {
+			tr.ProjectID = b.projectID
+		}
+		t.traces = append(t.traces, cloneTrace(tr))
+	}
+	t.mu.Unlock()
+
+	p := b.Profiler
+	p.mu.Lock()
+	for _, profile := range seed.Profiles {
+		profile := cloneProfile(&profile)
+		if profile.Deployment == nil {
+			profile.Deployment = &profiler.Deployment{}
+		}
+		if profile.Deployment.ProjectID == "" {
+			profile.Deployment.ProjectID = b.projectID
+		}
+		if profile.Name == "" {
+			profile.Name = p.newName(profile.Deployment.ProjectID)
+		}
+		p.store(profile)
+	}
+	p.mu.Unlock()
+
+	a := b.Assets
+	a.mu.Lock()
+	a.assets = append(a.assets, seed.Assets...)
+	a.mu.Unlock()
+	return nil
+}
+
+// shiftToNow moves the timestamps of a seed so that the latest one is now.
+// The slices of the seed are copied first, so that the caller's are kept.
+func shiftToNow(seed *Seed, now time.Time) {
+	seed.LogEntries = slices.Clone(seed.LogEntries)
+	seed.TimeSeries = slices.Clone(seed.TimeSeries)
+	for i := range seed.TimeSeries {
+		seed.TimeSeries[i].Values = slices.Clone(seed.TimeSeries[i].Values)
+	}
+	seed.Alerts = slices.Clone(seed.Alerts)
+	for i, a := range seed.Alerts {
+		if a.CloseTime != nil {
+			closeTime := *a.CloseTime
+			seed.Alerts[i].CloseTime = &closeTime
+		}
+	}
+	seed.Traces = slices.Clone(seed.Traces)
+	for i := range seed.Traces {
+		seed.Traces[i] = cloneTrace(seed.Traces[i])
+	}
+	seed.Profiles = slices.Clone(seed.Profiles)
+
+	var latest time.Time
+	forEachTime(seed, func(t *time.Time) {
+		if t.After(latest) {
+			latest = *t
+		}
+	})
+	if latest.IsZero() {
+		return
+	}
+	shift := now.Sub(latest)
+	forEachTime(seed, func(t *time.Time) {
+		if !t.IsZero() {
+			*t = t.Add(shift)
+		}
+	})
+}
+
+// forEachTime calls fn with each timestamp of a seed
+func forEachTime(seed *Seed, fn func(*time.Time)) {
+	for i := range seed.LogEntries {
+		fn(&seed.LogEntries[i].Timestamp)
+	}
+	for i := range seed.TimeSeries {
+		for j := range seed.TimeSeries[i].Values {
+			fn(&seed.TimeSeries[i].Values[j].Timestamp)
+		}
+	}
+	for i := range seed.Alerts {
+		fn(&seed.Alerts[i].OpenTime)
+		if seed.Alerts[i].CloseTime != nil {
+			fn(seed.Alerts[i].CloseTime)
+		}
+	}
+	for i := range seed.Traces {
+		for j := range seed.Traces[i].Spans {
+			fn(&seed.Traces[i].Spans[j].StartTime)
+			fn(&seed.Traces[i].Spans[j].EndTime)
+		}
+	}
+	for i := range seed.Profiles {
+		fn(&seed.Profiles[i].StartTime)
+	}
+}
+
+// page returns the items of the page of size n at the offset encoded in
+// pageToken, and the token of the next page, or "" on the last page
+func page[T any](items []T, n int, pageToken string) ([]T, string, error) {
+	offset := 0
+	if pageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(pageToken)
+		if err != nil || offset < 0 || offset > len(items) {
+			return nil, "", status.Error(codes.InvalidArgument, "page_token is invalid")
+		}
+	}
+	end := min(offset+n, len(items))
+	next := ""
+	if end < len(items) {
+		next = strconv.Itoa(end)
+	}
+	return items[offset:end], next, nil
+}
@@ -0,0 +1,381 @@
+package fake_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBackend_LoadSeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.json")
+	seed := `{
+		"shift_to_now": true,
+		"log_entries": [
+			{"log_name": "app", "severity": "INFO", "message": "started", "timestamp": "2024-01-01T11:00:00Z"},
+			{"log_name": "app", "severity": "ERROR", "message": "failed", "timestamp": "2024-01-01T12:00:00Z"}
+		],
+		"time_series": [
+			{"metric_type": "custom.googleapis.com/queue_depth", "resource_type": "global", "values": [{"value": 3, "timestamp": "2024-01-01T11:59:00Z"}]}
+		],
+		"service_level_objectives": [
+			{"name": "projects/fake-project/services/api/serviceLevelObjectives/availability", "goal": 0.999, "period": "720h", "good_filter": "a", "total_filter": "b"}
+		],
+		"traces": [
+			{"trace_id": "abc", "spans": [{"span_id": "1", "name": "GET /", "start_time": "2024-01-01T11:59:59Z", "end_time": "2024-01-01T12:00:00Z"}]}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(seed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := fake.LoadSeed(path)
+	if err != nil {
+		t.Fatalf("LoadSeed() error = %v", err)
+	}
+	backend := fake.New(fake.DefaultProjectID)
+	before := time.Now()
+	if err := backend.Load(s); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	ctx := context.Background()
+
+	logs, err := logging.NewWithClient(backend.Logging).ListEntries(ctx, logging.ListEntriesRequest{Filter: `severity>=ERROR AND log_id("app")`})
+	if err != nil {
+		t.Fatalf("ListEntries() error = %v", err)
+	}
+	if len(logs.Entries) != 1 || logs.Entries[0].Message != "failed" {
+		t.Fatalf("Expected the error entry, got %+v", logs.Entries)
+	}
+	if logs.Entries[0].Timestamp.Before(before) {
+		t.Errorf("Expected the latest timestamp to be shifted to now, got %s", logs.Entries[0].Timestamp)
+	}
+
+	series, err := monitoring.NewWithClient(backend.Monitoring, fake.DefaultProjectID).ListTimeSeries(ctx, monitoring.ListTimeSeriesRequest{
+		Filter: `metric.type="custom.googleapis.com/queue_depth"`,
+		Interval: struct {
+			StartTime time.Time `json:"start_time"`
+			EndTime   time.Time `json:"end_time"`
+		}{StartTime: time.Now().Add(-time.Hour), EndTime: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("ListTimeSeries() error = %v", err)
+	}
+	if len(series.TimeSeries) != 1 || len(series.TimeSeries[0].Values) != 1 {
+		t.Errorf("Expected the seeded point in the last hour, got %+v", series.TimeSeries)
+	}
+
+	slo, err := backend.Monitoring.GetServiceLevelObjective(ctx, "projects/fake-project/services/api/serviceLevelObjectives/availability")
+	if err != nil {
+		t.Fatalf("GetServiceLevelObjective() error = %v", err)
+	}
+	if slo.Period != 720*time.Hour {
+		t.Errorf("Expected a period of 720h, got %s", slo.Period)
+	}
+
+	tr, err := trace.NewWithClient(backend.Trace, fake.DefaultProjectID).GetTrace(ctx, trace.GetTraceRequest{TraceID: "abc"})
+	if err != nil {
+		t.Fatalf("GetTrace() error = %v", err)
+	}
+	if tr.ProjectID != fake.DefaultProjectID || len(tr.Spans) != 1 {
+		t.Errorf("Expected the seeded trace in the default project, got %+v", tr)
+	}
+}
+
+func TestBackend_InvalidSeed(t *testing.T) {
+	backend := fake.New(fake.DefaultProjectID)
+	err := backend.Load(fake.Seed{LogEntries: []fake.SeedLogEntry{{LogEntry: logging.LogEntry{Message: "no log"}}}})
+	if err == nil {
+		t.Error("Expected an error for a log entry without a log name")
+	}
+}
+
+func TestLoggingClient_ListEntries(t *testing.T) {
+	client := fake.NewLoggingClient("test-project")
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var entries []logging.LogEntry
+	for i := range 5 {
+		entries = append(entries, logging.LogEntry{Severity: "INFO", Message: "request", Timestamp: base.Add(time.Duration(i) * time.Minute)})
+	}
+	if err := client.WriteEntries(ctx, logging.WriteEntriesRequest{LogName: "app", Entries: entries}); err != nil {
+		t.Fatalf("WriteEntries() error = %v", err)
+	}
+	if err := client.WriteEntry(ctx, "projects/other-project/logs/app", logging.LogEntry{Message: "other"}); err != nil {
+		t.Fatalf("WriteEntry() error = %v", err)
+	}
+
+	var got []time.Time
+	req := logging.ListEntriesRequest{Limit: 2, OrderBy: logging.OrderByTimestampAsc}
+	for {
+		resp, err := client.ListEntries(ctx, req)
+		if err != nil {
+			t.Fatalf("ListEntries() error = %v", err)
+		}
+		for _, e := range resp.Entries {
+			got = append(got, e.Timestamp)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+	if len(got) != 5 || !got[0].Equal(base) || !got[4].Equal(base.Add(4*time.Minute)) {
+		t.Errorf("Expected the 5 entries of the project oldest first, got %v", got)
+	}
+
+	if _, err := client.ListEntries(ctx, logging.ListEntriesRequest{PageToken: "invalid"}); err == nil {
+		t.Error("Expected an error for an invalid page token")
+	}
+	if _, err := client.ListEntries(ctx, logging.ListEntriesRequest{OrderBy: "severity"}); err == nil {
+		t.Error("Expected an error for an unsupported order")
+	}
+}
+
+func TestMonitoringClient_Aggregation(t *testing.T) {
+	client := fake.NewMonitoringClient("test-project")
+	ctx := context.Background()
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Delta points count the requests of the minute before their timestamp
+	point := func(minutes int, value float64) monitoring.MetricValue {
+		timestamp := end.Add(-time.Duration(minutes) * time.Minute)
+		return monitoring.MetricValue{StartTime: timestamp.Add(-time.Minute), Timestamp: timestamp, Value: value}
+	}
+	err := client.WriteTimeSeries(ctx, monitoring.WriteTimeSeriesRequest{TimeSeries: []monitoring.TimeSeriesData{
+		{MetricType: "custom.googleapis.com/requests", MetricKind: "DELTA", MetricLabels: map[string]string{"status": "200"}, ResourceType: "global",
+			Values: []monitoring.MetricValue{point(1, 10), point(3, 20), point(6, 5)}},
+		{MetricType: "custom.googleapis.com/requests", MetricKind: "DELTA", MetricLabels: map[string]string{"status": "500"}, ResourceType: "global",
+			Values: []monitoring.MetricValue{point(2, 1), point(7, 2)}},
+	}})
+	if err != nil {
+		t.Fatalf("WriteTimeSeries() error = %v", err)
+	}
+
+	req := monitoring.ListTimeSeriesRequest{
+		Filter:      `metric.type="custom.googleapis.com/requests"`,
+		Aggregation: &monitoring.AggregationConfig{AlignmentPeriod: "300s", PerSeriesAligner: "ALIGN_SUM", CrossSeriesReducer: "REDUCE_SUM"},
+	}
+	req.Interval.StartTime = end.Add(-10 * time.Minute)
+	req.Interval.EndTime = end
+	resp, err := client.ListTimeSeries(ctx, req)
+	if err != nil {
+		t.Fatalf("ListTimeSeries() error = %v", err)
+	}
+	want := []monitoring.MetricValue{{Timestamp: end, Value: 31}, {Timestamp: end.Add(-5 * time.Minute), Value: 7}}
+	if len(resp.TimeSeries) != 1 || !reflect.DeepEqual(resp.TimeSeries[0].Values, want) {
+		t.Errorf("Expected %v, got %+v", want, resp.TimeSeries)
+	}
+
+	req.Aggregation.GroupByFields = []string{"metric.label.status"}
+	resp, err = client.ListTimeSeries(ctx, req)
+	if err != nil {
+		t.Fatalf("ListTimeSeries() error = %v", err)
+	}
+	if len(resp.TimeSeries) != 2 || resp.TimeSeries[0].MetricLabels["status"] != "200" {
+		t.Errorf("Expected a series per status, got %+v", resp.TimeSeries)
+	}
+
+	descriptors, err := client.ListMetricDescriptors(ctx, monitoring.ListMetricDescriptorsRequest{Filter: `metric.type=starts_with("custom.googleapis.com/")`})
+	if err != nil {
+		t.Fatalf("ListMetricDescriptors() error = %v", err)
+	}
+	if len(descriptors.Descriptors) != 1 || descriptors.Descriptors[0].MetricKind != "DELTA" {
+		t.Errorf("Expected a descriptor created by the write, got %+v", descriptors.Descriptors)
+	}
+
+	if err := client.DeleteMetricDescriptor(ctx, "custom.googleapis.com/requests"); err != nil {
+		t.Fatalf("DeleteMetricDescriptor() error = %v", err)
+	}
+	if err := client.DeleteMetricDescriptor(ctx, "custom.googleapis.com/requests"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestMonitoringClient_ApplyAlertPolicy(t *testing.T) {
+	client := fake.NewMonitoringClient("test-project")
+	ctx := context.Background()
+	definition := json.RawMessage(`{"displayName": "High error rate", "conditions": [{"displayName": "errors", "conditionThreshold": {"filter": "metric.type=\"custom.googleapis.com/errors\"", "comparison": "COMPARISON_GT", "thresholdValue": 5}}]}`)
+
+	created, err := client.ApplyAlertPolicy(ctx, monitoring.ApplyRequest{Definition: definition})
+	if err != nil {
+		t.Fatalf("ApplyAlertPolicy() error = %v", err)
+	}
+	if created.Action != monitoring.ApplyActionCreated || created.Name == "" {
+		t.Fatalf("Expected a created policy, got %+v", created)
+	}
+
+	updated, err := client.ApplyAlertPolicy(ctx, monitoring.ApplyRequest{
+		Definition: json.RawMessage(`{"name": "` + created.Name + `", "displayName": "Very high error rate"}`),
+		Mode:       monitoring.ApplyModeUpdate,
+	})
+	if err != nil {
+		t.Fatalf("ApplyAlertPolicy() error = %v", err)
+	}
+	if updated.Action != monitoring.ApplyActionUpdated || updated.Name != created.Name {
+		t.Errorf("Expected %s to be updated, got %+v", created.Name, updated)
+	}
+
+	policies, err := client.ListAlertPolicies(ctx, monitoring.ListAlertPoliciesRequest{Filter: `display_name=starts_with("Very")`})
+	if err != nil {
+		t.Fatalf("ListAlertPolicies() error = %v", err)
+	}
+	if len(policies) != 1 || policies[0].DisplayName != "Very high error rate" {
+		t.Errorf("Expected the updated policy, got %+v", policies)
+	}
+
+	_, err = client.ApplyAlertPolicy(ctx, monitoring.ApplyRequest{
+		Definition: json.RawMessage(`{"name": "projects/test-project/alertPolicies/missing"}`),
+		Mode:       monitoring.ApplyModeUpdate,
+	})
+	if err == nil {
+		t.Error("Expected an error when updating a missing policy")
+	}
+}
+
+func TestTraceClient(t *testing.T) {
+	client := fake.NewTraceClient("test-project")
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	patch := func(traceID, name string, latency time.Duration) {
+		t.Helper()
+		err := client.PatchTraces(ctx, trace.PatchTraceRequest{TraceID: traceID, Spans: []trace.Span{
+			{SpanID: "1", Name: name, StartTime: start, EndTime: start.Add(latency), Labels: map[string]string{"/http/status_code": "200"}},
+			{SpanID: "2", ParentID: "1", Name: "db.query", StartTime: start, EndTime: start.Add(latency / 2)},
+		}})
+		if err != nil {
+			t.Fatalf("PatchTraces() error = %v", err)
+		}
+	}
+	patch("fast", "GET /health", 10*time.Millisecond)
+	patch("slow", "GET /orders", 2*time.Second)
+
+	tests := []struct {
+		filter string
+		view   string
+		want   []string
+		spans  int
+	}{
+		{filter: "", want: []string{"slow", "fast"}},
+		{filter: "root:GET", view: trace.ViewRootSpan, want: []string{"slow", "fast"}, spans: 1},
+		{filter: "+root:GET", want: nil},
+		{filter: "latency:1s", view: trace.ViewComplete, want: []string{"slow"}, spans: 2},
+		{filter: "span:db +/http/status_code:200 GET /orders", want: nil},
+		{filter: "span:db +/http/status_code:200 GET", want: []string{"slow", "fast"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			resp, err := client.ListTraces(ctx, trace.ListTracesRequest{Filter: tt.filter, View: tt.view, OrderBy: "duration desc"})
+			if err != nil {
+				t.Fatalf("ListTraces() error = %v", err)
+			}
+			var got []string
+			for _, tr := range resp.Traces {
+				got = append(got, tr.TraceID)
+				if len(tr.Spans) != tt.spans {
+					t.Errorf("Expected %d spans, got %d", tt.spans, len(tr.Spans))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := client.GetTrace(ctx, trace.GetTraceRequest{TraceID: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestProfilerClient(t *testing.T) {
+	client := fake.NewProfilerClient("test-project")
+	ctx := context.Background()
+
+	profile, err := client.CreateProfile(ctx, profiler.CreateProfileRequest{
+		Deployment:  &profiler.Deployment{Target: "api", Labels: map[string]string{"version": "v1"}},
+		ProfileType: []profiler.ProfileType{profiler.ProfileTypeCPU},
+	})
+	if err != nil {
+		t.Fatalf("CreateProfile() error = %v", err)
+	}
+	if profiles, _ := client.ListProfiles(ctx, profiler.ListProfilesRequest{}); len(profiles.Profiles) != 0 {
+		t.Errorf("Expected no profiles before the update, got %d", len(profiles.Profiles))
+	}
+
+	if _, err := client.UpdateProfile(ctx, profiler.UpdateProfileRequest{Profile: profile, ProfileBytes: "cHByb2Y="}); err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+	if _, err := client.UpdateProfile(ctx, profiler.UpdateProfileRequest{Profile: profile}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a second update, got %v", err)
+	}
+
+	profiles, err := client.QueryProfiles(ctx, profiler.QueryProfilesRequest{
+		Target:      "api",
+		ProfileType: profiler.ProfileTypeCPU,
+		Labels:      map[string]string{"version": "v1"},
+		StartTime:   time.Now().Add(-time.Minute),
+		EndTime:     time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("QueryProfiles() error = %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].ProfileBytes != "cHByb2Y=" || profiles[0].Duration != "10s" {
+		t.Errorf("Expected the updated profile, got %+v", profiles)
+	}
+}
+
+func TestDLPClient(t *testing.T) {
+	scanner := redact.NewDLPScannerWithClient(fake.DLPClient{}, "test-project", nil)
+	entries, summary, err := scanner.ScanEntries(context.Background(), "", nil, []logging.LogEntry{
+		{Message: "login by alice@example.com from 203.0.113.7"},
+	})
+	if err != nil {
+		t.Fatalf("ScanEntries() error = %v", err)
+	}
+	if want := "login by [EMAIL_ADDRESS] from [IP_ADDRESS]"; entries[0].Message != want {
+		t.Errorf("Expected %q, got %q", want, entries[0].Message)
+	}
+	if summary.Total != 2 {
+		t.Errorf("Expected 2 findings, got %d", summary.Total)
+	}
+}
+
+func TestAssetClient(t *testing.T) {
+	backend := fake.New("test-project")
+	err := backend.Load(fake.Seed{Assets: []asset.Asset{
+		{Name: "//compute.googleapis.com/projects/test-project/zones/us-central1-b/instances/web-1", AssetType: "compute.googleapis.com/Instance", ID: "1111"},
+		{Name: "//storage.googleapis.com/logs", AssetType: "storage.googleapis.com/Bucket"},
+	}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	client := asset.NewWithClient(backend.Assets, "test-project")
+	resp, err := client.FindAssets(context.Background(), asset.FindAssetsRequest{AssetType: "compute.googleapis.com/Instance", IDs: []string{"1111", "2222"}})
+	if err != nil {
+		t.Fatalf("FindAssets() error = %v", err)
+	}
+	if resp.Total != 1 || len(resp.Assets) != 1 || !reflect.DeepEqual(resp.Missing, []string{"2222"}) {
+		t.Errorf("Expected the seeded instance and 2222 missing, got %+v", resp)
+	}
+
+	other, err := backend.Assets.ListAssets(context.Background(), asset.ListAssetsRequest{ProjectID: "other-project"})
+	if err != nil {
+		t.Fatalf("ListAssets() error = %v", err)
+	}
+	if len(other.Assets) != 0 {
+		t.Errorf("Expected no assets in another project, got %+v", other.Assets)
+	}
+}
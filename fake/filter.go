@@ -0,0 +1,487 @@
+package fake
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+)
+
+// record is an item matched by filters
+type record interface {
+	// field returns the value of a field as a string, and whether it is set
+	field(name string) (string, bool)
+	// text returns the values matched by restrictions without a field
+	text() []string
+}
+
+// filterExpr is a parsed filter in the subset of the Cloud Logging and Cloud
+// Monitoring filter languages supported by the fake backend: comparisons
+// (=, !=, <, <=, >, >=, :, =~, !~), global restrictions, AND, OR, NOT,
+// parentheses, log_id(), and the starts_with(), ends_with(),
+// has_substring(), one_of(), and monitoring.regex.full_match() values
+type filterExpr interface {
+	match(r record) bool
+}
+
+// valueFunctions are the functions supported on the right of comparisons
+var valueFunctions = []string{"starts_with", "ends_with", "has_substring", "one_of", "monitoring.regex.full_match"}
+
+type matchAll struct{}
+
+func (matchAll) match(record) bool { return true }
+
+type andExpr struct{ left, right filterExpr }
+
+func (e andExpr) match(r record) bool { return e.left.match(r) && e.right.match(r) }
+
+type orExpr struct{ left, right filterExpr }
+
+func (e orExpr) match(r record) bool { return e.left.match(r) || e.right.match(r) }
+
+type notExpr struct{ expr filterExpr }
+
+func (e notExpr) match(r record) bool { return !e.expr.match(r) }
+
+// globalTerm matches records with a value containing the term
+type globalTerm struct{ value string }
+
+func (t globalTerm) match(r record) bool {
+	value := strings.ToLower(t.value)
+	for _, text := range r.text() {
+		if strings.Contains(strings.ToLower(text), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// logIDTerm matches the log entries of a log, as log_id("LOG_ID")
+type logIDTerm struct{ logID string }
+
+func (t logIDTerm) match(r record) bool {
+	logName, ok := r.field("logName")
+	return ok && strings.HasSuffix(logName, "/logs/"+url.PathEscape(t.logID))
+}
+
+// comparison matches records whose field compares to a value, or to the
+// arguments of a value function when fn is set
+type comparison struct {
+	field string
+	op    string
+	value string
+	fn    string
+	args  []string
+	re    *regexp.Regexp
+}
+
+func (c comparison) match(r record) bool {
+	v, ok := r.field(c.field)
+	negated := c.op == "!=" || c.op == "!~"
+	if !ok {
+		return negated
+	}
+
+	if c.fn != "" {
+		matched := false
+		switch c.fn {
+		case "starts_with":
+			matched = strings.HasPrefix(v, c.args[0])
+		case "ends_with":
+			matched = strings.HasSuffix(v, c.args[0])
+		case "has_substring":
+			matched = strings.Contains(v, c.args[0])
+		case "one_of":
+			matched = slices.Contains(c.args, v)
+		case "monitoring.regex.full_match":
+			matched = c.re.MatchString(v)
+		}
+		return matched != negated
+	}
+
+	switch c.op {
+	case ":":
+		return strings.Contains(strings.ToLower(v), strings.ToLower(c.value))
+	case "=~":
+		return c.re.MatchString(v)
+	case "!~":
+		return !c.re.MatchString(v)
+	}
+	order := compareValues(c.field, v, c.value)
+	switch c.op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default: // >=
+		return order >= 0
+	}
+}
+
+// compareValues compares severities by their level, and other values as
+// numbers or timestamps when both are, and as strings otherwise
+func compareValues(field, a, b string) int {
+	if field == "severity" {
+		i, j := slices.Index(logging.Severities, strings.ToUpper(a)), slices.Index(logging.Severities, strings.ToUpper(b))
+		if i >= 0 && j >= 0 {
+			return i - j
+		}
+	}
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			return compareFloats(x, y)
+		}
+	}
+	if x, err := time.Parse(time.RFC3339Nano, a); err == nil {
+		if y, err := time.Parse(time.RFC3339Nano, b); err == nil {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// operators are the comparison operators, two-character ones first
+var operators = []string{">=", "<=", "!=", "=~", "!~", "=", "<", ">", ":"}
+
+// tokenize splits a filter into tokens. Quoted strings are unquoted, except
+// in field names such as labels."k8s-pod/app", which are kept as written.
+func tokenize(filter string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(filter); {
+		c := filter[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "("})
+			i++
+			continue
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")"})
+			i++
+			continue
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ","})
+			i++
+			continue
+		case c == '"':
+			quoted, err := scanQuoted(filter[i:])
+			if err != nil {
+				return nil, err
+			}
+			s, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s in filter: %w", quoted, err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s})
+			i += len(quoted)
+			continue
+		}
+
+		if op := operatorAt(filter[i:]); op != "" {
+			tokens = append(tokens, token{kind: tokenOperator, text: op})
+			i += len(op)
+			continue
+		}
+
+		start := i
+		for i < len(filter) && !strings.ContainsRune(" \t\n\r(),\"", rune(filter[i])) && operatorAt(filter[i:]) == "" {
+			i++
+			// Quoted segments of field names, e.g. labels."k8s-pod/app"
+			if filter[i-1] == '.' && i < len(filter) && filter[i] == '"' {
+				quoted, err := scanQuoted(filter[i:])
+				if err != nil {
+					return nil, err
+				}
+				i += len(quoted)
+			}
+		}
+		if i == start {
+			return nil, fmt.Errorf("unexpected character %q in filter", filter[i])
+		}
+		tokens = append(tokens, token{kind: tokenWord, text: filter[start:i]})
+	}
+	return tokens, nil
+}
+
+// scanQuoted returns the quoted string at the start of s, quotes included
+func scanQuoted(s string) (string, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1], nil
+		}
+	}
+	return "", fmt.Errorf("unterminated string in filter: %s", s)
+}
+
+// operatorAt returns the comparison operator at the start of s, if any
+func operatorAt(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parseFilter parses a filter. An empty filter matches everything.
+func parseFilter(filter string) (filterExpr, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return matchAll{}, nil
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q in filter", t.text)
+	}
+	return expr, nil
+}
+
+// Filter is a parsed filter, for other fakes of Google Cloud APIs matching
+// their own records
+type Filter struct {
+	expr filterExpr
+}
+
+// ParseFilter parses a filter in the subset of the Cloud Logging and Cloud
+// Monitoring filter languages supported by the fake backend
+func ParseFilter(filter string) (Filter, error) {
+	expr, err := parseFilter(filter)
+	if err != nil {
+		return Filter{}, err
+	}
+	return Filter{expr: expr}, nil
+}
+
+// Match reports whether the record whose fields are returned by field, and
+// whose values matched by restrictions without a field are texts, matches
+func (f Filter) Match(field func(name string) (string, bool), texts ...string) bool {
+	return f.expr.match(funcRecord{fieldFunc: field, texts: texts})
+}
+
+// funcRecord is a record given by a function
+type funcRecord struct {
+	fieldFunc func(name string) (string, bool)
+	texts     []string
+}
+
+func (r funcRecord) field(name string) (string, bool) { return r.fieldFunc(name) }
+
+func (r funcRecord) text() []string { return r.texts }
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{kind: tokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && t.text == keyword
+}
+
+func (p *parser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses restrictions joined by AND, which may be omitted
+func (p *parser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if t := p.peek(); t.kind == tokenEOF || t.kind == tokenRightParen || p.isKeyword("OR") {
+			return left, nil
+		}
+		if p.isKeyword("AND") {
+			p.next()
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+}
+
+func (p *parser) parseUnary() (filterExpr, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (filterExpr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLeftParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenRightParen {
+			return nil, fmt.Errorf("missing ) in filter")
+		}
+		return expr, nil
+	case tokenString:
+		return globalTerm{t.text}, nil
+	case tokenWord:
+	default:
+		return nil, fmt.Errorf("unexpected %q in filter", t.text)
+	}
+
+	if p.peek().kind == tokenLeftParen {
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		if t.text != "log_id" || len(args) != 1 {
+			return nil, fmt.Errorf("unsupported function %s in filter: only log_id(\"LOG_ID\") is supported", t.text)
+		}
+		return logIDTerm{args[0]}, nil
+	}
+	if p.peek().kind != tokenOperator {
+		return globalTerm{t.text}, nil
+	}
+
+	c := comparison{field: t.text, op: p.next().text}
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, fmt.Errorf("missing value after %s%s in filter", c.field, c.op)
+	}
+	if value.kind == tokenWord && p.peek().kind == tokenLeftParen {
+		if !slices.Contains(valueFunctions, value.text) {
+			return nil, fmt.Errorf("unsupported function %s in filter: use one of %s", value.text, strings.Join(valueFunctions, ", "))
+		}
+		if c.op != "=" && c.op != "!=" {
+			return nil, fmt.Errorf("%s requires = or != in filter", value.text)
+		}
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 0 || (value.text != "one_of" && len(args) != 1) {
+			return nil, fmt.Errorf("invalid arguments to %s in filter", value.text)
+		}
+		c.fn, c.args = value.text, args
+		if c.fn == "monitoring.regex.full_match" {
+			re, err := regexp.Compile("^(?:" + args[0] + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression in filter: %w", err)
+			}
+			c.re = re
+		}
+		return c, nil
+	}
+
+	c.value = value.text
+	if c.op == "=~" || c.op == "!~" {
+		re, err := regexp.Compile(c.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in filter: %w", err)
+		}
+		c.re = re
+	}
+	return c, nil
+}
+
+// parseArgs parses the parenthesized arguments of a function
+func (p *parser) parseArgs() ([]string, error) {
+	p.next()
+	var args []string
+	for {
+		t := p.next()
+		switch t.kind {
+		case tokenRightParen:
+			return args, nil
+		case tokenString, tokenWord:
+			args = append(args, t.text)
+		default:
+			return nil, fmt.Errorf("unexpected %q in function arguments of filter", t.text)
+		}
+		if p.peek().kind == tokenComma {
+			p.next()
+		}
+	}
+}
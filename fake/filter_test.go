@@ -0,0 +1,73 @@
+package fake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+)
+
+func TestParseFilter(t *testing.T) {
+	entry := storedEntry{
+		logName: "projects/test-project/logs/cloudaudit.googleapis.com%2Factivity",
+		entry: logging.LogEntry{
+			Severity:  "ERROR",
+			Message:   "Connection refused by db-1",
+			Labels:    map[string]string{"k8s-pod/app": "api"},
+			Payload:   map[string]any{"latency_ms": 250.0},
+			Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			Resource:  &logging.MonitoredResource{Type: "k8s_container", Labels: map[string]string{"cluster_name": "prod"}},
+		},
+	}
+
+	tests := []struct {
+		filter  string
+		want    bool
+		wantErr bool
+	}{
+		{filter: "", want: true},
+		{filter: "severity>=WARNING", want: true},
+		{filter: "severity>=CRITICAL", want: false},
+		{filter: `severity=error`, want: true},
+		{filter: `resource.type="k8s_container" AND resource.labels.cluster_name="prod"`, want: true},
+		{filter: `resource.type="k8s_container" resource.labels.cluster_name="staging"`, want: false},
+		{filter: `resource.labels.cluster_name="staging" OR severity=ERROR`, want: true},
+		{filter: `NOT severity=ERROR`, want: false},
+		{filter: `(textPayload=~"refused" OR jsonPayload.message=~"refused") AND timestamp>="2024-01-01T00:00:00Z"`, want: true},
+		{filter: `timestamp<"2024-01-01T00:00:00Z"`, want: false},
+		{filter: `textPayload:"CONNECTION"`, want: true},
+		{filter: `textPayload!~"^Conn"`, want: false},
+		{filter: `labels."k8s-pod/app"="api"`, want: true},
+		{filter: `jsonPayload.latency_ms>100`, want: true},
+		{filter: `labels.missing!="x"`, want: true},
+		{filter: `labels.missing="x"`, want: false},
+		{filter: `"db-1"`, want: true},
+		{filter: `db-2`, want: false},
+		{filter: `log_id("cloudaudit.googleapis.com/activity")`, want: true},
+		{filter: `log_id("app")`, want: false},
+		{filter: `resource.type=starts_with("k8s_")`, want: true},
+		{filter: `resource.type=one_of("gce_instance", "k8s_container")`, want: true},
+		{filter: `resource.type!=has_substring("k8s")`, want: false},
+		{filter: `resource.type=monitoring.regex.full_match("k8s_.*")`, want: true},
+		{filter: `severity=(ERROR`, wantErr: true},
+		{filter: `textPayload="unterminated`, wantErr: true},
+		{filter: `textPayload=~"("`, wantErr: true},
+		{filter: `sample(insertId, 0.1)`, wantErr: true},
+		{filter: `resource.type>starts_with("k8s")`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			expr, err := parseFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := expr.match(entry); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+)
+
+// LoggingClient implements logging.LoggingClientInterface in memory
+type LoggingClient struct {
+	projectID string
+	now       func() time.Time
+
+	mu      sync.Mutex
+	entries []storedEntry
+	nextID  int
+}
+
+// storedEntry is a log entry with the full resource name of its log, e.g.
+// projects/PROJECT/logs/LOG_ID
+type storedEntry struct {
+	logName string
+	entry   logging.LogEntry
+}
+
+// field implements record for log entry filters
+func (e storedEntry) field(name string) (string, bool) {
+	switch name {
+	case "logName":
+		return e.logName, true
+	case "timestamp", "receiveTimestamp":
+		return e.entry.Timestamp.Format(time.RFC3339Nano), true
+	}
+	value, err := logging.FieldValue(e.entry, name)
+	if err != nil || value == nil {
+		return "", false
+	}
+	return *value, true
+}
+
+// text implements record for log entry filters
+func (e storedEntry) text() []string {
+	texts := []string{e.entry.Message}
+	for _, v := range e.entry.Labels {
+		texts = append(texts, v)
+	}
+	if e.entry.Payload != nil {
+		if data, err := json.Marshal(e.entry.Payload); err == nil {
+			texts = append(texts, string(data))
+		}
+	}
+	return texts
+}
+
+// NewLoggingClient creates an empty LoggingClient writing to projectID by default
+func NewLoggingClient(projectID string) *LoggingClient {
+	return &LoggingClient{projectID: projectID, now: time.Now}
+}
+
+// WriteEntry implements logging.LoggingClientInterface
+func (c *LoggingClient) WriteEntry(ctx context.Context, logName string, entry logging.LogEntry) error {
+	return c.WriteEntries(ctx, logging.WriteEntriesRequest{LogName: logName, Entries: []logging.LogEntry{entry}})
+}
+
+// WriteEntries implements logging.LoggingClientInterface
+func (c *LoggingClient) WriteEntries(ctx context.Context, req logging.WriteEntriesRequest) error {
+	logName, err := c.fullLogName(req.LogName)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range req.Entries {
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = c.now()
+		}
+		if entry.Severity == "" {
+			entry.Severity = "DEFAULT"
+		}
+		if entry.InsertID == "" {
+			c.nextID++
+			entry.InsertID = fmt.Sprintf("fake-%d", c.nextID)
+		}
+		// Like Cloud Logging, keep one of the entries with the same insert ID
+		// and timestamp in a log
+		if slices.ContainsFunc(c.entries, func(e storedEntry) bool {
+			return e.logName == logName && e.entry.InsertID == entry.InsertID && e.entry.Timestamp.Equal(entry.Timestamp)
+		}) {
+			continue
+		}
+		c.entries = append(c.entries, storedEntry{logName: logName, entry: entry})
+	}
+	return nil
+}
+
+// fullLogName returns the full resource name of a log given either as a log
+// ID written under the client's project or a full resource name
+func (c *LoggingClient) fullLogName(logName string) (string, error) {
+	if logName == "" {
+		return "", fmt.Errorf("log name is required")
+	}
+	if parent, logID, ok := strings.Cut(logName, "/logs/"); ok {
+		unescaped, err := url.PathUnescape(logID)
+		if err != nil {
+			return "", fmt.Errorf("invalid log name %q: %w", logName, err)
+		}
+		return parent + "/logs/" + url.PathEscape(unescaped), nil
+	}
+	return "projects/" + c.projectID + "/logs/" + url.PathEscape(logName), nil
+}
+
+// ListEntries implements logging.LoggingClientInterface
+func (c *LoggingClient) ListEntries(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+	filter, err := parseFilter(req.Filter)
+	if err != nil {
+		return logging.ListEntriesResponse{}, err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	projectID := req.ProjectID
+	if projectID == "" {
+		projectID = c.projectID
+	}
+
+	c.mu.Lock()
+	var matched []storedEntry
+	for _, e := range c.entries {
+		if strings.HasPrefix(e.logName, "projects/"+projectID+"/") && filter.match(e) {
+			matched = append(matched, e)
+		}
+	}
+	c.mu.Unlock()
+
+	switch req.OrderBy {
+	case "", logging.OrderByTimestampDesc:
+		slices.SortStableFunc(matched, func(a, b storedEntry) int { return b.entry.Timestamp.Compare(a.entry.Timestamp) })
+	case logging.OrderByTimestampAsc:
+		slices.SortStableFunc(matched, func(a, b storedEntry) int { return a.entry.Timestamp.Compare(b.entry.Timestamp) })
+	default:
+		return logging.ListEntriesResponse{}, fmt.Errorf("unsupported order_by %q: must be %q or %q", req.OrderBy, logging.OrderByTimestampAsc, logging.OrderByTimestampDesc)
+	}
+
+	matched, next, err := page(matched, limit, req.PageToken)
+	if err != nil {
+		return logging.ListEntriesResponse{}, err
+	}
+	entries := make([]logging.LogEntry, len(matched))
+	for i, e := range matched {
+		entries[i] = e.entry
+	}
+	return logging.ListEntriesResponse{Entries: entries, NextPageToken: next}, nil
+}
@@ -0,0 +1,755 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MonitoringClient implements monitoring.MonitoringClientInterface in memory
+type MonitoringClient struct {
+	projectID string
+
+	mu          sync.Mutex
+	descriptors []storedDescriptor
+	series      []storedSeries
+	alerts      []monitoring.Alert
+	policies    []monitoring.AlertPolicy
+	dashboards  []monitoring.Dashboard
+	slos        []monitoring.ServiceLevelObjective
+	nextID      int
+}
+
+// storedDescriptor is a metric descriptor of a project
+type storedDescriptor struct {
+	projectID  string
+	descriptor monitoring.MetricDescriptor
+}
+
+// field implements record for metric descriptor filters
+func (d storedDescriptor) field(name string) (string, bool) {
+	switch name {
+	case "metric.type":
+		return d.descriptor.Type, true
+	case "metric.kind", "metric_kind":
+		return d.descriptor.MetricKind, true
+	case "value_type":
+		return d.descriptor.ValueType, true
+	}
+	return "", false
+}
+
+// text implements record for metric descriptor filters
+func (d storedDescriptor) text() []string {
+	return []string{d.descriptor.Type, d.descriptor.DisplayName, d.descriptor.Description}
+}
+
+// storedSeries is a time series of a project, with its points oldest first
+type storedSeries struct {
+	projectID string
+	series    monitoring.TimeSeriesData
+}
+
+// field implements record for time series filters and group by fields
+func (s storedSeries) field(name string) (string, bool) {
+	return seriesField(s.series, name)
+}
+
+// text implements record for time series filters
+func (s storedSeries) text() []string {
+	return []string{s.series.MetricType, s.series.ResourceType}
+}
+
+// seriesField returns a field of a time series, e.g. metric.type or
+// resource.labels.zone. Labels are also accepted in the singular, as in
+// group by fields, e.g. metric.label.status.
+func seriesField(ts monitoring.TimeSeriesData, name string) (string, bool) {
+	switch name {
+	case "metric.type":
+		return ts.MetricType, true
+	case "resource.type":
+		return ts.ResourceType, true
+	}
+	for _, prefix := range []string{"metric.labels.", "metric.label."} {
+		if key, ok := strings.CutPrefix(name, prefix); ok {
+			v, ok := ts.MetricLabels[key]
+			return v, ok
+		}
+	}
+	for _, prefix := range []string{"resource.labels.", "resource.label."} {
+		if key, ok := strings.CutPrefix(name, prefix); ok {
+			v, ok := ts.ResourceLabels[key]
+			return v, ok
+		}
+	}
+	return "", false
+}
+
+// NewMonitoringClient creates an empty MonitoringClient for projectID
+func NewMonitoringClient(projectID string) *MonitoringClient {
+	return &MonitoringClient{projectID: projectID}
+}
+
+func (c *MonitoringClient) project(projectID string) string {
+	if projectID == "" {
+		return c.projectID
+	}
+	return projectID
+}
+
+// CreateMetricDescriptor implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) CreateMetricDescriptor(ctx context.Context, req monitoring.CreateMetricRequest) error {
+	if req.MetricDescriptor.Type == "" {
+		return status.Error(codes.InvalidArgument, "metric type is required")
+	}
+	if err := req.MetricDescriptor.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	descriptor := req.MetricDescriptor
+	if descriptor.MetricKind == "" {
+		descriptor.MetricKind = "GAUGE"
+	}
+	if descriptor.ValueType == "" {
+		descriptor.ValueType = "DOUBLE"
+	}
+	descriptor.ConsoleURL = ""
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putDescriptor(c.project(req.ProjectID), descriptor)
+	return nil
+}
+
+// putDescriptor creates or replaces a metric descriptor
+func (c *MonitoringClient) putDescriptor(projectID string, descriptor monitoring.MetricDescriptor) {
+	for i, d := range c.descriptors {
+		if d.projectID == projectID && d.descriptor.Type == descriptor.Type {
+			c.descriptors[i].descriptor = descriptor
+			return
+		}
+	}
+	c.descriptors = append(c.descriptors, storedDescriptor{projectID: projectID, descriptor: descriptor})
+}
+
+// WriteTimeSeries implements monitoring.MonitoringClientInterface. As in
+// Cloud Monitoring, descriptors are created for custom metrics written
+// without one.
+func (c *MonitoringClient) WriteTimeSeries(ctx context.Context, req monitoring.WriteTimeSeriesRequest) error {
+	projectID := c.project(req.ProjectID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ts := range req.TimeSeries {
+		if ts.MetricType == "" {
+			return status.Error(codes.InvalidArgument, "metric type is required")
+		}
+		if err := ts.Validate(); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		valueType := "DOUBLE"
+		ts.Values = slices.Clone(ts.Values)
+		for i, v := range ts.Values {
+			if v.Distribution == nil {
+				continue
+			}
+			if err := v.Distribution.Validate(); err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			// Distributions are read back as their mean, as from Cloud Monitoring
+			valueType = "DISTRIBUTION"
+			ts.Values[i].Value = v.Distribution.Mean
+		}
+		if !slices.ContainsFunc(c.descriptors, func(d storedDescriptor) bool {
+			return d.projectID == projectID && d.descriptor.Type == ts.MetricType
+		}) {
+			kind := ts.MetricKind
+			if kind == "" {
+				kind = "GAUGE"
+			}
+			labels := make(map[string]string)
+			for key := range ts.MetricLabels {
+				labels[key] = ""
+			}
+			c.descriptors = append(c.descriptors, storedDescriptor{projectID: projectID, descriptor: monitoring.MetricDescriptor{
+				Type: ts.MetricType, MetricKind: kind, ValueType: valueType, Labels: labels,
+			}})
+		}
+		c.appendPoints(projectID, ts)
+	}
+	return nil
+}
+
+// appendPoints adds the points of ts to the time series with the same
+// metric and resource, creating it when needed
+func (c *MonitoringClient) appendPoints(projectID string, ts monitoring.TimeSeriesData) {
+	key := seriesKey(ts)
+	for i, s := range c.series {
+		if s.projectID == projectID && seriesKey(s.series) == key {
+			c.series[i].series.Values = sortedPoints(append(slices.Clone(s.series.Values), ts.Values...))
+			return
+		}
+	}
+	ts.Values = sortedPoints(slices.Clone(ts.Values))
+	c.series = append(c.series, storedSeries{projectID: projectID, series: ts})
+}
+
+// seriesKey identifies a time series by its metric and resource
+func seriesKey(ts monitoring.TimeSeriesData) string {
+	return fmt.Sprint(ts.MetricType, ts.MetricLabels, ts.ResourceType, ts.ResourceLabels)
+}
+
+// sortedPoints sorts points oldest first
+func sortedPoints(points []monitoring.MetricValue) []monitoring.MetricValue {
+	slices.SortStableFunc(points, func(a, b monitoring.MetricValue) int { return a.Timestamp.Compare(b.Timestamp) })
+	return points
+}
+
+// ListTimeSeries implements monitoring.MonitoringClientInterface. Points are
+// returned newest first, as by Cloud Monitoring, after the aggregation.
+func (c *MonitoringClient) ListTimeSeries(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+	filter, err := parseFilter(req.Filter)
+	if err != nil {
+		return monitoring.ListTimeSeriesResponse{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !strings.Contains(req.Filter, "metric.type") {
+		return monitoring.ListTimeSeriesResponse{}, status.Error(codes.InvalidArgument, "the filter must specify a metric.type")
+	}
+	projectID := c.project(req.ProjectID)
+	start, end := req.Interval.StartTime, req.Interval.EndTime
+
+	c.mu.Lock()
+	var series []monitoring.TimeSeriesData
+	for _, s := range c.series {
+		if s.projectID != projectID || !filter.match(s) {
+			continue
+		}
+		var points []monitoring.MetricValue
+		for _, p := range s.series.Values {
+			if (start.IsZero() || p.Timestamp.After(start)) && !p.Timestamp.After(end) {
+				points = append(points, p)
+			}
+		}
+		if len(points) == 0 {
+			continue
+		}
+		ts := s.series
+		ts.Values = points
+		series = append(series, ts)
+	}
+	c.mu.Unlock()
+
+	if req.Aggregation != nil {
+		series, err = aggregate(series, *req.Aggregation, end)
+		if err != nil {
+			return monitoring.ListTimeSeriesResponse{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	for i := range series {
+		slices.Reverse(series[i].Values)
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	series, next, err := page(series, pageSize, req.PageToken)
+	if err != nil {
+		return monitoring.ListTimeSeriesResponse{}, err
+	}
+	return monitoring.ListTimeSeriesResponse{TimeSeries: series, NextPageToken: next}, nil
+}
+
+// aggregate aligns the points of each series, oldest first, into periods
+// ending at end, then reduces the series across the group by fields
+func aggregate(series []monitoring.TimeSeriesData, agg monitoring.AggregationConfig, end time.Time) ([]monitoring.TimeSeriesData, error) {
+	if err := agg.Validate(); err != nil {
+		return nil, err
+	}
+	if agg.PerSeriesAligner != "" && agg.PerSeriesAligner != "ALIGN_NONE" {
+		period, err := time.ParseDuration(agg.AlignmentPeriod)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("invalid alignment period %q", agg.AlignmentPeriod)
+		}
+		aligned := make([]monitoring.TimeSeriesData, len(series))
+		for i, ts := range series {
+			aligned[i] = ts
+			aligned[i].Values = alignPoints(ts.Values, ts.MetricKind, agg.PerSeriesAligner, period, end)
+		}
+		series = aligned
+	}
+	if agg.CrossSeriesReducer == "" || agg.CrossSeriesReducer == "REDUCE_NONE" {
+		return series, nil
+	}
+
+	var groups []monitoring.TimeSeriesData
+	values := make(map[string]map[time.Time][]float64)
+	for _, ts := range series {
+		group := monitoring.TimeSeriesData{MetricType: ts.MetricType, MetricKind: ts.MetricKind, ResourceType: ts.ResourceType}
+		for _, field := range agg.GroupByFields {
+			v, ok := seriesField(ts, field)
+			if !ok {
+				continue
+			}
+			if key, ok := cutLabelPrefix(field, "metric"); ok {
+				if group.MetricLabels == nil {
+					group.MetricLabels = make(map[string]string)
+				}
+				group.MetricLabels[key] = v
+			} else if key, ok := cutLabelPrefix(field, "resource"); ok {
+				if group.ResourceLabels == nil {
+					group.ResourceLabels = make(map[string]string)
+				}
+				group.ResourceLabels[key] = v
+			}
+		}
+		key := seriesKey(group)
+		if _, ok := values[key]; !ok {
+			values[key] = make(map[time.Time][]float64)
+			groups = append(groups, group)
+		}
+		for _, p := range ts.Values {
+			values[key][p.Timestamp] = append(values[key][p.Timestamp], p.Value)
+		}
+	}
+
+	for i, group := range groups {
+		byTime := values[seriesKey(group)]
+		for _, t := range slices.SortedFunc(maps.Keys(byTime), func(a, b time.Time) int { return a.Compare(b) }) {
+			groups[i].Values = append(groups[i].Values, monitoring.MetricValue{Timestamp: t, Value: reduce(byTime[t], agg.CrossSeriesReducer)})
+		}
+	}
+	return groups, nil
+}
+
+// cutLabelPrefix returns the key of a metric or resource label field, e.g.
+// status for metric.label.status
+func cutLabelPrefix(field, kind string) (string, bool) {
+	for _, prefix := range []string{kind + ".labels.", kind + ".label."} {
+		if key, ok := strings.CutPrefix(field, prefix); ok {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// alignPoints applies an aligner to points, oldest first, in periods ending
+// at end. Unsupported aligners default to ALIGN_MEAN, as in the real client.
+func alignPoints(points []monitoring.MetricValue, kind, aligner string, period time.Duration, end time.Time) []monitoring.MetricValue {
+	buckets := make(map[int64][]float64)
+	for _, p := range points {
+		i := int64(end.Sub(p.Timestamp) / period)
+		buckets[i] = append(buckets[i], p.Value)
+	}
+
+	var aligned []monitoring.MetricValue
+	for _, i := range slices.Backward(slices.Sorted(maps.Keys(buckets))) {
+		vs := buckets[i]
+		var value float64
+		switch aligner {
+		case "ALIGN_SUM":
+			value = reduce(vs, "REDUCE_SUM")
+		case "ALIGN_MIN":
+			value = reduce(vs, "REDUCE_MIN")
+		case "ALIGN_MAX":
+			value = reduce(vs, "REDUCE_MAX")
+		case "ALIGN_COUNT":
+			value = float64(len(vs))
+		case "ALIGN_NEXT_OLDER", "ALIGN_NEXT_NEWER":
+			value = vs[len(vs)-1]
+		case "ALIGN_DELTA", "ALIGN_RATE":
+			if kind == "DELTA" {
+				value = reduce(vs, "REDUCE_SUM")
+			} else {
+				value = vs[len(vs)-1] - vs[0]
+			}
+			if aligner == "ALIGN_RATE" {
+				value /= period.Seconds()
+			}
+		case "ALIGN_PERCENTILE_99", "ALIGN_PERCENTILE_95", "ALIGN_PERCENTILE_50", "ALIGN_PERCENTILE_05":
+			value = reduce(vs, strings.Replace(aligner, "ALIGN_", "REDUCE_", 1))
+		default:
+			value = reduce(vs, "REDUCE_MEAN")
+		}
+		aligned = append(aligned, monitoring.MetricValue{Timestamp: end.Add(-time.Duration(i) * period), Value: value})
+	}
+	return aligned
+}
+
+// reduce combines values with a reducer. Unsupported reducers default to
+// REDUCE_MEAN.
+func reduce(values []float64, reducer string) float64 {
+	switch reducer {
+	case "REDUCE_SUM":
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	case "REDUCE_MIN":
+		return slices.Min(values)
+	case "REDUCE_MAX":
+		return slices.Max(values)
+	case "REDUCE_COUNT":
+		return float64(len(values))
+	case "REDUCE_PERCENTILE_99", "REDUCE_PERCENTILE_95", "REDUCE_PERCENTILE_50", "REDUCE_PERCENTILE_05":
+		var p float64
+		fmt.Sscanf(strings.TrimPrefix(reducer, "REDUCE_PERCENTILE_"), "%g", &p)
+		sorted := slices.Sorted(slices.Values(values))
+		i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+	return reduce(values, "REDUCE_SUM") / float64(len(values))
+}
+
+// ListMetricDescriptors implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListMetricDescriptors(ctx context.Context, req monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
+	descriptors, err := c.matchDescriptors(req.ProjectID, req.Filter)
+	if err != nil {
+		return monitoring.ListMetricDescriptorsResponse{}, err
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 5
+	}
+	descriptors, next, err := page(descriptors, pageSize, req.PageToken)
+	if err != nil {
+		return monitoring.ListMetricDescriptorsResponse{}, err
+	}
+	return monitoring.ListMetricDescriptorsResponse{Descriptors: descriptors, NextPageToken: next}, nil
+}
+
+// matchDescriptors returns the metric descriptors of a project matching a filter
+func (c *MonitoringClient) matchDescriptors(projectID, filter string) ([]monitoring.MetricDescriptor, error) {
+	expr, err := parseFilter(filter)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	projectID = c.project(projectID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var descriptors []monitoring.MetricDescriptor
+	for _, d := range c.descriptors {
+		if d.projectID == projectID && expr.match(d) {
+			descriptors = append(descriptors, d.descriptor)
+		}
+	}
+	return descriptors, nil
+}
+
+// DeleteMetricDescriptor implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) DeleteMetricDescriptor(ctx context.Context, metricType string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.IndexFunc(c.descriptors, func(d storedDescriptor) bool {
+		return d.projectID == c.projectID && d.descriptor.Type == metricType
+	})
+	if i < 0 {
+		return status.Errorf(codes.NotFound, "metric descriptor %s does not exist", metricType)
+	}
+	c.descriptors = slices.Delete(c.descriptors, i, i+1)
+	c.series = slices.DeleteFunc(c.series, func(s storedSeries) bool {
+		return s.projectID == c.projectID && s.series.MetricType == metricType
+	})
+	return nil
+}
+
+// ListAvailableMetrics implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListAvailableMetrics(ctx context.Context, req monitoring.ListAvailableMetricsRequest) ([]monitoring.AvailableMetric, error) {
+	descriptors, err := c.matchDescriptors(req.ProjectID, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	descriptors, _, err = page(descriptors, pageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]monitoring.AvailableMetric, len(descriptors))
+	for i, d := range descriptors {
+		metrics[i] = monitoring.AvailableMetric{
+			Type:        d.Type,
+			DisplayName: d.DisplayName,
+			Description: d.Description,
+			MetricKind:  d.MetricKind,
+			ValueType:   d.ValueType,
+		}
+		for _, key := range slices.Sorted(maps.Keys(d.Labels)) {
+			metrics[i].Labels = append(metrics[i].Labels, monitoring.MetricLabel{Key: key, ValueType: "STRING", Description: d.Labels[key]})
+		}
+	}
+	return metrics, nil
+}
+
+// alertRecord implements record for alert filters
+type alertRecord monitoring.Alert
+
+func (a alertRecord) field(name string) (string, bool) {
+	switch name {
+	case "state":
+		return a.State, true
+	case "open_time":
+		return a.OpenTime.Format(time.RFC3339Nano), true
+	case "close_time":
+		if a.CloseTime == nil {
+			return "", false
+		}
+		return a.CloseTime.Format(time.RFC3339Nano), true
+	case "policy.name":
+		return a.PolicyName, true
+	case "policy.display_name":
+		return a.PolicyDisplayName, true
+	case "policy.severity":
+		return a.Severity, true
+	}
+	return seriesField(monitoring.TimeSeriesData{
+		MetricType: a.MetricType, MetricLabels: a.MetricLabels, ResourceType: a.ResourceType, ResourceLabels: a.ResourceLabels,
+	}, name)
+}
+
+func (a alertRecord) text() []string {
+	return []string{a.PolicyDisplayName, a.MetricType, a.ResourceType}
+}
+
+// ListAlerts implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListAlerts(ctx context.Context, req monitoring.ListAlertsRequest) (monitoring.ListAlertsResponse, error) {
+	filter, err := parseFilter(req.Filter)
+	if err != nil {
+		return monitoring.ListAlertsResponse{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	prefix := "projects/" + c.project(req.ProjectID) + "/"
+
+	c.mu.Lock()
+	var alerts []monitoring.Alert
+	for _, a := range c.alerts {
+		if strings.HasPrefix(a.Name, prefix) && filter.match(alertRecord(a)) {
+			alerts = append(alerts, a)
+		}
+	}
+	c.mu.Unlock()
+
+	field, direction, _ := strings.Cut(strings.TrimSpace(req.OrderBy), " ")
+	switch field {
+	case "", "open_time":
+		slices.SortStableFunc(alerts, func(a, b monitoring.Alert) int { return a.OpenTime.Compare(b.OpenTime) })
+	case "close_time":
+		slices.SortStableFunc(alerts, func(a, b monitoring.Alert) int {
+			return closeTime(a).Compare(closeTime(b))
+		})
+	default:
+		return monitoring.ListAlertsResponse{}, status.Errorf(codes.InvalidArgument, "unsupported order_by %q", req.OrderBy)
+	}
+	if field == "" || strings.TrimSpace(direction) == "desc" {
+		slices.Reverse(alerts)
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	alerts, next, err := page(alerts, pageSize, req.PageToken)
+	if err != nil {
+		return monitoring.ListAlertsResponse{}, err
+	}
+	return monitoring.ListAlertsResponse{Alerts: alerts, NextPageToken: next}, nil
+}
+
+// closeTime returns when an alert closed, or the far future when it is open
+func closeTime(a monitoring.Alert) time.Time {
+	if a.CloseTime == nil {
+		return time.Unix(math.MaxInt32, 0)
+	}
+	return *a.CloseTime
+}
+
+// GetServiceLevelObjective implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) GetServiceLevelObjective(ctx context.Context, name string) (monitoring.ServiceLevelObjective, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, slo := range c.slos {
+		if slo.Name == name {
+			return slo, nil
+		}
+	}
+	return monitoring.ServiceLevelObjective{}, status.Errorf(codes.NotFound, "%s does not exist", name)
+}
+
+// policyRecord implements record for alerting policy filters
+type policyRecord monitoring.AlertPolicy
+
+func (p policyRecord) field(name string) (string, bool) {
+	switch name {
+	case "name":
+		return p.Name, true
+	case "display_name":
+		return p.DisplayName, true
+	case "enabled":
+		return fmt.Sprint(p.Enabled), true
+	case "severity":
+		return p.Severity, true
+	}
+	if key, ok := strings.CutPrefix(name, "user_labels."); ok {
+		v, ok := p.UserLabels[key]
+		return v, ok
+	}
+	return "", false
+}
+
+func (p policyRecord) text() []string {
+	return []string{p.DisplayName, p.Documentation}
+}
+
+// ListAlertPolicies implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListAlertPolicies(ctx context.Context, req monitoring.ListAlertPoliciesRequest) ([]monitoring.AlertPolicy, error) {
+	filter, err := parseFilter(req.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert policies: %w", err)
+	}
+	prefix := "projects/" + c.project(req.ProjectID) + "/"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	policies := []monitoring.AlertPolicy{}
+	for _, p := range c.policies {
+		if strings.HasPrefix(p.Name, prefix) && filter.match(policyRecord(p)) {
+			policies = append(policies, p)
+		}
+	}
+	return policies, nil
+}
+
+// ListDashboards implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListDashboards(ctx context.Context, req monitoring.ListDashboardsRequest) ([]monitoring.Dashboard, error) {
+	prefix := "projects/" + c.project(req.ProjectID) + "/"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dashboards := []monitoring.Dashboard{}
+	for _, d := range c.dashboards {
+		if strings.HasPrefix(d.Name, prefix) {
+			dashboards = append(dashboards, d)
+		}
+	}
+	return dashboards, nil
+}
+
+// ApplyAlertPolicy implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ApplyAlertPolicy(ctx context.Context, req monitoring.ApplyRequest) (monitoring.ApplyResult, error) {
+	policy, err := monitoring.ParseAlertPolicy(req.Definition)
+	if err != nil {
+		return monitoring.ApplyResult{}, err
+	}
+	if req.NotificationChannels != nil {
+		policy.NotificationChannels = req.NotificationChannels
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, len(c.policies))
+	for i, p := range c.policies {
+		names[i] = p.Name
+	}
+	result, err := c.resolveApply(req, "alertPolicies", policy.Name, policy.DisplayName, names)
+	if err != nil || req.DryRun {
+		return result, err
+	}
+
+	policy.Name = result.Name
+	for i := range policy.Conditions {
+		policy.Conditions[i].Name = fmt.Sprintf("%s/conditions/%d", policy.Name, i)
+	}
+	if result.Action == monitoring.ApplyActionUpdated {
+		c.policies[slices.Index(names, result.Name)] = policy
+	} else {
+		c.policies = append(c.policies, policy)
+	}
+	return result, nil
+}
+
+// ApplyDashboard implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ApplyDashboard(ctx context.Context, req monitoring.ApplyRequest) (monitoring.ApplyResult, error) {
+	var definition map[string]any
+	if err := json.Unmarshal(req.Definition, &definition); err != nil {
+		return monitoring.ApplyResult{}, fmt.Errorf("invalid dashboard definition: %w", err)
+	}
+	name, _ := definition["name"].(string)
+	displayName, _ := definition["displayName"].(string)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, len(c.dashboards))
+	for i, d := range c.dashboards {
+		names[i] = d.Name
+	}
+	result, err := c.resolveApply(req, "dashboards", name, displayName, names)
+	if err != nil || req.DryRun {
+		return result, err
+	}
+
+	definition["name"] = result.Name
+	delete(definition, "etag")
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return monitoring.ApplyResult{}, err
+	}
+	dashboard := monitoring.Dashboard{Name: result.Name, DisplayName: displayName, Definition: data}
+	if result.Action == monitoring.ApplyActionUpdated {
+		c.dashboards[slices.Index(names, result.Name)] = dashboard
+	} else {
+		c.dashboards = append(c.dashboards, dashboard)
+	}
+	return result, nil
+}
+
+// resolveApply resolves whether applying a definition named name creates or
+// updates a resource among existing, following the modes of
+// monitoring.ApplyRequest. The name of created resources is assigned unless
+// it is a dry run.
+func (c *MonitoringClient) resolveApply(req monitoring.ApplyRequest, collection, name, displayName string, existing []string) (monitoring.ApplyResult, error) {
+	result := monitoring.ApplyResult{DisplayName: displayName, DryRun: req.DryRun}
+	mode := req.Mode
+	if mode == "" {
+		mode = monitoring.ApplyModeAuto
+	}
+	switch mode {
+	case monitoring.ApplyModeAuto, monitoring.ApplyModeUpdate, monitoring.ApplyModeCreate:
+	default:
+		return monitoring.ApplyResult{}, fmt.Errorf("unsupported mode %q: use %q, %q, or %q", mode, monitoring.ApplyModeAuto, monitoring.ApplyModeCreate, monitoring.ApplyModeUpdate)
+	}
+
+	project := "projects/" + c.project(req.ProjectID)
+	if id := name[strings.LastIndex(name, "/")+1:]; mode != monitoring.ApplyModeCreate && id != "" {
+		target := fmt.Sprintf("%s/%s/%s", project, collection, id)
+		if slices.Contains(existing, target) {
+			result.Action = monitoring.ApplyActionUpdated
+			result.Name = target
+			return result, nil
+		}
+		if mode == monitoring.ApplyModeUpdate {
+			return monitoring.ApplyResult{}, fmt.Errorf("%s does not exist", target)
+		}
+	} else if mode == monitoring.ApplyModeUpdate {
+		return monitoring.ApplyResult{}, fmt.Errorf("the definition has no name to update")
+	}
+
+	result.Action = monitoring.ApplyActionCreated
+	if !req.DryRun {
+		c.nextID++
+		result.Name = fmt.Sprintf("%s/%s/fake-%d", project, collection, c.nextID)
+	}
+	return result, nil
+}
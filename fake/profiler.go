@@ -0,0 +1,192 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProfilerClient implements profiler.ProfilerClientInterface in memory.
+// Profiles created with CreateProfile are stored once they are updated with
+// their data, as by Cloud Profiler.
+type ProfilerClient struct {
+	projectID string
+	now       func() time.Time
+
+	mu       sync.Mutex
+	pending  map[string]*profiler.Profile // created but not yet updated
+	profiles []*profiler.Profile
+	nextID   int
+}
+
+// NewProfilerClient creates an empty ProfilerClient for projectID
+func NewProfilerClient(projectID string) *ProfilerClient {
+	return &ProfilerClient{projectID: projectID, now: time.Now, pending: make(map[string]*profiler.Profile)}
+}
+
+func (c *ProfilerClient) project(projectID string) string {
+	if projectID == "" {
+		return c.projectID
+	}
+	return projectID
+}
+
+// newName returns the name of a new profile of a project. The lock must be held.
+func (c *ProfilerClient) newName(projectID string) string {
+	c.nextID++
+	return fmt.Sprintf("projects/%s/profiles/fake-%d", projectID, c.nextID)
+}
+
+// CreateProfile implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) CreateProfile(ctx context.Context, req profiler.CreateProfileRequest) (*profiler.Profile, error) {
+	if req.Deployment == nil || req.Deployment.Target == "" {
+		return nil, status.Error(codes.InvalidArgument, "deployment target is required")
+	}
+	if len(req.ProfileType) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "profile type is required")
+	}
+	projectID := c.project(req.ProjectID)
+	duration := req.Duration
+	if duration == "" {
+		duration = "10s"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	profile := &profiler.Profile{
+		Name:        c.newName(projectID),
+		ProfileType: req.ProfileType[0],
+		Duration:    duration,
+		Labels:      maps.Clone(req.Labels),
+		Deployment: &profiler.Deployment{
+			ProjectID: projectID,
+			Target:    req.Deployment.Target,
+			Labels:    maps.Clone(req.Deployment.Labels),
+		},
+	}
+	c.pending[profile.Name] = profile
+	return cloneProfile(profile), nil
+}
+
+// CreateOfflineProfile implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) CreateOfflineProfile(ctx context.Context, req profiler.CreateOfflineProfileRequest) (*profiler.Profile, error) {
+	if req.Profile == nil {
+		return nil, status.Error(codes.InvalidArgument, "profile is required")
+	}
+	projectID := c.project(req.ProjectID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	profile := cloneProfile(req.Profile)
+	profile.Name = c.newName(projectID)
+	if profile.Deployment == nil {
+		profile.Deployment = &profiler.Deployment{}
+	}
+	profile.Deployment.ProjectID = projectID
+	c.store(profile)
+	return cloneProfile(profile), nil
+}
+
+// UpdateProfile implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) UpdateProfile(ctx context.Context, req profiler.UpdateProfileRequest) (*profiler.Profile, error) {
+	if req.Profile == nil {
+		return nil, status.Error(codes.InvalidArgument, "profile is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	profile, ok := c.pending[req.Profile.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "profile %s does not exist or was already updated", req.Profile.Name)
+	}
+	delete(c.pending, req.Profile.Name)
+
+	updated := cloneProfile(req.Profile)
+	updated.ProfileBytes = req.ProfileBytes
+	if updated.Deployment == nil {
+		updated.Deployment = profile.Deployment
+	}
+	c.store(updated)
+	return cloneProfile(updated), nil
+}
+
+// store stores a collected profile, starting now unless its start time is
+// known. The lock must be held.
+func (c *ProfilerClient) store(profile *profiler.Profile) {
+	if profile.StartTime.IsZero() {
+		profile.StartTime = c.now()
+	}
+	profile.EndTime = profile.StartTime.Add(profile.ParsedDuration())
+	profile.ExpireTime = profile.StartTime.Add(profiler.ProfileRetention)
+	if profile.Deployment != nil {
+		profile.ConsoleURL = profiler.ConsoleURL(profile.Deployment.ProjectID, profile.Deployment.Target, profile.ProfileType)
+	}
+	c.profiles = append(c.profiles, profile)
+}
+
+// ListProfiles implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) ListProfiles(ctx context.Context, req profiler.ListProfilesRequest) (profiler.ListProfilesResponse, error) {
+	profiles := c.projectProfiles(req.ProjectID)
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	profiles, next, err := page(profiles, pageSize, req.PageToken)
+	if err != nil {
+		return profiler.ListProfilesResponse{}, err
+	}
+	return profiler.ListProfilesResponse{Profiles: profiles, NextPageToken: next}, nil
+}
+
+// QueryProfiles implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) QueryProfiles(ctx context.Context, req profiler.QueryProfilesRequest) ([]*profiler.Profile, error) {
+	var profiles []*profiler.Profile
+	for _, p := range c.projectProfiles(req.ProjectID) {
+		if p.Deployment.Target != req.Target || p.ProfileType != req.ProfileType ||
+			p.StartTime.Before(req.StartTime) || !p.StartTime.Before(req.EndTime) {
+			continue
+		}
+		if slices.ContainsFunc(slices.Collect(maps.Keys(req.Labels)), func(k string) bool { return p.Deployment.Labels[k] != req.Labels[k] }) {
+			continue
+		}
+		profiles = append(profiles, p)
+		if req.MaxProfiles > 0 && len(profiles) >= req.MaxProfiles {
+			break
+		}
+	}
+	return profiles, nil
+}
+
+// projectProfiles returns copies of the collected profiles of a project
+func (c *ProfilerClient) projectProfiles(projectID string) []*profiler.Profile {
+	projectID = c.project(projectID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var profiles []*profiler.Profile
+	for _, p := range c.profiles {
+		if p.Deployment != nil && p.Deployment.ProjectID == projectID {
+			profiles = append(profiles, cloneProfile(p))
+		}
+	}
+	return profiles
+}
+
+// cloneProfile copies a profile so that callers cannot modify stored profiles
+func cloneProfile(p *profiler.Profile) *profiler.Profile {
+	clone := *p
+	clone.Labels = maps.Clone(p.Labels)
+	if p.Deployment != nil {
+		deployment := *p.Deployment
+		deployment.Labels = maps.Clone(p.Deployment.Labels)
+		clone.Deployment = &deployment
+	}
+	return &clone
+}
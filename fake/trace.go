@@ -0,0 +1,234 @@
+package fake
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TraceClient implements trace.TraceClientInterface in memory
+type TraceClient struct {
+	projectID string
+
+	mu     sync.Mutex
+	traces []trace.Trace
+}
+
+// NewTraceClient creates an empty TraceClient for projectID
+func NewTraceClient(projectID string) *TraceClient {
+	return &TraceClient{projectID: projectID}
+}
+
+func (c *TraceClient) project(projectID string) string {
+	if projectID == "" {
+		return c.projectID
+	}
+	return projectID
+}
+
+// ListTraces implements trace.TraceClientInterface. Filters support the
+// root:NAME, span:NAME, latency:DURATION, and LABEL:VALUE terms of Cloud
+// Trace, with a leading + for exact matches of names and values.
+func (c *TraceClient) ListTraces(ctx context.Context, req trace.ListTracesRequest) (trace.ListTracesResponse, error) {
+	terms, err := parseTraceFilter(req.Filter)
+	if err != nil {
+		return trace.ListTracesResponse{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	projectID := c.project(req.ProjectID)
+
+	c.mu.Lock()
+	var traces []trace.Trace
+	for _, t := range c.traces {
+		if t.ProjectID != projectID || len(t.Spans) == 0 {
+			continue
+		}
+		start, end := traceBounds(t)
+		if (!req.StartTime.IsZero() && end.Before(req.StartTime)) || (!req.EndTime.IsZero() && start.After(req.EndTime)) {
+			continue
+		}
+		if !slices.ContainsFunc(terms, func(term traceTerm) bool { return !term.match(t) }) {
+			traces = append(traces, cloneTrace(t))
+		}
+	}
+	c.mu.Unlock()
+
+	if err := sortTraces(traces, req.OrderBy); err != nil {
+		return trace.ListTracesResponse{}, err
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	traces, next, err := page(traces, pageSize, req.PageToken)
+	if err != nil {
+		return trace.ListTracesResponse{}, err
+	}
+
+	for i, t := range traces {
+		switch req.View {
+		case trace.ViewComplete:
+		case trace.ViewRootSpan:
+			if root, ok := t.RootSpan(); ok {
+				traces[i].Spans = []trace.Span{root}
+			}
+		default:
+			traces[i].Spans = nil
+		}
+	}
+	return trace.ListTracesResponse{Traces: traces, NextPageToken: next}, nil
+}
+
+// sortTraces sorts traces by start, duration, or root span name, optionally
+// followed by desc. Traces are listed newest first by default.
+func sortTraces(traces []trace.Trace, orderBy string) error {
+	field, direction, _ := strings.Cut(strings.TrimSpace(orderBy), " ")
+	var compare func(a, b trace.Trace) int
+	switch field {
+	case "", "start":
+		compare = func(a, b trace.Trace) int {
+			startA, _ := traceBounds(a)
+			startB, _ := traceBounds(b)
+			return startA.Compare(startB)
+		}
+	case "duration":
+		compare = func(a, b trace.Trace) int { return cmp.Compare(a.Duration(), b.Duration()) }
+	case "name":
+		compare = func(a, b trace.Trace) int {
+			rootA, _ := a.RootSpan()
+			rootB, _ := b.RootSpan()
+			return strings.Compare(rootA.Name, rootB.Name)
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported order_by %q: use start, duration, or name, optionally followed by desc", orderBy)
+	}
+	slices.SortStableFunc(traces, compare)
+	if field == "" || strings.TrimSpace(direction) == "desc" {
+		slices.Reverse(traces)
+	}
+	return nil
+}
+
+// GetTrace implements trace.TraceClientInterface
+func (c *TraceClient) GetTrace(ctx context.Context, req trace.GetTraceRequest) (*trace.Trace, error) {
+	projectID := c.project(req.ProjectID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range c.traces {
+		if t.ProjectID == projectID && t.TraceID == req.TraceID {
+			t = cloneTrace(t)
+			return &t, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "trace %s does not exist", req.TraceID)
+}
+
+// PatchTraces implements trace.TraceClientInterface. Spans replace the spans
+// of the trace with the same IDs, and the other spans are added.
+func (c *TraceClient) PatchTraces(ctx context.Context, req trace.PatchTraceRequest) error {
+	if req.TraceID == "" {
+		return status.Error(codes.InvalidArgument, "trace ID is required")
+	}
+	projectID := c.project(req.ProjectID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.IndexFunc(c.traces, func(t trace.Trace) bool { return t.ProjectID == projectID && t.TraceID == req.TraceID })
+	if i < 0 {
+		c.traces = append(c.traces, trace.Trace{TraceID: req.TraceID, ProjectID: projectID})
+		i = len(c.traces) - 1
+	}
+	for _, span := range req.Spans {
+		j := slices.IndexFunc(c.traces[i].Spans, func(s trace.Span) bool { return s.SpanID == span.SpanID })
+		if j < 0 {
+			c.traces[i].Spans = append(c.traces[i].Spans, span)
+		} else {
+			c.traces[i].Spans[j] = span
+		}
+	}
+	return nil
+}
+
+// cloneTrace copies a trace so that callers cannot modify the stored spans
+func cloneTrace(t trace.Trace) trace.Trace {
+	t.Spans = slices.Clone(t.Spans)
+	return t
+}
+
+// traceBounds returns the earliest start and latest end of the spans of a trace
+func traceBounds(t trace.Trace) (start, end time.Time) {
+	for i, span := range t.Spans {
+		if i == 0 || span.StartTime.Before(start) {
+			start = span.StartTime
+		}
+		if i == 0 || span.EndTime.After(end) {
+			end = span.EndTime
+		}
+	}
+	return start, end
+}
+
+// traceTerm is a term of a Cloud Trace filter
+type traceTerm struct {
+	key   string // root, span, latency, or a label key
+	value string
+	exact bool
+	// latency is the minimum latency of latency terms
+	latency time.Duration
+}
+
+// parseTraceFilter parses a Cloud Trace filter into its terms, all of which
+// must match
+func parseTraceFilter(filter string) ([]traceTerm, error) {
+	var terms []traceTerm
+	for _, field := range strings.Fields(filter) {
+		term := traceTerm{}
+		field, term.exact = strings.CutPrefix(field, "+")
+		key, value, ok := strings.Cut(field, ":")
+		if !ok {
+			// A bare name matches root spans whose name starts with it
+			key, value = "root", field
+		}
+		term.key, term.value = key, value
+		if key == "latency" {
+			latency, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid latency %q in filter: %w", value, err)
+			}
+			term.latency = latency
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+func (t traceTerm) match(tr trace.Trace) bool {
+	matchValue := func(v string) bool {
+		if t.exact {
+			return v == t.value
+		}
+		return strings.HasPrefix(v, t.value)
+	}
+
+	switch t.key {
+	case "root":
+		root, ok := tr.RootSpan()
+		return ok && matchValue(root.Name)
+	case "span":
+		return slices.ContainsFunc(tr.Spans, func(s trace.Span) bool { return matchValue(s.Name) })
+	case "latency":
+		return tr.Duration() >= t.latency
+	}
+	return slices.ContainsFunc(tr.Spans, func(s trace.Span) bool {
+		v, ok := s.Labels[t.key]
+		return ok && matchValue(v)
+	})
+}
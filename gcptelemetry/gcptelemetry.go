@@ -0,0 +1,551 @@
+// Package gcptelemetry lets Go MCP hosts serve the Cloud Logging, Cloud
+// Monitoring, Cloud Trace, and Cloud Profiler tools, either with a server
+// created by NewServer or by registering the tools on their own server.
+package gcptelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/apiusage"
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+	"github.com/kitagry/gcp-telemetry-mcp/calllimit"
+	"github.com/kitagry/gcp-telemetry-mcp/credentials"
+	"github.com/kitagry/gcp-telemetry-mcp/handlers"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/middleware"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/notifications"
+	"github.com/kitagry/gcp-telemetry-mcp/policyerror"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/kitagry/gcp-telemetry-mcp/replay"
+	"github.com/kitagry/gcp-telemetry-mcp/savedquery"
+	"github.com/kitagry/gcp-telemetry-mcp/schedule"
+	"github.com/kitagry/gcp-telemetry-mcp/selftelemetry"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"github.com/kitagry/gcp-telemetry-mcp/watch"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// DefaultName is the name of the servers created by NewServer without Config.Name
+const DefaultName = "GCP Telemetry MCP"
+
+// Config configures the tools and the clients they call
+type Config struct {
+	// ProjectID is the default Google Cloud project. Required.
+	ProjectID string
+	// ClientOptions, e.g. credentials, are used by all the Google Cloud clients
+	ClientOptions []option.ClientOption
+	// ReadOnly leaves out the tools that write to Google Cloud, and requests
+	// read-only OAuth scopes
+	ReadOnly bool
+	// DisabledTools are the names of the tools left out
+	DisabledTools []string
+
+	// SavedQueries is where saved queries are stored, a file path or a
+	// gs://BUCKET/OBJECT location. Defaults to savedquery.DefaultLocation().
+	SavedQueries string
+	// AlertSubscription is a Pub/Sub subscription ID or full name receiving
+	// alert notifications, which are pushed to the clients. Optional.
+	AlertSubscription string
+	// WebhookHosts, when set, are the only hosts watches can post their
+	// events to, e.g. "hooks.slack.com". Any https URL is accepted otherwise.
+	WebhookHosts []string
+	// SourceMappings are the default source mappings of profile hotspots
+	SourceMappings []profiler.SourceMapping
+	// WriteLabels are attached to the telemetry written by tools, see
+	// session.ParseWriteLabels. When nil, the labels of
+	// session.DefaultWriteLabels are attached; set an empty map to attach none.
+	WriteLabels map[string]string
+	// DLPInfoTypes are scanned for by list_log_entries with scan_and_redact.
+	// Defaults to redact.DefaultInfoTypes.
+	DLPInfoTypes []string
+	// Redactor redacts the log entries and traces returned to clients when set
+	Redactor *redact.Redactor
+	// TimeZone is the time zone the timestamps of tool results are shown in,
+	// unless a session sets its own with set_session_defaults. When nil, they
+	// are left in UTC.
+	TimeZone *time.Location
+	// MaxResultBytes, when positive, is the size over which the text of tool
+	// results is moved into embedded resources in LargeResultFormat, see
+	// middleware.LargeResults. LargeResultFormat defaults to
+	// middleware.LargeResultResource.
+	MaxResultBytes    int
+	LargeResultFormat middleware.LargeResultFormat
+	// CacheTTL, when positive, is how long the results of the tools that only
+	// read are reused for identical calls, see middleware.Cache
+	CacheTTL time.Duration
+	// MaxConcurrentCalls limits the Google Cloud calls in flight at the same
+	// time across all the tools; calls over the limit wait. Defaults to
+	// calllimit.DefaultMax, and a negative value removes the limit.
+	MaxConcurrentCalls int
+	// Middleware is applied to all the tools after the session defaults are
+	// made available, the first middleware outermost, e.g. the middleware of
+	// the middleware package
+	Middleware []server.ToolHandlerMiddleware
+	// TracerProvider and MeterProvider, when set, trace and measure the tool
+	// calls and the Google Cloud calls they make, see the selftelemetry
+	// package. Either may be left nil.
+	TracerProvider oteltrace.TracerProvider
+	MeterProvider  otelmetric.MeterProvider
+	// ExportSelfTelemetry, when TracerProvider and MeterProvider are not
+	// set, traces and measures the tool calls and exports them to Cloud
+	// Trace and Cloud Monitoring in ProjectID. Tools.Shutdown exports the
+	// telemetry recorded last.
+	ExportSelfTelemetry bool
+	// ScheduledJobs adds schedule_job, list_jobs, and cancel_job, running
+	// tools on a schedule for as long as the server runs, e.g. for servers
+	// served over HTTP. Tools.Shutdown cancels the jobs.
+	ScheduledJobs bool
+
+	// LoggingAPI, MonitoringAPI, TraceAPI, ProfilerAPI, DLPAPI, and
+	// AssetAPI replace the clients calling Google Cloud when set, e.g. with
+	// the in-memory clients of the fake package
+	LoggingAPI    logging.LoggingClientInterface
+	MonitoringAPI monitoring.MonitoringClientInterface
+	TraceAPI      trace.TraceClientInterface
+	ProfilerAPI   profiler.ProfilerClientInterface
+	DLPAPI        redact.DLPClientInterface
+	AssetAPI      asset.AssetClientInterface
+	// Recorder records the API calls and their responses when set
+	Recorder *replay.Cassette
+
+	// Name and Version identify the servers created by NewServer. Name
+	// defaults to DefaultName, and Version to "dev".
+	Name    string
+	Version string
+}
+
+// Tools are the tools and the clients they call. They are registered on one server.
+type Tools struct {
+	config        Config
+	logging       logging.LoggingClient
+	monitoring    monitoring.MonitoringClient
+	trace         trace.TraceClient
+	profiler      profiler.ProfilerClient
+	dlpScanner    *redact.DLPScanner
+	assets        asset.AssetClient
+	queries       savedquery.Store
+	subscriber    *notifications.Subscriber
+	sessions      *session.Store
+	apiUsage      *apiusage.Tracker
+	selfTelemetry *selftelemetry.Instrumentation
+	shutdown      []func(context.Context) error // flush the exported self telemetry
+	watches       *watch.Manager
+	scheduler     *schedule.Scheduler // nil unless Config.ScheduledJobs
+	writeLabels   map[string]string
+	server        atomic.Pointer[server.MCPServer] // the server the tools are registered on
+}
+
+// New creates the clients of the tools. ctx bounds the receiving of alert
+// notifications from Config.AlertSubscription.
+func New(ctx context.Context, cfg Config) (*Tools, error) {
+	if cfg.ProjectID == "" {
+		return nil, fmt.Errorf("project ID is required")
+	}
+	for _, name := range cfg.DisabledTools {
+		if !slices.ContainsFunc(handlers.Tools(handlers.Deps{}), func(tool handlers.Tool) bool { return tool.Definition.Name == name }) {
+			return nil, fmt.Errorf("unknown tool %q in disabled tools", name)
+		}
+	}
+
+	// Explain the calls rejected by VPC Service Controls or organization
+	// policies in their errors
+	cfg.ClientOptions = append(slices.Clone(cfg.ClientOptions), policyerror.ClientOptions()...)
+
+	// Export the telemetry of the server itself with clients of their own,
+	// so that the calls exporting it are not recorded in turn
+	tracerProvider, meterProvider := cfg.TracerProvider, cfg.MeterProvider
+	var shutdown []func(context.Context) error
+	if cfg.ExportSelfTelemetry && tracerProvider == nil && meterProvider == nil {
+		if cfg.ReadOnly {
+			return nil, fmt.Errorf("self telemetry cannot be exported in read-only mode")
+		}
+		traceAPI := cfg.TraceAPI
+		if traceAPI == nil {
+			var err error
+			traceAPI, err = trace.NewAPIClient(cfg.ProjectID, scopedClientOptions(cfg.ClientOptions, credentials.ServiceTrace, false)...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create self telemetry trace client: %w", err)
+			}
+		}
+		monitoringAPI := cfg.MonitoringAPI
+		if monitoringAPI == nil {
+			var err error
+			monitoringAPI, err = monitoring.NewAPIClient(cfg.ProjectID, scopedClientOptions(cfg.ClientOptions, credentials.ServiceMonitoring, false)...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create self telemetry monitoring client: %w", err)
+			}
+		}
+		tp, mp := selftelemetry.NewProviders(trace.NewWithClient(traceAPI, cfg.ProjectID), monitoring.NewWithClient(monitoringAPI, cfg.ProjectID))
+		tracerProvider, meterProvider = tp, mp
+		shutdown = []func(context.Context) error{tp.Shutdown, mp.Shutdown}
+	}
+
+	// Count the calls of each session, and limit the calls in flight of all
+	// the clients together. The clients of REST APIs ignore the gRPC
+	// interceptors, and have their transports wrapped instead.
+	apiUsage := apiusage.NewTracker()
+	cfg.ClientOptions = append(cfg.ClientOptions, apiUsage.ClientOptions()...)
+	transports := []func(http.RoundTripper) http.RoundTripper{apiUsage.Transport}
+	maxCalls := cfg.MaxConcurrentCalls
+	if maxCalls == 0 {
+		maxCalls = calllimit.DefaultMax
+	}
+	if maxCalls > 0 {
+		limiter := calllimit.New(maxCalls)
+		cfg.ClientOptions = append(cfg.ClientOptions, limiter.ClientOptions()...)
+		transports = append(transports, limiter.Transport)
+	}
+	var selfTelemetry *selftelemetry.Instrumentation
+	if tracerProvider != nil || meterProvider != nil {
+		if tracerProvider == nil {
+			tracerProvider = tracenoop.NewTracerProvider()
+		}
+		if meterProvider == nil {
+			meterProvider = metricnoop.NewMeterProvider()
+		}
+		var err error
+		selfTelemetry, err = selftelemetry.New(tracerProvider, meterProvider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create self telemetry: %w", err)
+		}
+		cfg.ClientOptions = append(cfg.ClientOptions, selfTelemetry.ClientOptions()...)
+		transports = append(transports, selfTelemetry.Transport)
+	}
+
+	var err error
+	loggingAPI := cfg.LoggingAPI
+	if loggingAPI == nil {
+		loggingAPI, err = logging.NewAPIClient(cfg.ProjectID, scopedClientOptions(cfg.ClientOptions, credentials.ServiceLogging, cfg.ReadOnly)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create logging client: %w", err)
+		}
+	}
+	monitoringAPI := cfg.MonitoringAPI
+	if monitoringAPI == nil {
+		monitoringAPI, err = monitoring.NewAPIClient(cfg.ProjectID, scopedClientOptions(cfg.ClientOptions, credentials.ServiceMonitoring, cfg.ReadOnly)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create monitoring client: %w", err)
+		}
+	}
+	traceAPI := cfg.TraceAPI
+	if traceAPI == nil {
+		traceAPI, err = trace.NewAPIClient(cfg.ProjectID, scopedClientOptions(cfg.ClientOptions, credentials.ServiceTrace, cfg.ReadOnly)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trace client: %w", err)
+		}
+	}
+	profilerAPI := cfg.ProfilerAPI
+	if profilerAPI == nil {
+		opts, err := restClientOptions(transports, scopedClientOptions(cfg.ClientOptions, credentials.ServiceProfiler, cfg.ReadOnly))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create profiler client: %w", err)
+		}
+		profilerAPI, err = profiler.NewAPIClient(cfg.ProjectID, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create profiler client: %w", err)
+		}
+	}
+	// The DLP and Cloud Asset clients are only created for the tools using
+	// them
+	dlpAPI := cfg.DLPAPI
+	if dlpAPI == nil && !slices.Contains(cfg.DisabledTools, "list_log_entries") {
+		opts, err := restClientOptions(transports, scopedClientOptions(cfg.ClientOptions, credentials.ServiceDLP, cfg.ReadOnly))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DLP client: %w", err)
+		}
+		dlpAPI, err = redact.NewDLPAPIClient(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DLP client: %w", err)
+		}
+	}
+	assetAPI := cfg.AssetAPI
+	if assetAPI == nil && !slices.Contains(cfg.DisabledTools, "list_assets") {
+		opts, err := restClientOptions(transports, scopedClientOptions(cfg.ClientOptions, credentials.ServiceAsset, cfg.ReadOnly))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Cloud Asset client: %w", err)
+		}
+		assetAPI, err = asset.NewAPIClient(opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Cloud Asset client: %w", err)
+		}
+	}
+
+	// Record the API calls and their responses when configured
+	if cfg.Recorder != nil {
+		loggingAPI = replay.NewLoggingClient(cfg.Recorder, loggingAPI)
+		monitoringAPI = replay.NewMonitoringClient(cfg.Recorder, monitoringAPI)
+		traceAPI = replay.NewTraceClient(cfg.Recorder, traceAPI)
+		profilerAPI = replay.NewProfilerClient(cfg.Recorder, profilerAPI)
+		if dlpAPI != nil {
+			dlpAPI = replay.NewDLPClient(cfg.Recorder, dlpAPI)
+		}
+		if assetAPI != nil {
+			assetAPI = replay.NewAssetClient(cfg.Recorder, assetAPI)
+		}
+	}
+
+	t := &Tools{
+		config:        cfg,
+		logging:       logging.NewWithClient(loggingAPI),
+		monitoring:    monitoring.NewWithClient(monitoringAPI, cfg.ProjectID),
+		trace:         trace.NewWithClient(traceAPI, cfg.ProjectID),
+		profiler:      profiler.NewWithClient(profilerAPI, cfg.ProjectID),
+		sessions:      session.NewStore(),
+		apiUsage:      apiUsage,
+		selfTelemetry: selfTelemetry,
+		shutdown:      shutdown,
+	}
+	if dlpAPI != nil {
+		t.dlpScanner = redact.NewDLPScannerWithClient(dlpAPI, cfg.ProjectID, cfg.DLPInfoTypes)
+	}
+	if assetAPI != nil {
+		t.assets = asset.NewWithClient(assetAPI, cfg.ProjectID)
+	}
+
+	// Redact sensitive data from the log entries and traces returned to clients when configured
+	if cfg.Redactor != nil {
+		t.logging = redact.NewLoggingClient(t.logging, cfg.Redactor)
+		t.trace = redact.NewTraceClient(t.trace, cfg.Redactor)
+	}
+
+	t.writeLabels = cfg.WriteLabels
+	if t.writeLabels == nil {
+		t.writeLabels, err = session.ParseWriteLabels(session.DefaultWriteLabels)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create saved query store
+	location := cfg.SavedQueries
+	if location == "" {
+		location, err = savedquery.DefaultLocation()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine saved query location: %w", err)
+		}
+	}
+	t.queries, err = savedquery.NewStore(ctx, location, scopedClientOptions(cfg.ClientOptions, credentials.ServiceStorage, cfg.ReadOnly)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create saved query store: %w", err)
+	}
+
+	// Push watch events to the session that registered the watch, and to
+	// the webhook of the watch if any
+	t.watches = watch.NewManager(func(sessionID string, event watch.Event) {
+		if s := t.server.Load(); s != nil {
+			_ = s.SendNotificationToSpecificClient(sessionID, "notifications/message", map[string]any{
+				"level":  event.Level,
+				"logger": "watch",
+				"data":   event,
+			})
+		}
+	}, watch.NewWebhooks(nil, cfg.WebhookHosts))
+
+	if cfg.ScheduledJobs {
+		t.scheduler = schedule.NewScheduler()
+	}
+
+	// Push alert notifications to all clients when a Pub/Sub subscription is configured
+	if cfg.AlertSubscription != "" {
+		t.subscriber, err = notifications.NewSubscriber(ctx, cfg.ProjectID, cfg.AlertSubscription, cfg.ClientOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create alert notification subscriber: %w", err)
+		}
+		t.subscriber.OnNotification(func(n notifications.Notification) {
+			if s := t.server.Load(); s != nil {
+				s.SendNotificationToAllClients("notifications/message", map[string]any{
+					"level":  notificationLevel(n),
+					"logger": "cloud-monitoring-alerts",
+					"data":   n,
+				})
+			}
+		})
+		go t.subscriber.Run(ctx)
+	}
+	return t, nil
+}
+
+// ServerOptions returns the options of the server the tools are registered
+// on: tool capabilities, logging to push watch events and alert
+// notifications, the hooks of AddHooks, and the middleware making the
+// session defaults available to the tools
+func (t *Tools) ServerOptions() []server.ServerOption {
+	hooks := &server.Hooks{}
+	t.AddHooks(hooks)
+	return []server.ServerOption{
+		server.WithToolCapabilities(true),
+		server.WithLogging(),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(t.Middleware()),
+	}
+}
+
+// Middleware returns the tool handler middleware tracing the tool calls
+// when configured, making the session defaults and write labels available
+// to the tools, moving large results into resources, and converting the
+// timestamps of results to the time zone of the session, followed by
+// Config.Middleware and the cache of results
+func (t *Tools) Middleware() server.ToolHandlerMiddleware {
+	var chain []server.ToolHandlerMiddleware
+	if t.selfTelemetry != nil {
+		// Outermost, so that the spans cover the other middleware
+		chain = append(chain, t.selfTelemetry.Middleware())
+	}
+	chain = append(chain, handlers.SessionDefaultsMiddleware(t.sessions, t.writeLabels))
+	if t.config.MaxResultBytes > 0 {
+		format := t.config.LargeResultFormat
+		if format == "" {
+			format = middleware.LargeResultResource
+		}
+		// Outside of the other middleware, so that the results are moved
+		// once they are final
+		chain = append(chain, middleware.LargeResults(t.config.MaxResultBytes, format))
+	}
+	chain = append(chain, handlers.TimeZoneMiddleware(t.config.TimeZone))
+	chain = append(chain, t.config.Middleware...)
+	if t.config.CacheTTL > 0 {
+		// Inside of the other middleware, so that cached calls are logged,
+		// authorized, and limited like the others
+		cacheable := make(map[string]bool)
+		cursorPaged := make(map[string]bool)
+		for _, tool := range handlers.Tools(handlers.Deps{}) {
+			cacheable[tool.Definition.Name] = tool.Cacheable
+			cursorPaged[tool.Definition.Name] = tool.CursorPaged
+		}
+		chain = append(chain, middleware.NewCache(t.config.CacheTTL,
+			func(tool string) bool { return cacheable[tool] },
+			func(tool string) bool { return cursorPaged[tool] },
+		).Middleware())
+	}
+	return middleware.Chain(chain...)
+}
+
+// AddHooks adds the hooks dropping the session defaults and API usage and
+// stopping the watches of a session when it ends, for servers with their own hooks
+func (t *Tools) AddHooks(hooks *server.Hooks) {
+	hooks.AddOnUnregisterSession(func(ctx context.Context, clientSession server.ClientSession) {
+		t.sessions.Delete(clientSession.SessionID())
+		t.apiUsage.Delete(clientSession.SessionID())
+		t.watches.StopSession(clientSession.SessionID())
+	})
+}
+
+// Register adds the tools to s. s must be created with ServerOptions, or
+// with the tool capabilities, the Middleware, and hooks passed to AddHooks.
+func (t *Tools) Register(s *server.MCPServer) {
+	t.server.Store(s)
+	handlers.RegisterTools(s, handlers.Deps{
+		Logging:        t.logging,
+		Monitoring:     t.monitoring,
+		Trace:          t.trace,
+		Profiler:       t.profiler,
+		DLPScanner:     t.dlpScanner,
+		Assets:         t.assets,
+		SavedQueries:   t.queries,
+		Sessions:       t.sessions,
+		APIUsage:       t.apiUsage,
+		Watches:        t.watches,
+		Scheduler:      t.scheduler,
+		Subscriber:     t.subscriber,
+		ProjectID:      t.config.ProjectID,
+		SourceMappings: t.config.SourceMappings,
+		ReadOnly:       t.config.ReadOnly,
+		DisabledTools:  t.config.DisabledTools,
+	})
+}
+
+// Shutdown cancels the scheduled jobs, and exports the self telemetry
+// recorded last, when Config.ExportSelfTelemetry is set. The tool calls are
+// no longer traced or measured afterwards.
+func (t *Tools) Shutdown(ctx context.Context) error {
+	if t.scheduler != nil {
+		t.scheduler.Stop()
+	}
+	var errs []error
+	for _, shutdown := range t.shutdown {
+		errs = append(errs, shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// LoggingClient returns the Cloud Logging client of the tools
+func (t *Tools) LoggingClient() logging.LoggingClient {
+	return t.logging
+}
+
+// TraceClient returns the Cloud Trace client of the tools
+func (t *Tools) TraceClient() trace.TraceClient {
+	return t.trace
+}
+
+// NewServer creates an MCP server serving the tools. opts are applied after
+// the options of the tools, e.g. to add tool handler middleware.
+func NewServer(ctx context.Context, cfg Config, opts ...server.ServerOption) (*server.MCPServer, *Tools, error) {
+	tools, err := New(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = DefaultName
+	}
+	version := cfg.Version
+	if version == "" {
+		version = "dev"
+	}
+	s := server.NewMCPServer(name, version, append(tools.ServerOptions(), opts...)...)
+	tools.Register(s)
+	return s, tools, nil
+}
+
+// notificationLevel returns the MCP log level for an alert notification:
+// opened incidents are warnings, or errors when the policy severity is
+// critical or error, and closed incidents are informational
+func notificationLevel(n notifications.Notification) mcp.LoggingLevel {
+	if n.State == notifications.StateClosed {
+		return mcp.LoggingLevelInfo
+	}
+	switch strings.ToUpper(n.Severity) {
+	case "CRITICAL", "ERROR":
+		return mcp.LoggingLevelError
+	}
+	return mcp.LoggingLevelWarning
+}
+
+// restClientOptions returns opts with an HTTP client whose transport is
+// wrapped by transports, the first outermost, for the clients of REST APIs.
+// opts must set the scopes of the client.
+func restClientOptions(transports []func(http.RoundTripper) http.RoundTripper, opts []option.ClientOption) ([]option.ClientOption, error) {
+	httpClient, _, err := htransport.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(transports) - 1; i >= 0; i-- {
+		httpClient.Transport = transports[i](httpClient.Transport)
+	}
+	return append(slices.Clone(opts), option.WithHTTPClient(httpClient)), nil
+}
+
+// scopedClientOptions returns the client options of a service with the
+// narrowest OAuth scopes its registered tools need
+func scopedClientOptions(opts []option.ClientOption, service string, readOnly bool) []option.ClientOption {
+	return append(slices.Clone(opts), option.WithScopes(credentials.Scopes(service, readOnly)...))
+}
@@ -0,0 +1,216 @@
+package gcptelemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/gcptelemetry"
+	"github.com/kitagry/gcp-telemetry-mcp/middleware"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// call sends a JSON-RPC request to s and decodes the result into v
+func call(t *testing.T, s *server.MCPServer, method string, params any, v any) {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, ok := s.HandleMessage(context.Background(), data).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("%s failed", method)
+	}
+	result, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(result, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeConfig returns a config serving the telemetry of an empty fake backend
+func fakeConfig(t *testing.T) gcptelemetry.Config {
+	backend := fake.New(fake.DefaultProjectID)
+	return gcptelemetry.Config{
+		ProjectID:     fake.DefaultProjectID,
+		SavedQueries:  filepath.Join(t.TempDir(), "saved_queries.json"),
+		LoggingAPI:    backend.Logging,
+		MonitoringAPI: backend.Monitoring,
+		TraceAPI:      backend.Trace,
+		ProfilerAPI:   backend.Profiler,
+		DLPAPI:        backend.DLP,
+		AssetAPI:      backend.Assets,
+	}
+}
+
+func TestNew_ProjectIDRequired(t *testing.T) {
+	cfg := fakeConfig(t)
+	cfg.ProjectID = ""
+	if _, err := gcptelemetry.New(context.Background(), cfg); err == nil {
+		t.Error("Expected an error without a project ID")
+	}
+}
+
+func TestNew_UnknownDisabledTool(t *testing.T) {
+	cfg := fakeConfig(t)
+	cfg.DisabledTools = []string{"list_log_entry"}
+	if _, err := gcptelemetry.New(context.Background(), cfg); err == nil {
+		t.Error("Expected an error for an unknown tool")
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	cfg := fakeConfig(t)
+	cfg.ReadOnly = true
+
+	var called []string
+	middleware := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			called = append(called, req.Params.Name)
+			return next(ctx, req)
+		}
+	}
+	s, _, err := gcptelemetry.NewServer(context.Background(), cfg, server.WithToolHandlerMiddleware(middleware))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	var tools mcp.ListToolsResult
+	call(t, s, "tools/list", nil, &tools)
+	registered := make(map[string]bool)
+	for _, tool := range tools.Tools {
+		registered[tool.Name] = true
+	}
+	if !registered["list_log_entries"] {
+		t.Error("Expected list_log_entries to be registered")
+	}
+	if registered["write_log_entry"] {
+		t.Error("Expected write_log_entry not to be registered with ReadOnly")
+	}
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{
+		"name":      "list_log_entries",
+		"arguments": map[string]any{"min_severity": "ERROR"},
+	}, &result)
+	if result.IsError || len(result.Content) == 0 {
+		t.Fatal("Expected list_log_entries to succeed")
+	}
+	// The console links use the configured project, not GOOGLE_CLOUD_PROJECT
+	if !strings.Contains(result.Content[0].Text, "project="+fake.DefaultProjectID) {
+		t.Errorf("Expected a console URL of the configured project, got %s", result.Content[0].Text)
+	}
+	if len(called) != 1 || called[0] != "list_log_entries" {
+		t.Errorf("Expected the custom middleware to be called for list_log_entries, got %v", called)
+	}
+}
+
+func TestTools_Register(t *testing.T) {
+	tools, err := gcptelemetry.New(context.Background(), fakeConfig(t))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	hooks := &server.Hooks{}
+	tools.AddHooks(hooks)
+	s := server.NewMCPServer("host", "1.0.0", server.WithToolCapabilities(true), server.WithHooks(hooks), server.WithToolHandlerMiddleware(tools.Middleware()))
+	tools.Register(s)
+
+	var result mcp.ListToolsResult
+	call(t, s, "tools/list", nil, &result)
+	found := false
+	for _, tool := range result.Tools {
+		if tool.Name == "write_log_entry" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected write_log_entry to be registered on the host server")
+	}
+}
+
+func TestConfig_Middleware(t *testing.T) {
+	cfg := fakeConfig(t)
+	metrics := middleware.NewMetrics()
+	cfg.Middleware = []server.ToolHandlerMiddleware{metrics.Middleware()}
+	s, _, err := gcptelemetry.NewServer(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{"name": "list_traces", "arguments": map[string]any{}}, &result)
+	if stats := metrics.Stats()["list_traces"]; stats.Calls != 1 {
+		t.Errorf("Expected the call to be counted, got %+v", stats)
+	}
+}
+
+func TestConfig_ExportSelfTelemetry(t *testing.T) {
+	cfg := fakeConfig(t)
+	cfg.ExportSelfTelemetry = true
+	s, tools, err := gcptelemetry.NewServer(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{"name": "list_traces", "arguments": map[string]any{}}, &result)
+	if err := tools.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	traces, err := cfg.TraceAPI.ListTraces(context.Background(), trace.ListTracesRequest{Filter: "root:tools/call", View: trace.ViewRootSpan})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces.Traces) != 1 || traces.Traces[0].Spans[0].Name != "tools/call list_traces" {
+		t.Errorf("Expected the tool call to be exported to Cloud Trace, got %+v", traces.Traces)
+	}
+
+	cfg.ReadOnly = true
+	if _, err := gcptelemetry.New(context.Background(), cfg); err == nil {
+		t.Error("Expected an error exporting self telemetry in read-only mode")
+	}
+}
+
+func TestConfig_ScheduledJobs(t *testing.T) {
+	cfg := fakeConfig(t)
+	cfg.ScheduledJobs = true
+	s, tools, err := gcptelemetry.NewServer(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{"name": "schedule_job", "arguments": map[string]any{
+		"tool":      "list_traces",
+		"arguments": map[string]any{"start_time": "{{now-1h}}", "end_time": "{{now}}"},
+	}}, &result)
+	if result.IsError {
+		t.Fatalf("schedule_job failed: %+v", result.Content)
+	}
+	if err := tools.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	call(t, s, "tools/call", map[string]any{"name": "list_jobs", "arguments": map[string]any{}}, &result)
+	if result.IsError || result.Content[0].Text != "[]" {
+		t.Errorf("Expected the jobs to be canceled on shutdown, got %+v", result.Content)
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/agent"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// checkAgentHealthArgs are the arguments of check_agent_health
+type checkAgentHealthArgs struct {
+	Instance   string    `json:"instance" validate:"required"`
+	EndTime    time.Time `json:"end_time"`
+	Lookback   duration  `json:"lookback"`
+	StaleAfter duration  `json:"stale_after"`
+}
+
+// createCheckAgentHealthHandler creates a handler for checking the Ops Agent of a VM
+func createCheckAgentHealthHandler(checker *agent.Checker) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[checkAgentHealthArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		report, err := checker.Check(ctx, agent.Request{
+			ProjectID:  session.FromContext(ctx).ProjectID,
+			Instance:   args.Instance,
+			EndTime:    args.EndTime,
+			Lookback:   time.Duration(args.Lookback),
+			StaleAfter: time.Duration(args.StaleAfter),
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to check agent health: %v", err)), nil
+		}
+
+		// Convert report to JSON for response
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal agent health: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(reportJSON)), nil
+	}
+}
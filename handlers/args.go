@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DecodeArgs decodes the arguments of a tool call into the fields of a T
+// named by their json tags, and validates them with their validate tags.
+// Arguments of the wrong type and unknown arguments are errors rather than
+// being ignored, except that empty strings leave non-string fields unset.
+// time.Time fields accept the timestamps of ParseTime. The fields of
+// embedded structs are decoded as fields of T.
+// The validate tags are comma-separated rules:
+//
+//   - required: the argument must be given and not be empty
+//   - oneof=A B C: a string argument, when given, must be one of the values
+//   - min=N, max=N: a number argument, when given, must be within the bounds,
+//     or an array argument must have within N items. A given 0 is checked
+//     like any other number.
+func DecodeArgs[T any](request mcp.CallToolRequest) (T, error) {
+	return decodeArgs[T](request.GetArguments())
+}
+
+// decodeArgs is DecodeArgs for an object argument, e.g. an item of an array
+// of objects
+func decodeArgs[T any](args map[string]any) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if rv.Kind() != reflect.Struct {
+		return v, fmt.Errorf("arguments must be decoded into a struct, not %s", rv.Type())
+	}
+
+	known := make(map[string]bool)
+	for _, field := range reflect.VisibleFields(rv.Type()) {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		known[name] = true
+		fv := rv.FieldByIndex(field.Index)
+
+		arg, ok := args[name]
+		given := ok && arg != nil && (arg != "" || field.Type.Kind() == reflect.String)
+		switch {
+		case !given:
+		case field.Type == reflect.TypeFor[time.Time]():
+			t, err := decodeTime(name, arg)
+			if err != nil {
+				return v, err
+			}
+			fv.Set(reflect.ValueOf(t))
+		default:
+			data, err := json.Marshal(arg)
+			if err != nil {
+				return v, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			if err := json.Unmarshal(data, fv.Addr().Interface()); err != nil {
+				return v, argError(name, field.Type, arg, err)
+			}
+		}
+		if err := validateArg(name, fv, field.Tag.Get("validate"), given); err != nil {
+			return v, err
+		}
+	}
+
+	var unknown []string
+	for name := range args {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return v, fmt.Errorf("unknown arguments: %s", strings.Join(unknown, ", "))
+	}
+	return v, nil
+}
+
+// argError describes an error decoding the argument name with the value arg
+// into a value of type t, quoting the value so that the caller can correct it
+func argError(name string, t reflect.Type, arg any, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field != "" {
+			return fmt.Errorf("%s.%s must be %s, got %s", name, typeErr.Field, jsonType(typeErr.Type), typeErr.Value)
+		}
+		return fmt.Errorf("%s must be %s, got %s", name, jsonType(t), rawValue(arg))
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeFor[duration]() || t == reflect.TypeFor[number]() {
+		return fmt.Errorf("invalid %s %s: must be %s", name, rawValue(arg), jsonType(t))
+	}
+	return fmt.Errorf("invalid %s: %w", name, err)
+}
+
+// decodeTime decodes the timestamp argument name with ParseTime, from a string
+// or from a number of Unix epoch seconds or milliseconds
+func decodeTime(name string, arg any) (time.Time, error) {
+	switch v := arg.(type) {
+	case string:
+		return parseTimeArg(name, v)
+	case float64:
+		return parseTimeArg(name, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return time.Time{}, fmt.Errorf("%s must be %s, got %s", name, timeFormats, rawValue(arg))
+}
+
+// jsonType describes the JSON type decoded into values of type t
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeFor[time.Time]():
+		return timeFormats
+	case t == reflect.TypeFor[duration]():
+		return fmt.Sprintf("a duration with a unit, e.g. %q", durationExample)
+	case t == reflect.TypeFor[number]():
+		return "a number, e.g. 42.5"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// validateArg checks the decoded argument name against the rules of its
+// validate tag. The bounds only apply to arguments that were given.
+func validateArg(name string, v reflect.Value, rules string, given bool) error {
+	if rules == "" {
+		return nil
+	}
+	for rule := range strings.SplitSeq(rules, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			if v.IsZero() || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0) {
+				return fmt.Errorf("%s is required", name)
+			}
+		case "oneof":
+			allowed := strings.Fields(value)
+			if s := v.String(); v.Kind() == reflect.String && s != "" && !slices.Contains(allowed, s) {
+				return fmt.Errorf("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), s)
+			}
+		case "min", "max":
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s rule of %s: %w", key, name, err)
+			}
+			if !given {
+				continue
+			}
+			if err := checkBound(name, v, key, bound); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown validation rule %q of %s", key, name)
+		}
+	}
+	return nil
+}
+
+// checkBound checks a given number, or the length of an array, against a min
+// or max bound
+func checkBound(name string, v reflect.Value, key string, bound float64) error {
+	var n float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	case reflect.Slice:
+		if key == "min" && v.Len() < int(bound) {
+			return fmt.Errorf("%s must have at least %v items, got %d", name, bound, v.Len())
+		}
+		if key == "max" && v.Len() > int(bound) {
+			return fmt.Errorf("%s must have at most %v items, got %d", name, bound, v.Len())
+		}
+		return nil
+	default:
+		return nil
+	}
+	if key == "min" && n < bound {
+		return fmt.Errorf("%s must be at least %v, got %v", name, bound, n)
+	}
+	if key == "max" && n > bound {
+		return fmt.Errorf("%s must be at most %v, got %v", name, bound, n)
+	}
+	return nil
+}
+
+// duration is a Go duration string argument, e.g. "5m"
+type duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// number is a number argument, which may also be given as a numeric string
+type number float64
+
+// UnmarshalJSON implements json.Unmarshaler
+func (n *number) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err == nil {
+		*n = number(f)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*n = number(f)
+	return nil
+}
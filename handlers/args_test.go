@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// request returns a tool call request with the given arguments
+func request(args map[string]any) mcp.CallToolRequest {
+	var req mcp.CallToolRequest
+	req.Params.Arguments = args
+	return req
+}
+
+type testArgs struct {
+	Name     string    `json:"name" validate:"required"`
+	View     string    `json:"view" validate:"oneof=MINIMAL COMPLETE"`
+	Limit    int       `json:"limit" validate:"min=1,max=100"`
+	IDs      []string  `json:"ids" validate:"max=2"`
+	Start    time.Time `json:"start"`
+	Interval duration  `json:"interval"`
+	Labels   map[string]string
+}
+
+func TestDecodeArgs(t *testing.T) {
+	args, err := DecodeArgs[testArgs](request(map[string]any{
+		"name":     "checkout",
+		"view":     "COMPLETE",
+		"limit":    float64(10),
+		"ids":      []any{"a", "b"},
+		"start":    "2026-01-02T03:04:05Z",
+		"interval": "5m",
+	}))
+	if err != nil {
+		t.Fatalf("DecodeArgs() error = %v", err)
+	}
+	want := testArgs{
+		Name:     "checkout",
+		View:     "COMPLETE",
+		Limit:    10,
+		IDs:      []string{"a", "b"},
+		Start:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Interval: duration(5 * time.Minute),
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("DecodeArgs() = %+v, want %+v", args, want)
+	}
+}
+
+func TestDecodeArgs_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantErr string
+	}{
+		{name: "missing required", args: map[string]any{}, wantErr: "name is required"},
+		{name: "empty required", args: map[string]any{"name": ""}, wantErr: "name is required"},
+		{name: "wrong type", args: map[string]any{"name": "a", "limit": "10"}, wantErr: `limit must be an integer, got "10"`},
+		{name: "fractional integer", args: map[string]any{"name": "a", "limit": 1.5}, wantErr: "limit must be an integer, got 1.5"},
+		{name: "not one of", args: map[string]any{"name": "a", "view": "FULL"}, wantErr: `view must be one of MINIMAL, COMPLETE, got "FULL"`},
+		{name: "below min", args: map[string]any{"name": "a", "limit": float64(-1)}, wantErr: "limit must be at least 1, got -1"},
+		{name: "given zero below min", args: map[string]any{"name": "a", "limit": float64(0)}, wantErr: "limit must be at least 1, got 0"},
+		{name: "above max", args: map[string]any{"name": "a", "limit": float64(101)}, wantErr: "limit must be at most 100, got 101"},
+		{name: "too many items", args: map[string]any{"name": "a", "ids": []any{"a", "b", "c"}}, wantErr: "ids must have at most 2 items, got 3"},
+		{name: "invalid time", args: map[string]any{"name": "a", "start": "yesterday"}, wantErr: `invalid start "yesterday": must be an RFC 3339 timestamp (e.g. "2026-01-02T15:04:05Z")`},
+		{name: "timestamp of the wrong type", args: map[string]any{"name": "a", "start": true}, wantErr: "start must be an RFC 3339 timestamp"},
+		{name: "invalid duration", args: map[string]any{"name": "a", "interval": "5 minutes"}, wantErr: `invalid interval "5 minutes": must be a duration with a unit, e.g. "5m"`},
+		{name: "empty time", args: map[string]any{"name": "a", "start": "", "view": "x"}, wantErr: "view must be one of"},
+		{name: "unknown arguments", args: map[string]any{"name": "a", "limt": float64(10), "Labels": "x"}, wantErr: "unknown arguments: Labels, limt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeArgs[testArgs](request(tt.args))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DecodeArgs() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeArgs_Number(t *testing.T) {
+	type numberArgs struct {
+		Value *number `json:"value" validate:"required"`
+	}
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    number
+		wantErr string
+	}{
+		{name: "number", args: map[string]any{"value": 1.5}, want: 1.5},
+		{name: "numeric string", args: map[string]any{"value": "2"}, want: 2},
+		{name: "zero", args: map[string]any{"value": float64(0)}, want: 0},
+		{name: "missing", args: map[string]any{}, wantErr: "value is required"},
+		{name: "not a number", args: map[string]any{"value": "high"}, wantErr: `invalid value "high": must be a number, e.g. 42.5`},
+		{name: "object", args: map[string]any{"value": map[string]any{"v": 1}}, wantErr: `value must be a number, e.g. 42.5, got {"v":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := DecodeArgs[numberArgs](request(tt.args))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("DecodeArgs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || *args.Value != tt.want {
+				t.Errorf("DecodeArgs() = %+v, %v, want %v", args, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeArgs_Embedded(t *testing.T) {
+	type embeddedArgs struct {
+		Parent string `json:"parent"`
+		testArgs
+	}
+	args, err := DecodeArgs[embeddedArgs](request(map[string]any{"parent": "folders/1", "name": "checkout"}))
+	if err != nil {
+		t.Fatalf("DecodeArgs() error = %v", err)
+	}
+	if args.Parent != "folders/1" || args.Name != "checkout" {
+		t.Errorf("DecodeArgs() = %+v, want the parent and the embedded name", args)
+	}
+
+	_, err = DecodeArgs[embeddedArgs](request(map[string]any{"parent": "folders/1"}))
+	if err == nil || err.Error() != "name is required" {
+		t.Errorf("DecodeArgs() error = %v, want the embedded name to be required", err)
+	}
+}
+
+// TestArgsMatchSchemas checks that the arguments decoded by the handlers are
+// the parameters of their tools, so that no parameter is rejected as unknown
+// and no decoded argument is missing from the schema. Every tool with
+// parameters must decode them with DecodeArgs.
+func TestArgsMatchSchemas(t *testing.T) {
+	argTypes := map[string]reflect.Type{
+		"list_traces":                reflect.TypeFor[listTracesArgs](),
+		"get_trace":                  reflect.TypeFor[traceIDArgs](),
+		"get_traces":                 reflect.TypeFor[getTracesArgs](),
+		"parse_trace_context":        reflect.TypeFor[parseTraceContextArgs](),
+		"analyze_trace_gaps":         reflect.TypeFor[analyzeTraceGapsArgs](),
+		"find_error_spans":           reflect.TypeFor[findErrorSpansArgs](),
+		"patch_traces":               reflect.TypeFor[patchTracesArgs](),
+		"watch_metric":               reflect.TypeFor[watchMetricArgs](),
+		"watch_logs":                 reflect.TypeFor[watchLogsArgs](),
+		"stop_watch":                 reflect.TypeFor[stopWatchArgs](),
+		"list_monitored_resources":   reflect.TypeFor[listMonitoredResourcesArgs](),
+		"describe_resource":          reflect.TypeFor[describeResourceArgs](),
+		"list_assets":                reflect.TypeFor[listAssetsArgs](),
+		"analyze_metric_cardinality": reflect.TypeFor[analyzeMetricCardinalityArgs](),
+		"estimate_query_cost":        reflect.TypeFor[estimateQueryCostArgs](),
+		"compare_metric_descriptors": reflect.TypeFor[compareMetricDescriptorsArgs](),
+		"clone_metric_descriptor":    reflect.TypeFor[cloneMetricDescriptorArgs](),
+		"delete_metric_descriptors":  reflect.TypeFor[deleteMetricDescriptorsArgs](),
+		"find_unused_metrics":        reflect.TypeFor[findUnusedMetricsArgs](),
+		"get_billing_metrics":        reflect.TypeFor[getBillingMetricsArgs](),
+		"get_trace_ingestion_stats":  reflect.TypeFor[getTraceIngestionStatsArgs](),
+		"check_agent_health":         reflect.TypeFor[checkAgentHealthArgs](),
+		"list_recent_notifications":  reflect.TypeFor[listRecentNotificationsArgs](),
+		"run_batch":                  reflect.TypeFor[runBatchArgs](),
+		"schedule_job":               reflect.TypeFor[scheduleJobArgs](),
+		"cancel_job":                 reflect.TypeFor[cancelJobArgs](),
+		"correlate_by_request_id":    reflect.TypeFor[correlateByRequestIDArgs](),
+		"build_timeline":             reflect.TypeFor[buildTimelineArgs](),
+		"record_deploy_marker":       reflect.TypeFor[recordDeployMarkerArgs](),
+		"list_deploy_markers":        reflect.TypeFor[listDeployMarkersArgs](),
+		"set_session_defaults":       reflect.TypeFor[setSessionDefaultsArgs](),
+		"save_query":                 reflect.TypeFor[saveQueryArgs](),
+		"run_saved_query":            reflect.TypeFor[runSavedQueryArgs](),
+		"create_metric_descriptor":   reflect.TypeFor[createMetricDescriptorArgs](),
+		"write_time_series":          reflect.TypeFor[writeTimeSeriesArgs](),
+		"write_time_series_batch":    reflect.TypeFor[writeTimeSeriesBatchArgs](),
+		"list_time_series":           reflect.TypeFor[listTimeSeriesArgs](),
+		"render_metric_chart":        reflect.TypeFor[renderMetricChartArgs](),
+		"forecast_metric":            reflect.TypeFor[forecastMetricArgs](),
+		"list_metric_descriptors":    reflect.TypeFor[listMetricDescriptorsArgs](),
+		"delete_metric_descriptor":   reflect.TypeFor[deleteMetricDescriptorArgs](),
+		"list_available_metrics":     reflect.TypeFor[listAvailableMetricsArgs](),
+		"search_metrics":             reflect.TypeFor[searchMetricsArgs](),
+		"get_quota_usage":            reflect.TypeFor[getQuotaUsageArgs](),
+		"simulate_burn_rate":         reflect.TypeFor[simulateBurnRateArgs](),
+		"lint_alert_policies":        reflect.TypeFor[lintAlertPoliciesArgs](),
+		"export_monitoring_config":   reflect.TypeFor[exportMonitoringConfigArgs](),
+		"apply_alert_policy_json":    reflect.TypeFor[applyAlertPolicyJSONArgs](),
+		"apply_dashboard_json":       reflect.TypeFor[applyDashboardJSONArgs](),
+		"write_log_entry":            reflect.TypeFor[writeLogEntryArgs](),
+		"write_log_entries":          reflect.TypeFor[writeLogEntriesArgs](),
+		"list_log_entries":           reflect.TypeFor[listLogEntriesArgs](),
+		"list_audit_logs":            reflect.TypeFor[listAuditLogsArgs](),
+		"list_gke_events":            reflect.TypeFor[listGKEEventsArgs](),
+		"log_volume_histogram":       reflect.TypeFor[logVolumeHistogramArgs](),
+		"extract_field_values":       reflect.TypeFor[extractFieldValuesArgs](),
+		"create_profile":             reflect.TypeFor[createProfileArgs](),
+		"create_offline_profile":     reflect.TypeFor[createOfflineProfileArgs](),
+		"update_profile":             reflect.TypeFor[updateProfileArgs](),
+		"list_profiles":              reflect.TypeFor[listProfilesArgs](),
+		"aggregate_profiles":         reflect.TypeFor[aggregateProfilesArgs](),
+		"detect_memory_growth":       reflect.TypeFor[detectMemoryGrowthArgs](),
+		"generate_incident_report":   reflect.TypeFor[generateIncidentReportArgs](),
+		"find_recent_changes":        reflect.TypeFor[findRecentChangesArgs](),
+	}
+
+	for _, tool := range Tools(Deps{}) {
+		argType, ok := argTypes[tool.Definition.Name]
+		if !ok {
+			// Tools without parameters need no arguments type
+			if len(tool.Definition.InputSchema.Properties) > 0 {
+				t.Errorf("%s has parameters but no arguments type", tool.Definition.Name)
+			}
+			continue
+		}
+		var fields, required []string
+		for _, field := range reflect.VisibleFields(argType) {
+			if field.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			fields = append(fields, name)
+			if strings.Contains(field.Tag.Get("validate"), "required") {
+				required = append(required, name)
+			}
+		}
+		var properties []string
+		for name := range tool.Definition.InputSchema.Properties {
+			properties = append(properties, name)
+		}
+		slices.Sort(fields)
+		slices.Sort(properties)
+		if !slices.Equal(fields, properties) {
+			t.Errorf("%s decodes %v, want the parameters %v", tool.Definition.Name, fields, properties)
+		}
+		schemaRequired := slices.Sorted(slices.Values(tool.Definition.InputSchema.Required))
+		slices.Sort(required)
+		if !slices.Equal(required, schemaRequired) {
+			t.Errorf("%s requires %v, want the required parameters %v", tool.Definition.Name, required, schemaRequired)
+		}
+	}
+}
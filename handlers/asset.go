@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// listAssetsArgs are the arguments of list_assets
+type listAssetsArgs struct {
+	AssetType string   `json:"asset_type" validate:"required"`
+	IDs       []string `json:"ids"`
+	Limit     int      `json:"limit" validate:"min=1,max=1000"`
+}
+
+// createListAssetsHandler creates a handler for listing or cross-checking the assets of a type
+func createListAssetsHandler(client asset.AssetClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listAssetsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		resp, err := client.FindAssets(ctx, asset.FindAssetsRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			AssetType: args.AssetType,
+			IDs:       args.IDs,
+			Limit:     args.Limit,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list assets: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal assets: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
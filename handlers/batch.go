@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// runBatchTool is the name of the batch tool, which batches cannot call
+const runBatchTool = "run_batch"
+
+// maxForEachItems limits the items of the steps with for_each
+const maxForEachItems = 20
+
+// batchStep is a tool call of a batch
+type batchStep struct {
+	// Name identifies the result of the step, and defaults to the tool name
+	Name string `json:"name"`
+	Tool string `json:"tool"`
+	// Arguments may refer to the results of earlier steps with templates,
+	// see expandTemplates
+	Arguments map[string]any `json:"arguments"`
+	// ForEach, when set, is an array, usually a reference to the result of
+	// an earlier step, and the tool is called once for each of its items,
+	// referred to as {{item}} in Arguments
+	ForEach any `json:"for_each"`
+}
+
+// runBatchArgs are the arguments of run_batch
+type runBatchArgs struct {
+	Steps       []batchStep `json:"steps" validate:"required,max=20"`
+	Concurrent  bool        `json:"concurrent"`
+	StopOnError bool        `json:"stop_on_error"`
+}
+
+// batchStepResult is the outcome of a step of a batch, or of an item of a
+// step with for_each
+type batchStepResult struct {
+	Name string `json:"name,omitempty"`
+	Tool string `json:"tool,omitempty"`
+	// Item is the item of for_each the tool was called for
+	Item any `json:"item,omitempty"`
+	// Status is "ok", "error" when the call failed or returned an error
+	// result, or "skipped" when the step was not run after a failure with
+	// stop_on_error, or because a step it refers to did not succeed
+	Status string `json:"status"`
+	// Result is the first text content of the result of the tool, as JSON
+	// when it is valid JSON
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Notes are the other text contents, e.g. the note of a cached result
+	Notes []string `json:"notes,omitempty"`
+	// Items are the calls of a step with for_each
+	Items      []batchStepResult `json:"items,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+	// Attachments counts the non-text contents of the result, e.g. charts,
+	// which follow the text content of the batch result in step order
+	Attachments int `json:"attachments,omitempty"`
+
+	contents []mcp.Content
+	// value is the result referred to by later steps: the decoded JSON
+	// result, or the values of the items of a step with for_each
+	value any
+}
+
+// Statuses of the steps of a batch
+const (
+	batchStatusOK      = "ok"
+	batchStatusError   = "error"
+	batchStatusSkipped = "skipped"
+)
+
+// batchResult is the result of run_batch
+type batchResult struct {
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped,omitempty"`
+	Steps     []batchStepResult `json:"steps"`
+}
+
+// batchRun runs the steps of a batch
+type batchRun struct {
+	server     *server.MCPServer
+	steps      []batchStep
+	concurrent bool
+	// index is the index of each step by name, and deps are the indexes of
+	// the steps each step refers to
+	index   map[string]int
+	deps    [][]int
+	results []batchStepResult
+}
+
+// createRunBatchHandler creates a handler for running several tools in one call
+func createRunBatchHandler() func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[runBatchArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		run := &batchRun{
+			steps:      args.Steps,
+			concurrent: args.Concurrent,
+			index:      make(map[string]int),
+			deps:       make([][]int, len(args.Steps)),
+			results:    make([]batchStepResult, len(args.Steps)),
+		}
+		for i, step := range args.Steps {
+			if step.Tool == "" {
+				return mcp.NewToolResultError(fmt.Sprintf("steps[%d].tool is required", i)), nil
+			}
+			if step.Tool == runBatchTool {
+				return mcp.NewToolResultError(fmt.Sprintf("steps[%d] cannot call %s", i, runBatchTool)), nil
+			}
+			if step.Name == "" {
+				step.Name = step.Tool
+				args.Steps[i].Name = step.Name
+			}
+			if !stepNamePattern.MatchString(step.Name) || step.Name == itemRef {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid step name %q: use letters, digits, _ and -, other than %q", step.Name, itemRef)), nil
+			}
+			if _, ok := run.index[step.Name]; ok {
+				return mcp.NewToolResultError(fmt.Sprintf("duplicate step name %q: name the steps calling the same tool", step.Name)), nil
+			}
+			for _, name := range append(templateRefs(step.ForEach), templateRefs(map[string]any(step.Arguments))...) {
+				dep, ok := run.index[name]
+				if !ok {
+					return mcp.NewToolResultError(fmt.Sprintf("steps[%d] refers to %q, which is not the name of an earlier step", i, name)), nil
+				}
+				run.deps[i] = append(run.deps[i], dep)
+			}
+			run.index[step.Name] = i
+		}
+
+		// The steps are called through the server, so that they go through
+		// the same middleware and hooks as the calls of the client
+		run.server = server.ServerFromContext(ctx)
+		if run.server == nil {
+			return mcp.NewToolResultError("run_batch must be called through an MCP server"), nil
+		}
+
+		results := run.results
+		if args.Concurrent {
+			// Steps wait for the steps they refer to
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			done := make([]chan struct{}, len(args.Steps))
+			for i := range done {
+				done[i] = make(chan struct{})
+			}
+			var wg sync.WaitGroup
+			for i, step := range args.Steps {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer close(done[i])
+					for _, dep := range run.deps[i] {
+						select {
+						case <-done[dep]:
+						case <-ctx.Done():
+							results[i] = batchStepResult{Name: step.Name, Tool: step.Tool, Status: batchStatusSkipped, Error: ctx.Err().Error()}
+							return
+						}
+					}
+					results[i] = run.step(ctx, i)
+					if args.StopOnError && results[i].Status == batchStatusError {
+						cancel()
+					}
+				}()
+			}
+			wg.Wait()
+		} else {
+			failed := false
+			for i, step := range args.Steps {
+				if failed && args.StopOnError {
+					results[i] = batchStepResult{Name: step.Name, Tool: step.Tool, Status: batchStatusSkipped}
+					continue
+				}
+				results[i] = run.step(ctx, i)
+				failed = failed || results[i].Status == batchStatusError
+			}
+		}
+
+		batch := batchResult{Steps: results}
+		var attachments []mcp.Content
+		for _, result := range results {
+			switch result.Status {
+			case batchStatusOK:
+				batch.Succeeded++
+			case batchStatusError:
+				batch.Failed++
+			case batchStatusSkipped:
+				batch.Skipped++
+			}
+			attachments = append(attachments, result.contents...)
+		}
+
+		batchJSON, err := json.MarshalIndent(batch, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal batch results: %v", err)), nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: append([]mcp.Content{mcp.NewTextContent(string(batchJSON))}, attachments...),
+		}, nil
+	}
+}
+
+// step runs the i-th step, once the steps it refers to are done
+func (r *batchRun) step(ctx context.Context, i int) batchStepResult {
+	step := r.steps[i]
+	result := batchStepResult{Name: step.Name, Tool: step.Tool, Status: batchStatusError}
+	for _, dep := range r.deps[i] {
+		if r.results[dep].Status != batchStatusOK {
+			result.Status = batchStatusSkipped
+			result.Error = fmt.Sprintf("step %q, which this step refers to, did not succeed", r.steps[dep].Name)
+			return result
+		}
+	}
+	lookup := func(name string) (any, error) {
+		if name == itemRef {
+			return nil, fmt.Errorf("{{%s}} can only be used in the arguments of steps with for_each", itemRef)
+		}
+		return r.results[r.index[name]].value, nil
+	}
+
+	if step.ForEach == nil {
+		arguments, err := expandTemplates(map[string]any(step.Arguments), lookup)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		call := r.call(ctx, i, step.Tool, arguments)
+		call.Name, call.Tool = step.Name, step.Tool
+		return call
+	}
+
+	forEach, err := expandTemplates(step.ForEach, lookup)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	items, ok := forEach.([]any)
+	if !ok {
+		result.Error = fmt.Sprintf("for_each must be an array, got %s", jsonKind(forEach))
+		return result
+	}
+	if len(items) > maxForEachItems {
+		result.Error = fmt.Sprintf("for_each has %d items, more than the %d allowed", len(items), maxForEachItems)
+		return result
+	}
+
+	start := time.Now()
+	result.Items = make([]batchStepResult, len(items))
+	callItem := func(j int) {
+		item := items[j]
+		arguments, err := expandTemplates(map[string]any(step.Arguments), func(name string) (any, error) {
+			if name == itemRef {
+				return item, nil
+			}
+			return lookup(name)
+		})
+		if err != nil {
+			result.Items[j] = batchStepResult{Status: batchStatusError, Error: err.Error()}
+		} else {
+			result.Items[j] = r.call(ctx, i, step.Tool, arguments)
+		}
+		result.Items[j].Item = item
+	}
+	if r.concurrent {
+		var wg sync.WaitGroup
+		for j := range items {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				callItem(j)
+			}()
+		}
+		wg.Wait()
+	} else {
+		for j := range items {
+			callItem(j)
+		}
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	values := make([]any, len(items))
+	result.Status = batchStatusOK
+	for j, item := range result.Items {
+		if item.Status != batchStatusOK {
+			result.Status = batchStatusError
+		}
+		values[j] = item.value
+		result.contents = append(result.contents, item.contents...)
+	}
+	result.value = values
+	result.Attachments = len(result.contents)
+	return result
+}
+
+// call calls a tool through the server of the batch as a tools/call request
+func (r *batchRun) call(ctx context.Context, id int, tool string, arguments any) batchStepResult {
+	return callTool(ctx, r.server, id, tool, arguments)
+}
+
+// callTool calls a tool through s as a tools/call request, so that the call
+// goes through the same middleware and hooks as the calls of the clients
+func callTool(ctx context.Context, s *server.MCPServer, id int, tool string, arguments any) batchStepResult {
+	result := batchStepResult{Status: batchStatusError}
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      id,
+		"method":  mcp.MethodToolsCall,
+		"params":  map[string]any{"name": tool, "arguments": arguments},
+	})
+	if err != nil {
+		result.Error = fmt.Sprintf("Failed to marshal arguments: %v", err)
+		return result
+	}
+
+	start := time.Now()
+	response := s.HandleMessage(ctx, message)
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	switch response := response.(type) {
+	case mcp.JSONRPCError:
+		result.Error = response.Error.Message
+	case mcp.JSONRPCResponse:
+		callResult, ok := response.Result.(mcp.CallToolResult)
+		if !ok {
+			result.Error = fmt.Sprintf("unexpected result of type %T", response.Result)
+			return result
+		}
+		var texts []string
+		// resourceJSON is the JSON of a result moved to an embedded resource
+		// by the large results middleware, leaving a summary in the text
+		var resourceJSON string
+		for _, content := range callResult.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				texts = append(texts, text.Text)
+				continue
+			}
+			if resource, ok := content.(mcp.EmbeddedResource); ok && resourceJSON == "" {
+				if contents, ok := resource.Resource.(mcp.TextResourceContents); ok && contents.MIMEType == "application/json" {
+					resourceJSON = contents.Text
+				}
+			}
+			result.contents = append(result.contents, content)
+		}
+		result.Attachments = len(result.contents)
+		if callResult.IsError {
+			result.Error = strings.Join(texts, "\n")
+			return result
+		}
+		result.Status = batchStatusOK
+		if len(texts) == 0 {
+			return result
+		}
+		if json.Valid([]byte(texts[0])) {
+			result.Result = json.RawMessage(texts[0])
+			_ = json.Unmarshal([]byte(texts[0]), &result.value)
+		} else {
+			result.Result = texts[0]
+			result.value = texts[0]
+		}
+		if resourceJSON != "" {
+			// Later steps refer to the JSON rather than its summary
+			_ = json.Unmarshal([]byte(resourceJSON), &result.value)
+		}
+		result.Notes = texts[1:]
+	default:
+		result.Error = fmt.Sprintf("unexpected response of type %T", response)
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/billing"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// getBillingMetricsArgs are the arguments of get_billing_metrics
+type getBillingMetricsArgs struct {
+	StartTime         time.Time `json:"start_time"`
+	EndTime           time.Time `json:"end_time"`
+	MetricPrefixes    []string  `json:"metric_prefixes"`
+	AlignmentPeriod   duration  `json:"alignment_period"`
+	BudgetAlertFilter string    `json:"budget_alert_filter"`
+	MaxMetrics        int       `json:"max_metrics" validate:"min=1"`
+}
+
+// createGetBillingMetricsHandler creates a handler for summarizing cost metrics and budget alerts
+func createGetBillingMetricsHandler(reader *billing.Reader) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[getBillingMetricsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		req := billing.Request{
+			ProjectID:         session.FromContext(ctx).ProjectID,
+			StartTime:         args.StartTime,
+			EndTime:           args.EndTime,
+			MetricPrefixes:    slices.DeleteFunc(args.MetricPrefixes, func(prefix string) bool { return prefix == "" }),
+			AlignmentPeriod:   time.Duration(args.AlignmentPeriod),
+			BudgetAlertFilter: args.BudgetAlertFilter,
+			MaxMetrics:        args.MaxMetrics,
+		}
+		if req.EndTime.IsZero() {
+			req.EndTime = time.Now()
+		}
+		if req.StartTime.IsZero() {
+			req.StartTime = req.EndTime.Add(-30 * 24 * time.Hour)
+		}
+
+		report, err := reader.Collect(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get billing metrics: %v", err)), nil
+		}
+
+		// Convert report to JSON for response
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal billing metrics: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(reportJSON)), nil
+	}
+}
+
+// getTraceIngestionStatsArgs are the arguments of get_trace_ingestion_stats
+type getTraceIngestionStatsArgs struct {
+	StartTime           time.Time `json:"start_time"`
+	EndTime             time.Time `json:"end_time"`
+	AlignmentPeriod     duration  `json:"alignment_period"`
+	RequestMetricFilter string    `json:"request_metric_filter"`
+}
+
+// createGetTraceIngestionStatsHandler creates a handler for summarizing Cloud Trace span ingestion
+func createGetTraceIngestionStatsHandler(reader *billing.Reader) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[getTraceIngestionStatsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		req := billing.TraceIngestionRequest{
+			ProjectID:           session.FromContext(ctx).ProjectID,
+			StartTime:           args.StartTime,
+			EndTime:             args.EndTime,
+			AlignmentPeriod:     time.Duration(args.AlignmentPeriod),
+			RequestMetricFilter: args.RequestMetricFilter,
+		}
+		if req.EndTime.IsZero() {
+			req.EndTime = time.Now()
+		}
+		if req.StartTime.IsZero() {
+			req.StartTime = req.EndTime.Add(-24 * time.Hour)
+		}
+
+		report, err := reader.TraceIngestion(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get trace ingestion stats: %v", err)), nil
+		}
+
+		// Convert report to JSON for response
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal trace ingestion stats: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(reportJSON)), nil
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/incident"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// generateIncidentReportArgs are the arguments of generate_incident_report
+type generateIncidentReportArgs struct {
+	Service             string    `json:"service" validate:"required"`
+	StartTime           time.Time `json:"start_time"`
+	EndTime             time.Time `json:"end_time"`
+	LogFilter           string    `json:"log_filter"`
+	LatencyMetricFilter string    `json:"latency_metric_filter"`
+	RequestMetricFilter string    `json:"request_metric_filter"`
+	TraceFilter         string    `json:"trace_filter"`
+	SlowTraceThreshold  duration  `json:"slow_trace_threshold"`
+	MaxLogEntries       int       `json:"max_log_entries" validate:"min=1"`
+	MaxTraces           int       `json:"max_traces" validate:"min=1"`
+}
+
+// createIncidentReportHandler creates a handler for generating incident reports
+func createIncidentReportHandler(generator *incident.Generator) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[generateIncidentReportArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := incident.Request{
+			ProjectID:           session.FromContext(ctx).ProjectID,
+			Service:             args.Service,
+			StartTime:           args.StartTime,
+			EndTime:             args.EndTime,
+			LogFilter:           args.LogFilter,
+			LatencyMetricFilter: args.LatencyMetricFilter,
+			RequestMetricFilter: args.RequestMetricFilter,
+			TraceFilter:         args.TraceFilter,
+			SlowTraceThreshold:  time.Duration(args.SlowTraceThreshold),
+			MaxLogEntries:       args.MaxLogEntries,
+			MaxTraces:           args.MaxTraces,
+		}
+		if req.EndTime.IsZero() {
+			req.EndTime = time.Now()
+		}
+		if req.StartTime.IsZero() {
+			req.StartTime = req.EndTime.Add(-time.Hour)
+		}
+
+		report, err := generator.Generate(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to generate incident report: %v", err)), nil
+		}
+
+		// Convert report to JSON for response
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal incident report: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(reportJSON)), nil
+	}
+}
+
+// findRecentChangesArgs are the arguments of find_recent_changes
+type findRecentChangesArgs struct {
+	Time         time.Time `json:"time"`
+	Before       duration  `json:"before"`
+	After        duration  `json:"after"`
+	Service      string    `json:"service"`
+	MetricFilter string    `json:"metric_filter"`
+	Limit        int       `json:"limit" validate:"min=1"`
+}
+
+// createFindRecentChangesHandler creates a handler for ranking changes near a regression
+func createFindRecentChangesHandler(generator *incident.Generator) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[findRecentChangesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := incident.ChangesRequest{
+			ProjectID:    session.FromContext(ctx).ProjectID,
+			Time:         args.Time,
+			Before:       time.Duration(args.Before),
+			After:        time.Duration(args.After),
+			Service:      args.Service,
+			MetricFilter: args.MetricFilter,
+			Limit:        args.Limit,
+		}
+		if req.Time.IsZero() {
+			req.Time = time.Now()
+		}
+
+		report, err := generator.FindRecentChanges(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to find recent changes: %v", err)), nil
+		}
+
+		// Convert report to JSON for response
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal changes: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(reportJSON)), nil
+	}
+}
+
+// correlateByRequestIDArgs are the arguments of correlate_by_request_id
+type correlateByRequestIDArgs struct {
+	RequestID     string    `json:"request_id" validate:"required"`
+	Label         string    `json:"label"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	MetricFilter  string    `json:"metric_filter"`
+	MaxLogEntries int       `json:"max_log_entries" validate:"min=1,max=1000"`
+}
+
+// createCorrelateByRequestIDHandler creates a handler for gathering the
+// telemetry of one request into a timeline
+func createCorrelateByRequestIDHandler(generator *incident.Generator) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[correlateByRequestIDArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		timeline, err := generator.CorrelateRequest(ctx, incident.RequestTimelineRequest{
+			ProjectID:     session.FromContext(ctx).ProjectID,
+			RequestID:     args.RequestID,
+			Label:         args.Label,
+			StartTime:     args.StartTime,
+			EndTime:       args.EndTime,
+			MetricFilter:  args.MetricFilter,
+			MaxLogEntries: args.MaxLogEntries,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to correlate request: %v", err)), nil
+		}
+
+		// Convert timeline to JSON for response
+		timelineJSON, err := json.MarshalIndent(timeline, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal request timeline: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(timelineJSON)), nil
+	}
+}
+
+// buildTimelineArgs are the arguments of build_timeline
+type buildTimelineArgs struct {
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Signals       []string  `json:"signals"`
+	LogFilter     string    `json:"log_filter"`
+	TraceFilter   string    `json:"trace_filter"`
+	MaxLogEntries int       `json:"max_log_entries" validate:"min=1,max=1000"`
+	MaxTraces     int       `json:"max_traces" validate:"min=1,max=100"`
+	MaxEvents     int       `json:"max_events" validate:"min=1,max=5000"`
+}
+
+// createBuildTimelineHandler creates a handler for merging the events of
+// several signals into one timeline
+func createBuildTimelineHandler(generator *incident.Generator) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[buildTimelineArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// The window defaults to the last hour
+		if args.EndTime.IsZero() {
+			args.EndTime = time.Now()
+		}
+		if args.StartTime.IsZero() {
+			args.StartTime = args.EndTime.Add(-time.Hour)
+		}
+
+		timeline, err := generator.BuildTimeline(ctx, incident.TimelineRequest{
+			ProjectID:     session.FromContext(ctx).ProjectID,
+			StartTime:     args.StartTime,
+			EndTime:       args.EndTime,
+			Signals:       args.Signals,
+			LogFilter:     args.LogFilter,
+			TraceFilter:   args.TraceFilter,
+			MaxLogEntries: args.MaxLogEntries,
+			MaxTraces:     args.MaxTraces,
+			MaxEvents:     args.MaxEvents,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to build timeline: %v", err)), nil
+		}
+
+		// Convert timeline to JSON for response
+		timelineJSON, err := json.MarshalIndent(timeline, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal timeline: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(timelineJSON)), nil
+	}
+}
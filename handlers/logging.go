@@ -0,0 +1,727 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/chart"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// logEntryArgs are the fields of the log entry of write_log_entry, and of
+// the items of the entries of write_log_entries
+type logEntryArgs struct {
+	Severity       string                     `json:"severity" validate:"required"`
+	Message        string                     `json:"message" validate:"required"`
+	Labels         map[string]string          `json:"labels"`
+	Payload        map[string]any             `json:"payload"`
+	Resource       *logging.MonitoredResource `json:"resource"`
+	SourceLocation *logging.SourceLocation    `json:"source_location"`
+	HTTPRequest    *logging.HTTPRequest       `json:"http_request"`
+	Operation      *logging.Operation         `json:"operation"`
+	InsertID       string                     `json:"insert_id"`
+	Timestamp      time.Time                  `json:"timestamp"`
+}
+
+// writeLogEntryArgs are the arguments of write_log_entry
+type writeLogEntryArgs struct {
+	LogName string `json:"log_name" validate:"required"`
+	Parent  string `json:"parent"`
+	logEntryArgs
+}
+
+// createWriteLogHandler creates a handler for writing log entries
+func createWriteLogHandler(client logging.LoggingClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[writeLogEntryArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		entry, err := parseLogEntryArg(args.logEntryArgs)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		defaults := session.FromContext(ctx)
+		logName, err := writeLogName(defaults, args.LogName, args.Parent)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		applyResourceDefaults(defaults, &entry)
+		entry.Labels = defaults.MergeWriteLabels(entry.Labels)
+
+		err = client.WriteEntry(ctx, logName, entry)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to write log entry: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText("Log entry written successfully"), nil
+	}
+}
+
+// writeLogEntriesArgs are the arguments of write_log_entries
+type writeLogEntriesArgs struct {
+	LogName string           `json:"log_name" validate:"required"`
+	Parent  string           `json:"parent"`
+	Entries []map[string]any `json:"entries" validate:"required"`
+	Async   bool             `json:"async"`
+}
+
+// createWriteLogsHandler creates a handler for writing multiple log entries
+func createWriteLogsHandler(client logging.LoggingClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[writeLogEntriesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		defaults := session.FromContext(ctx)
+		logName, err := writeLogName(defaults, args.LogName, args.Parent)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Parse entries from the request
+		var entries []logging.LogEntry
+		for i, item := range args.Entries {
+			entryArgs, err := decodeArgs[logEntryArgs](item)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("entries[%d]: %v", i, err)), nil
+			}
+			entry, err := parseLogEntryArg(entryArgs)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("entries[%d]: %v", i, err)), nil
+			}
+
+			applyResourceDefaults(defaults, &entry)
+			entry.Labels = defaults.MergeWriteLabels(entry.Labels)
+			entries = append(entries, entry)
+		}
+
+		req := logging.WriteEntriesRequest{
+			LogName: logName,
+			Entries: entries,
+			Async:   args.Async,
+		}
+
+		err = client.WriteEntries(ctx, req)
+		var partial *logging.PartialWriteError
+		if errors.As(err, &partial) && partial.Written > 0 {
+			// Report the entries written and the failed ones rather than
+			// failing the call, since retrying all of them would write the
+			// others twice
+			responseJSON, err := json.MarshalIndent(partial, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal write report: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(responseJSON)), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to write log entries: %v", err)), nil
+		}
+
+		if args.Async {
+			return mcp.NewToolResultText(fmt.Sprintf("%d log entries buffered and flushed successfully", len(entries))), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%d log entries written successfully", len(entries))), nil
+	}
+}
+
+// parseLogEntryArg builds the log entry of the fields of a log entry object
+func parseLogEntryArg(args logEntryArgs) (logging.LogEntry, error) {
+	severity, err := logging.ParseSeverity(args.Severity)
+	if err != nil {
+		return logging.LogEntry{}, err
+	}
+	if args.Resource != nil && args.Resource.Type == "" {
+		return logging.LogEntry{}, fmt.Errorf("resource.type is required when resource is set")
+	}
+	if args.Operation != nil && args.Operation.ID == "" {
+		return logging.LogEntry{}, fmt.Errorf("operation.id is required when operation is set")
+	}
+	// The timestamp may be of an event collected elsewhere
+	if args.Timestamp.After(time.Now().Add(maxLogEntryFutureSkew)) {
+		return logging.LogEntry{}, fmt.Errorf("timestamp %s is more than %s in the future, which Cloud Logging does not accept", args.Timestamp.Format(time.RFC3339), maxLogEntryFutureSkew)
+	}
+
+	return logging.LogEntry{
+		Severity:       severity,
+		Message:        args.Message,
+		Labels:         args.Labels,
+		Payload:        args.Payload,
+		Resource:       args.Resource,
+		SourceLocation: args.SourceLocation,
+		HTTPRequest:    args.HTTPRequest,
+		Operation:      args.Operation,
+		InsertID:       args.InsertID,
+		Timestamp:      args.Timestamp,
+	}, nil
+}
+
+// maxLogEntryFutureSkew is how far in the future Cloud Logging accepts the
+// timestamps of log entries
+const maxLogEntryFutureSkew = 24 * time.Hour
+
+// applyResourceDefaults fills in the session default resource of a log entry
+func applyResourceDefaults(defaults session.Defaults, entry *logging.LogEntry) {
+	if entry.Resource == nil {
+		if defaults.ResourceType == "" {
+			return
+		}
+		entry.Resource = &logging.MonitoredResource{Type: defaults.ResourceType}
+	}
+	entry.Resource.Labels = defaults.MergeResourceLabels(entry.Resource.Type, entry.Resource.Labels)
+}
+
+// writeLogName returns the log the write tools write logName to: the log
+// of parent when given, e.g. a folder, or of the session default project
+func writeLogName(defaults session.Defaults, logName, parent string) (string, error) {
+	if parent == "" {
+		logName = sessionLogName(defaults, logName)
+	} else {
+		if err := logging.ValidateParent(parent); err != nil {
+			return "", err
+		}
+		if _, _, ok := logging.CutLogName(logName); ok {
+			return "", fmt.Errorf("log_name must be a log ID when parent is set, got the full name %q", logName)
+		}
+		logName = logging.LogName(parent, defaults.LogNamePrefix+logName)
+	}
+
+	// Catch malformed names here rather than in the Cloud Logging client
+	logName, err := logging.NormalizeLogName(logName)
+	if err != nil {
+		return "", fmt.Errorf("log_name: %w", err)
+	}
+	return logName, nil
+}
+
+// sessionLogName applies the session default log name prefix and project to logName.
+// Full resource names (e.g. projects/PROJECT_ID/logs/LOG_ID) are returned unchanged.
+func sessionLogName(defaults session.Defaults, logName string) string {
+	if _, _, ok := logging.CutLogName(logName); ok {
+		return logName
+	}
+
+	logName = defaults.LogNamePrefix + logName
+	if defaults.ProjectID == "" {
+		return logName
+	}
+	return fmt.Sprintf("projects/%s/logs/%s", defaults.ProjectID, url.PathEscape(logName))
+}
+
+// listLogEntriesArgs are the arguments of list_log_entries
+type listLogEntriesArgs struct {
+	Filter        string   `json:"filter"`
+	MinSeverity   string   `json:"min_severity"`
+	TextRegex     string   `json:"text_regex"`
+	ExcludeText   []string `json:"exclude_text"`
+	Fields        []string `json:"fields"`
+	ScanAndRedact bool     `json:"scan_and_redact"`
+	InfoTypes     []string `json:"info_types"`
+	Limit         int      `json:"limit" validate:"min=1"`
+	OrderBy       string   `json:"order_by"`
+	PageToken     string   `json:"page_token"`
+	CountOnly     bool     `json:"count_only"`
+}
+
+// createListLogsHandler creates a handler for listing log entries
+func createListLogsHandler(client logging.LoggingClient, scanner *redact.DLPScanner, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listLogEntriesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		entryFilter := logging.EntryFilter{
+			Filter:      args.Filter,
+			MinSeverity: args.MinSeverity,
+			TextRegex:   args.TextRegex,
+			ExcludeText: args.ExcludeText,
+		}
+		filter, err := entryFilter.Build()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		projection, err := logging.NewPayloadProjection(args.Fields)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if args.OrderBy != "" && args.OrderBy != logging.OrderByTimestampAsc && args.OrderBy != logging.OrderByTimestampDesc {
+			return mcp.NewToolResultError(fmt.Sprintf("order_by must be '%s' or '%s'", logging.OrderByTimestampAsc, logging.OrderByTimestampDesc)), nil
+		}
+
+		req := logging.ListEntriesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    filter,
+			Limit:     args.Limit,
+			OrderBy:   args.OrderBy,
+		}
+		if req.Limit == 0 {
+			req.Limit = 50 // default
+		}
+
+		if args.CountOnly {
+			severityCounts := map[string]int{}
+			count, truncated, err := countAll(func(pageSize int, pageToken string) (int, string, error) {
+				countReq := req
+				countReq.Limit = pageSize
+				countReq.PageToken = pageToken
+				resp, err := client.ListEntries(ctx, countReq)
+				logging.CountSeverities(severityCounts, resp.Entries)
+				return len(resp.Entries), resp.NextPageToken, err
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to count log entries: %v", err)), nil
+			}
+			response := countResponse(count, truncated)
+			response["severity_counts"] = severityCounts
+			response["console_url"] = logging.ConsoleURL(sessionProjectID(ctx, projectID), req.Filter)
+			responseJSON, err := json.MarshalIndent(response, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(responseJSON)), nil
+		}
+
+		req.PageToken = args.PageToken
+		resp, err := client.ListEntries(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list log entries: %v", err)), nil
+		}
+		for i, entry := range resp.Entries {
+			resp.Entries[i] = projection.Apply(entry)
+		}
+
+		// Scan the entries with Cloud DLP when requested
+		var summary *redact.ScanSummary
+		if args.ScanAndRedact {
+			entries, scanSummary, err := scanner.ScanEntries(ctx, sessionProjectID(ctx, projectID), args.InfoTypes, resp.Entries)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to scan log entries: %v", err)), nil
+			}
+			resp.Entries = entries
+			summary = &scanSummary
+		}
+
+		// Summarize the severities of the page, which is quicker to read
+		// than the entries
+		severityCounts := map[string]int{}
+		logging.CountSeverities(severityCounts, resp.Entries)
+
+		// Create a response object that includes both entries and pagination info
+		response := map[string]any{
+			"entries":         resp.Entries,
+			"severity_counts": severityCounts,
+			"console_url":     logging.ConsoleURL(sessionProjectID(ctx, projectID), req.Filter),
+		}
+		if summary != nil {
+			response["redaction_summary"] = summary
+		}
+
+		// Add next_page_token if present
+		if resp.NextPageToken != "" {
+			response["next_page_token"] = resp.NextPageToken
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// listAuditLogsArgs are the arguments of list_audit_logs
+type listAuditLogsArgs struct {
+	LogType   string    `json:"log_type"`
+	Service   string    `json:"service"`
+	Method    string    `json:"method"`
+	Principal string    `json:"principal"`
+	CallerIP  string    `json:"caller_ip"`
+	Resource  string    `json:"resource"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Filter    string    `json:"filter"`
+	Limit     int       `json:"limit" validate:"min=1"`
+	PageToken string    `json:"page_token"`
+}
+
+// createListAuditLogsHandler creates a handler for listing audit log entries
+func createListAuditLogsHandler(client logging.LoggingClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listAuditLogsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		auditFilter := logging.AuditLogFilter{
+			LogType:        args.LogType,
+			ServiceName:    args.Service,
+			MethodName:     args.Method,
+			PrincipalEmail: args.Principal,
+			CallerIP:       args.CallerIP,
+			ResourceName:   args.Resource,
+			Filter:         args.Filter,
+			StartTime:      args.StartTime,
+			EndTime:        args.EndTime,
+		}
+		filter, err := auditFilter.Build()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := logging.ListEntriesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    filter,
+			Limit:     args.Limit,
+			PageToken: args.PageToken,
+		}
+		if req.Limit == 0 {
+			req.Limit = 50 // default
+		}
+
+		resp, err := client.ListEntries(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list audit logs: %v", err)), nil
+		}
+
+		// Summarize the severities of the page, which is quicker to read
+		// than the entries
+		severityCounts := map[string]int{}
+		logging.CountSeverities(severityCounts, resp.Entries)
+
+		// Create a response object that includes both entries and pagination info
+		response := map[string]any{
+			"entries":         resp.Entries,
+			"severity_counts": severityCounts,
+			"console_url":     logging.ConsoleURL(sessionProjectID(ctx, projectID), req.Filter),
+		}
+
+		// Add next_page_token if present
+		if resp.NextPageToken != "" {
+			response["next_page_token"] = resp.NextPageToken
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// listGKEEventsArgs are the arguments of list_gke_events
+type listGKEEventsArgs struct {
+	ClusterName string    `json:"cluster_name"`
+	Namespace   string    `json:"namespace"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Reason      string    `json:"reason"`
+	Type        string    `json:"type"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Filter      string    `json:"filter"`
+	Limit       int       `json:"limit" validate:"min=1"`
+	PageToken   string    `json:"page_token"`
+}
+
+// createListGKEEventsHandler creates a handler for listing GKE events
+func createListGKEEventsHandler(client logging.LoggingClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listGKEEventsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		eventFilter := logging.GKEEventFilter{
+			ClusterName: args.ClusterName,
+			Namespace:   args.Namespace,
+			Kind:        args.Kind,
+			Name:        args.Name,
+			Reason:      args.Reason,
+			Type:        args.Type,
+			Filter:      args.Filter,
+			StartTime:   args.StartTime,
+			EndTime:     args.EndTime,
+		}
+		filter, err := eventFilter.Build()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := logging.ListEntriesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    filter,
+			Limit:     args.Limit,
+			PageToken: args.PageToken,
+		}
+		if req.Limit == 0 {
+			req.Limit = 50 // default
+		}
+
+		resp, err := client.ListEntries(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list GKE events: %v", err)), nil
+		}
+
+		events := make([]logging.KubernetesEvent, 0, len(resp.Entries))
+		for _, entry := range resp.Entries {
+			events = append(events, logging.NewKubernetesEvent(entry))
+		}
+
+		// Create a response object that includes both events and pagination info
+		response := map[string]any{
+			"events":      events,
+			"console_url": logging.ConsoleURL(sessionProjectID(ctx, projectID), req.Filter),
+		}
+
+		// Add next_page_token if present
+		if resp.NextPageToken != "" {
+			response["next_page_token"] = resp.NextPageToken
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// recordDeployMarkerArgs are the arguments of record_deploy_marker
+type recordDeployMarkerArgs struct {
+	Service     string    `json:"service" validate:"required"`
+	Version     string    `json:"version"`
+	Environment string    `json:"environment"`
+	Commit      string    `json:"commit"`
+	Author      string    `json:"author"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// createRecordDeployMarkerHandler creates a handler for recording deployments as log entries
+func createRecordDeployMarkerHandler(client logging.LoggingClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[recordDeployMarkerArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		marker := logging.DeployMarker{
+			Timestamp:   args.Timestamp,
+			Service:     args.Service,
+			Version:     args.Version,
+			Environment: args.Environment,
+			Commit:      args.Commit,
+			Author:      args.Author,
+			Description: args.Description,
+			URL:         args.URL,
+		}
+		if marker.Timestamp.IsZero() {
+			marker.Timestamp = time.Now()
+		}
+
+		// The markers are read back from a fixed log, so the session log
+		// name prefix does not apply
+		defaults := session.FromContext(ctx)
+		logName := logging.DeployMarkerLogID
+		if defaults.ProjectID != "" {
+			logName = logging.LogName("projects/"+defaults.ProjectID, logName)
+		}
+		entry := marker.Entry()
+		entry.Labels = defaults.MergeWriteLabels(entry.Labels)
+
+		if err := client.WriteEntry(ctx, logName, entry); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to record deploy marker: %v", err)), nil
+		}
+
+		// Convert marker to JSON for response
+		markerJSON, err := json.MarshalIndent(marker, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal deploy marker: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(markerJSON)), nil
+	}
+}
+
+// listDeployMarkersArgs are the arguments of list_deploy_markers
+type listDeployMarkersArgs struct {
+	Service     string    `json:"service"`
+	Version     string    `json:"version"`
+	Environment string    `json:"environment"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Filter      string    `json:"filter"`
+	Limit       int       `json:"limit" validate:"min=1,max=1000"`
+	PageToken   string    `json:"page_token"`
+}
+
+// createListDeployMarkersHandler creates a handler for listing recorded deployments
+func createListDeployMarkersHandler(client logging.LoggingClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listDeployMarkersArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := logging.ListEntriesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter: logging.DeployMarkerFilter{
+				Service:     args.Service,
+				Version:     args.Version,
+				Environment: args.Environment,
+				StartTime:   args.StartTime,
+				EndTime:     args.EndTime,
+				Filter:      args.Filter,
+			}.Build(),
+			OrderBy:   logging.OrderByTimestampDesc,
+			Limit:     args.Limit,
+			PageToken: args.PageToken,
+		}
+		if req.Limit == 0 {
+			req.Limit = 50 // default
+		}
+
+		resp, err := client.ListEntries(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list deploy markers: %v", err)), nil
+		}
+
+		markers := make([]logging.DeployMarker, 0, len(resp.Entries))
+		for _, entry := range resp.Entries {
+			markers = append(markers, logging.NewDeployMarker(entry))
+		}
+
+		response := map[string]any{
+			"markers":     markers,
+			"console_url": logging.ConsoleURL(sessionProjectID(ctx, projectID), req.Filter),
+		}
+		if resp.NextPageToken != "" {
+			response["next_page_token"] = resp.NextPageToken
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// logVolumeHistogramArgs are the arguments of log_volume_histogram
+type logVolumeHistogramArgs struct {
+	Filter      string    `json:"filter"`
+	MinSeverity string    `json:"min_severity"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Bins        int       `json:"bins" validate:"min=1"`
+	MaxPerBin   int       `json:"max_per_bin" validate:"min=1"`
+}
+
+// createLogVolumeHistogramHandler creates a handler for counting log entries over time
+func createLogVolumeHistogramHandler(client logging.LoggingClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[logVolumeHistogramArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		entryFilter := logging.EntryFilter{Filter: args.Filter, MinSeverity: args.MinSeverity}
+		filter, err := entryFilter.Build()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := logging.VolumeHistogramRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    filter,
+			StartTime: args.StartTime,
+			EndTime:   args.EndTime,
+			Bins:      args.Bins,
+			MaxPerBin: args.MaxPerBin,
+		}
+
+		resp, err := client.VolumeHistogram(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to build log volume histogram: %v", err)), nil
+		}
+
+		counts := make([]float64, len(resp.Bins))
+		for i, b := range resp.Bins {
+			counts[i] = float64(b.Count)
+		}
+		resp.Sparkline = chart.Sparkline(counts, len(counts))
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal histogram: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// extractFieldValuesArgs are the arguments of extract_field_values
+type extractFieldValuesArgs struct {
+	Field       string `json:"field" validate:"required"`
+	Filter      string `json:"filter"`
+	MinSeverity string `json:"min_severity"`
+	MaxEntries  int    `json:"max_entries" validate:"min=1"`
+	Top         int    `json:"top" validate:"min=1"`
+}
+
+// createExtractFieldValuesHandler creates a handler for counting the distinct values of a log field
+func createExtractFieldValuesHandler(client logging.LoggingClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[extractFieldValuesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		entryFilter := logging.EntryFilter{Filter: args.Filter, MinSeverity: args.MinSeverity}
+		filter, err := entryFilter.Build()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := logging.FieldValuesRequest{
+			ProjectID:  session.FromContext(ctx).ProjectID,
+			Filter:     filter,
+			Field:      args.Field,
+			MaxEntries: args.MaxEntries,
+			Top:        args.Top,
+		}
+
+		resp, err := client.ExtractFieldValues(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to extract field values: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal field values: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
@@ -0,0 +1,1459 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/chart"
+	"github.com/kitagry/gcp-telemetry-mcp/export"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// createMetricDescriptorArgs are the arguments of create_metric_descriptor
+type createMetricDescriptorArgs struct {
+	Type        string `json:"type" validate:"required"`
+	MetricKind  string `json:"metric_kind" validate:"required,oneof=GAUGE DELTA CUMULATIVE"`
+	ValueType   string `json:"value_type" validate:"required,oneof=BOOL INT64 DOUBLE STRING DISTRIBUTION"`
+	Description string `json:"description" validate:"required"`
+	DisplayName string `json:"display_name"`
+}
+
+// createMetricDescriptorHandler creates a handler for creating metric descriptors
+func createMetricDescriptorHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[createMetricDescriptorArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Declare the write labels, which are attached to the time series
+		// written by write_time_series
+		defaults := session.FromContext(ctx)
+		var labels map[string]string
+		for key := range defaults.WriteLabels {
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[key] = "Attached by gcp-telemetry-mcp to written time series"
+		}
+
+		req := monitoring.CreateMetricRequest{
+			ProjectID: defaults.ProjectID,
+			MetricDescriptor: monitoring.MetricDescriptor{
+				Type:        args.Type,
+				MetricKind:  args.MetricKind,
+				ValueType:   args.ValueType,
+				Description: args.Description,
+				DisplayName: args.DisplayName,
+				Labels:      labels,
+			},
+		}
+
+		if err := req.MetricDescriptor.Validate(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		err = client.CreateMetricDescriptor(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to create metric descriptor: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText("Metric descriptor created successfully"), nil
+	}
+}
+
+// writeTimeSeriesArgs are the arguments of write_time_series, and of the
+// items of the time_series of write_time_series_batch
+type writeTimeSeriesArgs struct {
+	MetricType     string            `json:"metric_type" validate:"required"`
+	ResourceType   string            `json:"resource_type" validate:"required"`
+	Value          *number           `json:"value"`
+	Distribution   map[string]any    `json:"distribution"`
+	MetricLabels   map[string]string `json:"metric_labels"`
+	ResourceLabels map[string]string `json:"resource_labels"`
+	Timestamp      time.Time         `json:"timestamp"`
+	MetricKind     string            `json:"metric_kind" validate:"oneof=GAUGE DELTA CUMULATIVE"`
+	StartTime      time.Time         `json:"start_time"`
+}
+
+// createWriteTimeSeriesHandler creates a handler for writing time series data
+func createWriteTimeSeriesHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[writeTimeSeriesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		defaults := session.FromContext(ctx)
+		timeSeries, err := parseTimeSeriesArg(args, defaults)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := monitoring.WriteTimeSeriesRequest{
+			ProjectID:  defaults.ProjectID,
+			TimeSeries: []monitoring.TimeSeriesData{timeSeries},
+		}
+		err = client.WriteTimeSeries(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to write time series: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText("Time series data written successfully"), nil
+	}
+}
+
+// maxBatchTimeSeries bounds the series of write_time_series_batch, which are
+// written in one call
+const maxBatchTimeSeries = 100
+
+// seriesWriteReport reports the outcome of a write_time_series_batch call
+// in which some of the series failed
+type seriesWriteReport struct {
+	Written int                `json:"written"`
+	Failed  int                `json:"failed"`
+	Errors  []seriesWriteError `json:"errors"`
+}
+
+// seriesWriteError represents the failure to write some of the series of a
+// batch
+type seriesWriteError struct {
+	// Start and End delimit the series of time_series among which Failed
+	// series were not written, End excluded
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Failed int    `json:"failed"`
+	Error  string `json:"error"`
+}
+
+// writeTimeSeriesBatchArgs are the arguments of write_time_series_batch
+type writeTimeSeriesBatchArgs struct {
+	TimeSeries []map[string]any `json:"time_series" validate:"required,max=100"`
+}
+
+// createWriteTimeSeriesBatchHandler creates a handler for writing several
+// time series in one call. The API writes the valid series of the call even
+// when others are rejected, and the rejected ones are reported.
+func createWriteTimeSeriesBatchHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[writeTimeSeriesBatchArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Reject the whole batch when an item is invalid, before anything
+		// is written
+		defaults := session.FromContext(ctx)
+		var timeSeries []monitoring.TimeSeriesData
+		for i, item := range args.TimeSeries {
+			seriesArgs, err := decodeArgs[writeTimeSeriesArgs](item)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("time_series[%d]: %v", i, err)), nil
+			}
+			ts, err := parseTimeSeriesArg(seriesArgs, defaults)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("time_series[%d]: %v", i, err)), nil
+			}
+			timeSeries = append(timeSeries, ts)
+		}
+
+		err = client.WriteTimeSeries(ctx, monitoring.WriteTimeSeriesRequest{
+			ProjectID:  defaults.ProjectID,
+			TimeSeries: timeSeries,
+		})
+		var partial *monitoring.PartialWriteError
+		if err != nil && !errors.As(err, &partial) {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to write time series: %v", err)), nil
+		}
+
+		report := seriesWriteReport{Written: len(timeSeries), Errors: []seriesWriteError{}}
+		if partial != nil {
+			report.Written, report.Failed = partial.Written, partial.Failed
+			for _, chunk := range partial.Chunks {
+				if chunk.Failed > 0 {
+					report.Errors = append(report.Errors, seriesWriteError{Start: chunk.Start, End: chunk.End, Failed: chunk.Failed, Error: chunk.Error})
+				}
+			}
+		}
+
+		if report.Failed == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("%d time series written successfully", report.Written)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal write report: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// parseTimeSeriesArg converts the arguments of write_time_series, or an item
+// of the time_series of write_time_series_batch, into a series of one point
+func parseTimeSeriesArg(args writeTimeSeriesArgs, defaults session.Defaults) (monitoring.TimeSeriesData, error) {
+	// A point has either a number or a distribution value
+	var err error
+	point := monitoring.MetricValue{Timestamp: args.Timestamp}
+	switch {
+	case args.Distribution != nil && args.Value != nil:
+		return monitoring.TimeSeriesData{}, fmt.Errorf("value and distribution cannot be given together")
+	case args.Distribution != nil:
+		point.Distribution, err = parseDistributionArg(args.Distribution)
+		if err != nil {
+			return monitoring.TimeSeriesData{}, err
+		}
+	case args.Value != nil:
+		point.Value = float64(*args.Value)
+	default:
+		return monitoring.TimeSeriesData{}, fmt.Errorf("value is required, or distribution for DISTRIBUTION metrics")
+	}
+	if point.Timestamp.IsZero() {
+		point.Timestamp = time.Now()
+	}
+
+	// Points of CUMULATIVE and DELTA metrics cover an interval ending at
+	// the timestamp
+	point.StartTime = args.StartTime
+
+	timeSeries := monitoring.TimeSeriesData{
+		MetricType:     args.MetricType,
+		MetricKind:     args.MetricKind,
+		MetricLabels:   defaults.MergeWriteLabels(args.MetricLabels),
+		ResourceType:   args.ResourceType,
+		ResourceLabels: defaults.MergeResourceLabels(args.ResourceType, args.ResourceLabels),
+		Values:         []monitoring.MetricValue{point},
+	}
+	if err := timeSeries.Validate(); err != nil {
+		return monitoring.TimeSeriesData{}, err
+	}
+	return timeSeries, nil
+}
+
+// distributionArg is the distribution argument of write_time_series, given
+// either as raw samples or as bucket counts with their mean
+type distributionArg struct {
+	BucketOptions         monitoring.BucketOptions `json:"bucket_options"`
+	Samples               []float64                `json:"samples"`
+	BucketCounts          []int64                  `json:"bucket_counts"`
+	Mean                  *float64                 `json:"mean"`
+	SumOfSquaredDeviation float64                  `json:"sum_of_squared_deviation"`
+}
+
+// parseDistributionArg parses the distribution argument of write_time_series
+func parseDistributionArg(arg any) (*monitoring.Distribution, error) {
+	data, err := json.Marshal(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid distribution: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var parsed distributionArg
+	if err := decoder.Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("invalid distribution %s: %w", rawValue(arg), err)
+	}
+
+	switch {
+	case parsed.Samples != nil && parsed.BucketCounts != nil:
+		return nil, fmt.Errorf("distribution takes either samples or bucket_counts, not both")
+	case parsed.Samples != nil:
+		d, err := monitoring.NewDistribution(parsed.BucketOptions, parsed.Samples)
+		if err != nil {
+			return nil, fmt.Errorf("invalid distribution: %w", err)
+		}
+		return &d, nil
+	case parsed.BucketCounts != nil:
+		d := monitoring.Distribution{
+			BucketOptions:         parsed.BucketOptions,
+			BucketCounts:          parsed.BucketCounts,
+			SumOfSquaredDeviation: parsed.SumOfSquaredDeviation,
+		}
+		for _, count := range parsed.BucketCounts {
+			d.Count += count
+		}
+		if parsed.Mean == nil && d.Count > 0 {
+			return nil, fmt.Errorf("distribution needs the mean of the samples with bucket_counts")
+		}
+		if parsed.Mean != nil {
+			d.Mean = *parsed.Mean
+		}
+		if err := d.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid distribution: %w", err)
+		}
+		return &d, nil
+	default:
+		return nil, fmt.Errorf("distribution needs samples or bucket_counts")
+	}
+}
+
+// listTimeSeriesArgs are the arguments of list_time_series
+type listTimeSeriesArgs struct {
+	Filter         string         `json:"filter" validate:"required"`
+	StartTime      time.Time      `json:"start_time" validate:"required"`
+	EndTime        time.Time      `json:"end_time" validate:"required"`
+	Aggregation    map[string]any `json:"aggregation"`
+	PageSize       int            `json:"page_size" validate:"min=1"`
+	PageToken      string         `json:"page_token"`
+	Format         string         `json:"format" validate:"oneof=full summary aligned"`
+	SparklineWidth int            `json:"sparkline_width" validate:"min=1"`
+	Transform      string         `json:"transform" validate:"oneof=rate delta cumsum"`
+	MaxPoints      int            `json:"max_points" validate:"min=3"`
+	Downsample     string         `json:"downsample" validate:"oneof=lttb mean"`
+}
+
+// createListTimeSeriesHandler creates a handler for listing time series data
+func createListTimeSeriesHandler(client monitoring.MonitoringClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listTimeSeriesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := monitoring.ListTimeSeriesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+			PageSize:  args.PageSize,
+			PageToken: args.PageToken,
+		}
+		if req.PageSize == 0 {
+			req.PageSize = 100 // default
+		}
+		req.Interval.StartTime = args.StartTime
+		req.Interval.EndTime = args.EndTime
+		if args.Aggregation != nil {
+			req.Aggregation, err = parseAggregation(args.Aggregation)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		// Aligned output uses the alignment period as its grid step
+		var gridStep time.Duration
+		if args.Format == "aligned" && req.Aggregation != nil && req.Aggregation.AlignmentPeriod != "" {
+			gridStep, err = parseDurationArg("aggregation.alignment_period", req.Aggregation.AlignmentPeriod)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		resp, err := client.ListTimeSeries(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list time series: %v", err)), nil
+		}
+
+		// Apply the optional transform before downsampling, which would otherwise distort rates
+		if args.Transform != "" {
+			resp.TimeSeries, err = monitoring.Transform(resp.TimeSeries, args.Transform)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid transform: %v", err)), nil
+			}
+		}
+
+		resp.TimeSeries, err = downsampleTimeSeries(args.MaxPoints, args.Downsample, resp.TimeSeries)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		// Create a response object that includes both time series data and pagination info
+		response := map[string]any{
+			"time_series": resp.TimeSeries,
+			"console_url": monitoring.ConsoleURL(sessionProjectID(ctx, projectID), req.Filter, req.Aggregation),
+		}
+
+		switch args.Format {
+		case "summary":
+			// Summarize each series for text-only clients
+			summaries := make([]chart.SeriesSummary, 0, len(resp.TimeSeries))
+			for _, ts := range resp.TimeSeries {
+				summaries = append(summaries, chart.Summarize(ts, args.SparklineWidth))
+			}
+			response["time_series"] = summaries
+		case "aligned":
+			aligned, err := monitoring.Align(resp.TimeSeries, gridStep)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to align time series: %v", err)), nil
+			}
+			delete(response, "time_series")
+			response["timestamps"] = aligned.Timestamps
+			response["series"] = aligned.Series
+		}
+
+		// Add next_page_token if present
+		if resp.NextPageToken != "" {
+			response["next_page_token"] = resp.NextPageToken
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// downsampleTimeSeries downsamples each series to maxPoints points with
+// method, when maxPoints was given
+func downsampleTimeSeries(maxPoints int, method string, series []monitoring.TimeSeriesData) ([]monitoring.TimeSeriesData, error) {
+	if maxPoints == 0 {
+		return series, nil
+	}
+	downsampled, err := monitoring.Downsample(series, maxPoints, method)
+	if err != nil {
+		return nil, fmt.Errorf("invalid downsampling: %w", err)
+	}
+	return downsampled, nil
+}
+
+// parseAggregation parses an aggregation configuration object, rejecting
+// unknown aligners and reducers
+func parseAggregation(agg map[string]any) (*monitoring.AggregationConfig, error) {
+	aggConfig := &monitoring.AggregationConfig{}
+
+	if alignmentPeriod, exists := agg["alignment_period"]; exists {
+		if ap, ok := alignmentPeriod.(string); ok {
+			aggConfig.AlignmentPeriod = ap
+		}
+	}
+
+	if perSeriesAligner, exists := agg["per_series_aligner"]; exists {
+		if psa, ok := perSeriesAligner.(string); ok {
+			aggConfig.PerSeriesAligner = psa
+		}
+	}
+
+	if crossSeriesReducer, exists := agg["cross_series_reducer"]; exists {
+		if csr, ok := crossSeriesReducer.(string); ok {
+			aggConfig.CrossSeriesReducer = csr
+		}
+	}
+
+	if groupByFields, exists := agg["group_by_fields"]; exists {
+		if gbf, ok := groupByFields.([]any); ok {
+			for _, field := range gbf {
+				if fieldStr, ok := field.(string); ok {
+					aggConfig.GroupByFields = append(aggConfig.GroupByFields, fieldStr)
+				}
+			}
+		}
+	}
+
+	if err := aggConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid aggregation: %w", err)
+	}
+	return aggConfig, nil
+}
+
+// renderMetricChartArgs are the arguments of render_metric_chart
+type renderMetricChartArgs struct {
+	Filter      string         `json:"filter" validate:"required"`
+	StartTime   time.Time      `json:"start_time"`
+	EndTime     time.Time      `json:"end_time"`
+	Aggregation map[string]any `json:"aggregation"`
+	Format      string         `json:"format" validate:"oneof=png svg"`
+	Title       string         `json:"title"`
+	Width       int            `json:"width" validate:"min=1,max=4096"`
+	Height      int            `json:"height" validate:"min=1,max=4096"`
+	MaxSeries   int            `json:"max_series" validate:"min=1"`
+}
+
+// createRenderMetricChartHandler creates a handler for rendering time series data as a chart image
+func createRenderMetricChartHandler(client monitoring.MonitoringClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[renderMetricChartArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		endTime := args.EndTime
+		if endTime.IsZero() {
+			endTime = time.Now()
+		}
+		startTime := args.StartTime
+		if startTime.IsZero() {
+			startTime = endTime.Add(-time.Hour)
+		}
+
+		req := monitoring.ListTimeSeriesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+			PageSize:  100,
+		}
+		req.Interval.StartTime = startTime
+		req.Interval.EndTime = endTime
+		if args.Aggregation != nil {
+			req.Aggregation, err = parseAggregation(args.Aggregation)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		opts := chart.Options{
+			Title:     args.Title,
+			Format:    args.Format,
+			Width:     args.Width,
+			Height:    args.Height,
+			MaxSeries: args.MaxSeries,
+		}
+		if opts.Title == "" {
+			opts.Title = args.Filter
+		}
+
+		resp, err := client.ListTimeSeries(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list time series: %v", err)), nil
+		}
+
+		image, err := chart.Render(resp.TimeSeries, opts)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to render chart: %v", err)), nil
+		}
+
+		summary := fmt.Sprintf("Chart of %d time series from %s to %s", image.SeriesCount, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+		if image.DroppedSeries > 0 {
+			summary += fmt.Sprintf(" (%d more series omitted; narrow the filter or raise max_series)", image.DroppedSeries)
+		}
+		summary += "\nOpen in Cloud Console: " + monitoring.ConsoleURL(sessionProjectID(ctx, projectID), req.Filter, req.Aggregation)
+
+		return mcp.NewToolResultImage(summary, base64.StdEncoding.EncodeToString(image.Data), image.MIMEType), nil
+	}
+}
+
+// forecastMetricArgs are the arguments of forecast_metric
+type forecastMetricArgs struct {
+	Filter      string         `json:"filter" validate:"required"`
+	Threshold   *number        `json:"threshold" validate:"required"`
+	StartTime   time.Time      `json:"start_time"`
+	EndTime     time.Time      `json:"end_time"`
+	Aggregation map[string]any `json:"aggregation"`
+	Model       string         `json:"model" validate:"oneof=linear holt_winters"`
+	Season      duration       `json:"season"`
+	Horizon     duration       `json:"horizon"`
+	Direction   string         `json:"direction" validate:"oneof=rising falling"`
+	MaxSeries   int            `json:"max_series" validate:"min=1"`
+}
+
+// createForecastMetricHandler creates a handler for projecting threshold crossings of time series
+func createForecastMetricHandler(client monitoring.MonitoringClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[forecastMetricArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		endTime := args.EndTime
+		if endTime.IsZero() {
+			endTime = time.Now()
+		}
+		startTime := args.StartTime
+		if startTime.IsZero() {
+			startTime = endTime.Add(-7 * 24 * time.Hour)
+		}
+
+		opts := monitoring.ForecastOptions{
+			Threshold: float64(*args.Threshold),
+			Model:     args.Model,
+			Direction: args.Direction,
+			Season:    time.Duration(args.Season),
+			Horizon:   time.Duration(args.Horizon),
+		}
+		maxSeries := args.MaxSeries
+		if maxSeries == 0 {
+			maxSeries = 10 // default
+		}
+
+		req := monitoring.ListTimeSeriesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+			PageSize:  maxSeries,
+		}
+		req.Interval.StartTime = startTime
+		req.Interval.EndTime = endTime
+		if args.Aggregation != nil {
+			req.Aggregation, err = parseAggregation(args.Aggregation)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		resp, err := client.ListTimeSeries(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list time series: %v", err)), nil
+		}
+		if len(resp.TimeSeries) == 0 {
+			return mcp.NewToolResultError("No time series matched the filter in the given time range"), nil
+		}
+
+		forecasts := make([]map[string]any, 0, len(resp.TimeSeries))
+		for _, ts := range resp.TimeSeries[:min(len(resp.TimeSeries), maxSeries)] {
+			forecast := map[string]any{
+				"series":          chart.SeriesName(ts),
+				"metric_labels":   ts.MetricLabels,
+				"resource_labels": ts.ResourceLabels,
+			}
+			result, err := monitoring.ForecastSeries(ts.Values, opts)
+			if err != nil {
+				forecast["error"] = err.Error()
+			} else {
+				forecast["forecast"] = result
+			}
+			forecasts = append(forecasts, forecast)
+		}
+
+		response := map[string]any{
+			"forecasts":   forecasts,
+			"console_url": monitoring.ConsoleURL(sessionProjectID(ctx, projectID), req.Filter, req.Aggregation),
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// listMetricDescriptorsArgs are the arguments of list_metric_descriptors
+type listMetricDescriptorsArgs struct {
+	Filter    string `json:"filter"`
+	PageSize  int    `json:"page_size" validate:"min=1"`
+	PageToken string `json:"page_token"`
+	FetchAll  bool   `json:"fetch_all"`
+}
+
+// createListMetricDescriptorsHandler creates a handler for listing metric descriptors
+func createListMetricDescriptorsHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listMetricDescriptorsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := monitoring.ListMetricDescriptorsRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+			PageSize:  args.PageSize,
+			PageToken: args.PageToken,
+		}
+		if req.PageSize == 0 {
+			req.PageSize = 5 // デフォルト値
+		}
+
+		if args.FetchAll {
+			descriptors, pages, next, err := fetchAll(req.PageToken, func(pageToken string) ([]monitoring.MetricDescriptor, string, error) {
+				req.PageToken = pageToken
+				resp, err := client.ListMetricDescriptors(ctx, req)
+				return resp.Descriptors, resp.NextPageToken, err
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list metric descriptors: %v", err)), nil
+			}
+			responseJSON, err := json.MarshalIndent(fetchAllResponse("descriptors", descriptors, pages, next), "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(responseJSON)), nil
+		}
+
+		resp, err := client.ListMetricDescriptors(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list metric descriptors: %v", err)), nil
+		}
+
+		// Create a response object that includes both descriptors and pagination info
+		response := map[string]any{
+			"descriptors": resp.Descriptors,
+		}
+
+		// Add next_page_token if present
+		if resp.NextPageToken != "" {
+			response["next_page_token"] = resp.NextPageToken
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// deleteMetricDescriptorArgs are the arguments of delete_metric_descriptor
+type deleteMetricDescriptorArgs struct {
+	MetricType string `json:"metric_type" validate:"required"`
+}
+
+// createDeleteMetricDescriptorHandler creates a handler for deleting metric descriptors
+func createDeleteMetricDescriptorHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[deleteMetricDescriptorArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		err = client.DeleteMetricDescriptor(ctx, args.MetricType)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to delete metric descriptor: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText("Metric descriptor deleted successfully"), nil
+	}
+}
+
+// cloneMetricDescriptorArgs are the arguments of clone_metric_descriptor
+type cloneMetricDescriptorArgs struct {
+	SourceType  string   `json:"source_type" validate:"required"`
+	TargetType  string   `json:"target_type" validate:"required"`
+	DisplayName string   `json:"display_name"`
+	CopyData    bool     `json:"copy_data"`
+	Lookback    duration `json:"lookback"`
+}
+
+// createCloneMetricDescriptorHandler creates a handler for copying a metric descriptor to a new type
+func createCloneMetricDescriptorHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[cloneMetricDescriptorArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		result, err := client.CloneMetricDescriptor(ctx, monitoring.CloneMetricDescriptorRequest{
+			ProjectID:   session.FromContext(ctx).ProjectID,
+			SourceType:  args.SourceType,
+			TargetType:  args.TargetType,
+			DisplayName: args.DisplayName,
+			CopyData:    args.CopyData,
+			Lookback:    time.Duration(args.Lookback),
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to clone metric descriptor: %v", err)), nil
+		}
+
+		// Convert result to JSON
+		resultJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal cloned metric descriptor: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(resultJSON)), nil
+	}
+}
+
+// compareMetricDescriptorsArgs are the arguments of compare_metric_descriptors
+type compareMetricDescriptorsArgs struct {
+	SourceProject string `json:"source_project"`
+	TargetProject string `json:"target_project" validate:"required"`
+	Prefix        string `json:"prefix"`
+}
+
+// createCompareMetricDescriptorsHandler creates a handler for comparing the metric descriptors of two projects
+func createCompareMetricDescriptorsHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[compareMetricDescriptorsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if args.SourceProject == "" {
+			args.SourceProject = session.FromContext(ctx).ProjectID
+		}
+
+		resp, err := client.CompareMetricDescriptors(ctx, monitoring.CompareMetricDescriptorsRequest{
+			SourceProject: args.SourceProject,
+			TargetProject: args.TargetProject,
+			Prefix:        args.Prefix,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to compare metric descriptors: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal metric descriptor comparison: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// findUnusedMetricsArgs are the arguments of find_unused_metrics
+type findUnusedMetricsArgs struct {
+	Prefix  string    `json:"prefix"`
+	Days    int       `json:"days" validate:"min=1"`
+	EndTime time.Time `json:"end_time"`
+	Delete  bool      `json:"delete"`
+	Confirm []string  `json:"confirm"`
+}
+
+// findUnusedMetricsResult is the response of find_unused_metrics, with the
+// outcome of the deletion when it was asked for
+type findUnusedMetricsResult struct {
+	monitoring.FindUnusedMetricsResponse
+	Deleted []string `json:"deleted,omitempty"`
+	// Skipped lists the confirmed metric types that are no longer unused
+	Skipped      []string          `json:"skipped,omitempty"`
+	DeleteErrors map[string]string `json:"delete_errors,omitempty"`
+	Note         string            `json:"note,omitempty"`
+}
+
+// createFindUnusedMetricsHandler creates a handler for finding, and optionally
+// deleting, metric descriptors without data. Deletion is refused unless
+// allowDelete.
+func createFindUnusedMetricsHandler(client monitoring.MonitoringClient, allowDelete bool) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[findUnusedMetricsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if args.Delete && !allowDelete {
+			return mcp.NewToolResultError("delete is not allowed in read-only mode"), nil
+		}
+		if args.Delete && len(args.Confirm) == 0 {
+			return mcp.NewToolResultError("delete requires confirm, listing the metric types to delete from the unused metrics of a previous call"), nil
+		}
+
+		resp, err := client.FindUnusedMetrics(ctx, monitoring.FindUnusedMetricsRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Prefix:    args.Prefix,
+			Days:      args.Days,
+			EndTime:   args.EndTime,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to find unused metrics: %v", err)), nil
+		}
+
+		result := findUnusedMetricsResult{FindUnusedMetricsResponse: resp}
+		if args.Delete {
+			// Only the confirmed metrics that are still unused are deleted
+			unused := make(map[string]bool)
+			for _, m := range resp.Unused {
+				unused[m.Type] = true
+			}
+			var types []string
+			for _, metricType := range args.Confirm {
+				if unused[metricType] {
+					types = append(types, metricType)
+				} else {
+					result.Skipped = append(result.Skipped, metricType)
+				}
+			}
+			if len(types) > 0 {
+				deleted, err := client.DeleteMetricDescriptors(ctx, monitoring.DeleteMetricDescriptorsRequest{
+					ProjectID: session.FromContext(ctx).ProjectID,
+					Types:     types,
+				})
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to delete unused metrics: %v", err)), nil
+				}
+				for _, r := range deleted.Results {
+					if r.Deleted {
+						result.Deleted = append(result.Deleted, r.Type)
+						continue
+					}
+					if result.DeleteErrors == nil {
+						result.DeleteErrors = make(map[string]string)
+					}
+					result.DeleteErrors[r.Type] = r.Error
+				}
+			}
+		} else if len(resp.Unused) > 0 && allowDelete {
+			result.Note = "To delete unused metrics, call again with delete=true and confirm listing their types. Deleting a descriptor also deletes its data and cannot be undone"
+		}
+
+		// Convert result to JSON
+		resultJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal unused metrics: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(resultJSON)), nil
+	}
+}
+
+// deleteMetricDescriptorsArgs are the arguments of delete_metric_descriptors
+type deleteMetricDescriptorsArgs struct {
+	Filter string   `json:"filter"`
+	Types  []string `json:"types"`
+	DryRun *bool    `json:"dry_run"`
+}
+
+// createDeleteMetricDescriptorsHandler creates a handler for previewing and deleting several metric descriptors
+func createDeleteMetricDescriptorsHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[deleteMetricDescriptorsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if args.Filter == "" && len(args.Types) == 0 {
+			return mcp.NewToolResultError("filter or types is required"), nil
+		}
+
+		resp, err := client.DeleteMetricDescriptors(ctx, monitoring.DeleteMetricDescriptorsRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+			Types:     args.Types,
+			// Matches are previewed unless deletion is asked for explicitly
+			DryRun: args.DryRun == nil || *args.DryRun,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to delete metric descriptors: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal deleted metric descriptors: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// listAvailableMetricsArgs are the arguments of list_available_metrics
+type listAvailableMetricsArgs struct {
+	Filter    string `json:"filter"`
+	PageSize  int    `json:"page_size" validate:"min=1"`
+	PageToken string `json:"page_token"`
+}
+
+// createListAvailableMetricsHandler creates a handler for listing available metrics
+func createListAvailableMetricsHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listAvailableMetricsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := monitoring.ListAvailableMetricsRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+			PageSize:  args.PageSize,
+			PageToken: args.PageToken,
+		}
+		if req.PageSize == 0 {
+			req.PageSize = 100 // default
+		}
+
+		metrics, err := client.ListAvailableMetrics(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list available metrics: %v", err)), nil
+		}
+
+		// Convert metrics to JSON for response
+		metricsJSON, err := json.MarshalIndent(metrics, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal available metrics: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(metricsJSON)), nil
+	}
+}
+
+// searchMetricsArgs are the arguments of search_metrics
+type searchMetricsArgs struct {
+	Query   string `json:"query" validate:"required"`
+	Limit   int    `json:"limit" validate:"min=1"`
+	Refresh bool   `json:"refresh"`
+}
+
+// createSearchMetricsHandler creates a handler for searching metric descriptors
+func createSearchMetricsHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[searchMetricsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := monitoring.SearchMetricsRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Query:     args.Query,
+			Limit:     args.Limit,
+			Refresh:   args.Refresh,
+		}
+		if req.Limit == 0 {
+			req.Limit = 20 // default
+		}
+
+		results, err := client.SearchMetrics(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to search metrics: %v", err)), nil
+		}
+
+		// Convert results to JSON for response
+		resultsJSON, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal metrics: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(resultsJSON)), nil
+	}
+}
+
+// getQuotaUsageArgs are the arguments of get_quota_usage
+type getQuotaUsageArgs struct {
+	Service        string    `json:"service"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	MinUtilization float64   `json:"min_utilization" validate:"min=0"`
+}
+
+// createGetQuotaUsageHandler creates a handler for inspecting quota usage against limits
+func createGetQuotaUsageHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[getQuotaUsageArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := monitoring.QuotaUsageRequest{
+			ProjectID:      session.FromContext(ctx).ProjectID,
+			Service:        args.Service,
+			StartTime:      args.StartTime,
+			EndTime:        args.EndTime,
+			MinUtilization: args.MinUtilization,
+		}
+
+		resp, err := client.GetQuotaUsage(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get quota usage: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal quota usage: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// listMonitoredResourcesArgs are the arguments of list_monitored_resources
+type listMonitoredResourcesArgs struct {
+	ResourceType string    `json:"resource_type"`
+	MetricType   string    `json:"metric_type"`
+	GroupBy      []string  `json:"group_by"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time"`
+}
+
+// createListMonitoredResourcesHandler creates a handler for discovering the resources emitting telemetry
+func createListMonitoredResourcesHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listMonitoredResourcesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		resp, err := client.ListMonitoredResources(ctx, monitoring.ListMonitoredResourcesRequest{
+			ProjectID:    session.FromContext(ctx).ProjectID,
+			ResourceType: args.ResourceType,
+			MetricType:   args.MetricType,
+			GroupBy:      args.GroupBy,
+			StartTime:    args.StartTime,
+			EndTime:      args.EndTime,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list monitored resources: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal monitored resources: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// describeResourceArgs are the arguments of describe_resource
+type describeResourceArgs struct {
+	ResourceType string            `json:"resource_type" validate:"required"`
+	Labels       map[string]string `json:"labels"`
+	MetricType   string            `json:"metric_type"`
+	StartTime    time.Time         `json:"start_time"`
+	EndTime      time.Time         `json:"end_time"`
+}
+
+// createDescribeResourceHandler creates a handler for looking up the metadata of a monitored resource
+func createDescribeResourceHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[describeResourceArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		resp, err := client.DescribeResource(ctx, monitoring.DescribeResourceRequest{
+			ProjectID:    session.FromContext(ctx).ProjectID,
+			ResourceType: args.ResourceType,
+			Labels:       args.Labels,
+			MetricType:   args.MetricType,
+			StartTime:    args.StartTime,
+			EndTime:      args.EndTime,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to describe resource: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal resource description: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// analyzeMetricCardinalityArgs are the arguments of analyze_metric_cardinality
+type analyzeMetricCardinalityArgs struct {
+	MetricType string    `json:"metric_type" validate:"required"`
+	Filter     string    `json:"filter"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Threshold  int       `json:"threshold" validate:"min=1"`
+}
+
+// createAnalyzeMetricCardinalityHandler creates a handler for analyzing the label cardinality of a metric
+func createAnalyzeMetricCardinalityHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[analyzeMetricCardinalityArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		result, err := client.AnalyzeMetricCardinality(ctx, monitoring.MetricCardinalityRequest{
+			ProjectID:  session.FromContext(ctx).ProjectID,
+			MetricType: args.MetricType,
+			Filter:     args.Filter,
+			StartTime:  args.StartTime,
+			EndTime:    args.EndTime,
+			Threshold:  args.Threshold,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to analyze metric cardinality: %v", err)), nil
+		}
+
+		// Convert result to JSON
+		resultJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal metric cardinality: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(resultJSON)), nil
+	}
+}
+
+// estimateQueryCostArgs are the arguments of estimate_query_cost
+type estimateQueryCostArgs struct {
+	Filter      string         `json:"filter" validate:"required"`
+	StartTime   time.Time      `json:"start_time" validate:"required"`
+	EndTime     time.Time      `json:"end_time" validate:"required"`
+	Aggregation map[string]any `json:"aggregation"`
+	PageSize    int            `json:"page_size" validate:"min=1"`
+}
+
+// createEstimateQueryCostHandler creates a handler for estimating the size of a time series query
+func createEstimateQueryCostHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[estimateQueryCostArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := monitoring.QueryCostRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+			StartTime: args.StartTime,
+			EndTime:   args.EndTime,
+			PageSize:  args.PageSize,
+		}
+		if args.Aggregation != nil {
+			req.Aggregation, err = parseAggregation(args.Aggregation)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		estimate, err := client.EstimateQueryCost(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to estimate query cost: %v", err)), nil
+		}
+
+		// Convert estimate to JSON
+		estimateJSON, err := json.MarshalIndent(estimate, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal query cost estimate: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(estimateJSON)), nil
+	}
+}
+
+// simulateBurnRateArgs are the arguments of simulate_burn_rate
+type simulateBurnRateArgs struct {
+	SLOName     string           `json:"slo_name"`
+	Goal        float64          `json:"goal"`
+	Period      duration         `json:"period"`
+	GoodFilter  string           `json:"good_filter"`
+	BadFilter   string           `json:"bad_filter"`
+	TotalFilter string           `json:"total_filter"`
+	StartTime   time.Time        `json:"start_time"`
+	EndTime     time.Time        `json:"end_time"`
+	Policies    []map[string]any `json:"policies"`
+}
+
+// burnRatePolicyArgs are the items of the policies of simulate_burn_rate
+type burnRatePolicyArgs struct {
+	Name        string   `json:"name"`
+	BurnRate    float64  `json:"burn_rate" validate:"required"`
+	LongWindow  duration `json:"long_window" validate:"required"`
+	ShortWindow duration `json:"short_window" validate:"required"`
+}
+
+// createSimulateBurnRateHandler creates a handler for replaying burn-rate alerts over SLO history
+func createSimulateBurnRateHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[simulateBurnRateArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if args.SLOName == "" && args.Goal == 0 {
+			return mcp.NewToolResultError("either slo_name or goal with two of good_filter, bad_filter, and total_filter is required"), nil
+		}
+
+		req := monitoring.BurnRateRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			SLOName:   args.SLOName,
+			SLO: monitoring.ServiceLevelObjective{
+				Goal:        args.Goal,
+				Period:      time.Duration(args.Period),
+				GoodFilter:  args.GoodFilter,
+				BadFilter:   args.BadFilter,
+				TotalFilter: args.TotalFilter,
+			},
+			StartTime: args.StartTime,
+			EndTime:   args.EndTime,
+		}
+		if req.SLO.Period == 0 {
+			req.SLO.Period = 30 * 24 * time.Hour
+		}
+		if req.EndTime.IsZero() {
+			req.EndTime = time.Now()
+		}
+		if req.StartTime.IsZero() {
+			req.StartTime = req.EndTime.Add(-7 * 24 * time.Hour)
+		}
+
+		for i, item := range args.Policies {
+			policyArgs, err := decodeArgs[burnRatePolicyArgs](item)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("policies[%d]: %v", i, err)), nil
+			}
+			policy := monitoring.BurnRatePolicy{
+				Name:        policyArgs.Name,
+				BurnRate:    policyArgs.BurnRate,
+				LongWindow:  time.Duration(policyArgs.LongWindow),
+				ShortWindow: time.Duration(policyArgs.ShortWindow),
+			}
+			if policy.Name == "" {
+				policy.Name = fmt.Sprintf("policy-%d", i+1)
+			}
+			req.Policies = append(req.Policies, policy)
+		}
+
+		simulation, err := client.SimulateBurnRate(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to simulate burn rate: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(simulation, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal simulation: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// lintAlertPoliciesArgs are the arguments of lint_alert_policies
+type lintAlertPoliciesArgs struct {
+	Filter string `json:"filter"`
+}
+
+// createLintAlertPoliciesHandler creates a handler for linting alerting policies
+func createLintAlertPoliciesHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[lintAlertPoliciesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := monitoring.LintAlertPoliciesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+		}
+
+		resp, err := client.LintAlertPolicies(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to lint alert policies: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal lint findings: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// exportMonitoringConfigArgs are the arguments of export_monitoring_config
+type exportMonitoringConfigArgs struct {
+	Kinds             []string `json:"kinds"`
+	Format            string   `json:"format" validate:"oneof=terraform yaml"`
+	AlertPolicyFilter string   `json:"alert_policy_filter"`
+	MetricTypePrefix  string   `json:"metric_type_prefix"`
+}
+
+// createExportMonitoringConfigHandler creates a handler for exporting monitoring configuration
+func createExportMonitoringConfigHandler(exporter *export.Exporter) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[exportMonitoringConfigArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := export.Request{
+			ProjectID:         session.FromContext(ctx).ProjectID,
+			Kinds:             args.Kinds,
+			Format:            args.Format,
+			AlertPolicyFilter: args.AlertPolicyFilter,
+			MetricTypePrefix:  args.MetricTypePrefix,
+		}
+
+		result, err := exporter.Export(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to export monitoring config: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal export: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// applyDashboardJSONArgs are the arguments of apply_dashboard_json
+type applyDashboardJSONArgs struct {
+	Definition any    `json:"definition" validate:"required"`
+	Mode       string `json:"mode" validate:"oneof=auto create update"`
+	DryRun     bool   `json:"dry_run"`
+}
+
+// applyAlertPolicyJSONArgs are the arguments of apply_alert_policy_json
+type applyAlertPolicyJSONArgs struct {
+	Definition           any      `json:"definition" validate:"required"`
+	Mode                 string   `json:"mode" validate:"oneof=auto create update"`
+	NotificationChannels []string `json:"notification_channels"`
+	DryRun               bool     `json:"dry_run"`
+}
+
+// parseApplyRequest parses the arguments shared by the apply_*_json tools.
+// The definition may also be passed as a JSON object instead of a string.
+func parseApplyRequest(ctx context.Context, definition any, mode string, dryRun bool) (monitoring.ApplyRequest, error) {
+	req := monitoring.ApplyRequest{
+		ProjectID: session.FromContext(ctx).ProjectID,
+		Mode:      mode,
+		DryRun:    dryRun,
+	}
+
+	switch definition := definition.(type) {
+	case string:
+		req.Definition = json.RawMessage(definition)
+	case map[string]any:
+		data, err := json.Marshal(definition)
+		if err != nil {
+			return req, fmt.Errorf("invalid definition: %w", err)
+		}
+		req.Definition = data
+	default:
+		return req, fmt.Errorf("definition must be a string or an object, got %s", rawValue(definition))
+	}
+	if !json.Valid(req.Definition) {
+		return req, fmt.Errorf("definition is not valid JSON")
+	}
+	return req, nil
+}
+
+// createApplyAlertPolicyJSONHandler creates a handler for applying alert policy JSON
+func createApplyAlertPolicyJSONHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[applyAlertPolicyJSONArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		req, err := parseApplyRequest(ctx, args.Definition, args.Mode, args.DryRun)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		req.NotificationChannels = args.NotificationChannels
+
+		result, err := client.ApplyAlertPolicy(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to apply alert policy: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// createApplyDashboardJSONHandler creates a handler for applying dashboard JSON
+func createApplyDashboardJSONHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[applyDashboardJSONArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		req, err := parseApplyRequest(ctx, args.Definition, args.Mode, args.DryRun)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		result, err := client.ApplyDashboard(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to apply dashboard: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/notifications"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// listRecentNotificationsArgs are the arguments of list_recent_notifications
+type listRecentNotificationsArgs struct {
+	State  string    `json:"state" validate:"oneof=open closed"`
+	Policy string    `json:"policy"`
+	Since  time.Time `json:"since"`
+	Limit  int       `json:"limit"`
+}
+
+// createListRecentNotificationsHandler creates a handler for listing recent alert notifications
+func createListRecentNotificationsHandler(subscriber *notifications.Subscriber) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listRecentNotificationsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		req := notifications.ListRequest{
+			State:  args.State,
+			Policy: args.Policy,
+			Since:  args.Since,
+			Limit:  args.Limit,
+		}
+
+		resp := subscriber.List(req)
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal notifications: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import "fmt"
+
+// maxFetchAllPages caps the pages walked by the fetch_all argument, so that a
+// broad query cannot call Google Cloud without bound
+const maxFetchAllPages = 20
+
+// fetchAllDescription describes the fetch_all argument of the list tools
+var fetchAllDescription = fmt.Sprintf("Walk all pages, starting at page_token, and return the combined result with the number of pages fetched (at most %d pages; next_page_token is returned when more are left)", maxFetchAllPages)
+
+// fetchAll calls fetch with pageToken, then with the token of each next page,
+// until no page is left or maxFetchAllPages were fetched. It returns the
+// items of all the pages, the number of pages, and the token of the next page
+// when the cap was hit.
+func fetchAll[T any](pageToken string, fetch func(pageToken string) ([]T, string, error)) ([]T, int, string, error) {
+	var all []T
+	for pages := 1; ; pages++ {
+		items, next, err := fetch(pageToken)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		all = append(all, items...)
+		if next == "" || pages == maxFetchAllPages {
+			return all, pages, next, nil
+		}
+		pageToken = next
+	}
+}
+
+// fetchAllResponse returns the response of a list tool called with fetch_all,
+// with the items under key
+func fetchAllResponse[T any](key string, items []T, pages int, nextPageToken string) map[string]any {
+	if items == nil {
+		items = []T{}
+	}
+	response := map[string]any{key: items}
+	addPageInfo(response, true, pages, nextPageToken)
+	return response
+}
+
+// addPageInfo adds the token of the next page to the response of a list
+// tool, with the number of pages and whether the cap was hit when it was
+// called with fetch_all
+func addPageInfo(response map[string]any, fetchAll bool, pages int, nextPageToken string) {
+	if fetchAll {
+		response["pages"] = pages
+	}
+	if nextPageToken != "" {
+		response["next_page_token"] = nextPageToken
+		if fetchAll {
+			response["truncated"] = true
+		}
+	}
+}
+
+const (
+	// maxCountedItems caps the matches counted by the count_only argument
+	maxCountedItems = 10000
+	// countPageSize is the number of matches fetched per page when counting
+	countPageSize = 1000
+)
+
+// countOnlyDescription describes the count_only argument of the list tools
+var countOnlyDescription = fmt.Sprintf("Only return the number of matches, e.g. to size a problem before pulling the data. Matches are counted up to %d, without returning their payloads; page_token and the paging and view arguments are ignored", maxCountedItems)
+
+// countAll counts the matches of a list by calling fetch with the size and
+// token of each page, starting without a token, until no page is left or
+// maxCountedItems were counted. fetch returns the number of matches of the
+// page and the token of the next page. The count is truncated when matches
+// are left past the cap.
+func countAll(fetch func(pageSize int, pageToken string) (int, string, error)) (count int, truncated bool, err error) {
+	pageToken := ""
+	for {
+		n, next, err := fetch(min(countPageSize, maxCountedItems-count), pageToken)
+		if err != nil {
+			return 0, false, err
+		}
+		count += n
+		if next == "" {
+			return count, false, nil
+		}
+		if count >= maxCountedItems {
+			return count, true, nil
+		}
+		pageToken = next
+	}
+}
+
+// countResponse returns the response of a list tool called with count_only
+func countResponse(count int, truncated bool) map[string]any {
+	response := map[string]any{"count": count}
+	if truncated {
+		response["truncated"] = true
+		response["note"] = fmt.Sprintf("Counting stopped at %d matches; narrow the filter or the time range for an exact count", maxCountedItems)
+	}
+	return response
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestFetchAll(t *testing.T) {
+	// fetchPages returns a page with one item per call, out of n pages
+	fetchPages := func(n int) func(string) ([]int, string, error) {
+		return func(pageToken string) ([]int, string, error) {
+			page := 0
+			if pageToken != "" {
+				page, _ = strconv.Atoi(pageToken)
+			}
+			next := ""
+			if page+1 < n {
+				next = strconv.Itoa(page + 1)
+			}
+			return []int{page}, next, nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		pageToken string
+		pages     int
+		wantItems int
+		wantPages int
+		wantNext  string
+	}{
+		{name: "single page", pages: 1, wantItems: 1, wantPages: 1},
+		{name: "all pages", pages: 5, wantItems: 5, wantPages: 5},
+		{name: "from a page token", pageToken: "3", pages: 5, wantItems: 2, wantPages: 2},
+		{name: "capped", pages: maxFetchAllPages + 5, wantItems: maxFetchAllPages, wantPages: maxFetchAllPages, wantNext: strconv.Itoa(maxFetchAllPages)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, pages, next, err := fetchAll(tt.pageToken, fetchPages(tt.pages))
+			if err != nil {
+				t.Fatalf("fetchAll() error = %v", err)
+			}
+			if len(items) != tt.wantItems || pages != tt.wantPages || next != tt.wantNext {
+				t.Errorf("fetchAll() = %d items, %d pages, %q, want %d items, %d pages, %q", len(items), pages, next, tt.wantItems, tt.wantPages, tt.wantNext)
+			}
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, _, _, err := fetchAll("", func(string) ([]int, string, error) { return nil, "", errors.New("denied") })
+		if err == nil {
+			t.Error("Expected the error of the page")
+		}
+	})
+}
+
+func TestCountAll(t *testing.T) {
+	// fetchMatches returns the pages of n matches
+	fetchMatches := func(n int) func(int, string) (int, string, error) {
+		return func(pageSize int, pageToken string) (int, string, error) {
+			offset := 0
+			if pageToken != "" {
+				offset, _ = strconv.Atoi(pageToken)
+			}
+			end := min(offset+pageSize, n)
+			next := ""
+			if end < n {
+				next = strconv.Itoa(end)
+			}
+			return end - offset, next, nil
+		}
+	}
+
+	tests := []struct {
+		name          string
+		matches       int
+		wantCount     int
+		wantTruncated bool
+	}{
+		{name: "none", matches: 0, wantCount: 0},
+		{name: "single page", matches: 10, wantCount: 10},
+		{name: "several pages", matches: 2*countPageSize + 1, wantCount: 2*countPageSize + 1},
+		{name: "exactly the cap", matches: maxCountedItems, wantCount: maxCountedItems},
+		{name: "capped", matches: maxCountedItems + 1, wantCount: maxCountedItems, wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, truncated, err := countAll(fetchMatches(tt.matches))
+			if err != nil {
+				t.Fatalf("countAll() error = %v", err)
+			}
+			if count != tt.wantCount || truncated != tt.wantTruncated {
+				t.Errorf("countAll() = %d, %v, want %d, %v", count, truncated, tt.wantCount, tt.wantTruncated)
+			}
+		})
+	}
+}
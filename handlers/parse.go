@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Examples of the expected formats, included in the errors of bad arguments
+// so that the caller can correct its next call
+const (
+	timeExample     = "2026-01-02T15:04:05Z"
+	durationExample = "5m"
+)
+
+// timeFormats describes the timestamps accepted by ParseTime
+var timeFormats = fmt.Sprintf("an RFC 3339 timestamp (e.g. %q), %q in UTC, or Unix epoch seconds or milliseconds", timeExample, "2026-01-02 15:04:05")
+
+// timeLayouts are the layouts accepted by ParseTime besides RFC 3339. The
+// layouts without a time zone are in UTC.
+var timeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+// minEpochMillis separates Unix epoch seconds from milliseconds: as seconds,
+// it would be in the year 5138
+const minEpochMillis = 1e11
+
+// maxRawValueLength bounds the length of a received value quoted in an error
+const maxRawValueLength = 100
+
+// ParseTime parses a timestamp given in RFC 3339, as "2006-01-02 15:04:05"
+// in UTC, or as Unix epoch seconds or milliseconds, which can have a
+// fractional part
+func ParseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+		if math.Abs(n) >= minEpochMillis {
+			if n == math.Trunc(n) {
+				return time.UnixMilli(int64(n)).UTC(), nil
+			}
+			n /= 1000
+		}
+		// Fractions are rounded to microseconds to drop the float noise
+		seconds, fraction := math.Modf(n)
+		return time.Unix(int64(seconds), int64(math.Round(fraction*1e6))*1e3).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q: must be %s", value, timeFormats)
+}
+
+// parseTimeArg parses the timestamp value of the argument name with ParseTime
+func parseTimeArg(name, value string) (time.Time, error) {
+	t, err := ParseTime(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: must be %s", name, value, timeFormats)
+	}
+	return t, nil
+}
+
+// parseDurationArg parses the Go duration value of the argument name
+func parseDurationArg(name, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: must be a duration with a unit, e.g. %q", name, value, durationExample)
+	}
+	return d, nil
+}
+
+// rawValue formats a received argument value as JSON for an error message,
+// truncated so that large values do not flood the result
+func rawValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(data) > maxRawValueLength {
+		return string(data[:maxRawValueLength]) + "..."
+	}
+	return string(data)
+}
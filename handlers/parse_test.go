@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "2026-01-02T03:04:05Z", want: want},
+		{value: "2026-01-02T12:04:05+09:00", want: want},
+		{value: "2026-01-02T03:04:05.25Z", want: want.Add(250 * time.Millisecond)},
+		{value: "2026-01-02 03:04:05", want: want},
+		{value: "2026-01-02T03:04:05", want: want},
+		{value: "1767323045", want: want},
+		{value: "1767323045.5", want: want.Add(500 * time.Millisecond)},
+		{value: "1767323045123", want: want.Add(123 * time.Millisecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseTime(tt.value)
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("ParseTime() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeArg(t *testing.T) {
+	for _, value := range []string{"yesterday", "2026-01-02", "NaN"} {
+		_, err := parseTimeArg("start_time", value)
+		want := fmt.Sprintf(`invalid start_time %q: must be an RFC 3339 timestamp (e.g. "2026-01-02T15:04:05Z"), "2026-01-02 15:04:05" in UTC, or Unix epoch seconds or milliseconds`, value)
+		if err == nil || err.Error() != want {
+			t.Errorf("parseTimeArg() error = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestParseDurationArg(t *testing.T) {
+	_, err := parseDurationArg("season", "24")
+	want := `invalid season "24": must be a duration with a unit, e.g. "5m"`
+	if err == nil || err.Error() != want {
+		t.Errorf("parseDurationArg() error = %v, want %q", err, want)
+	}
+}
+
+func TestRawValue(t *testing.T) {
+	if got := rawValue("5 minutes"); got != `"5 minutes"` {
+		t.Errorf("rawValue() = %s", got)
+	}
+	if got := rawValue(strings.Repeat("a", 200)); len(got) != maxRawValueLength+3 || !strings.HasSuffix(got, "...") {
+		t.Errorf("Expected long values to be truncated, got %s", got)
+	}
+}
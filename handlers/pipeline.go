@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// templatePattern matches the references to the results of earlier steps in
+// the arguments of batch steps, e.g. {{slow.traces[0].trace_id}}
+var templatePattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// stepNamePattern matches the names of batch steps, which start references
+var stepNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// itemRef refers to the current item of a step with for_each
+const itemRef = "item"
+
+// templateRefs returns the names of the steps referenced in v, a decoded
+// JSON value. References to the item of for_each are left out.
+func templateRefs(v any) []string {
+	var names []string
+	walkStrings(v, func(s string) {
+		for _, match := range templatePattern.FindAllStringSubmatch(s, -1) {
+			name, _ := splitRef(match[1])
+			if name != itemRef {
+				names = append(names, name)
+			}
+		}
+	})
+	return names
+}
+
+// walkStrings calls f with the strings of v, a decoded JSON value
+func walkStrings(v any, f func(string)) {
+	switch v := v.(type) {
+	case string:
+		f(v)
+	case map[string]any:
+		for _, value := range v {
+			walkStrings(value, f)
+		}
+	case []any:
+		for _, value := range v {
+			walkStrings(value, f)
+		}
+	}
+}
+
+// expandTemplates replaces the references in v, a decoded JSON value, with
+// the values returned by lookup. A string holding a single reference is
+// replaced by the value as is, e.g. an array of trace IDs; references
+// inside longer strings are replaced by their text.
+func expandTemplates(v any, lookup func(name string) (any, error)) (any, error) {
+	switch v := v.(type) {
+	case string:
+		if match := templatePattern.FindStringSubmatch(v); match != nil && match[0] == v {
+			return resolveRef(match[1], lookup)
+		}
+		var err error
+		expanded := templatePattern.ReplaceAllStringFunc(v, func(s string) string {
+			if err != nil {
+				return s
+			}
+			var value any
+			value, err = resolveRef(templatePattern.FindStringSubmatch(s)[1], lookup)
+			if text, ok := value.(string); ok {
+				return text
+			}
+			data, _ := json.Marshal(value)
+			return string(data)
+		})
+		return expanded, err
+	case map[string]any:
+		expanded := make(map[string]any, len(v))
+		for key, value := range v {
+			var err error
+			if expanded[key], err = expandTemplates(value, lookup); err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	case []any:
+		expanded := make([]any, len(v))
+		for i, value := range v {
+			var err error
+			if expanded[i], err = expandTemplates(value, lookup); err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	}
+	return v, nil
+}
+
+// splitRef splits a reference into the step name and the path into its result
+func splitRef(ref string) (name, path string) {
+	if i := strings.IndexAny(ref, ".["); i >= 0 {
+		return ref[:i], ref[i:]
+	}
+	return ref, ""
+}
+
+// resolveRef returns the value a reference such as slow.traces[*].trace_id
+// points to
+func resolveRef(ref string, lookup func(name string) (any, error)) (any, error) {
+	name, path := splitRef(ref)
+	value, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	value, err = evalPath(value, path)
+	if err != nil {
+		return nil, fmt.Errorf("{{%s}}: %w", ref, err)
+	}
+	return value, nil
+}
+
+// evalPath follows a path of .key, [N], and [*] selectors into value. [*]
+// selects the rest of the path in all the items of an array.
+func evalPath(value any, path string) (any, error) {
+	for path != "" {
+		switch {
+		case strings.HasPrefix(path, "[*]"):
+			items, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("[*] needs an array, got %s", jsonKind(value))
+			}
+			selected := make([]any, len(items))
+			for i, item := range items {
+				var err error
+				if selected[i], err = evalPath(item, path[3:]); err != nil {
+					return nil, err
+				}
+			}
+			return selected, nil
+		case strings.HasPrefix(path, "["):
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in path")
+			}
+			index, err := strconv.Atoi(path[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q: use a number or *", path[1:end])
+			}
+			items, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("[%d] needs an array, got %s", index, jsonKind(value))
+			}
+			if index < 0 {
+				index += len(items)
+			}
+			if index < 0 || index >= len(items) {
+				return nil, fmt.Errorf("index %s out of range of %d items", path[1:end], len(items))
+			}
+			value, path = items[index], path[end+1:]
+		case strings.HasPrefix(path, "."):
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			key := path[:end]
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf(".%s needs an object, got %s", key, jsonKind(value))
+			}
+			if value, ok = object[key]; !ok {
+				return nil, fmt.Errorf("no field %q", key)
+			}
+			path = path[end:]
+		default:
+			return nil, fmt.Errorf("invalid path %q: use .field, [N], or [*]", path)
+		}
+	}
+	return value, nil
+}
+
+// jsonKind describes the JSON type of a decoded JSON value
+func jsonKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
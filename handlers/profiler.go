@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// createProfileArgs are the arguments of create_profile
+type createProfileArgs struct {
+	Target      string            `json:"target" validate:"required"`
+	ProfileType string            `json:"profile_type" validate:"required"`
+	Duration    string            `json:"duration"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// createProfileHandler creates a handler for creating profiles
+func createProfileHandler(client profiler.ProfilerClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[createProfileArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		profileType, err := profiler.ParseProfileType(args.ProfileType)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if args.Duration == "" {
+			args.Duration = "60s" // default
+		}
+
+		req := profiler.CreateProfileRequest{
+			ProjectID: sessionProjectID(ctx, projectID),
+			Deployment: &profiler.Deployment{
+				ProjectID: sessionProjectID(ctx, projectID),
+				Target:    args.Target,
+				Labels:    args.Labels,
+			},
+			ProfileType: []profiler.ProfileType{profileType},
+			Duration:    args.Duration,
+			Labels:      args.Labels,
+		}
+
+		profile, err := client.CreateProfile(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to create profile: %v", err)), nil
+		}
+
+		// Convert profile to JSON for response
+		profileJSON, err := json.MarshalIndent(profile, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal profile: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(profileJSON)), nil
+	}
+}
+
+// createOfflineProfileArgs are the arguments of create_offline_profile
+type createOfflineProfileArgs struct {
+	Target      string            `json:"target" validate:"required"`
+	ProfileType string            `json:"profile_type" validate:"required"`
+	ProfileData string            `json:"profile_data"`
+	ProfilePath string            `json:"profile_path"`
+	Duration    string            `json:"duration"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// createOfflineProfileHandler creates a handler for creating offline profiles
+func createOfflineProfileHandler(client profiler.ProfilerClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[createOfflineProfileArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		profileType, err := profiler.ParseProfileType(args.ProfileType)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var profileData string
+		switch {
+		case args.ProfileData != "" && args.ProfilePath != "":
+			return mcp.NewToolResultError("profile_data and profile_path are mutually exclusive"), nil
+		case args.ProfilePath != "":
+			profileData, err = profiler.ReadProfileFile(args.ProfilePath)
+		case args.ProfileData != "":
+			profileData, err = profiler.DecodeProfileData(args.ProfileData)
+		default:
+			return mcp.NewToolResultError("either profile_data or profile_path is required"), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid profile: %v", err)), nil
+		}
+		if args.Duration == "" {
+			args.Duration = "60s" // default
+		}
+
+		req := profiler.CreateOfflineProfileRequest{
+			ProjectID: sessionProjectID(ctx, projectID),
+			Profile: &profiler.Profile{
+				ProfileType:  profileType,
+				Duration:     args.Duration,
+				Labels:       args.Labels,
+				ProfileBytes: profileData,
+				Deployment: &profiler.Deployment{
+					ProjectID: sessionProjectID(ctx, projectID),
+					Target:    args.Target,
+					Labels:    args.Labels,
+				},
+			},
+		}
+
+		profile, err := client.CreateOfflineProfile(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to create offline profile: %v", err)), nil
+		}
+
+		// Convert profile to JSON for response
+		profileJSON, err := json.MarshalIndent(profile, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal profile: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(profileJSON)), nil
+	}
+}
+
+// updateProfileArgs are the arguments of update_profile
+type updateProfileArgs struct {
+	ProfileName string            `json:"profile_name" validate:"required"`
+	ProfileData string            `json:"profile_data"`
+	Labels      map[string]string `json:"labels"`
+	UpdateMask  string            `json:"update_mask"`
+}
+
+// updateProfileHandler creates a handler for updating profiles
+func updateProfileHandler(client profiler.ProfilerClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[updateProfileArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := profiler.UpdateProfileRequest{
+			Profile: &profiler.Profile{
+				Name:   args.ProfileName,
+				Labels: args.Labels,
+			},
+			ProfileBytes: args.ProfileData,
+			UpdateMask:   args.UpdateMask,
+		}
+
+		profile, err := client.UpdateProfile(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to update profile: %v", err)), nil
+		}
+
+		// Convert profile to JSON for response
+		profileJSON, err := json.MarshalIndent(profile, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal profile: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(profileJSON)), nil
+	}
+}
+
+// listProfilesArgs are the arguments of list_profiles
+type listProfilesArgs struct {
+	PageSize  int64  `json:"page_size" validate:"min=1"`
+	PageToken string `json:"page_token"`
+	FetchAll  bool   `json:"fetch_all"`
+}
+
+// listProfilesHandler creates a handler for listing profiles
+func listProfilesHandler(client profiler.ProfilerClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listProfilesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := profiler.ListProfilesRequest{
+			ProjectID: sessionProjectID(ctx, projectID),
+			PageSize:  args.PageSize,
+			PageToken: args.PageToken,
+		}
+		if req.PageSize == 0 {
+			req.PageSize = 100 // default
+		}
+
+		var response any
+		if args.FetchAll {
+			profiles, pages, next, err := fetchAll(req.PageToken, func(pageToken string) ([]*profiler.Profile, string, error) {
+				req.PageToken = pageToken
+				resp, err := client.ListProfiles(ctx, req)
+				return resp.Profiles, resp.NextPageToken, err
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list profiles: %v", err)), nil
+			}
+			response = fetchAllResponse("profiles", profiles, pages, next)
+		} else {
+			resp, err := client.ListProfiles(ctx, req)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list profiles: %v", err)), nil
+			}
+			response = resp
+		}
+
+		// Convert profiles to JSON for response
+		profilesJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal profiles: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(profilesJSON)), nil
+	}
+}
+
+// aggregateProfilesArgs are the arguments of aggregate_profiles
+type aggregateProfilesArgs struct {
+	Target         string                   `json:"target" validate:"required"`
+	ProfileType    string                   `json:"profile_type" validate:"required"`
+	StartTime      time.Time                `json:"start_time"`
+	EndTime        time.Time                `json:"end_time"`
+	Labels         map[string]string        `json:"labels"`
+	MaxProfiles    int                      `json:"max_profiles" validate:"min=1"`
+	SampleType     string                   `json:"sample_type"`
+	Top            int                      `json:"top" validate:"min=1"`
+	SourceMappings []profiler.SourceMapping `json:"source_mappings"`
+}
+
+// createAggregateProfilesHandler creates a handler for merging profiles and reporting hotspots
+func createAggregateProfilesHandler(client profiler.ProfilerClient, sourceMappings []profiler.SourceMapping, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[aggregateProfilesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		profileType, err := profiler.ParseProfileType(args.ProfileType)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		mappings, err := parseSourceMappings(args.SourceMappings, sourceMappings)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid source_mappings: %v", err)), nil
+		}
+
+		req := profiler.AggregateProfilesRequest{
+			ProjectID:      sessionProjectID(ctx, projectID),
+			Target:         args.Target,
+			ProfileType:    profileType,
+			StartTime:      args.StartTime,
+			EndTime:        args.EndTime,
+			Labels:         args.Labels,
+			MaxProfiles:    args.MaxProfiles,
+			SampleType:     args.SampleType,
+			Top:            args.Top,
+			SourceMappings: mappings,
+		}
+
+		resp, err := client.AggregateProfiles(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to aggregate profiles: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal aggregated profiles: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// detectMemoryGrowthArgs are the arguments of detect_memory_growth
+type detectMemoryGrowthArgs struct {
+	Target         string                   `json:"target" validate:"required"`
+	StartTime      time.Time                `json:"start_time"`
+	EndTime        time.Time                `json:"end_time"`
+	Labels         map[string]string        `json:"labels"`
+	Buckets        int                      `json:"buckets" validate:"min=3,max=24"`
+	MaxProfiles    int                      `json:"max_profiles" validate:"min=1"`
+	MinGrowthBytes int64                    `json:"min_growth_bytes" validate:"min=0"`
+	Top            int                      `json:"top" validate:"min=1"`
+	SourceMappings []profiler.SourceMapping `json:"source_mappings"`
+}
+
+// createDetectMemoryGrowthHandler creates a handler for detecting allocation sites with growing in-use bytes
+func createDetectMemoryGrowthHandler(client profiler.ProfilerClient, sourceMappings []profiler.SourceMapping, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[detectMemoryGrowthArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		mappings, err := parseSourceMappings(args.SourceMappings, sourceMappings)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid source_mappings: %v", err)), nil
+		}
+
+		req := profiler.MemoryGrowthRequest{
+			ProjectID:      sessionProjectID(ctx, projectID),
+			Target:         args.Target,
+			StartTime:      args.StartTime,
+			EndTime:        args.EndTime,
+			Labels:         args.Labels,
+			Buckets:        args.Buckets,
+			MaxProfiles:    args.MaxProfiles,
+			MinGrowthBytes: args.MinGrowthBytes,
+			Top:            args.Top,
+			SourceMappings: mappings,
+		}
+
+		resp, err := client.DetectMemoryGrowth(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to detect memory growth: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal memory growth: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// parseSourceMappings validates the source_mappings argument, or returns the
+// default mappings when it is not set
+func parseSourceMappings(mappings, defaults []profiler.SourceMapping) ([]profiler.SourceMapping, error) {
+	if mappings == nil {
+		return defaults, nil
+	}
+	for i, m := range mappings {
+		if err := m.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid source mapping %d: %w", i, err)
+		}
+	}
+	return mappings, nil
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/savedquery"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// saveQueryArgs are the arguments of save_query
+type saveQueryArgs struct {
+	Name        string         `json:"name" validate:"required"`
+	Kind        string         `json:"kind" validate:"required"`
+	Filter      string         `json:"filter" validate:"required"`
+	Description string         `json:"description"`
+	Aggregation map[string]any `json:"aggregation"`
+}
+
+// createSaveQueryHandler creates a handler for saving queries
+func createSaveQueryHandler(store savedquery.Store) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[saveQueryArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		query := savedquery.Query{
+			Name:        args.Name,
+			Kind:        savedquery.Kind(args.Kind),
+			Filter:      args.Filter,
+			Description: args.Description,
+			CreatedAt:   time.Now(),
+		}
+		if args.Aggregation != nil {
+			query.Aggregation, err = parseAggregation(args.Aggregation)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		if err := query.Validate(); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid query: %v", err)), nil
+		}
+
+		if err := store.Save(ctx, query); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to save query: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Query %q saved successfully", args.Name)), nil
+	}
+}
+
+// createListSavedQueriesHandler creates a handler for listing saved queries
+func createListSavedQueriesHandler(store savedquery.Store) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		queries, err := store.List(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list saved queries: %v", err)), nil
+		}
+
+		// Convert queries to JSON for response
+		queriesJSON, err := json.MarshalIndent(queries, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal saved queries: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(queriesJSON)), nil
+	}
+}
+
+// runSavedQueryArgs are the arguments of run_saved_query
+type runSavedQueryArgs struct {
+	Name       string    `json:"name" validate:"required"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Limit      int       `json:"limit" validate:"min=1"`
+	MaxPoints  int       `json:"max_points" validate:"min=3"`
+	Downsample string    `json:"downsample" validate:"oneof=lttb mean"`
+}
+
+// createRunSavedQueryHandler creates a handler for running saved queries
+func createRunSavedQueryHandler(store savedquery.Store, loggingClient logging.LoggingClient, monitoringClient monitoring.MonitoringClient, projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[runSavedQueryArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		query, err := store.Get(ctx, args.Name)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get saved query: %v", err)), nil
+		}
+
+		var result any
+		var consoleURL string
+		switch query.Kind {
+		case savedquery.KindLogs:
+			req := logging.ListEntriesRequest{
+				ProjectID: session.FromContext(ctx).ProjectID,
+				Filter:    query.Filter,
+				Limit:     50, // default
+			}
+			if args.Limit > 0 {
+				req.Limit = args.Limit
+			}
+
+			resp, err := loggingClient.ListEntries(ctx, req)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list log entries: %v", err)), nil
+			}
+			result = resp
+			consoleURL = logging.ConsoleURL(sessionProjectID(ctx, projectID), query.Filter)
+
+		case savedquery.KindTimeSeries:
+			endTime := args.EndTime
+			if endTime.IsZero() {
+				endTime = time.Now()
+			}
+			startTime := args.StartTime
+			if startTime.IsZero() {
+				startTime = endTime.Add(-1 * time.Hour)
+			}
+
+			req := monitoring.ListTimeSeriesRequest{
+				ProjectID:   session.FromContext(ctx).ProjectID,
+				Filter:      query.Filter,
+				Aggregation: query.Aggregation,
+				PageSize:    100, // default
+			}
+			req.Interval.StartTime = startTime
+			req.Interval.EndTime = endTime
+			if args.Limit > 0 {
+				req.PageSize = args.Limit
+			}
+
+			resp, err := monitoringClient.ListTimeSeries(ctx, req)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list time series: %v", err)), nil
+			}
+			resp.TimeSeries, err = downsampleTimeSeries(args.MaxPoints, args.Downsample, resp.TimeSeries)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			result = resp
+			consoleURL = monitoring.ConsoleURL(sessionProjectID(ctx, projectID), query.Filter, query.Aggregation)
+
+		default:
+			return mcp.NewToolResultError(fmt.Sprintf("Saved query %q has unsupported kind %q", query.Name, query.Kind)), nil
+		}
+
+		// Create a response object that includes the query and its results
+		response := map[string]any{
+			"query":       query,
+			"results":     result,
+			"console_url": consoleURL,
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/apiusage"
+	"github.com/kitagry/gcp-telemetry-mcp/schedule"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// unschedulableTools are the tools jobs cannot call, since they manage jobs,
+// or only make sense in the session of a client
+var unschedulableTools = []string{
+	"schedule_job",
+	"cancel_job",
+	"set_session_defaults",
+	"watch_metric",
+	"watch_logs",
+	"stop_watch",
+}
+
+// maxJobSummaryBytes limits the result of a run kept as the last result of a job
+const maxJobSummaryBytes = 1000
+
+// scheduleJobArgs are the arguments of schedule_job
+type scheduleJobArgs struct {
+	Tool        string         `json:"tool" validate:"required"`
+	Arguments   map[string]any `json:"arguments"`
+	Interval    duration       `json:"interval"`
+	Description string         `json:"description"`
+	Metric      *jobMetric     `json:"metric"`
+}
+
+// jobMetric writes a number selected from the result of each run of a job
+// to a custom metric
+type jobMetric struct {
+	MetricType string `json:"metric_type"`
+	// ValuePath selects the number in the JSON result, see evalPath
+	ValuePath      string            `json:"value_path"`
+	MetricLabels   map[string]string `json:"metric_labels"`
+	ResourceType   string            `json:"resource_type"`
+	ResourceLabels map[string]string `json:"resource_labels"`
+}
+
+// jobSession is the session the calls of a job are made in. Jobs outlive the
+// session that scheduled them, and nothing receives their notifications.
+type jobSession struct {
+	id string
+}
+
+func (s jobSession) Initialize()                                         {}
+func (s jobSession) Initialized() bool                                   { return true }
+func (s jobSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s jobSession) SessionID() string                                   { return s.id }
+
+// createScheduleJobHandler creates a handler for running a tool on a schedule
+func createScheduleJobHandler(scheduler *schedule.Scheduler, sessions *session.Store, readOnly bool) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[scheduleJobArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if slices.Contains(unschedulableTools, args.Tool) {
+			return mcp.NewToolResultError(fmt.Sprintf("jobs cannot call %s", args.Tool)), nil
+		}
+		for _, name := range templateRefs(map[string]any(args.Arguments)) {
+			if _, err := jobTimes(time.Now())(name); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if args.Metric != nil {
+			if readOnly {
+				return mcp.NewToolResultError("metric cannot be used in read-only mode, since write_time_series is not available"), nil
+			}
+			if args.Metric.MetricType == "" {
+				return mcp.NewToolResultError("metric.metric_type is required"), nil
+			}
+			if args.Metric.ResourceType == "" {
+				args.Metric.ResourceType = "global"
+			}
+		}
+
+		// The calls of the job are made through the server in a session of
+		// their own, with the defaults of the session scheduling the job
+		s := server.ServerFromContext(ctx)
+		if s == nil {
+			return mcp.NewToolResultError("schedule_job must be called through an MCP server"), nil
+		}
+		var defaults session.Defaults
+		if clientSession := server.ClientSessionFromContext(ctx); clientSession != nil {
+			defaults = sessions.Get(clientSession.SessionID())
+		}
+
+		task := func(ctx context.Context, jobID string) (string, error) {
+			sessions.Set(jobID, defaults)
+			ctx = s.WithContext(ctx, jobSession{id: jobID})
+			arguments, err := expandTemplates(map[string]any(args.Arguments), jobTimes(time.Now()))
+			if err != nil {
+				return "", err
+			}
+			result := callTool(ctx, s, 1, args.Tool, arguments)
+			if result.Status != batchStatusOK {
+				return "", errors.New(result.Error)
+			}
+			if args.Metric == nil {
+				return jobSummary(result.Result), nil
+			}
+			return writeJobMetric(ctx, s, args.Metric, result.value)
+		}
+
+		info, err := scheduler.Schedule(schedule.Info{
+			Description: args.Description,
+			Tool:        args.Tool,
+			Arguments:   args.Arguments,
+			Interval:    time.Duration(args.Interval),
+		}, task)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to schedule job: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal job: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// jobTimes resolves the {{now}} and {{now-DURATION}} templates in the
+// arguments of jobs to timestamps relative to now, the time of the run, so
+// that each run covers its own time range
+func jobTimes(now time.Time) func(name string) (any, error) {
+	return func(name string) (any, error) {
+		if name == "now" {
+			return now.Format(time.RFC3339), nil
+		}
+		if offset, ok := strings.CutPrefix(name, "now-"); ok {
+			if d, err := time.ParseDuration(offset); err == nil && d > 0 {
+				return now.Add(-d).Format(time.RFC3339), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid template {{%s}}: jobs can only use {{now}} and {{now-DURATION}}, e.g. {{now-1h}}", name)
+	}
+}
+
+// writeJobMetric writes the number metric.ValuePath selects in value, the
+// result of a run, with write_time_series
+func writeJobMetric(ctx context.Context, s *server.MCPServer, metric *jobMetric, value any) (string, error) {
+	path := metric.ValuePath
+	if path != "" && !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+		path = "." + path
+	}
+	selected, err := evalPath(value, path)
+	if err != nil {
+		return "", fmt.Errorf("metric.value_path: %w", err)
+	}
+	number, ok := selected.(float64)
+	if !ok {
+		return "", fmt.Errorf("metric.value_path must select a number, got %s", jsonKind(selected))
+	}
+
+	arguments := map[string]any{
+		"metric_type":   metric.MetricType,
+		"resource_type": metric.ResourceType,
+		"value":         number,
+	}
+	if len(metric.MetricLabels) > 0 {
+		arguments["metric_labels"] = metric.MetricLabels
+	}
+	if len(metric.ResourceLabels) > 0 {
+		arguments["resource_labels"] = metric.ResourceLabels
+	}
+	if result := callTool(ctx, s, 2, "write_time_series", arguments); result.Status != batchStatusOK {
+		return "", fmt.Errorf("failed to write %s: %s", metric.MetricType, result.Error)
+	}
+	return fmt.Sprintf("wrote %v to %s", number, metric.MetricType), nil
+}
+
+// jobSummary returns the result of a run as kept by the job, truncated
+func jobSummary(result any) string {
+	var text string
+	switch result := result.(type) {
+	case json.RawMessage:
+		text = string(result)
+	case string:
+		text = result
+	}
+	if len(text) > maxJobSummaryBytes {
+		text = text[:maxJobSummaryBytes] + "... (truncated)"
+	}
+	return text
+}
+
+// createListJobsHandler creates a handler for listing the scheduled jobs
+func createListJobsHandler(scheduler *schedule.Scheduler) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(scheduler.List(), "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal jobs: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// cancelJobArgs are the arguments of cancel_job
+type cancelJobArgs struct {
+	ID string `json:"id" validate:"required"`
+}
+
+// createCancelJobHandler creates a handler for canceling a scheduled job
+func createCancelJobHandler(scheduler *schedule.Scheduler, sessions *session.Store, usage *apiusage.Tracker) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[cancelJobArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := scheduler.Cancel(args.ID); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to cancel job: %v", err)), nil
+		}
+		// Drop the session of the job's calls
+		sessions.Delete(args.ID)
+		if usage != nil {
+			usage.Delete(args.ID)
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Job %s canceled successfully", args.ID)), nil
+	}
+}
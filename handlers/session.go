@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/apiusage"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// SessionDefaultsMiddleware makes the defaults of the calling session, including
+// the write labels resolved for the session, available to tool handlers
+func SessionDefaultsMiddleware(sessions *session.Store, writeLabels map[string]string) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var sessionID string
+			var defaults session.Defaults
+			if clientSession := server.ClientSessionFromContext(ctx); clientSession != nil {
+				sessionID = clientSession.SessionID()
+				defaults = sessions.Get(sessionID)
+			}
+			defaults.WriteLabels = session.ResolveWriteLabels(writeLabels, sessionID)
+			return next(session.NewContext(ctx, defaults), request)
+		}
+	}
+}
+
+// sessionProjectID returns the session default project, falling back to the
+// configured projectID
+func sessionProjectID(ctx context.Context, projectID string) string {
+	if sessionProjectID := session.FromContext(ctx).ProjectID; sessionProjectID != "" {
+		return sessionProjectID
+	}
+	return projectID
+}
+
+// setSessionDefaultsArgs are the arguments of set_session_defaults
+type setSessionDefaultsArgs struct {
+	ProjectID      string            `json:"project_id"`
+	ResourceType   string            `json:"resource_type"`
+	ResourceLabels map[string]string `json:"resource_labels"`
+	LogNamePrefix  string            `json:"log_name_prefix"`
+	TimeZone       string            `json:"time_zone"`
+	Clear          bool              `json:"clear"`
+}
+
+// createSetSessionDefaultsHandler creates a handler for setting session defaults
+func createSetSessionDefaultsHandler(sessions *session.Store) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		clientSession := server.ClientSessionFromContext(ctx)
+		if clientSession == nil {
+			return mcp.NewToolResultError("No active session"), nil
+		}
+		sessionID := clientSession.SessionID()
+
+		args, err := DecodeArgs[setSessionDefaultsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if args.TimeZone != "" {
+			if _, err := time.LoadLocation(args.TimeZone); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf(`invalid time_zone %q: must be an IANA time zone name, e.g. "Asia/Tokyo" or "UTC"`, args.TimeZone)), nil
+			}
+		}
+		update := session.Defaults{
+			ProjectID:      args.ProjectID,
+			ResourceType:   args.ResourceType,
+			ResourceLabels: args.ResourceLabels,
+			LogNamePrefix:  args.LogNamePrefix,
+			TimeZone:       args.TimeZone,
+		}
+
+		current := sessions.Get(sessionID)
+		if args.Clear {
+			current = session.Defaults{}
+		}
+		current = current.Merge(update)
+		sessions.Set(sessionID, current)
+
+		// Convert defaults to JSON for response
+		defaultsJSON, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal session defaults: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(defaultsJSON)), nil
+	}
+}
+
+// createGetSessionStatsHandler creates a handler for reporting the API calls of the session
+func createGetSessionStatsHandler(apiUsage *apiusage.Tracker) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		clientSession := server.ClientSessionFromContext(ctx)
+		if clientSession == nil {
+			return mcp.NewToolResultError("No active session"), nil
+		}
+
+		statsJSON, err := json.MarshalIndent(apiUsage.Stats(clientSession.SessionID()), "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal session stats: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(statsJSON)), nil
+	}
+}
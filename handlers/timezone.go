@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// timestampString matches a JSON string holding a whole RFC 3339 timestamp
+var timestampString = regexp.MustCompile(`"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})"`)
+
+// TimeZoneMiddleware converts the timestamps of tool results to the time zone
+// set by set_session_defaults, or to defaultZone when the session sets none,
+// and notes the zone in the results. Only JSON strings holding a whole
+// timestamp are converted, so that the timestamps in console URLs and filters
+// are left alone. A nil defaultZone leaves the results of the sessions
+// without a time zone unchanged.
+func TimeZoneMiddleware(defaultZone *time.Location) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil || result.IsError {
+				return result, err
+			}
+
+			zone := defaultZone
+			if name := session.FromContext(ctx).TimeZone; name != "" {
+				// The zone was checked by set_session_defaults
+				if loc, err := time.LoadLocation(name); err == nil {
+					zone = loc
+				}
+			}
+			if zone != nil {
+				localizeTimestamps(result, zone)
+			}
+			return result, nil
+		}
+	}
+}
+
+// localizeTimestamps converts the timestamps of the text contents of result
+// to zone, and adds a text content naming the zone when any was converted
+func localizeTimestamps(result *mcp.CallToolResult, zone *time.Location) {
+	converted := false
+	for i, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+		text.Text = timestampString.ReplaceAllStringFunc(text.Text, func(s string) string {
+			t, err := time.Parse(time.RFC3339Nano, s[1:len(s)-1])
+			if err != nil {
+				return s
+			}
+			converted = true
+			return `"` + t.In(zone).Format(time.RFC3339Nano) + `"`
+		})
+		result.Content[i] = text
+	}
+	if converted {
+		result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf("Timestamps are shown in the %s time zone", zone)))
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestTimeZoneMiddleware(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := TimeZoneMiddleware(tokyo)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(`{"timestamp": "2026-01-02T03:04:05.5Z", "console_url": "https://console.cloud.google.com/logs?cursorTimestamp=2026-01-02T03:04:05Z"}`), nil
+	})
+
+	tests := []struct {
+		name     string
+		defaults session.Defaults
+		want     string
+		wantNote string
+	}{
+		{
+			name:     "server default",
+			want:     `{"timestamp": "2026-01-02T12:04:05.5+09:00", "console_url": "https://console.cloud.google.com/logs?cursorTimestamp=2026-01-02T03:04:05Z"}`,
+			wantNote: "Timestamps are shown in the Asia/Tokyo time zone",
+		},
+		{
+			name:     "session time zone",
+			defaults: session.Defaults{TimeZone: "America/New_York"},
+			want:     `{"timestamp": "2026-01-01T22:04:05.5-05:00", "console_url": "https://console.cloud.google.com/logs?cursorTimestamp=2026-01-02T03:04:05Z"}`,
+			wantNote: "Timestamps are shown in the America/New_York time zone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := handler(session.NewContext(context.Background(), tt.defaults), mcp.CallToolRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Content) != 2 {
+				t.Fatalf("Expected the result and a note of the time zone, got %+v", result.Content)
+			}
+			if got := result.Content[0].(mcp.TextContent).Text; got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+			if got := result.Content[1].(mcp.TextContent).Text; got != tt.wantNote {
+				t.Errorf("Expected %q, got %q", tt.wantNote, got)
+			}
+		})
+	}
+}
+
+func TestTimeZoneMiddleware_NoZone(t *testing.T) {
+	text := `{"timestamp": "2026-01-02T03:04:05Z"}`
+	result, err := TimeZoneMiddleware(nil)(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(text), nil
+	})(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Content) != 1 || result.Content[0].(mcp.TextContent).Text != text {
+		t.Errorf("Expected the result to be unchanged, got %+v", result.Content)
+	}
+}
@@ -0,0 +1,1884 @@
+// Package handlers defines the MCP tools of the server and their handlers.
+// The clients the handlers call are injected through Deps, so that the
+// handlers can be unit tested and the tools registered by other Go programs.
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kitagry/gcp-telemetry-mcp/agent"
+	"github.com/kitagry/gcp-telemetry-mcp/apiusage"
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+	"github.com/kitagry/gcp-telemetry-mcp/billing"
+	"github.com/kitagry/gcp-telemetry-mcp/chart"
+	"github.com/kitagry/gcp-telemetry-mcp/export"
+	"github.com/kitagry/gcp-telemetry-mcp/incident"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/notifications"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/kitagry/gcp-telemetry-mcp/savedquery"
+	"github.com/kitagry/gcp-telemetry-mcp/schedule"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"github.com/kitagry/gcp-telemetry-mcp/watch"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Deps are the clients and stores called by the tool handlers
+type Deps struct {
+	Logging    logging.LoggingClient
+	Monitoring monitoring.MonitoringClient
+	Trace      trace.TraceClient
+	Profiler   profiler.ProfilerClient
+	// DLPScanner scans log entries for list_log_entries with scan_and_redact
+	DLPScanner *redact.DLPScanner
+	// Assets lists the resources of Cloud Asset Inventory for list_assets
+	Assets       asset.AssetClient
+	SavedQueries savedquery.Store
+	// ProjectID is the configured project, used e.g. in console URLs when
+	// the session has no default project
+	ProjectID string
+	// Sessions holds the session defaults set by set_session_defaults, which
+	// SessionDefaultsMiddleware makes available to the handlers
+	Sessions *session.Store
+	// APIUsage counts the Google Cloud calls of each session for
+	// get_session_stats. It is optional.
+	APIUsage *apiusage.Tracker
+	Watches  *watch.Manager
+	// Scheduler runs the jobs of schedule_job. The job tools are left out
+	// when it is nil.
+	Scheduler *schedule.Scheduler
+	// Subscriber receives alert notifications. It is optional.
+	Subscriber *notifications.Subscriber
+	// SourceMappings are the default source mappings of profile hotspots
+	SourceMappings []profiler.SourceMapping
+	// ReadOnly leaves out the tools that write to Google Cloud
+	ReadOnly bool
+	// DisabledTools are the names of the tools left out
+	DisabledTools []string
+}
+
+// Tool is an MCP tool and the handler of its calls
+type Tool struct {
+	// Definition is the name, description, and parameter schema of the tool
+	Definition mcp.Tool
+	Handler    server.ToolHandlerFunc
+	// Write marks the tools that write to Google Cloud, which are left out
+	// in read-only mode
+	Write bool
+	// Disabled leaves out the tool, e.g. when a client it needs is not configured
+	Disabled bool
+	// Cacheable marks the tools that only read from Google Cloud, whose
+	// results can be reused for identical calls for a while
+	Cacheable bool
+	// CursorPaged marks the tools whose page tokens are single-use log
+	// entry cursors, whose results are not reused while pages are left
+	CursorPaged bool
+}
+
+// aggregationProperties is the schema of the aggregation parameters of the
+// tools querying time series
+var aggregationProperties = map[string]any{
+	"alignment_period":     map[string]any{"type": "string", "description": "Alignment period (e.g., '60s')"},
+	"per_series_aligner":   map[string]any{"type": "string", "description": "Per-series aligner, defaulting to ALIGN_MEAN", "enum": monitoring.Aligners},
+	"cross_series_reducer": map[string]any{"type": "string", "description": "Optional cross-series reducer", "enum": monitoring.Reducers},
+	"group_by_fields":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Fields to group the series by when reducing (e.g., 'resource.label.zone')"},
+}
+
+// Tools returns the table of the tools with their handlers calling deps
+func Tools(deps Deps) []Tool {
+	incidentGenerator := incident.NewGenerator(deps.Logging, deps.Monitoring, deps.Trace)
+	billingReader := billing.NewReader(deps.Logging, deps.Monitoring)
+	agentChecker := agent.NewChecker(deps.Logging, deps.Monitoring)
+	exporter := export.NewExporter(deps.Monitoring)
+
+	return []Tool{
+		{
+			Definition: mcp.NewTool("write_log_entry",
+				mcp.WithDescription("Write a log entry to Cloud Logging"),
+				mcp.WithString("log_name",
+					mcp.Required(),
+					mcp.Description("Name of the log to write to: a log ID of letters, digits, '/', '_', '-', and '.' (e.g., 'my-app/requests'), or a full name like 'projects/PROJECT_ID/logs/LOG_ID'"),
+				),
+				mcp.WithString("parent",
+					mcp.Description("Resource to write to instead of the project: 'projects/PROJECT_ID', 'folders/FOLDER_ID', 'organizations/ORGANIZATION_ID', or 'billingAccounts/BILLING_ACCOUNT_ID'. log_name must then be a log ID"),
+				),
+				mcp.WithString("severity",
+					mcp.Required(),
+					mcp.Description("Log severity"),
+					mcp.Enum(logging.Severities...),
+				),
+				mcp.WithString("message",
+					mcp.Required(),
+					mcp.Description("Log message"),
+				),
+				mcp.WithObject("labels",
+					mcp.Description("Optional labels for the log entry"),
+				),
+				mcp.WithObject("payload",
+					mcp.Description("Optional structured payload for the log entry"),
+				),
+				mcp.WithObject("resource",
+					mcp.Description("Optional monitored resource with 'type' (e.g., 'gce_instance', 'k8s_container') and 'labels'"),
+				),
+				mcp.WithObject("source_location",
+					mcp.Description("Optional source location with 'file', 'line', and 'function'"),
+				),
+				mcp.WithObject("http_request",
+					mcp.Description("Optional HTTP request with 'method', 'url', 'status', 'request_size', 'response_size', 'user_agent', 'referer', 'remote_ip', 'server_ip', and 'latency' (e.g., '250ms')"),
+				),
+				mcp.WithObject("operation",
+					mcp.Description("Optional operation with 'id', 'producer', 'first', and 'last'"),
+				),
+				mcp.WithString("insert_id",
+					mcp.Description("Optional unique identifier for the log entry. Entries with the same insert_id and timestamp in the same log are deduplicated, so that retried or backfilled writes are stored once"),
+				),
+				mcp.WithString("timestamp",
+					mcp.Description("Optional time of the event, e.g. in the past when backfilling events collected elsewhere (RFC 3339 or Unix epoch seconds or milliseconds, defaults to now). Cloud Logging rejects timestamps more than 24h in the future, and drops entries older than the retention of the log bucket"),
+				),
+			),
+			Handler: createWriteLogHandler(deps.Logging),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("write_log_entries",
+				mcp.WithDescription("Write multiple log entries to Cloud Logging in a single call"),
+				mcp.WithString("log_name",
+					mcp.Required(),
+					mcp.Description("Name of the log to write to: a log ID of letters, digits, '/', '_', '-', and '.' (e.g., 'my-app/requests'), or a full name like 'projects/PROJECT_ID/logs/LOG_ID'"),
+				),
+				mcp.WithString("parent",
+					mcp.Description("Resource to write to instead of the project: 'projects/PROJECT_ID', 'folders/FOLDER_ID', 'organizations/ORGANIZATION_ID', or 'billingAccounts/BILLING_ACCOUNT_ID'. log_name must then be a log ID"),
+				),
+				mcp.WithArray("entries",
+					mcp.Required(),
+					mcp.Description("Array of log entry objects with severity, message, and optional labels and payload"),
+					mcp.Items(map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"severity":        map[string]any{"type": "string", "description": "Log severity", "enum": logging.Severities},
+							"message":         map[string]any{"type": "string", "description": "Log message"},
+							"labels":          map[string]any{"type": "object", "description": "Optional labels for the log entry"},
+							"payload":         map[string]any{"type": "object", "description": "Optional structured payload for the log entry"},
+							"resource":        map[string]any{"type": "object", "description": "Optional monitored resource with 'type' and 'labels'"},
+							"source_location": map[string]any{"type": "object", "description": "Optional source location with 'file', 'line', and 'function'"},
+							"http_request":    map[string]any{"type": "object", "description": "Optional HTTP request with 'method', 'url', 'status', 'latency', etc."},
+							"operation":       map[string]any{"type": "object", "description": "Optional operation with 'id', 'producer', 'first', and 'last'"},
+							"insert_id":       map[string]any{"type": "string", "description": "Optional unique identifier for the log entry, deduplicating entries with the same timestamp"},
+							"timestamp":       map[string]any{"type": "string", "description": "Optional time of the event (RFC 3339, defaults to now)"},
+						},
+						"required": []string{"severity", "message"},
+					}),
+				),
+				mcp.WithBoolean("async",
+					mcp.Description("Buffer the entries and flush them once at the end instead of writing each entry synchronously (default: false). Synchronous writes report which entries failed while the others are written; async writes succeed or fail as a whole"),
+				),
+			),
+			Handler: createWriteLogsHandler(deps.Logging),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("list_log_entries",
+				mcp.WithDescription("List log entries from Cloud Logging, with severity_counts summarizing the severities of the returned entries. With count_only, severity_counts covers all the counted matches"),
+				mcp.WithString("filter",
+					mcp.Description(`Filter sets an advanced logs filter for listing log entries (see
+https://cloud.google.com/logging/docs/view/advanced_filters). The filter is compared against all log entries in the projects specified by ProjectIDs. Only entries that match the filter are retrieved. An empty filter (the default) matches all log entries.
+
+In the filter string, log names must be written in their full form, as "projects/PROJECT-ID/logs/LOG-ID". Forward slashes in LOG-ID must be replaced by %2F before calling Filter.
+
+Timestamps in the filter string must be written in RFC 3339 format. By default, timestamp filters for the past 24 hours.
+
+e.x.)
+
+* If you want to filter logs for a specific Kubernetes pod, you can use a filter like this:
+
+resource.type = "k8s_container"
+resource.labels.project_id="YOUR PROJECT ID"
+resource.labels.cluster_name="YOUR CLUSTER NAME"
+resource.labels.namespace_name="YOUR NAMESPACE NAME"
+(labels.k8s-pod/app="YOUR APP LABEL NAME")
+`),
+				),
+				mcp.WithString("min_severity",
+					mcp.Description("Only return entries at or above this severity, combined with the filter"),
+					mcp.Enum(logging.Severities...),
+				),
+				mcp.WithString("text_regex",
+					mcp.Description("Only return entries whose textPayload or jsonPayload.message matches this RE2 regular expression (e.g., 'timeout after \\d+ms'). Validated before the query is sent"),
+				),
+				mcp.WithArray("exclude_text",
+					mcp.Description("Exclude entries containing any of these texts in any field (e.g., ['healthz', 'readiness probe'])"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithArray("fields",
+					mcp.Description("Only return these JSON payload fields, as dotted paths (e.g., ['message', 'user.id', 'jsonPayload.error.code']), to shrink entries with large structured payloads. The other entry fields are kept"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithBoolean("scan_and_redact",
+					mcp.Description("Inspect the returned entries with Cloud DLP and replace findings with their infoType (e.g., '[EMAIL_ADDRESS]'). The response includes a redaction_summary of what was found. Requires the DLP API and is billed per byte inspected"),
+				),
+				mcp.WithArray("info_types",
+					mcp.Description("Cloud DLP infoTypes to scan for with scan_and_redact (e.g., ['EMAIL_ADDRESS', 'PHONE_NUMBER']). Defaults to GCP_TELEMETRY_MCP_DLP_INFO_TYPES or common personal data and credentials"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of entries to return (default: 50)"),
+				),
+				mcp.WithString("order_by",
+					mcp.Description("Order of the returned entries: 'timestamp desc' (newest first, default) or 'timestamp asc' (oldest first)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token returned by a previous call to continue where it stopped. The filter and order_by must match the previous call. Tokens expire after 10 minutes of inactivity"),
+				),
+				mcp.WithBoolean("count_only",
+					mcp.Description(countOnlyDescription),
+				),
+			),
+			Handler:     createListLogsHandler(deps.Logging, deps.DLPScanner, deps.ProjectID),
+			Cacheable:   true,
+			CursorPaged: true,
+		},
+		{
+			Definition: mcp.NewTool("list_audit_logs",
+				mcp.WithDescription("List Cloud Audit Logs entries (who did what, where, and when) with structured filters. Each entry includes the decoded audit_log payload"),
+				mcp.WithString("log_type",
+					mcp.Description("Audit log type: 'activity' (admin activity), 'data_access', 'system_event', or 'policy' (policy denied). All audit logs when omitted"),
+				),
+				mcp.WithString("service",
+					mcp.Description("Service that was called (e.g., 'compute.googleapis.com', 'iam.googleapis.com')"),
+				),
+				mcp.WithString("method",
+					mcp.Description("Substring of the called method name (e.g., 'SetIamPolicy', 'instances.delete')"),
+				),
+				mcp.WithString("principal",
+					mcp.Description("Email of the user or service account that made the call"),
+				),
+				mcp.WithString("caller_ip",
+					mcp.Description("IP address the call was made from (e.g., '203.0.113.1')"),
+				),
+				mcp.WithString("resource",
+					mcp.Description("Substring of the resource name that was accessed (e.g., 'instances/web-1')"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Only return entries at or after this time (ISO 8601 format)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("Only return entries at or before this time (ISO 8601 format)"),
+				),
+				mcp.WithString("filter",
+					mcp.Description("Additional Cloud Logging filter ANDed with the other conditions"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of entries to return (default: 50)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token returned by a previous call to continue where it stopped. The other parameters must match the previous call"),
+				),
+			),
+			Handler:     createListAuditLogsHandler(deps.Logging, deps.ProjectID),
+			Cacheable:   true,
+			CursorPaged: true,
+		},
+		{
+			Definition: mcp.NewTool("list_gke_events",
+				mcp.WithDescription("List Kubernetes events (scheduling failures, image pull errors, OOM kills, restarts, ...) that GKE exports to Cloud Logging. Returns each event's type, reason, message, and involved object"),
+				mcp.WithString("cluster_name",
+					mcp.Description("GKE cluster name"),
+				),
+				mcp.WithString("namespace",
+					mcp.Description("Namespace of the involved object"),
+				),
+				mcp.WithString("kind",
+					mcp.Description("Kind of the involved object (e.g., 'Pod', 'Node', 'Deployment')"),
+				),
+				mcp.WithString("name",
+					mcp.Description("Substring of the involved object's name (e.g., a pod name prefix)"),
+				),
+				mcp.WithString("reason",
+					mcp.Description("Event reason (e.g., 'BackOff', 'FailedScheduling', 'OOMKilling', 'Unhealthy')"),
+				),
+				mcp.WithString("type",
+					mcp.Description("Event type: 'Normal' or 'Warning'"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Only return events logged at or after this time (ISO 8601 format)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("Only return events logged at or before this time (ISO 8601 format)"),
+				),
+				mcp.WithString("filter",
+					mcp.Description("Additional Cloud Logging filter ANDed with the other conditions"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of events to return (default: 50)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token returned by a previous call to continue where it stopped. The other parameters must match the previous call"),
+				),
+			),
+			Handler:     createListGKEEventsHandler(deps.Logging, deps.ProjectID),
+			Cacheable:   true,
+			CursorPaged: true,
+		},
+		{
+			Definition: mcp.NewTool("record_deploy_marker",
+				mcp.WithDescription(`Record a deployment as a structured log entry in the deploy_markers log, so that build_timeline and list_deploy_markers can show what changed alongside the telemetry.
+Use it for deployments that leave no Admin Activity audit log, e.g. those rolled out by a CI system or a feature flag service`),
+				mcp.WithString("service",
+					mcp.Required(),
+					mcp.Description("Service that was deployed (e.g., 'checkout')"),
+				),
+				mcp.WithString("version",
+					mcp.Description("Version that was deployed (e.g., 'v42')"),
+				),
+				mcp.WithString("environment",
+					mcp.Description("Environment deployed to (e.g., 'production')"),
+				),
+				mcp.WithString("commit",
+					mcp.Description("Source commit of the deployment"),
+				),
+				mcp.WithString("author",
+					mcp.Description("Who deployed"),
+				),
+				mcp.WithString("description",
+					mcp.Description("What changed"),
+				),
+				mcp.WithString("url",
+					mcp.Description("Link to the release, change, or CI run"),
+				),
+				mcp.WithString("timestamp",
+					mcp.Description("Time of the deployment (ISO 8601 format, defaults to now)"),
+				),
+			),
+			Handler: createRecordDeployMarkerHandler(deps.Logging),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("list_deploy_markers",
+				mcp.WithDescription("List the deployments recorded by record_deploy_marker, newest first"),
+				mcp.WithString("service",
+					mcp.Description("Only markers of this service"),
+				),
+				mcp.WithString("version",
+					mcp.Description("Only markers of this version"),
+				),
+				mcp.WithString("environment",
+					mcp.Description("Only markers of this environment"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start time for filtering (ISO 8601 format)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End time for filtering (ISO 8601 format)"),
+				),
+				mcp.WithString("filter",
+					mcp.Description("Additional Cloud Logging filter ANDed with the conditions"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of markers to return, up to 1000 (default: 50)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token returned by a previous call to continue where it stopped. The other parameters must match the previous call"),
+				),
+			),
+			Handler:     createListDeployMarkersHandler(deps.Logging, deps.ProjectID),
+			Cacheable:   true,
+			CursorPaged: true,
+		},
+		{
+			Definition: mcp.NewTool("log_volume_histogram",
+				mcp.WithDescription("Count the log entries matching a filter in consecutive time bins, with one bounded query per bin, to spot when a burst of errors started. Returns the count of each bin, the peak bin, the start of the burst leading to the peak when it is well above the median, and a sparkline"),
+				mcp.WithString("filter",
+					mcp.Description("Cloud Logging filter of the entries to count (e.g., 'resource.type=\"cloud_run_revision\"')"),
+				),
+				mcp.WithString("min_severity",
+					mcp.Description("Only count entries at or above this severity, combined with the filter"),
+					mcp.Enum(logging.Severities...),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the time range (RFC3339 format, defaults to an hour before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the time range (RFC3339 format, defaults to now)"),
+				),
+				mcp.WithNumber("bins",
+					mcp.Description("Number of time bins (default: 12, maximum: 48). Each bin costs one list request against the Cloud Logging read quota"),
+				),
+				mcp.WithNumber("max_per_bin",
+					mcp.Description("Maximum number of entries counted per bin; bins reaching it are marked truncated (default: 1000, maximum: 10000)"),
+				),
+			),
+			Handler:   createLogVolumeHistogramHandler(deps.Logging),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("extract_field_values",
+				mcp.WithDescription("Scan the log entries matching a filter and return the distinct values of a field with their counts and first and last occurrence, most frequent first. Useful for blast-radius analysis, e.g. how many users, versions, or pods an error affects"),
+				mcp.WithString("field",
+					mcp.Required(),
+					mcp.Description("Field to extract, named as in Cloud Logging: 'severity', 'textPayload', 'labels.KEY', 'resource.type', 'resource.labels.KEY', 'jsonPayload.PATH' (e.g., 'jsonPayload.user_id'), 'httpRequest.status', 'httpRequest.requestUrl', 'sourceLocation.file', 'protoPayload.methodName', ..."),
+				),
+				mcp.WithString("filter",
+					mcp.Description("Cloud Logging filter of the entries to scan"),
+				),
+				mcp.WithString("min_severity",
+					mcp.Description("Only scan entries at or above this severity, combined with the filter"),
+					mcp.Enum(logging.Severities...),
+				),
+				mcp.WithNumber("max_entries",
+					mcp.Description("Maximum number of entries to scan, newest first (default: 1000, maximum: 10000)"),
+				),
+				mcp.WithNumber("top",
+					mcp.Description("Number of distinct values to return (default: 20)"),
+				),
+			),
+			Handler:   createExtractFieldValuesHandler(deps.Logging),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("create_metric_descriptor",
+				mcp.WithDescription("Create a custom metric descriptor in Cloud Monitoring"),
+				mcp.WithString("type",
+					mcp.Required(),
+					mcp.Description("Metric type (e.g., 'custom.googleapis.com/my_metric')"),
+				),
+				mcp.WithString("metric_kind",
+					mcp.Required(),
+					mcp.Description("Metric kind"),
+					mcp.Enum(monitoring.MetricKinds...),
+				),
+				mcp.WithString("value_type",
+					mcp.Required(),
+					mcp.Description("Value type"),
+					mcp.Enum(monitoring.ValueTypes...),
+				),
+				mcp.WithString("description",
+					mcp.Required(),
+					mcp.Description("Description of the metric"),
+				),
+				mcp.WithString("display_name",
+					mcp.Description("Display name for the metric"),
+				),
+			),
+			Handler: createMetricDescriptorHandler(deps.Monitoring),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("write_time_series",
+				mcp.WithDescription("Write time series data to Cloud Monitoring. Takes a number value, or a distribution value for DISTRIBUTION metrics such as latency histograms"),
+				mcp.WithString("metric_type",
+					mcp.Required(),
+					mcp.Description("Metric type to write data for"),
+				),
+				mcp.WithString("resource_type",
+					mcp.Required(),
+					mcp.Description("Resource type (e.g., 'global', 'gce_instance')"),
+				),
+				mcp.WithNumber("value",
+					mcp.Description("Metric value to write. Required unless distribution is given"),
+				),
+				mcp.WithObject("distribution",
+					mcp.Description("Distribution value to write instead of value. Takes bucket_options with one of 'bounds' (explicit bucket boundaries), 'linear' ({num_finite_buckets, width, offset}), or 'exponential' ({num_finite_buckets, growth_factor, scale}), and either raw 'samples' to bucket, or 'bucket_counts' (starting with the underflow bucket) with their 'mean' and optional 'sum_of_squared_deviation'"),
+					mcp.Properties(map[string]any{
+						"bucket_options": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"bounds":      map[string]any{"type": "array", "items": map[string]any{"type": "number"}},
+								"linear":      map[string]any{"type": "object"},
+								"exponential": map[string]any{"type": "object"},
+							},
+						},
+						"samples":                  map[string]any{"type": "array", "items": map[string]any{"type": "number"}},
+						"bucket_counts":            map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+						"mean":                     map[string]any{"type": "number"},
+						"sum_of_squared_deviation": map[string]any{"type": "number"},
+					}),
+				),
+				mcp.WithObject("metric_labels",
+					mcp.Description("Optional metric labels"),
+				),
+				mcp.WithObject("resource_labels",
+					mcp.Description("Optional resource labels (e.g., {'instance_id': '123', 'zone': 'us-central1-a'})"),
+				),
+				mcp.WithString("timestamp",
+					mcp.Description("Timestamp for the data point (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithString("metric_kind",
+					mcp.Description("Kind of the metric (defaults to GAUGE). CUMULATIVE and DELTA points need a start_time"),
+					mcp.Enum(monitoring.MetricKinds...),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the interval of a CUMULATIVE or DELTA point, before timestamp (ISO 8601 format). For CUMULATIVE metrics, keep the same start time until the counter resets"),
+				),
+			),
+			Handler: createWriteTimeSeriesHandler(deps.Monitoring),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("write_time_series_batch",
+				mcp.WithDescription(fmt.Sprintf("Write up to %d time series points to Cloud Monitoring in one call. The valid series are written even when others are rejected, and the result then reports the written and failed counts and the errors of the rejected series instead of failing the call", maxBatchTimeSeries)),
+				mcp.WithArray("time_series",
+					mcp.Required(),
+					mcp.Description("Array of points, each with the arguments of write_time_series"),
+					mcp.Items(map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"metric_type":     map[string]any{"type": "string", "description": "Metric type to write data for"},
+							"resource_type":   map[string]any{"type": "string", "description": "Resource type (e.g., 'global', 'gce_instance')"},
+							"value":           map[string]any{"type": "number", "description": "Metric value to write. Required unless distribution is given"},
+							"distribution":    map[string]any{"type": "object", "description": "Distribution value to write instead of value, as in write_time_series"},
+							"metric_labels":   map[string]any{"type": "object", "description": "Optional metric labels"},
+							"resource_labels": map[string]any{"type": "object", "description": "Optional resource labels"},
+							"timestamp":       map[string]any{"type": "string", "description": "Timestamp for the data point (ISO 8601 format, defaults to now)"},
+							"metric_kind":     map[string]any{"type": "string", "description": "Kind of the metric (defaults to GAUGE)", "enum": monitoring.MetricKinds},
+							"start_time":      map[string]any{"type": "string", "description": "Start of the interval of a CUMULATIVE or DELTA point (ISO 8601 format)"},
+						},
+						"required": []string{"metric_type", "resource_type"},
+					}),
+				),
+			),
+			Handler: createWriteTimeSeriesBatchHandler(deps.Monitoring),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("list_time_series",
+				mcp.WithDescription("List time series data from Cloud Monitoring"),
+				mcp.WithString("filter",
+					mcp.Required(),
+					mcp.Description(`A [monitoring filter](https://cloud.google.com/monitoring/api/v3/filters) that specifies which time series should be returned.  The filter must specify a single metric type, and can additionally specify metric labels and other information. For example:
+
+    metric.type = "compute.googleapis.com/instance/cpu/usage_time" AND
+        metric.labels.instance_name = "my-instance-name"
+			`),
+				),
+				mcp.WithString("start_time",
+					mcp.Required(),
+					mcp.Description("Start time for the query (ISO 8601 format)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Required(),
+					mcp.Description("End time for the query (ISO 8601 format)"),
+				),
+				mcp.WithObject("aggregation",
+					mcp.Description("Optional aggregation configuration"),
+					mcp.Properties(aggregationProperties),
+				),
+				mcp.WithNumber("page_size",
+					mcp.Description("Maximum number of time series to return (default: 100)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token for pagination"),
+				),
+				mcp.WithString("format",
+					mcp.Description("Output format: 'full' (default) returns every data point; 'summary' returns min/max/avg/last and a unicode sparkline per series, which is far more compact; 'aligned' places all series on one shared list of timestamps (every aggregation alignment_period when set) with null for gaps, for point-by-point comparison"),
+					mcp.Enum("full", "summary", "aligned"),
+				),
+				mcp.WithNumber("sparkline_width",
+					mcp.Description("Maximum number of sparkline characters per series in summary format (default: 60)"),
+				),
+				mcp.WithString("transform",
+					mcp.Description("Compute values client-side when no aligner was set: 'rate' (change per second), 'delta' (change between points), or 'cumsum' (running total). Decreases of CUMULATIVE series are treated as counter resets"),
+					mcp.Enum(monitoring.TransformRate, monitoring.TransformDelta, monitoring.TransformCumsum),
+				),
+				mcp.WithNumber("max_points",
+					mcp.Description("Downsample each series to at most this many points (minimum 3), so that long ranges of dense data stay small"),
+				),
+				mcp.WithString("downsample",
+					mcp.Description("Downsampling method used with max_points: 'lttb' (default) keeps the points that best preserve the shape of the series, 'mean' averages buckets of consecutive points"),
+					mcp.Enum(monitoring.DownsampleLTTB, monitoring.DownsampleMean),
+				),
+			),
+			Handler:   createListTimeSeriesHandler(deps.Monitoring, deps.ProjectID),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("estimate_query_cost",
+				mcp.WithDescription("Estimate the series, points, and pages a list_time_series query returns before running it, from the number of raw points of each matching series in the window. Heavy queries (over 10000 points or more than one page) come with warnings and a suggested aggregation that returns far fewer points"),
+				mcp.WithString("filter",
+					mcp.Required(),
+					mcp.Description("Monitoring filter of the query, as in list_time_series"),
+				),
+				mcp.WithString("start_time",
+					mcp.Required(),
+					mcp.Description("Start time of the query (ISO 8601 format)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Required(),
+					mcp.Description("End time of the query (ISO 8601 format)"),
+				),
+				mcp.WithObject("aggregation",
+					mcp.Description("Optional aggregation of the query, as in list_time_series"),
+					mcp.Properties(aggregationProperties),
+				),
+				mcp.WithNumber("page_size",
+					mcp.Description("Page size of the query (default: 100)"),
+				),
+			),
+			Handler:   createEstimateQueryCostHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("render_metric_chart",
+				mcp.WithDescription("Query time series data from Cloud Monitoring and render it as a line chart image (PNG or SVG)"),
+				mcp.WithString("filter",
+					mcp.Required(),
+					mcp.Description("A monitoring filter selecting a single metric type, as in list_time_series"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start time for the query (ISO 8601 format, defaults to 1 hour before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End time for the query (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithObject("aggregation",
+					mcp.Description("Optional aggregation configuration, as in list_time_series"),
+					mcp.Properties(aggregationProperties),
+				),
+				mcp.WithString("format",
+					mcp.Description("Image format: 'png' (default) or 'svg'"),
+					mcp.Enum(chart.FormatPNG, chart.FormatSVG),
+				),
+				mcp.WithString("title",
+					mcp.Description("Chart title (defaults to the filter)"),
+				),
+				mcp.WithNumber("width",
+					mcp.Description("Image width in pixels (default: 1024, max: 4096)"),
+				),
+				mcp.WithNumber("height",
+					mcp.Description("Image height in pixels (default: 512, max: 4096)"),
+				),
+				mcp.WithNumber("max_series",
+					mcp.Description("Maximum number of series to draw (default: 10)"),
+				),
+			),
+			Handler:   createRenderMetricChartHandler(deps.Monitoring, deps.ProjectID),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("forecast_metric",
+				mcp.WithDescription("Fit a linear or Holt-Winters model to the history of a metric and project when it will cross a threshold (e.g., the date a disk fills up), with a 95% range and a confidence level"),
+				mcp.WithString("filter",
+					mcp.Required(),
+					mcp.Description("A monitoring filter selecting a single metric type, as in list_time_series"),
+				),
+				mcp.WithNumber("threshold",
+					mcp.Required(),
+					mcp.Description("Value whose crossing is projected (e.g., 0.9 for 90% disk utilization)"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the history (ISO 8601 format, defaults to 7 days before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the history (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithObject("aggregation",
+					mcp.Description("Optional aggregation configuration, as in list_time_series. An alignment_period (e.g., '3600s') is recommended, and required for evenly spaced points with holt_winters"),
+					mcp.Properties(aggregationProperties),
+				),
+				mcp.WithString("model",
+					mcp.Description("'linear' (default) fits a least-squares line; 'holt_winters' applies exponential smoothing with an optional season"),
+					mcp.Enum(monitoring.ForecastLinear, monitoring.ForecastHoltWinters),
+				),
+				mcp.WithString("season",
+					mcp.Description("Length of one seasonal cycle for holt_winters (e.g., '24h'); the history must span at least two cycles"),
+				),
+				mcp.WithString("horizon",
+					mcp.Description("How far past the last point to project (e.g., '2160h', default: '720h')"),
+				),
+				mcp.WithString("direction",
+					mcp.Description("'rising' for crossings above the threshold (e.g., disk usage) or 'falling' for crossings below it (e.g., free memory). Defaults to the side of the threshold the last value is on"),
+					mcp.Enum(monitoring.DirectionRising, monitoring.DirectionFalling),
+				),
+				mcp.WithNumber("max_series",
+					mcp.Description("Maximum number of series to forecast (default: 10)"),
+				),
+			),
+			Handler:   createForecastMetricHandler(deps.Monitoring, deps.ProjectID),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("list_metric_descriptors",
+				mcp.WithDescription("List metric descriptors from Cloud Monitoring"),
+				mcp.WithString("filter",
+					mcp.Description(`Filter expression for metric descriptors.
+If this field is empty, all custom and system-defined metric descriptors are returned.
+Otherwise, the [filter](https://cloud.google.com/monitoring/api/v3/filters) specifies which metric descriptors are to be returned. For example, the following filter matches all [custom metrics](https://cloud.google.com/monitoring/custom-metrics):
+
+metric.type = starts_with("custom.googleapis.com/")
+`),
+				),
+				mcp.WithNumber("page_size",
+					mcp.Description("Maximum number of descriptors to return (default: 100)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token for pagination"),
+				),
+				mcp.WithBoolean("fetch_all",
+					mcp.Description(fetchAllDescription),
+				),
+			),
+			Handler:   createListMetricDescriptorsHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("delete_metric_descriptor",
+				mcp.WithDescription("Delete a custom metric descriptor from Cloud Monitoring"),
+				mcp.WithString("metric_type",
+					mcp.Required(),
+					mcp.Description("Metric type to delete"),
+				),
+			),
+			Handler: createDeleteMetricDescriptorHandler(deps.Monitoring),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("delete_metric_descriptors",
+				mcp.WithDescription(`Delete the custom metric descriptors matching a filter or listed by type, with their data, e.g. to clean up experiment leftovers. Only user-defined metrics (custom.googleapis.com/ and external.googleapis.com/) can be deleted.
+The matches are previewed by default; call again with dry_run set to false to delete them concurrently, with one result per descriptor`),
+				mcp.WithString("filter",
+					mcp.Description("Monitoring filter selecting the descriptors to delete (e.g., 'metric.type = starts_with(\"custom.googleapis.com/experiment/\")')"),
+				),
+				mcp.WithArray("types",
+					mcp.Description("Metric types to delete, in addition to the filter matches"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithBoolean("dry_run",
+					mcp.Description("Only list the descriptors that would be deleted (default: true). Set to false to delete them"),
+				),
+			),
+			Handler: createDeleteMetricDescriptorsHandler(deps.Monitoring),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("clone_metric_descriptor",
+				mcp.WithDescription("Copy the kind, value type, description, and labels of a metric descriptor to a new type, e.g. to rename a custom metric, which Cloud Monitoring does not support. Optionally copies the latest point of each recent series of GAUGE DOUBLE metrics, so that charts and alerts on the new type have data right away. The source is left as is; delete it with delete_metric_descriptor once writers use the new type"),
+				mcp.WithString("source_type",
+					mcp.Required(),
+					mcp.Description("Metric type to copy (e.g., 'custom.googleapis.com/queue_depth')"),
+				),
+				mcp.WithString("target_type",
+					mcp.Required(),
+					mcp.Description("New user-defined metric type, which must not exist (e.g., 'custom.googleapis.com/queue/depth')"),
+				),
+				mcp.WithString("display_name",
+					mcp.Description("Display name of the new descriptor (default: the display name of the source)"),
+				),
+				mcp.WithBoolean("copy_data",
+					mcp.Description("Write the latest point of each series of the source in the lookback to the new type (GAUGE DOUBLE metrics only)"),
+				),
+				mcp.WithString("lookback",
+					mcp.Description("How far back series are copied with copy_data (e.g., '6h', default: '1h', maximum: '24h')"),
+				),
+			),
+			Handler: createCloneMetricDescriptorHandler(deps.Monitoring),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("compare_metric_descriptors",
+				mcp.WithDescription("Compare the custom metric descriptors of two projects, e.g. when promoting telemetry config from staging to production. Reports the metric types missing from either project, and those whose metric kind, value type, labels, display name, or description differ"),
+				mcp.WithString("source_project",
+					mcp.Description("Project to compare from, e.g. staging (default: the current project)"),
+				),
+				mcp.WithString("target_project",
+					mcp.Required(),
+					mcp.Description("Project to compare to, e.g. production"),
+				),
+				mcp.WithString("prefix",
+					mcp.Description("Prefix of the metric types to compare (default: 'custom.googleapis.com/')"),
+				),
+			),
+			Handler:   createCompareMetricDescriptorsHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("find_unused_metrics",
+				mcp.WithDescription(`List the custom metric descriptors with no data points written in the last days, by checking the time series of each descriptor. Descriptors that could not be checked are reported in errors rather than as unused.
+Unused metrics can be deleted by calling again with delete and confirm; only the confirmed metrics that are still unused are deleted`),
+				mcp.WithString("prefix",
+					mcp.Description("Prefix of the metric types to check (default: 'custom.googleapis.com/')"),
+				),
+				mcp.WithNumber("days",
+					mcp.Description("Number of days without data points for a metric to count as unused (default: 30)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithBoolean("delete",
+					mcp.Description("Delete the unused metrics listed in confirm, with their data. Not allowed in read-only mode"),
+				),
+				mcp.WithArray("confirm",
+					mcp.Description("Metric types to delete with delete, taken from the unused metrics of a previous call"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+			),
+			Handler: createFindUnusedMetricsHandler(deps.Monitoring, !deps.ReadOnly),
+		},
+		{
+			Definition: mcp.NewTool("list_available_metrics",
+				mcp.WithDescription("List available metrics in Cloud Monitoring"),
+				mcp.WithString("filter",
+					mcp.Description(`Filter expression for metric descriptors.
+If this field is empty, all custom and system-defined metric descriptors are returned.
+Otherwise, the [filter](https://cloud.google.com/monitoring/api/v3/filters) specifies which metric descriptors are to be returned. For example, the following filter matches all [custom metrics](https://cloud.google.com/monitoring/custom-metrics):
+
+metric.type = starts_with("custom.googleapis.com/")
+`),
+				),
+				mcp.WithNumber("page_size",
+					mcp.Description("Maximum number of metrics to return (default: 100)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token for pagination"),
+				),
+			),
+			Handler:   createListAvailableMetricsHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("search_metrics",
+				mcp.WithDescription("Search metric descriptors by keywords matched against metric type, display name, and description (e.g., 'pubsub backlog'). Matching is fuzzy, so exact filter syntax is not needed"),
+				mcp.WithString("query",
+					mcp.Required(),
+					mcp.Description("Keywords describing the metric to find"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of metrics to return (default: 20)"),
+				),
+				mcp.WithBoolean("refresh",
+					mcp.Description("Refetch the metric descriptor list instead of using the cached list (cached for 1 hour)"),
+				),
+			),
+			Handler:   createSearchMetricsHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("get_quota_usage",
+				mcp.WithDescription("Compare the peak allocation and rate quota usage of Google Cloud APIs with their limits, and report limits that rejected requests, to answer 'are we being throttled?'. Quotas are listed with throttled and most utilized first"),
+				mcp.WithString("service",
+					mcp.Description("Service to inspect (e.g., 'compute.googleapis.com'); all services when omitted"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (ISO 8601 format, defaults to 24 hours before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithNumber("min_utilization",
+					mcp.Description("Only return quotas whose peak usage reached this fraction of their limit (e.g., 0.8), or that were exceeded (default: 0)"),
+				),
+			),
+			Handler:   createGetQuotaUsageHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("list_monitored_resources",
+				mcp.WithDescription(fmt.Sprintf(`Enumerate the instances, services, and clusters emitting telemetry in a window, to answer 'what is even running here?'. For each resource type, a metric every active resource writes is listed, grouped by the resource labels identifying a resource, with the number of points and when the resource was last seen.
+Discovers %s by default; other resource types take metric_type and group_by`, strings.Join(monitoring.ResourceTypes, ", "))),
+				mcp.WithString("resource_type",
+					mcp.Description("Only discover resources of this monitored resource type (e.g., 'gce_instance'); all the default types when omitted"),
+				),
+				mcp.WithString("metric_type",
+					mcp.Description("Metric written by every active resource of resource_type (e.g., 'redis.googleapis.com/clients/connected'). Required with group_by for other resource types"),
+				),
+				mcp.WithArray("group_by",
+					mcp.Description("Resource labels identifying a resource of resource_type (e.g., ['instance_id']). Required with metric_type for other resource types"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (ISO 8601 format, defaults to 1 hour before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+			),
+			Handler:   createListMonitoredResourcesHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("describe_resource",
+				mcp.WithDescription(fmt.Sprintf(`Look up the metadata Cloud Monitoring keeps about a monitored resource, e.g. the name, machine type, and user labels of a VM known only by its instance_id, to tie numbers back to named infrastructure. Resources are found by a metric they write, so only resources active in the window are described; most recently seen first, up to 20.
+Resource types %s have a default metric; other resource types take metric_type`, strings.Join(monitoring.ResourceTypes, ", "))),
+				mcp.WithString("resource_type",
+					mcp.Required(),
+					mcp.Description("Monitored resource type (e.g., 'gce_instance')"),
+				),
+				mcp.WithObject("labels",
+					mcp.Description("Resource labels selecting the resource (e.g., {\"instance_id\": \"1234567890\"}); every resource of the type when omitted"),
+				),
+				mcp.WithString("metric_type",
+					mcp.Description("Metric written by the resource (e.g., 'redis.googleapis.com/clients/connected'). Required for other resource types"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (ISO 8601 format, defaults to 1 hour before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+			),
+			Handler:   createDescribeResourceHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("list_assets",
+				mcp.WithDescription("List the resources of a type that exist in the project according to Cloud Asset Inventory, with their id, name, location, state, and labels, to match telemetry against the infrastructure it claims to come from. With ids, e.g. the instance_id labels of time series, returns the matching assets and the ids without an asset, such as the metrics of deleted VMs. Requires the Cloud Asset API and roles/cloudasset.viewer"),
+				mcp.WithString("asset_type",
+					mcp.Required(),
+					mcp.Description("Cloud Asset Inventory asset type (e.g., 'compute.googleapis.com/Instance', 'container.googleapis.com/Cluster', 'run.googleapis.com/Service', 'sqladmin.googleapis.com/Instance')"),
+				),
+				mcp.WithArray("ids",
+					mcp.Description("Resource IDs or names to check, matched against the id, name, and full resource name of the assets (e.g., ['1234567890', 'web-1'])"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of assets to return, up to 1000 (default: 100)"),
+				),
+			),
+			Handler:   createListAssetsHandler(deps.Assets),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("analyze_metric_cardinality",
+				mcp.WithDescription("Count the time series of a metric in a window, each a distinct combination of label values, and the distinct values of each label, and warn about high-cardinality metric labels (e.g., user or request IDs) that risk quota and cost blowups. Labels are listed with the most distinct values first"),
+				mcp.WithString("metric_type",
+					mcp.Required(),
+					mcp.Description("Metric type to analyze (e.g., 'custom.googleapis.com/checkout/latency')"),
+				),
+				mcp.WithString("filter",
+					mcp.Description("Monitoring filter added to the metric type (e.g., 'resource.type=\"gce_instance\"')"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (ISO 8601 format, defaults to 24 hours before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithNumber("threshold",
+					mcp.Description("Number of distinct values above which a metric label is reported as high cardinality (default: 100)"),
+				),
+			),
+			Handler:   createAnalyzeMetricCardinalityHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("simulate_burn_rate",
+				mcp.WithDescription("Replay multi-window burn-rate alerts over the history of a request-based SLO and report when each alert would have fired, to tune alerting policies before deploying them. Takes an existing SLO by name or an inline definition with a goal and two of good, bad, and total filters"),
+				mcp.WithString("slo_name",
+					mcp.Description("Full resource name of an existing SLO (projects/PROJECT/services/SERVICE/serviceLevelObjectives/SLO). Overrides the inline definition"),
+				),
+				mcp.WithNumber("goal",
+					mcp.Description("SLO goal for an inline definition (e.g., 0.999)"),
+				),
+				mcp.WithString("period",
+					mcp.Description("Compliance period of an inline definition (e.g., '672h', default: '720h')"),
+				),
+				mcp.WithString("good_filter",
+					mcp.Description("Monitoring filter counting good events"),
+				),
+				mcp.WithString("bad_filter",
+					mcp.Description("Monitoring filter counting bad events"),
+				),
+				mcp.WithString("total_filter",
+					mcp.Description("Monitoring filter counting all events"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the replay (ISO 8601 format, defaults to 7 days before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the replay (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithArray("policies",
+					mcp.Description("Burn-rate alert policies to replay. Defaults to paging at 14.4x over 1h/5m and 6x over 6h/30m, and a ticket at 1x over 72h/6h"),
+					mcp.Items(map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"name":         map[string]any{"type": "string", "description": "Policy name"},
+							"burn_rate":    map[string]any{"type": "number", "description": "Burn rate both windows must reach for the alert to fire"},
+							"long_window":  map[string]any{"type": "string", "description": "Long lookback window (e.g., '1h')"},
+							"short_window": map[string]any{"type": "string", "description": "Short lookback window (e.g., '5m')"},
+						},
+						"required": []string{"burn_rate", "long_window", "short_window"},
+					}),
+				),
+			),
+			Handler:   createSimulateBurnRateHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("lint_alert_policies",
+				mcp.WithDescription("Check alerting policies for common problems: invalid policies, no notification channel, missing documentation, conditions that fire on a single data point, and deprecated metrics. Each finding comes with an actionable suggestion, most severe first"),
+				mcp.WithString("filter",
+					mcp.Description(`Alert policy filter selecting the policies to check (e.g., 'display_name=starts_with("prod")'); all policies when omitted`),
+				),
+			),
+			Handler:   createLintAlertPoliciesHandler(deps.Monitoring),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("export_monitoring_config",
+				mcp.WithDescription("Export alert policies, dashboards, and custom metric descriptors as Terraform HCL (google provider resources, each with its import command) or YAML (the API representation accepted by gcloud), so that configuration can be committed as infrastructure as code"),
+				mcp.WithArray("kinds",
+					mcp.Description("Resource kinds to export (default: all)"),
+					mcp.Items(map[string]any{"type": "string", "enum": export.AllKinds}),
+				),
+				mcp.WithString("format",
+					mcp.Description("Output format (default: 'terraform')"),
+					mcp.Enum(export.FormatTerraform, export.FormatYAML),
+				),
+				mcp.WithString("alert_policy_filter",
+					mcp.Description(`Alert policy filter selecting the policies to export (e.g., 'display_name=starts_with("prod")')`),
+				),
+				mcp.WithString("metric_type_prefix",
+					mcp.Description("Prefix of the metric descriptors to export (default: 'custom.googleapis.com/')"),
+				),
+			),
+			Handler:   createExportMonitoringConfigHandler(exporter),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("apply_alert_policy_json",
+				mcp.WithDescription("Create or update an alerting policy from its JSON definition, as exported from the Cloud Console or returned by the API, e.g., to migrate a policy between projects. By default, the policy with the same ID in the target project is updated when it exists, and a new policy is created otherwise"),
+				mcp.WithString("definition",
+					mcp.Required(),
+					mcp.Description("Alert policy JSON"),
+				),
+				mcp.WithString("mode",
+					mcp.Description("'auto' (default), 'create' to always create a new policy, or 'update' to fail unless the policy exists in the target project"),
+					mcp.Enum(monitoring.ApplyModeAuto, monitoring.ApplyModeCreate, monitoring.ApplyModeUpdate),
+				),
+				mcp.WithArray("notification_channels",
+					mcp.Description("Notification channels replacing those of the definition (e.g., 'projects/my-project/notificationChannels/123'). Without it, channels of a different source project are dropped"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithBoolean("dry_run",
+					mcp.Description("Parse the definition and report whether the policy would be created or updated, without applying it"),
+				),
+			),
+			Handler: createApplyAlertPolicyJSONHandler(deps.Monitoring),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("apply_dashboard_json",
+				mcp.WithDescription("Create or update a dashboard from its JSON definition, as exported from the Cloud Console or returned by the API, e.g., to migrate a dashboard between projects. By default, the dashboard with the same ID in the target project is replaced when it exists, and a new dashboard is created otherwise"),
+				mcp.WithString("definition",
+					mcp.Required(),
+					mcp.Description("Dashboard JSON"),
+				),
+				mcp.WithString("mode",
+					mcp.Description("'auto' (default), 'create' to always create a new dashboard, or 'update' to fail unless the dashboard exists in the target project"),
+					mcp.Enum(monitoring.ApplyModeAuto, monitoring.ApplyModeCreate, monitoring.ApplyModeUpdate),
+				),
+				mcp.WithBoolean("dry_run",
+					mcp.Description("Validate the definition with the API and report whether the dashboard would be created or updated, without applying it"),
+				),
+			),
+			Handler: createApplyDashboardJSONHandler(deps.Monitoring),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("watch_metric",
+				mcp.WithDescription("Watch time series in the background for the rest of the session. The filter is polled every interval, and the client is sent a log notification whenever the latest point of a series crosses the threshold, and again when it recovers, optionally also posted to a webhook. Returns the watch, which can be stopped with stop_watch."),
+				mcp.WithString("filter",
+					mcp.Required(),
+					mcp.Description("Time series filter (e.g., 'metric.type=\"compute.googleapis.com/instance/cpu/utilization\"')"),
+				),
+				mcp.WithNumber("threshold",
+					mcp.Required(),
+					mcp.Description("Threshold compared to the latest point of each series"),
+				),
+				mcp.WithString("comparison",
+					mcp.Description("Whether a series breaches when it is above (default) or below the threshold"),
+					mcp.Enum(watch.ComparisonAbove, watch.ComparisonBelow),
+				),
+				mcp.WithString("interval",
+					mcp.Description("Polling interval as a duration (default: 1m, minimum: 10s)"),
+				),
+				mcp.WithObject("aggregation",
+					mcp.Description("Optional aggregation configuration applied before comparing, e.g. to compare a rate or a sum across series"),
+					mcp.Properties(aggregationProperties),
+				),
+				mcp.WithString("webhook_url",
+					mcp.Description("https URL of a webhook the notifications are also posted to, e.g. a Slack incoming webhook, with the message as 'text' and the notification as 'event'"),
+				),
+			),
+			Handler: createWatchMetricHandler(deps.Watches, deps.Monitoring),
+		},
+		{
+			Definition: mcp.NewTool("watch_logs",
+				mcp.WithDescription("Watch log entries in the background for the rest of the session. The filter is polled every interval, and the client is sent a log notification whenever new matching entries are written, e.g. to be told when an error shows up again, optionally also posted to a webhook. Only entries written after the watch started are reported. Returns the watch, which can be stopped with stop_watch."),
+				mcp.WithString("filter",
+					mcp.Required(),
+					mcp.Description("Cloud Logging filter (e.g., 'severity>=ERROR AND resource.type=\"cloud_run_revision\"')"),
+				),
+				mcp.WithString("pattern",
+					mcp.Description("Regular expression the message or JSON payload of an entry must match, for matching beyond what the filter can express"),
+				),
+				mcp.WithString("interval",
+					mcp.Description("Polling interval as a duration (default: 1m, minimum: 10s)"),
+				),
+				mcp.WithNumber("samples",
+					mcp.Description("Maximum number of matching entries included in each notification (default: 5)"),
+				),
+				mcp.WithString("webhook_url",
+					mcp.Description("https URL of a webhook the notifications are also posted to, e.g. a Slack incoming webhook, with the message as 'text' and the notification as 'event'"),
+				),
+			),
+			Handler: createWatchLogsHandler(deps.Watches, deps.Logging),
+		},
+		{
+			Definition: mcp.NewTool("list_watches",
+				mcp.WithDescription("List the watches running in the current session, with the time and error of their last check and the number of notifications they sent"),
+			),
+			Handler: createListWatchesHandler(deps.Watches),
+		},
+		{
+			Definition: mcp.NewTool("stop_watch",
+				mcp.WithDescription("Stop a watch running in the current session"),
+				mcp.WithString("id",
+					mcp.Required(),
+					mcp.Description("ID of the watch, as returned by watch_metric, watch_logs, or list_watches"),
+				),
+			),
+			Handler: createStopWatchHandler(deps.Watches),
+		},
+		{
+			Definition: mcp.NewTool("list_recent_notifications",
+				mcp.WithDescription("List the Cloud Monitoring alert notifications recently received from the configured Pub/Sub subscription, newest first. Each notification describes an incident that opened or closed, with its policy, condition, resource, metric, observed and threshold values, and documentation."),
+				mcp.WithString("state",
+					mcp.Description("Only list notifications of incidents in this state"),
+					mcp.Enum(notifications.StateOpen, notifications.StateClosed),
+				),
+				mcp.WithString("policy",
+					mcp.Description("Only list notifications of alert policies whose name contains this text (case-insensitive)"),
+				),
+				mcp.WithString("since",
+					mcp.Description("Only list notifications received at or after this time (RFC3339 format)"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of notifications to return (default: 20)"),
+				),
+			),
+			Handler:  createListRecentNotificationsHandler(deps.Subscriber),
+			Disabled: deps.Subscriber == nil,
+		},
+		{
+			Definition: mcp.NewTool("list_traces",
+				mcp.WithDescription("List traces from Cloud Trace"),
+				mcp.WithString("start_time",
+					mcp.Required(),
+					mcp.Description("Start time for the query (ISO 8601 format)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Required(),
+					mcp.Description("End time for the query (ISO 8601 format)"),
+				),
+				mcp.WithString("filter",
+					mcp.Description(`By default, searches use prefix matching. To specify exact match, prepend
+  a plus symbol (+) to the search term.
+  Multiple terms are ANDed. Syntax:
+
+    - root:NAME_PREFIX or NAME_PREFIX: Return traces where any root
+      span starts with NAME_PREFIX.
+    - +root:NAME or +NAME: Return traces where any root span's name is
+      exactly NAME.
+    - span:NAME_PREFIX: Return traces where any span starts with
+      NAME_PREFIX.
+    - +span:NAME: Return traces where any span's name is exactly
+      NAME.
+    - latency:DURATION: Return traces whose overall latency is
+      greater or equal to than DURATION. Accepted units are nanoseconds
+      (ns), milliseconds (ms), and seconds (s). Default is ms. For
+      example, latency:24ms returns traces whose overall latency
+      is greater than or equal to 24 milliseconds.
+    - label:LABEL_KEY: Return all traces containing the specified
+      label key (exact match, case-sensitive) regardless of the key:value
+      pair's value (including empty values).
+    - LABEL_KEY:VALUE_PREFIX: Return all traces containing the specified
+      label key (exact match, case-sensitive) whose value starts with
+      VALUE_PREFIX. Both a key and a value must be specified.
+    - +LABEL_KEY:VALUE: Return all traces containing a key:value pair
+      exactly matching the specified text. Both a key and a value must be
+      specified.
+    - method:VALUE: Equivalent to /http/method:VALUE.
+    - url:VALUE: Equivalent to /http/url:VALUE.
+      `),
+				),
+				mcp.WithString("order_by",
+					mcp.Description("Order by field (e.g., 'start_time desc')"),
+				),
+				mcp.WithString("view",
+					mcp.Description("Amount of data returned per trace: 'MINIMAL' (trace IDs only, default), 'ROOTSPAN' (root span only), or 'COMPLETE' (all spans)"),
+				),
+				mcp.WithNumber("page_size",
+					mcp.Description("Maximum number of traces to return (default: 100)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token for pagination"),
+				),
+				mcp.WithBoolean("fetch_all",
+					mcp.Description(fetchAllDescription),
+				),
+				mcp.WithBoolean("count_only",
+					mcp.Description(countOnlyDescription),
+				),
+				mcp.WithString("sort",
+					mcp.Description("Sort the listed traces by the duration or the start time of their root span. The view defaults to ROOTSPAN when set"),
+					mcp.Enum(trace.TraceSorts...),
+				),
+				mcp.WithString("group_by",
+					mcp.Description("Group the listed traces by the name of their root span, with the count and the min, mean, and max duration of each group, largest groups first. The view defaults to ROOTSPAN when set"),
+					mcp.Enum("root_span_name"),
+				),
+				mcp.WithNumber("top",
+					mcp.Description("Only return the first N traces, or the first N groups with group_by, after sorting"),
+				),
+			),
+			Handler:   createListTracesHandler(deps.Trace),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("get_trace",
+				mcp.WithDescription("Get a specific trace from Cloud Trace"),
+				mcp.WithString("trace_id",
+					mcp.Required(),
+					mcp.Description("Trace ID to retrieve"),
+				),
+			),
+			Handler:   createGetTraceHandler(deps.Trace),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("get_traces",
+				mcp.WithDescription("Get several traces from Cloud Trace concurrently, e.g. all exemplar traces of a metric. Returns the traces keyed by trace ID; traces that could not be fetched are reported under errors"),
+				mcp.WithArray("trace_ids",
+					mcp.Required(),
+					mcp.Description("Trace IDs to retrieve (at most 100)"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+			),
+			Handler:   createGetTracesHandler(deps.Trace),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("parse_trace_context",
+				mcp.WithDescription("Parse a W3C traceparent or X-Cloud-Trace-Context header value into its trace ID, span ID, and sampling decision, with a link to the trace in the Cloud Console"),
+				mcp.WithString("header",
+					mcp.Required(),
+					mcp.Description("Header value, optionally prefixed with the header name (e.g., '00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01' or 'X-Cloud-Trace-Context: 105445aa7843bc8bf206b12000100000/1;o=1')"),
+				),
+			),
+			Handler: createParseTraceContextHandler(deps.ProjectID),
+		},
+		{
+			Definition: mcp.NewTool("analyze_trace_gaps",
+				mcp.WithDescription("Inspect a trace and report untraced time between spans (span duration minus the time covered by its children). Large gaps show where instrumentation is missing or where the application did blocking work"),
+				mcp.WithString("trace_id",
+					mcp.Required(),
+					mcp.Description("Trace ID to analyze"),
+				),
+				mcp.WithString("min_gap",
+					mcp.Description("Minimum duration of reported gaps (e.g., '10ms', default: '1ms')"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of gaps to return, largest first (default: 20)"),
+				),
+			),
+			Handler:   createAnalyzeTraceGapsHandler(deps.Trace),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("find_error_spans",
+				mcp.WithDescription("Scan the traces in a time window for failed spans, with an HTTP status code of 500 or above (/http/status_code label) or an error=true label, and return them grouped by span name with counts, status codes, and example trace IDs, most errors first"),
+				mcp.WithString("start_time",
+					mcp.Required(),
+					mcp.Description("Start time of the window"),
+				),
+				mcp.WithString("end_time",
+					mcp.Required(),
+					mcp.Description("End time of the window"),
+				),
+				mcp.WithString("filter",
+					mcp.Description("Cloud Trace filter selecting the traces to scan, with the syntax of list_traces (e.g., 'root:GET /api')"),
+				),
+				mcp.WithNumber("max_examples",
+					mcp.Description("Maximum number of example trace IDs per span name (default: 3, max: 20)"),
+				),
+			),
+			Handler:   createFindErrorSpansHandler(deps.Trace),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("patch_traces",
+				mcp.WithDescription("Update trace spans in Cloud Trace. The trace ID must be 32 lowercase hexadecimal characters, and parents must be among the patched spans unless allow_missing_parents is set; spans are validated before being sent and all problems are reported at once"),
+				mcp.WithString("trace_id",
+					mcp.Required(),
+					mcp.Description("Trace ID to update"),
+				),
+				mcp.WithObject("spans",
+					mcp.Required(),
+					mcp.Description("Array of span objects to update or create, with 'span_id', 'name', 'start_time', 'end_time', and optional 'parent_id', 'labels', and 'kind' (UNSPECIFIED, RPC_SERVER, or RPC_CLIENT)"),
+				),
+				mcp.WithBoolean("allow_missing_parents",
+					mcp.Description("Accept parent_id values that are not among the patched spans, to add child spans to a trace that already exists (default: false)"),
+				),
+			),
+			Handler: createPatchTracesHandler(deps.Trace),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("create_profile",
+				mcp.WithDescription("Create a new profile in Cloud Profiler"),
+				mcp.WithString("target",
+					mcp.Required(),
+					mcp.Description("Target deployment name"),
+				),
+				mcp.WithString("profile_type",
+					mcp.Required(),
+					mcp.Description("Profile type"),
+					mcp.Enum(profiler.ProfileTypes...),
+				),
+				mcp.WithString("duration",
+					mcp.Description("Profile duration (e.g., '60s', '5m', defaults to '60s')"),
+				),
+				mcp.WithObject("labels",
+					mcp.Description("Optional labels for the profile"),
+				),
+			),
+			Handler: createProfileHandler(deps.Profiler, deps.ProjectID),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("create_offline_profile",
+				mcp.WithDescription("Create an offline profile in Cloud Profiler from base64-encoded data or a local pprof file. Gzip compressed, uncompressed, and legacy text pprof profiles are accepted, up to 10 MiB"),
+				mcp.WithString("target",
+					mcp.Required(),
+					mcp.Description("Target deployment name"),
+				),
+				mcp.WithString("profile_type",
+					mcp.Required(),
+					mcp.Description("Profile type"),
+					mcp.Enum(profiler.ProfileTypes...),
+				),
+				mcp.WithString("profile_data",
+					mcp.Description("Base64-encoded profile data (either profile_data or profile_path is required)"),
+				),
+				mcp.WithString("profile_path",
+					mcp.Description("Path of a local pprof file on the machine running the server, e.g. '/tmp/cpu.pb.gz' (either profile_data or profile_path is required)"),
+				),
+				mcp.WithString("duration",
+					mcp.Description("Profile duration (e.g., '60s', '5m')"),
+				),
+				mcp.WithObject("labels",
+					mcp.Description("Optional labels for the profile"),
+				),
+			),
+			Handler: createOfflineProfileHandler(deps.Profiler, deps.ProjectID),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("update_profile",
+				mcp.WithDescription("Update a profile in Cloud Profiler"),
+				mcp.WithString("profile_name",
+					mcp.Required(),
+					mcp.Description("Profile name to update"),
+				),
+				mcp.WithString("profile_data",
+					mcp.Description("Updated base64-encoded profile data"),
+				),
+				mcp.WithObject("labels",
+					mcp.Description("Updated labels for the profile"),
+				),
+				mcp.WithString("update_mask",
+					mcp.Description("Fields to update (e.g., 'labels,profile_bytes')"),
+				),
+			),
+			Handler: updateProfileHandler(deps.Profiler),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("list_profiles",
+				mcp.WithDescription("List profiles from Cloud Profiler"),
+				mcp.WithNumber("page_size",
+					mcp.Description("Maximum number of profiles to return (default: 100)"),
+				),
+				mcp.WithString("page_token",
+					mcp.Description("Page token for pagination"),
+				),
+				mcp.WithBoolean("fetch_all",
+					mcp.Description(fetchAllDescription),
+				),
+			),
+			Handler:   listProfilesHandler(deps.Profiler, deps.ProjectID),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("aggregate_profiles",
+				mcp.WithDescription("Merge the profiles of a target and type collected over a time window and report the top hotspots by flat and cumulative value. Merging many profiles gives statistically meaningful results instead of the noise of a single profile."),
+				mcp.WithString("target",
+					mcp.Required(),
+					mcp.Description("Target deployment name"),
+				),
+				mcp.WithString("profile_type",
+					mcp.Required(),
+					mcp.Description("Profile type"),
+					mcp.Enum(profiler.ProfileTypes...),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (RFC3339 format, defaults to 24 hours before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (RFC3339 format, defaults to now)"),
+				),
+				mcp.WithObject("labels",
+					mcp.Description("Only merge profiles whose deployment has these labels, e.g. {\"version\": \"1.2.0\", \"zone\": \"us-central1-a\"}"),
+				),
+				mcp.WithNumber("max_profiles",
+					mcp.Description("Maximum number of profiles to merge (default: 50, maximum: 500)"),
+				),
+				mcp.WithString("sample_type",
+					mcp.Description("Sample type to report, e.g. 'cpu', 'alloc_space', or 'inuse_space' (defaults to the profile's default sample type)"),
+				),
+				mcp.WithNumber("top",
+					mcp.Description("Number of hotspots to return (default: 20)"),
+				),
+				mcp.WithArray("source_mappings",
+					mcp.Description("Map profile file paths to repositories so that each hotspot links to its hottest line. Overrides GCP_TELEMETRY_MCP_SOURCE_MAPPINGS"),
+					mcp.Items(map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"path_prefix": map[string]any{"type": "string", "description": "Prefix of the file paths in the profiles, e.g. 'github.com/org/repo/' or '/app/'"},
+							"repository":  map[string]any{"type": "string", "description": "Repository URL, e.g. 'https://github.com/org/repo' or 'https://source.cloud.google.com/PROJECT/REPO'"},
+							"revision":    map[string]any{"type": "string", "description": "Commit, tag, or branch to link to (defaults to HEAD, or master on Cloud Source Repositories)"},
+							"directory":   map[string]any{"type": "string", "description": "Directory of the repository the prefix corresponds to"},
+						},
+						"required": []string{"path_prefix", "repository"},
+					}),
+				),
+			),
+			Handler:   createAggregateProfilesHandler(deps.Profiler, deps.SourceMappings, deps.ProjectID),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("detect_memory_growth",
+				mcp.WithDescription("Detect memory leaks from the heap profiles of a target: the time range is split into buckets, the in-use bytes of each allocation site are averaged over the profiles of each bucket, and the sites whose in-use bytes increased monotonically across the buckets are reported, largest growth first"),
+				mcp.WithString("target",
+					mcp.Required(),
+					mcp.Description("Target deployment name"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the time range (RFC3339 format, defaults to 24 hours before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the time range (RFC3339 format, defaults to now)"),
+				),
+				mcp.WithObject("labels",
+					mcp.Description("Only compare profiles whose deployment has these labels, e.g. {\"version\": \"1.2.0\"}, so that restarts of other versions do not hide the growth"),
+				),
+				mcp.WithNumber("buckets",
+					mcp.Description("Number of time buckets to compare (default: 4, between 3 and 24)"),
+				),
+				mcp.WithNumber("max_profiles",
+					mcp.Description("Maximum number of heap profiles to compare (default: 100, maximum: 500)"),
+				),
+				mcp.WithNumber("min_growth_bytes",
+					mcp.Description("Only report sites whose in-use bytes grew by at least this many bytes"),
+				),
+				mcp.WithNumber("top",
+					mcp.Description("Number of sites to return (default: 20)"),
+				),
+				mcp.WithArray("source_mappings",
+					mcp.Description("Map profile file paths to repositories so that each site links to its allocating line. Overrides GCP_TELEMETRY_MCP_SOURCE_MAPPINGS"),
+					mcp.Items(map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"path_prefix": map[string]any{"type": "string", "description": "Prefix of the file paths in the profiles, e.g. 'github.com/org/repo/' or '/app/'"},
+							"repository":  map[string]any{"type": "string", "description": "Repository URL, e.g. 'https://github.com/org/repo' or 'https://source.cloud.google.com/PROJECT/REPO'"},
+							"revision":    map[string]any{"type": "string", "description": "Commit, tag, or branch to link to (defaults to HEAD, or master on Cloud Source Repositories)"},
+							"directory":   map[string]any{"type": "string", "description": "Directory of the repository the prefix corresponds to"},
+						},
+						"required": []string{"path_prefix", "repository"},
+					}),
+				),
+			),
+			Handler:   createDetectMemoryGrowthHandler(deps.Profiler, deps.SourceMappings, deps.ProjectID),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("save_query",
+				mcp.WithDescription("Save a named log or time series query to the shared saved query library"),
+				mcp.WithString("name",
+					mcp.Required(),
+					mcp.Description("Unique name of the query. Saving with an existing name replaces that query"),
+				),
+				mcp.WithString("kind",
+					mcp.Required(),
+					mcp.Description("Kind of query: 'logs' (runs like list_log_entries) or 'time_series' (runs like list_time_series)"),
+				),
+				mcp.WithString("filter",
+					mcp.Required(),
+					mcp.Description("Logging filter for 'logs' queries or monitoring filter for 'time_series' queries"),
+				),
+				mcp.WithString("description",
+					mcp.Description("What the query is for and how to interpret its results"),
+				),
+				mcp.WithObject("aggregation",
+					mcp.Description("Optional aggregation configuration for 'time_series' queries"),
+					mcp.Properties(aggregationProperties),
+				),
+			),
+			Handler: createSaveQueryHandler(deps.SavedQueries),
+			Write:   true,
+		},
+		{
+			Definition: mcp.NewTool("list_saved_queries",
+				mcp.WithDescription("List the queries in the saved query library"),
+			),
+			Handler: createListSavedQueriesHandler(deps.SavedQueries),
+		},
+		{
+			Definition: mcp.NewTool("run_saved_query",
+				mcp.WithDescription("Run a query from the saved query library"),
+				mcp.WithString("name",
+					mcp.Required(),
+					mcp.Description("Name of the saved query to run"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start time for 'time_series' queries (ISO 8601 format, defaults to 1 hour before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End time for 'time_series' queries (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of log entries or time series to return (default: 50 for logs, 100 for time series)"),
+				),
+				mcp.WithNumber("max_points",
+					mcp.Description("For 'time_series' queries, downsample each series to at most this many points (minimum 3), so that long ranges of dense data stay small"),
+				),
+				mcp.WithString("downsample",
+					mcp.Description("Downsampling method used with max_points: 'lttb' (default) keeps the points that best preserve the shape of the series, 'mean' averages buckets of consecutive points"),
+					mcp.Enum(monitoring.DownsampleLTTB, monitoring.DownsampleMean),
+				),
+			),
+			Handler:   createRunSavedQueryHandler(deps.SavedQueries, deps.Logging, deps.Monitoring, deps.ProjectID),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("generate_incident_report",
+				mcp.WithDescription(`Collect error logs, p99 latency, error rate, slow traces, and alerts for a service and time window concurrently, and return them as one structured report with a summary.
+By default, metrics and log labels of a Cloud Run service are used. Override the filters for services on other platforms`),
+				mcp.WithString("service",
+					mcp.Required(),
+					mcp.Description("Service name (e.g., the Cloud Run service, GKE container, App Engine module, or Cloud Function name)"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (ISO 8601 format, defaults to 1 hour before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithString("log_filter",
+					mcp.Description("Cloud Logging filter for the service's error logs (default: severity>=ERROR on entries whose service_name, container_name, module_id, or function_name resource label is the service)"),
+				),
+				mcp.WithString("latency_metric_filter",
+					mcp.Description("Monitoring filter selecting a latency distribution metric (default: run.googleapis.com/request_latencies of the service)"),
+				),
+				mcp.WithString("request_metric_filter",
+					mcp.Description("Monitoring filter selecting a request count metric with a response_code_class label (default: run.googleapis.com/request_count of the service)"),
+				),
+				mcp.WithString("trace_filter",
+					mcp.Description("Cloud Trace filter scoping slow traces to the service (e.g., 'root:/api'). All traces in the project when omitted"),
+				),
+				mcp.WithString("slow_trace_threshold",
+					mcp.Description("Minimum latency of traces reported as slow (e.g., '500ms', default: '1s')"),
+				),
+				mcp.WithNumber("max_log_entries",
+					mcp.Description("Maximum number of error log entries to examine (default: 200)"),
+				),
+				mcp.WithNumber("max_traces",
+					mcp.Description("Maximum number of slow traces to return (default: 10)"),
+				),
+			),
+			Handler:   createIncidentReportHandler(incidentGenerator),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("find_recent_changes",
+				mcp.WithDescription(`Scan Admin Activity audit logs for deployments, config changes, and IAM changes near a regression and rank them as likely culprits.
+Changes shortly before the regression rank highest. When metric_filter is given, the largest spike of that metric is used as the time of the regression`),
+				mcp.WithString("time",
+					mcp.Description("When the regression was noticed (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithString("before",
+					mcp.Description("How far before time to look for changes (e.g., '6h', default: '2h')"),
+				),
+				mcp.WithString("after",
+					mcp.Description("How far after time to look for changes (e.g., '30m', default: '15m')"),
+				),
+				mcp.WithString("service",
+					mcp.Description("Service name; changes whose resource name contains it rank higher"),
+				),
+				mcp.WithString("metric_filter",
+					mcp.Description("Monitoring filter selecting a metric whose largest increase marks the regression (e.g., 'metric.type=\"run.googleapis.com/request_latencies\"')"),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of changes to return (default: 20)"),
+				),
+			),
+			Handler:   createFindRecentChangesHandler(incidentGenerator),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("correlate_by_request_id",
+				mcp.WithDescription(`Gather the telemetry of one application request into a single timeline: the log entries whose label holds the request ID, the spans of the traces those entries were written in and of the traces with a span carrying the same label, and the metrics around the request.
+By default, the p99 latency of the Cloud Run service that logged the request is shown. Pass metric_filter for services on other platforms`),
+				mcp.WithString("request_id",
+					mcp.Required(),
+					mcp.Description("Request ID to look up (e.g., 'req-8f14e45f')"),
+				),
+				mcp.WithString("label",
+					mcp.Description("Log entry and span label holding the request ID (default: 'request_id')"),
+				),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window to search (ISO 8601 format, defaults to 24 hours before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window to search (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithString("metric_filter",
+					mcp.Description("Monitoring filter selecting the metrics shown from 5 minutes before to 5 minutes after the request (default: run.googleapis.com/request_latencies of the Cloud Run service that logged the request)"),
+				),
+				mcp.WithNumber("max_log_entries",
+					mcp.Description("Maximum number of log entries to examine, up to 1000 (default: 100)"),
+				),
+			),
+			Handler:   createCorrelateByRequestIDHandler(incidentGenerator),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("build_timeline",
+				mcp.WithDescription(`Merge the events of several signals during a time window into one chronologically ordered list: log entries, span starts and ends, alert openings and closings, and deployments recorded in the Admin Activity audit logs or by record_deploy_marker.
+Each event has its source (log, span_start, span_end, alert_open, alert_close, or deploy), time, and message. Use it to see what happened in what order around an incident`),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (ISO 8601 format, defaults to 1 hour before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithArray("signals",
+					mcp.Description("Signals to include: 'logs', 'spans', 'alerts', and 'deploys' (default: all)"),
+					mcp.Items(map[string]any{"type": "string", "enum": incident.Signals}),
+				),
+				mcp.WithString("log_filter",
+					mcp.Description("Logging filter selecting the log entries to include (default: 'severity>=WARNING')"),
+				),
+				mcp.WithString("trace_filter",
+					mcp.Description("Cloud Trace filter selecting the traces whose spans are included (e.g., 'root:/orders')"),
+				),
+				mcp.WithNumber("max_log_entries",
+					mcp.Description("Maximum number of log entries to include, up to 1000 (default: 200)"),
+				),
+				mcp.WithNumber("max_traces",
+					mcp.Description("Maximum number of traces whose spans are included, up to 100 (default: 10)"),
+				),
+				mcp.WithNumber("max_events",
+					mcp.Description("Maximum number of events to return, the earliest first, up to 5000 (default: 500)"),
+				),
+			),
+			Handler:   createBuildTimelineHandler(incidentGenerator),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("get_billing_metrics",
+				mcp.WithDescription(`Summarize cost metrics exported to Cloud Monitoring and Cloud Billing budget alerts written to Cloud Logging, so that cost anomalies can be investigated alongside performance ones.
+Series whose latest period is far above their earlier periods are reported as anomalies`),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (ISO 8601 format, defaults to 30 days before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithArray("metric_prefixes",
+					mcp.Description("Metric type prefixes of cost metrics (default: 'custom.googleapis.com/billing/', 'custom.googleapis.com/cost/', 'workload.googleapis.com/billing/')"),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithString("alignment_period",
+					mcp.Description("Period each cost metric is summed or averaged over (e.g., '1h', default: '24h')"),
+				),
+				mcp.WithString("budget_alert_filter",
+					mcp.Description("Logging filter matching budget notifications (default: 'jsonPayload.budgetDisplayName:*')"),
+				),
+				mcp.WithNumber("max_metrics",
+					mcp.Description("Maximum number of cost metrics to summarize (default: 20)"),
+				),
+			),
+			Handler:   createGetBillingMetricsHandler(billingReader),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("get_trace_ingestion_stats",
+				mcp.WithDescription(`Report how many spans Cloud Trace ingested, from the cloudtrace.googleapis.com/billing metrics, to understand sampling rates and trace costs.
+Returns the spans and chargeable spans in the window, the share of each service, the volume per alignment period, and the month-to-date chargeable spans with their estimated cost at list price. With request_metric_filter, the spans per request are reported too`),
+				mcp.WithString("start_time",
+					mcp.Description("Start of the window (ISO 8601 format, defaults to 24 hours before end_time)"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithString("alignment_period",
+					mcp.Description("Period the ingested spans are summed over (e.g., '15m', default: '1h')"),
+				),
+				mcp.WithString("request_metric_filter",
+					mcp.Description("Monitoring filter selecting a count of requests to compare the spans with (e.g., 'metric.type=\"run.googleapis.com/request_count\"')"),
+				),
+			),
+			Handler:   createGetTraceIngestionStatsHandler(billingReader),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("check_agent_health",
+				mcp.WithDescription(`Check whether the Ops Agent of a Compute Engine VM is installed, running, and shipping data, from the agent.googleapis.com/agent/uptime metric, the logs shipped from the VM, and the warnings the agent logged about itself.
+The status is 'healthy', 'degraded' (running, but logging problems or shipping no logs), 'stopped' (no recent metrics), or 'not_installed' (no agent data in the window)`),
+				mcp.WithString("instance",
+					mcp.Required(),
+					mcp.Description("Name or numeric ID of the Compute Engine instance"),
+				),
+				mcp.WithString("end_time",
+					mcp.Description("End of the window (ISO 8601 format, defaults to now)"),
+				),
+				mcp.WithString("lookback",
+					mcp.Description("Length of the window searched for agent data (e.g., '6h', default: '1h')"),
+				),
+				mcp.WithString("stale_after",
+					mcp.Description("How old the last uptime point may be for the agent to count as running (e.g., '5m', default: '10m')"),
+				),
+			),
+			Handler:   createCheckAgentHealthHandler(agentChecker),
+			Cacheable: true,
+		},
+		{
+			Definition: mcp.NewTool("set_session_defaults",
+				mcp.WithDescription("Set defaults applied to subsequent tool calls in this session. Only the given fields are changed; explicit tool arguments always take precedence"),
+				mcp.WithString("project_id",
+					mcp.Description("Project used by logging, monitoring, trace, and profiler tools instead of GOOGLE_CLOUD_PROJECT"),
+				),
+				mcp.WithString("resource_type",
+					mcp.Description("Monitored resource type the default resource labels apply to (e.g., 'gce_instance'). Also used as the resource of written log entries that do not set one"),
+				),
+				mcp.WithObject("resource_labels",
+					mcp.Description("Resource labels added to written log entries and time series whose resource type matches resource_type (e.g., {'zone': 'us-central1-a'})"),
+				),
+				mcp.WithString("log_name_prefix",
+					mcp.Description("Prefix prepended to log_name when writing log entries (e.g., 'checkout-')"),
+				),
+				mcp.WithString("time_zone",
+					mcp.Description("IANA time zone (e.g., 'Asia/Tokyo') that the timestamps of tool results are converted to, instead of the server default (UTC unless configured)"),
+				),
+				mcp.WithBoolean("clear",
+					mcp.Description("Clear all existing defaults before applying the given fields"),
+				),
+			),
+			Handler: createSetSessionDefaultsHandler(deps.Sessions),
+		},
+		{
+			Definition: mcp.NewTool("get_session_stats",
+				mcp.WithDescription("Report the Google Cloud API calls made for this session so far: the number of calls and errors, the bytes returned, and the approximate quota categories consumed, per API. Calls made by watches are not counted for the session"),
+			),
+			Handler:  createGetSessionStatsHandler(deps.APIUsage),
+			Disabled: deps.APIUsage == nil,
+		},
+		{
+			Definition: mcp.NewTool(runBatchTool,
+				mcp.WithDescription(`Run several tools in one call and return their results by step name, to save round trips for well-understood investigations, e.g. listing the error logs, the slowest traces, and the recent changes of a service at once.
+Each step is called like a separate call of its tool, with the session defaults. A failed step does not fail the batch: its error is reported with its result.
+Arguments can use the results of earlier steps with {{name.path}} templates, where path selects into the JSON result with .field, [N], and [*] for all the items of an array, e.g. {{slow.traces[*].trace_id}}. A string holding only a template is replaced by the selected value itself. Steps with for_each call their tool for each item of an array, referred to as {{item}}, e.g. get_trace with {'trace_id': '{{item}}'} for each trace ID. Steps referring to a step that did not succeed are skipped`),
+				mcp.WithArray("steps",
+					mcp.Required(),
+					mcp.Description("Tool calls to run, in order (at most 20)"),
+					mcp.Items(map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"name":      map[string]any{"type": "string", "description": "Name of the step's result, unique in the batch (default: the tool name)"},
+							"tool":      map[string]any{"type": "string", "description": "Name of the tool to call, e.g. 'list_log_entries'"},
+							"arguments": map[string]any{"type": "object", "description": "Arguments of the tool, which may hold {{name.path}} templates"},
+							"for_each":  map[string]any{"description": "Array, usually a template such as '{{slow.traces[*].trace_id}}', calling the tool once for each item, referred to as {{item}} in the arguments (at most 20 items)"},
+						},
+						"required": []string{"tool"},
+					}),
+				),
+				mcp.WithBoolean("concurrent",
+					mcp.Description("Run the steps at the same time instead of one after the other (default: false)"),
+				),
+				mcp.WithBoolean("stop_on_error",
+					mcp.Description("Skip the remaining steps after a step fails, or cancel the other steps when concurrent (default: false)"),
+				),
+			),
+			Handler: createRunBatchHandler(),
+		},
+		{
+			Definition: mcp.NewTool("schedule_job",
+				mcp.WithDescription(`Run a tool on a schedule in the background while the server runs, e.g. query the p99 latency of a service every hour, and optionally write a number from each result to a custom metric to keep a history of it. Jobs are not tied to the session scheduling them: they run with its session defaults until canceled with cancel_job or the server stops.
+The job runs once right away, and is only scheduled when that run succeeds. Returns the job, with the result of its first run`),
+				mcp.WithString("tool",
+					mcp.Required(),
+					mcp.Description("Name of the tool to call, e.g. 'list_time_series'"),
+				),
+				mcp.WithObject("arguments",
+					mcp.Description("Arguments of the tool. Timestamps can be given as {{now}} or {{now-DURATION}}, e.g. {'start_time': '{{now-1h}}', 'end_time': '{{now}}'}, resolved on every run"),
+				),
+				mcp.WithString("interval",
+					mcp.Description("Interval between runs as a duration (default: 1h, minimum: 1m)"),
+				),
+				mcp.WithString("description",
+					mcp.Description("What the job is for, shown by list_jobs"),
+				),
+				mcp.WithObject("metric",
+					mcp.Description("Write a number selected from each result to a custom metric with write_time_series, as a GAUGE point at the time of the run"),
+					mcp.Properties(map[string]any{
+						"metric_type":     map[string]any{"type": "string", "description": "Metric type to write, e.g. 'custom.googleapis.com/checkout/p99_latency'"},
+						"value_path":      map[string]any{"type": "string", "description": "Path of the number in the JSON result, with .field, [N], e.g. 'time_series[0].points[0].value'. Empty when the result is the number itself"},
+						"metric_labels":   map[string]any{"type": "object", "description": "Optional metric labels"},
+						"resource_type":   map[string]any{"type": "string", "description": "Resource type (default: 'global')"},
+						"resource_labels": map[string]any{"type": "object", "description": "Optional resource labels"},
+					}),
+				),
+			),
+			Handler:  createScheduleJobHandler(deps.Scheduler, deps.Sessions, deps.ReadOnly),
+			Disabled: deps.Scheduler == nil,
+		},
+		{
+			Definition: mcp.NewTool("list_jobs",
+				mcp.WithDescription("List the jobs scheduled with schedule_job, with the time, result, and error of their last run, and their number of runs and failures"),
+			),
+			Handler:  createListJobsHandler(deps.Scheduler),
+			Disabled: deps.Scheduler == nil,
+		},
+		{
+			Definition: mcp.NewTool("cancel_job",
+				mcp.WithDescription("Cancel a job scheduled with schedule_job"),
+				mcp.WithString("id",
+					mcp.Required(),
+					mcp.Description("ID of the job, as returned by schedule_job or list_jobs"),
+				),
+			),
+			Handler:  createCancelJobHandler(deps.Scheduler, deps.Sessions, deps.APIUsage),
+			Disabled: deps.Scheduler == nil,
+		},
+	}
+}
+
+// RegisterTools adds the tools of Tools to s. The tools that write to Google
+// Cloud are not added when deps.ReadOnly, the tools named in
+// deps.DisabledTools are not added, and list_recent_notifications is only
+// added when deps.Subscriber is set.
+func RegisterTools(s *server.MCPServer, deps Deps) {
+	for _, tool := range Tools(deps) {
+		if tool.Disabled || (tool.Write && deps.ReadOnly) || slices.Contains(deps.DisabledTools, tool.Definition.Name) {
+			continue
+		}
+		s.AddTool(tool.Definition, tool.Handler)
+	}
+}
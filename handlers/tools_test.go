@@ -0,0 +1,1225 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/kitagry/gcp-telemetry-mcp/apiusage"
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/handlers"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/middleware"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	monitoringmocks "github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/schedule"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	tracemocks "github.com/kitagry/gcp-telemetry-mcp/trace/mocks"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// call sends a JSON-RPC request to s and decodes the result into v
+func call(t *testing.T, s *server.MCPServer, method string, params any, v any) {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, ok := s.HandleMessage(context.Background(), data).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("%s failed", method)
+	}
+	result, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(result, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTools(t *testing.T) {
+	names := make(map[string]bool)
+	for _, tool := range handlers.Tools(handlers.Deps{}) {
+		if names[tool.Definition.Name] {
+			t.Errorf("Duplicate tool %s", tool.Definition.Name)
+		}
+		names[tool.Definition.Name] = true
+		if tool.Handler == nil {
+			t.Errorf("Tool %s has no handler", tool.Definition.Name)
+		}
+		if tool.Definition.Description == "" {
+			t.Errorf("Tool %s has no description", tool.Definition.Name)
+		}
+		if tool.Cacheable && tool.Write {
+			t.Errorf("Tool %s writes, so its results cannot be cached", tool.Definition.Name)
+		}
+	}
+}
+
+func TestRegisterTools(t *testing.T) {
+	tests := []struct {
+		name          string
+		readOnly      bool
+		disabledTools []string
+		wantWrite     bool
+	}{
+		{name: "all tools", readOnly: false, wantWrite: true},
+		{name: "read-only", readOnly: true, wantWrite: false},
+		{name: "disabled tool", disabledTools: []string{"write_log_entry"}, wantWrite: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+			handlers.RegisterTools(s, handlers.Deps{ReadOnly: tt.readOnly, DisabledTools: tt.disabledTools})
+
+			var result mcp.ListToolsResult
+			call(t, s, "tools/list", nil, &result)
+			tools := make(map[string]bool)
+			for _, tool := range result.Tools {
+				tools[tool.Name] = true
+			}
+			if !tools["list_log_entries"] {
+				t.Error("Expected list_log_entries to be registered")
+			}
+			if tools["write_log_entry"] != tt.wantWrite {
+				t.Errorf("write_log_entry registered = %v, want %v", tools["write_log_entry"], tt.wantWrite)
+			}
+			if tools["list_recent_notifications"] {
+				t.Error("Expected list_recent_notifications not to be registered without a subscriber")
+			}
+			if tools["get_session_stats"] {
+				t.Error("Expected get_session_stats not to be registered without an API usage tracker")
+			}
+			if tools["schedule_job"] {
+				t.Error("Expected schedule_job not to be registered without a scheduler")
+			}
+		})
+	}
+}
+
+func TestListLogEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockLoggingClient(ctrl)
+	client.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+			if req.Limit != 10 || !strings.Contains(req.Filter, "ERROR") {
+				return logging.ListEntriesResponse{}, fmt.Errorf("unexpected request %+v", req)
+			}
+			return logging.ListEntriesResponse{Entries: []logging.LogEntry{{Severity: "ERROR", Message: "payment failed"}}}, nil
+		})
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Logging: client})
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{
+		"name":      "list_log_entries",
+		"arguments": map[string]any{"min_severity": "ERROR", "limit": 10},
+	}, &result)
+	if result.IsError {
+		t.Fatalf("list_log_entries failed: %+v", result.Content)
+	}
+	if len(result.Content) != 1 || !strings.Contains(result.Content[0].Text, "payment failed") {
+		t.Errorf("Expected the listed entry, got %+v", result.Content)
+	}
+
+	var response struct {
+		SeverityCounts map[string]int `json:"severity_counts"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(response.SeverityCounts) != "map[ERROR:1]" {
+		t.Errorf("Expected the severities of the listed entries, got %v", response.SeverityCounts)
+	}
+}
+
+func TestListLogEntriesCountOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockLoggingClient(ctrl)
+	client.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+			if req.PageToken == "" {
+				return logging.ListEntriesResponse{Entries: []logging.LogEntry{{Severity: "ERROR"}, {Severity: "CRITICAL"}}, NextPageToken: "next"}, nil
+			}
+			return logging.ListEntriesResponse{Entries: []logging.LogEntry{{Severity: "ERROR"}}}, nil
+		}).
+		Times(2)
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Logging: client})
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{
+		"name":      "list_log_entries",
+		"arguments": map[string]any{"min_severity": "ERROR", "count_only": true},
+	}, &result)
+	if result.IsError || len(result.Content) != 1 {
+		t.Fatalf("list_log_entries failed: %+v", result.Content)
+	}
+	var response map[string]any
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response["count"] != float64(3) || response["entries"] != nil {
+		t.Errorf("Expected a count of 3 without entries, got %v", response)
+	}
+	// The severities of all the counted matches are summarized
+	if fmt.Sprint(response["severity_counts"]) != "map[CRITICAL:1 ERROR:2]" {
+		t.Errorf("Expected the severities of all the matches, got %v", response["severity_counts"])
+	}
+}
+
+func TestWriteLogEntryBackfill(t *testing.T) {
+	client := fake.NewLoggingClient("test-project")
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Logging: logging.NewWithClient(client)})
+
+	write := func(timestamp string) (string, bool) {
+		t.Helper()
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{
+			"name": "write_log_entry",
+			"arguments": map[string]any{
+				"log_name":  "backfill",
+				"severity":  "ERROR",
+				"message":   "payment failed",
+				"insert_id": "order-42",
+				"timestamp": timestamp,
+			},
+		}, &result)
+		return result.Content[0].Text, result.IsError
+	}
+
+	// Retried writes of the same event are stored once
+	past := time.Now().Add(-72 * time.Hour).UTC().Truncate(time.Second)
+	for range 2 {
+		if text, isError := write(past.Format(time.RFC3339)); isError {
+			t.Fatalf("write_log_entry failed: %s", text)
+		}
+	}
+	entries, err := client.ListEntries(context.Background(), logging.ListEntriesRequest{Filter: fmt.Sprintf(`timestamp>=%q`, past.Add(-time.Hour).Format(time.RFC3339))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries.Entries) != 1 || !entries.Entries[0].Timestamp.Equal(past) || entries.Entries[0].InsertID != "order-42" {
+		t.Errorf("Expected one entry at %s, got %+v", past, entries.Entries)
+	}
+
+	if text, isError := write(time.Now().Add(48 * time.Hour).Format(time.RFC3339)); !isError || !strings.Contains(text, "in the future") {
+		t.Errorf("Expected an error for a timestamp in the future, got %s", text)
+	}
+	if text, isError := write("yesterday"); !isError || !strings.Contains(text, "invalid timestamp") {
+		t.Errorf("Expected an error for an invalid timestamp, got %s", text)
+	}
+}
+
+func TestWriteLogEntriesPartialFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockLoggingClient(ctrl)
+	client.EXPECT().
+		WriteEntries(gomock.Any(), gomock.Any()).
+		Return(&logging.PartialWriteError{Written: 1, Failed: 1, Errors: []logging.EntryWriteError{{Index: 1, Error: "permission denied"}}})
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Logging: client})
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{
+		"name": "write_log_entries",
+		"arguments": map[string]any{"log_name": "app", "entries": []any{
+			map[string]any{"severity": "INFO", "message": "first"},
+			map[string]any{"severity": "INFO", "message": "second"},
+		}},
+	}, &result)
+	if result.IsError {
+		t.Fatalf("Expected a report of the partial write, got error %s", result.Content[0].Text)
+	}
+	var report logging.PartialWriteError
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Written != 1 || report.Failed != 1 || len(report.Errors) != 1 || report.Errors[0].Index != 1 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
+
+func TestDeployMarkers(t *testing.T) {
+	client := fake.NewLoggingClient("test-project")
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Logging: logging.NewWithClient(client)})
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	deployed := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	for _, version := range []string{"v41", "v42"} {
+		call(t, s, "tools/call", map[string]any{
+			"name": "record_deploy_marker",
+			"arguments": map[string]any{
+				"service":     "checkout",
+				"version":     version,
+				"environment": "production",
+				"commit":      "8f14e45f",
+				"timestamp":   deployed.Format(time.RFC3339),
+			},
+		}, &result)
+		if result.IsError {
+			t.Fatalf("record_deploy_marker failed: %s", result.Content[0].Text)
+		}
+		deployed = deployed.Add(time.Minute)
+	}
+
+	call(t, s, "tools/call", map[string]any{
+		"name":      "list_deploy_markers",
+		"arguments": map[string]any{"service": "checkout", "limit": 1},
+	}, &result)
+	if result.IsError {
+		t.Fatalf("list_deploy_markers failed: %s", result.Content[0].Text)
+	}
+	var response struct {
+		Markers       []logging.DeployMarker `json:"markers"`
+		NextPageToken string                 `json:"next_page_token"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Markers) != 1 || response.Markers[0].Version != "v42" || response.Markers[0].Commit != "8f14e45f" || response.NextPageToken == "" {
+		t.Errorf("Expected the newest marker and a next page, got %+v", response)
+	}
+
+	call(t, s, "tools/call", map[string]any{
+		"name":      "list_deploy_markers",
+		"arguments": map[string]any{"service": "billing"},
+	}, &result)
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Markers) != 0 {
+		t.Errorf("Expected no markers of another service, got %+v", response.Markers)
+	}
+}
+
+func TestWriteTimeSeriesBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := monitoringmocks.NewMockMonitoringClientInterface(ctrl)
+	// The series are written in one call, which rejects the payments
+	// series and writes the others
+	client.EXPECT().
+		WriteTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.WriteTimeSeriesRequest) error {
+			var rejected int32
+			for _, ts := range req.TimeSeries {
+				if ts.MetricLabels["queue"] == "payments" {
+					rejected++
+				}
+			}
+			if rejected == 0 {
+				return nil
+			}
+			total := int32(len(req.TimeSeries))
+			st, err := status.New(codes.InvalidArgument, "Points must be written in order.").
+				WithDetails(&monitoringpb.CreateTimeSeriesSummary{TotalPointCount: total, SuccessPointCount: total - rejected})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return st.Err()
+		}).
+		Times(3)
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Monitoring: monitoring.NewWithClient(client, "test-project")})
+
+	write := func(queues ...string) (string, bool) {
+		t.Helper()
+		var series []any
+		for _, queue := range queues {
+			series = append(series, map[string]any{
+				"metric_type":   "custom.googleapis.com/queue_depth",
+				"resource_type": "global",
+				"metric_labels": map[string]any{"queue": queue},
+				"value":         42,
+			})
+		}
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{"name": "write_time_series_batch", "arguments": map[string]any{"time_series": series}}, &result)
+		return result.Content[0].Text, result.IsError
+	}
+
+	if text, isError := write("orders", "shipping"); isError || text != "2 time series written successfully" {
+		t.Errorf("Expected both series to be written, got %s", text)
+	}
+
+	// The other series are written, and the failure is reported
+	text, isError := write("orders", "payments", "shipping")
+	if isError {
+		t.Fatalf("Expected a report of the partial write, got error %s", text)
+	}
+	var report struct {
+		Written int `json:"written"`
+		Failed  int `json:"failed"`
+		Errors  []struct {
+			Start  int    `json:"start"`
+			End    int    `json:"end"`
+			Failed int    `json:"failed"`
+			Error  string `json:"error"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(text), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Written != 2 || report.Failed != 1 || len(report.Errors) != 1 || report.Errors[0].End != 3 || report.Errors[0].Failed != 1 || !strings.Contains(report.Errors[0].Error, "in order") {
+		t.Errorf("Unexpected report %s", text)
+	}
+
+	if text, isError := write("payments"); !isError || !strings.Contains(text, "Failed to write time series") {
+		t.Errorf("Expected an error when no series is written, got %s", text)
+	}
+}
+
+func TestWriteLogEntryLogName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockLoggingClient(ctrl)
+	client.EXPECT().
+		WriteEntry(gomock.Any(), "projects/my-project/logs/app%2Frequests", gomock.Any()).
+		Return(nil)
+	client.EXPECT().
+		WriteEntry(gomock.Any(), "my-project%2Flogs%2Fapp", gomock.Any()).
+		Return(nil)
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Logging: client})
+
+	write := func(logName string) (string, bool) {
+		t.Helper()
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{
+			"name":      "write_log_entry",
+			"arguments": map[string]any{"log_name": logName, "severity": "INFO", "message": "ok"},
+		}, &result)
+		return result.Content[0].Text, result.IsError
+	}
+
+	// Slashes in the log ID are URL-encoded
+	if text, isError := write("projects/my-project/logs/app/requests"); isError {
+		t.Fatalf("write_log_entry failed: %s", text)
+	}
+	// Log IDs containing "/logs/" are log IDs, not full names
+	if text, isError := write("my-project/logs/app"); isError {
+		t.Fatalf("write_log_entry failed: %s", text)
+	}
+	// Malformed names are rejected before reaching the client
+	for _, logName := range []string{"my log", "projects/my-project/logs/"} {
+		if text, isError := write(logName); !isError || !strings.HasPrefix(text, "log_name: ") {
+			t.Errorf("Expected an error for %q, got %s", logName, text)
+		}
+	}
+}
+
+func TestWriteLogEntriesParent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockLoggingClient(ctrl)
+	client.EXPECT().
+		WriteEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req logging.WriteEntriesRequest) error {
+			if req.LogName != "folders/123456789/logs/audit%2Fexport" {
+				return fmt.Errorf("unexpected log name %s", req.LogName)
+			}
+			return nil
+		})
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Logging: client})
+
+	write := func(arguments map[string]any) (string, bool) {
+		t.Helper()
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		arguments["entries"] = []any{map[string]any{"severity": "NOTICE", "message": "exported"}}
+		call(t, s, "tools/call", map[string]any{"name": "write_log_entries", "arguments": arguments}, &result)
+		return result.Content[0].Text, result.IsError
+	}
+
+	if text, isError := write(map[string]any{"log_name": "audit/export", "parent": "folders/123456789"}); isError {
+		t.Fatalf("write_log_entries failed: %s", text)
+	}
+	if text, isError := write(map[string]any{"log_name": "audit", "parent": "folder/123456789"}); !isError || !strings.Contains(text, "invalid parent") {
+		t.Errorf("Expected an error for an invalid parent, got %s", text)
+	}
+	if text, isError := write(map[string]any{"log_name": "projects/p/logs/audit", "parent": "folders/123456789"}); !isError || !strings.Contains(text, "must be a log ID") {
+		t.Errorf("Expected an error for a full log name with a parent, got %s", text)
+	}
+}
+
+func TestListTracesSortAndGroup(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	newTrace := func(id, name string, duration time.Duration) trace.Trace {
+		return trace.Trace{TraceID: id, Spans: []trace.Span{{SpanID: "1", Name: name, StartTime: start, EndTime: start.Add(duration)}}}
+	}
+	ctrl := gomock.NewController(t)
+	client := tracemocks.NewMockTraceClient(ctrl)
+	client.EXPECT().
+		ListTraces(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req trace.ListTracesRequest) (trace.ListTracesResponse, error) {
+			if req.View != trace.ViewRootSpan {
+				return trace.ListTracesResponse{}, fmt.Errorf("expected the root spans, got the view %q", req.View)
+			}
+			return trace.ListTracesResponse{Traces: []trace.Trace{
+				newTrace("a", "GET /orders", time.Second),
+				newTrace("b", "GET /users", 3*time.Second),
+				newTrace("c", "GET /orders", 2*time.Second),
+			}}, nil
+		}).
+		Times(2)
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Trace: client})
+	listTraces := func(args map[string]any) string {
+		t.Helper()
+		args["start_time"] = "2024-01-01T09:00:00Z"
+		args["end_time"] = "2024-01-01T11:00:00Z"
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{"name": "list_traces", "arguments": args}, &result)
+		if result.IsError || len(result.Content) != 1 {
+			t.Fatalf("list_traces failed: %+v", result.Content)
+		}
+		return result.Content[0].Text
+	}
+
+	var sorted struct {
+		Traces []trace.Trace `json:"traces"`
+	}
+	if err := json.Unmarshal([]byte(listTraces(map[string]any{"sort": "duration_desc", "top": 2})), &sorted); err != nil {
+		t.Fatal(err)
+	}
+	if len(sorted.Traces) != 2 || sorted.Traces[0].TraceID != "b" || sorted.Traces[1].TraceID != "c" {
+		t.Errorf("Expected the 2 slowest traces b and c, got %+v", sorted.Traces)
+	}
+
+	var grouped struct {
+		Groups []trace.TraceGroup `json:"groups"`
+	}
+	if err := json.Unmarshal([]byte(listTraces(map[string]any{"group_by": "root_span_name", "top": 1})), &grouped); err != nil {
+		t.Fatal(err)
+	}
+	if len(grouped.Groups) != 1 || grouped.Groups[0].RootSpanName != "GET /orders" || grouped.Groups[0].Count != 2 {
+		t.Errorf("Expected the group of 2 GET /orders traces, got %+v", grouped.Groups)
+	}
+}
+
+func TestPatchTracesChildOfExistingTrace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := tracemocks.NewMockTraceClient(ctrl)
+	client.EXPECT().
+		PatchTraces(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req trace.PatchTraceRequest) error {
+			// The client validates the patch before sending it
+			return req.Validate()
+		}).
+		Times(2)
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Trace: client})
+	patchTraces := func(args map[string]any) (string, bool) {
+		t.Helper()
+		args["trace_id"] = "1234567890abcdef1234567890abcdef"
+		args["spans"] = []map[string]any{{
+			"span_id":    "child1",
+			"name":       "query-db",
+			"start_time": "2024-01-01T12:00:01Z",
+			"end_time":   "2024-01-01T12:00:02Z",
+			// The parent was written by an earlier patch
+			"parent_id": "root1",
+		}}
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{"name": "patch_traces", "arguments": args}, &result)
+		if len(result.Content) != 1 {
+			t.Fatalf("Expected one content, got %+v", result.Content)
+		}
+		return result.Content[0].Text, result.IsError
+	}
+
+	if text, isError := patchTraces(map[string]any{}); !isError || !strings.Contains(text, `parent span \"root1\" is not in the patch`) {
+		t.Errorf("Expected the missing parent to be rejected by default, got %q", text)
+	}
+	if text, isError := patchTraces(map[string]any{"allow_missing_parents": true}); isError {
+		t.Errorf("Expected the child to be added to the existing trace, got %q", text)
+	}
+}
+
+func TestEnumArguments(t *testing.T) {
+	tests := []struct {
+		tool    string
+		args    map[string]any
+		wantErr string
+	}{
+		{
+			tool:    "write_log_entry",
+			args:    map[string]any{"log_name": "app", "severity": "FATAL", "message": "m"},
+			wantErr: `unsupported severity "FATAL": must be one of DEFAULT, DEBUG, INFO`,
+		},
+		{
+			tool:    "write_log_entries",
+			args:    map[string]any{"log_name": "app", "entries": []any{map[string]any{"severity": "FATAL", "message": "m"}}},
+			wantErr: `entries[0]: unsupported severity "FATAL"`,
+		},
+		{
+			tool:    "write_time_series_batch",
+			args:    map[string]any{"time_series": []any{map[string]any{"metric_type": "custom.googleapis.com/m", "resource_type": "global"}}},
+			wantErr: `time_series[0]: value is required, or distribution`,
+		},
+		{
+			tool:    "create_metric_descriptor",
+			args:    map[string]any{"type": "custom.googleapis.com/m", "metric_kind": "COUNTER", "value_type": "DOUBLE", "description": "d"},
+			wantErr: `metric_kind must be one of GAUGE, DELTA, CUMULATIVE, got "COUNTER"`,
+		},
+		{
+			tool:    "create_metric_descriptor",
+			args:    map[string]any{"type": "custom.googleapis.com/m", "metric_kind": "GAUGE", "value_type": "FLOAT", "description": "d"},
+			wantErr: `value_type must be one of BOOL, INT64, DOUBLE, STRING, DISTRIBUTION, got "FLOAT"`,
+		},
+		{
+			tool: "list_time_series",
+			args: map[string]any{
+				"filter": `metric.type="m"`, "start_time": "2026-01-01T00:00:00Z", "end_time": "2026-01-01T01:00:00Z",
+				"aggregation": map[string]any{"per_series_aligner": "ALIGN_AVG"},
+			},
+			wantErr: `unsupported per-series aligner "ALIGN_AVG": must be one of ALIGN_NONE, ALIGN_DELTA, ALIGN_RATE`,
+		},
+		{
+			tool: "list_time_series",
+			args: map[string]any{
+				"filter": `metric.type="m"`, "start_time": "2026-01-01T00:00:00Z", "end_time": "2026-01-01T01:00:00Z",
+				"aggregation": map[string]any{"per_series_aligner": "ALIGN_MEAN", "cross_series_reducer": "REDUCE_AVG"},
+			},
+			wantErr: `unsupported cross-series reducer "REDUCE_AVG": must be one of REDUCE_NONE, REDUCE_MEAN`,
+		},
+		{
+			tool:    "aggregate_profiles",
+			args:    map[string]any{"target": "checkout", "profile_type": "MEMORY"},
+			wantErr: `unsupported profile type "MEMORY": must be one of CPU, HEAP, THREADS, CONTENTION, WALL`,
+		},
+	}
+
+	// The arguments are rejected before any client is called
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{})
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			var result struct {
+				Content []mcp.TextContent `json:"content"`
+				IsError bool              `json:"isError"`
+			}
+			call(t, s, "tools/call", map[string]any{"name": tt.tool, "arguments": tt.args}, &result)
+			if !result.IsError || len(result.Content) != 1 || !strings.Contains(result.Content[0].Text, tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %+v", tt.wantErr, result)
+			}
+		})
+	}
+}
+
+func TestListProfilesFetchAll(t *testing.T) {
+	client := fake.NewProfilerClient("test-project")
+	for range 3 {
+		if _, err := client.CreateOfflineProfile(context.Background(), profiler.CreateOfflineProfileRequest{Profile: &profiler.Profile{ProfileType: profiler.ProfileTypeCPU}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Profiler: profiler.NewWithClient(client, "test-project")})
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{
+		"name":      "list_profiles",
+		"arguments": map[string]any{"page_size": 2, "fetch_all": true},
+	}, &result)
+	if result.IsError || len(result.Content) != 1 {
+		t.Fatalf("list_profiles failed: %+v", result.Content)
+	}
+	var response struct {
+		Profiles      []profiler.Profile `json:"profiles"`
+		Pages         int                `json:"pages"`
+		NextPageToken string             `json:"next_page_token"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Profiles) != 3 || response.Pages != 2 || response.NextPageToken != "" {
+		t.Errorf("Expected 3 profiles in 2 pages, got %d profiles in %d pages, next page token %q", len(response.Profiles), response.Pages, response.NextPageToken)
+	}
+}
+
+func TestFindUnusedMetricsDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := monitoringmocks.NewMockMonitoringClient(ctrl)
+	client.EXPECT().
+		FindUnusedMetrics(gomock.Any(), gomock.Any()).
+		Return(monitoring.FindUnusedMetricsResponse{
+			Checked: 3,
+			Unused:  []monitoring.UnusedMetric{{Type: "custom.googleapis.com/old"}, {Type: "custom.googleapis.com/older"}},
+		}, nil).
+		AnyTimes()
+	// Only the confirmed metric that is still unused is deleted
+	client.EXPECT().
+		DeleteMetricDescriptors(gomock.Any(), monitoring.DeleteMetricDescriptorsRequest{Types: []string{"custom.googleapis.com/old"}}).
+		Return(monitoring.DeleteMetricDescriptorsResponse{
+			Matched: []string{"custom.googleapis.com/old"},
+			Results: []monitoring.DeleteResult{{Type: "custom.googleapis.com/old", Deleted: true}},
+			Deleted: 1,
+		}, nil)
+
+	tests := []struct {
+		name      string
+		readOnly  bool
+		arguments map[string]any
+		wantError string
+		want      []string
+	}{
+		{
+			name:      "list",
+			arguments: map[string]any{},
+			want:      []string{`"custom.googleapis.com/older"`, `"note"`},
+		},
+		{
+			name:      "delete without confirm",
+			arguments: map[string]any{"delete": true},
+			wantError: "delete requires confirm",
+		},
+		{
+			name:      "delete in read-only mode",
+			readOnly:  true,
+			arguments: map[string]any{"delete": true, "confirm": []string{"custom.googleapis.com/old"}},
+			wantError: "not allowed in read-only mode",
+		},
+		{
+			name:      "delete confirmed",
+			arguments: map[string]any{"delete": true, "confirm": []string{"custom.googleapis.com/old", "custom.googleapis.com/used"}},
+			want:      []string{`"deleted": [` + "\n" + `    "custom.googleapis.com/old"`, `"skipped": [` + "\n" + `    "custom.googleapis.com/used"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+			handlers.RegisterTools(s, handlers.Deps{Monitoring: client, ReadOnly: tt.readOnly})
+
+			var result struct {
+				Content []mcp.TextContent `json:"content"`
+				IsError bool              `json:"isError"`
+			}
+			call(t, s, "tools/call", map[string]any{"name": "find_unused_metrics", "arguments": tt.arguments}, &result)
+			if len(result.Content) != 1 {
+				t.Fatalf("Expected one content, got %+v", result.Content)
+			}
+			text := result.Content[0].Text
+			if tt.wantError != "" {
+				if !result.IsError || !strings.Contains(text, tt.wantError) {
+					t.Errorf("Expected error %q, got %s", tt.wantError, text)
+				}
+				return
+			}
+			if result.IsError {
+				t.Fatalf("find_unused_metrics failed: %s", text)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("Expected %s in %s", want, text)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteMetricDescriptorsPreviewsByDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := monitoringmocks.NewMockMonitoringClient(ctrl)
+	client.EXPECT().
+		DeleteMetricDescriptors(gomock.Any(), monitoring.DeleteMetricDescriptorsRequest{Types: []string{"custom.googleapis.com/a"}, DryRun: true}).
+		Return(monitoring.DeleteMetricDescriptorsResponse{DryRun: true, Matched: []string{"custom.googleapis.com/a"}}, nil)
+	client.EXPECT().
+		DeleteMetricDescriptors(gomock.Any(), monitoring.DeleteMetricDescriptorsRequest{Types: []string{"custom.googleapis.com/a"}}).
+		Return(monitoring.DeleteMetricDescriptorsResponse{Matched: []string{"custom.googleapis.com/a"}, Deleted: 1}, nil)
+
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Monitoring: client})
+
+	for _, arguments := range []map[string]any{
+		{"types": []string{"custom.googleapis.com/a"}},
+		{"types": []string{"custom.googleapis.com/a"}, "dry_run": false},
+	} {
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{"name": "delete_metric_descriptors", "arguments": arguments}, &result)
+		if result.IsError {
+			t.Errorf("delete_metric_descriptors failed with %v: %+v", arguments, result.Content)
+		}
+	}
+}
+
+func TestWriteTimeSeriesDistribution(t *testing.T) {
+	client := fake.NewMonitoringClient("test-project")
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Monitoring: monitoring.NewWithClient(client, "test-project")})
+
+	write := func(arguments map[string]any) (string, bool) {
+		t.Helper()
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		arguments["metric_type"] = "custom.googleapis.com/latency"
+		arguments["resource_type"] = "global"
+		call(t, s, "tools/call", map[string]any{"name": "write_time_series", "arguments": arguments}, &result)
+		if len(result.Content) != 1 {
+			t.Fatalf("Expected one content, got %+v", result.Content)
+		}
+		return result.Content[0].Text, result.IsError
+	}
+
+	buckets := map[string]any{"exponential": map[string]any{"num_finite_buckets": 10, "growth_factor": 2, "scale": 1}}
+	if text, isError := write(map[string]any{"distribution": map[string]any{"bucket_options": buckets, "samples": []float64{3, 5, 40}}}); isError {
+		t.Fatalf("Writing samples failed: %s", text)
+	}
+	if text, isError := write(map[string]any{"distribution": map[string]any{"bucket_options": buckets, "bucket_counts": []int{0, 1, 2}, "mean": 2.5}}); isError {
+		t.Fatalf("Writing bucket counts failed: %s", text)
+	}
+
+	descriptors, err := client.ListMetricDescriptors(context.Background(), monitoring.ListMetricDescriptorsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(descriptors.Descriptors) != 1 || descriptors.Descriptors[0].ValueType != "DISTRIBUTION" {
+		t.Errorf("Expected a DISTRIBUTION descriptor to be created, got %+v", descriptors.Descriptors)
+	}
+
+	errorTests := []struct {
+		arguments map[string]any
+		want      string
+	}{
+		{map[string]any{}, "value is required, or distribution"},
+		{map[string]any{"value": 1, "distribution": map[string]any{"bucket_options": buckets, "samples": []float64{1}}}, "cannot be given together"},
+		{map[string]any{"distribution": map[string]any{"bucket_options": buckets, "bucket_counts": []int{1}}}, "needs the mean"},
+		{map[string]any{"distribution": map[string]any{"bucket_options": buckets, "samples": []float64{1}, "bucket_counts": []int{1}}}, "not both"},
+		{map[string]any{"distribution": map[string]any{"buckets": buckets}}, `unknown field "buckets"`},
+	}
+	for _, tt := range errorTests {
+		if text, isError := write(tt.arguments); !isError || !strings.Contains(text, tt.want) {
+			t.Errorf("write_time_series(%v) = %s, want error %q", tt.arguments, text, tt.want)
+		}
+	}
+}
+
+func TestWriteTimeSeriesCumulative(t *testing.T) {
+	client := fake.NewMonitoringClient("test-project")
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Monitoring: monitoring.NewWithClient(client, "test-project")})
+
+	write := func(arguments map[string]any) (string, bool) {
+		t.Helper()
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		arguments["metric_type"] = "custom.googleapis.com/requests"
+		arguments["resource_type"] = "global"
+		arguments["value"] = 42
+		call(t, s, "tools/call", map[string]any{"name": "write_time_series", "arguments": arguments}, &result)
+		if len(result.Content) != 1 {
+			t.Fatalf("Expected one content, got %+v", result.Content)
+		}
+		return result.Content[0].Text, result.IsError
+	}
+
+	if text, isError := write(map[string]any{"metric_kind": "CUMULATIVE"}); !isError || !strings.Contains(text, "need a start time") {
+		t.Errorf("Expected a CUMULATIVE point without start_time to be rejected, got %s", text)
+	}
+	if text, isError := write(map[string]any{"start_time": "2024-01-01T10:00:00Z", "timestamp": "2024-01-01T11:00:00Z"}); !isError || !strings.Contains(text, "GAUGE") {
+		t.Errorf("Expected a GAUGE point with start_time to be rejected, got %s", text)
+	}
+	if text, isError := write(map[string]any{"metric_kind": "CUMULATIVE", "start_time": "2024-01-01T10:00:00Z", "timestamp": "2024-01-01T11:00:00Z"}); isError {
+		t.Fatalf("Writing a CUMULATIVE point failed: %s", text)
+	}
+
+	descriptors, err := client.ListMetricDescriptors(context.Background(), monitoring.ListMetricDescriptorsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(descriptors.Descriptors) != 1 || descriptors.Descriptors[0].MetricKind != "CUMULATIVE" {
+		t.Errorf("Expected a CUMULATIVE descriptor to be created, got %+v", descriptors.Descriptors)
+	}
+}
+
+func TestGetSessionStats(t *testing.T) {
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{APIUsage: apiusage.NewTracker()})
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{"name": "get_session_stats", "arguments": map[string]any{}}, &result)
+	if !result.IsError || len(result.Content) != 1 || result.Content[0].Text != "No active session" {
+		t.Errorf("Expected an error outside of sessions, got %+v", result)
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	client := fake.NewMonitoringClient("test-project")
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Monitoring: monitoring.NewWithClient(client, "test-project")})
+
+	type stepResult struct {
+		Name   string          `json:"name"`
+		Status string          `json:"status"`
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	run := func(arguments map[string]any) (batch struct {
+		Succeeded int          `json:"succeeded"`
+		Failed    int          `json:"failed"`
+		Skipped   int          `json:"skipped"`
+		Steps     []stepResult `json:"steps"`
+	}, text string, isError bool) {
+		t.Helper()
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{"name": "run_batch", "arguments": arguments}, &result)
+		if len(result.Content) == 0 {
+			t.Fatal("Expected a content")
+		}
+		if !result.IsError {
+			if err := json.Unmarshal([]byte(result.Content[0].Text), &batch); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return batch, result.Content[0].Text, result.IsError
+	}
+
+	write := map[string]any{"tool": "write_time_series", "arguments": map[string]any{
+		"metric_type": "custom.googleapis.com/queue_depth", "resource_type": "global", "value": 3,
+	}}
+	list := map[string]any{"name": "descriptors", "tool": "list_metric_descriptors", "arguments": map[string]any{"filter": `metric.type = starts_with("custom.googleapis.com/")`}}
+	batch, text, isError := run(map[string]any{"steps": []any{
+		write,
+		list,
+		map[string]any{"tool": "no_such_tool"},
+		map[string]any{"name": "again", "tool": "list_metric_descriptors"},
+	}, "stop_on_error": true})
+	if isError {
+		t.Fatalf("run_batch failed: %s", text)
+	}
+	if batch.Succeeded != 2 || batch.Failed != 1 || batch.Skipped != 1 || len(batch.Steps) != 4 {
+		t.Fatalf("Unexpected batch result %s", text)
+	}
+	if batch.Steps[0].Name != "write_time_series" || batch.Steps[1].Name != "descriptors" || !strings.Contains(string(batch.Steps[1].Result), "custom.googleapis.com/queue_depth") {
+		t.Errorf("Expected the written metric to be listed by the next step, got %s", text)
+	}
+	if batch.Steps[2].Status != "error" || !strings.Contains(batch.Steps[2].Error, "not found") || batch.Steps[3].Status != "skipped" {
+		t.Errorf("Expected the unknown tool to fail and the last step to be skipped, got %s", text)
+	}
+
+	batch, text, isError = run(map[string]any{"steps": []any{list, map[string]any{"tool": "no_such_tool"}}, "concurrent": true})
+	if isError || batch.Succeeded != 1 || batch.Failed != 1 {
+		t.Errorf("Expected the concurrent steps to run independently, got %s", text)
+	}
+
+	errorTests := []struct {
+		steps []any
+		want  string
+	}{
+		{[]any{map[string]any{"tool": "run_batch"}}, "cannot call run_batch"},
+		{[]any{list, list}, `duplicate step name "descriptors"`},
+		{[]any{map[string]any{"name": "x"}}, "steps[0].tool is required"},
+	}
+	for _, tt := range errorTests {
+		if _, text, isError := run(map[string]any{"steps": tt.steps}); !isError || !strings.Contains(text, tt.want) {
+			t.Errorf("run_batch(%v) = %s, want error %q", tt.steps, text, tt.want)
+		}
+	}
+}
+
+func TestRunBatchTemplates(t *testing.T) {
+	client := fake.NewMonitoringClient("test-project")
+	for _, metricType := range []string{"custom.googleapis.com/queue_depth", "custom.googleapis.com/queue_age"} {
+		err := client.WriteTimeSeries(context.Background(), monitoring.WriteTimeSeriesRequest{TimeSeries: []monitoring.TimeSeriesData{{
+			MetricType:   metricType,
+			ResourceType: "global",
+			Values:       []monitoring.MetricValue{{Value: 1, Timestamp: time.Now().Add(-time.Minute)}},
+		}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{Monitoring: monitoring.NewWithClient(client, "test-project")})
+
+	run := func(arguments map[string]any) (string, bool) {
+		t.Helper()
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{"name": "run_batch", "arguments": arguments}, &result)
+		return result.Content[0].Text, result.IsError
+	}
+
+	for _, concurrent := range []bool{false, true} {
+		text, isError := run(map[string]any{"concurrent": concurrent, "steps": []any{
+			map[string]any{"name": "descriptors", "tool": "list_metric_descriptors", "arguments": map[string]any{"filter": `metric.type = starts_with("custom.googleapis.com/queue_")`}},
+			map[string]any{"name": "series", "tool": "list_time_series", "for_each": "{{descriptors.descriptors[*].type}}", "arguments": map[string]any{
+				"filter":     `metric.type="{{item}}"`,
+				"start_time": time.Now().Add(-time.Hour).Format(time.RFC3339),
+				"end_time":   time.Now().Format(time.RFC3339),
+			}},
+		}})
+		if isError {
+			t.Fatalf("run_batch failed: %s", text)
+		}
+		var batch struct {
+			Steps []struct {
+				Status string `json:"status"`
+				Items  []struct {
+					Item   string          `json:"item"`
+					Status string          `json:"status"`
+					Result json.RawMessage `json:"result"`
+				} `json:"items"`
+			} `json:"steps"`
+		}
+		if err := json.Unmarshal([]byte(text), &batch); err != nil {
+			t.Fatal(err)
+		}
+		items := batch.Steps[1].Items
+		if batch.Steps[1].Status != "ok" || len(items) != 2 {
+			t.Fatalf("Expected list_time_series to be called for each descriptor, got %s", text)
+		}
+		for _, item := range items {
+			if !strings.HasPrefix(item.Item, "custom.googleapis.com/queue_") || !strings.Contains(string(item.Result), item.Item) {
+				t.Errorf("Expected the time series of %s, got %s", item.Item, item.Result)
+			}
+		}
+	}
+
+	text, isError := run(map[string]any{"steps": []any{
+		map[string]any{"name": "descriptors", "tool": "list_metric_descriptors", "arguments": map[string]any{"filter": `metric.type = starts_with("custom.googleapis.com/queue_")`}},
+		map[string]any{"name": "series", "tool": "list_time_series", "arguments": map[string]any{"filter": `metric.type="{{descriptors.descriptors[5].type}}"`}},
+		map[string]any{"name": "after", "tool": "list_time_series", "arguments": map[string]any{"filter": "{{series}}"}},
+	}})
+	if isError || !strings.Contains(text, "index 5 out of range of 2 items") || !strings.Contains(text, `step \"series\", which this step refers to, did not succeed`) {
+		t.Errorf("Expected the reference out of range to fail the step and skip the next, got %s", text)
+	}
+
+	errorTests := []struct {
+		steps []any
+		want  string
+	}{
+		{[]any{map[string]any{"tool": "list_metric_descriptors", "arguments": map[string]any{"filter": "{{later.type}}"}}, map[string]any{"name": "later", "tool": "list_metric_descriptors"}}, `refers to "later", which is not the name of an earlier step`},
+		{[]any{map[string]any{"name": "a.b", "tool": "list_metric_descriptors"}}, `invalid step name "a.b"`},
+	}
+	for _, tt := range errorTests {
+		if text, isError := run(map[string]any{"steps": tt.steps}); !isError || !strings.Contains(text, tt.want) {
+			t.Errorf("run_batch(%v) = %s, want error %q", tt.steps, text, tt.want)
+		}
+	}
+}
+
+func TestRunBatchLargeResults(t *testing.T) {
+	client := fake.NewMonitoringClient("test-project")
+	err := client.WriteTimeSeries(context.Background(), monitoring.WriteTimeSeriesRequest{TimeSeries: []monitoring.TimeSeriesData{{
+		MetricType:   "custom.googleapis.com/queue_depth",
+		ResourceType: "global",
+		Values:       []monitoring.MetricValue{{Value: 1, Timestamp: time.Now().Add(-time.Minute)}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Results over 100 bytes are moved to embedded resources
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true), server.WithToolHandlerMiddleware(middleware.LargeResults(100, middleware.LargeResultResource)))
+	handlers.RegisterTools(s, handlers.Deps{Monitoring: monitoring.NewWithClient(client, "test-project")})
+
+	var result struct {
+		Content []struct {
+			Text     string `json:"text"`
+			Resource struct {
+				Text string `json:"text"`
+			} `json:"resource"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	call(t, s, "tools/call", map[string]any{"name": "run_batch", "arguments": map[string]any{"steps": []any{
+		map[string]any{"name": "descriptors", "tool": "list_metric_descriptors", "arguments": map[string]any{"filter": `metric.type = starts_with("custom.googleapis.com/queue_")`}},
+		map[string]any{"name": "series", "tool": "list_time_series", "arguments": map[string]any{
+			"filter":     `metric.type="{{descriptors.descriptors[0].type}}"`,
+			"start_time": time.Now().Add(-time.Hour).Format(time.RFC3339),
+			"end_time":   time.Now().Format(time.RFC3339),
+		}},
+	}}}, &result)
+	if result.IsError || len(result.Content) < 2 {
+		t.Fatalf("run_batch failed: %+v", result)
+	}
+
+	// The batch result is itself large, so it is the first resource
+	var batch struct {
+		Succeeded int `json:"succeeded"`
+		Steps     []struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		} `json:"steps"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[1].Resource.Text), &batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch.Succeeded != 2 {
+		t.Errorf("Expected the template to refer to the JSON of the moved result, got %+v", batch)
+	}
+}
+
+func TestScheduleJob(t *testing.T) {
+	client := fake.NewMonitoringClient("test-project")
+	err := client.WriteTimeSeries(context.Background(), monitoring.WriteTimeSeriesRequest{TimeSeries: []monitoring.TimeSeriesData{{
+		MetricType:   "custom.googleapis.com/checkout/latency",
+		ResourceType: "global",
+		Values:       []monitoring.MetricValue{{Value: 0.25, Timestamp: time.Now().Add(-time.Minute)}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler := schedule.NewScheduler()
+	defer scheduler.Stop()
+	s := server.NewMCPServer("test", "dev", server.WithToolCapabilities(true))
+	handlers.RegisterTools(s, handlers.Deps{
+		Monitoring: monitoring.NewWithClient(client, "test-project"),
+		Sessions:   session.NewStore(),
+		Scheduler:  scheduler,
+	})
+
+	run := func(name string, arguments map[string]any) (string, bool) {
+		t.Helper()
+		var result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		}
+		call(t, s, "tools/call", map[string]any{"name": name, "arguments": arguments}, &result)
+		return result.Content[0].Text, result.IsError
+	}
+
+	text, isError := run("schedule_job", map[string]any{
+		"tool": "list_time_series",
+		"arguments": map[string]any{
+			"filter":     `metric.type="custom.googleapis.com/checkout/latency"`,
+			"start_time": "{{now-1h}}",
+			"end_time":   "{{now}}",
+		},
+		"metric": map[string]any{
+			"metric_type": "custom.googleapis.com/checkout/latency_snapshot",
+			"value_path":  "time_series[0].values[0].value",
+		},
+	})
+	if isError {
+		t.Fatalf("schedule_job failed: %s", text)
+	}
+	var job struct {
+		ID         string `json:"id"`
+		Interval   string `json:"interval"`
+		Runs       int    `json:"runs"`
+		LastResult string `json:"last_result"`
+	}
+	if err := json.Unmarshal([]byte(text), &job); err != nil {
+		t.Fatal(err)
+	}
+	if job.Interval != "1h0m0s" || job.Runs != 1 || job.LastResult != "wrote 0.25 to custom.googleapis.com/checkout/latency_snapshot" {
+		t.Errorf("Unexpected job %s", text)
+	}
+
+	// The first run wrote the snapshot
+	req := monitoring.ListTimeSeriesRequest{Filter: `metric.type="custom.googleapis.com/checkout/latency_snapshot"`}
+	req.Interval.StartTime = time.Now().Add(-time.Hour)
+	req.Interval.EndTime = time.Now().Add(time.Hour)
+	series, err := client.ListTimeSeries(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series.TimeSeries) != 1 || series.TimeSeries[0].Values[0].Value != 0.25 {
+		t.Errorf("Expected the snapshot to be written, got %+v", series.TimeSeries)
+	}
+
+	if text, _ := run("list_jobs", nil); !strings.Contains(text, job.ID) {
+		t.Errorf("Expected the job to be listed, got %s", text)
+	}
+	if text, isError := run("cancel_job", map[string]any{"id": job.ID}); isError {
+		t.Errorf("cancel_job failed: %s", text)
+	}
+	if text, _ := run("list_jobs", nil); text != "[]" {
+		t.Errorf("Expected no jobs after canceling, got %s", text)
+	}
+
+	errorTests := []struct {
+		arguments map[string]any
+		want      string
+	}{
+		{map[string]any{"tool": "watch_metric"}, "jobs cannot call watch_metric"},
+		{map[string]any{"tool": "list_time_series", "arguments": map[string]any{"end_time": "{{yesterday}}"}}, "invalid template {{yesterday}}"},
+		{map[string]any{"tool": "list_time_series", "interval": "10s"}, "interval must be at least 1m0s"},
+		{map[string]any{"tool": "no_such_tool"}, "the first run failed"},
+		{map[string]any{"tool": "list_time_series", "arguments": map[string]any{
+			"filter": `metric.type="custom.googleapis.com/checkout/latency"`, "start_time": "{{now-1h}}", "end_time": "{{now}}",
+		}, "metric": map[string]any{"metric_type": "custom.googleapis.com/x", "value_path": "time_series"}}, "must select a number, got an array"},
+	}
+	for _, tt := range errorTests {
+		text, isError := run("schedule_job", tt.arguments)
+		if !isError || !strings.Contains(text, tt.want) {
+			t.Errorf("schedule_job(%v) = %s, want an error containing %q", tt.arguments, text, tt.want)
+		}
+	}
+	if jobs := scheduler.List(); len(jobs) != 0 {
+		t.Errorf("Expected the failed jobs not to be scheduled, got %+v", jobs)
+	}
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// listTracesArgs are the arguments of list_traces
+type listTracesArgs struct {
+	StartTime time.Time `json:"start_time" validate:"required"`
+	EndTime   time.Time `json:"end_time" validate:"required"`
+	Filter    string    `json:"filter"`
+	OrderBy   string    `json:"order_by"`
+	View      string    `json:"view" validate:"oneof=MINIMAL ROOTSPAN COMPLETE"`
+	PageSize  int       `json:"page_size" validate:"min=1"`
+	PageToken string    `json:"page_token"`
+	FetchAll  bool      `json:"fetch_all"`
+	CountOnly bool      `json:"count_only"`
+	Sort      string    `json:"sort" validate:"oneof=duration_desc duration_asc start_time_desc start_time_asc"`
+	GroupBy   string    `json:"group_by" validate:"oneof=root_span_name"`
+	Top       int       `json:"top" validate:"min=1"`
+}
+
+// createListTracesHandler creates a handler for listing traces
+func createListTracesHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[listTracesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := trace.ListTracesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			StartTime: args.StartTime,
+			EndTime:   args.EndTime,
+			Filter:    args.Filter,
+			OrderBy:   args.OrderBy,
+			View:      args.View,
+			PageSize:  args.PageSize,
+			PageToken: args.PageToken,
+		}
+		if req.PageSize == 0 {
+			req.PageSize = 100 // default
+		}
+		organize := args.Sort != "" || args.GroupBy != ""
+		if organize && req.View == "" {
+			// Durations and names come from the root spans
+			req.View = trace.ViewRootSpan
+		}
+		if organize && req.View == trace.ViewMinimal {
+			return mcp.NewToolResultError("sort and group_by need the root spans: use the view ROOTSPAN or COMPLETE"), nil
+		}
+
+		if args.CountOnly {
+			count, truncated, err := countAll(func(pageSize int, pageToken string) (int, string, error) {
+				countReq := req
+				countReq.View = trace.ViewMinimal // IDs only
+				countReq.PageSize = pageSize
+				countReq.PageToken = pageToken
+				resp, err := client.ListTraces(ctx, countReq)
+				return len(resp.Traces), resp.NextPageToken, err
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to count traces: %v", err)), nil
+			}
+			return marshalTracesResponse(countResponse(count, truncated))
+		}
+
+		var traces []trace.Trace
+		var pages int
+		var next string
+		if args.FetchAll {
+			traces, pages, next, err = fetchAll(req.PageToken, func(pageToken string) ([]trace.Trace, string, error) {
+				req.PageToken = pageToken
+				resp, err := client.ListTraces(ctx, req)
+				return resp.Traces, resp.NextPageToken, err
+			})
+		} else {
+			var resp trace.ListTracesResponse
+			resp, err = client.ListTraces(ctx, req)
+			traces, next = resp.Traces, resp.NextPageToken
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list traces: %v", err)), nil
+		}
+
+		// Organize the listed traces for analysis
+		if args.Sort != "" {
+			if err := trace.SortTraces(traces, args.Sort); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		var response map[string]any
+		if args.GroupBy != "" {
+			groups := trace.GroupTracesByRootSpan(traces)
+			if args.Top > 0 {
+				groups = groups[:min(len(groups), args.Top)]
+			}
+			response = map[string]any{"groups": groups}
+		} else {
+			if args.Top > 0 {
+				traces = traces[:min(len(traces), args.Top)]
+			}
+			response = map[string]any{"traces": traces}
+		}
+		addPageInfo(response, args.FetchAll, pages, next)
+		return marshalTracesResponse(response)
+	}
+}
+
+// marshalTracesResponse returns the result of list_traces holding response
+func marshalTracesResponse(response any) (*mcp.CallToolResult, error) {
+	// Convert traces to JSON for response
+	tracesJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal traces: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(tracesJSON)), nil
+}
+
+// traceIDArgs are the arguments of get_trace
+type traceIDArgs struct {
+	TraceID string `json:"trace_id" validate:"required"`
+}
+
+// createGetTraceHandler creates a handler for getting a specific trace
+func createGetTraceHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[traceIDArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		req := trace.GetTraceRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			TraceID:   args.TraceID,
+		}
+
+		traceResult, err := client.GetTrace(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get trace: %v", err)), nil
+		}
+
+		// Convert trace to JSON for response
+		traceJSON, err := json.MarshalIndent(traceResult, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal trace: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(traceJSON)), nil
+	}
+}
+
+// getTracesArgs are the arguments of get_traces
+type getTracesArgs struct {
+	TraceIDs []string `json:"trace_ids" validate:"required,max=100"`
+}
+
+// createGetTracesHandler creates a handler for retrieving several traces at once
+func createGetTracesHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[getTracesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if i := slices.Index(args.TraceIDs, ""); i >= 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("trace_ids[%d] must be a non-empty string", i)), nil
+		}
+
+		resp := client.GetTraces(ctx, trace.GetTracesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			TraceIDs:  args.TraceIDs,
+		})
+
+		// Convert traces to JSON for response
+		tracesJSON, err := json.MarshalIndent(resp, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal traces: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(tracesJSON)), nil
+	}
+}
+
+// parseTraceContextArgs are the arguments of parse_trace_context
+type parseTraceContextArgs struct {
+	Header string `json:"header" validate:"required"`
+}
+
+// createParseTraceContextHandler creates a handler for parsing trace context headers
+func createParseTraceContextHandler(projectID string) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[parseTraceContextArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		traceContext, err := trace.ParseTraceContext(args.Header)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		result := struct {
+			trace.TraceContext
+			ConsoleURL string `json:"console_url"`
+		}{
+			TraceContext: traceContext,
+			ConsoleURL:   trace.ConsoleURL(sessionProjectID(ctx, projectID), traceContext.TraceID),
+		}
+
+		// Convert result to JSON for response
+		resultJSON, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal trace context: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(resultJSON)), nil
+	}
+}
+
+// analyzeTraceGapsArgs are the arguments of analyze_trace_gaps
+type analyzeTraceGapsArgs struct {
+	TraceID string   `json:"trace_id" validate:"required"`
+	MinGap  duration `json:"min_gap"`
+	Limit   int      `json:"limit" validate:"min=1"`
+}
+
+// createAnalyzeTraceGapsHandler creates a handler for reporting untraced time in a trace
+func createAnalyzeTraceGapsHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[analyzeTraceGapsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		minGap := time.Duration(args.MinGap)
+		if minGap == 0 {
+			minGap = time.Millisecond
+		}
+		limit := args.Limit
+		if limit == 0 {
+			limit = 20
+		}
+
+		traceResult, err := client.GetTrace(ctx, trace.GetTraceRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			TraceID:   args.TraceID,
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to get trace: %v", err)), nil
+		}
+
+		analysis := trace.AnalyzeGaps(*traceResult, minGap)
+		analysis.Gaps = analysis.Gaps[:min(len(analysis.Gaps), limit)]
+
+		// Convert analysis to JSON for response
+		analysisJSON, err := json.MarshalIndent(analysis, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal gap analysis: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(analysisJSON)), nil
+	}
+}
+
+// findErrorSpansArgs are the arguments of find_error_spans
+type findErrorSpansArgs struct {
+	StartTime   time.Time `json:"start_time" validate:"required"`
+	EndTime     time.Time `json:"end_time" validate:"required"`
+	Filter      string    `json:"filter"`
+	MaxExamples int       `json:"max_examples" validate:"min=1,max=20"`
+}
+
+// createFindErrorSpansHandler creates a handler for finding the failed spans of the traces in a window
+func createFindErrorSpansHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[findErrorSpansArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		maxExamples := args.MaxExamples
+		if maxExamples == 0 {
+			maxExamples = 3
+		}
+
+		req := trace.ListTracesRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			StartTime: args.StartTime,
+			EndTime:   args.EndTime,
+			Filter:    args.Filter,
+			View:      trace.ViewComplete,
+			PageSize:  100,
+		}
+		traces, _, next, err := fetchAll("", func(pageToken string) ([]trace.Trace, string, error) {
+			req.PageToken = pageToken
+			resp, err := client.ListTraces(ctx, req)
+			return resp.Traces, resp.NextPageToken, err
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to list traces: %v", err)), nil
+		}
+
+		response := struct {
+			trace.ErrorSpanReport
+			// Truncated is set when traces were left unscanned past the cap
+			Truncated bool `json:"truncated,omitempty"`
+		}{
+			ErrorSpanReport: trace.FindErrorSpans(traces, maxExamples),
+			Truncated:       next != "",
+		}
+
+		// Convert report to JSON for response
+		responseJSON, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal error spans: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// patchTracesArgs are the arguments of patch_traces
+type patchTracesArgs struct {
+	TraceID             string       `json:"trace_id" validate:"required"`
+	Spans               []trace.Span `json:"spans" validate:"required"`
+	AllowMissingParents bool         `json:"allow_missing_parents"`
+}
+
+// createPatchTracesHandler creates a handler for updating trace spans
+func createPatchTracesHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, err := DecodeArgs[patchTracesArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		spans := args.Spans
+
+		defaults := session.FromContext(ctx)
+		for i := range spans {
+			spans[i].Labels = defaults.MergeWriteLabels(spans[i].Labels)
+		}
+
+		req := trace.PatchTraceRequest{
+			ProjectID: defaults.ProjectID,
+			TraceID:   args.TraceID,
+			Spans:     spans,
+			// Spans added to an existing trace have their parents in it
+			AllowMissingParents: args.AllowMissingParents,
+		}
+
+		err = client.PatchTraces(ctx, req)
+		var validationErr *trace.ValidationError
+		if errors.As(err, &validationErr) {
+			// Report every problem so that all spans can be fixed at once
+			problemsJSON, err := json.MarshalIndent(validationErr, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal validation problems: %v", err)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("Spans were not sent because the patch is invalid:\n%s", problemsJSON)), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to patch traces: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText("Trace spans updated successfully"), nil
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/kitagry/gcp-telemetry-mcp/watch"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// watchMetricArgs are the arguments of watch_metric
+type watchMetricArgs struct {
+	Filter      string         `json:"filter" validate:"required"`
+	Threshold   *float64       `json:"threshold" validate:"required"`
+	Comparison  string         `json:"comparison" validate:"oneof=above below"`
+	Interval    duration       `json:"interval"`
+	Aggregation map[string]any `json:"aggregation"`
+	WebhookURL  string         `json:"webhook_url"`
+}
+
+// createWatchMetricHandler creates a handler for watching time series for threshold breaches
+func createWatchMetricHandler(watches *watch.Manager, client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		clientSession := server.ClientSessionFromContext(ctx)
+		if clientSession == nil {
+			return mcp.NewToolResultError("No active session"), nil
+		}
+
+		args, err := DecodeArgs[watchMetricArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		req := watch.MetricWatchRequest{
+			ProjectID:  session.FromContext(ctx).ProjectID,
+			Filter:     args.Filter,
+			Threshold:  *args.Threshold,
+			Comparison: args.Comparison,
+			Interval:   time.Duration(args.Interval),
+			Webhook:    args.WebhookURL,
+		}
+		if args.Aggregation != nil {
+			req.Aggregation, err = parseAggregation(args.Aggregation)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		info, err := watches.WatchMetric(clientSession.SessionID(), client, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to watch metric: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal watch: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// watchLogsArgs are the arguments of watch_logs
+type watchLogsArgs struct {
+	Filter     string   `json:"filter" validate:"required"`
+	Pattern    string   `json:"pattern"`
+	Interval   duration `json:"interval"`
+	Samples    int      `json:"samples" validate:"min=1"`
+	WebhookURL string   `json:"webhook_url"`
+}
+
+// createWatchLogsHandler creates a handler for watching for new log entries
+func createWatchLogsHandler(watches *watch.Manager, client logging.LoggingClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		clientSession := server.ClientSessionFromContext(ctx)
+		if clientSession == nil {
+			return mcp.NewToolResultError("No active session"), nil
+		}
+
+		args, err := DecodeArgs[watchLogsArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		req := watch.LogWatchRequest{
+			ProjectID: session.FromContext(ctx).ProjectID,
+			Filter:    args.Filter,
+			Pattern:   args.Pattern,
+			Interval:  time.Duration(args.Interval),
+			Samples:   args.Samples,
+			Webhook:   args.WebhookURL,
+		}
+
+		info, err := watches.WatchLogs(clientSession.SessionID(), client, req)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to watch logs: %v", err)), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal watch: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// createListWatchesHandler creates a handler for listing the watches of the session
+func createListWatchesHandler(watches *watch.Manager) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		clientSession := server.ClientSessionFromContext(ctx)
+		if clientSession == nil {
+			return mcp.NewToolResultError("No active session"), nil
+		}
+
+		// Convert response to JSON
+		responseJSON, err := json.MarshalIndent(watches.List(clientSession.SessionID()), "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal watches: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(string(responseJSON)), nil
+	}
+}
+
+// stopWatchArgs are the arguments of stop_watch
+type stopWatchArgs struct {
+	ID string `json:"id" validate:"required"`
+}
+
+// createStopWatchHandler creates a handler for stopping a watch of the session
+func createStopWatchHandler(watches *watch.Manager) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		clientSession := server.ClientSessionFromContext(ctx)
+		if clientSession == nil {
+			return mcp.NewToolResultError("No active session"), nil
+		}
+
+		args, err := DecodeArgs[stopWatchArgs](request)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		if err := watches.Stop(clientSession.SessionID(), args.ID); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to stop watch: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Watch %s stopped successfully", args.ID)), nil
+	}
+}
@@ -0,0 +1,253 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+// Change categories
+const (
+	ChangeCategoryDeployment = "deployment"
+	ChangeCategoryIAM        = "iam"
+	ChangeCategoryConfig     = "config"
+)
+
+const (
+	defaultChangesBefore  = 2 * time.Hour
+	defaultChangesAfter   = 15 * time.Minute
+	defaultMaxChanges     = 20
+	maxChangeLogEntries   = 500
+	changeProximityWindow = 30 * time.Minute // changes this long before the anchor score about a third of an immediate one
+)
+
+// Category weights used to rank changes
+var changeCategoryWeights = map[string]float64{
+	ChangeCategoryDeployment: 1.0,
+	ChangeCategoryIAM:        0.8,
+	ChangeCategoryConfig:     0.6,
+}
+
+// deploymentMethods are substrings of audit log method names that roll out new code
+var deploymentMethods = []string{
+	// Cloud Deploy
+	"CreateRollout", "CreateRelease",
+	// Cloud Run
+	"Services.CreateService", "Services.ReplaceService", "Services.UpdateService", "Jobs.ReplaceJob", "Jobs.UpdateJob",
+	// Cloud Functions
+	"CloudFunctionsService.CreateFunction", "CloudFunctionsService.UpdateFunction", "FunctionService.UpdateFunction",
+	// App Engine
+	"Versions.CreateVersion",
+	// Kubernetes
+	"deployments.create", "deployments.update", "deployments.patch",
+	"statefulsets.update", "statefulsets.patch", "daemonsets.update", "daemonsets.patch",
+	// Managed instance groups
+	"instanceGroupManagers.setInstanceTemplate", "instanceGroupManagers.patch",
+}
+
+// ChangesRequest represents a request to find changes near a regression
+type ChangesRequest struct {
+	ProjectID    string        `json:"project_id,omitempty"` // defaults to the clients' project
+	Time         time.Time     `json:"time"`                 // when the regression was noticed
+	Before       time.Duration `json:"before,omitempty"`     // how far before Time to look for changes
+	After        time.Duration `json:"after,omitempty"`      // how far after Time to look for changes
+	Service      string        `json:"service,omitempty"`    // changes mentioning the service rank higher
+	MetricFilter string        `json:"metric_filter,omitempty"`
+	Limit        int           `json:"limit,omitempty"`
+}
+
+// ChangesReport represents the ranked changes near a regression
+type ChangesReport struct {
+	Time        time.Time `json:"time"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Spike       *Spike    `json:"spike,omitempty"`
+	AnchorTime  time.Time `json:"anchor_time"` // spike time when a spike was found, otherwise Time
+	Changes     []Change  `json:"changes"`
+	Truncated   bool      `json:"truncated,omitempty"` // more audit log entries exist than were examined
+	MetricError string    `json:"metric_error,omitempty"`
+}
+
+// Spike represents the largest increase between consecutive metric points
+type Spike struct {
+	Time     time.Time `json:"time"`
+	Value    float64   `json:"value"`
+	Previous float64   `json:"previous"`
+}
+
+// Change represents a change recorded in the Admin Activity audit log
+type Change struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Category       string    `json:"category"`
+	ServiceName    string    `json:"service_name"`
+	MethodName     string    `json:"method_name"`
+	ResourceName   string    `json:"resource_name,omitempty"`
+	PrincipalEmail string    `json:"principal_email,omitempty"`
+	Failed         bool      `json:"failed,omitempty"`
+	Score          float64   `json:"score"`
+	Reasons        []string  `json:"reasons"`
+}
+
+// FindRecentChanges ranks changes from the Admin Activity audit logs by how
+// likely they caused a regression at req.Time. When a metric filter is given,
+// changes are ranked by proximity to the metric's largest spike instead.
+func (g *Generator) FindRecentChanges(ctx context.Context, req ChangesRequest) (ChangesReport, error) {
+	if req.Time.IsZero() {
+		return ChangesReport{}, fmt.Errorf("time is required")
+	}
+	if req.Before <= 0 {
+		req.Before = defaultChangesBefore
+	}
+	if req.After <= 0 {
+		req.After = defaultChangesAfter
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultMaxChanges
+	}
+
+	report := ChangesReport{
+		Time:       req.Time,
+		StartTime:  req.Time.Add(-req.Before),
+		EndTime:    req.Time.Add(req.After),
+		AnchorTime: req.Time,
+		Changes:    []Change{},
+	}
+
+	if req.MetricFilter != "" {
+		spike, err := g.findSpike(ctx, req.ProjectID, req.MetricFilter, report.StartTime, report.EndTime)
+		if err != nil {
+			// The changes are still useful without the spike
+			report.MetricError = err.Error()
+		} else if spike != nil {
+			report.Spike = spike
+			report.AnchorTime = spike.Time
+		}
+	}
+
+	filter, err := logging.AuditLogFilter{
+		LogType:   logging.AuditLogTypeActivity,
+		StartTime: report.StartTime,
+		EndTime:   report.EndTime,
+	}.Build()
+	if err != nil {
+		return ChangesReport{}, err
+	}
+
+	resp, err := g.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		Limit:     maxChangeLogEntries,
+	})
+	if err != nil {
+		return ChangesReport{}, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	report.Truncated = resp.NextPageToken != ""
+
+	for _, entry := range resp.Entries {
+		if entry.AuditLog == nil {
+			continue
+		}
+		report.Changes = append(report.Changes, scoreChange(entry, report.AnchorTime, req.Service))
+	}
+
+	sort.SliceStable(report.Changes, func(i, j int) bool {
+		return report.Changes[i].Score > report.Changes[j].Score
+	})
+	report.Changes = report.Changes[:min(len(report.Changes), req.Limit)]
+	return report, nil
+}
+
+// findSpike returns the largest increase between consecutive points of the metric, or nil if it never increases
+func (g *Generator) findSpike(ctx context.Context, projectID, filter string, start, end time.Time) (*Spike, error) {
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID: projectID,
+		Filter:    filter,
+		Aggregation: &monitoring.AggregationConfig{
+			AlignmentPeriod:    "60s",
+			PerSeriesAligner:   "ALIGN_MEAN",
+			CrossSeriesReducer: "REDUCE_SUM",
+		},
+	}
+	listReq.Interval.StartTime = start
+	listReq.Interval.EndTime = end
+
+	resp, err := g.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.TimeSeries) == 0 {
+		return nil, nil
+	}
+
+	points := sortedPoints(resp.TimeSeries[0].Values)
+	var spike *Spike
+	for i := 1; i < len(points); i++ {
+		increase := points[i].Value - points[i-1].Value
+		if increase > 0 && (spike == nil || increase > spike.Value-spike.Previous) {
+			spike = &Spike{Time: points[i].Timestamp, Value: points[i].Value, Previous: points[i-1].Value}
+		}
+	}
+	return spike, nil
+}
+
+// scoreChange converts an audit log entry to a Change scored against the anchor time
+func scoreChange(entry logging.LogEntry, anchor time.Time, service string) Change {
+	auditLog := entry.AuditLog
+	change := Change{
+		Timestamp:      entry.Timestamp,
+		Category:       changeCategory(auditLog),
+		ServiceName:    auditLog.ServiceName,
+		MethodName:     auditLog.MethodName,
+		ResourceName:   auditLog.ResourceName,
+		PrincipalEmail: auditLog.PrincipalEmail,
+		Failed:         auditLog.Status != nil && auditLog.Status.Code != 0,
+		Reasons:        []string{},
+	}
+
+	change.Score = changeCategoryWeights[change.Category]
+	change.Reasons = append(change.Reasons, fmt.Sprintf("%s change", change.Category))
+
+	// Changes shortly before the anchor are the most likely culprits
+	delta := anchor.Sub(entry.Timestamp)
+	if delta >= 0 {
+		change.Score *= math.Exp(-float64(delta) / float64(changeProximityWindow))
+		change.Reasons = append(change.Reasons, fmt.Sprintf("%s before the regression", delta.Round(time.Second)))
+	} else {
+		change.Score *= 0.1
+		change.Reasons = append(change.Reasons, fmt.Sprintf("%s after the regression", (-delta).Round(time.Second)))
+	}
+
+	if service != "" && strings.Contains(strings.ToLower(auditLog.ResourceName), strings.ToLower(service)) {
+		change.Score *= 2
+		change.Reasons = append(change.Reasons, fmt.Sprintf("affects %s", service))
+	}
+
+	if change.Failed {
+		change.Score *= 0.3
+		change.Reasons = append(change.Reasons, "operation failed")
+	}
+
+	return change
+}
+
+// changeCategory classifies an audit logged operation
+func changeCategory(auditLog *logging.AuditLog) string {
+	if strings.Contains(auditLog.MethodName, "SetIamPolicy") || auditLog.ServiceName == "iam.googleapis.com" {
+		return ChangeCategoryIAM
+	}
+	if auditLog.ServiceName == "clouddeploy.googleapis.com" {
+		return ChangeCategoryDeployment
+	}
+	for _, method := range deploymentMethods {
+		if strings.Contains(auditLog.MethodName, method) {
+			return ChangeCategoryDeployment
+		}
+	}
+	return ChangeCategoryConfig
+}
@@ -0,0 +1,190 @@
+package incident_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/incident"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	loggingmocks "github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	monitoringmocks "github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func auditEntry(timestamp time.Time, auditLog logging.AuditLog) logging.LogEntry {
+	return logging.LogEntry{Timestamp: timestamp, AuditLog: &auditLog}
+}
+
+func TestGenerator_FindRecentChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	regression := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	loggingClient := loggingmocks.NewMockLoggingClient(ctrl)
+	loggingClient.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+			if !strings.Contains(req.Filter, `log_id("cloudaudit.googleapis.com/activity")`) {
+				t.Errorf("Expected activity audit log filter, got %s", req.Filter)
+			}
+			return logging.ListEntriesResponse{
+				Entries: []logging.LogEntry{
+					auditEntry(regression.Add(-90*time.Minute), logging.AuditLog{
+						ServiceName:  "run.googleapis.com",
+						MethodName:   "google.cloud.run.v1.Services.ReplaceService",
+						ResourceName: "namespaces/my-project/services/billing",
+					}),
+					auditEntry(regression.Add(-5*time.Minute), logging.AuditLog{
+						ServiceName:  "run.googleapis.com",
+						MethodName:   "google.cloud.run.v1.Services.ReplaceService",
+						ResourceName: "namespaces/my-project/services/checkout",
+					}),
+					auditEntry(regression.Add(-10*time.Minute), logging.AuditLog{
+						ServiceName:  "cloudresourcemanager.googleapis.com",
+						MethodName:   "SetIamPolicy",
+						ResourceName: "projects/my-project",
+					}),
+					auditEntry(regression.Add(-3*time.Minute), logging.AuditLog{
+						ServiceName:  "compute.googleapis.com",
+						MethodName:   "v1.compute.firewalls.patch",
+						ResourceName: "projects/my-project/global/firewalls/allow-db",
+						Status:       &logging.AuditStatus{Code: 7},
+					}),
+					{Timestamp: regression, Message: "not an audit log"},
+				},
+			}, nil
+		}).
+		Times(1)
+
+	generator := incident.NewGenerator(loggingClient, nil, nil)
+	report, err := generator.FindRecentChanges(context.Background(), incident.ChangesRequest{
+		Time:    regression,
+		Service: "checkout",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(report.Changes) != 4 {
+		t.Fatalf("Expected 4 changes, got %+v", report.Changes)
+	}
+
+	expected := []struct {
+		category string
+		resource string
+	}{
+		{incident.ChangeCategoryDeployment, "namespaces/my-project/services/checkout"},
+		{incident.ChangeCategoryIAM, "projects/my-project"},
+		{incident.ChangeCategoryConfig, "projects/my-project/global/firewalls/allow-db"},
+		{incident.ChangeCategoryDeployment, "namespaces/my-project/services/billing"},
+	}
+	for i, want := range expected {
+		got := report.Changes[i]
+		if got.Category != want.category || got.ResourceName != want.resource {
+			t.Errorf("Change %d: expected %s on %s, got %s on %s", i, want.category, want.resource, got.Category, got.ResourceName)
+		}
+	}
+
+	if !report.Changes[2].Failed {
+		t.Error("Expected the firewall change to be marked as failed")
+	}
+	if !report.AnchorTime.Equal(regression) {
+		t.Errorf("Expected anchor time %v, got %v", regression, report.AnchorTime)
+	}
+}
+
+func TestGenerator_FindRecentChangesWithMetricSpike(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	regression := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	spike := regression.Add(-time.Hour)
+
+	monitoringClient := monitoringmocks.NewMockMonitoringClient(ctrl)
+	monitoringClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		Return(monitoring.ListTimeSeriesResponse{
+			TimeSeries: []monitoring.TimeSeriesData{
+				{Values: []monitoring.MetricValue{
+					{Value: 900, Timestamp: spike.Add(time.Minute)},
+					{Value: 100, Timestamp: spike.Add(-time.Minute)},
+					{Value: 850, Timestamp: spike},
+				}},
+			},
+		}, nil).
+		Times(1)
+
+	loggingClient := loggingmocks.NewMockLoggingClient(ctrl)
+	loggingClient.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		Return(logging.ListEntriesResponse{
+			Entries: []logging.LogEntry{
+				auditEntry(regression.Add(-5*time.Minute), logging.AuditLog{
+					ServiceName: "clouddeploy.googleapis.com",
+					MethodName:  "google.cloud.deploy.v1.CloudDeploy.CreateRollout",
+				}),
+				auditEntry(spike.Add(-2*time.Minute), logging.AuditLog{
+					ServiceName: "sqladmin.googleapis.com",
+					MethodName:  "cloudsql.instances.update",
+				}),
+			},
+		}, nil).
+		Times(1)
+
+	generator := incident.NewGenerator(loggingClient, monitoringClient, nil)
+	report, err := generator.FindRecentChanges(context.Background(), incident.ChangesRequest{
+		Time:         regression,
+		MetricFilter: `metric.type="run.googleapis.com/request_latencies"`,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Spike == nil || !report.Spike.Time.Equal(spike) || report.Spike.Previous != 100 {
+		t.Fatalf("Expected spike at %v from 100, got %+v", spike, report.Spike)
+	}
+	if !report.AnchorTime.Equal(spike) {
+		t.Errorf("Expected anchor time %v, got %v", spike, report.AnchorTime)
+	}
+	// The config change right before the spike outranks the later rollout
+	if len(report.Changes) != 2 || report.Changes[0].MethodName != "cloudsql.instances.update" {
+		t.Errorf("Expected the config change first, got %+v", report.Changes)
+	}
+}
+
+func TestGenerator_FindRecentChangesMetricError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	monitoringClient := monitoringmocks.NewMockMonitoringClient(ctrl)
+	monitoringClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		Return(monitoring.ListTimeSeriesResponse{}, errors.New("invalid filter")).
+		Times(1)
+
+	loggingClient := loggingmocks.NewMockLoggingClient(ctrl)
+	loggingClient.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		Return(logging.ListEntriesResponse{}, nil).
+		Times(1)
+
+	generator := incident.NewGenerator(loggingClient, monitoringClient, nil)
+	report, err := generator.FindRecentChanges(context.Background(), incident.ChangesRequest{
+		Time:         time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		MetricFilter: "bad",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.MetricError != "invalid filter" {
+		t.Errorf("Expected metric error, got %q", report.MetricError)
+	}
+	if report.Spike != nil {
+		t.Errorf("Expected no spike, got %+v", report.Spike)
+	}
+}
@@ -0,0 +1,489 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+const (
+	defaultMaxLogEntries      = 200
+	defaultMaxTraces          = 10
+	defaultSlowTraceThreshold = time.Second
+	maxLogSamples             = 5
+	maxTopMessages            = 10
+	// errorResponseCodeClass is the response_code_class label value counted as errors
+	errorResponseCodeClass = "5xx"
+)
+
+// Request represents a request to generate an incident report. Filters left
+// empty default to the Cloud Run metrics and log labels of Service.
+type Request struct {
+	ProjectID           string        `json:"project_id,omitempty"` // defaults to the clients' project
+	Service             string        `json:"service"`
+	StartTime           time.Time     `json:"start_time"`
+	EndTime             time.Time     `json:"end_time"`
+	LogFilter           string        `json:"log_filter,omitempty"`
+	LatencyMetricFilter string        `json:"latency_metric_filter,omitempty"` // must select a distribution metric
+	RequestMetricFilter string        `json:"request_metric_filter,omitempty"` // must select a count metric with a response_code_class label
+	TraceFilter         string        `json:"trace_filter,omitempty"`
+	SlowTraceThreshold  time.Duration `json:"slow_trace_threshold,omitempty"`
+	MaxLogEntries       int           `json:"max_log_entries,omitempty"`
+	MaxTraces           int           `json:"max_traces,omitempty"`
+}
+
+// Report represents the signals collected for a service during a time window
+type Report struct {
+	Service     string            `json:"service"`
+	StartTime   time.Time         `json:"start_time"`
+	EndTime     time.Time         `json:"end_time"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Summary     []string          `json:"summary"`
+	ErrorLogs   *ErrorLogs        `json:"error_logs,omitempty"`
+	Latency     *Latency          `json:"latency,omitempty"`
+	ErrorRate   *ErrorRate        `json:"error_rate,omitempty"`
+	SlowTraces  *SlowTraces       `json:"slow_traces,omitempty"`
+	Alerts      *Alerts           `json:"alerts,omitempty"`
+	Errors      map[string]string `json:"errors,omitempty"` // section name to the error that prevented collecting it
+}
+
+// ErrorLogs summarizes the error log entries in the window
+type ErrorLogs struct {
+	Filter      string             `json:"filter"`
+	Count       int                `json:"count"`
+	Truncated   bool               `json:"truncated,omitempty"` // more entries exist than were examined
+	TopMessages []MessageCount     `json:"top_messages"`
+	Samples     []logging.LogEntry `json:"samples"`
+}
+
+// MessageCount represents how often similar log messages occurred
+type MessageCount struct {
+	Message   string    `json:"message"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Latency summarizes request latency in the window
+type Latency struct {
+	Filter   string                   `json:"filter"`
+	Points   []monitoring.MetricValue `json:"points"` // 99th percentile per alignment period
+	Peak     float64                  `json:"peak"`
+	PeakTime time.Time                `json:"peak_time"`
+	Average  float64                  `json:"average"`
+}
+
+// ErrorRate summarizes the fraction of failed requests in the window
+type ErrorRate struct {
+	Filter        string                   `json:"filter"`
+	Points        []monitoring.MetricValue `json:"points"` // error fraction per alignment period
+	TotalRequests float64                  `json:"total_requests"`
+	ErrorRequests float64                  `json:"error_requests"`
+	OverallRate   float64                  `json:"overall_rate"`
+	PeakRate      float64                  `json:"peak_rate"`
+	PeakTime      time.Time                `json:"peak_time"`
+}
+
+// SlowTraces lists the slowest traces in the window
+type SlowTraces struct {
+	Filter string         `json:"filter"`
+	Traces []TraceSummary `json:"traces"`
+}
+
+// TraceSummary represents a trace by its root span
+type TraceSummary struct {
+	TraceID      string    `json:"trace_id"`
+	RootSpanName string    `json:"root_span_name"`
+	StartTime    time.Time `json:"start_time"`
+	Duration     string    `json:"duration"`
+}
+
+// Alerts lists the alerts that were open at any point in the window
+type Alerts struct {
+	Alerts []monitoring.Alert `json:"alerts"`
+}
+
+// Generator collects incident reports from the telemetry clients
+type Generator struct {
+	logging    logging.LoggingClient
+	monitoring monitoring.MonitoringClient
+	trace      trace.TraceClient
+	now        func() time.Time
+}
+
+// NewGenerator creates a new Generator
+func NewGenerator(loggingClient logging.LoggingClient, monitoringClient monitoring.MonitoringClient, traceClient trace.TraceClient) *Generator {
+	return &Generator{
+		logging:    loggingClient,
+		monitoring: monitoringClient,
+		trace:      traceClient,
+		now:        time.Now,
+	}
+}
+
+// Generate collects all report sections concurrently. A section that fails is
+// reported in Report.Errors instead of failing the whole report.
+func (g *Generator) Generate(ctx context.Context, req Request) (Report, error) {
+	if req.Service == "" && (req.LogFilter == "" || req.LatencyMetricFilter == "" || req.RequestMetricFilter == "") {
+		return Report{}, fmt.Errorf("service is required unless log, latency metric, and request metric filters are all given")
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return Report{}, fmt.Errorf("start_time must be before end_time")
+	}
+	if req.SlowTraceThreshold <= 0 {
+		req.SlowTraceThreshold = defaultSlowTraceThreshold
+	}
+	if req.MaxLogEntries <= 0 {
+		req.MaxLogEntries = defaultMaxLogEntries
+	}
+	if req.MaxTraces <= 0 {
+		req.MaxTraces = defaultMaxTraces
+	}
+
+	report := Report{
+		Service:     req.Service,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		GeneratedAt: g.now(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	collect := func(section string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if report.Errors == nil {
+					report.Errors = make(map[string]string)
+				}
+				report.Errors[section] = err.Error()
+			}
+		}()
+	}
+
+	// Each section writes only its own field, so only Errors needs the mutex
+	collect("error_logs", func() (err error) {
+		report.ErrorLogs, err = g.errorLogs(ctx, req)
+		return err
+	})
+	collect("latency", func() (err error) {
+		report.Latency, err = g.latency(ctx, req)
+		return err
+	})
+	collect("error_rate", func() (err error) {
+		report.ErrorRate, err = g.errorRate(ctx, req)
+		return err
+	})
+	collect("slow_traces", func() (err error) {
+		report.SlowTraces, err = g.slowTraces(ctx, req)
+		return err
+	})
+	collect("alerts", func() (err error) {
+		report.Alerts, err = g.alerts(ctx, req)
+		return err
+	})
+	wg.Wait()
+
+	report.Summary = summarize(report)
+	return report, nil
+}
+
+// errorLogs collects error log entries and groups them by message
+func (g *Generator) errorLogs(ctx context.Context, req Request) (*ErrorLogs, error) {
+	filter := req.LogFilter
+	if filter == "" {
+		filter = fmt.Sprintf(`severity>=ERROR AND (resource.labels.service_name=%q OR resource.labels.container_name=%q OR resource.labels.module_id=%q OR resource.labels.function_name=%q)`,
+			req.Service, req.Service, req.Service, req.Service)
+	}
+	filter = fmt.Sprintf("%s AND timestamp>=%q AND timestamp<=%q", filter,
+		req.StartTime.UTC().Format(time.RFC3339Nano), req.EndTime.UTC().Format(time.RFC3339Nano))
+
+	resp, err := g.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		Limit:     req.MaxLogEntries,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	section := &ErrorLogs{
+		Filter:      filter,
+		Count:       len(resp.Entries),
+		Truncated:   resp.NextPageToken != "",
+		TopMessages: topMessages(resp.Entries),
+		Samples:     resp.Entries[:min(len(resp.Entries), maxLogSamples)],
+	}
+	return section, nil
+}
+
+var digitsPattern = regexp.MustCompile(`\d+`)
+
+// topMessages groups entries whose messages differ only in numbers
+func topMessages(entries []logging.LogEntry) []MessageCount {
+	counts := make(map[string]*MessageCount)
+	for _, entry := range entries {
+		key := digitsPattern.ReplaceAllString(entry.Message, "N")
+		if len(key) > 200 {
+			key = key[:200]
+		}
+
+		mc, ok := counts[key]
+		if !ok {
+			mc = &MessageCount{Message: entry.Message, FirstSeen: entry.Timestamp, LastSeen: entry.Timestamp}
+			counts[key] = mc
+		}
+		mc.Count++
+		if entry.Timestamp.Before(mc.FirstSeen) {
+			mc.FirstSeen = entry.Timestamp
+		}
+		if entry.Timestamp.After(mc.LastSeen) {
+			mc.LastSeen = entry.Timestamp
+		}
+	}
+
+	result := make([]MessageCount, 0, len(counts))
+	for _, mc := range counts {
+		result = append(result, *mc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Message < result[j].Message
+	})
+	return result[:min(len(result), maxTopMessages)]
+}
+
+// alignmentPeriod splits the window into about 30 points, at least a minute apart
+func alignmentPeriod(req Request) string {
+	period := max(req.EndTime.Sub(req.StartTime)/30, time.Minute).Truncate(time.Minute)
+	return fmt.Sprintf("%ds", int64(period.Seconds()))
+}
+
+// latency collects the 99th percentile request latency
+func (g *Generator) latency(ctx context.Context, req Request) (*Latency, error) {
+	filter := req.LatencyMetricFilter
+	if filter == "" {
+		filter = fmt.Sprintf(`metric.type="run.googleapis.com/request_latencies" AND resource.labels.service_name=%q`, req.Service)
+	}
+
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		Aggregation: &monitoring.AggregationConfig{
+			AlignmentPeriod:    alignmentPeriod(req),
+			PerSeriesAligner:   "ALIGN_DELTA",
+			CrossSeriesReducer: "REDUCE_PERCENTILE_99",
+		},
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	resp, err := g.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return nil, err
+	}
+
+	section := &Latency{Filter: filter, Points: []monitoring.MetricValue{}}
+	if len(resp.TimeSeries) == 0 {
+		return section, nil
+	}
+
+	points := sortedPoints(resp.TimeSeries[0].Values)
+	var sum float64
+	for _, p := range points {
+		sum += p.Value
+		if p.Value > section.Peak {
+			section.Peak = p.Value
+			section.PeakTime = p.Timestamp
+		}
+	}
+	section.Points = points
+	if len(points) > 0 {
+		section.Average = sum / float64(len(points))
+	}
+	return section, nil
+}
+
+// errorRate collects the fraction of requests with an error response code class
+func (g *Generator) errorRate(ctx context.Context, req Request) (*ErrorRate, error) {
+	filter := req.RequestMetricFilter
+	if filter == "" {
+		filter = fmt.Sprintf(`metric.type="run.googleapis.com/request_count" AND resource.labels.service_name=%q`, req.Service)
+	}
+
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		Aggregation: &monitoring.AggregationConfig{
+			AlignmentPeriod:    alignmentPeriod(req),
+			PerSeriesAligner:   "ALIGN_SUM",
+			CrossSeriesReducer: "REDUCE_SUM",
+			GroupByFields:      []string{"metric.label.response_code_class"},
+		},
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	resp, err := g.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[time.Time]float64)
+	errs := make(map[time.Time]float64)
+	section := &ErrorRate{Filter: filter, Points: []monitoring.MetricValue{}}
+	for _, ts := range resp.TimeSeries {
+		isError := ts.MetricLabels["response_code_class"] == errorResponseCodeClass
+		for _, v := range ts.Values {
+			totals[v.Timestamp] += v.Value
+			section.TotalRequests += v.Value
+			if isError {
+				errs[v.Timestamp] += v.Value
+				section.ErrorRequests += v.Value
+			}
+		}
+	}
+
+	for timestamp, total := range totals {
+		if total == 0 {
+			continue
+		}
+		rate := errs[timestamp] / total
+		section.Points = append(section.Points, monitoring.MetricValue{Value: rate, Timestamp: timestamp})
+		if rate > section.PeakRate {
+			section.PeakRate = rate
+			section.PeakTime = timestamp
+		}
+	}
+	section.Points = sortedPoints(section.Points)
+	if section.TotalRequests > 0 {
+		section.OverallRate = section.ErrorRequests / section.TotalRequests
+	}
+	return section, nil
+}
+
+// sortedPoints returns points ordered by timestamp
+func sortedPoints(points []monitoring.MetricValue) []monitoring.MetricValue {
+	sorted := append([]monitoring.MetricValue(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+	return sorted
+}
+
+// slowTraces collects the slowest traces above the threshold
+func (g *Generator) slowTraces(ctx context.Context, req Request) (*SlowTraces, error) {
+	filter := fmt.Sprintf("latency:%s", req.SlowTraceThreshold)
+	if req.TraceFilter != "" {
+		filter = req.TraceFilter + " " + filter
+	}
+
+	resp, err := g.trace.ListTraces(ctx, trace.ListTracesRequest{
+		ProjectID: req.ProjectID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Filter:    filter,
+		OrderBy:   "duration desc",
+		View:      trace.ViewRootSpan,
+		PageSize:  req.MaxTraces,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	section := &SlowTraces{Filter: filter, Traces: []TraceSummary{}}
+	for _, t := range resp.Traces {
+		summary := TraceSummary{TraceID: t.TraceID}
+		for _, span := range t.Spans {
+			if span.ParentID == "" {
+				summary.RootSpanName = span.Name
+				summary.StartTime = span.StartTime
+				summary.Duration = span.EndTime.Sub(span.StartTime).String()
+				break
+			}
+		}
+		section.Traces = append(section.Traces, summary)
+	}
+	return section, nil
+}
+
+// alerts collects the alerts that were open at any point in the window
+func (g *Generator) alerts(ctx context.Context, req Request) (*Alerts, error) {
+	resp, err := g.monitoring.ListAlerts(ctx, monitoring.ListAlertsRequest{
+		ProjectID: req.ProjectID,
+		Filter:    fmt.Sprintf("open_time <= %q", req.EndTime.UTC().Format(time.RFC3339Nano)),
+		OrderBy:   "open_time desc",
+		PageSize:  100,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	section := &Alerts{Alerts: []monitoring.Alert{}}
+	for _, alert := range resp.Alerts {
+		if alert.CloseTime != nil && alert.CloseTime.Before(req.StartTime) {
+			continue
+		}
+		section.Alerts = append(section.Alerts, alert)
+	}
+	return section, nil
+}
+
+// summarize describes the highlights of each collected section
+func summarize(report Report) []string {
+	summary := []string{}
+
+	if s := report.ErrorLogs; s != nil {
+		count := fmt.Sprintf("%d", s.Count)
+		if s.Truncated {
+			count += "+"
+		}
+		line := fmt.Sprintf("%s error log entries", count)
+		if len(s.TopMessages) > 0 {
+			line += fmt.Sprintf("; most frequent (%d): %s", s.TopMessages[0].Count, s.TopMessages[0].Message)
+		}
+		summary = append(summary, line)
+	}
+
+	if s := report.Latency; s != nil && len(s.Points) > 0 {
+		summary = append(summary, fmt.Sprintf("p99 latency peaked at %.1f (average %.1f) at %s",
+			s.Peak, s.Average, s.PeakTime.Format(time.RFC3339)))
+	}
+
+	if s := report.ErrorRate; s != nil && s.TotalRequests > 0 {
+		summary = append(summary, fmt.Sprintf("%.2f%% of %.0f requests failed; error rate peaked at %.2f%% at %s",
+			s.OverallRate*100, s.TotalRequests, s.PeakRate*100, s.PeakTime.Format(time.RFC3339)))
+	}
+
+	if s := report.SlowTraces; s != nil && len(s.Traces) > 0 {
+		slowest := s.Traces[0]
+		summary = append(summary, fmt.Sprintf("%d slow traces; slowest %s took %s", len(s.Traces), slowest.RootSpanName, slowest.Duration))
+	}
+
+	if s := report.Alerts; s != nil && len(s.Alerts) > 0 {
+		open := 0
+		for _, alert := range s.Alerts {
+			if alert.State == monitoring.AlertStateOpen {
+				open++
+			}
+		}
+		summary = append(summary, fmt.Sprintf("%d alerts during the window, %d still open", len(s.Alerts), open))
+	}
+
+	for _, section := range slices.Sorted(maps.Keys(report.Errors)) {
+		summary = append(summary, fmt.Sprintf("%s could not be collected: %s", section, report.Errors[section]))
+	}
+	return summary
+}
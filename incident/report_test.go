@@ -0,0 +1,161 @@
+package incident_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/incident"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	loggingmocks "github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	monitoringmocks "github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	tracemocks "github.com/kitagry/gcp-telemetry-mcp/trace/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGenerator_Generate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	loggingClient := loggingmocks.NewMockLoggingClient(ctrl)
+	monitoringClient := monitoringmocks.NewMockMonitoringClient(ctrl)
+	traceClient := tracemocks.NewMockTraceClient(ctrl)
+
+	loggingClient.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		Return(logging.ListEntriesResponse{
+			Entries: []logging.LogEntry{
+				{Severity: "ERROR", Message: "timeout after 30s calling db-1", Timestamp: start.Add(10 * time.Minute)},
+				{Severity: "ERROR", Message: "timeout after 31s calling db-2", Timestamp: start.Add(20 * time.Minute)},
+				{Severity: "ERROR", Message: "panic: nil map", Timestamp: start.Add(15 * time.Minute)},
+			},
+		}, nil).
+		Times(1)
+
+	// Latency and request count queries
+	monitoringClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			if req.Aggregation.CrossSeriesReducer == "REDUCE_PERCENTILE_99" {
+				return monitoring.ListTimeSeriesResponse{
+					TimeSeries: []monitoring.TimeSeriesData{
+						{Values: []monitoring.MetricValue{
+							{Value: 120, Timestamp: start.Add(10 * time.Minute)},
+							{Value: 900, Timestamp: start.Add(20 * time.Minute)},
+						}},
+					},
+				}, nil
+			}
+			return monitoring.ListTimeSeriesResponse{
+				TimeSeries: []monitoring.TimeSeriesData{
+					{MetricLabels: map[string]string{"response_code_class": "2xx"}, Values: []monitoring.MetricValue{
+						{Value: 90, Timestamp: start.Add(10 * time.Minute)},
+						{Value: 50, Timestamp: start.Add(20 * time.Minute)},
+					}},
+					{MetricLabels: map[string]string{"response_code_class": "5xx"}, Values: []monitoring.MetricValue{
+						{Value: 10, Timestamp: start.Add(10 * time.Minute)},
+						{Value: 50, Timestamp: start.Add(20 * time.Minute)},
+					}},
+				},
+			}, nil
+		}).
+		Times(2)
+
+	monitoringClient.EXPECT().
+		ListAlerts(gomock.Any(), gomock.Any()).
+		Return(monitoring.ListAlertsResponse{}, errors.New("permission denied")).
+		Times(1)
+
+	traceClient.EXPECT().
+		ListTraces(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req trace.ListTracesRequest) (trace.ListTracesResponse, error) {
+			if req.View != trace.ViewRootSpan {
+				t.Errorf("Expected view %s, got %s", trace.ViewRootSpan, req.View)
+			}
+			return trace.ListTracesResponse{Traces: []trace.Trace{
+				{TraceID: "abc", Spans: []trace.Span{
+					{SpanID: "1", Name: "GET /api/orders", StartTime: start, EndTime: start.Add(4 * time.Second)},
+				}},
+			}}, nil
+		}).
+		Times(1)
+
+	generator := incident.NewGenerator(loggingClient, monitoringClient, traceClient)
+	report, err := generator.Generate(context.Background(), incident.Request{
+		Service:   "checkout",
+		StartTime: start,
+		EndTime:   end,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.ErrorLogs == nil || report.ErrorLogs.Count != 3 {
+		t.Fatalf("Expected 3 error logs, got %+v", report.ErrorLogs)
+	}
+	if top := report.ErrorLogs.TopMessages[0]; top.Count != 2 {
+		t.Errorf("Expected similar timeout messages to be grouped, got %+v", report.ErrorLogs.TopMessages)
+	}
+
+	if report.Latency == nil || report.Latency.Peak != 900 {
+		t.Errorf("Expected latency peak 900, got %+v", report.Latency)
+	}
+
+	if report.ErrorRate == nil {
+		t.Fatal("Expected error rate section")
+	}
+	if report.ErrorRate.OverallRate != 0.3 {
+		t.Errorf("Expected overall error rate 0.3, got %v", report.ErrorRate.OverallRate)
+	}
+	if report.ErrorRate.PeakRate != 0.5 {
+		t.Errorf("Expected peak error rate 0.5, got %v", report.ErrorRate.PeakRate)
+	}
+
+	if report.SlowTraces == nil || len(report.SlowTraces.Traces) != 1 || report.SlowTraces.Traces[0].Duration != "4s" {
+		t.Errorf("Expected one 4s slow trace, got %+v", report.SlowTraces)
+	}
+
+	if report.Alerts != nil {
+		t.Errorf("Expected no alerts section, got %+v", report.Alerts)
+	}
+	if report.Errors["alerts"] != "permission denied" {
+		t.Errorf("Expected alerts error, got %v", report.Errors)
+	}
+
+	if len(report.Summary) != 5 {
+		t.Errorf("Expected 5 summary lines, got %v", report.Summary)
+	}
+}
+
+func TestGenerator_GenerateValidation(t *testing.T) {
+	generator := incident.NewGenerator(nil, nil, nil)
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		req  incident.Request
+	}{
+		{
+			name: "missing service",
+			req:  incident.Request{StartTime: start, EndTime: start.Add(time.Hour)},
+		},
+		{
+			name: "empty window",
+			req:  incident.Request{Service: "checkout", StartTime: start, EndTime: start},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := generator.Generate(context.Background(), tt.req); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
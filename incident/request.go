@@ -0,0 +1,368 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+const (
+	defaultRequestIDLabel       = "request_id"
+	defaultRequestWindow        = 24 * time.Hour
+	defaultMaxRequestLogEntries = 100
+	maxRequestTraces            = 5
+	maxRequestSpans             = 100
+	maxRequestMetricSeries      = 5
+	// requestMetricPadding is the time around the request covered by the metrics
+	requestMetricPadding = 5 * time.Minute
+)
+
+// validRequestLabel matches the label keys a request ID can be looked up by
+var validRequestLabel = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// Sources of timeline events
+const (
+	EventSourceLog  = "log"
+	EventSourceSpan = "span"
+)
+
+// RequestTimelineRequest represents a request to gather the telemetry of one
+// application request, identified by the value of a label
+type RequestTimelineRequest struct {
+	ProjectID string    `json:"project_id,omitempty"` // defaults to the clients' project
+	RequestID string    `json:"request_id"`
+	Label     string    `json:"label,omitempty"`     // log and span label holding the request ID, defaults to request_id
+	StartTime time.Time `json:"start_time,omitzero"` // defaults to a day before EndTime
+	EndTime   time.Time `json:"end_time,omitzero"`   // defaults to now
+	// MetricFilter selects the metrics shown around the request. It defaults
+	// to the p99 latency of the Cloud Run service that logged the request.
+	MetricFilter  string `json:"metric_filter,omitempty"`
+	MaxLogEntries int    `json:"max_log_entries,omitempty"`
+}
+
+// RequestTimeline represents the log entries and spans of one request in time
+// order, with the metrics around it
+type RequestTimeline struct {
+	RequestID       string            `json:"request_id"`
+	Label           string            `json:"label"`
+	StartTime       time.Time         `json:"start_time"` // window searched
+	EndTime         time.Time         `json:"end_time"`
+	FirstSeen       time.Time         `json:"first_seen,omitzero"`
+	LastSeen        time.Time         `json:"last_seen,omitzero"`
+	Summary         []string          `json:"summary"`
+	LogFilter       string            `json:"log_filter"`
+	TraceFilter     string            `json:"trace_filter"`
+	LogCount        int               `json:"log_count"`
+	LogsTruncated   bool              `json:"logs_truncated,omitempty"` // more entries exist than were examined
+	TraceIDs        []string          `json:"trace_ids"`
+	TracesTruncated bool              `json:"traces_truncated,omitempty"` // more traces were found than are included
+	SpansTruncated  bool              `json:"spans_truncated,omitempty"`  // only the earliest spans are included
+	Events          []TimelineEvent   `json:"timeline"`
+	Metrics         *RequestMetrics   `json:"metrics,omitempty"`
+	Errors          map[string]string `json:"errors,omitempty"` // source to the error that prevented collecting it
+}
+
+// TimelineEvent represents a log entry, span, alert, or deployment in a timeline
+type TimelineEvent struct {
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`             // one of the EventSource constants
+	Severity string    `json:"severity,omitempty"` // of log entries and alerts
+	Message  string    `json:"message"`            // message of log entries, name of spans, policy of alerts, operation of deployments
+	Resource string    `json:"resource,omitempty"` // monitored resource type of log entries and alerts
+	TraceID  string    `json:"trace_id,omitempty"`
+	SpanID   string    `json:"span_id,omitempty"`
+	Duration string    `json:"duration,omitempty"` // of spans
+}
+
+// RequestMetrics represents the time series around a request
+type RequestMetrics struct {
+	Filter     string                      `json:"filter"`
+	StartTime  time.Time                   `json:"start_time"`
+	EndTime    time.Time                   `json:"end_time"`
+	TimeSeries []monitoring.TimeSeriesData `json:"time_series"`
+	Truncated  bool                        `json:"truncated,omitempty"` // more series matched than are returned
+}
+
+// CorrelateRequest gathers the log entries labeled with a request ID, the
+// traces they belong to or whose spans carry the same label, and the metrics
+// around the request, into one timeline. A source that fails is reported in
+// RequestTimeline.Errors instead of failing the whole timeline.
+func (g *Generator) CorrelateRequest(ctx context.Context, req RequestTimelineRequest) (RequestTimeline, error) {
+	if req.RequestID == "" {
+		return RequestTimeline{}, fmt.Errorf("request_id is required")
+	}
+	if strings.ContainsFunc(req.RequestID, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '"' }) {
+		return RequestTimeline{}, fmt.Errorf("invalid request_id %q: must not contain spaces or quotes", req.RequestID)
+	}
+	if req.Label == "" {
+		req.Label = defaultRequestIDLabel
+	}
+	if !validRequestLabel.MatchString(req.Label) {
+		return RequestTimeline{}, fmt.Errorf(`invalid label %q: only letters, digits, "_", ".", "/", and "-" are allowed`, req.Label)
+	}
+	if req.EndTime.IsZero() {
+		req.EndTime = g.now()
+	}
+	if req.StartTime.IsZero() {
+		req.StartTime = req.EndTime.Add(-defaultRequestWindow)
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return RequestTimeline{}, fmt.Errorf("start_time must be before end_time")
+	}
+	if req.MaxLogEntries <= 0 {
+		req.MaxLogEntries = defaultMaxRequestLogEntries
+	}
+
+	timeline := RequestTimeline{
+		RequestID: req.RequestID,
+		Label:     req.Label,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		LogFilter: fmt.Sprintf("labels.%q=%q AND timestamp>=%q AND timestamp<=%q", req.Label, req.RequestID,
+			req.StartTime.UTC().Format(time.RFC3339Nano), req.EndTime.UTC().Format(time.RFC3339Nano)),
+		// An exact match on the label of any span
+		TraceFilter: fmt.Sprintf("+%s:%s", req.Label, req.RequestID),
+		TraceIDs:    []string{},
+		Events:      []TimelineEvent{},
+	}
+	addError := func(source string, err error) {
+		if timeline.Errors == nil {
+			timeline.Errors = make(map[string]string)
+		}
+		timeline.Errors[source] = err.Error()
+	}
+
+	// The traces are those the log entries were written in, and those with
+	// a span labeled with the request ID
+	var entries []logging.LogEntry
+	resp, err := g.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    timeline.LogFilter,
+		OrderBy:   logging.OrderByTimestampAsc,
+		Limit:     req.MaxLogEntries,
+	})
+	if err != nil {
+		addError("logs", err)
+	} else {
+		entries = resp.Entries
+		timeline.LogCount = len(entries)
+		timeline.LogsTruncated = resp.NextPageToken != ""
+		for _, entry := range entries {
+			traceID := entryTraceID(entry.Trace)
+			timeline.Events = append(timeline.Events, TimelineEvent{
+				Time:     entry.Timestamp,
+				Source:   EventSourceLog,
+				Severity: entry.Severity,
+				Message:  entry.Message,
+				Resource: entryResourceType(entry),
+				TraceID:  traceID,
+				SpanID:   entry.SpanID,
+			})
+			if traceID != "" && !slices.Contains(timeline.TraceIDs, traceID) {
+				timeline.TraceIDs = append(timeline.TraceIDs, traceID)
+			}
+		}
+	}
+
+	labeled, err := g.trace.ListTraces(ctx, trace.ListTracesRequest{
+		ProjectID: req.ProjectID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Filter:    timeline.TraceFilter,
+		PageSize:  maxRequestTraces,
+	})
+	if err != nil {
+		addError("trace_search", err)
+	} else {
+		for _, t := range labeled.Traces {
+			if !slices.Contains(timeline.TraceIDs, t.TraceID) {
+				timeline.TraceIDs = append(timeline.TraceIDs, t.TraceID)
+			}
+		}
+	}
+	if len(timeline.TraceIDs) > maxRequestTraces {
+		timeline.TraceIDs = timeline.TraceIDs[:maxRequestTraces]
+		timeline.TracesTruncated = true
+	}
+
+	if len(timeline.TraceIDs) > 0 {
+		traces := g.trace.GetTraces(ctx, trace.GetTracesRequest{ProjectID: req.ProjectID, TraceIDs: timeline.TraceIDs})
+		for _, traceID := range timeline.TraceIDs {
+			if msg, ok := traces.Errors[traceID]; ok {
+				addError("trace "+traceID, fmt.Errorf("%s", msg))
+			}
+		}
+		spans, truncated := spanEvents(traces.Traces, timeline.TraceIDs)
+		timeline.Events = append(timeline.Events, spans...)
+		timeline.SpansTruncated = truncated
+	}
+
+	sort.SliceStable(timeline.Events, func(i, j int) bool { return timeline.Events[i].Time.Before(timeline.Events[j].Time) })
+	if len(timeline.Events) > 0 {
+		timeline.FirstSeen = timeline.Events[0].Time
+		timeline.LastSeen = timeline.Events[len(timeline.Events)-1].Time
+	}
+
+	// The metrics need the time of the request
+	if metricFilter, aggregation := requestMetricFilter(req.MetricFilter, entries); metricFilter != "" && !timeline.FirstSeen.IsZero() {
+		metrics, err := g.requestMetrics(ctx, req.ProjectID, metricFilter, aggregation, timeline.FirstSeen, timeline.LastSeen)
+		if err != nil {
+			addError("metrics", err)
+		} else {
+			timeline.Metrics = metrics
+		}
+	}
+
+	timeline.Summary = summarizeRequest(timeline)
+	return timeline, nil
+}
+
+// spanEvents returns the spans of the traces as timeline events, the earliest
+// maxRequestSpans of them, and whether spans were left out
+func spanEvents(traces map[string]*trace.Trace, traceIDs []string) ([]TimelineEvent, bool) {
+	var events []TimelineEvent
+	for _, traceID := range traceIDs {
+		t, ok := traces[traceID]
+		if !ok || t == nil {
+			continue
+		}
+		for _, span := range t.Spans {
+			events = append(events, TimelineEvent{
+				Time:     span.StartTime,
+				Source:   EventSourceSpan,
+				Message:  span.Name,
+				TraceID:  traceID,
+				SpanID:   span.SpanID,
+				Duration: span.EndTime.Sub(span.StartTime).String(),
+			})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events[:min(len(events), maxRequestSpans)], len(events) > maxRequestSpans
+}
+
+// entryTraceID returns the trace ID of the trace field of a log entry, given
+// either as "projects/PROJECT_ID/traces/TRACE_ID" or as the bare ID
+func entryTraceID(traceName string) string {
+	if i := strings.LastIndex(traceName, "/traces/"); i >= 0 {
+		return traceName[i+len("/traces/"):]
+	}
+	return traceName
+}
+
+// entryResourceType returns the monitored resource type of a log entry
+func entryResourceType(entry logging.LogEntry) string {
+	if entry.Resource == nil {
+		return ""
+	}
+	return entry.Resource.Type
+}
+
+// requestMetricFilter returns the filter of the metrics shown around a
+// request, with their aggregation: the given filter with raw points, or the
+// p99 latency of the Cloud Run service that logged the request. It returns
+// an empty filter when neither applies.
+func requestMetricFilter(filter string, entries []logging.LogEntry) (string, *monitoring.AggregationConfig) {
+	if filter != "" {
+		return filter, nil
+	}
+	for _, entry := range entries {
+		if entry.Resource == nil || entry.Resource.Type != "cloud_run_revision" || entry.Resource.Labels["service_name"] == "" {
+			continue
+		}
+		filter := fmt.Sprintf(`metric.type="run.googleapis.com/request_latencies" AND resource.labels.service_name=%q`, entry.Resource.Labels["service_name"])
+		return filter, &monitoring.AggregationConfig{
+			AlignmentPeriod:    "60s",
+			PerSeriesAligner:   "ALIGN_DELTA",
+			CrossSeriesReducer: "REDUCE_PERCENTILE_99",
+		}
+	}
+	return "", nil
+}
+
+// requestMetrics collects the time series of filter from requestMetricPadding
+// before the request to requestMetricPadding after it
+func (g *Generator) requestMetrics(ctx context.Context, projectID, filter string, aggregation *monitoring.AggregationConfig, firstSeen, lastSeen time.Time) (*RequestMetrics, error) {
+	metrics := &RequestMetrics{
+		Filter:    filter,
+		StartTime: firstSeen.Add(-requestMetricPadding),
+		EndTime:   lastSeen.Add(requestMetricPadding),
+	}
+
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID:   projectID,
+		Filter:      filter,
+		Aggregation: aggregation,
+	}
+	listReq.Interval.StartTime = metrics.StartTime
+	listReq.Interval.EndTime = metrics.EndTime
+
+	resp, err := g.monitoring.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.TimeSeries = append([]monitoring.TimeSeriesData{}, resp.TimeSeries[:min(len(resp.TimeSeries), maxRequestMetricSeries)]...)
+	metrics.Truncated = len(resp.TimeSeries) > maxRequestMetricSeries
+	for i, ts := range metrics.TimeSeries {
+		metrics.TimeSeries[i].Values = sortedPoints(ts.Values)
+	}
+	return metrics, nil
+}
+
+// summarizeRequest describes the highlights of a request timeline
+func summarizeRequest(timeline RequestTimeline) []string {
+	summary := []string{}
+
+	if timeline.FirstSeen.IsZero() {
+		summary = append(summary, fmt.Sprintf("no log entries or spans labeled %s=%s between %s and %s",
+			timeline.Label, timeline.RequestID, timeline.StartTime.Format(time.RFC3339), timeline.EndTime.Format(time.RFC3339)))
+	} else {
+		summary = append(summary, fmt.Sprintf("request seen from %s to %s (%s)",
+			timeline.FirstSeen.Format(time.RFC3339Nano), timeline.LastSeen.Format(time.RFC3339Nano), timeline.LastSeen.Sub(timeline.FirstSeen)))
+	}
+
+	if timeline.LogCount > 0 {
+		count := fmt.Sprintf("%d", timeline.LogCount)
+		if timeline.LogsTruncated {
+			count += "+"
+		}
+		line := fmt.Sprintf("%s log entries", count)
+		errorCount := 0
+		var firstError *TimelineEvent
+		for i, event := range timeline.Events {
+			if event.Source == EventSourceLog && slices.Index(logging.Severities, event.Severity) >= slices.Index(logging.Severities, "ERROR") {
+				errorCount++
+				if firstError == nil {
+					firstError = &timeline.Events[i]
+				}
+			}
+		}
+		if firstError != nil {
+			line += fmt.Sprintf("; %d at ERROR or above, first at %s: %s", errorCount, firstError.Time.Format(time.RFC3339Nano), firstError.Message)
+		}
+		summary = append(summary, line)
+	}
+
+	if len(timeline.TraceIDs) > 0 {
+		count := fmt.Sprintf("%d", len(timeline.TraceIDs))
+		if timeline.TracesTruncated {
+			count += "+"
+		}
+		summary = append(summary, fmt.Sprintf("%s traces: %s", count, strings.Join(timeline.TraceIDs, ", ")))
+	}
+
+	for _, source := range slices.Sorted(maps.Keys(timeline.Errors)) {
+		summary = append(summary, fmt.Sprintf("%s could not be collected: %s", source, timeline.Errors[source]))
+	}
+	return summary
+}
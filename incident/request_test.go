@@ -0,0 +1,137 @@
+package incident_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/incident"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+func TestGenerator_CorrelateRequest(t *testing.T) {
+	ctx := context.Background()
+	backend := fake.New("test-project")
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	checkout := &logging.MonitoredResource{Type: "cloud_run_revision", Labels: map[string]string{"service_name": "checkout"}}
+	err := backend.Logging.WriteEntries(ctx, logging.WriteEntriesRequest{LogName: "app", Entries: []logging.LogEntry{
+		{Timestamp: start, Severity: "INFO", Message: "order received", Labels: map[string]string{"request_id": "req-42"}, Resource: checkout, Trace: "projects/test-project/traces/aaa"},
+		{Timestamp: start.Add(300 * time.Millisecond), Severity: "ERROR", Message: "payment declined", Labels: map[string]string{"request_id": "req-42"}, Resource: checkout, Trace: "projects/test-project/traces/aaa"},
+		{Timestamp: start.Add(time.Second), Severity: "ERROR", Message: "other request", Labels: map[string]string{"request_id": "req-43"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// aaa is found through the log entries, bbb through the label of its span
+	spans := map[string][]trace.Span{
+		"aaa": {
+			{SpanID: "1", Name: "POST /orders", StartTime: start.Add(-10 * time.Millisecond), EndTime: start.Add(400 * time.Millisecond)},
+			{SpanID: "2", ParentID: "1", Name: "payments.Charge", StartTime: start.Add(100 * time.Millisecond), EndTime: start.Add(290 * time.Millisecond)},
+		},
+		"bbb": {
+			{SpanID: "3", Name: "ship order", StartTime: start.Add(2 * time.Second), EndTime: start.Add(3 * time.Second), Labels: map[string]string{"request_id": "req-42"}},
+		},
+		"ccc": {
+			{SpanID: "4", Name: "ship order", StartTime: start.Add(2 * time.Second), EndTime: start.Add(3 * time.Second), Labels: map[string]string{"request_id": "req-420"}},
+		},
+	}
+	for traceID, s := range spans {
+		if err := backend.Trace.PatchTraces(ctx, trace.PatchTraceRequest{TraceID: traceID, Spans: s}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = backend.Monitoring.WriteTimeSeries(ctx, monitoring.WriteTimeSeriesRequest{TimeSeries: []monitoring.TimeSeriesData{{
+		MetricType:   "custom.googleapis.com/queue_depth",
+		ResourceType: "global",
+		Values:       []monitoring.MetricValue{{Value: 3, Timestamp: start.Add(-time.Minute)}, {Value: 9, Timestamp: start.Add(time.Minute)}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator := incident.NewGenerator(
+		logging.NewWithClient(backend.Logging),
+		monitoring.NewWithClient(backend.Monitoring, "test-project"),
+		trace.NewWithClient(backend.Trace, "test-project"),
+	)
+	timeline, err := generator.CorrelateRequest(ctx, incident.RequestTimelineRequest{
+		RequestID:    "req-42",
+		MetricFilter: `metric.type="custom.googleapis.com/queue_depth"`,
+	})
+	if err != nil {
+		t.Fatalf("CorrelateRequest() error = %v", err)
+	}
+	if len(timeline.Errors) > 0 {
+		t.Fatalf("Unexpected errors %v", timeline.Errors)
+	}
+
+	if timeline.LogCount != 2 || strings.Join(timeline.TraceIDs, ",") != "aaa,bbb" {
+		t.Errorf("Expected 2 log entries in traces aaa and bbb, got %d in %v", timeline.LogCount, timeline.TraceIDs)
+	}
+	var order []string
+	for _, event := range timeline.Events {
+		order = append(order, event.Source+":"+event.Message)
+	}
+	want := "span:POST /orders,log:order received,span:payments.Charge,log:payment declined,span:ship order"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("Expected the timeline %s, got %s", want, got)
+	}
+	if !timeline.FirstSeen.Equal(start.Add(-10*time.Millisecond)) || !timeline.LastSeen.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Unexpected first and last seen %s, %s", timeline.FirstSeen, timeline.LastSeen)
+	}
+	if timeline.Metrics == nil || len(timeline.Metrics.TimeSeries) != 1 || len(timeline.Metrics.TimeSeries[0].Values) != 2 {
+		t.Errorf("Expected the queue depth around the request, got %+v", timeline.Metrics)
+	}
+	if !strings.Contains(strings.Join(timeline.Summary, "\n"), "1 at ERROR or above, first at") {
+		t.Errorf("Expected the summary to point at the error, got %v", timeline.Summary)
+	}
+
+	// The p99 latency of the Cloud Run service is shown by default
+	timeline, err = generator.CorrelateRequest(ctx, incident.RequestTimelineRequest{RequestID: "req-42"})
+	if err != nil {
+		t.Fatalf("CorrelateRequest() error = %v", err)
+	}
+	if timeline.Metrics == nil || !strings.Contains(timeline.Metrics.Filter, `service_name="checkout"`) {
+		t.Errorf("Expected the latency of the checkout service, got %+v (errors %v)", timeline.Metrics, timeline.Errors)
+	}
+}
+
+func TestGenerator_CorrelateRequest_Errors(t *testing.T) {
+	backend := fake.New("test-project")
+	generator := incident.NewGenerator(
+		logging.NewWithClient(backend.Logging),
+		monitoring.NewWithClient(backend.Monitoring, "test-project"),
+		trace.NewWithClient(backend.Trace, "test-project"),
+	)
+
+	tests := []struct {
+		req     incident.RequestTimelineRequest
+		wantErr string
+	}{
+		{req: incident.RequestTimelineRequest{}, wantErr: "request_id is required"},
+		{req: incident.RequestTimelineRequest{RequestID: "a b"}, wantErr: "must not contain spaces"},
+		{req: incident.RequestTimelineRequest{RequestID: "req-42", Label: `x" OR true`}, wantErr: "invalid label"},
+	}
+	for _, tt := range tests {
+		_, err := generator.CorrelateRequest(context.Background(), tt.req)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CorrelateRequest(%+v) error = %v, want %q", tt.req, err, tt.wantErr)
+		}
+	}
+
+	// A request that left no telemetry is reported as such
+	timeline, err := generator.CorrelateRequest(context.Background(), incident.RequestTimelineRequest{RequestID: "req-42"})
+	if err != nil {
+		t.Fatalf("CorrelateRequest() error = %v", err)
+	}
+	if len(timeline.Events) != 0 || timeline.Metrics != nil || !strings.HasPrefix(timeline.Summary[0], "no log entries or spans labeled request_id=req-42") {
+		t.Errorf("Unexpected timeline %+v", timeline)
+	}
+}
@@ -0,0 +1,343 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+// Sources of merged timeline events, besides EventSourceLog
+const (
+	EventSourceSpanStart  = "span_start"
+	EventSourceSpanEnd    = "span_end"
+	EventSourceAlertOpen  = "alert_open"
+	EventSourceAlertClose = "alert_close"
+	EventSourceDeploy     = "deploy"
+)
+
+// Signals a timeline can be built from
+const (
+	SignalLogs    = "logs"
+	SignalSpans   = "spans"
+	SignalAlerts  = "alerts"
+	SignalDeploys = "deploys"
+)
+
+// Signals lists the signals in the order they are collected
+var Signals = []string{SignalLogs, SignalSpans, SignalAlerts, SignalDeploys}
+
+const (
+	defaultTimelineLogFilter     = "severity>=WARNING"
+	defaultTimelineMaxLogEntries = 200
+	defaultTimelineMaxTraces     = 10
+	defaultTimelineMaxEvents     = 500
+	maxTimelineAlerts            = 100
+)
+
+// TimelineRequest represents a request to merge the events of several
+// signals during a time window into one timeline
+type TimelineRequest struct {
+	ProjectID     string    `json:"project_id,omitempty"` // defaults to the clients' project
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Signals       []string  `json:"signals,omitempty"`      // defaults to all Signals
+	LogFilter     string    `json:"log_filter,omitempty"`   // defaults to warnings and above
+	TraceFilter   string    `json:"trace_filter,omitempty"` // selects the traces whose spans are included
+	MaxLogEntries int       `json:"max_log_entries,omitempty"`
+	MaxTraces     int       `json:"max_traces,omitempty"`
+	MaxEvents     int       `json:"max_events,omitempty"` // the earliest events are kept
+}
+
+// Timeline represents the events of several signals during a time window in
+// time order
+type Timeline struct {
+	StartTime time.Time         `json:"start_time"`
+	EndTime   time.Time         `json:"end_time"`
+	Signals   []string          `json:"signals"`
+	Counts    map[string]int    `json:"counts"`              // event source to the number of its events in the window
+	Truncated []string          `json:"truncated,omitempty"` // signals with more events than were examined, and "events" when MaxEvents was reached
+	Events    []TimelineEvent   `json:"timeline"`
+	Errors    map[string]string `json:"errors,omitempty"` // signal to the error that prevented collecting it
+}
+
+// BuildTimeline collects log entries, span starts and ends, alert openings
+// and closings, and deployments from the Admin Activity audit log and deploy
+// markers during the window concurrently, and merges them into one timeline. A signal that
+// fails is reported in Timeline.Errors instead of failing the whole timeline.
+func (g *Generator) BuildTimeline(ctx context.Context, req TimelineRequest) (Timeline, error) {
+	if req.StartTime.IsZero() || req.EndTime.IsZero() {
+		return Timeline{}, fmt.Errorf("start_time and end_time are required")
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return Timeline{}, fmt.Errorf("start_time must be before end_time")
+	}
+	if len(req.Signals) == 0 {
+		req.Signals = Signals
+	}
+	for _, signal := range req.Signals {
+		if !slices.Contains(Signals, signal) {
+			return Timeline{}, fmt.Errorf("unsupported signal %q: must be one of %s", signal, strings.Join(Signals, ", "))
+		}
+	}
+	if req.LogFilter == "" {
+		req.LogFilter = defaultTimelineLogFilter
+	}
+	if req.MaxLogEntries <= 0 {
+		req.MaxLogEntries = defaultTimelineMaxLogEntries
+	}
+	if req.MaxTraces <= 0 {
+		req.MaxTraces = defaultTimelineMaxTraces
+	}
+	if req.MaxEvents <= 0 {
+		req.MaxEvents = defaultTimelineMaxEvents
+	}
+
+	timeline := Timeline{
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Counts:    map[string]int{},
+		Events:    []TimelineEvent{},
+	}
+	for _, signal := range Signals {
+		if slices.Contains(req.Signals, signal) {
+			timeline.Signals = append(timeline.Signals, signal)
+		}
+	}
+
+	collectors := map[string]func(context.Context, TimelineRequest) ([]TimelineEvent, bool, error){
+		SignalLogs:    g.timelineLogs,
+		SignalSpans:   g.timelineSpans,
+		SignalAlerts:  g.timelineAlerts,
+		SignalDeploys: g.timelineDeploys,
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, signal := range timeline.Signals {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			events, truncated, err := collectors[signal](ctx, req)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if timeline.Errors == nil {
+					timeline.Errors = make(map[string]string)
+				}
+				timeline.Errors[signal] = err.Error()
+				return
+			}
+			if truncated {
+				timeline.Truncated = append(timeline.Truncated, signal)
+			}
+			timeline.Events = append(timeline.Events, events...)
+		}()
+	}
+	wg.Wait()
+
+	// Spans and alerts may start before the window, so only their events
+	// inside it are kept
+	timeline.Events = slices.DeleteFunc(timeline.Events, func(event TimelineEvent) bool {
+		return event.Time.Before(req.StartTime) || event.Time.After(req.EndTime)
+	})
+	sort.SliceStable(timeline.Events, func(i, j int) bool { return timeline.Events[i].Time.Before(timeline.Events[j].Time) })
+	for _, event := range timeline.Events {
+		timeline.Counts[event.Source]++
+	}
+	slices.SortFunc(timeline.Truncated, func(a, b string) int { return slices.Index(Signals, a) - slices.Index(Signals, b) })
+	if len(timeline.Events) > req.MaxEvents {
+		timeline.Events = timeline.Events[:req.MaxEvents]
+		timeline.Truncated = append(timeline.Truncated, "events")
+	}
+	return timeline, nil
+}
+
+// timelineLogs collects the log entries matching the filter
+func (g *Generator) timelineLogs(ctx context.Context, req TimelineRequest) ([]TimelineEvent, bool, error) {
+	filter := fmt.Sprintf("(%s) AND timestamp>=%q AND timestamp<=%q", req.LogFilter,
+		req.StartTime.UTC().Format(time.RFC3339Nano), req.EndTime.UTC().Format(time.RFC3339Nano))
+	resp, err := g.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		OrderBy:   logging.OrderByTimestampAsc,
+		Limit:     req.MaxLogEntries,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	var events []TimelineEvent
+	for _, entry := range resp.Entries {
+		events = append(events, TimelineEvent{
+			Time:     entry.Timestamp,
+			Source:   EventSourceLog,
+			Severity: entry.Severity,
+			Message:  entry.Message,
+			Resource: entryResourceType(entry),
+			TraceID:  entryTraceID(entry.Trace),
+			SpanID:   entry.SpanID,
+		})
+	}
+	return events, resp.NextPageToken != "", nil
+}
+
+// timelineSpans collects the starts and ends of the spans of the traces
+// matching the filter
+func (g *Generator) timelineSpans(ctx context.Context, req TimelineRequest) ([]TimelineEvent, bool, error) {
+	resp, err := g.trace.ListTraces(ctx, trace.ListTracesRequest{
+		ProjectID: req.ProjectID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Filter:    req.TraceFilter,
+		View:      trace.ViewComplete,
+		PageSize:  req.MaxTraces,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	var events []TimelineEvent
+	for _, t := range resp.Traces {
+		for _, span := range t.Spans {
+			event := TimelineEvent{
+				Source:   EventSourceSpanStart,
+				Time:     span.StartTime,
+				Message:  span.Name,
+				TraceID:  t.TraceID,
+				SpanID:   span.SpanID,
+				Duration: span.EndTime.Sub(span.StartTime).String(),
+			}
+			events = append(events, event)
+			event.Source = EventSourceSpanEnd
+			event.Time = span.EndTime
+			events = append(events, event)
+		}
+	}
+	return events, resp.NextPageToken != "", nil
+}
+
+// timelineAlerts collects the openings and closings of the alerts that were
+// open at any point in the window
+func (g *Generator) timelineAlerts(ctx context.Context, req TimelineRequest) ([]TimelineEvent, bool, error) {
+	resp, err := g.monitoring.ListAlerts(ctx, monitoring.ListAlertsRequest{
+		ProjectID: req.ProjectID,
+		Filter:    fmt.Sprintf("open_time <= %q", req.EndTime.UTC().Format(time.RFC3339Nano)),
+		OrderBy:   "open_time desc",
+		PageSize:  maxTimelineAlerts,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	var events []TimelineEvent
+	for _, alert := range resp.Alerts {
+		if alert.CloseTime != nil && alert.CloseTime.Before(req.StartTime) {
+			continue
+		}
+		event := TimelineEvent{
+			Source:   EventSourceAlertOpen,
+			Time:     alert.OpenTime,
+			Severity: alert.Severity,
+			Message:  alertMessage(alert),
+			Resource: alert.ResourceType,
+		}
+		events = append(events, event)
+		if alert.CloseTime != nil {
+			event.Source = EventSourceAlertClose
+			event.Time = *alert.CloseTime
+			events = append(events, event)
+		}
+	}
+	return events, resp.NextPageToken != "", nil
+}
+
+// alertMessage describes an alert by its policy and the metric it fired on
+func alertMessage(alert monitoring.Alert) string {
+	message := alert.PolicyDisplayName
+	if message == "" {
+		message = alert.PolicyName
+	}
+	if message == "" {
+		message = alert.Name
+	}
+	if alert.MetricType != "" {
+		message += " (" + alert.MetricType + ")"
+	}
+	return message
+}
+
+// timelineDeploys collects the deployments in the Admin Activity audit log
+// and the recorded deploy markers
+func (g *Generator) timelineDeploys(ctx context.Context, req TimelineRequest) ([]TimelineEvent, bool, error) {
+	filter, err := logging.AuditLogFilter{
+		LogType:   logging.AuditLogTypeActivity,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+	}.Build()
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := g.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		OrderBy:   logging.OrderByTimestampAsc,
+		Limit:     maxChangeLogEntries,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	var events []TimelineEvent
+	for _, entry := range resp.Entries {
+		if entry.AuditLog == nil || changeCategory(entry.AuditLog) != ChangeCategoryDeployment {
+			continue
+		}
+		events = append(events, TimelineEvent{
+			Time:     entry.Timestamp,
+			Source:   EventSourceDeploy,
+			Message:  deployMessage(entry.AuditLog),
+			Resource: entryResourceType(entry),
+		})
+	}
+
+	// Deployments the audit logs miss, e.g. by CI systems, are recorded as markers
+	markers, err := g.logging.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    logging.DeployMarkerFilter{StartTime: req.StartTime, EndTime: req.EndTime}.Build(),
+		OrderBy:   logging.OrderByTimestampAsc,
+		Limit:     maxChangeLogEntries,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list deploy markers: %w", err)
+	}
+	for _, entry := range markers.Entries {
+		events = append(events, TimelineEvent{
+			Time:    entry.Timestamp,
+			Source:  EventSourceDeploy,
+			Message: logging.NewDeployMarker(entry).Message(),
+		})
+	}
+	return events, resp.NextPageToken != "" || markers.NextPageToken != "", nil
+}
+
+// deployMessage describes a deployment by what was deployed and by whom
+func deployMessage(auditLog *logging.AuditLog) string {
+	message := auditLog.MethodName
+	if auditLog.ResourceName != "" {
+		message += " " + auditLog.ResourceName
+	}
+	if auditLog.PrincipalEmail != "" {
+		message += " by " + auditLog.PrincipalEmail
+	}
+	if auditLog.Status != nil && auditLog.Status.Code != 0 {
+		message += " (failed)"
+	}
+	return message
+}
@@ -0,0 +1,122 @@
+package incident_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/incident"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+func TestGenerator_BuildTimeline(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	closed := at(50)
+
+	backend := fake.New("test-project")
+	err := backend.Load(fake.Seed{
+		LogEntries: []fake.SeedLogEntry{
+			{LogName: "app", LogEntry: logging.LogEntry{Timestamp: at(20), Severity: "ERROR", Message: "payment declined"}},
+			{LogName: "app", LogEntry: logging.LogEntry{Timestamp: at(21), Severity: "INFO", Message: "order received"}},
+			{LogName: "app", LogEntry: logging.LogEntry{Timestamp: at(90), Severity: "ERROR", Message: "after the window"}},
+			{LogName: "cloudaudit.googleapis.com/activity", LogEntry: logging.LogEntry{Timestamp: at(10), AuditLog: &logging.AuditLog{
+				ServiceName:    "run.googleapis.com",
+				MethodName:     "google.cloud.run.v1.Services.ReplaceService",
+				ResourceName:   "namespaces/test-project/services/checkout",
+				PrincipalEmail: "deployer@example.com",
+			}}},
+			{LogName: "cloudaudit.googleapis.com/activity", LogEntry: logging.LogEntry{Timestamp: at(12), AuditLog: &logging.AuditLog{
+				ServiceName: "compute.googleapis.com",
+				MethodName:  "v1.compute.firewalls.patch",
+			}}},
+			{LogName: logging.DeployMarkerLogID, LogEntry: logging.DeployMarker{Timestamp: at(15), Service: "payments", Version: "v7"}.Entry()},
+		},
+		Alerts: []monitoring.Alert{
+			{OpenTime: at(25), CloseTime: &closed, PolicyDisplayName: "Checkout errors", Severity: "CRITICAL"},
+			{OpenTime: at(-30), PolicyDisplayName: "Still open", Severity: "WARNING"},
+		},
+		Traces: []trace.Trace{{ProjectID: "test-project", TraceID: "aaa", Spans: []trace.Span{
+			{SpanID: "1", Name: "POST /orders", StartTime: at(19), EndTime: at(22)},
+		}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator := incident.NewGenerator(
+		logging.NewWithClient(backend.Logging),
+		monitoring.NewWithClient(backend.Monitoring, "test-project"),
+		trace.NewWithClient(backend.Trace, "test-project"),
+	)
+	timeline, err := generator.BuildTimeline(ctx, incident.TimelineRequest{StartTime: start, EndTime: at(60)})
+	if err != nil {
+		t.Fatalf("BuildTimeline() error = %v", err)
+	}
+	if len(timeline.Errors) > 0 {
+		t.Fatalf("Unexpected errors %v", timeline.Errors)
+	}
+
+	var order []string
+	for _, event := range timeline.Events {
+		order = append(order, event.Source+":"+event.Message)
+	}
+	want := []string{
+		"deploy:google.cloud.run.v1.Services.ReplaceService namespaces/test-project/services/checkout by deployer@example.com",
+		"deploy:deployed payments v7",
+		"span_start:POST /orders",
+		"log:payment declined",
+		"span_end:POST /orders",
+		"alert_open:Checkout errors",
+		"alert_close:Checkout errors",
+	}
+	if got := strings.Join(order, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("Expected the timeline\n%s\ngot\n%s", strings.Join(want, "\n"), got)
+	}
+	if timeline.Counts[incident.EventSourceLog] != 1 || timeline.Counts[incident.EventSourceAlertOpen] != 1 {
+		t.Errorf("Unexpected counts %v", timeline.Counts)
+	}
+
+	// Only the selected signals are collected, and the earliest events kept
+	timeline, err = generator.BuildTimeline(ctx, incident.TimelineRequest{
+		StartTime: start,
+		EndTime:   at(60),
+		Signals:   []string{incident.SignalLogs},
+		LogFilter: `log_id("app")`,
+		MaxEvents: 1,
+	})
+	if err != nil {
+		t.Fatalf("BuildTimeline() error = %v", err)
+	}
+	if len(timeline.Events) != 1 || timeline.Events[0].Message != "payment declined" || strings.Join(timeline.Truncated, ",") != "events" {
+		t.Errorf("Expected only the first log entry, got %+v (truncated %v)", timeline.Events, timeline.Truncated)
+	}
+	if timeline.Counts[incident.EventSourceLog] != 2 {
+		t.Errorf("Expected both log entries to be counted, got %v", timeline.Counts)
+	}
+}
+
+func TestGenerator_BuildTimeline_Errors(t *testing.T) {
+	generator := incident.NewGenerator(nil, nil, nil)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		req     incident.TimelineRequest
+		wantErr string
+	}{
+		{req: incident.TimelineRequest{}, wantErr: "start_time and end_time are required"},
+		{req: incident.TimelineRequest{StartTime: start, EndTime: start}, wantErr: "start_time must be before end_time"},
+		{req: incident.TimelineRequest{StartTime: start, EndTime: start.Add(time.Hour), Signals: []string{"metrics"}}, wantErr: `unsupported signal "metrics"`},
+	}
+	for _, tt := range tests {
+		_, err := generator.BuildTimeline(context.Background(), tt.req)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("BuildTimeline(%+v) error = %v, want %q", tt.req, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package testserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxLogEntryBytes is the size above which Cloud Logging rejects entries
+const maxLogEntryBytes = 256 * 1024
+
+// LoggingServer fakes the WriteLogEntries and ListLogEntries methods of the
+// Cloud Logging API, with the filters supported by the fake backend
+type LoggingServer struct {
+	loggingpb.UnimplementedLoggingServiceV2Server
+
+	mu      sync.Mutex
+	entries []*loggingpb.LogEntry
+	nextID  int
+}
+
+// NewLoggingServer creates an empty LoggingServer
+func NewLoggingServer() *LoggingServer {
+	return &LoggingServer{}
+}
+
+// Entries returns copies of the entries written to the server
+func (s *LoggingServer) Entries() []*loggingpb.LogEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]*loggingpb.LogEntry, len(s.entries))
+	for i, e := range s.entries {
+		entries[i] = proto.Clone(e).(*loggingpb.LogEntry)
+	}
+	return entries
+}
+
+// WriteLogEntries implements loggingpb.LoggingServiceV2Server
+func (s *LoggingServer) WriteLogEntries(ctx context.Context, req *loggingpb.WriteLogEntriesRequest) (*loggingpb.WriteLogEntriesResponse, error) {
+	now := timestamppb.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range req.GetEntries() {
+		e = proto.Clone(e).(*loggingpb.LogEntry)
+		if e.LogName == "" {
+			e.LogName = req.GetLogName()
+		}
+		if !strings.Contains(e.LogName, "/logs/") {
+			return nil, status.Errorf(codes.InvalidArgument, "entry %d has no valid log_name", i)
+		}
+		if size := proto.Size(e); size > maxLogEntryBytes {
+			return nil, status.Errorf(codes.InvalidArgument, "Log entry with size %d bytes exceeds maximum size of %d bytes", size, maxLogEntryBytes)
+		}
+		if e.Resource == nil {
+			e.Resource = req.GetResource()
+		}
+		if len(req.GetLabels()) > 0 {
+			labels := maps.Clone(req.GetLabels())
+			maps.Copy(labels, e.Labels)
+			e.Labels = labels
+		}
+		if e.Timestamp == nil {
+			e.Timestamp = now
+		}
+		e.ReceiveTimestamp = now
+		if e.InsertId == "" {
+			s.nextID++
+			e.InsertId = fmt.Sprintf("testserver-%d", s.nextID)
+		}
+		s.entries = append(s.entries, e)
+	}
+	return &loggingpb.WriteLogEntriesResponse{}, nil
+}
+
+// ListLogEntries implements loggingpb.LoggingServiceV2Server
+func (s *LoggingServer) ListLogEntries(ctx context.Context, req *loggingpb.ListLogEntriesRequest) (*loggingpb.ListLogEntriesResponse, error) {
+	filter, err := fake.ParseFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+
+	s.mu.Lock()
+	var entries []*loggingpb.LogEntry
+	for _, e := range s.entries {
+		inResource := slices.ContainsFunc(req.GetResourceNames(), func(name string) bool {
+			return strings.HasPrefix(e.LogName, name+"/logs/")
+		})
+		if inResource && filter.Match(entryField(e), entryTexts(e)...) {
+			entries = append(entries, proto.Clone(e).(*loggingpb.LogEntry))
+		}
+	}
+	s.mu.Unlock()
+
+	slices.SortStableFunc(entries, func(a, b *loggingpb.LogEntry) int {
+		return a.Timestamp.AsTime().Compare(b.Timestamp.AsTime())
+	})
+	if strings.EqualFold(strings.TrimSpace(req.GetOrderBy()), "timestamp desc") {
+		slices.Reverse(entries)
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	entries, next, err := page(entries, pageSize, req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	return &loggingpb.ListLogEntriesResponse{Entries: entries, NextPageToken: next}, nil
+}
+
+// entryField returns the field function of an entry for filters
+func entryField(e *loggingpb.LogEntry) func(string) (string, bool) {
+	entry := logging.LogEntry{
+		Severity:     e.Severity.String(),
+		Message:      e.GetTextPayload(),
+		Labels:       e.Labels,
+		InsertID:     e.InsertId,
+		Trace:        e.Trace,
+		SpanID:       e.SpanId,
+		TraceSampled: e.TraceSampled,
+	}
+	if payload := e.GetJsonPayload(); payload != nil {
+		entry.Payload = payload.AsMap()
+		if message, ok := entry.Payload["message"].(string); ok {
+			entry.Message = message
+		}
+	}
+	if e.Resource != nil {
+		entry.Resource = &logging.MonitoredResource{Type: e.Resource.Type, Labels: e.Resource.Labels}
+	}
+
+	return func(name string) (string, bool) {
+		switch name {
+		case "logName":
+			return e.LogName, true
+		case "timestamp":
+			return e.Timestamp.AsTime().Format(time.RFC3339Nano), true
+		case "receiveTimestamp":
+			return e.ReceiveTimestamp.AsTime().Format(time.RFC3339Nano), true
+		}
+		value, err := logging.FieldValue(entry, name)
+		if err != nil || value == nil {
+			return "", false
+		}
+		return *value, true
+	}
+}
+
+// entryTexts returns the values of an entry matched by restrictions without a field
+func entryTexts(e *loggingpb.LogEntry) []string {
+	texts := []string{e.GetTextPayload()}
+	for _, v := range e.Labels {
+		texts = append(texts, v)
+	}
+	if payload := e.GetJsonPayload(); payload != nil {
+		if data, err := json.Marshal(payload.AsMap()); err == nil {
+			texts = append(texts, string(data))
+		}
+	}
+	return texts
+}
@@ -0,0 +1,236 @@
+package testserver
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"google.golang.org/genproto/googleapis/api/metric"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// MetricServer fakes the metric descriptor and time series methods of the
+// Cloud Monitoring API, with the filters supported by the fake backend.
+// Aggregations are not applied: the written points are returned as is.
+type MetricServer struct {
+	monitoringpb.UnimplementedMetricServiceServer
+
+	mu          sync.Mutex
+	descriptors []*metric.MetricDescriptor
+	series      []storedSeries
+}
+
+// storedSeries is a time series of a project, with its points newest first
+// as returned by the API
+type storedSeries struct {
+	project string // projects/PROJECT_ID
+	series  *monitoringpb.TimeSeries
+}
+
+// NewMetricServer creates an empty MetricServer
+func NewMetricServer() *MetricServer {
+	return &MetricServer{}
+}
+
+// TimeSeries returns copies of the time series written to the server
+func (s *MetricServer) TimeSeries() []*monitoringpb.TimeSeries {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series := make([]*monitoringpb.TimeSeries, len(s.series))
+	for i, ts := range s.series {
+		series[i] = proto.Clone(ts.series).(*monitoringpb.TimeSeries)
+	}
+	return series
+}
+
+// CreateMetricDescriptor implements monitoringpb.MetricServiceServer
+func (s *MetricServer) CreateMetricDescriptor(ctx context.Context, req *monitoringpb.CreateMetricDescriptorRequest) (*metric.MetricDescriptor, error) {
+	if req.GetMetricDescriptor().GetType() == "" {
+		return nil, status.Error(codes.InvalidArgument, "metric descriptor type is required")
+	}
+	d := proto.Clone(req.GetMetricDescriptor()).(*metric.MetricDescriptor)
+	d.Name = req.GetName() + "/metricDescriptors/" + d.Type
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteDescriptor(d.Name)
+	s.descriptors = append(s.descriptors, d)
+	return proto.Clone(d).(*metric.MetricDescriptor), nil
+}
+
+// GetMetricDescriptor implements monitoringpb.MetricServiceServer
+func (s *MetricServer) GetMetricDescriptor(ctx context.Context, req *monitoringpb.GetMetricDescriptorRequest) (*metric.MetricDescriptor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.descriptors {
+		if d.Name == req.GetName() {
+			return proto.Clone(d).(*metric.MetricDescriptor), nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "metric descriptor %s does not exist", req.GetName())
+}
+
+// ListMetricDescriptors implements monitoringpb.MetricServiceServer
+func (s *MetricServer) ListMetricDescriptors(ctx context.Context, req *monitoringpb.ListMetricDescriptorsRequest) (*monitoringpb.ListMetricDescriptorsResponse, error) {
+	filter, err := fake.ParseFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+
+	s.mu.Lock()
+	var descriptors []*metric.MetricDescriptor
+	for _, d := range s.descriptors {
+		if !strings.HasPrefix(d.Name, req.GetName()+"/") {
+			continue
+		}
+		field := func(name string) (string, bool) {
+			if name == "metric.type" {
+				return d.Type, true
+			}
+			return "", false
+		}
+		if filter.Match(field, d.Type, d.DisplayName, d.Description) {
+			descriptors = append(descriptors, proto.Clone(d).(*metric.MetricDescriptor))
+		}
+	}
+	s.mu.Unlock()
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	descriptors, next, err := page(descriptors, pageSize, req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	return &monitoringpb.ListMetricDescriptorsResponse{MetricDescriptors: descriptors, NextPageToken: next}, nil
+}
+
+// DeleteMetricDescriptor implements monitoringpb.MetricServiceServer
+func (s *MetricServer) DeleteMetricDescriptor(ctx context.Context, req *monitoringpb.DeleteMetricDescriptorRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.deleteDescriptor(req.GetName()) {
+		return nil, status.Errorf(codes.NotFound, "metric descriptor %s does not exist", req.GetName())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// deleteDescriptor deletes a descriptor by name, and reports whether it
+// existed. The lock must be held.
+func (s *MetricServer) deleteDescriptor(name string) bool {
+	for i, d := range s.descriptors {
+		if d.Name == name {
+			s.descriptors = append(s.descriptors[:i], s.descriptors[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// CreateTimeSeries implements monitoringpb.MetricServiceServer
+func (s *MetricServer) CreateTimeSeries(ctx context.Context, req *monitoringpb.CreateTimeSeriesRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ts := range req.GetTimeSeries() {
+		if ts.GetMetric().GetType() == "" || len(ts.GetPoints()) != 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "time series %d must have a metric type and exactly one point", i)
+		}
+		if existing := s.findSeries(req.GetName(), ts); existing != nil {
+			existing.Points = append([]*monitoringpb.Point{proto.Clone(ts.Points[0]).(*monitoringpb.Point)}, existing.Points...)
+			continue
+		}
+		s.series = append(s.series, storedSeries{project: req.GetName(), series: proto.Clone(ts).(*monitoringpb.TimeSeries)})
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// findSeries returns the stored time series of a project with the same
+// metric and resource as ts, or nil. The lock must be held.
+func (s *MetricServer) findSeries(project string, ts *monitoringpb.TimeSeries) *monitoringpb.TimeSeries {
+	for _, stored := range s.series {
+		if stored.project == project &&
+			stored.series.GetMetric().GetType() == ts.GetMetric().GetType() &&
+			maps.Equal(stored.series.GetMetric().GetLabels(), ts.GetMetric().GetLabels()) &&
+			stored.series.GetResource().GetType() == ts.GetResource().GetType() &&
+			maps.Equal(stored.series.GetResource().GetLabels(), ts.GetResource().GetLabels()) {
+			return stored.series
+		}
+	}
+	return nil
+}
+
+// ListTimeSeries implements monitoringpb.MetricServiceServer
+func (s *MetricServer) ListTimeSeries(ctx context.Context, req *monitoringpb.ListTimeSeriesRequest) (*monitoringpb.ListTimeSeriesResponse, error) {
+	filter, err := fake.ParseFilter(req.GetFilter())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	start, end := req.GetInterval().GetStartTime().AsTime(), req.GetInterval().GetEndTime().AsTime()
+
+	s.mu.Lock()
+	var series []*monitoringpb.TimeSeries
+	for _, stored := range s.series {
+		if stored.project != req.GetName() || !filter.Match(seriesField(stored.series), stored.series.GetMetric().GetType()) {
+			continue
+		}
+		ts := proto.Clone(stored.series).(*monitoringpb.TimeSeries)
+		ts.Points = nil
+		for _, p := range stored.series.Points {
+			t := p.GetInterval().GetEndTime().AsTime()
+			if t.After(start) && !t.After(end) {
+				ts.Points = append(ts.Points, proto.Clone(p).(*monitoringpb.Point))
+			}
+		}
+		if len(ts.Points) == 0 {
+			continue
+		}
+		if req.GetView() == monitoringpb.ListTimeSeriesRequest_HEADERS {
+			ts.Points = nil
+		}
+		series = append(series, ts)
+	}
+	s.mu.Unlock()
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = 10000
+	}
+	series, next, err := page(series, pageSize, req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	return &monitoringpb.ListTimeSeriesResponse{TimeSeries: series, NextPageToken: next}, nil
+}
+
+// seriesField returns the field function of a time series for filters, e.g.
+// for metric.type or resource.labels.zone
+func seriesField(ts *monitoringpb.TimeSeries) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		switch name {
+		case "metric.type":
+			return ts.GetMetric().GetType(), true
+		case "resource.type":
+			return ts.GetResource().GetType(), true
+		}
+		for _, prefix := range []string{"metric.labels.", "metric.label."} {
+			if key, ok := strings.CutPrefix(name, prefix); ok {
+				v, ok := ts.GetMetric().GetLabels()[key]
+				return v, ok
+			}
+		}
+		for _, prefix := range []string{"resource.labels.", "resource.label."} {
+			if key, ok := strings.CutPrefix(name, prefix); ok {
+				v, ok := ts.GetResource().GetLabels()[key]
+				return v, ok
+			}
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,72 @@
+// Package testserver serves in-memory fakes of the Cloud Logging, Cloud
+// Monitoring, and Cloud Trace gRPC APIs, so that integration tests can run
+// the real clients against them by setting LOGGING_EMULATOR_HOST,
+// MONITORING_EMULATOR_HOST, and TRACE_EMULATOR_HOST to the server's address.
+package testserver
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"cloud.google.com/go/trace/apiv1/tracepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server serves the fakes on a local port
+type Server struct {
+	Addr       string // host:port to set in the emulator environment variables
+	Logging    *LoggingServer
+	Monitoring *MetricServer
+	Trace      *TraceServer
+	server     *grpc.Server
+}
+
+// Start starts a Server on a free local port
+func Start() (*Server, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s := &Server{
+		Addr:       lis.Addr().String(),
+		Logging:    NewLoggingServer(),
+		Monitoring: NewMetricServer(),
+		Trace:      NewTraceServer(),
+		server:     grpc.NewServer(),
+	}
+	loggingpb.RegisterLoggingServiceV2Server(s.server, s.Logging)
+	monitoringpb.RegisterMetricServiceServer(s.server, s.Monitoring)
+	tracepb.RegisterTraceServiceServer(s.server, s.Trace)
+	go s.server.Serve(lis)
+	return s, nil
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.server.Stop()
+}
+
+// page returns the items of the page of size n at the offset encoded in
+// pageToken, and the token of the next page, or "" on the last page
+func page[T any](items []T, n int, pageToken string) ([]T, string, error) {
+	offset := 0
+	if pageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(pageToken)
+		if err != nil || offset < 0 || offset > len(items) {
+			return nil, "", status.Error(codes.InvalidArgument, "page_token is invalid")
+		}
+	}
+	end := min(offset+n, len(items))
+	next := ""
+	if end < len(items) {
+		next = strconv.Itoa(end)
+	}
+	return items[offset:end], next, nil
+}
@@ -0,0 +1,177 @@
+package testserver_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/internal/testserver"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+// startServer starts a test server and points the clients at it
+func startServer(t *testing.T) *testserver.Server {
+	t.Helper()
+	srv, err := testserver.Start()
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(srv.Close)
+	t.Setenv("LOGGING_EMULATOR_HOST", srv.Addr)
+	t.Setenv("MONITORING_EMULATOR_HOST", srv.Addr)
+	t.Setenv("TRACE_EMULATOR_HOST", srv.Addr)
+	return srv
+}
+
+func TestServer_Logging(t *testing.T) {
+	srv := startServer(t)
+	ctx := context.Background()
+
+	client, err := logging.New("test-project")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Now()
+	err = client.WriteEntries(ctx, logging.WriteEntriesRequest{
+		LogName: "app",
+		Entries: []logging.LogEntry{
+			{Severity: "INFO", Message: "started", Timestamp: now.Add(-time.Minute)},
+			{Severity: "ERROR", Message: "failed", Timestamp: now, Labels: map[string]string{"env": "test"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteEntries() error = %v", err)
+	}
+	// The client library also writes a diagnostic-log entry once per process
+	written := 0
+	for _, e := range srv.Logging.Entries() {
+		if e.LogName == "projects/test-project/logs/app" {
+			written++
+		}
+	}
+	if written != 2 {
+		t.Fatalf("Expected 2 entries written to the server, got %d", written)
+	}
+
+	resp, err := client.ListEntries(ctx, logging.ListEntriesRequest{Filter: `severity>=ERROR AND labels.env="test"`})
+	if err != nil {
+		t.Fatalf("ListEntries() error = %v", err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Message != "failed" {
+		t.Errorf("Expected the error entry, got %+v", resp.Entries)
+	}
+}
+
+func TestServer_LoggingRejectedEntry(t *testing.T) {
+	startServer(t)
+	ctx := context.Background()
+
+	client, err := logging.New("test-project")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// The API rejects entries larger than 256 KiB, and the error must reach the caller
+	err = client.WriteEntry(ctx, "app", logging.LogEntry{Severity: "INFO", Message: strings.Repeat("x", 300*1024)})
+	if err == nil || !strings.Contains(err.Error(), "exceeds maximum size") {
+		t.Errorf("Expected the rejection of the entry, got %v", err)
+	}
+	if err := client.WriteEntry(ctx, "app", logging.LogEntry{Severity: "INFO", Message: "started"}); err != nil {
+		t.Errorf("WriteEntry() error = %v", err)
+	}
+}
+
+func TestServer_LoggingPartialWrite(t *testing.T) {
+	srv := startServer(t)
+	ctx := context.Background()
+
+	client, err := logging.New("test-project")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	err = client.WriteEntries(ctx, logging.WriteEntriesRequest{
+		LogName: "batch",
+		Entries: []logging.LogEntry{
+			{Severity: "INFO", Message: "first"},
+			{Severity: "INFO", Message: strings.Repeat("x", 300*1024)},
+			{Severity: "INFO", Message: "third"},
+		},
+	})
+	var partial *logging.PartialWriteError
+	if !errors.As(err, &partial) {
+		t.Fatalf("Expected a *PartialWriteError, got %v", err)
+	}
+	if partial.Written != 2 || partial.Failed != 1 || len(partial.Errors) != 1 || partial.Errors[0].Index != 1 {
+		t.Errorf("Expected the second entry to fail, got %+v", partial)
+	}
+
+	// The entries after the failed one are written
+	written := 0
+	for _, e := range srv.Logging.Entries() {
+		if e.LogName == "projects/test-project/logs/batch" {
+			written++
+		}
+	}
+	if written != 2 {
+		t.Errorf("Expected 2 entries written to the server, got %d", written)
+	}
+}
+
+func TestServer_Monitoring(t *testing.T) {
+	startServer(t)
+	ctx := context.Background()
+
+	client, err := monitoring.New("test-project")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Now()
+	err = client.WriteTimeSeries(ctx, monitoring.WriteTimeSeriesRequest{
+		TimeSeries: []monitoring.TimeSeriesData{
+			{MetricType: "custom.googleapis.com/queue_depth", ResourceType: "global", Values: []monitoring.MetricValue{{Value: 42, Timestamp: now}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteTimeSeries() error = %v", err)
+	}
+
+	req := monitoring.ListTimeSeriesRequest{Filter: `metric.type="custom.googleapis.com/queue_depth"`}
+	req.Interval.StartTime = now.Add(-time.Hour)
+	req.Interval.EndTime = now.Add(time.Minute)
+	resp, err := client.ListTimeSeries(ctx, req)
+	if err != nil {
+		t.Fatalf("ListTimeSeries() error = %v", err)
+	}
+	if len(resp.TimeSeries) != 1 || len(resp.TimeSeries[0].Values) != 1 || resp.TimeSeries[0].Values[0].Value != 42 {
+		t.Errorf("Expected the written point, got %+v", resp.TimeSeries)
+	}
+}
+
+func TestServer_Trace(t *testing.T) {
+	startServer(t)
+	ctx := context.Background()
+
+	client, err := trace.New("test-project")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Now()
+	err = client.PatchTraces(ctx, trace.PatchTraceRequest{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		Spans:   []trace.Span{{SpanID: "1", Name: "GET /orders", StartTime: now.Add(-time.Second), EndTime: now}},
+	})
+	if err != nil {
+		t.Fatalf("PatchTraces() error = %v", err)
+	}
+
+	got, err := client.GetTrace(ctx, trace.GetTraceRequest{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	if err != nil {
+		t.Fatalf("GetTrace() error = %v", err)
+	}
+	if len(got.Spans) != 1 || got.Spans[0].Name != "GET /orders" {
+		t.Errorf("Expected the patched span, got %+v", got.Spans)
+	}
+}
@@ -0,0 +1,132 @@
+package testserver
+
+import (
+	"context"
+	"sync"
+
+	"cloud.google.com/go/trace/apiv1/tracepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TraceServer fakes the Cloud Trace API. Traces are listed by time range
+// and view only: filters are not supported.
+type TraceServer struct {
+	tracepb.UnimplementedTraceServiceServer
+
+	mu     sync.Mutex
+	traces []*tracepb.Trace
+}
+
+// NewTraceServer creates an empty TraceServer
+func NewTraceServer() *TraceServer {
+	return &TraceServer{}
+}
+
+// PatchTraces implements tracepb.TraceServiceServer. Spans are added to
+// their trace, replacing the spans with the same ID.
+func (s *TraceServer) PatchTraces(ctx context.Context, req *tracepb.PatchTracesRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, patch := range req.GetTraces().GetTraces() {
+		t := s.findTrace(req.GetProjectId(), patch.GetTraceId())
+		if t == nil {
+			t = &tracepb.Trace{ProjectId: req.GetProjectId(), TraceId: patch.GetTraceId()}
+			s.traces = append(s.traces, t)
+		}
+		for _, span := range patch.GetSpans() {
+			span = proto.Clone(span).(*tracepb.TraceSpan)
+			replaced := false
+			for i, existing := range t.Spans {
+				if existing.SpanId == span.SpanId {
+					t.Spans[i] = span
+					replaced = true
+				}
+			}
+			if !replaced {
+				t.Spans = append(t.Spans, span)
+			}
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// findTrace returns a stored trace, or nil. The lock must be held.
+func (s *TraceServer) findTrace(projectID, traceID string) *tracepb.Trace {
+	for _, t := range s.traces {
+		if t.ProjectId == projectID && t.TraceId == traceID {
+			return t
+		}
+	}
+	return nil
+}
+
+// GetTrace implements tracepb.TraceServiceServer
+func (s *TraceServer) GetTrace(ctx context.Context, req *tracepb.GetTraceRequest) (*tracepb.Trace, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.findTrace(req.GetProjectId(), req.GetTraceId())
+	if t == nil {
+		return nil, status.Errorf(codes.NotFound, "trace %s does not exist", req.GetTraceId())
+	}
+	return proto.Clone(t).(*tracepb.Trace), nil
+}
+
+// ListTraces implements tracepb.TraceServiceServer
+func (s *TraceServer) ListTraces(ctx context.Context, req *tracepb.ListTracesRequest) (*tracepb.ListTracesResponse, error) {
+	if req.GetFilter() != "" {
+		return nil, status.Error(codes.Unimplemented, "filters are not supported by the test server")
+	}
+
+	s.mu.Lock()
+	var traces []*tracepb.Trace
+	for _, t := range s.traces {
+		if t.ProjectId != req.GetProjectId() || !inRange(t, req) {
+			continue
+		}
+		t = proto.Clone(t).(*tracepb.Trace)
+		switch req.GetView() {
+		case tracepb.ListTracesRequest_COMPLETE:
+		case tracepb.ListTracesRequest_ROOTSPAN:
+			var roots []*tracepb.TraceSpan
+			for _, span := range t.Spans {
+				if span.ParentSpanId == 0 {
+					roots = append(roots, span)
+				}
+			}
+			t.Spans = roots
+		default: // MINIMAL
+			t.Spans = nil
+		}
+		traces = append(traces, t)
+	}
+	s.mu.Unlock()
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	traces, next, err := page(traces, pageSize, req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	return &tracepb.ListTracesResponse{Traces: traces, NextPageToken: next}, nil
+}
+
+// inRange reports whether a span of a trace ends in the time range of a
+// request. Unset bounds are open.
+func inRange(t *tracepb.Trace, req *tracepb.ListTracesRequest) bool {
+	for _, span := range t.Spans {
+		end := span.GetEndTime().AsTime()
+		if req.GetStartTime() != nil && end.Before(req.GetStartTime().AsTime()) {
+			continue
+		}
+		if req.GetEndTime() != nil && end.After(req.GetEndTime().AsTime()) {
+			continue
+		}
+		return true
+	}
+	return false
+}
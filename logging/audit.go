@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/cloud/audit"
+)
+
+// Audit log types, named after the log IDs under cloudaudit.googleapis.com
+const (
+	AuditLogTypeActivity    = "activity"
+	AuditLogTypeDataAccess  = "data_access"
+	AuditLogTypeSystemEvent = "system_event"
+	AuditLogTypePolicy      = "policy"
+)
+
+// AuditLog represents the decoded protoPayload of a Cloud Audit Logs entry
+type AuditLog struct {
+	ServiceName             string              `json:"service_name,omitempty"`
+	MethodName              string              `json:"method_name,omitempty"`
+	ResourceName            string              `json:"resource_name,omitempty"`
+	ResourceLocations       []string            `json:"resource_locations,omitempty"`
+	PrincipalEmail          string              `json:"principal_email,omitempty"`
+	PrincipalSubject        string              `json:"principal_subject,omitempty"` // e.g. a workload identity pool subject without an email
+	ServiceAccountKeyName   string              `json:"service_account_key_name,omitempty"`
+	DelegationChain         []string            `json:"delegation_chain,omitempty"` // principals that impersonated the service account, outermost first
+	CallerIP                string              `json:"caller_ip,omitempty"`
+	CallerNetwork           string              `json:"caller_network,omitempty"`
+	CallerSuppliedUserAgent string              `json:"caller_supplied_user_agent,omitempty"`
+	Status                  *AuditStatus        `json:"status,omitempty"`
+	AuthorizationInfo       []AuthorizationInfo `json:"authorization_info,omitempty"`
+	NumResponseItems        int64               `json:"num_response_items,omitempty"`
+	Request                 map[string]any      `json:"request,omitempty"`
+	Response                map[string]any      `json:"response,omitempty"`
+	Metadata                map[string]any      `json:"metadata,omitempty"`
+}
+
+// AuditStatus represents the outcome of an audited operation
+type AuditStatus struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// AuthorizationInfo represents a single authorization check of an audited operation
+type AuthorizationInfo struct {
+	Resource   string `json:"resource,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	Granted    bool   `json:"granted"`
+}
+
+// AuditLogFilter represents structured conditions for selecting audit log entries
+type AuditLogFilter struct {
+	LogType        string    `json:"log_type,omitempty"` // one of the AuditLogType constants; all audit logs when empty
+	ServiceName    string    `json:"service_name,omitempty"`
+	MethodName     string    `json:"method_name,omitempty"` // matched as a substring
+	PrincipalEmail string    `json:"principal_email,omitempty"`
+	CallerIP       string    `json:"caller_ip,omitempty"`
+	ResourceName   string    `json:"resource_name,omitempty"` // matched as a substring
+	StartTime      time.Time `json:"start_time,omitempty"`
+	EndTime        time.Time `json:"end_time,omitempty"`
+	Filter         string    `json:"filter,omitempty"` // additional logging filter ANDed with the conditions
+}
+
+// Build returns the Cloud Logging filter expression for the conditions
+func (f AuditLogFilter) Build() (string, error) {
+	var conditions []string
+
+	switch f.LogType {
+	case "":
+		conditions = append(conditions, `logName:"cloudaudit.googleapis.com"`)
+	case AuditLogTypeActivity, AuditLogTypeDataAccess, AuditLogTypeSystemEvent, AuditLogTypePolicy:
+		conditions = append(conditions, fmt.Sprintf("log_id(%q)", "cloudaudit.googleapis.com/"+f.LogType))
+	default:
+		return "", fmt.Errorf("unsupported audit log type %q: must be %q, %q, %q or %q",
+			f.LogType, AuditLogTypeActivity, AuditLogTypeDataAccess, AuditLogTypeSystemEvent, AuditLogTypePolicy)
+	}
+
+	if f.ServiceName != "" {
+		conditions = append(conditions, fmt.Sprintf("protoPayload.serviceName=%q", f.ServiceName))
+	}
+	if f.MethodName != "" {
+		conditions = append(conditions, fmt.Sprintf("protoPayload.methodName:%q", f.MethodName))
+	}
+	if f.PrincipalEmail != "" {
+		conditions = append(conditions, fmt.Sprintf("protoPayload.authenticationInfo.principalEmail=%q", f.PrincipalEmail))
+	}
+	if f.CallerIP != "" {
+		conditions = append(conditions, fmt.Sprintf("protoPayload.requestMetadata.callerIp=%q", f.CallerIP))
+	}
+	if f.ResourceName != "" {
+		conditions = append(conditions, fmt.Sprintf("protoPayload.resourceName:%q", f.ResourceName))
+	}
+	if !f.StartTime.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("timestamp>=%q", f.StartTime.UTC().Format(time.RFC3339Nano)))
+	}
+	if !f.EndTime.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("timestamp<=%q", f.EndTime.UTC().Format(time.RFC3339Nano)))
+	}
+	if f.Filter != "" {
+		conditions = append(conditions, "("+f.Filter+")")
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
+// fromAuditLog converts a Cloud Audit Logs proto payload to our AuditLog
+func fromAuditLog(payload *audit.AuditLog) *AuditLog {
+	auditLog := &AuditLog{
+		ServiceName:      payload.GetServiceName(),
+		MethodName:       payload.GetMethodName(),
+		ResourceName:     payload.GetResourceName(),
+		NumResponseItems: payload.GetNumResponseItems(),
+	}
+
+	if location := payload.GetResourceLocation(); location != nil {
+		auditLog.ResourceLocations = location.GetCurrentLocations()
+	}
+
+	if authn := payload.GetAuthenticationInfo(); authn != nil {
+		auditLog.PrincipalEmail = authn.GetPrincipalEmail()
+		auditLog.PrincipalSubject = authn.GetPrincipalSubject()
+		auditLog.ServiceAccountKeyName = authn.GetServiceAccountKeyName()
+		for _, delegation := range authn.GetServiceAccountDelegationInfo() {
+			principal := delegation.GetFirstPartyPrincipal().GetPrincipalEmail()
+			if principal == "" {
+				principal = delegation.GetPrincipalSubject()
+			}
+			if principal != "" {
+				auditLog.DelegationChain = append(auditLog.DelegationChain, principal)
+			}
+		}
+	}
+
+	if metadata := payload.GetRequestMetadata(); metadata != nil {
+		auditLog.CallerIP = metadata.GetCallerIp()
+		auditLog.CallerNetwork = metadata.GetCallerNetwork()
+		auditLog.CallerSuppliedUserAgent = metadata.GetCallerSuppliedUserAgent()
+	}
+
+	if status := payload.GetStatus(); status != nil {
+		auditLog.Status = &AuditStatus{
+			Code:    status.GetCode(),
+			Message: status.GetMessage(),
+		}
+	}
+
+	for _, authz := range payload.GetAuthorizationInfo() {
+		auditLog.AuthorizationInfo = append(auditLog.AuthorizationInfo, AuthorizationInfo{
+			Resource:   authz.GetResource(),
+			Permission: authz.GetPermission(),
+			Granted:    authz.GetGranted(),
+		})
+	}
+
+	if payload.GetRequest() != nil {
+		auditLog.Request = payload.GetRequest().AsMap()
+	}
+	if payload.GetResponse() != nil {
+		auditLog.Response = payload.GetResponse().AsMap()
+	}
+	if payload.GetMetadata() != nil {
+		auditLog.Metadata = payload.GetMetadata().AsMap()
+	}
+
+	return auditLog
+}
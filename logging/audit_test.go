@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/cloud/audit"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestAuditLogFilter_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  AuditLogFilter
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "all audit logs",
+			filter: AuditLogFilter{},
+			want:   `logName:"cloudaudit.googleapis.com"`,
+		},
+		{
+			name: "all conditions",
+			filter: AuditLogFilter{
+				LogType:        AuditLogTypeActivity,
+				ServiceName:    "compute.googleapis.com",
+				MethodName:     "delete",
+				PrincipalEmail: "alice@example.com",
+				CallerIP:       "203.0.113.1",
+				ResourceName:   "instances/web-1",
+				StartTime:      time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+				EndTime:        time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				Filter:         "severity>=WARNING",
+			},
+			want: `log_id("cloudaudit.googleapis.com/activity")` +
+				` AND protoPayload.serviceName="compute.googleapis.com"` +
+				` AND protoPayload.methodName:"delete"` +
+				` AND protoPayload.authenticationInfo.principalEmail="alice@example.com"` +
+				` AND protoPayload.requestMetadata.callerIp="203.0.113.1"` +
+				` AND protoPayload.resourceName:"instances/web-1"` +
+				` AND timestamp>="2024-01-01T10:00:00Z"` +
+				` AND timestamp<="2024-01-01T12:00:00Z"` +
+				` AND (severity>=WARNING)`,
+		},
+		{
+			name:   "quotes values",
+			filter: AuditLogFilter{LogType: AuditLogTypeDataAccess, PrincipalEmail: `a"b@example.com`},
+			want:   `log_id("cloudaudit.googleapis.com/data_access") AND protoPayload.authenticationInfo.principalEmail="a\"b@example.com"`,
+		},
+		{
+			name:    "unsupported log type",
+			filter:  AuditLogFilter{LogType: "admin"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Build() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromAuditLog(t *testing.T) {
+	request, err := structpb.NewStruct(map[string]any{"name": "web-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := fromAuditLog(&audit.AuditLog{
+		ServiceName:  "compute.googleapis.com",
+		MethodName:   "v1.compute.instances.delete",
+		ResourceName: "projects/test-project/zones/us-central1-a/instances/web-1",
+		AuthenticationInfo: &audit.AuthenticationInfo{
+			PrincipalEmail: "deployer@test-project.iam.gserviceaccount.com",
+			ServiceAccountDelegationInfo: []*audit.ServiceAccountDelegationInfo{
+				{Authority: &audit.ServiceAccountDelegationInfo_FirstPartyPrincipal_{
+					FirstPartyPrincipal: &audit.ServiceAccountDelegationInfo_FirstPartyPrincipal{PrincipalEmail: "alice@example.com"},
+				}},
+				{PrincipalSubject: "principal://iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/ci/subject/repo"},
+			},
+		},
+		RequestMetadata: &audit.RequestMetadata{
+			CallerIp:      "203.0.113.1",
+			CallerNetwork: "//compute.googleapis.com/projects/test-project/global/networks/default",
+		},
+		Status: &status.Status{Code: 7, Message: "PERMISSION_DENIED"},
+		AuthorizationInfo: []*audit.AuthorizationInfo{
+			{Resource: "projects/test-project", Permission: "compute.instances.delete", Granted: false},
+		},
+		Request: request,
+	})
+
+	if got.MethodName != "v1.compute.instances.delete" {
+		t.Errorf("MethodName = %s, want v1.compute.instances.delete", got.MethodName)
+	}
+	if got.PrincipalEmail != "deployer@test-project.iam.gserviceaccount.com" {
+		t.Errorf("PrincipalEmail = %s, want deployer@test-project.iam.gserviceaccount.com", got.PrincipalEmail)
+	}
+	wantChain := []string{"alice@example.com", "principal://iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/ci/subject/repo"}
+	if !slices.Equal(got.DelegationChain, wantChain) {
+		t.Errorf("DelegationChain = %v, want %v", got.DelegationChain, wantChain)
+	}
+	if got.CallerIP != "203.0.113.1" {
+		t.Errorf("CallerIP = %s, want 203.0.113.1", got.CallerIP)
+	}
+	if got.CallerNetwork != "//compute.googleapis.com/projects/test-project/global/networks/default" {
+		t.Errorf("CallerNetwork = %s", got.CallerNetwork)
+	}
+	if got.Status == nil || got.Status.Code != 7 {
+		t.Errorf("Status = %+v, want code 7", got.Status)
+	}
+	if len(got.AuthorizationInfo) != 1 || got.AuthorizationInfo[0].Permission != "compute.instances.delete" {
+		t.Errorf("AuthorizationInfo = %+v", got.AuthorizationInfo)
+	}
+	if got.Request["name"] != "web-1" {
+		t.Errorf("Request = %v, want name web-1", got.Request)
+	}
+}
@@ -6,33 +6,117 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/logging"
+	"cloud.google.com/go/logging/apiv2/loggingpb"
 	"cloud.google.com/go/logging/logadmin"
+	"github.com/kitagry/gcp-telemetry-mcp/credentials"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/api/monitoredres"
+	"google.golang.org/genproto/googleapis/cloud/audit"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // LogEntry represents a log entry to be written or retrieved
 type LogEntry struct {
-	Severity  string            `json:"severity"`
-	Message   string            `json:"message"`
-	Labels    map[string]string `json:"labels,omitempty"`
-	Payload   map[string]any    `json:"payload,omitempty"`
-	Timestamp time.Time         `json:"timestamp"`
+	Severity       string             `json:"severity"`
+	Message        string             `json:"message"`
+	Labels         map[string]string  `json:"labels,omitempty"`
+	Payload        map[string]any     `json:"payload,omitempty"`
+	Timestamp      time.Time          `json:"timestamp"`
+	Resource       *MonitoredResource `json:"resource,omitempty"`
+	SourceLocation *SourceLocation    `json:"source_location,omitempty"`
+	HTTPRequest    *HTTPRequest       `json:"http_request,omitempty"`
+	Operation      *Operation         `json:"operation,omitempty"`
+	InsertID       string             `json:"insert_id,omitempty"`
+	Trace          string             `json:"trace,omitempty"`
+	SpanID         string             `json:"span_id,omitempty"`
+	TraceSampled   bool               `json:"trace_sampled,omitempty"`
+	AuditLog       *AuditLog          `json:"audit_log,omitempty"` // decoded payload of Cloud Audit Logs entries
 }
 
+// MonitoredResource represents the monitored resource that produced a log entry
+type MonitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// SourceLocation represents the source code location that produced a log entry
+type SourceLocation struct {
+	File     string `json:"file,omitempty"`
+	Line     int64  `json:"line,omitempty"`
+	Function string `json:"function,omitempty"`
+}
+
+// HTTPRequest represents the HTTP request associated with a log entry
+type HTTPRequest struct {
+	Method       string `json:"method,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Status       int    `json:"status,omitempty"`
+	RequestSize  int64  `json:"request_size,omitempty"`
+	ResponseSize int64  `json:"response_size,omitempty"`
+	UserAgent    string `json:"user_agent,omitempty"`
+	Referer      string `json:"referer,omitempty"`
+	RemoteIP     string `json:"remote_ip,omitempty"`
+	ServerIP     string `json:"server_ip,omitempty"`
+	Latency      string `json:"latency,omitempty"` // duration string, e.g. "250ms"
+}
+
+// Operation represents a long-running operation that a log entry belongs to
+type Operation struct {
+	ID       string `json:"id"`
+	Producer string `json:"producer,omitempty"`
+	First    bool   `json:"first,omitempty"`
+	Last     bool   `json:"last,omitempty"`
+}
+
+// Supported values for ListEntriesRequest.OrderBy
+const (
+	OrderByTimestampAsc  = "timestamp asc"
+	OrderByTimestampDesc = "timestamp desc"
+)
+
 // ListEntriesRequest represents a request to list log entries
 type ListEntriesRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
 	Filter    string `json:"filter,omitempty"`
 	OrderBy   string `json:"order_by,omitempty"`
 	Limit     int    `json:"limit,omitempty"`
 	PageToken string `json:"page_token,omitempty"`
 }
 
+// ListEntriesResponse represents a response with log entries and pagination info
+type ListEntriesResponse struct {
+	Entries       []LogEntry `json:"entries"`
+	NextPageToken string     `json:"next_page_token,omitempty"`
+}
+
+// WriteEntriesRequest represents a request to write multiple log entries to a single log
+type WriteEntriesRequest struct {
+	// LogName is either a log ID written under the client's project or a full
+	// resource name such as "projects/PROJECT_ID/logs/LOG_ID"
+	LogName string     `json:"log_name"`
+	Entries []LogEntry `json:"entries"`
+	// Async buffers the entries in the logger and flushes them once at the end
+	// instead of writing each entry synchronously. The flush status covers
+	// all the entries, while synchronous writes report the failed entries
+	// with a *PartialWriteError.
+	Async bool `json:"async,omitempty"`
+}
+
 // LoggingClient defines the interface for Cloud Logging operations
 type LoggingClient interface {
 	WriteEntry(ctx context.Context, logName string, entry LogEntry) error
-	ListEntries(ctx context.Context, req ListEntriesRequest) ([]LogEntry, error)
+	WriteEntries(ctx context.Context, req WriteEntriesRequest) error
+	ListEntries(ctx context.Context, req ListEntriesRequest) (ListEntriesResponse, error)
+	VolumeHistogram(ctx context.Context, req VolumeHistogramRequest) (VolumeHistogramResponse, error)
+	ExtractFieldValues(ctx context.Context, req FieldValuesRequest) (FieldValuesResponse, error)
 }
 
 // CloudLoggingClient implements LoggingClient using Google Cloud Logging
@@ -43,26 +127,45 @@ type CloudLoggingClient struct {
 // LoggingClientInterface abstracts the Google Cloud Logging client for testing
 type LoggingClientInterface interface {
 	WriteEntry(ctx context.Context, logName string, entry LogEntry) error
-	ListEntries(ctx context.Context, req ListEntriesRequest) ([]LogEntry, error)
+	WriteEntries(ctx context.Context, req WriteEntriesRequest) error
+	ListEntries(ctx context.Context, req ListEntriesRequest) (ListEntriesResponse, error)
 }
 
-// New creates a new CloudLoggingClient
-func New(projectID string) (*CloudLoggingClient, error) {
-	client, err := logging.NewClient(context.Background(), projectID)
+// New creates a new CloudLoggingClient. The options, e.g. credentials, are
+// used for all the underlying clients.
+func New(projectID string, opts ...option.ClientOption) (*CloudLoggingClient, error) {
+	client, err := NewAPIClient(projectID, opts...)
 	if err != nil {
 		return nil, err
 	}
+	return NewWithClient(client), nil
+}
 
-	adminClient, err := logadmin.NewClient(context.Background(), projectID)
+// NewAPIClient creates the LoggingClientInterface calling the Cloud Logging
+// API, e.g. to be wrapped before it is passed to NewWithClient, or the
+// emulator set in LOGGING_EMULATOR_HOST
+func NewAPIClient(projectID string, opts ...option.ClientOption) (LoggingClientInterface, error) {
+	if emulatorOpts, ok := credentials.EmulatorOptions(credentials.ServiceLogging); ok {
+		opts = emulatorOpts
+	}
+
+	client, err := logging.NewClient(context.Background(), projectID, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &CloudLoggingClient{
-		client: &realLoggingClient{
-			client:      client,
-			adminClient: adminClient,
-		},
+	adminClient, err := logadmin.NewClient(context.Background(), projectID, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &realLoggingClient{
+		client:        client,
+		adminClient:   adminClient,
+		projectID:     projectID,
+		parentClients: make(map[string]*logging.Client),
+		cursors:       newCursorStore(defaultCursorTTL),
+		opts:          opts,
 	}, nil
 }
 
@@ -78,8 +181,13 @@ func (c *CloudLoggingClient) WriteEntry(ctx context.Context, logName string, ent
 	return c.client.WriteEntry(ctx, logName, entry)
 }
 
+// WriteEntries writes multiple log entries to Cloud Logging
+func (c *CloudLoggingClient) WriteEntries(ctx context.Context, req WriteEntriesRequest) error {
+	return c.client.WriteEntries(ctx, req)
+}
+
 // ListEntries retrieves log entries from Cloud Logging
-func (c *CloudLoggingClient) ListEntries(ctx context.Context, req ListEntriesRequest) ([]LogEntry, error) {
+func (c *CloudLoggingClient) ListEntries(ctx context.Context, req ListEntriesRequest) (ListEntriesResponse, error) {
 	return c.client.ListEntries(ctx, req)
 }
 
@@ -87,38 +195,172 @@ func (c *CloudLoggingClient) ListEntries(ctx context.Context, req ListEntriesReq
 type realLoggingClient struct {
 	client      *logging.Client
 	adminClient *logadmin.Client
+	projectID   string
+	cursors     *cursorStore
+	opts        []option.ClientOption // also used for the clients of other parents
+
+	mu            sync.Mutex
+	parentClients map[string]*logging.Client // clients for parents other than the client's project
+}
+
+// loggerFor returns a logger for logName, which is either a log ID written
+// under the client's project or a full resource name like projects/P/logs/ID
+func (r *realLoggingClient) loggerFor(logName string) (*logging.Logger, error) {
+	logName, err := NormalizeLogName(logName)
+	if err != nil {
+		return nil, err
+	}
+	parent, logID, ok := splitLogName(logName)
+	if !ok {
+		return r.client.Logger(logName), nil
+	}
+
+	if parent == "projects/"+r.projectID {
+		return r.client.Logger(logID), nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.parentClients[parent]
+	if !ok {
+		client, err = logging.NewClient(context.Background(), parent, r.opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create logging client for %s: %w", parent, err)
+		}
+		r.parentClients[parent] = client
+	}
+	return client.Logger(logID), nil
+}
+
+// splitLogName splits a full log resource name into its parent and log ID.
+// It returns false when logName is not a full resource name.
+func splitLogName(logName string) (parent, logID string, ok bool) {
+	parent, escapedLogID, ok := CutLogName(logName)
+	if !ok {
+		return "", "", false
+	}
+
+	// The logger escapes the log ID itself, so undo any escaping in the resource name
+	logID, err := url.PathUnescape(escapedLogID)
+	if err != nil || logID == "" {
+		return "", "", false
+	}
+	return parent, logID, true
 }
 
 // WriteEntry implements LoggingClientInterface for the real client
-func (r *realLoggingClient) WriteEntry(ctx context.Context, logName string, entry LogEntry) (err error) {
-	logger := r.client.Logger(logName)
-	defer func() {
-		flushErr := logger.Flush()
-		if flushErr != nil {
-			err = errors.Join(err, fmt.Errorf("failed to flush logger: %w", flushErr))
-		}
-	}()
-
-	// Convert severity string to logging.Severity
-	var severity logging.Severity
-	switch entry.Severity {
-	case "DEBUG":
-		severity = logging.Debug
-	case "INFO":
-		severity = logging.Info
-	case "WARNING":
-		severity = logging.Warning
-	case "ERROR":
-		severity = logging.Error
-	case "CRITICAL":
-		severity = logging.Critical
-	default:
-		severity = logging.Info
+func (r *realLoggingClient) WriteEntry(ctx context.Context, logName string, entry LogEntry) error {
+	logger, err := r.loggerFor(logName)
+	if err != nil {
+		return err
+	}
+
+	logEntry, err := toLoggingEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write synchronously so that rejected entries are reported to the caller,
+	// where Log would only hand the error to the client's OnError
+	if err := logger.LogSync(ctx, logEntry); err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	return nil
+}
+
+// WriteEntries implements LoggingClientInterface for the real client
+func (r *realLoggingClient) WriteEntries(ctx context.Context, req WriteEntriesRequest) error {
+	logger, err := r.loggerFor(req.LogName)
+	if err != nil {
+		return err
+	}
+
+	if req.Async {
+		// Let the logger buffer and bundle the entries, then report the flush status
+		for i, entry := range req.Entries {
+			logEntry, err := toLoggingEntry(entry)
+			if err != nil {
+				return fmt.Errorf("invalid entry %d: %w", i, err)
+			}
+			logger.Log(logEntry)
+		}
+		if err := logger.Flush(); err != nil {
+			return fmt.Errorf("failed to flush logger: %w", err)
+		}
+		return nil
+	}
+
+	// Write every entry even when an earlier one fails, and report the
+	// failed entries
+	partial := &PartialWriteError{}
+	for i, entry := range req.Entries {
+		logEntry, err := toLoggingEntry(entry)
+		if err != nil {
+			partial.add(i, fmt.Errorf("invalid entry: %w", err))
+			continue
+		}
+		partial.add(i, logger.LogSync(ctx, logEntry))
+	}
+	if partial.Failed > 0 {
+		return partial
+	}
+	return nil
+}
+
+// toLoggingEntry converts our LogEntry to a logging.Entry
+func toLoggingEntry(entry LogEntry) (logging.Entry, error) {
+	// Entries without a severity are written at INFO, but unknown severities
+	// are rejected rather than written at a severity the caller did not ask for
+	severity := logging.Info
+	if entry.Severity != "" {
+		name, err := ParseSeverity(entry.Severity)
+		if err != nil {
+			return logging.Entry{}, err
+		}
+		severity = logging.ParseSeverity(name)
 	}
 
 	logEntry := logging.Entry{
-		Severity: severity,
-		Labels:   entry.Labels,
+		Timestamp:    entry.Timestamp, // the zero time is replaced with the time of writing
+		Severity:     severity,
+		Labels:       entry.Labels,
+		InsertID:     entry.InsertID,
+		Trace:        entry.Trace,
+		SpanID:       entry.SpanID,
+		TraceSampled: entry.TraceSampled,
+	}
+
+	if entry.Resource != nil {
+		logEntry.Resource = &monitoredres.MonitoredResource{
+			Type:   entry.Resource.Type,
+			Labels: entry.Resource.Labels,
+		}
+	}
+
+	if entry.SourceLocation != nil {
+		logEntry.SourceLocation = &loggingpb.LogEntrySourceLocation{
+			File:     entry.SourceLocation.File,
+			Line:     entry.SourceLocation.Line,
+			Function: entry.SourceLocation.Function,
+		}
+	}
+
+	if entry.Operation != nil {
+		logEntry.Operation = &loggingpb.LogEntryOperation{
+			Id:       entry.Operation.ID,
+			Producer: entry.Operation.Producer,
+			First:    entry.Operation.First,
+			Last:     entry.Operation.Last,
+		}
+	}
+
+	if entry.HTTPRequest != nil {
+		httpRequest, err := toLoggingHTTPRequest(entry.HTTPRequest)
+		if err != nil {
+			return logging.Entry{}, err
+		}
+		logEntry.HTTPRequest = httpRequest
 	}
 
 	// Set payload - prefer structured payload over message
@@ -128,79 +370,227 @@ func (r *realLoggingClient) WriteEntry(ctx context.Context, logName string, entr
 		logEntry.Payload = entry.Message
 	}
 
-	logger.Log(logEntry)
-	return nil
+	return logEntry, nil
+}
+
+// toLoggingHTTPRequest converts our HTTPRequest to a logging.HTTPRequest
+func toLoggingHTTPRequest(req *HTTPRequest) (*logging.HTTPRequest, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	httpReq, err := http.NewRequest(method, req.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http_request: %w", err)
+	}
+	if req.UserAgent != "" {
+		httpReq.Header.Set("User-Agent", req.UserAgent)
+	}
+	if req.Referer != "" {
+		httpReq.Header.Set("Referer", req.Referer)
+	}
+
+	result := &logging.HTTPRequest{
+		Request:      httpReq,
+		Status:       req.Status,
+		RequestSize:  req.RequestSize,
+		ResponseSize: req.ResponseSize,
+		RemoteIP:     req.RemoteIP,
+		LocalIP:      req.ServerIP,
+	}
+
+	if req.Latency != "" {
+		latency, err := time.ParseDuration(req.Latency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http_request latency %q: %w", req.Latency, err)
+		}
+		result.Latency = latency
+	}
+
+	return result, nil
 }
 
 // ListEntries implements LoggingClientInterface for the real client
-func (r *realLoggingClient) ListEntries(ctx context.Context, req ListEntriesRequest) ([]LogEntry, error) {
+func (r *realLoggingClient) ListEntries(ctx context.Context, req ListEntriesRequest) (ListEntriesResponse, error) {
 	// Set limit, default to 50 if not specified
 	limit := req.Limit
 	if limit <= 0 {
 		limit = 50
 	}
 
-	// Create an iterator for log entries using the admin client
-	iterator := r.adminClient.Entries(ctx, logadmin.Filter(req.Filter), logadmin.NewestFirst())
+	var cursor *entryCursor
+	if req.PageToken != "" {
+		// Resume the iterator left behind by a previous call
+		var ok bool
+		cursor, ok = r.cursors.take(req.PageToken)
+		if !ok {
+			return ListEntriesResponse{}, fmt.Errorf("page_token is invalid or has expired")
+		}
+		if cursor.projectID != req.ProjectID || cursor.filter != req.Filter || cursor.orderBy != req.OrderBy {
+			cursor.cancel()
+			return ListEntriesResponse{}, fmt.Errorf("page_token was issued for a different project, filter, or order_by")
+		}
+	} else {
+		opts := []logadmin.EntriesOption{logadmin.Filter(req.Filter)}
+		if req.ProjectID != "" {
+			opts = append(opts, logadmin.ProjectIDs([]string{req.ProjectID}))
+		}
+
+		// Set order, default to newest first if not specified
+		switch req.OrderBy {
+		case "", OrderByTimestampDesc:
+			opts = append(opts, logadmin.NewestFirst())
+		case OrderByTimestampAsc:
+			// logadmin returns the oldest entries first by default
+		default:
+			return ListEntriesResponse{}, fmt.Errorf("unsupported order_by %q: must be %q or %q", req.OrderBy, OrderByTimestampAsc, OrderByTimestampDesc)
+		}
+
+		// The iterator may outlive this call, so it must not be bound to the request's cancellation
+		iterCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		cursor = &entryCursor{
+			iterator:  r.adminClient.Entries(iterCtx, opts...),
+			cancel:    cancel,
+			projectID: req.ProjectID,
+			filter:    req.Filter,
+			orderBy:   req.OrderBy,
+		}
+	}
 
 	var entries []LogEntry
-	count := 0
 
-	// Iterate through the entries
-	for count < limit {
-		entry, err := iterator.Next()
-		if err != nil {
-			// Check for iterator done using Google API standard approach
-			if err.Error() == "no more items in iterator" {
-				break
+	// Iterate through the entries, reading one extra entry to know whether more remain
+	for len(entries) <= limit {
+		entry := cursor.next
+		cursor.next = nil
+		if entry == nil {
+			var err error
+			entry, err = cursor.iterator.Next()
+			if errors.Is(err, iterator.Done) {
+				cursor.cancel()
+				return ListEntriesResponse{Entries: entries}, nil
 			}
-			return nil, err
-		}
-
-		// Convert logging.Entry to our LogEntry format
-		logEntry := LogEntry{
-			Timestamp: entry.Timestamp,
-			Labels:    entry.Labels,
-		}
-
-		// Convert severity
-		switch entry.Severity {
-		case logging.Debug:
-			logEntry.Severity = "DEBUG"
-		case logging.Info:
-			logEntry.Severity = "INFO"
-		case logging.Warning:
-			logEntry.Severity = "WARNING"
-		case logging.Error:
-			logEntry.Severity = "ERROR"
-		case logging.Critical:
-			logEntry.Severity = "CRITICAL"
-		default:
-			logEntry.Severity = "INFO"
-		}
-
-		// Handle payload - could be string or structured data
-		if entry.Payload != nil {
-			switch payload := entry.Payload.(type) {
-			case string:
-				logEntry.Message = payload
-			case map[string]any:
-				logEntry.Payload = payload
-				// Try to extract message from payload if available
-				if msg, ok := payload["message"]; ok {
-					if msgStr, ok := msg.(string); ok {
-						logEntry.Message = msgStr
-					}
+			if err != nil {
+				cursor.cancel()
+				return ListEntriesResponse{}, err
+			}
+		}
+
+		if len(entries) == limit {
+			cursor.next = entry
+			break
+		}
+		entries = append(entries, fromLoggingEntry(entry))
+	}
+
+	token, err := r.cursors.put(cursor)
+	if err != nil {
+		cursor.cancel()
+		return ListEntriesResponse{}, fmt.Errorf("failed to store page cursor: %w", err)
+	}
+
+	return ListEntriesResponse{
+		Entries:       entries,
+		NextPageToken: token,
+	}, nil
+}
+
+// fromLoggingEntry converts a logging.Entry to our LogEntry format
+func fromLoggingEntry(entry *logging.Entry) LogEntry {
+	logEntry := LogEntry{
+		Timestamp:    entry.Timestamp,
+		Labels:       entry.Labels,
+		InsertID:     entry.InsertID,
+		Trace:        entry.Trace,
+		SpanID:       entry.SpanID,
+		TraceSampled: entry.TraceSampled,
+	}
+
+	// Convert severity, e.g. Notice to NOTICE, which is one of Severities
+	logEntry.Severity = strings.ToUpper(entry.Severity.String())
+
+	// Handle payload - could be string or structured data
+	if entry.Payload != nil {
+		switch payload := entry.Payload.(type) {
+		case string:
+			logEntry.Message = payload
+		case map[string]any:
+			logEntry.Payload = payload
+			// Try to extract message from payload if available
+			if msg, ok := payload["message"]; ok {
+				if msgStr, ok := msg.(string); ok {
+					logEntry.Message = msgStr
 				}
-			default:
-				// Convert other types to string
-				logEntry.Message = fmt.Sprintf("%v", payload)
 			}
+		case *structpb.Struct:
+			// logadmin returns JSON payloads as a Struct
+			logEntry.Payload = payload.AsMap()
+			if msg, ok := logEntry.Payload["message"].(string); ok {
+				logEntry.Message = msg
+			}
+		case *audit.AuditLog:
+			logEntry.AuditLog = fromAuditLog(payload)
+			logEntry.Message = payload.GetMethodName()
+		default:
+			// Convert other types to string
+			logEntry.Message = fmt.Sprintf("%v", payload)
 		}
+	}
+
+	if entry.Resource != nil {
+		logEntry.Resource = &MonitoredResource{
+			Type:   entry.Resource.Type,
+			Labels: entry.Resource.Labels,
+		}
+	}
+
+	if entry.SourceLocation != nil {
+		logEntry.SourceLocation = &SourceLocation{
+			File:     entry.SourceLocation.File,
+			Line:     entry.SourceLocation.Line,
+			Function: entry.SourceLocation.Function,
+		}
+	}
+
+	if entry.Operation != nil {
+		logEntry.Operation = &Operation{
+			ID:       entry.Operation.Id,
+			Producer: entry.Operation.Producer,
+			First:    entry.Operation.First,
+			Last:     entry.Operation.Last,
+		}
+	}
+
+	if entry.HTTPRequest != nil {
+		logEntry.HTTPRequest = fromLoggingHTTPRequest(entry.HTTPRequest)
+	}
+
+	return logEntry
+}
+
+// fromLoggingHTTPRequest converts a logging.HTTPRequest to our HTTPRequest
+func fromLoggingHTTPRequest(req *logging.HTTPRequest) *HTTPRequest {
+	result := &HTTPRequest{
+		Status:       req.Status,
+		RequestSize:  req.RequestSize,
+		ResponseSize: req.ResponseSize,
+		RemoteIP:     req.RemoteIP,
+		ServerIP:     req.LocalIP,
+	}
+
+	if req.Request != nil {
+		result.Method = req.Request.Method
+		if req.Request.URL != nil {
+			result.URL = req.Request.URL.String()
+		}
+		result.UserAgent = req.Request.UserAgent()
+		result.Referer = req.Request.Referer()
+	}
 
-		entries = append(entries, logEntry)
-		count++
+	if req.Latency > 0 {
+		result.Latency = req.Latency.String()
 	}
 
-	return entries, nil
+	return result
 }
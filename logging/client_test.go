@@ -39,6 +39,37 @@ func TestCloudLoggingClient_WriteEntry(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "write log entry with resource and request metadata",
+			entry: logging.LogEntry{
+				Severity: "WARNING",
+				Message:  "slow request",
+				Resource: &logging.MonitoredResource{
+					Type: "k8s_container",
+					Labels: map[string]string{
+						"cluster_name": "test-cluster",
+					},
+				},
+				SourceLocation: &logging.SourceLocation{
+					File:     "main.go",
+					Line:     42,
+					Function: "main.handler",
+				},
+				HTTPRequest: &logging.HTTPRequest{
+					Method:  "GET",
+					URL:     "https://example.com/api",
+					Status:  200,
+					Latency: "1.5s",
+				},
+				Operation: &logging.Operation{
+					ID:       "op-123",
+					Producer: "test-producer",
+					First:    true,
+				},
+				InsertID: "insert-123",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -63,6 +94,58 @@ func TestCloudLoggingClient_WriteEntry(t *testing.T) {
 	}
 }
 
+func TestCloudLoggingClient_WriteEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     logging.WriteEntriesRequest
+		wantErr bool
+	}{
+		{
+			name: "write entries synchronously",
+			req: logging.WriteEntriesRequest{
+				LogName: "test-log",
+				Entries: []logging.LogEntry{
+					{Severity: "INFO", Message: "first message"},
+					{Severity: "ERROR", Message: "second message"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "write entries asynchronously",
+			req: logging.WriteEntriesRequest{
+				LogName: "test-log",
+				Entries: []logging.LogEntry{
+					{Severity: "DEBUG", Message: "buffered message"},
+				},
+				Async: true,
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := mocks.NewMockLoggingClientInterface(ctrl)
+			client := logging.NewWithClient(mockClient)
+
+			// Set expectation for WriteEntries call
+			mockClient.EXPECT().
+				WriteEntries(gomock.Any(), tt.req).
+				Return(nil).
+				Times(1)
+
+			err := client.WriteEntries(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WriteEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestCloudLoggingClient_ListEntries(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -77,6 +160,17 @@ func TestCloudLoggingClient_ListEntries(t *testing.T) {
 			Timestamp: time.Now(),
 			Severity:  "ERROR",
 			Message:   "test message 2",
+			InsertID:  "insert-2",
+			Trace:     "projects/test-project/traces/1234567890abcdef1234567890abcdef",
+			SpanID:    "000000000000004a",
+			Resource: &logging.MonitoredResource{
+				Type: "cloud_run_revision",
+			},
+			HTTPRequest: &logging.HTTPRequest{
+				Method: "POST",
+				URL:    "https://example.com/api",
+				Status: 500,
+			},
 		},
 	}
 
@@ -91,14 +185,20 @@ func TestCloudLoggingClient_ListEntries(t *testing.T) {
 	// Set expectation for ListEntries call
 	mockClient.EXPECT().
 		ListEntries(gomock.Any(), req).
-		Return(expectedEntries, nil).
+		Return(logging.ListEntriesResponse{Entries: expectedEntries, NextPageToken: "next-token"}, nil).
 		Times(1)
 
-	entries, err := client.ListEntries(context.Background(), req)
+	resp, err := client.ListEntries(context.Background(), req)
 	if err != nil {
 		t.Errorf("ListEntries() error = %v", err)
 	}
 
+	if resp.NextPageToken != "next-token" {
+		t.Errorf("Expected next page token to be next-token, got %s", resp.NextPageToken)
+	}
+
+	entries := resp.Entries
+
 	if len(entries) != 2 {
 		t.Errorf("Expected 2 entries, got %d", len(entries))
 	}
@@ -110,4 +210,37 @@ func TestCloudLoggingClient_ListEntries(t *testing.T) {
 	if entries[1].Severity != "ERROR" {
 		t.Errorf("Expected second entry severity to be ERROR, got %s", entries[1].Severity)
 	}
+
+	if entries[1].Trace != expectedEntries[1].Trace {
+		t.Errorf("Expected second entry trace to be %s, got %s", expectedEntries[1].Trace, entries[1].Trace)
+	}
+
+	if entries[1].HTTPRequest == nil || entries[1].HTTPRequest.Status != 500 {
+		t.Errorf("Expected second entry HTTP request status to be 500, got %+v", entries[1].HTTPRequest)
+	}
+}
+
+func TestCloudLoggingClient_ListEntries_OrderBy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockLoggingClientInterface(ctrl)
+	client := logging.NewWithClient(mockClient)
+
+	req := logging.ListEntriesRequest{
+		Filter:  "severity>=ERROR",
+		OrderBy: logging.OrderByTimestampAsc,
+		Limit:   10,
+	}
+
+	// Set expectation for ListEntries call with ascending order
+	mockClient.EXPECT().
+		ListEntries(gomock.Any(), req).
+		Return(logging.ListEntriesResponse{}, nil).
+		Times(1)
+
+	_, err := client.ListEntries(context.Background(), req)
+	if err != nil {
+		t.Errorf("ListEntries() error = %v", err)
+	}
 }
@@ -0,0 +1,16 @@
+package logging
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ConsoleURL returns the Logs Explorer URL running the filter in the project
+func ConsoleURL(projectID, filter string) string {
+	u := "https://console.cloud.google.com/logs/query"
+	if filter != "" {
+		// The query is a matrix parameter of the path, so spaces must not become "+"
+		u += ";query=" + strings.ReplaceAll(url.QueryEscape(filter), "+", "%20")
+	}
+	return u + "?" + url.Values{"project": {projectID}}.Encode()
+}
@@ -0,0 +1,29 @@
+package logging
+
+import "testing"
+
+func TestConsoleURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   string
+	}{
+		{
+			name:   "filter",
+			filter: `severity>=ERROR AND resource.type="k8s_container"`,
+			want:   "https://console.cloud.google.com/logs/query;query=severity%3E%3DERROR%20AND%20resource.type%3D%22k8s_container%22?project=my-project",
+		},
+		{
+			name: "no filter",
+			want: "https://console.cloud.google.com/logs/query?project=my-project",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConsoleURL("my-project", tt.filter); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
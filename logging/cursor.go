@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"cloud.google.com/go/logging/logadmin"
+)
+
+const (
+	// defaultCursorTTL is how long an unused cursor is kept before it expires
+	defaultCursorTTL = 10 * time.Minute
+	// maxCursors bounds the number of cursors kept in memory at once
+	maxCursors = 100
+)
+
+// entryCursor holds a paused log entries iterator so that a later ListEntries
+// call can continue exactly where the previous one stopped. logadmin's
+// iterator cannot be recreated from a page token, so the iterator itself is kept.
+type entryCursor struct {
+	iterator  *logadmin.EntryIterator
+	cancel    context.CancelFunc
+	next      *logging.Entry // entry already read from the iterator but not yet returned
+	projectID string
+	filter    string
+	orderBy   string
+	expiresAt time.Time
+}
+
+// cursorStore keeps entry cursors keyed by opaque tokens
+type cursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]*entryCursor
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// newCursorStore creates an empty cursorStore
+func newCursorStore(ttl time.Duration) *cursorStore {
+	return &cursorStore{
+		cursors: make(map[string]*entryCursor),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// put stores the cursor and returns the token to resume it with
+func (s *cursorStore) put(cursor *entryCursor) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictExpiredLocked()
+	if len(s.cursors) >= maxCursors {
+		s.evictOldestLocked()
+	}
+
+	cursor.expiresAt = s.now().Add(s.ttl)
+	s.cursors[token] = cursor
+	return token, nil
+}
+
+// take removes and returns the cursor for token, or false if it is unknown or expired
+func (s *cursorStore) take(token string) (*entryCursor, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictExpiredLocked()
+	cursor, ok := s.cursors[token]
+	if !ok {
+		return nil, false
+	}
+	delete(s.cursors, token)
+	return cursor, true
+}
+
+// evictExpiredLocked drops expired cursors. s.mu must be held.
+func (s *cursorStore) evictExpiredLocked() {
+	now := s.now()
+	for token, cursor := range s.cursors {
+		if now.After(cursor.expiresAt) {
+			cursor.cancel()
+			delete(s.cursors, token)
+		}
+	}
+}
+
+// evictOldestLocked drops the cursor closest to expiry. s.mu must be held.
+func (s *cursorStore) evictOldestLocked() {
+	var oldestToken string
+	var oldest *entryCursor
+	for token, cursor := range s.cursors {
+		if oldest == nil || cursor.expiresAt.Before(oldest.expiresAt) {
+			oldestToken, oldest = token, cursor
+		}
+	}
+	if oldest != nil {
+		oldest.cancel()
+		delete(s.cursors, oldestToken)
+	}
+}
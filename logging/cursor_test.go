@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCursorStore_PutTake(t *testing.T) {
+	store := newCursorStore(time.Minute)
+
+	_, cancel := context.WithCancel(context.Background())
+	token, err := store.put(&entryCursor{cancel: cancel, filter: "severity>=ERROR"})
+	if err != nil {
+		t.Fatalf("put() error = %v", err)
+	}
+
+	cursor, ok := store.take(token)
+	if !ok {
+		t.Fatal("Expected cursor to be found")
+	}
+	if cursor.filter != "severity>=ERROR" {
+		t.Errorf("Expected filter severity>=ERROR, got %s", cursor.filter)
+	}
+
+	// A cursor can only be resumed once
+	if _, ok := store.take(token); ok {
+		t.Error("Expected cursor to be removed after take")
+	}
+}
+
+func TestCursorStore_Expiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newCursorStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	token, err := store.put(&entryCursor{cancel: cancel})
+	if err != nil {
+		t.Fatalf("put() error = %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := store.take(token); ok {
+		t.Error("Expected expired cursor not to be found")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected expired cursor to be cancelled")
+	}
+}
+
+func TestCursorStore_EvictsOldestWhenFull(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newCursorStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	var firstToken string
+	for i := range maxCursors + 1 {
+		_, cancel := context.WithCancel(context.Background())
+		token, err := store.put(&entryCursor{cancel: cancel})
+		if err != nil {
+			t.Fatalf("put() error = %v", err)
+		}
+		if i == 0 {
+			firstToken = token
+		}
+		now = now.Add(time.Millisecond)
+	}
+
+	if len(store.cursors) != maxCursors {
+		t.Errorf("Expected %d cursors, got %d", maxCursors, len(store.cursors))
+	}
+	if _, ok := store.take(firstToken); ok {
+		t.Error("Expected oldest cursor to be evicted")
+	}
+}
@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeployMarkerLogID is the log deploy markers are written to
+const DeployMarkerLogID = "deploy_markers"
+
+// DeployMarker represents a deployment recorded as a log entry, so that
+// analysis tools can show what changed alongside the telemetry. The service,
+// version, and environment are written as labels as well as in the payload,
+// so that markers can be selected by them.
+type DeployMarker struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Service     string    `json:"service"`
+	Version     string    `json:"version,omitempty"`
+	Environment string    `json:"environment,omitempty"` // e.g. production or staging
+	Commit      string    `json:"commit,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Description string    `json:"description,omitempty"`
+	URL         string    `json:"url,omitempty"` // e.g. of the release or the CI run
+}
+
+// Message describes the deployment in one line
+func (m DeployMarker) Message() string {
+	message := "deployed " + m.Service
+	if m.Version != "" {
+		message += " " + m.Version
+	}
+	if m.Environment != "" {
+		message += " to " + m.Environment
+	}
+	if m.Description != "" {
+		message += ": " + m.Description
+	}
+	return message
+}
+
+// Entry returns the log entry recording the marker
+func (m DeployMarker) Entry() LogEntry {
+	entry := LogEntry{
+		Timestamp: m.Timestamp,
+		Severity:  "NOTICE",
+		Labels:    map[string]string{"service": m.Service},
+		Payload:   map[string]any{"message": m.Message(), "service": m.Service},
+	}
+	fields := map[string]string{
+		"version":     m.Version,
+		"environment": m.Environment,
+		"commit":      m.Commit,
+		"author":      m.Author,
+		"description": m.Description,
+		"url":         m.URL,
+	}
+	for key, value := range fields {
+		if value != "" {
+			entry.Payload[key] = value
+		}
+	}
+	if m.Version != "" {
+		entry.Labels["version"] = m.Version
+	}
+	if m.Environment != "" {
+		entry.Labels["environment"] = m.Environment
+	}
+	return entry
+}
+
+// NewDeployMarker extracts the deploy marker from a deploy markers log entry
+func NewDeployMarker(entry LogEntry) DeployMarker {
+	marker := DeployMarker{Timestamp: entry.Timestamp}
+	payload := entry.Payload
+	marker.Service, _ = payload["service"].(string)
+	marker.Version, _ = payload["version"].(string)
+	marker.Environment, _ = payload["environment"].(string)
+	marker.Commit, _ = payload["commit"].(string)
+	marker.Author, _ = payload["author"].(string)
+	marker.Description, _ = payload["description"].(string)
+	marker.URL, _ = payload["url"].(string)
+
+	// Markers written by other tools may only have labels
+	if marker.Service == "" {
+		marker.Service = entry.Labels["service"]
+	}
+	if marker.Version == "" {
+		marker.Version = entry.Labels["version"]
+	}
+	if marker.Environment == "" {
+		marker.Environment = entry.Labels["environment"]
+	}
+	return marker
+}
+
+// DeployMarkerFilter represents structured conditions for selecting deploy markers
+type DeployMarkerFilter struct {
+	Service     string    `json:"service,omitempty"`
+	Version     string    `json:"version,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	StartTime   time.Time `json:"start_time,omitempty"`
+	EndTime     time.Time `json:"end_time,omitempty"`
+	Filter      string    `json:"filter,omitempty"` // additional logging filter ANDed with the conditions
+}
+
+// Build returns the Cloud Logging filter expression for the conditions
+func (f DeployMarkerFilter) Build() string {
+	conditions := []string{fmt.Sprintf("log_id(%q)", DeployMarkerLogID)}
+
+	if f.Service != "" {
+		conditions = append(conditions, fmt.Sprintf("labels.service=%q", f.Service))
+	}
+	if f.Version != "" {
+		conditions = append(conditions, fmt.Sprintf("labels.version=%q", f.Version))
+	}
+	if f.Environment != "" {
+		conditions = append(conditions, fmt.Sprintf("labels.environment=%q", f.Environment))
+	}
+	if !f.StartTime.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("timestamp>=%q", f.StartTime.UTC().Format(time.RFC3339Nano)))
+	}
+	if !f.EndTime.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("timestamp<=%q", f.EndTime.UTC().Format(time.RFC3339Nano)))
+	}
+	if f.Filter != "" {
+		conditions = append(conditions, "("+f.Filter+")")
+	}
+
+	return strings.Join(conditions, " AND ")
+}
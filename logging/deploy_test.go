@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeployMarkerFilter_Build(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter DeployMarkerFilter
+		want   string
+	}{
+		{
+			name:   "all markers",
+			filter: DeployMarkerFilter{},
+			want:   `log_id("deploy_markers")`,
+		},
+		{
+			name: "all conditions",
+			filter: DeployMarkerFilter{
+				Service:     "checkout",
+				Version:     "v42",
+				Environment: "production",
+				StartTime:   time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+				EndTime:     time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC),
+				Filter:      `labels.region="us"`,
+			},
+			want: `log_id("deploy_markers") AND labels.service="checkout" AND labels.version="v42" AND labels.environment="production"` +
+				` AND timestamp>="2024-01-01T10:00:00Z" AND timestamp<="2024-01-01T11:00:00Z" AND (labels.region="us")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Build(); got != tt.want {
+				t.Errorf("Build() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeployMarker_Entry(t *testing.T) {
+	marker := DeployMarker{
+		Timestamp:   time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		Service:     "checkout",
+		Version:     "v42",
+		Environment: "production",
+		Commit:      "8f14e45f",
+		Description: "retry payments",
+	}
+
+	entry := marker.Entry()
+	if entry.Payload["message"] != "deployed checkout v42 to production: retry payments" {
+		t.Errorf("Unexpected message %v", entry.Payload["message"])
+	}
+	wantLabels := map[string]string{"service": "checkout", "version": "v42", "environment": "production"}
+	if !reflect.DeepEqual(entry.Labels, wantLabels) {
+		t.Errorf("Expected labels %v, got %v", wantLabels, entry.Labels)
+	}
+	if _, ok := entry.Payload["author"]; ok {
+		t.Errorf("Expected no empty fields in the payload, got %v", entry.Payload)
+	}
+
+	if got := NewDeployMarker(entry); got != marker {
+		t.Errorf("NewDeployMarker() = %+v, want %+v", got, marker)
+	}
+
+	// Markers written by other tools may only have labels
+	got := NewDeployMarker(LogEntry{Labels: map[string]string{"service": "billing", "version": "v7"}})
+	if got.Service != "billing" || got.Version != "v7" {
+		t.Errorf("Expected the marker from the labels, got %+v", got)
+	}
+}
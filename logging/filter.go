@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Severities lists the log severities in increasing order
+var Severities = []string{"DEFAULT", "DEBUG", "INFO", "NOTICE", "WARNING", "ERROR", "CRITICAL", "ALERT", "EMERGENCY"}
+
+// ParseSeverity returns the severity named by s in any case, or an error
+// listing the Severities
+func ParseSeverity(s string) (string, error) {
+	severity := strings.ToUpper(s)
+	if !slices.Contains(Severities, severity) {
+		return "", fmt.Errorf("unsupported severity %q: must be one of %s", s, strings.Join(Severities, ", "))
+	}
+	return severity, nil
+}
+
+// CountSeverities adds the number of entries of each severity to counts
+func CountSeverities(counts map[string]int, entries []LogEntry) {
+	for _, entry := range entries {
+		counts[entry.Severity]++
+	}
+}
+
+// EntryFilter represents structured conditions for selecting log entries,
+// built into a filter so that models do not have to write the syntax
+type EntryFilter struct {
+	Filter      string   `json:"filter,omitempty"`       // logging filter ANDed with the conditions
+	MinSeverity string   `json:"min_severity,omitempty"` // one of Severities
+	TextRegex   string   `json:"text_regex,omitempty"`   // RE2 expression matched against textPayload and jsonPayload.message
+	ExcludeText []string `json:"exclude_text,omitempty"` // entries containing any of the texts in any field are excluded
+}
+
+// Build returns the Cloud Logging filter expression for the conditions
+func (f EntryFilter) Build() (string, error) {
+	var conditions []string
+
+	if f.MinSeverity != "" {
+		severity, err := ParseSeverity(f.MinSeverity)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, "severity>="+severity)
+	}
+	if f.TextRegex != "" {
+		// Cloud Logging uses RE2 like Go, so invalid expressions are caught
+		// before the request is sent
+		if _, err := regexp.Compile(f.TextRegex); err != nil {
+			return "", fmt.Errorf("invalid text_regex: %w", err)
+		}
+		conditions = append(conditions, fmt.Sprintf("(textPayload=~%q OR jsonPayload.message=~%q)", f.TextRegex, f.TextRegex))
+	}
+	for _, text := range f.ExcludeText {
+		if text == "" {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("NOT %q", text))
+	}
+
+	if f.Filter != "" {
+		if len(conditions) == 0 {
+			return f.Filter, nil
+		}
+		conditions = append(conditions, "("+f.Filter+")")
+	}
+	return strings.Join(conditions, " AND "), nil
+}
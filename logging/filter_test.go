@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/logging"
+)
+
+func TestEntryFilter_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  EntryFilter
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "filter only",
+			filter: EntryFilter{Filter: `resource.type="cloud_run_revision"`},
+			want:   `resource.type="cloud_run_revision"`,
+		},
+		{
+			name:   "severity only",
+			filter: EntryFilter{MinSeverity: "ERROR"},
+			want:   "severity>=ERROR",
+		},
+		{
+			name: "severity combined with filter",
+			filter: EntryFilter{
+				Filter:      `resource.type="k8s_container" OR resource.type="gce_instance"`,
+				MinSeverity: "warning",
+			},
+			want: `severity>=WARNING AND (resource.type="k8s_container" OR resource.type="gce_instance")`,
+		},
+		{
+			name: "all conditions",
+			filter: EntryFilter{
+				Filter:      `resource.type="k8s_container"`,
+				MinSeverity: "ERROR",
+				TextRegex:   `timeout after \d+ms`,
+				ExcludeText: []string{"healthz", `say "hi"`},
+			},
+			want: `severity>=ERROR` +
+				` AND (textPayload=~"timeout after \\d+ms" OR jsonPayload.message=~"timeout after \\d+ms")` +
+				` AND NOT "healthz"` +
+				` AND NOT "say \"hi\""` +
+				` AND (resource.type="k8s_container")`,
+		},
+		{
+			name:   "empty",
+			filter: EntryFilter{},
+			want:   "",
+		},
+		{
+			name:    "unsupported severity",
+			filter:  EntryFilter{MinSeverity: "FATAL"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regex",
+			filter:  EntryFilter{TextRegex: "timeout (after"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Build() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if got, err := ParseSeverity("warning"); err != nil || got != "WARNING" {
+		t.Errorf("ParseSeverity(warning) = %q, %v, want WARNING", got, err)
+	}
+	_, err := ParseSeverity("FATAL")
+	if err == nil || !strings.Contains(err.Error(), "must be one of DEFAULT, DEBUG, INFO") {
+		t.Errorf("ParseSeverity(FATAL) error = %v, want the accepted severities", err)
+	}
+}
+
+func TestCountSeverities(t *testing.T) {
+	counts := map[string]int{"ERROR": 1}
+	CountSeverities(counts, []LogEntry{{Severity: "ERROR"}, {Severity: "INFO"}, {Severity: "DEFAULT"}})
+	if len(counts) != 3 || counts["ERROR"] != 2 || counts["INFO"] != 1 || counts["DEFAULT"] != 1 {
+		t.Errorf("Unexpected counts %v", counts)
+	}
+}
+
+func TestFromLoggingEntry_Severity(t *testing.T) {
+	var entries []LogEntry
+	for _, severity := range []logging.Severity{logging.Default, logging.Notice, logging.Alert, logging.Alert, logging.Emergency} {
+		entries = append(entries, fromLoggingEntry(&logging.Entry{Severity: severity}))
+	}
+	for _, entry := range entries {
+		if !slices.Contains(Severities, entry.Severity) {
+			t.Errorf("Severity %q is not one of %v", entry.Severity, Severities)
+		}
+	}
+
+	counts := map[string]int{}
+	CountSeverities(counts, entries)
+	want := map[string]int{"DEFAULT": 1, "NOTICE": 1, "ALERT": 2, "EMERGENCY": 1}
+	if !maps.Equal(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+}
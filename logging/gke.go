@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kubernetes event types
+const (
+	KubernetesEventTypeNormal  = "Normal"
+	KubernetesEventTypeWarning = "Warning"
+)
+
+// KubernetesEvent represents a Kubernetes event exported by GKE to the events log
+type KubernetesEvent struct {
+	Timestamp       time.Time          `json:"timestamp"`
+	Type            string             `json:"type,omitempty"`
+	Reason          string             `json:"reason,omitempty"`
+	Message         string             `json:"message,omitempty"`
+	InvolvedObject  InvolvedObject     `json:"involved_object"`
+	SourceComponent string             `json:"source_component,omitempty"`
+	SourceHost      string             `json:"source_host,omitempty"`
+	Count           int64              `json:"count,omitempty"`
+	FirstTimestamp  string             `json:"first_timestamp,omitempty"`
+	LastTimestamp   string             `json:"last_timestamp,omitempty"`
+	Resource        *MonitoredResource `json:"resource,omitempty"`
+}
+
+// InvolvedObject represents the Kubernetes object an event is about
+type InvolvedObject struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	UID       string `json:"uid,omitempty"`
+	FieldPath string `json:"field_path,omitempty"`
+}
+
+// GKEEventFilter represents structured conditions for selecting GKE events
+type GKEEventFilter struct {
+	ClusterName string    `json:"cluster_name,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Kind        string    `json:"kind,omitempty"`
+	Name        string    `json:"name,omitempty"` // matched as a substring
+	Reason      string    `json:"reason,omitempty"`
+	Type        string    `json:"type,omitempty"` // Normal or Warning
+	StartTime   time.Time `json:"start_time,omitempty"`
+	EndTime     time.Time `json:"end_time,omitempty"`
+	Filter      string    `json:"filter,omitempty"` // additional logging filter ANDed with the conditions
+}
+
+// Build returns the Cloud Logging filter expression for the conditions
+func (f GKEEventFilter) Build() (string, error) {
+	conditions := []string{
+		`log_id("events")`,
+		`resource.type=("k8s_cluster" OR "k8s_node" OR "k8s_pod")`,
+	}
+
+	switch f.Type {
+	case "", KubernetesEventTypeNormal, KubernetesEventTypeWarning:
+	default:
+		return "", fmt.Errorf("unsupported event type %q: must be %q or %q", f.Type, KubernetesEventTypeNormal, KubernetesEventTypeWarning)
+	}
+
+	if f.ClusterName != "" {
+		conditions = append(conditions, fmt.Sprintf("resource.labels.cluster_name=%q", f.ClusterName))
+	}
+	if f.Namespace != "" {
+		conditions = append(conditions, fmt.Sprintf("jsonPayload.involvedObject.namespace=%q", f.Namespace))
+	}
+	if f.Kind != "" {
+		conditions = append(conditions, fmt.Sprintf("jsonPayload.involvedObject.kind=%q", f.Kind))
+	}
+	if f.Name != "" {
+		conditions = append(conditions, fmt.Sprintf("jsonPayload.involvedObject.name:%q", f.Name))
+	}
+	if f.Reason != "" {
+		conditions = append(conditions, fmt.Sprintf("jsonPayload.reason=%q", f.Reason))
+	}
+	if f.Type != "" {
+		conditions = append(conditions, fmt.Sprintf("jsonPayload.type=%q", f.Type))
+	}
+	if !f.StartTime.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("timestamp>=%q", f.StartTime.UTC().Format(time.RFC3339Nano)))
+	}
+	if !f.EndTime.IsZero() {
+		conditions = append(conditions, fmt.Sprintf("timestamp<=%q", f.EndTime.UTC().Format(time.RFC3339Nano)))
+	}
+	if f.Filter != "" {
+		conditions = append(conditions, "("+f.Filter+")")
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
+// NewKubernetesEvent extracts the Kubernetes event from a GKE events log entry
+func NewKubernetesEvent(entry LogEntry) KubernetesEvent {
+	event := KubernetesEvent{
+		Timestamp: entry.Timestamp,
+		Resource:  entry.Resource,
+	}
+
+	payload := entry.Payload
+	event.Type, _ = payload["type"].(string)
+	event.Reason, _ = payload["reason"].(string)
+	event.Message, _ = payload["message"].(string)
+	event.FirstTimestamp, _ = payload["firstTimestamp"].(string)
+	event.LastTimestamp, _ = payload["lastTimestamp"].(string)
+	if count, ok := payload["count"].(float64); ok {
+		event.Count = int64(count)
+	}
+
+	if object, ok := payload["involvedObject"].(map[string]any); ok {
+		event.InvolvedObject.Kind, _ = object["kind"].(string)
+		event.InvolvedObject.Namespace, _ = object["namespace"].(string)
+		event.InvolvedObject.Name, _ = object["name"].(string)
+		event.InvolvedObject.UID, _ = object["uid"].(string)
+		event.InvolvedObject.FieldPath, _ = object["fieldPath"].(string)
+	}
+
+	if source, ok := payload["source"].(map[string]any); ok {
+		event.SourceComponent, _ = source["component"].(string)
+		event.SourceHost, _ = source["host"].(string)
+	}
+	// Events recorded through the events.k8s.io API report their source here instead
+	if event.SourceComponent == "" {
+		event.SourceComponent, _ = payload["reportingComponent"].(string)
+	}
+
+	return event
+}
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGKEEventFilter_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  GKEEventFilter
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "all events",
+			filter: GKEEventFilter{},
+			want:   `log_id("events") AND resource.type=("k8s_cluster" OR "k8s_node" OR "k8s_pod")`,
+		},
+		{
+			name: "all conditions",
+			filter: GKEEventFilter{
+				ClusterName: "prod",
+				Namespace:   "default",
+				Kind:        "Pod",
+				Name:        "web-",
+				Reason:      "BackOff",
+				Type:        KubernetesEventTypeWarning,
+				StartTime:   time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			},
+			want: `log_id("events") AND resource.type=("k8s_cluster" OR "k8s_node" OR "k8s_pod")` +
+				` AND resource.labels.cluster_name="prod"` +
+				` AND jsonPayload.involvedObject.namespace="default"` +
+				` AND jsonPayload.involvedObject.kind="Pod"` +
+				` AND jsonPayload.involvedObject.name:"web-"` +
+				` AND jsonPayload.reason="BackOff"` +
+				` AND jsonPayload.type="Warning"` +
+				` AND timestamp>="2024-01-01T10:00:00Z"`,
+		},
+		{
+			name:    "unsupported type",
+			filter:  GKEEventFilter{Type: "Error"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Build() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKubernetesEvent(t *testing.T) {
+	entry := LogEntry{
+		Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		Resource: &MonitoredResource{
+			Type:   "k8s_pod",
+			Labels: map[string]string{"cluster_name": "prod"},
+		},
+		Payload: map[string]any{
+			"type":    "Warning",
+			"reason":  "BackOff",
+			"message": "Back-off restarting failed container",
+			"count":   float64(12),
+			"involvedObject": map[string]any{
+				"kind":      "Pod",
+				"namespace": "default",
+				"name":      "web-7d9f8",
+				"fieldPath": "spec.containers{web}",
+			},
+			"source": map[string]any{
+				"component": "kubelet",
+				"host":      "gke-prod-pool-1",
+			},
+		},
+	}
+
+	got := NewKubernetesEvent(entry)
+
+	if got.Reason != "BackOff" || got.Type != "Warning" {
+		t.Errorf("Reason, Type = %s, %s, want BackOff, Warning", got.Reason, got.Type)
+	}
+	if got.Count != 12 {
+		t.Errorf("Count = %d, want 12", got.Count)
+	}
+	if got.InvolvedObject.Name != "web-7d9f8" || got.InvolvedObject.FieldPath != "spec.containers{web}" {
+		t.Errorf("InvolvedObject = %+v", got.InvolvedObject)
+	}
+	if got.SourceComponent != "kubelet" || got.SourceHost != "gke-prod-pool-1" {
+		t.Errorf("Source = %s, %s, want kubelet, gke-prod-pool-1", got.SourceComponent, got.SourceHost)
+	}
+	if got.Resource == nil || got.Resource.Labels["cluster_name"] != "prod" {
+		t.Errorf("Resource = %+v", got.Resource)
+	}
+}
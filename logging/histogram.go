@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultHistogramBins is the number of time bins used by default
+	defaultHistogramBins = 12
+	// maxHistogramBins bounds the number of bins, each of which costs a list
+	// request against the read quota of 60 requests per minute
+	maxHistogramBins = 48
+	// defaultMaxEntriesPerBin is the number of entries counted per bin by default
+	defaultMaxEntriesPerBin = 1000
+	// maxMaxEntriesPerBin bounds the entries counted per bin
+	maxMaxEntriesPerBin = 10000
+	// defaultHistogramWindow is the time range covered by default
+	defaultHistogramWindow = time.Hour
+	// histogramConcurrency bounds the bins counted at the same time
+	histogramConcurrency = 4
+	// burstFactor is how many times the median count a bin needs to be part of a burst
+	burstFactor = 2
+)
+
+// VolumeHistogramRequest represents a request to count matching log entries
+// in consecutive time bins
+type VolumeHistogramRequest struct {
+	ProjectID string    `json:"project_id,omitempty"` // defaults to the client's project
+	Filter    string    `json:"filter,omitempty"`
+	StartTime time.Time `json:"start_time,omitzero"`   // defaults to an hour before EndTime
+	EndTime   time.Time `json:"end_time,omitzero"`     // defaults to now
+	Bins      int       `json:"bins,omitempty"`        // defaults to defaultHistogramBins
+	MaxPerBin int       `json:"max_per_bin,omitempty"` // defaults to defaultMaxEntriesPerBin
+}
+
+// VolumeBin represents the number of matching entries in a time bin
+type VolumeBin struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Count     int       `json:"count"`
+	Truncated bool      `json:"truncated,omitempty"` // counting stopped at the maximum per bin
+}
+
+// VolumeHistogramResponse represents the counts of matching log entries over time
+type VolumeHistogramResponse struct {
+	Filter     string      `json:"filter"`
+	Bins       []VolumeBin `json:"bins"`
+	Total      int         `json:"total"`
+	Truncated  bool        `json:"truncated,omitempty"` // at least one bin was truncated
+	PeakStart  time.Time   `json:"peak_start,omitzero"`
+	BurstStart time.Time   `json:"burst_start,omitzero"` // start of the bins leading to the peak that are well above the median
+	Sparkline  string      `json:"sparkline,omitempty"`
+}
+
+// VolumeHistogram counts the entries matching a filter in consecutive time
+// bins, with one bounded query per bin, so that the start of a burst of
+// errors can be spotted
+func (c *CloudLoggingClient) VolumeHistogram(ctx context.Context, req VolumeHistogramRequest) (VolumeHistogramResponse, error) {
+	if req.Bins == 0 {
+		req.Bins = defaultHistogramBins
+	}
+	if req.Bins < 1 || req.Bins > maxHistogramBins {
+		return VolumeHistogramResponse{}, fmt.Errorf("bins must be between 1 and %d", maxHistogramBins)
+	}
+	if req.MaxPerBin <= 0 {
+		req.MaxPerBin = defaultMaxEntriesPerBin
+	}
+	req.MaxPerBin = min(req.MaxPerBin, maxMaxEntriesPerBin)
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+	if req.StartTime.IsZero() {
+		req.StartTime = req.EndTime.Add(-defaultHistogramWindow)
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return VolumeHistogramResponse{}, fmt.Errorf("start time must be before end time")
+	}
+
+	width := req.EndTime.Sub(req.StartTime) / time.Duration(req.Bins)
+	bins := make([]VolumeBin, req.Bins)
+	for i := range bins {
+		bins[i].StartTime = req.StartTime.Add(time.Duration(i) * width)
+		bins[i].EndTime = req.StartTime.Add(time.Duration(i+1) * width)
+	}
+	bins[len(bins)-1].EndTime = req.EndTime
+
+	errs := make([]error, len(bins))
+	semaphore := make(chan struct{}, histogramConcurrency)
+	var wg sync.WaitGroup
+	for i := range bins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			errs[i] = c.countBin(ctx, req, &bins[i])
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return VolumeHistogramResponse{}, err
+		}
+	}
+
+	resp := VolumeHistogramResponse{Filter: req.Filter, Bins: bins}
+	for _, b := range bins {
+		resp.Total += b.Count
+		resp.Truncated = resp.Truncated || b.Truncated
+	}
+	resp.PeakStart, resp.BurstStart = findBurst(bins)
+	return resp, nil
+}
+
+// countBin counts the entries of a bin, up to the maximum per bin. Bins are
+// half-open, so that entries on a boundary are only counted once.
+func (c *CloudLoggingClient) countBin(ctx context.Context, req VolumeHistogramRequest, bin *VolumeBin) error {
+	filter := fmt.Sprintf("timestamp>=%q AND timestamp<%q",
+		bin.StartTime.UTC().Format(time.RFC3339Nano), bin.EndTime.UTC().Format(time.RFC3339Nano))
+	if req.Filter != "" {
+		filter = "(" + req.Filter + ") AND " + filter
+	}
+
+	resp, err := c.client.ListEntries(ctx, ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		Limit:     req.MaxPerBin,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count entries from %s: %w", bin.StartTime.Format(time.RFC3339), err)
+	}
+	bin.Count = len(resp.Entries)
+	bin.Truncated = resp.NextPageToken != ""
+	return nil
+}
+
+// findBurst returns the start of the bin with the most entries and, when that
+// count is well above the median, the start of the run of bins well above
+// the median that ends at the peak
+func findBurst(bins []VolumeBin) (peakStart, burstStart time.Time) {
+	peak := 0
+	for i, b := range bins {
+		if b.Count > bins[peak].Count {
+			peak = i
+		}
+	}
+	if bins[peak].Count == 0 {
+		return time.Time{}, time.Time{}
+	}
+
+	counts := make([]int, len(bins))
+	for i, b := range bins {
+		counts[i] = b.Count
+	}
+	slices.Sort(counts)
+	median := counts[len(counts)/2]
+	aboveMedian := func(count int) bool {
+		return count > burstFactor*median
+	}
+	if !aboveMedian(bins[peak].Count) {
+		return bins[peak].StartTime, time.Time{}
+	}
+
+	start := peak
+	for start > 0 && aboveMedian(bins[start-1].Count) {
+		start--
+	}
+	return bins[peak].StartTime, bins[start].StartTime
+}
@@ -0,0 +1,99 @@
+package logging_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudLoggingClient_VolumeHistogram(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	// Entries per 10 minute bin: a burst starts at 10:30 and peaks at 10:40
+	counts := map[string]int{
+		"2024-01-01T10:00:00Z": 2,
+		"2024-01-01T10:10:00Z": 3,
+		"2024-01-01T10:20:00Z": 2,
+		"2024-01-01T10:30:00Z": 20,
+		"2024-01-01T10:40:00Z": 50,
+		"2024-01-01T10:50:00Z": 3,
+	}
+
+	mockClient := mocks.NewMockLoggingClientInterface(ctrl)
+	mockClient.EXPECT().
+		ListEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+			if req.Limit != 30 {
+				t.Errorf("Expected limit 30, got %d", req.Limit)
+			}
+			if !strings.HasPrefix(req.Filter, "(severity>=ERROR) AND timestamp>=") {
+				t.Errorf("Unexpected filter %s", req.Filter)
+			}
+			for from, count := range counts {
+				if strings.Contains(req.Filter, `timestamp>="`+from+`"`) {
+					resp := logging.ListEntriesResponse{Entries: make([]logging.LogEntry, min(count, req.Limit))}
+					if count > req.Limit {
+						resp.NextPageToken = "more"
+					}
+					return resp, nil
+				}
+			}
+			t.Errorf("Unexpected bin in filter %s", req.Filter)
+			return logging.ListEntriesResponse{}, nil
+		}).
+		Times(6)
+
+	client := logging.NewWithClient(mockClient)
+	resp, err := client.VolumeHistogram(context.Background(), logging.VolumeHistogramRequest{
+		Filter:    "severity>=ERROR",
+		StartTime: start,
+		EndTime:   end,
+		Bins:      6,
+		MaxPerBin: 30,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []int
+	for _, b := range resp.Bins {
+		got = append(got, b.Count)
+	}
+	want := []int{2, 3, 2, 20, 30, 3}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected counts %v, got %v", want, got)
+		}
+	}
+	if !resp.Bins[4].Truncated || !resp.Truncated || resp.Total != 60 {
+		t.Errorf("Expected the peak bin to be truncated and a total of 60, got %+v", resp)
+	}
+	if !resp.PeakStart.Equal(start.Add(40*time.Minute)) || !resp.BurstStart.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("Expected peak at 10:40 and burst from 10:30, got %v and %v", resp.PeakStart, resp.BurstStart)
+	}
+}
+
+func TestCloudLoggingClient_VolumeHistogramInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := logging.NewWithClient(mocks.NewMockLoggingClientInterface(ctrl))
+	now := time.Now()
+	for _, req := range []logging.VolumeHistogramRequest{
+		{Bins: 100},
+		{StartTime: now, EndTime: now.Add(-time.Hour)},
+	} {
+		if _, err := client.VolumeHistogram(context.Background(), req); err == nil {
+			t.Errorf("Expected error for %+v", req)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MaxLogIDLength is the maximum length of a log ID, before URL-encoding
+const MaxLogIDLength = 511
+
+// validLogID matches the log IDs Cloud Logging accepts
+var validLogID = regexp.MustCompile(`^[A-Za-z0-9/_.-]+$`)
+
+// ParentKinds are the kinds of resources log entries can be written to
+var ParentKinds = []string{"projects", "folders", "organizations", "billingAccounts"}
+
+// ValidateParent returns an error unless parent is a resource log entries
+// can be written to, e.g. "projects/my-project" or "folders/123456789"
+func ValidateParent(parent string) error {
+	kind, id, ok := strings.Cut(parent, "/")
+	if !ok || !slices.Contains(ParentKinds, kind) || id == "" || strings.Contains(id, "/") {
+		return fmt.Errorf(`invalid parent %q: must be "projects/PROJECT_ID", "folders/FOLDER_ID", "organizations/ORGANIZATION_ID", or "billingAccounts/BILLING_ACCOUNT_ID"`, parent)
+	}
+	return nil
+}
+
+// LogName returns the full resource name of the log logID of parent, e.g.
+// "folders/123456789/logs/audit%2Fexport"
+func LogName(parent, logID string) string {
+	return parent + "/logs/" + url.PathEscape(logID)
+}
+
+// ValidateLogID returns an error unless logID is a log ID Cloud Logging
+// accepts: letters, digits, "/", "_", "-", and ".", at most MaxLogIDLength
+// characters
+func ValidateLogID(logID string) error {
+	if logID == "" {
+		return fmt.Errorf("log ID is required")
+	}
+	if len(logID) > MaxLogIDLength {
+		return fmt.Errorf("invalid log ID %q: must be at most %d characters, got %d", logID, MaxLogIDLength, len(logID))
+	}
+	if !validLogID.MatchString(logID) {
+		i := strings.IndexFunc(logID, func(r rune) bool { return !validLogID.MatchString(string(r)) })
+		return fmt.Errorf(`invalid log ID %q: %q is not allowed, only letters, digits, "/", "_", "-", and "." are`, logID, []rune(logID[i:])[0])
+	}
+	return nil
+}
+
+// CutLogName splits a full log resource name like
+// "projects/my-project/logs/my-log" into its parent and its URL-encoded log
+// ID. It returns false when logName does not start with a parent of
+// ParentKinds followed by "/logs/", i.e. when logName is a log ID, even one
+// containing "/logs/" like "app/logs/requests".
+func CutLogName(logName string) (parent, escapedLogID string, ok bool) {
+	kind, rest, _ := strings.Cut(logName, "/")
+	id, rest, _ := strings.Cut(rest, "/")
+	escapedLogID, ok = strings.CutPrefix(rest, "logs/")
+	if !ok || !slices.Contains(ParentKinds, kind) || id == "" {
+		return "", "", false
+	}
+	return kind + "/" + id, escapedLogID, true
+}
+
+// NormalizeLogName validates logName, either a log ID or a full resource
+// name like "projects/my-project/logs/my-log", and returns it with the log
+// ID URL-encoded once, e.g. "projects/my-project/logs/a/b" becomes
+// "projects/my-project/logs/a%2Fb" and "a/b" becomes "a%2Fb".
+func NormalizeLogName(logName string) (string, error) {
+	parent, escapedLogID, full := CutLogName(logName)
+	if !full {
+		escapedLogID = logName
+	}
+
+	logID, err := url.PathUnescape(escapedLogID)
+	if err == nil {
+		err = ValidateLogID(logID)
+	}
+	switch {
+	case err != nil && full:
+		return "", fmt.Errorf("invalid log name %q: %w", logName, err)
+	case err != nil:
+		return "", err
+	case full:
+		return LogName(parent, logID), nil
+	}
+	return url.PathEscape(logID), nil
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateParent(t *testing.T) {
+	tests := []struct {
+		parent  string
+		wantErr bool
+	}{
+		{parent: "projects/my-project"},
+		{parent: "folders/123456789"},
+		{parent: "organizations/123456789"},
+		{parent: "billingAccounts/012345-567890-ABCDEF"},
+		{parent: "my-project", wantErr: true},
+		{parent: "folders/", wantErr: true},
+		{parent: "folders/123/logs", wantErr: true},
+		{parent: "buckets/123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.parent, func(t *testing.T) {
+			if err := ValidateParent(tt.parent); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateParent(%q) error = %v, wantErr %v", tt.parent, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLogName(t *testing.T) {
+	if got, want := LogName("folders/123", "audit/export"), "folders/123/logs/audit%2Fexport"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestNormalizeLogName(t *testing.T) {
+	tests := []struct {
+		logName string
+		want    string
+		wantErr bool
+	}{
+		{logName: "my-log", want: "my-log"},
+		{logName: "app/requests.v1", want: "app%2Frequests.v1"},
+		{logName: "app%2Frequests.v1", want: "app%2Frequests.v1"},
+		{logName: "app/logs/requests", want: "app%2Flogs%2Frequests"},
+		{logName: "my-project/logs/app", want: "my-project%2Flogs%2Fapp"},
+		{logName: "projects/my-project/logs/app/requests", want: "projects/my-project/logs/app%2Frequests"},
+		{logName: "projects/my-project/logs/app/logs/requests", want: "projects/my-project/logs/app%2Flogs%2Frequests"},
+		{logName: "projects/my-project/logs/app%2Frequests", want: "projects/my-project/logs/app%2Frequests"},
+		{logName: "folders/123/logs/audit", want: "folders/123/logs/audit"},
+		{logName: "", wantErr: true},
+		{logName: "my log", wantErr: true},
+		{logName: "ログ", wantErr: true},
+		{logName: strings.Repeat("a", MaxLogIDLength+1), wantErr: true},
+		{logName: "app%zz", wantErr: true},
+		{logName: "projects/my-project/logs/", wantErr: true},
+		{logName: "projects/my-project/logs/app%zz", wantErr: true},
+		{logName: "projects/my-project/logs/app%20log", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.logName, func(t *testing.T) {
+			got, err := NormalizeLogName(tt.logName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeLogName(%q) error = %v, wantErr %v", tt.logName, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeLogName(%q) = %q, want %q", tt.logName, got, tt.want)
+			}
+		})
+	}
+}
@@ -41,11 +41,26 @@ func (m *MockLoggingClient) EXPECT() *MockLoggingClientMockRecorder {
 	return m.recorder
 }
 
+// ExtractFieldValues mocks base method.
+func (m *MockLoggingClient) ExtractFieldValues(ctx context.Context, req logging.FieldValuesRequest) (logging.FieldValuesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExtractFieldValues", ctx, req)
+	ret0, _ := ret[0].(logging.FieldValuesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExtractFieldValues indicates an expected call of ExtractFieldValues.
+func (mr *MockLoggingClientMockRecorder) ExtractFieldValues(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtractFieldValues", reflect.TypeOf((*MockLoggingClient)(nil).ExtractFieldValues), ctx, req)
+}
+
 // ListEntries mocks base method.
-func (m *MockLoggingClient) ListEntries(ctx context.Context, req logging.ListEntriesRequest) ([]logging.LogEntry, error) {
+func (m *MockLoggingClient) ListEntries(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListEntries", ctx, req)
-	ret0, _ := ret[0].([]logging.LogEntry)
+	ret0, _ := ret[0].(logging.ListEntriesResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -56,6 +71,35 @@ func (mr *MockLoggingClientMockRecorder) ListEntries(ctx, req any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockLoggingClient)(nil).ListEntries), ctx, req)
 }
 
+// VolumeHistogram mocks base method.
+func (m *MockLoggingClient) VolumeHistogram(ctx context.Context, req logging.VolumeHistogramRequest) (logging.VolumeHistogramResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VolumeHistogram", ctx, req)
+	ret0, _ := ret[0].(logging.VolumeHistogramResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VolumeHistogram indicates an expected call of VolumeHistogram.
+func (mr *MockLoggingClientMockRecorder) VolumeHistogram(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VolumeHistogram", reflect.TypeOf((*MockLoggingClient)(nil).VolumeHistogram), ctx, req)
+}
+
+// WriteEntries mocks base method.
+func (m *MockLoggingClient) WriteEntries(ctx context.Context, req logging.WriteEntriesRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteEntries", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteEntries indicates an expected call of WriteEntries.
+func (mr *MockLoggingClientMockRecorder) WriteEntries(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteEntries", reflect.TypeOf((*MockLoggingClient)(nil).WriteEntries), ctx, req)
+}
+
 // WriteEntry mocks base method.
 func (m *MockLoggingClient) WriteEntry(ctx context.Context, logName string, entry logging.LogEntry) error {
 	m.ctrl.T.Helper()
@@ -95,10 +139,10 @@ func (m *MockLoggingClientInterface) EXPECT() *MockLoggingClientInterfaceMockRec
 }
 
 // ListEntries mocks base method.
-func (m *MockLoggingClientInterface) ListEntries(ctx context.Context, req logging.ListEntriesRequest) ([]logging.LogEntry, error) {
+func (m *MockLoggingClientInterface) ListEntries(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListEntries", ctx, req)
-	ret0, _ := ret[0].([]logging.LogEntry)
+	ret0, _ := ret[0].(logging.ListEntriesResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -109,6 +153,20 @@ func (mr *MockLoggingClientInterfaceMockRecorder) ListEntries(ctx, req any) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockLoggingClientInterface)(nil).ListEntries), ctx, req)
 }
 
+// WriteEntries mocks base method.
+func (m *MockLoggingClientInterface) WriteEntries(ctx context.Context, req logging.WriteEntriesRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteEntries", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteEntries indicates an expected call of WriteEntries.
+func (mr *MockLoggingClientInterfaceMockRecorder) WriteEntries(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteEntries", reflect.TypeOf((*MockLoggingClientInterface)(nil).WriteEntries), ctx, req)
+}
+
 // WriteEntry mocks base method.
 func (m *MockLoggingClientInterface) WriteEntry(ctx context.Context, logName string, entry logging.LogEntry) error {
 	m.ctrl.T.Helper()
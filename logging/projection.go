@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PayloadProjection selects fields of JSON payloads, to shrink the output
+// of entries with large structured payloads
+type PayloadProjection struct {
+	paths [][]string
+}
+
+// NewPayloadProjection returns a projection keeping the given payload paths.
+// Paths are dotted, e.g. "user.id", and may be prefixed with "jsonPayload."
+// or the JSONPath root "$.".
+func NewPayloadProjection(paths []string) (PayloadProjection, error) {
+	var projection PayloadProjection
+	for _, path := range paths {
+		trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$."), "jsonPayload.")
+		keys := strings.Split(trimmed, ".")
+		if trimmed == "" || slices.Contains(keys, "") {
+			return PayloadProjection{}, fmt.Errorf("invalid payload path %q: use dotted field names such as \"user.id\"", path)
+		}
+		projection.paths = append(projection.paths, keys)
+	}
+	return projection, nil
+}
+
+// Apply returns the entry with a payload only holding the projected paths
+// that it has, nested as in the original payload. Entries are returned
+// unchanged when the projection is empty.
+func (p PayloadProjection) Apply(entry LogEntry) LogEntry {
+	if len(p.paths) == 0 || entry.Payload == nil {
+		return entry
+	}
+
+	projected := make(map[string]any)
+	for _, keys := range p.paths {
+		value, ok := lookupPath(entry.Payload, keys)
+		if !ok {
+			continue
+		}
+		object := projected
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := object[key].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				object[key] = child
+			}
+			object = child
+		}
+		object[keys[len(keys)-1]] = value
+	}
+
+	entry.Payload = nil
+	if len(projected) > 0 {
+		entry.Payload = projected
+	}
+	return entry
+}
+
+// lookupPath returns the value at the keys of a JSON payload
+func lookupPath(payload map[string]any, keys []string) (any, bool) {
+	var value any = payload
+	for _, key := range keys {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,58 @@
+package logging_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+)
+
+func TestPayloadProjection_Apply(t *testing.T) {
+	entry := logging.LogEntry{
+		Severity: "ERROR",
+		Message:  "payment failed",
+		Payload: map[string]any{
+			"message": "payment failed",
+			"user":    map[string]any{"id": "u-42", "email": "alice@example.com"},
+			"order":   map[string]any{"id": "o-1", "items": []any{"a", "b"}},
+			"debug":   map[string]any{"dump": "..."},
+		},
+	}
+
+	projection, err := logging.NewPayloadProjection([]string{"message", "jsonPayload.user.id", "$.order.items", "missing.field"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got := projection.Apply(entry)
+
+	want := map[string]any{
+		"message": "payment failed",
+		"user":    map[string]any{"id": "u-42"},
+		"order":   map[string]any{"items": []any{"a", "b"}},
+	}
+	if !reflect.DeepEqual(got.Payload, want) {
+		t.Errorf("Expected payload %v, got %v", want, got.Payload)
+	}
+	if got.Severity != "ERROR" || got.Message != "payment failed" {
+		t.Errorf("Expected the other fields to be kept, got %+v", got)
+	}
+	if _, ok := entry.Payload["debug"]; !ok {
+		t.Error("Expected the original payload to be left unchanged")
+	}
+
+	if got := projection.Apply(logging.LogEntry{Message: "plain text"}); got.Payload != nil {
+		t.Errorf("Expected no payload for a text entry, got %v", got.Payload)
+	}
+	empty, _ := logging.NewPayloadProjection(nil)
+	if got := empty.Apply(entry); !reflect.DeepEqual(got, entry) {
+		t.Error("Expected an empty projection to keep the entry unchanged")
+	}
+}
+
+func TestNewPayloadProjectionInvalid(t *testing.T) {
+	for _, path := range []string{"", "jsonPayload.", "user..id", "$."} {
+		if _, err := logging.NewPayloadProjection([]string{path}); err == nil {
+			t.Errorf("Expected error for path %q", path)
+		}
+	}
+}
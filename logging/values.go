@@ -0,0 +1,285 @@
+package logging
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultValueScanEntries is the number of entries scanned by default
+	defaultValueScanEntries = 1000
+	// maxValueScanEntries bounds the entries scanned for one extraction
+	maxValueScanEntries = 10000
+	// defaultTopValues is the number of distinct values returned by default
+	defaultTopValues = 20
+)
+
+// FieldValuesRequest represents a request for the distinct values of a field
+// of the entries matching a filter
+type FieldValuesRequest struct {
+	ProjectID  string `json:"project_id,omitempty"` // defaults to the client's project
+	Filter     string `json:"filter,omitempty"`
+	Field      string `json:"field"`                 // e.g. "jsonPayload.user_id", "labels.version", or "httpRequest.status"
+	MaxEntries int    `json:"max_entries,omitempty"` // defaults to defaultValueScanEntries
+	Top        int    `json:"top,omitempty"`         // defaults to defaultTopValues
+}
+
+// FieldValueCount represents a distinct value of a field and the entries having it
+type FieldValueCount struct {
+	Value     string    `json:"value"`
+	Count     int       `json:"count"`
+	Percent   float64   `json:"percent"` // of the entries having the field
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// FieldValuesResponse represents the distinct values of a field, most frequent first
+type FieldValuesResponse struct {
+	Field            string            `json:"field"`
+	Filter           string            `json:"filter"`
+	EntriesScanned   int               `json:"entries_scanned"`
+	EntriesWithField int               `json:"entries_with_field"`
+	Distinct         int               `json:"distinct"`
+	Values           []FieldValueCount `json:"values"`
+	Truncated        bool              `json:"truncated,omitempty"` // more entries matched than were scanned
+}
+
+// ExtractFieldValues scans the entries matching a filter and counts the
+// distinct values of a field, e.g. to find how many users or versions an
+// error affects
+func (c *CloudLoggingClient) ExtractFieldValues(ctx context.Context, req FieldValuesRequest) (FieldValuesResponse, error) {
+	if req.Field == "" {
+		return FieldValuesResponse{}, fmt.Errorf("field is required")
+	}
+	if _, err := FieldValue(LogEntry{}, req.Field); err != nil {
+		return FieldValuesResponse{}, err
+	}
+	if req.MaxEntries <= 0 {
+		req.MaxEntries = defaultValueScanEntries
+	}
+	req.MaxEntries = min(req.MaxEntries, maxValueScanEntries)
+	if req.Top <= 0 {
+		req.Top = defaultTopValues
+	}
+
+	resp, err := c.client.ListEntries(ctx, ListEntriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    req.Filter,
+		Limit:     req.MaxEntries,
+	})
+	if err != nil {
+		return FieldValuesResponse{}, fmt.Errorf("failed to list log entries: %w", err)
+	}
+
+	result := FieldValuesResponse{
+		Field:          req.Field,
+		Filter:         req.Filter,
+		EntriesScanned: len(resp.Entries),
+		Truncated:      resp.NextPageToken != "",
+	}
+	counts := make(map[string]*FieldValueCount)
+	for _, entry := range resp.Entries {
+		value, _ := FieldValue(entry, req.Field)
+		if value == nil {
+			continue
+		}
+		result.EntriesWithField++
+
+		key := *value
+		count, ok := counts[key]
+		if !ok {
+			count = &FieldValueCount{Value: key, FirstSeen: entry.Timestamp, LastSeen: entry.Timestamp}
+			counts[key] = count
+		}
+		count.Count++
+		if entry.Timestamp.Before(count.FirstSeen) {
+			count.FirstSeen = entry.Timestamp
+		}
+		if entry.Timestamp.After(count.LastSeen) {
+			count.LastSeen = entry.Timestamp
+		}
+	}
+
+	result.Distinct = len(counts)
+	result.Values = make([]FieldValueCount, 0, len(counts))
+	for _, count := range counts {
+		count.Percent = math.Round(float64(count.Count)/float64(result.EntriesWithField)*10000) / 100
+		result.Values = append(result.Values, *count)
+	}
+	slices.SortFunc(result.Values, func(a, b FieldValueCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	if len(result.Values) > req.Top {
+		result.Values = result.Values[:req.Top]
+	}
+	return result, nil
+}
+
+// FieldValue returns the value of a field of an entry as a string, or nil
+// when the entry does not have it. Fields use the Cloud Logging names, e.g.
+// "severity", "labels.KEY", "resource.labels.KEY", "jsonPayload.PATH", or
+// "httpRequest.status". An error is returned for unsupported fields.
+func FieldValue(entry LogEntry, field string) (*string, error) {
+	str := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+
+	switch field {
+	case "severity":
+		return str(entry.Severity), nil
+	case "textPayload", "message", "jsonPayload.message":
+		return str(entry.Message), nil
+	case "insertId":
+		return str(entry.InsertID), nil
+	case "trace":
+		return str(entry.Trace), nil
+	case "spanId":
+		return str(entry.SpanID), nil
+	case "resource.type":
+		if entry.Resource == nil {
+			return nil, nil
+		}
+		return str(entry.Resource.Type), nil
+	case "httpRequest.requestMethod", "httpRequest.requestUrl", "httpRequest.status", "httpRequest.userAgent",
+		"httpRequest.remoteIp", "httpRequest.serverIp", "httpRequest.referer", "httpRequest.latency":
+		return httpRequestValue(entry.HTTPRequest, strings.TrimPrefix(field, "httpRequest.")), nil
+	case "sourceLocation.file":
+		if entry.SourceLocation == nil {
+			return nil, nil
+		}
+		return str(entry.SourceLocation.File), nil
+	case "sourceLocation.function":
+		if entry.SourceLocation == nil {
+			return nil, nil
+		}
+		return str(entry.SourceLocation.Function), nil
+	case "operation.id":
+		if entry.Operation == nil {
+			return nil, nil
+		}
+		return str(entry.Operation.ID), nil
+	case "protoPayload.serviceName", "protoPayload.methodName", "protoPayload.resourceName", "protoPayload.authenticationInfo.principalEmail",
+		"protoPayload.authenticationInfo.principalSubject", "protoPayload.requestMetadata.callerIp":
+		return auditLogValue(entry.AuditLog, strings.TrimPrefix(field, "protoPayload.")), nil
+	}
+
+	switch {
+	case strings.HasPrefix(field, "labels.") && len(field) > len("labels."):
+		return labelValue(entry.Labels, strings.TrimPrefix(field, "labels.")), nil
+	case strings.HasPrefix(field, "resource.labels.") && len(field) > len("resource.labels."):
+		if entry.Resource == nil {
+			return nil, nil
+		}
+		return labelValue(entry.Resource.Labels, strings.TrimPrefix(field, "resource.labels.")), nil
+	case strings.HasPrefix(field, "jsonPayload.") && len(field) > len("jsonPayload."):
+		return payloadValue(entry.Payload, strings.TrimPrefix(field, "jsonPayload.")), nil
+	}
+	return nil, fmt.Errorf("unsupported field %q: use severity, textPayload, insertId, trace, spanId, resource.type, labels.KEY, resource.labels.KEY, jsonPayload.PATH, httpRequest.FIELD, sourceLocation.file, sourceLocation.function, operation.id, or protoPayload.FIELD", field)
+}
+
+// labelValue returns the value of a label, allowing the key to be quoted as
+// in filters, e.g. labels."k8s-pod/app"
+func labelValue(labels map[string]string, key string) *string {
+	if unquoted, err := strconv.Unquote(key); err == nil {
+		key = unquoted
+	}
+	value, ok := labels[key]
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// payloadValue returns the value at a dotted path of a JSON payload. Strings
+// are returned as is and other values as JSON.
+func payloadValue(payload map[string]any, path string) *string {
+	value, ok := lookupPath(payload, strings.Split(path, "."))
+	if !ok {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return &v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		s := string(data)
+		return &s
+	}
+}
+
+// httpRequestValue returns a field of an HTTP request, named as in Cloud Logging
+func httpRequestValue(req *HTTPRequest, field string) *string {
+	if req == nil {
+		return nil
+	}
+	var value string
+	switch field {
+	case "requestMethod":
+		value = req.Method
+	case "requestUrl":
+		value = req.URL
+	case "status":
+		if req.Status != 0 {
+			value = strconv.Itoa(req.Status)
+		}
+	case "userAgent":
+		value = req.UserAgent
+	case "remoteIp":
+		value = req.RemoteIP
+	case "serverIp":
+		value = req.ServerIP
+	case "referer":
+		value = req.Referer
+	case "latency":
+		value = req.Latency
+	}
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// auditLogValue returns a field of an audit log, named as in Cloud Logging
+func auditLogValue(auditLog *AuditLog, field string) *string {
+	if auditLog == nil {
+		return nil
+	}
+	var value string
+	switch field {
+	case "serviceName":
+		value = auditLog.ServiceName
+	case "methodName":
+		value = auditLog.MethodName
+	case "resourceName":
+		value = auditLog.ResourceName
+	case "authenticationInfo.principalEmail":
+		value = auditLog.PrincipalEmail
+	case "authenticationInfo.principalSubject":
+		value = auditLog.PrincipalSubject
+	case "requestMetadata.callerIp":
+		value = auditLog.CallerIP
+	}
+	if value == "" {
+		return nil
+	}
+	return &value
+}
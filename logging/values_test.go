@@ -0,0 +1,130 @@
+package logging_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFieldValue(t *testing.T) {
+	entry := logging.LogEntry{
+		Severity: "ERROR",
+		Message:  "payment failed",
+		Labels:   map[string]string{"version": "1.2.0", "k8s-pod/app": "checkout"},
+		Payload: map[string]any{
+			"message": "payment failed",
+			"user":    map[string]any{"id": "u-42", "plan": map[string]any{"tier": 2.0}},
+		},
+		Resource:    &logging.MonitoredResource{Type: "k8s_container", Labels: map[string]string{"namespace_name": "shop"}},
+		HTTPRequest: &logging.HTTPRequest{Method: "POST", Status: 502},
+	}
+
+	tests := []struct {
+		field   string
+		want    string
+		missing bool
+		wantErr bool
+	}{
+		{field: "severity", want: "ERROR"},
+		{field: "labels.version", want: "1.2.0"},
+		{field: `labels."k8s-pod/app"`, want: "checkout"},
+		{field: "labels.zone", missing: true},
+		{field: "resource.type", want: "k8s_container"},
+		{field: "resource.labels.namespace_name", want: "shop"},
+		{field: "jsonPayload.user.id", want: "u-42"},
+		{field: "jsonPayload.user.plan", want: `{"tier":2}`},
+		{field: "jsonPayload.user.id.extra", missing: true},
+		{field: "httpRequest.status", want: "502"},
+		{field: "httpRequest.remoteIp", missing: true},
+		{field: "protoPayload.methodName", missing: true},
+		{field: "timestamp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, err := logging.FieldValue(entry, tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FieldValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.missing {
+				if got != nil {
+					t.Errorf("FieldValue() = %s, want missing", *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.want {
+				t.Errorf("FieldValue() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloudLoggingClient_ExtractFieldValues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	entry := func(minute int, user string) logging.LogEntry {
+		e := logging.LogEntry{Timestamp: base.Add(time.Duration(minute) * time.Minute), Payload: map[string]any{}}
+		if user != "" {
+			e.Payload["user_id"] = user
+		}
+		return e
+	}
+
+	mockClient := mocks.NewMockLoggingClientInterface(ctrl)
+	mockClient.EXPECT().
+		ListEntries(gomock.Any(), logging.ListEntriesRequest{Filter: "severity>=ERROR", Limit: 1000}).
+		Return(logging.ListEntriesResponse{
+			Entries: []logging.LogEntry{
+				entry(5, "alice"), entry(4, "bob"), entry(3, "alice"), entry(2, ""), entry(1, "carol"), entry(0, "alice"),
+			},
+			NextPageToken: "more",
+		}, nil).
+		Times(1)
+
+	client := logging.NewWithClient(mockClient)
+	resp, err := client.ExtractFieldValues(context.Background(), logging.FieldValuesRequest{
+		Filter: "severity>=ERROR",
+		Field:  "jsonPayload.user_id",
+		Top:    2,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.EntriesScanned != 6 || resp.EntriesWithField != 5 || resp.Distinct != 3 || !resp.Truncated {
+		t.Errorf("Unexpected counts %+v", resp)
+	}
+	want := []logging.FieldValueCount{
+		{Value: "alice", Count: 3, Percent: 60, FirstSeen: base, LastSeen: base.Add(5 * time.Minute)},
+		{Value: "bob", Count: 1, Percent: 20, FirstSeen: base.Add(4 * time.Minute), LastSeen: base.Add(4 * time.Minute)},
+	}
+	if len(resp.Values) != len(want) {
+		t.Fatalf("Expected %d values, got %+v", len(want), resp.Values)
+	}
+	for i, v := range want {
+		if resp.Values[i] != v {
+			t.Errorf("Value %d: expected %+v, got %+v", i, v, resp.Values[i])
+		}
+	}
+}
+
+func TestCloudLoggingClient_ExtractFieldValuesInvalidField(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := logging.NewWithClient(mocks.NewMockLoggingClientInterface(ctrl))
+	for _, field := range []string{"", "timestamp", "labels."} {
+		if _, err := client.ExtractFieldValues(context.Background(), logging.FieldValuesRequest{Field: field}); err == nil {
+			t.Errorf("Expected error for field %q", field)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EntryWriteError represents the failure to write one entry of a request
+type EntryWriteError struct {
+	// Index is the position of the entry in the request
+	Index int    `json:"index"`
+	Error string `json:"error"`
+
+	err error
+}
+
+// PartialWriteError is returned when some of the entries of a synchronous
+// write failed. The other entries were written.
+type PartialWriteError struct {
+	Written int               `json:"written"` // entries written
+	Failed  int               `json:"failed"`  // entries not written
+	Errors  []EntryWriteError `json:"errors"`  // one item per failed entry, in order
+}
+
+// Error implements the error interface
+func (e *PartialWriteError) Error() string {
+	var failed []string
+	for _, entry := range e.Errors {
+		failed = append(failed, fmt.Sprintf("entry %d: %s", entry.Index, entry.Error))
+	}
+	return fmt.Sprintf("wrote %d entries, but failed to write %d: %s", e.Written, e.Failed, strings.Join(failed, "; "))
+}
+
+// Unwrap returns the errors of the failed entries, e.g. so that errors.Is
+// finds a canceled context
+func (e *PartialWriteError) Unwrap() []error {
+	var errs []error
+	for _, entry := range e.Errors {
+		if entry.err != nil {
+			errs = append(errs, entry.err)
+		}
+	}
+	return errs
+}
+
+// add records the outcome of writing the entry at index
+func (e *PartialWriteError) add(index int, err error) {
+	if err == nil {
+		e.Written++
+		return
+	}
+	e.Failed++
+	e.Errors = append(e.Errors, EntryWriteError{Index: index, Error: err.Error(), err: err})
+}
@@ -2,18 +2,34 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	// Embed the time zone database, so that time zones can be set in
+	// containers without one
+	_ "time/tzdata"
+
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+	"github.com/kitagry/gcp-telemetry-mcp/credentials"
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/gcptelemetry"
 	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/middleware"
 	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/otlp"
 	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/kitagry/gcp-telemetry-mcp/replay"
+	"github.com/kitagry/gcp-telemetry-mcp/session"
 	"github.com/kitagry/gcp-telemetry-mcp/trace"
-	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"google.golang.org/api/option"
 )
 
 var (
@@ -24,6 +40,15 @@ var (
 
 func main() {
 	showVersion := flag.Bool("version", false, "show version information")
+	transport := flag.String("transport", "stdio", "transport to serve MCP over: stdio or http")
+	addr := flag.String("addr", "localhost:8080", "address to listen on with the http transport; the server does not authenticate clients, so put an authenticating proxy in front of it before listening on other interfaces")
+	readOnly := flag.Bool("read-only", false, "only register tools that do not write to Google Cloud, and request read-only OAuth scopes")
+	login := flag.Bool("login", false, "log in with your Google account in the browser and cache the credentials used instead of Application Default Credentials, then exit. Requires GCP_TELEMETRY_MCP_OAUTH_CLIENT_SECRETS")
+	fakeBackend := flag.Bool("fake-backend", false, "serve in-memory telemetry instead of calling Google Cloud, to develop and demo the tools without a project")
+	fakeSeed := flag.String("fake-seed", "", "with -fake-backend, JSON file of the telemetry loaded into the fake backend")
+	recordFile := flag.String("record", "", "record the Google Cloud API calls and their responses to a cassette file, to be served with -replay")
+	replayFile := flag.String("replay", "", "serve the API responses recorded with -record to a cassette file instead of calling Google Cloud")
+	enableOTLP := flag.Bool("otlp", false, "with the http transport, also receive OTLP/HTTP (JSON) spans and logs at /v1/traces and /v1/logs and forward them to Cloud Trace and Cloud Logging")
 	flag.Parse()
 
 	if *showVersion {
@@ -31,1217 +56,353 @@ func main() {
 		return
 	}
 
-	// Get project ID from environment variable
-	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
-	if projectID == "" {
-		fmt.Printf("GOOGLE_CLOUD_PROJECT environment variable not set\n")
-		os.Exit(1)
+	if *login {
+		clientSecrets := os.Getenv("GCP_TELEMETRY_MCP_OAUTH_CLIENT_SECRETS")
+		if clientSecrets == "" {
+			fmt.Printf("GCP_TELEMETRY_MCP_OAUTH_CLIENT_SECRETS environment variable not set\n")
+			os.Exit(1)
+		}
+		oauthClient, err := credentials.LoadOAuthClient(clientSecrets)
+		if err != nil {
+			fmt.Printf("Failed to load OAuth client: %v\n", err)
+			os.Exit(1)
+		}
+		path, err := credentials.UserCredentialsPath()
+		if err != nil {
+			fmt.Printf("Failed to determine credentials location: %v\n", err)
+			os.Exit(1)
+		}
+		if err := credentials.Login(context.Background(), oauthClient, path, credentials.OpenBrowser); err != nil {
+			fmt.Printf("Failed to log in: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved credentials to %s\n", path)
+		return
 	}
 
-	// Create Cloud Logging client
-	loggingClient, err := logging.New(projectID)
-	if err != nil {
-		fmt.Printf("Failed to create logging client: %v\n", err)
+	if *transport != "stdio" && *transport != "http" {
+		fmt.Printf("Unsupported transport %q: must be stdio or http\n", *transport)
 		os.Exit(1)
 	}
-
-	// Create Cloud Monitoring client
-	monitoringClient, err := monitoring.New(projectID)
-	if err != nil {
-		fmt.Printf("Failed to create monitoring client: %v\n", err)
+	if *enableOTLP && *transport != "http" {
+		fmt.Printf("-otlp requires -transport=http\n")
 		os.Exit(1)
 	}
-
-	// Create Cloud Trace client
-	traceClient, err := trace.New(projectID)
-	if err != nil {
-		fmt.Printf("Failed to create trace client: %v\n", err)
+	if *fakeSeed != "" && !*fakeBackend {
+		fmt.Printf("-fake-seed requires -fake-backend\n")
 		os.Exit(1)
 	}
-
-	// Create Cloud Profiler client
-	profilerClient, err := profiler.New(projectID)
-	if err != nil {
-		fmt.Printf("Failed to create profiler client: %v\n", err)
+	if *recordFile != "" && *replayFile != "" {
+		fmt.Printf("-record cannot be used with -replay\n")
 		os.Exit(1)
 	}
-
-	// Create a new MCP server
-	s := server.NewMCPServer(
-		"GCP Telemetry MCP",
-		version,
-		server.WithToolCapabilities(true),
-	)
-
-	// Add write_log_entry tool
-	writeLogTool := mcp.NewTool("write_log_entry",
-		mcp.WithDescription("Write a log entry to Cloud Logging"),
-		mcp.WithString("log_name",
-			mcp.Required(),
-			mcp.Description("Name of the log to write to"),
-		),
-		mcp.WithString("severity",
-			mcp.Required(),
-			mcp.Description("Log severity: DEBUG, INFO, WARNING, ERROR, CRITICAL"),
-		),
-		mcp.WithString("message",
-			mcp.Required(),
-			mcp.Description("Log message"),
-		),
-		mcp.WithObject("labels",
-			mcp.Description("Optional labels for the log entry"),
-		),
-		mcp.WithObject("payload",
-			mcp.Description("Optional structured payload for the log entry"),
-		),
-	)
-
-	// Add list_log_entries tool
-	listLogsTool := mcp.NewTool("list_log_entries",
-		mcp.WithDescription("List log entries from Cloud Logging"),
-		mcp.WithString("filter",
-			mcp.Description(`Filter sets an advanced logs filter for listing log entries (see
-https://cloud.google.com/logging/docs/view/advanced_filters). The filter is compared against all log entries in the projects specified by ProjectIDs. Only entries that match the filter are retrieved. An empty filter (the default) matches all log entries.
-
-In the filter string, log names must be written in their full form, as "projects/PROJECT-ID/logs/LOG-ID". Forward slashes in LOG-ID must be replaced by %2F before calling Filter.
-
-Timestamps in the filter string must be written in RFC 3339 format. By default, timestamp filters for the past 24 hours.
-
-e.x.)
-
-* If you want to filter logs for a specific Kubernetes pod, you can use a filter like this:
-
-resource.type = "k8s_container"
-resource.labels.project_id="YOUR PROJECT ID"
-resource.labels.cluster_name="YOUR CLUSTER NAME"
-resource.labels.namespace_name="YOUR NAMESPACE NAME"
-(labels.k8s-pod/app="YOUR APP LABEL NAME")
-`),
-		),
-		mcp.WithNumber("limit",
-			mcp.Description("Maximum number of entries to return (default: 50)"),
-		),
-	)
-
-	// Add create_metric_descriptor tool
-	createMetricTool := mcp.NewTool("create_metric_descriptor",
-		mcp.WithDescription("Create a custom metric descriptor in Cloud Monitoring"),
-		mcp.WithString("type",
-			mcp.Required(),
-			mcp.Description("Metric type (e.g., 'custom.googleapis.com/my_metric')"),
-		),
-		mcp.WithString("metric_kind",
-			mcp.Required(),
-			mcp.Description("Metric kind: GAUGE, DELTA, or CUMULATIVE"),
-		),
-		mcp.WithString("value_type",
-			mcp.Required(),
-			mcp.Description("Value type: BOOL, INT64, DOUBLE, STRING, or DISTRIBUTION"),
-		),
-		mcp.WithString("description",
-			mcp.Required(),
-			mcp.Description("Description of the metric"),
-		),
-		mcp.WithString("display_name",
-			mcp.Description("Display name for the metric"),
-		),
-	)
-
-	// Add write_time_series tool
-	writeTimeSeresTool := mcp.NewTool("write_time_series",
-		mcp.WithDescription("Write time series data to Cloud Monitoring"),
-		mcp.WithString("metric_type",
-			mcp.Required(),
-			mcp.Description("Metric type to write data for"),
-		),
-		mcp.WithString("resource_type",
-			mcp.Required(),
-			mcp.Description("Resource type (e.g., 'global', 'gce_instance')"),
-		),
-		mcp.WithNumber("value",
-			mcp.Required(),
-			mcp.Description("Metric value to write"),
-		),
-		mcp.WithObject("metric_labels",
-			mcp.Description("Optional metric labels"),
-		),
-		mcp.WithString("timestamp",
-			mcp.Description("Timestamp for the data point (ISO 8601 format, defaults to now)"),
-		),
-	)
-
-	// Add list_time_series tool
-	listTimeSeresTool := mcp.NewTool("list_time_series",
-		mcp.WithDescription("List time series data from Cloud Monitoring"),
-		mcp.WithString("filter",
-			mcp.Required(),
-			mcp.Description(`A [monitoring filter](https://cloud.google.com/monitoring/api/v3/filters) that specifies which time series should be returned.  The filter must specify a single metric type, and can additionally specify metric labels and other information. For example:
-
-    metric.type = "compute.googleapis.com/instance/cpu/usage_time" AND
-        metric.labels.instance_name = "my-instance-name"
-			`),
-		),
-		mcp.WithString("start_time",
-			mcp.Required(),
-			mcp.Description("Start time for the query (ISO 8601 format)"),
-		),
-		mcp.WithString("end_time",
-			mcp.Required(),
-			mcp.Description("End time for the query (ISO 8601 format)"),
-		),
-		mcp.WithObject("aggregation",
-			mcp.Description("Optional aggregation configuration"),
-		),
-		mcp.WithNumber("page_size",
-			mcp.Description("Maximum number of time series to return (default: 100)"),
-		),
-		mcp.WithString("page_token",
-			mcp.Description("Page token for pagination"),
-		),
-	)
-
-	// Add list_metric_descriptors tool
-	listMetricDescriptorsTool := mcp.NewTool("list_metric_descriptors",
-		mcp.WithDescription("List metric descriptors from Cloud Monitoring"),
-		mcp.WithString("filter",
-			mcp.Description(`Filter expression for metric descriptors.
-If this field is empty, all custom and system-defined metric descriptors are returned.
-Otherwise, the [filter](https://cloud.google.com/monitoring/api/v3/filters) specifies which metric descriptors are to be returned. For example, the following filter matches all [custom metrics](https://cloud.google.com/monitoring/custom-metrics):
-
-metric.type = starts_with("custom.googleapis.com/")
-`),
-		),
-		mcp.WithNumber("page_size",
-			mcp.Description("Maximum number of descriptors to return (default: 100)"),
-		),
-		mcp.WithString("page_token",
-			mcp.Description("Page token for pagination"),
-		),
-	)
-
-	// Add delete_metric_descriptor tool
-	deleteMetricTool := mcp.NewTool("delete_metric_descriptor",
-		mcp.WithDescription("Delete a custom metric descriptor from Cloud Monitoring"),
-		mcp.WithString("metric_type",
-			mcp.Required(),
-			mcp.Description("Metric type to delete"),
-		),
-	)
-
-	// Add list_available_metrics tool
-	listAvailableMetricsTool := mcp.NewTool("list_available_metrics",
-		mcp.WithDescription("List available metrics in Cloud Monitoring"),
-		mcp.WithString("filter",
-			mcp.Description(`Filter expression for metric descriptors.
-If this field is empty, all custom and system-defined metric descriptors are returned.
-Otherwise, the [filter](https://cloud.google.com/monitoring/api/v3/filters) specifies which metric descriptors are to be returned. For example, the following filter matches all [custom metrics](https://cloud.google.com/monitoring/custom-metrics):
-
-metric.type = starts_with("custom.googleapis.com/")
-`),
-		),
-		mcp.WithNumber("page_size",
-			mcp.Description("Maximum number of metrics to return (default: 100)"),
-		),
-		mcp.WithString("page_token",
-			mcp.Description("Page token for pagination"),
-		),
-	)
-
-	// Add list_traces tool
-	listTracesTool := mcp.NewTool("list_traces",
-		mcp.WithDescription("List traces from Cloud Trace"),
-		mcp.WithString("start_time",
-			mcp.Required(),
-			mcp.Description("Start time for the query (ISO 8601 format)"),
-		),
-		mcp.WithString("end_time",
-			mcp.Required(),
-			mcp.Description("End time for the query (ISO 8601 format)"),
-		),
-		mcp.WithString("filter",
-			mcp.Description(`By default, searches use prefix matching. To specify exact match, prepend
-  a plus symbol (+) to the search term.
-  Multiple terms are ANDed. Syntax:
-
-    - root:NAME_PREFIX or NAME_PREFIX: Return traces where any root
-      span starts with NAME_PREFIX.
-    - +root:NAME or +NAME: Return traces where any root span's name is
-      exactly NAME.
-    - span:NAME_PREFIX: Return traces where any span starts with
-      NAME_PREFIX.
-    - +span:NAME: Return traces where any span's name is exactly
-      NAME.
-    - latency:DURATION: Return traces whose overall latency is
-      greater or equal to than DURATION. Accepted units are nanoseconds
-      (ns), milliseconds (ms), and seconds (s). Default is ms. For
-      example, latency:24ms returns traces whose overall latency
-      is greater than or equal to 24 milliseconds.
-    - label:LABEL_KEY: Return all traces containing the specified
-      label key (exact match, case-sensitive) regardless of the key:value
-      pair's value (including empty values).
-    - LABEL_KEY:VALUE_PREFIX: Return all traces containing the specified
-      label key (exact match, case-sensitive) whose value starts with
-      VALUE_PREFIX. Both a key and a value must be specified.
-    - +LABEL_KEY:VALUE: Return all traces containing a key:value pair
-      exactly matching the specified text. Both a key and a value must be
-      specified.
-    - method:VALUE: Equivalent to /http/method:VALUE.
-    - url:VALUE: Equivalent to /http/url:VALUE.
-      `),
-		),
-		mcp.WithString("order_by",
-			mcp.Description("Order by field (e.g., 'start_time desc')"),
-		),
-		mcp.WithNumber("page_size",
-			mcp.Description("Maximum number of traces to return (default: 100)"),
-		),
-		mcp.WithString("page_token",
-			mcp.Description("Page token for pagination"),
-		),
-	)
-
-	// Add get_trace tool
-	getTraceTool := mcp.NewTool("get_trace",
-		mcp.WithDescription("Get a specific trace from Cloud Trace"),
-		mcp.WithString("trace_id",
-			mcp.Required(),
-			mcp.Description("Trace ID to retrieve"),
-		),
-	)
-
-	// Add patch_traces tool
-	patchTracesTool := mcp.NewTool("patch_traces",
-		mcp.WithDescription("Update trace spans in Cloud Trace"),
-		mcp.WithString("trace_id",
-			mcp.Required(),
-			mcp.Description("Trace ID to update"),
-		),
-		mcp.WithObject("spans",
-			mcp.Required(),
-			mcp.Description("Array of span objects to update or create"),
-		),
-	)
-
-	// Add create_profile tool
-	createProfileTool := mcp.NewTool("create_profile",
-		mcp.WithDescription("Create a new profile in Cloud Profiler"),
-		mcp.WithString("target",
-			mcp.Required(),
-			mcp.Description("Target deployment name"),
-		),
-		mcp.WithString("profile_type",
-			mcp.Required(),
-			mcp.Description("Profile type: CPU, HEAP, THREADS, CONTENTION, or WALL"),
-		),
-		mcp.WithString("duration",
-			mcp.Description("Profile duration (e.g., '60s', '5m', defaults to '60s')"),
-		),
-		mcp.WithObject("labels",
-			mcp.Description("Optional labels for the profile"),
-		),
-	)
-
-	// Add create_offline_profile tool
-	createOfflineProfileTool := mcp.NewTool("create_offline_profile",
-		mcp.WithDescription("Create an offline profile in Cloud Profiler"),
-		mcp.WithString("target",
-			mcp.Required(),
-			mcp.Description("Target deployment name"),
-		),
-		mcp.WithString("profile_type",
-			mcp.Required(),
-			mcp.Description("Profile type: CPU, HEAP, THREADS, CONTENTION, or WALL"),
-		),
-		mcp.WithString("profile_data",
-			mcp.Required(),
-			mcp.Description("Base64-encoded profile data"),
-		),
-		mcp.WithString("duration",
-			mcp.Description("Profile duration (e.g., '60s', '5m')"),
-		),
-		mcp.WithObject("labels",
-			mcp.Description("Optional labels for the profile"),
-		),
-	)
-
-	// Add update_profile tool
-	updateProfileTool := mcp.NewTool("update_profile",
-		mcp.WithDescription("Update a profile in Cloud Profiler"),
-		mcp.WithString("profile_name",
-			mcp.Required(),
-			mcp.Description("Profile name to update"),
-		),
-		mcp.WithString("profile_data",
-			mcp.Description("Updated base64-encoded profile data"),
-		),
-		mcp.WithObject("labels",
-			mcp.Description("Updated labels for the profile"),
-		),
-		mcp.WithString("update_mask",
-			mcp.Description("Fields to update (e.g., 'labels,profile_bytes')"),
-		),
-	)
-
-	// Add list_profiles tool
-	listProfilesTool := mcp.NewTool("list_profiles",
-		mcp.WithDescription("List profiles from Cloud Profiler"),
-		mcp.WithNumber("page_size",
-			mcp.Description("Maximum number of profiles to return (default: 100)"),
-		),
-		mcp.WithString("page_token",
-			mcp.Description("Page token for pagination"),
-		),
-	)
-
-	// Add tool handlers
-	s.AddTool(writeLogTool, createWriteLogHandler(loggingClient))
-	s.AddTool(listLogsTool, createListLogsHandler(loggingClient))
-	s.AddTool(createMetricTool, createMetricDescriptorHandler(monitoringClient))
-	s.AddTool(writeTimeSeresTool, createWriteTimeSeriesHandler(monitoringClient))
-	s.AddTool(listTimeSeresTool, createListTimeSeriesHandler(monitoringClient))
-	s.AddTool(listMetricDescriptorsTool, createListMetricDescriptorsHandler(monitoringClient))
-	s.AddTool(deleteMetricTool, createDeleteMetricDescriptorHandler(monitoringClient))
-	s.AddTool(listAvailableMetricsTool, createListAvailableMetricsHandler(monitoringClient))
-	s.AddTool(listTracesTool, createListTracesHandler(traceClient))
-	s.AddTool(getTraceTool, createGetTraceHandler(traceClient))
-	s.AddTool(patchTracesTool, createPatchTracesHandler(traceClient))
-	s.AddTool(createProfileTool, createProfileHandler(profilerClient))
-	s.AddTool(createOfflineProfileTool, createOfflineProfileHandler(profilerClient))
-	s.AddTool(updateProfileTool, updateProfileHandler(profilerClient))
-	s.AddTool(listProfilesTool, listProfilesHandler(profilerClient))
-
-	// Start the stdio server
-	if err := server.ServeStdio(s); err != nil {
-		fmt.Printf("Server error: %v\n", err)
+	if *fakeBackend && *replayFile != "" {
+		fmt.Printf("-fake-backend cannot be used with -replay\n")
+		os.Exit(1)
 	}
-}
-
-// createWriteLogHandler creates a handler for writing log entries
-func createWriteLogHandler(client logging.LoggingClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		logName, err := request.RequireString("log_name")
-		if err != nil {
-			return mcp.NewToolResultError("log_name is required"), nil
-		}
-
-		severity, err := request.RequireString("severity")
-		if err != nil {
-			return mcp.NewToolResultError("severity is required"), nil
-		}
-
-		message, err := request.RequireString("message")
-		if err != nil {
-			return mcp.NewToolResultError("message is required"), nil
-		}
-
-		// Optional parameters - simplified for now
-		var labels map[string]string
-		var payload map[string]any
-
-		entry := logging.LogEntry{
-			Severity: severity,
-			Message:  message,
-			Labels:   labels,
-			Payload:  payload,
-		}
-
-		err = client.WriteEntry(ctx, logName, entry)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to write log entry: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText("Log entry written successfully"), nil
+	if *enableOTLP && *readOnly {
+		fmt.Printf("-otlp cannot be used with -read-only\n")
+		os.Exit(1)
 	}
-}
-
-// createListLogsHandler creates a handler for listing log entries
-func createListLogsHandler(client logging.LoggingClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		req := logging.ListEntriesRequest{
-			Limit: 50, // default
-		}
-
-		// Parse optional filter parameter
-		args := request.GetArguments()
-		if filterArg, exists := args["filter"]; exists {
-			if filter, ok := filterArg.(string); ok && filter != "" {
-				req.Filter = filter
-			}
-		}
-
-		// Parse optional limit parameter
-		if limitArg, exists := args["limit"]; exists {
-			if limitFloat, ok := limitArg.(float64); ok && limitFloat > 0 {
-				req.Limit = int(limitFloat)
-			}
-		}
 
-		entries, err := client.ListEntries(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to list log entries: %v", err)), nil
-		}
-
-		// Convert entries to JSON for response
-		entriesJSON, err := json.MarshalIndent(entries, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal entries: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText(string(entriesJSON)), nil
+	// Get project ID from environment variable. The fake backend and replayed
+	// cassettes need no Google Cloud project.
+	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if projectID == "" && (*fakeBackend || *replayFile != "") {
+		projectID = fake.DefaultProjectID
 	}
-}
-
-// createMetricDescriptorHandler creates a handler for creating metric descriptors
-func createMetricDescriptorHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		metricType, err := request.RequireString("type")
-		if err != nil {
-			return mcp.NewToolResultError("type is required"), nil
-		}
-
-		metricKind, err := request.RequireString("metric_kind")
-		if err != nil {
-			return mcp.NewToolResultError("metric_kind is required"), nil
-		}
-
-		valueType, err := request.RequireString("value_type")
-		if err != nil {
-			return mcp.NewToolResultError("value_type is required"), nil
-		}
-
-		description, err := request.RequireString("description")
-		if err != nil {
-			return mcp.NewToolResultError("description is required"), nil
-		}
-
-		args := request.GetArguments()
-		displayName := ""
-		if displayNameArg, exists := args["display_name"]; exists {
-			if dn, ok := displayNameArg.(string); ok {
-				displayName = dn
-			}
-		}
-
-		req := monitoring.CreateMetricRequest{
-			MetricDescriptor: monitoring.MetricDescriptor{
-				Type:        metricType,
-				MetricKind:  metricKind,
-				ValueType:   valueType,
-				Description: description,
-				DisplayName: displayName,
-			},
-		}
-
-		err = client.CreateMetricDescriptor(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to create metric descriptor: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText("Metric descriptor created successfully"), nil
+	if projectID == "" {
+		fmt.Printf("GOOGLE_CLOUD_PROJECT environment variable not set\n")
+		os.Exit(1)
 	}
-}
 
-// createWriteTimeSeriesHandler creates a handler for writing time series data
-func createWriteTimeSeriesHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		metricType, err := request.RequireString("metric_type")
-		if err != nil {
-			return mcp.NewToolResultError("metric_type is required"), nil
+	// Load the infoTypes scanned for by list_log_entries with scan_and_redact
+	var dlpInfoTypes []string
+	for _, infoType := range strings.Split(os.Getenv("GCP_TELEMETRY_MCP_DLP_INFO_TYPES"), ",") {
+		if infoType = strings.TrimSpace(infoType); infoType != "" {
+			dlpInfoTypes = append(dlpInfoTypes, infoType)
 		}
-
-		resourceType, err := request.RequireString("resource_type")
-		if err != nil {
-			return mcp.NewToolResultError("resource_type is required"), nil
-		}
-
-		valueArg, err := request.RequireFloat("value")
-		if err != nil {
-			return mcp.NewToolResultError("value is required"), nil
-		}
-
-		args := request.GetArguments()
-
-		// Parse timestamp
-		timestamp := time.Now()
-		if timestampArg, exists := args["timestamp"]; exists {
-			if ts, ok := timestampArg.(string); ok && ts != "" {
-				if parsedTime, parseErr := time.Parse(time.RFC3339, ts); parseErr == nil {
-					timestamp = parsedTime
-				}
-			}
-		}
-
-		// Parse metric labels
-		var metricLabels map[string]string
-		if labelsArg, exists := args["metric_labels"]; exists {
-			if labels, ok := labelsArg.(map[string]any); ok {
-				metricLabels = make(map[string]string)
-				for k, v := range labels {
-					if str, ok := v.(string); ok {
-						metricLabels[k] = str
-					}
-				}
-			}
-		}
-
-		timeSeries := monitoring.TimeSeriesData{
-			MetricType:   metricType,
-			MetricLabels: metricLabels,
-			ResourceType: resourceType,
-			Values: []monitoring.MetricValue{
-				{
-					Value:     valueArg,
-					Timestamp: timestamp,
-				},
-			},
-		}
-
-		req := monitoring.WriteTimeSeriesRequest{
-			TimeSeries: []monitoring.TimeSeriesData{timeSeries},
-		}
-
-		err = client.WriteTimeSeries(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to write time series: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText("Time series data written successfully"), nil
 	}
-}
-
-// createListTimeSeriesHandler creates a handler for listing time series data
-func createListTimeSeriesHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		filter, err := request.RequireString("filter")
-		if err != nil {
-			return mcp.NewToolResultError("filter is required"), nil
-		}
-
-		startTimeStr, err := request.RequireString("start_time")
-		if err != nil {
-			return mcp.NewToolResultError("start_time is required"), nil
-		}
-
-		endTimeStr, err := request.RequireString("end_time")
-		if err != nil {
-			return mcp.NewToolResultError("end_time is required"), nil
-		}
-
-		startTime, err := time.Parse(time.RFC3339, startTimeStr)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Invalid start_time format: %v", err)), nil
-		}
 
-		endTime, err := time.Parse(time.RFC3339, endTimeStr)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Invalid end_time format: %v", err)), nil
-		}
-
-		req := monitoring.ListTimeSeriesRequest{
-			Filter:   filter,
-			PageSize: 100, // デフォルト値
-		}
-		req.Interval.StartTime = startTime
-		req.Interval.EndTime = endTime
-
-		// Parse optional aggregation
-		args := request.GetArguments()
-		if aggArg, exists := args["aggregation"]; exists {
-			if agg, ok := aggArg.(map[string]any); ok {
-				aggConfig := &monitoring.AggregationConfig{}
-
-				if alignmentPeriod, exists := agg["alignment_period"]; exists {
-					if ap, ok := alignmentPeriod.(string); ok {
-						aggConfig.AlignmentPeriod = ap
-					}
-				}
-
-				if perSeriesAligner, exists := agg["per_series_aligner"]; exists {
-					if psa, ok := perSeriesAligner.(string); ok {
-						aggConfig.PerSeriesAligner = psa
-					}
-				}
-
-				if crossSeriesReducer, exists := agg["cross_series_reducer"]; exists {
-					if csr, ok := crossSeriesReducer.(string); ok {
-						aggConfig.CrossSeriesReducer = csr
-					}
-				}
-
-				if groupByFields, exists := agg["group_by_fields"]; exists {
-					if gbf, ok := groupByFields.([]any); ok {
-						for _, field := range gbf {
-							if fieldStr, ok := field.(string); ok {
-								aggConfig.GroupByFields = append(aggConfig.GroupByFields, fieldStr)
-							}
-						}
-					}
-				}
-
-				req.Aggregation = aggConfig
+	var (
+		clientOptions []option.ClientOption
+		loggingAPI    logging.LoggingClientInterface
+		monitoringAPI monitoring.MonitoringClientInterface
+		traceAPI      trace.TraceClientInterface
+		profilerAPI   profiler.ProfilerClientInterface
+		dlpAPI        redact.DLPClientInterface
+		assetAPI      asset.AssetClientInterface
+		err           error
+	)
+	// The clients calling Google Cloud are created by gcptelemetry unless
+	// replaced by the fake backend or a replayed cassette
+	switch {
+	case *fakeBackend:
+		// Serve in-memory telemetry, optionally seeded from a JSON file
+		backend := fake.New(projectID)
+		if *fakeSeed != "" {
+			seed, err := fake.LoadSeed(*fakeSeed)
+			if err != nil {
+				fmt.Printf("Failed to load fake backend seed: %v\n", err)
+				os.Exit(1)
 			}
-		}
-
-		// Parse optional page_size parameter
-		if pageSizeArg, exists := args["page_size"]; exists {
-			if pageSize, ok := pageSizeArg.(float64); ok && pageSize > 0 {
-				req.PageSize = int(pageSize)
+			if err := backend.Load(seed); err != nil {
+				fmt.Printf("Failed to load fake backend seed: %v\n", err)
+				os.Exit(1)
 			}
 		}
-
-		// Parse optional page_token parameter
-		if pageTokenArg, exists := args["page_token"]; exists {
-			if pageToken, ok := pageTokenArg.(string); ok && pageToken != "" {
-				req.PageToken = pageToken
+		loggingAPI = backend.Logging
+		monitoringAPI = backend.Monitoring
+		traceAPI = backend.Trace
+		profilerAPI = backend.Profiler
+		dlpAPI = backend.DLP
+		assetAPI = backend.Assets
+	case *replayFile != "":
+		// Serve the responses recorded with -record
+		cassette, err := replay.Load(*replayFile)
+		if err != nil {
+			fmt.Printf("Failed to load cassette: %v\n", err)
+			os.Exit(1)
+		}
+		loggingAPI = replay.NewLoggingClient(cassette, nil)
+		monitoringAPI = replay.NewMonitoringClient(cassette, nil)
+		traceAPI = replay.NewTraceClient(cassette, nil)
+		profilerAPI = replay.NewProfilerClient(cassette, nil)
+		dlpAPI = replay.NewDLPClient(cassette, nil)
+		assetAPI = replay.NewAssetClient(cassette, nil)
+	default:
+		// Authenticate with workload identity federation or the credentials cached
+		// by -login instead of Application Default Credentials when configured
+		credentialsConfig := credentials.Config{
+			CredentialsFile: os.Getenv("GCP_TELEMETRY_MCP_CREDENTIALS_FILE"),
+			OIDCTokenFile:   os.Getenv("GCP_TELEMETRY_MCP_OIDC_TOKEN_FILE"),
+			Audience:        os.Getenv("GCP_TELEMETRY_MCP_WORKLOAD_IDENTITY_PROVIDER"),
+			ServiceAccount:  os.Getenv("GCP_TELEMETRY_MCP_SERVICE_ACCOUNT"),
+		}
+		if credentialsConfig == (credentials.Config{}) {
+			if path, err := credentials.UserCredentialsPath(); err == nil {
+				if _, err := os.Stat(path); err == nil {
+					credentialsConfig.CredentialsFile = path
+				}
 			}
 		}
-
-		resp, err := client.ListTimeSeries(ctx, req)
+		clientOptions, err = credentialsConfig.ClientOptions()
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to list time series: %v", err)), nil
+			fmt.Printf("Failed to load credentials: %v\n", err)
+			os.Exit(1)
 		}
-
-		// Create a response object that includes both time series data and pagination info
-		response := map[string]any{
-			"time_series": resp.TimeSeries,
-		}
-
-		// Add next_page_token if present
-		if resp.NextPageToken != "" {
-			response["next_page_token"] = resp.NextPageToken
-		}
-
-		// Convert response to JSON
-		responseJSON, err := json.MarshalIndent(response, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText(string(responseJSON)), nil
 	}
-}
-
-// createListMetricDescriptorsHandler creates a handler for listing metric descriptors
-func createListMetricDescriptorsHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		args := request.GetArguments()
-		req := monitoring.ListMetricDescriptorsRequest{
-			PageSize: 5, // デフォルト値
-		}
-
-		// Parse optional filter parameter
-		if filterArg, exists := args["filter"]; exists {
-			if filter, ok := filterArg.(string); ok {
-				req.Filter = filter
-			}
-		}
-
-		// Parse optional page_size parameter
-		if pageSizeArg, exists := args["page_size"]; exists {
-			if pageSize, ok := pageSizeArg.(float64); ok && pageSize > 0 {
-				req.PageSize = int(pageSize)
-			}
-		}
-
-		// Parse optional page_token parameter
-		if pageTokenArg, exists := args["page_token"]; exists {
-			if pageToken, ok := pageTokenArg.(string); ok && pageToken != "" {
-				req.PageToken = pageToken
-			}
-		}
-
-		resp, err := client.ListMetricDescriptors(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to list metric descriptors: %v", err)), nil
-		}
-
-		// Create a response object that includes both descriptors and pagination info
-		response := map[string]any{
-			"descriptors": resp.Descriptors,
-		}
-
-		// Add next_page_token if present
-		if resp.NextPageToken != "" {
-			response["next_page_token"] = resp.NextPageToken
-		}
 
-		// Convert response to JSON
-		responseJSON, err := json.MarshalIndent(response, "", "  ")
+	// Record the API calls and their responses to a cassette when configured
+	var recorder *replay.Cassette
+	if *recordFile != "" {
+		recorder, err = replay.NewRecorder(*recordFile)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+			fmt.Printf("Failed to create cassette: %v\n", err)
+			os.Exit(1)
 		}
-
-		return mcp.NewToolResultText(string(responseJSON)), nil
 	}
-}
 
-// createDeleteMetricDescriptorHandler creates a handler for deleting metric descriptors
-func createDeleteMetricDescriptorHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		metricType, err := request.RequireString("metric_type")
+	// Redact sensitive data from the log entries and traces returned to clients when configured
+	var redactor *redact.Redactor
+	if redactConfig := os.Getenv("GCP_TELEMETRY_MCP_REDACT"); redactConfig != "" {
+		config, err := redact.ParseConfig(redactConfig)
 		if err != nil {
-			return mcp.NewToolResultError("metric_type is required"), nil
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_REDACT: %v\n", err)
+			os.Exit(1)
 		}
-
-		err = client.DeleteMetricDescriptor(ctx, metricType)
+		redactor, err = redact.New(config)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to delete metric descriptor: %v", err)), nil
+			fmt.Printf("Failed to create redactor: %v\n", err)
+			os.Exit(1)
 		}
-
-		return mcp.NewToolResultText("Metric descriptor deleted successfully"), nil
 	}
-}
-
-// createListAvailableMetricsHandler creates a handler for listing available metrics
-func createListAvailableMetricsHandler(client monitoring.MonitoringClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		args := request.GetArguments()
-		req := monitoring.ListAvailableMetricsRequest{
-			PageSize: 100, // default
-		}
-
-		// Parse optional filter parameter
-		if filterArg, exists := args["filter"]; exists {
-			if filter, ok := filterArg.(string); ok && filter != "" {
-				req.Filter = filter
-			}
-		}
 
-		// Parse optional page_size parameter
-		if pageSizeArg, exists := args["page_size"]; exists {
-			if pageSize, ok := pageSizeArg.(float64); ok && pageSize > 0 {
-				req.PageSize = int(pageSize)
-			}
-		}
-
-		// Parse optional page_token parameter
-		if pageTokenArg, exists := args["page_token"]; exists {
-			if pageToken, ok := pageTokenArg.(string); ok && pageToken != "" {
-				req.PageToken = pageToken
-			}
-		}
-
-		metrics, err := client.ListAvailableMetrics(ctx, req)
+	// Load the default source mappings linking profile hotspots to their code
+	var sourceMappings []profiler.SourceMapping
+	if mappings := os.Getenv("GCP_TELEMETRY_MCP_SOURCE_MAPPINGS"); mappings != "" {
+		sourceMappings, err = profiler.ParseSourceMappings(mappings)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to list available metrics: %v", err)), nil
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_SOURCE_MAPPINGS: %v\n", err)
+			os.Exit(1)
 		}
-
-		// Convert metrics to JSON for response
-		metricsJSON, err := json.MarshalIndent(metrics, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal available metrics: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText(string(metricsJSON)), nil
 	}
-}
 
-// createListTracesHandler creates a handler for listing traces
-func createListTracesHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		startTimeStr, err := request.RequireString("start_time")
+	// Load the labels attached to the telemetry written by tools, so that it
+	// can be told apart from the telemetry of applications
+	var writeLabels map[string]string
+	if writeLabelsConfig := os.Getenv("GCP_TELEMETRY_MCP_WRITE_LABELS"); writeLabelsConfig != "" {
+		writeLabels, err = session.ParseWriteLabels(writeLabelsConfig)
 		if err != nil {
-			return mcp.NewToolResultError("start_time is required"), nil
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_WRITE_LABELS: %v\n", err)
+			os.Exit(1)
 		}
-
-		endTimeStr, err := request.RequireString("end_time")
-		if err != nil {
-			return mcp.NewToolResultError("end_time is required"), nil
-		}
-
-		startTime, err := time.Parse(time.RFC3339, startTimeStr)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Invalid start_time format: %v", err)), nil
-		}
-
-		endTime, err := time.Parse(time.RFC3339, endTimeStr)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Invalid end_time format: %v", err)), nil
-		}
-
-		args := request.GetArguments()
-		req := trace.ListTracesRequest{
-			StartTime: startTime,
-			EndTime:   endTime,
-			PageSize:  100, // default
-		}
-
-		// Parse optional filter parameter
-		if filterArg, exists := args["filter"]; exists {
-			if filter, ok := filterArg.(string); ok && filter != "" {
-				req.Filter = filter
-			}
-		}
-
-		// Parse optional order_by parameter
-		if orderByArg, exists := args["order_by"]; exists {
-			if orderBy, ok := orderByArg.(string); ok && orderBy != "" {
-				req.OrderBy = orderBy
-			}
-		}
-
-		// Parse optional page_size parameter
-		if pageSizeArg, exists := args["page_size"]; exists {
-			if pageSize, ok := pageSizeArg.(float64); ok && pageSize > 0 {
-				req.PageSize = int(pageSize)
-			}
-		}
-
-		// Parse optional page_token parameter
-		if pageTokenArg, exists := args["page_token"]; exists {
-			if pageToken, ok := pageTokenArg.(string); ok && pageToken != "" {
-				req.PageToken = pageToken
-			}
-		}
-
-		traces, err := client.ListTraces(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to list traces: %v", err)), nil
-		}
-
-		// Convert traces to JSON for response
-		tracesJSON, err := json.MarshalIndent(traces, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal traces: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText(string(tracesJSON)), nil
 	}
-}
 
-// createGetTraceHandler creates a handler for getting a specific trace
-func createGetTraceHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		traceID, err := request.RequireString("trace_id")
-		if err != nil {
-			return mcp.NewToolResultError("trace_id is required"), nil
-		}
-
-		req := trace.GetTraceRequest{
-			TraceID: traceID,
-		}
-
-		traceResult, err := client.GetTrace(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to get trace: %v", err)), nil
+	// Load the names of the tools left out
+	var disabledTools []string
+	for _, name := range strings.Split(os.Getenv("GCP_TELEMETRY_MCP_DISABLED_TOOLS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			disabledTools = append(disabledTools, name)
 		}
-
-		// Convert trace to JSON for response
-		traceJSON, err := json.MarshalIndent(traceResult, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal trace: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText(string(traceJSON)), nil
 	}
-}
 
-// createPatchTracesHandler creates a handler for updating trace spans
-func createPatchTracesHandler(client trace.TraceClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		traceID, err := request.RequireString("trace_id")
-		if err != nil {
-			return mcp.NewToolResultError("trace_id is required"), nil
+	// Load the hosts watches can post their events to
+	var webhookHosts []string
+	for _, host := range strings.Split(os.Getenv("GCP_TELEMETRY_MCP_WEBHOOK_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			webhookHosts = append(webhookHosts, host)
 		}
-
-		args := request.GetArguments()
-		spansArg, exists := args["spans"]
-		if !exists {
-			return mcp.NewToolResultError("spans is required"), nil
-		}
-
-		// Parse spans from the request
-		var spans []trace.Span
-		if spansArray, ok := spansArg.([]any); ok {
-			for _, spanData := range spansArray {
-				if spanObj, ok := spanData.(map[string]any); ok {
-					span := trace.Span{}
-
-					if spanID, ok := spanObj["span_id"].(string); ok {
-						span.SpanID = spanID
-					}
-
-					if name, ok := spanObj["name"].(string); ok {
-						span.Name = name
-					}
-
-					if parentID, ok := spanObj["parent_id"].(string); ok {
-						span.ParentID = parentID
-					}
-
-					if kind, ok := spanObj["kind"].(string); ok {
-						span.Kind = kind
-					}
-
-					// Parse start_time
-					if startTimeStr, ok := spanObj["start_time"].(string); ok {
-						if startTime, parseErr := time.Parse(time.RFC3339, startTimeStr); parseErr == nil {
-							span.StartTime = startTime
-						}
-					}
-
-					// Parse end_time
-					if endTimeStr, ok := spanObj["end_time"].(string); ok {
-						if endTime, parseErr := time.Parse(time.RFC3339, endTimeStr); parseErr == nil {
-							span.EndTime = endTime
-						}
-					}
-
-					// Parse labels
-					if labelsObj, ok := spanObj["labels"].(map[string]any); ok {
-						span.Labels = make(map[string]string)
-						for k, v := range labelsObj {
-							if str, ok := v.(string); ok {
-								span.Labels[k] = str
-							}
-						}
-					}
-
-					spans = append(spans, span)
-				}
-			}
-		} else {
-			return mcp.NewToolResultError("spans must be an array of span objects"), nil
-		}
-
-		req := trace.PatchTraceRequest{
-			TraceID: traceID,
-			Spans:   spans,
-		}
-
-		err = client.PatchTraces(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to patch traces: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText("Trace spans updated successfully"), nil
 	}
-}
 
-// createProfileHandler creates a handler for creating profiles
-func createProfileHandler(client profiler.ProfilerClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		target, err := request.RequireString("target")
+	// Log the tool calls and limit their rate when configured
+	var toolMiddleware []server.ToolHandlerMiddleware
+	if logToolCalls := os.Getenv("GCP_TELEMETRY_MCP_LOG_TOOL_CALLS"); logToolCalls != "" {
+		enabled, err := strconv.ParseBool(logToolCalls)
 		if err != nil {
-			return mcp.NewToolResultError("target is required"), nil
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_LOG_TOOL_CALLS: %v\n", err)
+			os.Exit(1)
 		}
-
-		profileTypeStr, err := request.RequireString("profile_type")
-		if err != nil {
-			return mcp.NewToolResultError("profile_type is required"), nil
-		}
-
-		args := request.GetArguments()
-		duration := "60s" // default
-		if durationArg, exists := args["duration"]; exists {
-			if d, ok := durationArg.(string); ok && d != "" {
-				duration = d
-			}
-		}
-
-		// Parse labels
-		var labels map[string]string
-		if labelsArg, exists := args["labels"]; exists {
-			if labelsObj, ok := labelsArg.(map[string]any); ok {
-				labels = make(map[string]string)
-				for k, v := range labelsObj {
-					if str, ok := v.(string); ok {
-						labels[k] = str
-					}
-				}
-			}
+		if enabled {
+			toolMiddleware = append(toolMiddleware, middleware.Logging(slog.New(slog.NewTextHandler(os.Stderr, nil))))
 		}
-
-		req := profiler.CreateProfileRequest{
-			ProjectID: os.Getenv("GOOGLE_CLOUD_PROJECT"),
-			Deployment: &profiler.Deployment{
-				ProjectID: os.Getenv("GOOGLE_CLOUD_PROJECT"),
-				Target:    target,
-				Labels:    labels,
-			},
-			ProfileType: []profiler.ProfileType{profiler.ProfileType(profileTypeStr)},
-			Duration:    duration,
-			Labels:      labels,
+	}
+	if rateLimit := os.Getenv("GCP_TELEMETRY_MCP_RATE_LIMIT"); rateLimit != "" {
+		limit, err := strconv.Atoi(rateLimit)
+		if err != nil || limit <= 0 {
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_RATE_LIMIT: must be a positive number of tool calls per minute\n")
+			os.Exit(1)
 		}
-
-		profile, err := client.CreateProfile(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to create profile: %v", err)), nil
+		toolMiddleware = append(toolMiddleware, middleware.NewRateLimiter(limit, time.Minute).Middleware())
+	}
+	var maxConcurrentCalls int
+	if maxCalls := os.Getenv("GCP_TELEMETRY_MCP_MAX_CONCURRENT_CALLS"); maxCalls != "" {
+		maxConcurrentCalls, err = strconv.Atoi(maxCalls)
+		if err != nil || maxConcurrentCalls < 0 {
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_MAX_CONCURRENT_CALLS: must be a number of calls, or 0 for no limit\n")
+			os.Exit(1)
 		}
-
-		// Convert profile to JSON for response
-		profileJSON, err := json.MarshalIndent(profile, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal profile: %v", err)), nil
+		// 0 removes the limit, which Config expresses with a negative value
+		if maxConcurrentCalls == 0 {
+			maxConcurrentCalls = -1
 		}
-
-		return mcp.NewToolResultText(string(profileJSON)), nil
 	}
-}
-
-// createOfflineProfileHandler creates a handler for creating offline profiles
-func createOfflineProfileHandler(client profiler.ProfilerClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		target, err := request.RequireString("target")
-		if err != nil {
-			return mcp.NewToolResultError("target is required"), nil
-		}
-
-		profileTypeStr, err := request.RequireString("profile_type")
-		if err != nil {
-			return mcp.NewToolResultError("profile_type is required"), nil
+	var maxResultBytes int
+	if maxBytes := os.Getenv("GCP_TELEMETRY_MCP_MAX_RESULT_BYTES"); maxBytes != "" {
+		maxResultBytes, err = strconv.Atoi(maxBytes)
+		if err != nil || maxResultBytes <= 0 {
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_MAX_RESULT_BYTES: must be a positive number of bytes\n")
+			os.Exit(1)
 		}
-
-		profileData, err := request.RequireString("profile_data")
+	}
+	var largeResultFormat middleware.LargeResultFormat
+	if format := os.Getenv("GCP_TELEMETRY_MCP_LARGE_RESULT_FORMAT"); format != "" {
+		largeResultFormat, err = middleware.ParseLargeResultFormat(format)
 		if err != nil {
-			return mcp.NewToolResultError("profile_data is required"), nil
-		}
-
-		args := request.GetArguments()
-		duration := "60s" // default
-		if durationArg, exists := args["duration"]; exists {
-			if d, ok := durationArg.(string); ok && d != "" {
-				duration = d
-			}
-		}
-
-		// Parse labels
-		var labels map[string]string
-		if labelsArg, exists := args["labels"]; exists {
-			if labelsObj, ok := labelsArg.(map[string]any); ok {
-				labels = make(map[string]string)
-				for k, v := range labelsObj {
-					if str, ok := v.(string); ok {
-						labels[k] = str
-					}
-				}
-			}
-		}
-
-		req := profiler.CreateOfflineProfileRequest{
-			ProjectID: os.Getenv("GOOGLE_CLOUD_PROJECT"),
-			Profile: &profiler.Profile{
-				ProfileType:  profiler.ProfileType(profileTypeStr),
-				Duration:     duration,
-				Labels:       labels,
-				ProfileBytes: profileData,
-				Deployment: &profiler.Deployment{
-					ProjectID: os.Getenv("GOOGLE_CLOUD_PROJECT"),
-					Target:    target,
-					Labels:    labels,
-				},
-			},
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_LARGE_RESULT_FORMAT: %v\n", err)
+			os.Exit(1)
 		}
-
-		profile, err := client.CreateOfflineProfile(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to create offline profile: %v", err)), nil
+	}
+	var cacheTTL time.Duration
+	if ttl := os.Getenv("GCP_TELEMETRY_MCP_CACHE_TTL"); ttl != "" {
+		cacheTTL, err = time.ParseDuration(ttl)
+		if err != nil || cacheTTL <= 0 {
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_CACHE_TTL: must be a positive duration, e.g. 30s\n")
+			os.Exit(1)
 		}
-
-		// Convert profile to JSON for response
-		profileJSON, err := json.MarshalIndent(profile, "", "  ")
+	}
+	var timeZone *time.Location
+	if name := os.Getenv("GCP_TELEMETRY_MCP_TIME_ZONE"); name != "" {
+		timeZone, err = time.LoadLocation(name)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal profile: %v", err)), nil
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_TIME_ZONE: %v\n", err)
+			os.Exit(1)
 		}
-
-		return mcp.NewToolResultText(string(profileJSON)), nil
 	}
-}
 
-// updateProfileHandler creates a handler for updating profiles
-func updateProfileHandler(client profiler.ProfilerClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		profileName, err := request.RequireString("profile_name")
+	var exportSelfTelemetry bool
+	if selfTelemetry := os.Getenv("GCP_TELEMETRY_MCP_SELF_TELEMETRY"); selfTelemetry != "" {
+		exportSelfTelemetry, err = strconv.ParseBool(selfTelemetry)
 		if err != nil {
-			return mcp.NewToolResultError("profile_name is required"), nil
+			fmt.Printf("Failed to parse GCP_TELEMETRY_MCP_SELF_TELEMETRY: %v\n", err)
+			os.Exit(1)
 		}
-
-		args := request.GetArguments()
-		var profileData string
-		if profileDataArg, exists := args["profile_data"]; exists {
-			if pd, ok := profileDataArg.(string); ok {
-				profileData = pd
-			}
-		}
-
-		var updateMask string
-		if updateMaskArg, exists := args["update_mask"]; exists {
-			if um, ok := updateMaskArg.(string); ok {
-				updateMask = um
-			}
-		}
-
-		// Parse labels
-		var labels map[string]string
-		if labelsArg, exists := args["labels"]; exists {
-			if labelsObj, ok := labelsArg.(map[string]any); ok {
-				labels = make(map[string]string)
-				for k, v := range labelsObj {
-					if str, ok := v.(string); ok {
-						labels[k] = str
-					}
-				}
-			}
+		if exportSelfTelemetry && *readOnly {
+			fmt.Printf("GCP_TELEMETRY_MCP_SELF_TELEMETRY cannot be used with -read-only\n")
+			os.Exit(1)
 		}
+	}
 
-		req := profiler.UpdateProfileRequest{
-			Profile: &profiler.Profile{
-				Name:   profileName,
-				Labels: labels,
-			},
-			ProfileBytes: profileData,
-			UpdateMask:   updateMask,
-		}
+	// Jobs only outlive the session scheduling them over HTTP, where the
+	// server keeps running between clients
+	scheduledJobs := *transport == "http"
+
+	// Create a new MCP server with the tools. Watch events and alert
+	// notifications are pushed to clients as log messages.
+	s, tools, err := gcptelemetry.NewServer(context.Background(), gcptelemetry.Config{
+		ProjectID:           projectID,
+		ClientOptions:       clientOptions,
+		ReadOnly:            *readOnly,
+		DisabledTools:       disabledTools,
+		SavedQueries:        os.Getenv("GCP_TELEMETRY_MCP_SAVED_QUERIES"),
+		AlertSubscription:   os.Getenv("GCP_TELEMETRY_MCP_ALERT_SUBSCRIPTION"),
+		WebhookHosts:        webhookHosts,
+		SourceMappings:      sourceMappings,
+		WriteLabels:         writeLabels,
+		DLPInfoTypes:        dlpInfoTypes,
+		Redactor:            redactor,
+		TimeZone:            timeZone,
+		MaxResultBytes:      maxResultBytes,
+		LargeResultFormat:   largeResultFormat,
+		CacheTTL:            cacheTTL,
+		MaxConcurrentCalls:  maxConcurrentCalls,
+		Middleware:          toolMiddleware,
+		ExportSelfTelemetry: exportSelfTelemetry,
+		ScheduledJobs:       scheduledJobs,
+		LoggingAPI:          loggingAPI,
+		MonitoringAPI:       monitoringAPI,
+		TraceAPI:            traceAPI,
+		ProfilerAPI:         profilerAPI,
+		DLPAPI:              dlpAPI,
+		AssetAPI:            assetAPI,
+		Recorder:            recorder,
+		Version:             version,
+	})
+	if err != nil {
+		fmt.Printf("Failed to create server: %v\n", err)
+		os.Exit(1)
+	}
 
-		profile, err := client.UpdateProfile(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to update profile: %v", err)), nil
+	if *transport == "http" {
+		mux := http.NewServeMux()
+		mux.Handle("/mcp", server.NewStreamableHTTPServer(s))
+		if *enableOTLP {
+			receiver := otlp.NewReceiver(tools.LoggingClient(), tools.TraceClient(), projectID)
+			mux.Handle(otlp.TracesPath, receiver)
+			mux.Handle(otlp.LogsPath, receiver)
 		}
 
-		// Convert profile to JSON for response
-		profileJSON, err := json.MarshalIndent(profile, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal profile: %v", err)), nil
+		// Start the HTTP server
+		if err := http.ListenAndServe(*addr, mux); err != nil {
+			fmt.Printf("Server error: %v\n", err)
 		}
+		shutdown(tools)
+		return
+	}
 
-		return mcp.NewToolResultText(string(profileJSON)), nil
+	// Start the stdio server
+	if err := server.ServeStdio(s); err != nil {
+		fmt.Printf("Server error: %v\n", err)
 	}
+	shutdown(tools)
 }
 
-// listProfilesHandler creates a handler for listing profiles
-func listProfilesHandler(client profiler.ProfilerClient) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		args := request.GetArguments()
-		req := profiler.ListProfilesRequest{
-			ProjectID: os.Getenv("GOOGLE_CLOUD_PROJECT"),
-			PageSize:  100, // default
-		}
-
-		// Parse optional page_size parameter
-		if pageSizeArg, exists := args["page_size"]; exists {
-			if pageSize, ok := pageSizeArg.(float64); ok && pageSize > 0 {
-				req.PageSize = int64(pageSize)
-			}
-		}
-
-		// Parse optional page_token parameter
-		if pageTokenArg, exists := args["page_token"]; exists {
-			if pageToken, ok := pageTokenArg.(string); ok && pageToken != "" {
-				req.PageToken = pageToken
-			}
-		}
-
-		profiles, err := client.ListProfiles(ctx, req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to list profiles: %v", err)), nil
-		}
-
-		// Convert profiles to JSON for response
-		profilesJSON, err := json.MarshalIndent(profiles, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal profiles: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText(string(profilesJSON)), nil
+// shutdown cancels the scheduled jobs and exports the self telemetry recorded
+// last before exiting
+func shutdown(tools *gcptelemetry.Tools) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := tools.Shutdown(ctx); err != nil {
+		fmt.Printf("Failed to export self telemetry: %v\n", err)
 	}
 }
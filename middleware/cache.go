@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxCacheEntries bounds the number of results kept by a Cache
+const maxCacheEntries = 100
+
+// Cache keeps the results of the tools that only read for a time to live,
+// so that identical calls, e.g. an agent listing the same entries again,
+// are answered without calling Google Cloud
+type Cache struct {
+	ttl         time.Duration
+	cacheable   func(tool string) bool
+	cursorPaged func(tool string) bool
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// cacheEntry is a result kept by a Cache
+type cacheEntry struct {
+	result *mcp.CallToolResult
+	stored time.Time
+}
+
+// NewCache creates a Cache keeping the results of the tools for which
+// cacheable returns true for ttl. The page tokens of the tools for which
+// cursorPaged returns true are single-use cursors, so their results with a
+// next_page_token are not kept: a reused result would hand out a token that
+// was already followed.
+func NewCache(ttl time.Duration, cacheable, cursorPaged func(tool string) bool) *Cache {
+	return &Cache{
+		ttl:         ttl,
+		cacheable:   cacheable,
+		cursorPaged: cursorPaged,
+		now:         time.Now,
+		entries:     make(map[string]cacheEntry),
+	}
+}
+
+// Middleware returns the middleware answering the calls of cacheable tools
+// from the cache when the same tool was called with the same arguments and
+// session defaults within the time to live. Cached results are marked with
+// "cached" in their metadata and a note of their age. Calls of other tools,
+// which may write, clear the cache.
+func (c *Cache) Middleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !c.cacheable(request.Params.Name) {
+				c.Clear()
+				return next(ctx, request)
+			}
+			key, err := cacheKey(ctx, request)
+			if err != nil {
+				return next(ctx, request)
+			}
+			if result, stored, ok := c.get(key); ok {
+				return cachedResult(result, c.now().Sub(stored), c.ttl), nil
+			}
+
+			result, err := next(ctx, request)
+			if err != nil || result == nil || result.IsError {
+				return result, err
+			}
+			if c.cursorPaged(request.Params.Name) && hasNextPageToken(result) {
+				return result, nil
+			}
+			c.put(key, result)
+			return copyResult(result), nil
+		}
+	}
+}
+
+// Clear drops all the cached results
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// get returns the result cached for key and when it was stored
+func (c *Cache) get(key string) (*mcp.CallToolResult, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || c.now().Sub(entry.stored) >= c.ttl {
+		return nil, time.Time{}, false
+	}
+	return entry.result, entry.stored, true
+}
+
+// put caches result for key, dropping the expired results and, when the
+// cache is full, the oldest one
+func (c *Cache) put(key string, result *mcp.CallToolResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	maps.DeleteFunc(c.entries, func(_ string, entry cacheEntry) bool {
+		return now.Sub(entry.stored) >= c.ttl
+	})
+	if len(c.entries) >= maxCacheEntries {
+		oldest := slices.MinFunc(slices.Collect(maps.Keys(c.entries)), func(a, b string) int {
+			return c.entries[a].stored.Compare(c.entries[b].stored)
+		})
+		delete(c.entries, oldest)
+	}
+	// The cache keeps its own copy, as the middleware outside of the cache
+	// may change the results they are given
+	c.entries[key] = cacheEntry{result: copyResult(result), stored: now}
+}
+
+// cacheKey identifies the calls returning the same result: the tool, its
+// arguments, and the session defaults, e.g. the project
+func cacheKey(ctx context.Context, request mcp.CallToolRequest) (string, error) {
+	// Maps are marshaled with sorted keys, so equal arguments give equal keys
+	args, err := json.Marshal(request.GetArguments())
+	if err != nil {
+		return "", err
+	}
+	defaults, err := json.Marshal(session.FromContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s", request.Params.Name, args, defaults), nil
+}
+
+// hasNextPageToken reports whether the JSON of result has a next_page_token
+func hasNextPageToken(result *mcp.CallToolResult) bool {
+	for _, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+		var page struct {
+			NextPageToken string `json:"next_page_token"`
+		}
+		if json.Unmarshal([]byte(text.Text), &page) == nil && page.NextPageToken != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// copyResult returns a copy of result whose content and metadata can be
+// changed without changing result
+func copyResult(result *mcp.CallToolResult) *mcp.CallToolResult {
+	copied := *result
+	copied.Content = slices.Clone(result.Content)
+	copied.Meta = maps.Clone(result.Meta)
+	return &copied
+}
+
+// cachedResult returns a copy of the cached result marked as cached age ago
+func cachedResult(result *mcp.CallToolResult, age, ttl time.Duration) *mcp.CallToolResult {
+	cached := copyResult(result)
+	if cached.Meta == nil {
+		cached.Meta = make(map[string]any)
+	}
+	cached.Meta["cached"] = true
+	cached.Meta["cache_age_seconds"] = int(age.Seconds())
+	cached.Content = append(cached.Content, mcp.NewTextContent(fmt.Sprintf("This result was cached %s ago, as identical calls within %s reuse it", age.Round(time.Second), ttl)))
+	return cached
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// LargeResultFormat is how LargeResults returns the text of large results
+type LargeResultFormat string
+
+const (
+	// LargeResultResource returns the text as an embedded text resource
+	LargeResultResource LargeResultFormat = "resource"
+	// LargeResultGzip returns the text gzipped, as an embedded blob resource
+	// encoded in base64
+	LargeResultGzip LargeResultFormat = "gzip"
+)
+
+// ParseLargeResultFormat returns the LargeResultFormat named by s
+func ParseLargeResultFormat(s string) (LargeResultFormat, error) {
+	switch format := LargeResultFormat(s); format {
+	case LargeResultResource, LargeResultGzip:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported large result format %q: must be %s or %s", s, LargeResultResource, LargeResultGzip)
+}
+
+// largeResultURIPrefix is the prefix of the URIs of the resources holding
+// large results, followed by the tool name and a sequence number
+const largeResultURIPrefix = "gcp-telemetry-mcp://results/"
+
+// LargeResults moves the text contents of tool results over maxBytes into
+// embedded resources in the given format, leaving a summary of the text,
+// e.g. the fields of a JSON object and the number of items of its arrays, in
+// its place. Clients can then keep the resources out of the model's context.
+func LargeResults(maxBytes int, format LargeResultFormat) server.ToolHandlerMiddleware {
+	var sequence atomic.Int64
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil || result.IsError {
+				return result, err
+			}
+
+			var contents []mcp.Content
+			for _, content := range result.Content {
+				text, ok := content.(mcp.TextContent)
+				if !ok || len(text.Text) <= maxBytes {
+					contents = append(contents, content)
+					continue
+				}
+				uri := fmt.Sprintf("%s%s/%d", largeResultURIPrefix, request.Params.Name, sequence.Add(1))
+				resource, err := largeResultResource(uri, text.Text, format)
+				if err != nil {
+					// The text is still returned inline rather than failing the call
+					contents = append(contents, content)
+					continue
+				}
+				contents = append(contents, mcp.NewTextContent(summarizeLargeResult(request.Params.Name, uri, text.Text, format)), resource)
+			}
+			result.Content = contents
+			return result, nil
+		}
+	}
+}
+
+// largeResultResource returns the embedded resource holding text in format
+func largeResultResource(uri, text string, format LargeResultFormat) (mcp.EmbeddedResource, error) {
+	mimeType := "text/plain"
+	if json.Valid([]byte(text)) {
+		mimeType = "application/json"
+	}
+	if format != LargeResultGzip {
+		return mcp.NewEmbeddedResource(mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: text}), nil
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(text)); err != nil {
+		return mcp.EmbeddedResource{}, err
+	}
+	if err := w.Close(); err != nil {
+		return mcp.EmbeddedResource{}, err
+	}
+	return mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+		URI:      uri,
+		MIMEType: "application/gzip",
+		Blob:     base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}), nil
+}
+
+// summarizeLargeResult describes the large result text of the tool moved to
+// the resource at uri
+func summarizeLargeResult(tool, uri, text string, format LargeResultFormat) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The result of %s is %s, so it is returned as the resource %s", tool, formatBytes(len(text)), uri)
+	if format == LargeResultGzip {
+		b.WriteString(" (gzip-compressed, base64-encoded)")
+	}
+	b.WriteString(".")
+
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err == nil {
+		switch v := value.(type) {
+		case map[string]any:
+			var fields []string
+			for _, key := range slices.Sorted(maps.Keys(v)) {
+				fields = append(fields, fmt.Sprintf("%s (%s)", key, describeJSON(v[key])))
+			}
+			fmt.Fprintf(&b, " It is a JSON object with the fields %s.", strings.Join(fields, ", "))
+		default:
+			fmt.Fprintf(&b, " It is %s.", describeJSON(v))
+		}
+	}
+	b.WriteString(" Narrow the query, e.g. with a shorter time range, a filter, or a limit, to get the result inline.")
+	return b.String()
+}
+
+// describeJSON describes the type of a decoded JSON value, with the number
+// of items of arrays
+func describeJSON(v any) string {
+	switch v := v.(type) {
+	case []any:
+		return fmt.Sprintf("an array of %d items", len(v))
+	case map[string]any:
+		return "an object"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return "null"
+	}
+}
+
+// formatBytes formats a number of bytes for people, e.g. "2.5 MB"
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolStats are the counts and total duration of the calls of a tool
+type ToolStats struct {
+	Calls    int           `json:"calls"`
+	Errors   int           `json:"errors"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Metrics counts the calls of each tool
+type Metrics struct {
+	mu    sync.Mutex
+	tools map[string]ToolStats
+}
+
+// NewMetrics creates a Metrics without calls
+func NewMetrics() *Metrics {
+	return &Metrics{tools: make(map[string]ToolStats)}
+}
+
+// Stats returns the stats of the tools called so far, by tool name
+func (m *Metrics) Stats() map[string]ToolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.tools)
+}
+
+// Middleware returns the middleware counting the tool calls. Calls returning
+// an error or an error result are counted as errors.
+func (m *Metrics) Middleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+
+			m.mu.Lock()
+			stats := m.tools[request.Params.Name]
+			stats.Calls++
+			if err != nil || (result != nil && result.IsError) {
+				stats.Errors++
+			}
+			stats.Duration += time.Since(start)
+			m.tools[request.Params.Name] = stats
+			m.mu.Unlock()
+			return result, err
+		}
+	}
+}
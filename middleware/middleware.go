@@ -0,0 +1,105 @@
+// Package middleware provides tool handler middleware applied uniformly to
+// all the tools of a server: logging, metrics, authorization, rate limiting,
+// redaction of results, and moving large results into resources.
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Chain combines middleware into one. The first middleware is the outermost,
+// i.e. it is called first and sees the result of all the others.
+func Chain(middleware ...server.ToolHandlerMiddleware) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+		return next
+	}
+}
+
+// sessionID returns the ID of the calling session, or "" outside of sessions
+func sessionID(ctx context.Context) string {
+	if clientSession := server.ClientSessionFromContext(ctx); clientSession != nil {
+		return clientSession.SessionID()
+	}
+	return ""
+}
+
+// Logging logs each tool call with its duration and outcome
+func Logging(logger *slog.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			attrs := []any{
+				slog.String("tool", request.Params.Name),
+				slog.String("session", sessionID(ctx)),
+				slog.Duration("duration", time.Since(start)),
+			}
+			switch {
+			case err != nil:
+				logger.ErrorContext(ctx, "tool call failed", append(attrs, slog.String("error", err.Error()))...)
+			case result != nil && result.IsError:
+				logger.WarnContext(ctx, "tool call returned an error", append(attrs, slog.String("error", resultText(result)))...)
+			default:
+				logger.InfoContext(ctx, "tool call", attrs...)
+			}
+			return result, err
+		}
+	}
+}
+
+// resultText returns the text content of a result
+func resultText(result *mcp.CallToolResult) string {
+	var text string
+	for _, content := range result.Content {
+		if c, ok := content.(mcp.TextContent); ok {
+			text += c.Text
+		}
+	}
+	return text
+}
+
+// Authorize calls allow before each tool call, and returns its error as the
+// tool result instead of calling the tool when it denies the call
+func Authorize(allow func(ctx context.Context, request mcp.CallToolRequest) error) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if err := allow(ctx, request); err != nil {
+				return mcp.NewToolResultError("Not authorized to call " + request.Params.Name + ": " + err.Error()), nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// Redact redacts the matches of the patterns of r in the text content of all
+// tool results, including the results of tools that do not return log
+// entries or traces, e.g. incident reports
+func Redact(r *redact.Redactor) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if result == nil {
+				return result, err
+			}
+			redacted := *result
+			redacted.Content = make([]mcp.Content, len(result.Content))
+			for i, content := range result.Content {
+				if c, ok := content.(mcp.TextContent); ok {
+					c.Text = r.String(c.Text)
+					content = c
+				}
+				redacted.Content[i] = content
+			}
+			return &redacted, err
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// callTool calls handler with a request for the named tool
+func callTool(t *testing.T, handler server.ToolHandlerFunc, name string) *mcp.CallToolResult {
+	t.Helper()
+	var request mcp.CallToolRequest
+	request.Params.Name = name
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	return result
+}
+
+func textHandler(text string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(text), nil
+	}
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+	record := func(name string) server.ToolHandlerMiddleware {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				order = append(order, name)
+				return next(ctx, request)
+			}
+		}
+	}
+
+	callTool(t, Chain(record("first"), record("second"))(textHandler("ok")), "tool")
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("Expected the first middleware to be called first, got %v", order)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	failing := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("Failed to list log entries"), nil
+	}
+
+	callTool(t, Logging(logger)(textHandler("ok")), "list_traces")
+	callTool(t, Logging(logger)(failing), "list_log_entries")
+	logs := buf.String()
+	if !strings.Contains(logs, "level=INFO msg=\"tool call\" tool=list_traces") {
+		t.Errorf("Expected the successful call to be logged, got %q", logs)
+	}
+	if !strings.Contains(logs, "level=WARN") || !strings.Contains(logs, `error="Failed to list log entries"`) {
+		t.Errorf("Expected the error result to be logged, got %q", logs)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	allow := func(ctx context.Context, request mcp.CallToolRequest) error {
+		if strings.HasPrefix(request.Params.Name, "write_") {
+			return errors.New("writes are disabled")
+		}
+		return nil
+	}
+	handler := Authorize(allow)(textHandler("ok"))
+
+	if result := callTool(t, handler, "list_traces"); result.IsError {
+		t.Errorf("Expected list_traces to be allowed, got %+v", result)
+	}
+	result := callTool(t, handler, "write_log_entry")
+	if !result.IsError || !strings.Contains(resultText(result), "writes are disabled") {
+		t.Errorf("Expected write_log_entry to be denied, got %+v", result)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	redactor, err := redact.New(redact.Config{Builtins: []string{redact.BuiltinEmail}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := callTool(t, Redact(redactor)(textHandler(`{"summary": "login failed for alice@example.com"}`)), "generate_incident_report")
+	if got := resultText(result); got != `{"summary": "login failed for [REDACTED:email]"}` {
+		t.Errorf("Expected the email to be redacted, got %q", got)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	if !limiter.Allow("a") || !limiter.Allow("a") {
+		t.Fatal("Expected a burst of 2 calls to be allowed")
+	}
+	if limiter.Allow("a") {
+		t.Error("Expected the third call to be limited")
+	}
+	if !limiter.Allow("b") {
+		t.Error("Expected other sessions not to be limited")
+	}
+	now = now.Add(30 * time.Second)
+	if !limiter.Allow("a") {
+		t.Error("Expected a call to be allowed after half the interval")
+	}
+	if limiter.Allow("a") {
+		t.Error("Expected the next call to be limited")
+	}
+
+	now = now.Add(time.Minute)
+	if !limiter.Allow("b") || len(limiter.buckets) != 1 {
+		t.Errorf("Expected the idle bucket of a to be dropped, got %d buckets", len(limiter.buckets))
+	}
+
+	result := callTool(t, limiter.Middleware()(textHandler("ok")), "list_traces")
+	if result.IsError {
+		t.Errorf("Expected calls outside of sessions to have their own limit, got %+v", result)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	failing := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("failed"), nil
+	}
+
+	callTool(t, metrics.Middleware()(textHandler("ok")), "list_traces")
+	callTool(t, metrics.Middleware()(failing), "list_traces")
+	stats := metrics.Stats()["list_traces"]
+	if stats.Calls != 2 || stats.Errors != 1 {
+		t.Errorf("Expected 2 calls and 1 error, got %+v", stats)
+	}
+}
+
+func TestLargeResults(t *testing.T) {
+	text := `{"entries": [{"message": "a"}, {"message": "b"}], "next_page_token": "t"}`
+
+	result := callTool(t, LargeResults(1000, LargeResultResource)(textHandler(text)), "list_log_entries")
+	if len(result.Content) != 1 || resultText(result) != text {
+		t.Errorf("Expected small results to be left inline, got %+v", result.Content)
+	}
+
+	result = callTool(t, LargeResults(10, LargeResultResource)(textHandler(text)), "list_log_entries")
+	if len(result.Content) != 2 {
+		t.Fatalf("Expected a summary and a resource, got %+v", result.Content)
+	}
+	summary := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"list_log_entries is 73 bytes", "gcp-telemetry-mcp://results/list_log_entries/1", "entries (an array of 2 items), next_page_token (a string)"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected the summary to contain %q, got %q", want, summary)
+		}
+	}
+	resource := result.Content[1].(mcp.EmbeddedResource).Resource.(mcp.TextResourceContents)
+	if resource.Text != text || resource.MIMEType != "application/json" {
+		t.Errorf("Expected the result in the resource, got %+v", resource)
+	}
+
+	result = callTool(t, LargeResults(10, LargeResultGzip)(textHandler(text)), "list_log_entries")
+	blob := result.Content[1].(mcp.EmbeddedResource).Resource.(mcp.BlobResourceContents)
+	data, err := base64.StdEncoding.DecodeString(blob.Blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(r)
+	if err != nil || string(decompressed) != text {
+		t.Errorf("Expected the gzipped result, got %q, %v", decompressed, err)
+	}
+}
+
+func TestParseLargeResultFormat(t *testing.T) {
+	if format, err := ParseLargeResultFormat("gzip"); err != nil || format != LargeResultGzip {
+		t.Errorf("ParseLargeResultFormat(gzip) = %q, %v", format, err)
+	}
+	if _, err := ParseLargeResultFormat("zip"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(30*time.Second, func(tool string) bool { return tool == "list_traces" }, func(string) bool { return false })
+	cache.now = func() time.Time { return now }
+	calls := 0
+	handler := cache.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		return mcp.NewToolResultText(fmt.Sprintf("call %d", calls)), nil
+	})
+	call := func(name string, args map[string]any) *mcp.CallToolResult {
+		var request mcp.CallToolRequest
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	call("list_traces", map[string]any{"filter": "a"})
+	now = now.Add(10 * time.Second)
+	result := call("list_traces", map[string]any{"filter": "a"})
+	if calls != 1 || result.Content[0].(mcp.TextContent).Text != "call 1" || result.Meta["cached"] != true {
+		t.Errorf("Expected the identical call to be answered from the cache, got %d calls and %+v", calls, result)
+	}
+	if note := result.Content[1].(mcp.TextContent).Text; !strings.Contains(note, "cached 10s ago") {
+		t.Errorf("Expected a note of the age of the result, got %q", note)
+	}
+
+	call("list_traces", map[string]any{"filter": "b"})
+	if calls != 2 {
+		t.Errorf("Expected other arguments not to be cached, got %d calls", calls)
+	}
+
+	now = now.Add(30 * time.Second)
+	call("list_traces", map[string]any{"filter": "b"})
+	if calls != 3 {
+		t.Errorf("Expected expired results not to be reused, got %d calls", calls)
+	}
+
+	call("write_log_entry", nil)
+	call("list_traces", map[string]any{"filter": "b"})
+	if calls != 5 {
+		t.Errorf("Expected other tools to clear the cache, got %d calls", calls)
+	}
+}
+
+func TestCache_CursorPaged(t *testing.T) {
+	cache := NewCache(30*time.Second, func(string) bool { return true }, func(tool string) bool { return tool == "list_log_entries" })
+	// Page tokens are single-use cursors, removed when they are followed
+	cursors := map[string]bool{}
+	calls := 0
+	handler := cache.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls++
+		token := request.GetString("page_token", "")
+		if token == "" {
+			next := fmt.Sprintf("cursor-%d", calls)
+			cursors[next] = true
+			return mcp.NewToolResultText(fmt.Sprintf(`{"entries": ["a"], "next_page_token": %q}`, next)), nil
+		}
+		if !cursors[token] {
+			return mcp.NewToolResultError("unknown page token"), nil
+		}
+		delete(cursors, token)
+		return mcp.NewToolResultText(`{"entries": ["b"]}`), nil
+	})
+	call := func(args map[string]any) *mcp.CallToolResult {
+		var request mcp.CallToolRequest
+		request.Params.Name = "list_log_entries"
+		request.Params.Arguments = args
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	follow := func(result *mcp.CallToolResult) *mcp.CallToolResult {
+		var page struct {
+			NextPageToken string `json:"next_page_token"`
+		}
+		if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &page); err != nil {
+			t.Fatal(err)
+		}
+		return call(map[string]any{"filter": "a", "page_token": page.NextPageToken})
+	}
+
+	first := call(map[string]any{"filter": "a"})
+	if result := follow(first); result.IsError {
+		t.Fatalf("Expected the token to be followed, got %+v", result)
+	}
+	second := call(map[string]any{"filter": "a"})
+	if calls != 3 || second.Meta["cached"] == true {
+		t.Errorf("Expected the first page not to be cached, got %d calls and %+v", calls, second)
+	}
+	if result := follow(second); result.IsError {
+		t.Errorf("Expected the token of the second call to be followed, got %+v", result)
+	}
+
+	// The last page has no token and is cached
+	if result := follow(second); calls != 4 || result.Meta["cached"] != true {
+		t.Errorf("Expected the last page to be cached, got %d calls", calls)
+	}
+}
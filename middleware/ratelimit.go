@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RateLimiter limits the tool calls of each session to a number of calls per
+// interval, allowing bursts of up to that number of calls
+type RateLimiter struct {
+	limit    int
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket holds the calls a session can still make
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing limit calls per interval for
+// each session
+func NewRateLimiter(limit int, interval time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:    limit,
+		interval: interval,
+		now:      time.Now,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether the session can make a call now, and counts the call
+// when it can
+func (l *RateLimiter) Allow(sessionID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	// Buckets refilled over a whole interval are full, as for new sessions,
+	// so they are dropped to forget the sessions that ended
+	for id, b := range l.buckets {
+		if now.Sub(b.last) >= l.interval {
+			delete(l.buckets, id)
+		}
+	}
+	b, ok := l.buckets[sessionID]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), last: now}
+		l.buckets[sessionID] = b
+	}
+	b.tokens = min(float64(l.limit), b.tokens+float64(l.limit)*float64(now.Sub(b.last))/float64(l.interval))
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Middleware returns the middleware failing the tool calls of sessions over
+// the limit without calling the tools
+func (l *RateLimiter) Middleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !l.Allow(sessionID(ctx)) {
+				return mcp.NewToolResultError(fmt.Sprintf("Rate limit exceeded: at most %d tool calls per %s", l.limit, l.interval)), nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// alertsEndpoint is the base URL of the Cloud Monitoring Alerts API, which
+// the generated Go client does not cover yet
+const alertsEndpoint = "https://monitoring.googleapis.com/v3"
+
+// Alert states
+const (
+	AlertStateOpen   = "OPEN"
+	AlertStateClosed = "CLOSED"
+)
+
+// Alert represents an alert (incident) opened by an alerting policy
+type Alert struct {
+	Name              string            `json:"name"`
+	State             string            `json:"state"`
+	OpenTime          time.Time         `json:"open_time"`
+	CloseTime         *time.Time        `json:"close_time,omitempty"`
+	PolicyName        string            `json:"policy_name,omitempty"`
+	PolicyDisplayName string            `json:"policy_display_name,omitempty"`
+	Severity          string            `json:"severity,omitempty"`
+	ResourceType      string            `json:"resource_type,omitempty"`
+	ResourceLabels    map[string]string `json:"resource_labels,omitempty"`
+	MetricType        string            `json:"metric_type,omitempty"`
+	MetricLabels      map[string]string `json:"metric_labels,omitempty"`
+}
+
+// ListAlertsRequest represents a request to list alerts
+type ListAlertsRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	Filter    string `json:"filter,omitempty"`     // e.g. state="OPEN"
+	OrderBy   string `json:"order_by,omitempty"`   // e.g. open_time desc
+	PageSize  int    `json:"page_size,omitempty"`
+	PageToken string `json:"page_token,omitempty"`
+}
+
+// ListAlertsResponse represents a response with alerts and pagination info
+type ListAlertsResponse struct {
+	Alerts        []Alert `json:"alerts"`
+	NextPageToken string  `json:"next_page_token,omitempty"`
+}
+
+// ListAlerts lists alerts opened by alerting policies
+func (c *CloudMonitoringClient) ListAlerts(ctx context.Context, req ListAlertsRequest) (ListAlertsResponse, error) {
+	return c.client.ListAlerts(ctx, req)
+}
+
+// apiAlert is the JSON representation of an alert in the Alerts API
+type apiAlert struct {
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	OpenTime  string `json:"openTime"`
+	CloseTime string `json:"closeTime"`
+	Policy    struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+		Severity    string `json:"severity"`
+	} `json:"policy"`
+	Resource struct {
+		Type   string            `json:"type"`
+		Labels map[string]string `json:"labels"`
+	} `json:"resource"`
+	Metric struct {
+		Type   string            `json:"type"`
+		Labels map[string]string `json:"labels"`
+	} `json:"metric"`
+}
+
+// ListAlerts implements MonitoringClientInterface for the real client
+func (r *realMonitoringClient) ListAlerts(ctx context.Context, req ListAlertsRequest) (ListAlertsResponse, error) {
+	query := url.Values{}
+	if req.Filter != "" {
+		query.Set("filter", req.Filter)
+	}
+	if req.OrderBy != "" {
+		query.Set("orderBy", req.OrderBy)
+	}
+	if req.PageSize > 0 {
+		query.Set("pageSize", strconv.Itoa(req.PageSize))
+	}
+	if req.PageToken != "" {
+		query.Set("pageToken", req.PageToken)
+	}
+
+	u := fmt.Sprintf("%s/%s/alerts?%s", r.alertsEndpoint, r.projectName(req.ProjectID), query.Encode())
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return ListAlertsResponse{}, err
+	}
+
+	resp, err := r.httpClient.Do(httpReq)
+	if err != nil {
+		return ListAlertsResponse{}, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ListAlertsResponse{}, fmt.Errorf("failed to read alerts response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ListAlertsResponse{}, fmt.Errorf("failed to list alerts: %s: %s", resp.Status, body)
+	}
+
+	var apiResp struct {
+		Alerts        []apiAlert `json:"alerts"`
+		NextPageToken string     `json:"nextPageToken"`
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return ListAlertsResponse{}, fmt.Errorf("failed to parse alerts response: %w", err)
+	}
+
+	alerts := make([]Alert, 0, len(apiResp.Alerts))
+	for _, a := range apiResp.Alerts {
+		alert := Alert{
+			Name:              a.Name,
+			State:             a.State,
+			PolicyName:        a.Policy.Name,
+			PolicyDisplayName: a.Policy.DisplayName,
+			Severity:          a.Policy.Severity,
+			ResourceType:      a.Resource.Type,
+			ResourceLabels:    a.Resource.Labels,
+			MetricType:        a.Metric.Type,
+			MetricLabels:      a.Metric.Labels,
+		}
+		if t, err := time.Parse(time.RFC3339Nano, a.OpenTime); err == nil {
+			alert.OpenTime = t
+		}
+		if t, err := time.Parse(time.RFC3339Nano, a.CloseTime); err == nil {
+			alert.CloseTime = &t
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return ListAlertsResponse{
+		Alerts:        alerts,
+		NextPageToken: apiResp.NextPageToken,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxGridPoints bounds the number of timestamps of an aligned grid
+const maxGridPoints = 10000
+
+// AlignedTimeSeries holds several time series sampled on one shared, ascending
+// grid of timestamps
+type AlignedTimeSeries struct {
+	Timestamps []time.Time     `json:"timestamps"`
+	Series     []AlignedSeries `json:"series"`
+}
+
+// AlignedSeries is a time series whose values line up with
+// AlignedTimeSeries.Timestamps. A nil value marks a gap with no data point.
+type AlignedSeries struct {
+	MetricType     string            `json:"metric_type"`
+	MetricLabels   map[string]string `json:"metric_labels,omitempty"`
+	ResourceType   string            `json:"resource_type"`
+	ResourceLabels map[string]string `json:"resource_labels,omitempty"`
+	Values         []*float64        `json:"values"`
+}
+
+// Align places all series on a common grid of timestamps. When step is
+// positive, the grid runs from the earliest to the latest point every step and
+// each point is snapped to the nearest grid timestamp, so that missing periods
+// show up as gaps. Otherwise the grid is the union of all point timestamps.
+func Align(series []TimeSeriesData, step time.Duration) (AlignedTimeSeries, error) {
+	var first, last time.Time
+	seen := map[time.Time]bool{}
+	for _, ts := range series {
+		for _, p := range ts.Values {
+			t := p.Timestamp.UTC()
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if last.IsZero() || t.After(last) {
+				last = t
+			}
+			seen[t] = true
+		}
+	}
+
+	var grid []time.Time
+	switch {
+	case len(seen) == 0:
+	case step > 0:
+		if n := int64(last.Sub(first)/step) + 1; n > maxGridPoints {
+			return AlignedTimeSeries{}, fmt.Errorf("aligning to a %s grid would produce %d timestamps (max %d): use a larger alignment period or a shorter range", step, n, maxGridPoints)
+		}
+		for t := first; !t.After(last.Add(step / 2)); t = t.Add(step) {
+			grid = append(grid, t)
+		}
+	default:
+		for t := range seen {
+			grid = append(grid, t)
+		}
+		sort.Slice(grid, func(i, j int) bool { return grid[i].Before(grid[j]) })
+	}
+
+	index := make(map[time.Time]int, len(grid))
+	for i, t := range grid {
+		index[t] = i
+	}
+
+	aligned := AlignedTimeSeries{Timestamps: grid, Series: make([]AlignedSeries, 0, len(series))}
+	for _, ts := range series {
+		values := make([]*float64, len(grid))
+		for _, p := range ts.Values {
+			var i int
+			if step > 0 {
+				i = int((p.Timestamp.Sub(first) + step/2) / step)
+			} else {
+				i = index[p.Timestamp.UTC()]
+			}
+			value := p.Value
+			values[i] = &value
+		}
+		aligned.Series = append(aligned.Series, AlignedSeries{
+			MetricType:     ts.MetricType,
+			MetricLabels:   ts.MetricLabels,
+			ResourceType:   ts.ResourceType,
+			ResourceLabels: ts.ResourceLabels,
+			Values:         values,
+		})
+	}
+	return aligned, nil
+}
@@ -0,0 +1,82 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlign(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int, seconds int) time.Time {
+		return start.Add(time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
+	}
+	series := []TimeSeriesData{
+		{
+			MetricType:   "custom.googleapis.com/latency",
+			MetricLabels: map[string]string{"zone": "a"},
+			// Newest first, with minute 2 missing
+			Values: []MetricValue{{Value: 3, Timestamp: at(3, 0)}, {Value: 1, Timestamp: at(1, 0)}, {Value: 0, Timestamp: at(0, 0)}},
+		},
+		{
+			MetricType:   "custom.googleapis.com/latency",
+			MetricLabels: map[string]string{"zone": "b"},
+			// Slightly off the grid
+			Values: []MetricValue{{Value: 12, Timestamp: at(2, 2)}, {Value: 11, Timestamp: at(0, 59)}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		step       time.Duration
+		timestamps []time.Time
+		want       [][]any
+	}{
+		{
+			name:       "fixed step",
+			step:       time.Minute,
+			timestamps: []time.Time{at(0, 0), at(1, 0), at(2, 0), at(3, 0)},
+			want:       [][]any{{0.0, 1.0, nil, 3.0}, {nil, 11.0, 12.0, nil}},
+		},
+		{
+			name:       "union of timestamps",
+			timestamps: []time.Time{at(0, 0), at(0, 59), at(1, 0), at(2, 2), at(3, 0)},
+			want:       [][]any{{0.0, nil, 1.0, nil, 3.0}, {nil, 11.0, nil, 12.0, nil}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Align(series, tt.step)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(got.Timestamps) != len(tt.timestamps) {
+				t.Fatalf("Expected timestamps %v, got %v", tt.timestamps, got.Timestamps)
+			}
+			for i := range tt.timestamps {
+				if !got.Timestamps[i].Equal(tt.timestamps[i]) {
+					t.Errorf("Expected timestamp %d to be %v, got %v", i, tt.timestamps[i], got.Timestamps[i])
+				}
+			}
+			for s, want := range tt.want {
+				values := got.Series[s].Values
+				for i, w := range want {
+					switch {
+					case w == nil && values[i] != nil:
+						t.Errorf("Expected gap in series %d at %d, got %v", s, i, *values[i])
+					case w != nil && (values[i] == nil || *values[i] != w.(float64)):
+						t.Errorf("Expected %v in series %d at %d, got %v", w, s, i, values[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAlign_TooManyPoints(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := []TimeSeriesData{{Values: []MetricValue{{Timestamp: start}, {Timestamp: start.Add(30 * 24 * time.Hour)}}}}
+	if _, err := Align(series, time.Second); err == nil {
+		t.Error("Expected error for an oversized grid")
+	}
+}
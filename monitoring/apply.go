@@ -0,0 +1,215 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"cloud.google.com/go/monitoring/dashboard/apiv1/dashboardpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Apply modes
+const (
+	// ApplyModeAuto updates the resource with the same ID in the target
+	// project when it exists, and creates a new resource otherwise
+	ApplyModeAuto   = "auto"
+	ApplyModeCreate = "create"
+	ApplyModeUpdate = "update"
+)
+
+// Apply actions
+const (
+	ApplyActionCreated = "created"
+	ApplyActionUpdated = "updated"
+)
+
+// ApplyRequest represents a request to create or update a resource from its
+// JSON definition, as exported from the Cloud Console or returned by the API
+type ApplyRequest struct {
+	ProjectID  string          `json:"project_id,omitempty"` // target project, defaults to the client's project
+	Definition json.RawMessage `json:"definition"`
+	Mode       string          `json:"mode,omitempty"` // defaults to ApplyModeAuto
+	// NotificationChannels replaces the notification channels of an alert policy when set
+	NotificationChannels []string `json:"notification_channels,omitempty"`
+	// DryRun resolves the action and validates the definition without applying it
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// ApplyResult represents the outcome of applying a definition
+type ApplyResult struct {
+	Action      string   `json:"action"` // ApplyActionCreated or ApplyActionUpdated; what would happen on a dry run
+	Name        string   `json:"name,omitempty"`
+	DisplayName string   `json:"display_name"`
+	DryRun      bool     `json:"dry_run,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// ApplyAlertPolicy creates or updates an alerting policy from its JSON definition
+func (c *CloudMonitoringClient) ApplyAlertPolicy(ctx context.Context, req ApplyRequest) (ApplyResult, error) {
+	return c.client.ApplyAlertPolicy(ctx, req)
+}
+
+// ApplyDashboard creates or updates a dashboard from its JSON definition
+func (c *CloudMonitoringClient) ApplyDashboard(ctx context.Context, req ApplyRequest) (ApplyResult, error) {
+	return c.client.ApplyDashboard(ctx, req)
+}
+
+// ApplyAlertPolicy implements MonitoringClientInterface for the real client
+func (r *realMonitoringClient) ApplyAlertPolicy(ctx context.Context, req ApplyRequest) (ApplyResult, error) {
+	policy := &monitoringpb.AlertPolicy{}
+	if err := protojson.Unmarshal(req.Definition, policy); err != nil {
+		return ApplyResult{}, fmt.Errorf("invalid alert policy definition: %w", err)
+	}
+	result := ApplyResult{DisplayName: policy.GetDisplayName(), DryRun: req.DryRun}
+	project := r.projectName(req.ProjectID)
+
+	if req.NotificationChannels != nil {
+		policy.NotificationChannels = req.NotificationChannels
+	} else {
+		var dropped []string
+		policy.NotificationChannels, dropped = sameProjectChannels(policy.GetName(), project, policy.GetNotificationChannels())
+		for _, ch := range dropped {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("dropped notification channel %s of the source project; set notification_channels to choose channels of the target project", ch))
+		}
+	}
+
+	target, exists, err := resolveApplyTarget(req.Mode, project, "alertPolicies", policy.GetName(), func(name string) error {
+		_, err := r.alertPolicyClient.GetAlertPolicy(ctx, &monitoringpb.GetAlertPolicyRequest{Name: name})
+		return err
+	})
+	if err != nil {
+		return ApplyResult{}, err
+	}
+
+	if exists {
+		result.Action = ApplyActionUpdated
+		policy.Name = target
+		if !req.DryRun {
+			updated, err := r.alertPolicyClient.UpdateAlertPolicy(ctx, &monitoringpb.UpdateAlertPolicyRequest{AlertPolicy: policy})
+			if err != nil {
+				return ApplyResult{}, fmt.Errorf("failed to update alert policy: %w", err)
+			}
+			target = updated.GetName()
+		}
+		result.Name = target
+		return result, nil
+	}
+
+	result.Action = ApplyActionCreated
+	// Names are assigned by Cloud Monitoring on creation
+	policy.Name = ""
+	for _, c := range policy.GetConditions() {
+		c.Name = ""
+	}
+	if !req.DryRun {
+		created, err := r.alertPolicyClient.CreateAlertPolicy(ctx, &monitoringpb.CreateAlertPolicyRequest{Name: project, AlertPolicy: policy})
+		if err != nil {
+			return ApplyResult{}, fmt.Errorf("failed to create alert policy: %w", err)
+		}
+		result.Name = created.GetName()
+	}
+	return result, nil
+}
+
+// ApplyDashboard implements MonitoringClientInterface for the real client
+func (r *realMonitoringClient) ApplyDashboard(ctx context.Context, req ApplyRequest) (ApplyResult, error) {
+	dashboard := &dashboardpb.Dashboard{}
+	if err := protojson.Unmarshal(req.Definition, dashboard); err != nil {
+		return ApplyResult{}, fmt.Errorf("invalid dashboard definition: %w", err)
+	}
+	result := ApplyResult{DisplayName: dashboard.GetDisplayName(), DryRun: req.DryRun}
+	project := r.projectName(req.ProjectID)
+
+	target, exists, err := resolveApplyTarget(req.Mode, project, "dashboards", dashboard.GetName(), func(name string) error {
+		_, err := r.dashboardClient.GetDashboard(ctx, &dashboardpb.GetDashboardRequest{Name: name})
+		return err
+	})
+	if err != nil {
+		return ApplyResult{}, err
+	}
+	// The definition replaces the dashboard regardless of edits since it was exported
+	dashboard.Etag = ""
+
+	if exists {
+		result.Action = ApplyActionUpdated
+		dashboard.Name = target
+		updated, err := r.dashboardClient.UpdateDashboard(ctx, &dashboardpb.UpdateDashboardRequest{Dashboard: dashboard, ValidateOnly: req.DryRun})
+		if err != nil {
+			return ApplyResult{}, fmt.Errorf("failed to update dashboard: %w", err)
+		}
+		result.Name = target
+		if !req.DryRun {
+			result.Name = updated.GetName()
+		}
+		return result, nil
+	}
+
+	result.Action = ApplyActionCreated
+	dashboard.Name = ""
+	created, err := r.dashboardClient.CreateDashboard(ctx, &dashboardpb.CreateDashboardRequest{Parent: project, Dashboard: dashboard, ValidateOnly: req.DryRun})
+	if err != nil {
+		return ApplyResult{}, fmt.Errorf("failed to create dashboard: %w", err)
+	}
+	if !req.DryRun {
+		result.Name = created.GetName()
+	}
+	return result, nil
+}
+
+// resolveApplyTarget returns the name of the resource in the target project
+// with the same ID as the definition's name, and whether it exists. The
+// definition may come from another project, so its own name is never updated.
+func resolveApplyTarget(mode, project, collection, name string, get func(name string) error) (string, bool, error) {
+	if mode == "" {
+		mode = ApplyModeAuto
+	}
+	switch mode {
+	case ApplyModeCreate:
+		return "", false, nil
+	case ApplyModeAuto, ApplyModeUpdate:
+	default:
+		return "", false, fmt.Errorf("unsupported mode %q: use %q, %q, or %q", mode, ApplyModeAuto, ApplyModeCreate, ApplyModeUpdate)
+	}
+
+	id := name[strings.LastIndex(name, "/")+1:]
+	if id == "" {
+		if mode == ApplyModeUpdate {
+			return "", false, fmt.Errorf("the definition has no name to update")
+		}
+		return "", false, nil
+	}
+	target := fmt.Sprintf("%s/%s/%s", project, collection, id)
+	err := get(target)
+	switch {
+	case err == nil:
+		return target, true, nil
+	case status.Code(err) == codes.NotFound && mode == ApplyModeAuto:
+		return "", false, nil
+	case status.Code(err) == codes.NotFound:
+		return "", false, fmt.Errorf("%s does not exist", target)
+	}
+	return "", false, fmt.Errorf("failed to get %s: %w", target, err)
+}
+
+// sameProjectChannels drops the notification channels that belong to the
+// project the policy was exported from when it differs from the target
+// project, since channels cannot be shared across projects
+func sameProjectChannels(policyName, project string, channels []string) (kept, dropped []string) {
+	source, _, ok := strings.Cut(strings.TrimPrefix(policyName, "projects/"), "/")
+	if !ok || "projects/"+source == project {
+		return channels, nil
+	}
+	for _, ch := range channels {
+		if strings.HasPrefix(ch, "projects/"+source+"/") {
+			dropped = append(dropped, ch)
+		} else {
+			kept = append(kept, ch)
+		}
+	}
+	return kept, dropped
+}
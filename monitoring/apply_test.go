@@ -0,0 +1,107 @@
+package monitoring
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResolveApplyTarget(t *testing.T) {
+	notFound := status.Error(codes.NotFound, "not found")
+	tests := []struct {
+		name       string
+		mode       string
+		defName    string
+		getErr     error
+		wantTarget string
+		wantExists bool
+		wantErr    bool
+	}{
+		{
+			name:       "same ID exists in the target project",
+			defName:    "projects/123456/alertPolicies/42",
+			wantTarget: "projects/target/alertPolicies/42",
+			wantExists: true,
+		},
+		{
+			name:    "exported from another project",
+			defName: "projects/source/alertPolicies/42",
+			getErr:  notFound,
+		},
+		{
+			name: "no name",
+		},
+		{
+			name:    "create ignores the name",
+			mode:    ApplyModeCreate,
+			defName: "projects/target/alertPolicies/42",
+		},
+		{
+			name:    "update of a missing resource",
+			mode:    ApplyModeUpdate,
+			defName: "projects/source/alertPolicies/42",
+			getErr:  notFound,
+			wantErr: true,
+		},
+		{
+			name:    "update without a name",
+			mode:    ApplyModeUpdate,
+			wantErr: true,
+		},
+		{
+			name:    "permission denied",
+			defName: "projects/target/alertPolicies/42",
+			getErr:  status.Error(codes.PermissionDenied, "denied"),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported mode",
+			mode:    "replace",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			target, exists, err := resolveApplyTarget(tt.mode, "projects/target", "alertPolicies", tt.defName, func(name string) error {
+				got = name
+				return tt.getErr
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveApplyTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if target != tt.wantTarget || exists != tt.wantExists {
+				t.Errorf("resolveApplyTarget() = %q, %v, want %q, %v", target, exists, tt.wantTarget, tt.wantExists)
+			}
+			if got != "" && got != "projects/target/alertPolicies/42" {
+				t.Errorf("Expected the lookup in the target project, got %s", got)
+			}
+		})
+	}
+
+	if _, _, err := resolveApplyTarget("", "projects/target", "dashboards", "projects/p/dashboards/d", func(string) error { return errors.New("boom") }); err == nil {
+		t.Error("Expected lookup errors to be returned")
+	}
+}
+
+func TestSameProjectChannels(t *testing.T) {
+	channels := []string{"projects/source/notificationChannels/1", "projects/shared/notificationChannels/2"}
+
+	kept, dropped := sameProjectChannels("projects/source/alertPolicies/42", "projects/target", channels)
+	if !reflect.DeepEqual(kept, []string{"projects/shared/notificationChannels/2"}) || !reflect.DeepEqual(dropped, []string{"projects/source/notificationChannels/1"}) {
+		t.Errorf("Unexpected channels kept %v, dropped %v", kept, dropped)
+	}
+
+	kept, dropped = sameProjectChannels("projects/target/alertPolicies/42", "projects/target", channels)
+	if !reflect.DeepEqual(kept, channels) || dropped != nil {
+		t.Errorf("Expected channels of the same project to be kept, got %v, dropped %v", kept, dropped)
+	}
+
+	kept, dropped = sameProjectChannels("", "projects/target", channels)
+	if !reflect.DeepEqual(kept, channels) || dropped != nil {
+		t.Errorf("Expected all channels to be kept without a name, got %v, dropped %v", kept, dropped)
+	}
+}
@@ -0,0 +1,137 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxBulkDeletes bounds the descriptors deleted by one request
+	maxBulkDeletes = 500
+	// maxConcurrentDeletes limits the descriptors deleted at the same time
+	maxConcurrentDeletes = 8
+)
+
+// userDefinedMetricPrefixes are the prefixes of the metric types whose
+// descriptors can be deleted
+var userDefinedMetricPrefixes = []string{CustomMetricPrefix, "external.googleapis.com/"}
+
+// DeleteMetricDescriptorsRequest represents a request to delete the
+// descriptors matching a filter or listed by type
+type DeleteMetricDescriptorsRequest struct {
+	ProjectID string   `json:"project_id,omitempty"` // defaults to the client's project
+	Filter    string   `json:"filter,omitempty"`     // e.g. metric.type = starts_with("custom.googleapis.com/experiment/")
+	Types     []string `json:"types,omitempty"`
+	// DryRun lists the descriptors that would be deleted without deleting them
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// DeleteResult represents the outcome of deleting one descriptor
+type DeleteResult struct {
+	Type    string `json:"type"`
+	Deleted bool   `json:"deleted"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DeleteMetricDescriptorsResponse lists the descriptors matched and the
+// outcome of deleting each of them
+type DeleteMetricDescriptorsResponse struct {
+	DryRun  bool     `json:"dry_run"`
+	Matched []string `json:"matched"`
+	// Results has one item per matched descriptor, unless DryRun
+	Results []DeleteResult `json:"results,omitempty"`
+	Deleted int            `json:"deleted"`
+	Failed  int            `json:"failed"`
+}
+
+// DeleteMetricDescriptors deletes the user-defined metric descriptors
+// matching the filter and those listed in Types, concurrently. A descriptor
+// that cannot be deleted is reported in its result rather than failing the
+// others.
+func (c *CloudMonitoringClient) DeleteMetricDescriptors(ctx context.Context, req DeleteMetricDescriptorsRequest) (DeleteMetricDescriptorsResponse, error) {
+	if req.Filter == "" && len(req.Types) == 0 {
+		return DeleteMetricDescriptorsResponse{}, fmt.Errorf("filter or types is required")
+	}
+	// Descriptors are deleted by type in the client's project
+	if req.ProjectID != "" && req.ProjectID != c.projectID {
+		return DeleteMetricDescriptorsResponse{}, fmt.Errorf("descriptors can only be deleted in project %s, not %s", c.projectID, req.ProjectID)
+	}
+
+	matched := slices.Clone(req.Types)
+	if req.Filter != "" {
+		listReq := ListMetricDescriptorsRequest{
+			ProjectID: req.ProjectID,
+			Filter:    req.Filter,
+			PageSize:  maxBulkDeletes,
+		}
+		for {
+			page, err := c.client.ListMetricDescriptors(ctx, listReq)
+			if err != nil {
+				return DeleteMetricDescriptorsResponse{}, fmt.Errorf("failed to list metric descriptors: %w", err)
+			}
+			for _, d := range page.Descriptors {
+				matched = append(matched, d.Type)
+			}
+			if page.NextPageToken == "" {
+				break
+			}
+			listReq.PageToken = page.NextPageToken
+		}
+	}
+	slices.Sort(matched)
+	matched = slices.Compact(matched)
+	if len(matched) > maxBulkDeletes {
+		return DeleteMetricDescriptorsResponse{}, fmt.Errorf("%d descriptors matched, more than the %d that can be deleted at once; narrow the filter", len(matched), maxBulkDeletes)
+	}
+	for _, metricType := range matched {
+		if !isUserDefinedMetric(metricType) {
+			return DeleteMetricDescriptorsResponse{}, fmt.Errorf("%s is not a user-defined metric: only the types starting with %s can be deleted",
+				metricType, strings.Join(userDefinedMetricPrefixes, " or "))
+		}
+	}
+
+	resp := DeleteMetricDescriptorsResponse{DryRun: req.DryRun, Matched: matched}
+	if req.DryRun {
+		return resp, nil
+	}
+
+	resp.Results = make([]DeleteResult, len(matched))
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxConcurrentDeletes)
+	)
+	for i, metricType := range matched {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			resp.Results[i] = DeleteResult{Type: metricType, Deleted: true}
+			if err := c.client.DeleteMetricDescriptor(ctx, metricType); err != nil {
+				resp.Results[i] = DeleteResult{Type: metricType, Error: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range resp.Results {
+		if result.Deleted {
+			resp.Deleted++
+		} else {
+			resp.Failed++
+		}
+	}
+	return resp, nil
+}
+
+// isUserDefinedMetric reports whether the descriptor of metricType was
+// created by users rather than by Google Cloud
+func isUserDefinedMetric(metricType string) bool {
+	return slices.ContainsFunc(userDefinedMetricPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(metricType, prefix)
+	})
+}
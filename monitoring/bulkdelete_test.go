@@ -0,0 +1,111 @@
+package monitoring_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudMonitoringClient_DeleteMetricDescriptors(t *testing.T) {
+	experiments := []monitoring.MetricDescriptor{
+		{Type: "custom.googleapis.com/experiment/a"},
+		{Type: "custom.googleapis.com/experiment/b"},
+	}
+
+	t.Run("deletes the matches and the listed types", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+		client := monitoring.NewWithClient(mockClient, "test-project")
+		mockClient.EXPECT().
+			ListMetricDescriptors(gomock.Any(), gomock.Any()).
+			Return(monitoring.ListMetricDescriptorsResponse{Descriptors: experiments}, nil)
+		mockClient.EXPECT().DeleteMetricDescriptor(gomock.Any(), "custom.googleapis.com/experiment/a").Return(nil)
+		mockClient.EXPECT().DeleteMetricDescriptor(gomock.Any(), "custom.googleapis.com/experiment/b").Return(errors.New("not found"))
+		mockClient.EXPECT().DeleteMetricDescriptor(gomock.Any(), "external.googleapis.com/prometheus/old").Return(nil)
+
+		resp, err := client.DeleteMetricDescriptors(context.Background(), monitoring.DeleteMetricDescriptorsRequest{
+			Filter: `metric.type = starts_with("custom.googleapis.com/experiment/")`,
+			// Listed types matching the filter are deleted once
+			Types: []string{"external.googleapis.com/prometheus/old", "custom.googleapis.com/experiment/a"},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		want := []string{"custom.googleapis.com/experiment/a", "custom.googleapis.com/experiment/b", "external.googleapis.com/prometheus/old"}
+		if !slices.Equal(resp.Matched, want) {
+			t.Errorf("Matched = %v, want %v", resp.Matched, want)
+		}
+		if resp.Deleted != 2 || resp.Failed != 1 {
+			t.Errorf("Expected 2 deleted and 1 failed, got %d and %d", resp.Deleted, resp.Failed)
+		}
+		if len(resp.Results) != 3 || resp.Results[1].Deleted || resp.Results[1].Error != "not found" {
+			t.Errorf("Unexpected results %+v", resp.Results)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+		client := monitoring.NewWithClient(mockClient, "test-project")
+		mockClient.EXPECT().
+			ListMetricDescriptors(gomock.Any(), gomock.Any()).
+			Return(monitoring.ListMetricDescriptorsResponse{Descriptors: experiments}, nil)
+
+		resp, err := client.DeleteMetricDescriptors(context.Background(), monitoring.DeleteMetricDescriptorsRequest{
+			Filter: `metric.type = starts_with("custom.googleapis.com/experiment/")`,
+			DryRun: true,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !resp.DryRun || len(resp.Matched) != 2 || resp.Results != nil || resp.Deleted != 0 {
+			t.Errorf("Expected the matches to be previewed only, got %+v", resp)
+		}
+	})
+
+	errorTests := []struct {
+		name    string
+		req     monitoring.DeleteMetricDescriptorsRequest
+		wantErr string
+	}{
+		{
+			name:    "nothing selected",
+			req:     monitoring.DeleteMetricDescriptorsRequest{},
+			wantErr: "filter or types is required",
+		},
+		{
+			name:    "built-in metric",
+			req:     monitoring.DeleteMetricDescriptorsRequest{Types: []string{"compute.googleapis.com/instance/uptime"}},
+			wantErr: "is not a user-defined metric",
+		},
+		{
+			name:    "another project",
+			req:     monitoring.DeleteMetricDescriptorsRequest{ProjectID: "other-project", Types: []string{"custom.googleapis.com/a"}},
+			wantErr: "can only be deleted in project test-project",
+		},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Nothing is deleted when the request is rejected
+			client := monitoring.NewWithClient(mocks.NewMockMonitoringClientInterface(ctrl), "test-project")
+			_, err := client.DeleteMetricDescriptors(context.Background(), tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BurnRatePolicy is a multi-window burn-rate alert: it fires while the error
+// budget burn rate over both the long and the short window is at least BurnRate
+type BurnRatePolicy struct {
+	Name        string        `json:"name"`
+	BurnRate    float64       `json:"burn_rate"`
+	LongWindow  time.Duration `json:"long_window"`
+	ShortWindow time.Duration `json:"short_window"`
+}
+
+// DefaultBurnRatePolicies are the multi-window, multi-burn-rate alerts
+// recommended by the Google SRE workbook for a 30 day SLO
+var DefaultBurnRatePolicies = []BurnRatePolicy{
+	{Name: "page-fast", BurnRate: 14.4, LongWindow: time.Hour, ShortWindow: 5 * time.Minute},
+	{Name: "page-slow", BurnRate: 6, LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute},
+	{Name: "ticket", BurnRate: 1, LongWindow: 72 * time.Hour, ShortWindow: 6 * time.Hour},
+}
+
+// maxBurnRateMinutes bounds the simulated range, including the longest window, at 90 days
+const maxBurnRateMinutes = 90 * 24 * 60
+
+// BurnRateRequest represents a request to replay burn-rate alerts over history.
+// Either SLOName or SLO must be given.
+type BurnRateRequest struct {
+	ProjectID string                `json:"project_id,omitempty"` // defaults to the client's project
+	SLOName   string                `json:"slo_name,omitempty"`   // full resource name of an existing SLO
+	SLO       ServiceLevelObjective `json:"slo"`
+	StartTime time.Time             `json:"start_time"`
+	EndTime   time.Time             `json:"end_time"`
+	Policies  []BurnRatePolicy      `json:"policies,omitempty"` // defaults to DefaultBurnRatePolicies
+}
+
+// BurnRateSimulation reports what burn-rate alerts would have fired
+type BurnRateSimulation struct {
+	SLO         ServiceLevelObjective `json:"slo"`
+	StartTime   time.Time             `json:"start_time"`
+	EndTime     time.Time             `json:"end_time"`
+	TotalEvents float64               `json:"total_events"`
+	BadEvents   float64               `json:"bad_events"`
+	ErrorRate   float64               `json:"error_rate"`
+	// BudgetConsumed is the fraction of the error budget of one SLO period
+	// consumed between StartTime and EndTime
+	BudgetConsumed float64            `json:"budget_consumed"`
+	Policies       []PolicySimulation `json:"policies"`
+}
+
+// PolicySimulation lists the times a burn-rate policy would have fired
+type PolicySimulation struct {
+	Name        string  `json:"name"`
+	BurnRate    float64 `json:"burn_rate"`
+	LongWindow  string  `json:"long_window"`
+	ShortWindow string  `json:"short_window"`
+	// BudgetAtDetection is the fraction of the error budget of one SLO period a
+	// sustained burn at BurnRate consumes before the policy fires
+	BudgetAtDetection float64          `json:"budget_at_detection"`
+	FiringMinutes     int              `json:"firing_minutes"`
+	Firings           []BurnRateFiring `json:"firings"`
+}
+
+// BurnRateFiring is one continuous period during which a policy fired
+type BurnRateFiring struct {
+	Start             time.Time `json:"start"`
+	End               time.Time `json:"end"`
+	Duration          string    `json:"duration"`
+	PeakLongBurnRate  float64   `json:"peak_long_burn_rate"`
+	PeakShortBurnRate float64   `json:"peak_short_burn_rate"`
+	Ongoing           bool      `json:"ongoing,omitempty"` // still firing at EndTime
+}
+
+// SimulateBurnRate replays multi-window burn-rate alerts over the SLO's
+// per-minute event counts between StartTime and EndTime
+func (c *CloudMonitoringClient) SimulateBurnRate(ctx context.Context, req BurnRateRequest) (BurnRateSimulation, error) {
+	slo := req.SLO
+	if req.SLOName != "" {
+		var err error
+		slo, err = c.client.GetServiceLevelObjective(ctx, req.SLOName)
+		if err != nil {
+			return BurnRateSimulation{}, err
+		}
+	}
+	if err := slo.Validate(); err != nil {
+		return BurnRateSimulation{}, fmt.Errorf("invalid SLO: %w", err)
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return BurnRateSimulation{}, fmt.Errorf("start_time must be before end_time")
+	}
+	policies := req.Policies
+	if len(policies) == 0 {
+		policies = DefaultBurnRatePolicies
+	}
+	var longest time.Duration
+	for _, p := range policies {
+		if p.BurnRate <= 0 || p.LongWindow < time.Minute || p.ShortWindow < time.Minute {
+			return BurnRateSimulation{}, fmt.Errorf("policy %q needs a positive burn rate and windows of at least one minute", p.Name)
+		}
+		longest = max(longest, p.LongWindow, p.ShortWindow)
+	}
+
+	start := req.StartTime.Truncate(time.Minute)
+	end := req.EndTime.Truncate(time.Minute)
+	// Windows at the start of the range reach back before it
+	from := start.Add(-longest)
+	if minutes := int(end.Sub(from) / time.Minute); minutes > maxBurnRateMinutes {
+		return BurnRateSimulation{}, fmt.Errorf("the range plus the longest window spans %d minutes (max %d): shorten the range", minutes, maxBurnRateMinutes)
+	}
+
+	counts := func(filter string) ([]float64, error) {
+		if filter == "" {
+			return nil, nil
+		}
+		listReq := ListTimeSeriesRequest{
+			ProjectID: req.ProjectID,
+			Filter:    filter,
+			Aggregation: &AggregationConfig{
+				AlignmentPeriod:    "60s",
+				PerSeriesAligner:   "ALIGN_DELTA",
+				CrossSeriesReducer: "REDUCE_SUM",
+			},
+			PageSize: 1,
+		}
+		listReq.Interval.StartTime = from
+		listReq.Interval.EndTime = end
+		resp, err := c.client.ListTimeSeries(ctx, listReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", filter, err)
+		}
+		perMinute := make([]float64, int(end.Sub(from)/time.Minute))
+		for _, ts := range resp.TimeSeries {
+			for _, p := range ts.Values {
+				// A point aligned at t counts the events of the minute ending at t
+				if i := int(p.Timestamp.Sub(from)/time.Minute) - 1; i >= 0 && i < len(perMinute) {
+					perMinute[i] += p.Value
+				}
+			}
+		}
+		return perMinute, nil
+	}
+
+	good, err := counts(slo.GoodFilter)
+	if err != nil {
+		return BurnRateSimulation{}, err
+	}
+	bad, err := counts(slo.BadFilter)
+	if err != nil {
+		return BurnRateSimulation{}, err
+	}
+	total, err := counts(slo.TotalFilter)
+	if err != nil {
+		return BurnRateSimulation{}, err
+	}
+	switch {
+	case bad == nil:
+		bad = make([]float64, len(total))
+		for i := range total {
+			bad[i] = max(total[i]-good[i], 0)
+		}
+	case total == nil:
+		total = make([]float64, len(bad))
+		for i := range bad {
+			total[i] = good[i] + bad[i]
+		}
+	}
+
+	simulation := simulateBurnRate(bad, total, from, start, slo, policies)
+	simulation.SLO = slo
+	simulation.StartTime = start
+	simulation.EndTime = end
+	return simulation, nil
+}
+
+// simulateBurnRate evaluates each policy every minute between start and the
+// end of the per-minute counts, which begin at from
+func simulateBurnRate(bad, total []float64, from, start time.Time, slo ServiceLevelObjective, policies []BurnRatePolicy) BurnRateSimulation {
+	// Prefix sums make every window sum constant time
+	badSum := make([]float64, len(bad)+1)
+	totalSum := make([]float64, len(total)+1)
+	for i := range bad {
+		badSum[i+1] = badSum[i] + bad[i]
+		totalSum[i+1] = totalSum[i] + total[i]
+	}
+	budget := 1 - slo.Goal
+	// burnRate returns the burn rate of the window of minutes ending before minute i
+	burnRate := func(i int, window time.Duration) float64 {
+		j := max(i-int(window/time.Minute), 0)
+		events := totalSum[i] - totalSum[j]
+		if events == 0 {
+			return 0
+		}
+		return (badSum[i] - badSum[j]) / events / budget
+	}
+
+	first := int(start.Sub(from) / time.Minute)
+	n := len(bad)
+	simulation := BurnRateSimulation{
+		TotalEvents: totalSum[n] - totalSum[first],
+		BadEvents:   badSum[n] - badSum[first],
+		Policies:    make([]PolicySimulation, 0, len(policies)),
+	}
+	if simulation.TotalEvents > 0 {
+		simulation.ErrorRate = simulation.BadEvents / simulation.TotalEvents
+		rangeLength := time.Duration(n-first) * time.Minute
+		simulation.BudgetConsumed = simulation.ErrorRate / budget * rangeLength.Seconds() / slo.Period.Seconds()
+	}
+
+	for _, policy := range policies {
+		result := PolicySimulation{
+			Name:              policy.Name,
+			BurnRate:          policy.BurnRate,
+			LongWindow:        policy.LongWindow.String(),
+			ShortWindow:       policy.ShortWindow.String(),
+			BudgetAtDetection: policy.BurnRate * policy.LongWindow.Seconds() / slo.Period.Seconds(),
+			Firings:           []BurnRateFiring{},
+		}
+
+		var firing *BurnRateFiring
+		// Minute i is evaluated at from + i minutes, over the windows ending then
+		for i := first + 1; i <= n; i++ {
+			at := from.Add(time.Duration(i) * time.Minute)
+			long, short := burnRate(i, policy.LongWindow), burnRate(i, policy.ShortWindow)
+			if long >= policy.BurnRate && short >= policy.BurnRate {
+				result.FiringMinutes++
+				if firing == nil {
+					firing = &BurnRateFiring{Start: at}
+				}
+				firing.End = at
+				firing.PeakLongBurnRate = max(firing.PeakLongBurnRate, long)
+				firing.PeakShortBurnRate = max(firing.PeakShortBurnRate, short)
+				continue
+			}
+			if firing != nil {
+				firing.End = at
+				firing.Duration = firing.End.Sub(firing.Start).String()
+				result.Firings = append(result.Firings, *firing)
+				firing = nil
+			}
+		}
+		if firing != nil {
+			firing.Ongoing = true
+			firing.Duration = firing.End.Sub(firing.Start).String()
+			result.Firings = append(result.Firings, *firing)
+		}
+		simulation.Policies = append(simulation.Policies, result)
+	}
+	return simulation
+}
@@ -0,0 +1,113 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimulateBurnRate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	from := start.Add(-time.Hour)
+	slo := ServiceLevelObjective{Goal: 0.99, Period: 30 * 24 * time.Hour}
+	policies := []BurnRatePolicy{
+		{Name: "slow", BurnRate: 1.9, LongWindow: time.Hour, ShortWindow: 5 * time.Minute},
+		{Name: "fast", BurnRate: 10, LongWindow: 5 * time.Minute, ShortWindow: time.Minute},
+	}
+
+	// One hour of lookback followed by two hours of 100 requests per minute, with
+	// 20% errors for ten minutes starting 30 minutes in and for the last three minutes
+	bad := make([]float64, 180)
+	total := make([]float64, 180)
+	for i := range total {
+		total[i] = 100
+		if (i >= 90 && i < 100) || i >= 177 {
+			bad[i] = 20
+		}
+	}
+
+	got := simulateBurnRate(bad, total, from, start, slo, policies)
+
+	if got.TotalEvents != 12000 || got.BadEvents != 260 {
+		t.Errorf("Expected 12000 total and 260 bad events, got %v and %v", got.TotalEvents, got.BadEvents)
+	}
+	wantConsumed := 260.0 / 12000 / 0.01 * 2 / (30 * 24)
+	if math.Abs(got.BudgetConsumed-wantConsumed) > 1e-9 {
+		t.Errorf("Expected budget consumed %v, got %v", wantConsumed, got.BudgetConsumed)
+	}
+	if len(got.Policies) != 2 {
+		t.Fatalf("Expected 2 policies, got %d", len(got.Policies))
+	}
+
+	slow := got.Policies[0]
+	if slow.FiringMinutes != 9 || len(slow.Firings) != 1 {
+		t.Fatalf("Expected slow policy to fire once for 9 minutes, got %+v", slow)
+	}
+	firing := slow.Firings[0]
+	if !firing.Start.Equal(start.Add(36*time.Minute)) || !firing.End.Equal(start.Add(45*time.Minute)) || firing.Ongoing {
+		t.Errorf("Unexpected slow firing %+v", firing)
+	}
+	if math.Abs(firing.PeakLongBurnRate-10.0/3) > 1e-9 || math.Abs(firing.PeakShortBurnRate-20) > 1e-9 {
+		t.Errorf("Expected peak burn rates 3.33 and 20, got %v and %v", firing.PeakLongBurnRate, firing.PeakShortBurnRate)
+	}
+	if math.Abs(slow.BudgetAtDetection-1.9/(30*24)) > 1e-9 {
+		t.Errorf("Unexpected budget at detection %v", slow.BudgetAtDetection)
+	}
+
+	fast := got.Policies[1]
+	if fast.FiringMinutes != 9 || len(fast.Firings) != 2 {
+		t.Fatalf("Expected fast policy to fire twice for 9 minutes, got %+v", fast)
+	}
+	if f := fast.Firings[0]; !f.Start.Equal(start.Add(33*time.Minute)) || f.Duration != "8m0s" {
+		t.Errorf("Unexpected first fast firing %+v", f)
+	}
+	if f := fast.Firings[1]; !f.Ongoing || !f.Start.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Expected the last fast firing to be ongoing at the end, got %+v", f)
+	}
+}
+
+func TestSimulateBurnRate_NoTraffic(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	slo := ServiceLevelObjective{Goal: 0.999, Period: 28 * 24 * time.Hour}
+
+	got := simulateBurnRate(make([]float64, 120), make([]float64, 120), start.Add(-time.Hour), start, slo, DefaultBurnRatePolicies[:1])
+	if got.ErrorRate != 0 || got.BudgetConsumed != 0 {
+		t.Errorf("Expected no budget consumed without traffic, got %+v", got)
+	}
+	if got.Policies[0].FiringMinutes != 0 || len(got.Policies[0].Firings) != 0 {
+		t.Errorf("Expected no firings without traffic, got %+v", got.Policies[0])
+	}
+}
+
+func TestServiceLevelObjective_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		slo     ServiceLevelObjective
+		wantErr bool
+	}{
+		{"good and total", ServiceLevelObjective{Goal: 0.99, Period: time.Hour, GoodFilter: "a", TotalFilter: "b"}, false},
+		{"bad and total", ServiceLevelObjective{Goal: 0.99, Period: time.Hour, BadFilter: "a", TotalFilter: "b"}, false},
+		{"goal of one", ServiceLevelObjective{Goal: 1, Period: time.Hour, GoodFilter: "a", TotalFilter: "b"}, true},
+		{"no period", ServiceLevelObjective{Goal: 0.99, GoodFilter: "a", TotalFilter: "b"}, true},
+		{"one filter", ServiceLevelObjective{Goal: 0.99, Period: time.Hour, TotalFilter: "b"}, true},
+		{"three filters", ServiceLevelObjective{Goal: 0.99, Period: time.Hour, GoodFilter: "a", BadFilter: "c", TotalFilter: "b"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.slo.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceLevelObjective_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(ServiceLevelObjective{Goal: 0.99, Period: 28 * 24 * time.Hour, TotalFilter: "x"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := `{"goal":0.99,"total_filter":"x","period":"672h0m0s"}`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
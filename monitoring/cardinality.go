@@ -0,0 +1,174 @@
+package monitoring
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCardinalityWindow    = 24 * time.Hour
+	defaultCardinalityThreshold = 100
+	// maxCardinalitySeries bounds the series counted for a metric
+	maxCardinalitySeries = 10000
+	cardinalityPageSize  = 1000
+	// maxSampleValues is the number of example values reported per label
+	maxSampleValues = 5
+)
+
+// idLikeValue matches label values that identify a single request, user, or
+// process, e.g. UUIDs, hex hashes, long numbers, and timestamps
+var idLikeValue = regexp.MustCompile(`^([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,}|\d{6,}|\d{4}-\d{2}-\d{2}T.*)$`)
+
+// MetricCardinalityRequest represents a request to analyze the label
+// cardinality of a metric
+type MetricCardinalityRequest struct {
+	ProjectID  string    `json:"project_id,omitempty"` // defaults to the client's project
+	MetricType string    `json:"metric_type"`
+	Filter     string    `json:"filter,omitempty"` // added to the metric type filter, e.g. resource.type="gce_instance"
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	// Threshold is the number of distinct values above which a label is
+	// reported as high cardinality; defaults to 100
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// LabelCardinality represents the distinct values of a label of a metric
+type LabelCardinality struct {
+	Label    string   `json:"label"` // e.g. metric.labels.user_id or resource.labels.zone
+	Distinct int      `json:"distinct"`
+	Share    float64  `json:"share"`   // Distinct / the number of series; near 1 when every series has its own value
+	IDLike   bool     `json:"id_like"` // most values look like IDs, hashes, or timestamps
+	Samples  []string `json:"samples"`
+}
+
+// MetricCardinality represents the label cardinality of a metric
+type MetricCardinality struct {
+	MetricType string             `json:"metric_type"`
+	Series     int                `json:"series"`              // distinct label value combinations
+	Truncated  bool               `json:"truncated,omitempty"` // counting stopped at maxCardinalitySeries
+	Labels     []LabelCardinality `json:"labels"`              // most distinct values first
+	Warnings   []string           `json:"warnings"`
+}
+
+// AnalyzeMetricCardinality counts the time series of a metric in a window,
+// each a distinct combination of label values, and the distinct values of
+// each label. Metric labels with more distinct values than the threshold, or
+// whose values look like IDs, are reported as risking quota and cost blowups.
+func (c *CloudMonitoringClient) AnalyzeMetricCardinality(ctx context.Context, req MetricCardinalityRequest) (MetricCardinality, error) {
+	if req.MetricType == "" {
+		return MetricCardinality{}, fmt.Errorf("metric_type is required")
+	}
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+	if req.StartTime.IsZero() {
+		req.StartTime = req.EndTime.Add(-defaultCardinalityWindow)
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return MetricCardinality{}, fmt.Errorf("start_time must be before end_time")
+	}
+	if req.Threshold <= 0 {
+		req.Threshold = defaultCardinalityThreshold
+	}
+
+	filter := fmt.Sprintf("metric.type=%q", req.MetricType)
+	if req.Filter != "" {
+		filter += " AND " + req.Filter
+	}
+	listReq := ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		// One point per series is enough to tell them apart
+		Aggregation: &AggregationConfig{
+			AlignmentPeriod:  windowPeriod(req.StartTime, req.EndTime),
+			PerSeriesAligner: "ALIGN_COUNT",
+		},
+		PageSize: cardinalityPageSize,
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	result := MetricCardinality{MetricType: req.MetricType, Labels: []LabelCardinality{}, Warnings: []string{}}
+	values := make(map[string]map[string]bool)
+	for {
+		resp, err := c.client.ListTimeSeries(ctx, listReq)
+		if err != nil {
+			return MetricCardinality{}, err
+		}
+		for _, ts := range resp.TimeSeries {
+			result.Series++
+			addLabelValues(values, "metric.labels.", ts.MetricLabels)
+			addLabelValues(values, "resource.labels.", ts.ResourceLabels)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		if result.Series >= maxCardinalitySeries {
+			result.Truncated = true
+			break
+		}
+		listReq.PageToken = resp.NextPageToken
+	}
+
+	for label, set := range values {
+		samples := slices.Sorted(maps.Keys(set))
+		idLike := 0
+		for _, v := range samples {
+			if idLikeValue.MatchString(v) {
+				idLike++
+			}
+		}
+		result.Labels = append(result.Labels, LabelCardinality{
+			Label:    label,
+			Distinct: len(set),
+			Share:    float64(len(set)) / float64(result.Series),
+			IDLike:   idLike*2 > len(samples),
+			Samples:  samples[:min(maxSampleValues, len(samples))],
+		})
+	}
+	slices.SortFunc(result.Labels, func(a, b LabelCardinality) int {
+		return cmp.Or(cmp.Compare(b.Distinct, a.Distinct), strings.Compare(a.Label, b.Label))
+	})
+
+	result.Warnings = cardinalityWarnings(result, req.Threshold)
+	return result, nil
+}
+
+// addLabelValues records the values of labels under their keys with prefix
+func addLabelValues(values map[string]map[string]bool, prefix string, labels map[string]string) {
+	for key, value := range labels {
+		label := prefix + key
+		if values[label] == nil {
+			values[label] = make(map[string]bool)
+		}
+		values[label][value] = true
+	}
+}
+
+// cardinalityWarnings returns one line per risk found in the cardinality of
+// a metric
+func cardinalityWarnings(result MetricCardinality, threshold int) []string {
+	warnings := []string{}
+	if result.Truncated {
+		warnings = append(warnings, fmt.Sprintf("Counting stopped at %d series; the metric has at least that many, so narrow the filter or the window for exact counts", result.Series))
+	}
+	for _, label := range result.Labels {
+		// Resource labels are bounded by the resources writing the metric
+		if !strings.HasPrefix(label.Label, "metric.labels.") {
+			continue
+		}
+		switch {
+		case label.Distinct > threshold:
+			warnings = append(warnings, fmt.Sprintf("%s has %d distinct values, over the threshold of %d: every value multiplies the series ingested and billed", label.Label, label.Distinct, threshold))
+		case label.IDLike && label.Distinct > 1:
+			warnings = append(warnings, fmt.Sprintf("The values of %s look like IDs or timestamps (e.g. %s): its cardinality grows without bound; move it to logs or traces", label.Label, label.Samples[0]))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,113 @@
+package monitoring_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudMonitoringClient_AnalyzeMetricCardinality(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	// 150 series over two pages: one per user, split over two methods and two instances
+	page := func(from, to int) []monitoring.TimeSeriesData {
+		var series []monitoring.TimeSeriesData
+		for i := from; i < to; i++ {
+			series = append(series, monitoring.TimeSeriesData{
+				MetricLabels:   map[string]string{"method": []string{"GET", "POST"}[i%2], "user_id": fmt.Sprintf("%08d", 10000000+i)},
+				ResourceLabels: map[string]string{"instance_id": fmt.Sprintf("%d", 1234567890+i%2)},
+				Values:         []monitoring.MetricValue{{Value: 1, Timestamp: end}},
+			})
+		}
+		return series
+	}
+	gomock.InOrder(
+		mockClient.EXPECT().
+			ListTimeSeries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+				if req.Filter != `metric.type="custom.googleapis.com/requests" AND resource.type="gce_instance"` {
+					t.Errorf("Unexpected filter %s", req.Filter)
+				}
+				if req.Aggregation == nil || req.Aggregation.AlignmentPeriod != "86400s" || req.Aggregation.CrossSeriesReducer != "" {
+					t.Errorf("Expected one point per series over the window, got %+v", req.Aggregation)
+				}
+				return monitoring.ListTimeSeriesResponse{TimeSeries: page(0, 100), NextPageToken: "next"}, nil
+			}),
+		mockClient.EXPECT().
+			ListTimeSeries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+				if req.PageToken != "next" {
+					t.Errorf("Expected the next page, got %q", req.PageToken)
+				}
+				return monitoring.ListTimeSeriesResponse{TimeSeries: page(100, 150)}, nil
+			}),
+	)
+
+	result, err := client.AnalyzeMetricCardinality(context.Background(), monitoring.MetricCardinalityRequest{
+		MetricType: "custom.googleapis.com/requests",
+		Filter:     `resource.type="gce_instance"`,
+		EndTime:    end,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.Series != 150 || result.Truncated {
+		t.Errorf("Expected 150 series, got %d (truncated: %v)", result.Series, result.Truncated)
+	}
+	if len(result.Labels) != 3 {
+		t.Fatalf("Expected 3 labels, got %+v", result.Labels)
+	}
+	user := result.Labels[0]
+	if user.Label != "metric.labels.user_id" || user.Distinct != 150 || user.Share != 1 || !user.IDLike || len(user.Samples) != 5 {
+		t.Errorf("Unexpected user_id cardinality %+v", user)
+	}
+	// Ties are ordered by label
+	if result.Labels[1].Label != "metric.labels.method" || result.Labels[1].Distinct != 2 || result.Labels[1].IDLike {
+		t.Errorf("Unexpected method cardinality %+v", result.Labels[1])
+	}
+	if result.Labels[2].Label != "resource.labels.instance_id" || !result.Labels[2].IDLike {
+		t.Errorf("Unexpected instance_id cardinality %+v", result.Labels[2])
+	}
+
+	// Only the user_id label is over the threshold; resource labels are not warned about
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "metric.labels.user_id has 150 distinct values") {
+		t.Errorf("Unexpected warnings %v", result.Warnings)
+	}
+}
+
+func TestCloudMonitoringClient_AnalyzeMetricCardinalityIDLike(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+	mockClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		Return(monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{
+			{MetricLabels: map[string]string{"request_id": "3f2b8c1e-4d5a-4b6c-8d7e-9f0a1b2c3d4e"}},
+			{MetricLabels: map[string]string{"request_id": "7a1c9e2f-0b3d-4c5e-a6f7-b8c9d0e1f2a3"}},
+		}}, nil)
+
+	result, err := client.AnalyzeMetricCardinality(context.Background(), monitoring.MetricCardinalityRequest{
+		MetricType: "custom.googleapis.com/latency",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Below the threshold, but the values will keep growing
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "look like IDs") {
+		t.Errorf("Expected an ID-like warning, got %v", result.Warnings)
+	}
+}
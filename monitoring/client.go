@@ -6,21 +6,36 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"net/http"
+	"slices"
 	"time"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
 	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	dashboard "cloud.google.com/go/monitoring/dashboard/apiv1"
+	"github.com/kitagry/gcp-telemetry-mcp/credentials"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+	"google.golang.org/genproto/googleapis/api/label"
 	"google.golang.org/genproto/googleapis/api/metric"
 	"google.golang.org/genproto/googleapis/api/monitoredres"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// monitoringReadScope is the OAuth scope for read-only Cloud Monitoring APIs
+const monitoringReadScope = "https://www.googleapis.com/auth/monitoring.read"
+
 // MetricValue represents a metric value with timestamp
 type MetricValue struct {
 	Value     float64   `json:"value"`
 	Timestamp time.Time `json:"timestamp"`
+	// StartTime starts the interval of the points of CUMULATIVE and DELTA
+	// metrics, which ends at Timestamp
+	StartTime time.Time `json:"start_time,omitzero"`
+	// Distribution is written instead of Value for DISTRIBUTION metrics
+	Distribution *Distribution `json:"distribution,omitempty"`
 }
 
 // MetricDescriptor represents metadata about a metric
@@ -30,31 +45,48 @@ type MetricDescriptor struct {
 	ValueType   string            `json:"value_type"`
 	Description string            `json:"description"`
 	DisplayName string            `json:"display_name"`
-	Labels      map[string]string `json:"labels,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"` // label keys and their descriptions
+	ConsoleURL  string            `json:"console_url,omitempty"`
 }
 
 // TimeSeriesData represents time series data for a metric
 type TimeSeriesData struct {
-	MetricType   string            `json:"metric_type"`
-	MetricLabels map[string]string `json:"metric_labels,omitempty"`
-	ResourceType string            `json:"resource_type"`
-	Values       []MetricValue     `json:"values"`
+	MetricType     string            `json:"metric_type"`
+	MetricKind     string            `json:"metric_kind,omitempty"` // GAUGE, DELTA or CUMULATIVE
+	MetricLabels   map[string]string `json:"metric_labels,omitempty"`
+	ResourceType   string            `json:"resource_type"`
+	ResourceLabels map[string]string `json:"resource_labels,omitempty"`
+	Metadata       *ResourceMetadata `json:"metadata,omitempty"` // of the resource, when the API returns it
+	Values         []MetricValue     `json:"values"`
+}
+
+// ResourceMetadata represents the metadata Cloud Monitoring keeps about a
+// monitored resource, e.g. the name and machine type of a VM
+type ResourceMetadata struct {
+	// SystemLabels are set by Google Cloud; values are strings, numbers,
+	// booleans, or lists, e.g. of network tags
+	SystemLabels map[string]any `json:"system_labels,omitempty"`
+	// UserLabels are the labels users set on the resource
+	UserLabels map[string]string `json:"user_labels,omitempty"`
 }
 
 // CreateMetricRequest represents a request to create a custom metric
 type CreateMetricRequest struct {
+	ProjectID        string           `json:"project_id,omitempty"` // defaults to the client's project
 	MetricDescriptor MetricDescriptor `json:"metric_descriptor"`
 }
 
 // WriteTimeSeriesRequest represents a request to write time series data
 type WriteTimeSeriesRequest struct {
+	ProjectID  string           `json:"project_id,omitempty"` // defaults to the client's project
 	TimeSeries []TimeSeriesData `json:"time_series"`
 }
 
 // ListTimeSeriesRequest represents a request to list time series data
 type ListTimeSeriesRequest struct {
-	Filter   string `json:"filter"`
-	Interval struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	Filter    string `json:"filter"`
+	Interval  struct {
 		StartTime time.Time `json:"start_time"`
 		EndTime   time.Time `json:"end_time"`
 	} `json:"interval"`
@@ -73,6 +105,7 @@ type AggregationConfig struct {
 
 // ListAvailableMetricsRequest represents a request to list available metrics
 type ListAvailableMetricsRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
 	Filter    string `json:"filter,omitempty"`
 	PageSize  int    `json:"page_size,omitempty"`
 	PageToken string `json:"page_token,omitempty"`
@@ -88,6 +121,7 @@ type AvailableMetric struct {
 	Unit        string        `json:"unit,omitempty"`
 	Labels      []MetricLabel `json:"labels,omitempty"`
 	LaunchStage string        `json:"launch_stage,omitempty"`
+	ConsoleURL  string        `json:"console_url,omitempty"`
 }
 
 // MetricLabel represents a label for a metric
@@ -110,13 +144,32 @@ type MonitoringClient interface {
 	ListTimeSeries(ctx context.Context, req ListTimeSeriesRequest) (ListTimeSeriesResponse, error)
 	ListMetricDescriptors(ctx context.Context, req ListMetricDescriptorsRequest) (ListMetricDescriptorsResponse, error)
 	DeleteMetricDescriptor(ctx context.Context, metricType string) error
+	DeleteMetricDescriptors(ctx context.Context, req DeleteMetricDescriptorsRequest) (DeleteMetricDescriptorsResponse, error)
+	CloneMetricDescriptor(ctx context.Context, req CloneMetricDescriptorRequest) (CloneMetricDescriptorResult, error)
+	CompareMetricDescriptors(ctx context.Context, req CompareMetricDescriptorsRequest) (CompareMetricDescriptorsResponse, error)
 	ListAvailableMetrics(ctx context.Context, req ListAvailableMetricsRequest) ([]AvailableMetric, error)
+	ListAlerts(ctx context.Context, req ListAlertsRequest) (ListAlertsResponse, error)
+	SearchMetrics(ctx context.Context, req SearchMetricsRequest) ([]MetricSearchResult, error)
+	GetQuotaUsage(ctx context.Context, req QuotaUsageRequest) (QuotaUsageResponse, error)
+	ListMonitoredResources(ctx context.Context, req ListMonitoredResourcesRequest) (ListMonitoredResourcesResponse, error)
+	DescribeResource(ctx context.Context, req DescribeResourceRequest) (DescribeResourceResponse, error)
+	AnalyzeMetricCardinality(ctx context.Context, req MetricCardinalityRequest) (MetricCardinality, error)
+	EstimateQueryCost(ctx context.Context, req QueryCostRequest) (QueryCostEstimate, error)
+	FindUnusedMetrics(ctx context.Context, req FindUnusedMetricsRequest) (FindUnusedMetricsResponse, error)
+	GetServiceLevelObjective(ctx context.Context, name string) (ServiceLevelObjective, error)
+	SimulateBurnRate(ctx context.Context, req BurnRateRequest) (BurnRateSimulation, error)
+	ListAlertPolicies(ctx context.Context, req ListAlertPoliciesRequest) ([]AlertPolicy, error)
+	LintAlertPolicies(ctx context.Context, req LintAlertPoliciesRequest) (LintAlertPoliciesResponse, error)
+	ListDashboards(ctx context.Context, req ListDashboardsRequest) ([]Dashboard, error)
+	ApplyAlertPolicy(ctx context.Context, req ApplyRequest) (ApplyResult, error)
+	ApplyDashboard(ctx context.Context, req ApplyRequest) (ApplyResult, error)
 }
 
 // CloudMonitoringClient implements MonitoringClient using Google Cloud Monitoring
 type CloudMonitoringClient struct {
 	client    MonitoringClientInterface
 	projectID string
+	catalogs  *metricCatalogCache
 }
 
 // MonitoringClientInterface abstracts the Google Cloud Monitoring client for testing
@@ -127,27 +180,72 @@ type MonitoringClientInterface interface {
 	ListMetricDescriptors(ctx context.Context, req ListMetricDescriptorsRequest) (ListMetricDescriptorsResponse, error)
 	DeleteMetricDescriptor(ctx context.Context, metricType string) error
 	ListAvailableMetrics(ctx context.Context, req ListAvailableMetricsRequest) ([]AvailableMetric, error)
+	ListAlerts(ctx context.Context, req ListAlertsRequest) (ListAlertsResponse, error)
+	GetServiceLevelObjective(ctx context.Context, name string) (ServiceLevelObjective, error)
+	ListAlertPolicies(ctx context.Context, req ListAlertPoliciesRequest) ([]AlertPolicy, error)
+	ListDashboards(ctx context.Context, req ListDashboardsRequest) ([]Dashboard, error)
+	ApplyAlertPolicy(ctx context.Context, req ApplyRequest) (ApplyResult, error)
+	ApplyDashboard(ctx context.Context, req ApplyRequest) (ApplyResult, error)
 }
 
 // New creates a new CloudMonitoringClient
-func New(projectID string) (*CloudMonitoringClient, error) {
-	metricClient, err := monitoring.NewMetricClient(context.Background())
+func New(projectID string, opts ...option.ClientOption) (*CloudMonitoringClient, error) {
+	client, err := NewAPIClient(projectID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithClient(client, projectID), nil
+}
+
+// NewAPIClient creates the MonitoringClientInterface calling the Cloud
+// Monitoring API, e.g. to be wrapped before it is passed to NewWithClient, or
+// the emulator set in MONITORING_EMULATOR_HOST
+func NewAPIClient(projectID string, opts ...option.ClientOption) (MonitoringClientInterface, error) {
+	alertsURL := alertsEndpoint
+	if emulatorOpts, ok := credentials.EmulatorOptions(credentials.ServiceMonitoring); ok {
+		opts = emulatorOpts
+		alertsURL = "http://" + credentials.EmulatorHost(credentials.ServiceMonitoring) + "/v3"
+	}
+
+	metricClient, err := monitoring.NewMetricClient(context.Background(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create metric client: %w", err)
 	}
 
-	queryClient, err := monitoring.NewQueryClient(context.Background())
+	queryClient, err := monitoring.NewQueryClient(context.Background(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create query client: %w", err)
 	}
 
-	return &CloudMonitoringClient{
-		client: &realMonitoringClient{
-			metricClient: metricClient,
-			queryClient:  queryClient,
-			projectID:    projectID,
-		},
-		projectID: projectID,
+	serviceClient, err := monitoring.NewServiceMonitoringClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service monitoring client: %w", err)
+	}
+
+	alertPolicyClient, err := monitoring.NewAlertPolicyClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert policy client: %w", err)
+	}
+
+	dashboardClient, err := dashboard.NewDashboardsClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dashboards client: %w", err)
+	}
+
+	httpClient, _, err := htransport.NewClient(context.Background(), append([]option.ClientOption{option.WithScopes(monitoringReadScope)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+
+	return &realMonitoringClient{
+		metricClient:      metricClient,
+		queryClient:       queryClient,
+		serviceClient:     serviceClient,
+		alertPolicyClient: alertPolicyClient,
+		dashboardClient:   dashboardClient,
+		httpClient:        httpClient,
+		alertsEndpoint:    alertsURL,
+		projectID:         projectID,
 	}, nil
 }
 
@@ -156,6 +254,7 @@ func NewWithClient(client MonitoringClientInterface, projectID string) *CloudMon
 	return &CloudMonitoringClient{
 		client:    client,
 		projectID: projectID,
+		catalogs:  newMetricCatalogCache(),
 	}
 }
 
@@ -164,9 +263,11 @@ func (c *CloudMonitoringClient) CreateMetricDescriptor(ctx context.Context, req
 	return c.client.CreateMetricDescriptor(ctx, req)
 }
 
-// WriteTimeSeries writes time series data to Cloud Monitoring
+// WriteTimeSeries writes time series data to Cloud Monitoring. Writes of
+// more series than the API accepts at once are split into several calls; a
+// *PartialWriteError reports the calls that failed.
 func (c *CloudMonitoringClient) WriteTimeSeries(ctx context.Context, req WriteTimeSeriesRequest) error {
-	return c.client.WriteTimeSeries(ctx, req)
+	return c.writeChunked(ctx, req)
 }
 
 // ListTimeSeries retrieves time series data from Cloud Monitoring
@@ -191,13 +292,35 @@ func (c *CloudMonitoringClient) ListAvailableMetrics(ctx context.Context, req Li
 
 // realMonitoringClient wraps the actual Google Cloud Monitoring clients
 type realMonitoringClient struct {
-	metricClient *monitoring.MetricClient
-	queryClient  *monitoring.QueryClient
-	projectID    string
+	metricClient      *monitoring.MetricClient
+	queryClient       *monitoring.QueryClient
+	serviceClient     *monitoring.ServiceMonitoringClient
+	alertPolicyClient *monitoring.AlertPolicyClient
+	dashboardClient   *dashboard.DashboardsClient
+	httpClient        *http.Client // for APIs without a generated client
+	alertsEndpoint    string       // base URL of the Alerts API
+	projectID         string
+}
+
+// project returns projectID, defaulting to the client's project
+func (r *realMonitoringClient) project(projectID string) string {
+	if projectID == "" {
+		return r.projectID
+	}
+	return projectID
+}
+
+// projectName returns the resource name of projectID, defaulting to the client's project
+func (r *realMonitoringClient) projectName(projectID string) string {
+	return fmt.Sprintf("projects/%s", r.project(projectID))
 }
 
 // CreateMetricDescriptor implements MonitoringClientInterface for the real client
 func (r *realMonitoringClient) CreateMetricDescriptor(ctx context.Context, req CreateMetricRequest) error {
+	if err := req.MetricDescriptor.Validate(); err != nil {
+		return err
+	}
+
 	// Convert our MetricDescriptor to the protobuf version
 	metricKind := metric.MetricDescriptor_GAUGE
 	switch req.MetricDescriptor.MetricKind {
@@ -224,7 +347,7 @@ func (r *realMonitoringClient) CreateMetricDescriptor(ctx context.Context, req C
 	}
 
 	pbReq := &monitoringpb.CreateMetricDescriptorRequest{
-		Name: fmt.Sprintf("projects/%s", r.projectID),
+		Name: r.projectName(req.ProjectID),
 		MetricDescriptor: &metric.MetricDescriptor{
 			Type:        req.MetricDescriptor.Type,
 			MetricKind:  metricKind,
@@ -233,6 +356,13 @@ func (r *realMonitoringClient) CreateMetricDescriptor(ctx context.Context, req C
 			DisplayName: req.MetricDescriptor.DisplayName,
 		},
 	}
+	for _, key := range slices.Sorted(maps.Keys(req.MetricDescriptor.Labels)) {
+		pbReq.MetricDescriptor.Labels = append(pbReq.MetricDescriptor.Labels, &label.LabelDescriptor{
+			Key:         key,
+			ValueType:   label.LabelDescriptor_STRING,
+			Description: req.MetricDescriptor.Labels[key],
+		})
+	}
 
 	_, err := r.metricClient.CreateMetricDescriptor(ctx, pbReq)
 	return err
@@ -243,17 +373,33 @@ func (r *realMonitoringClient) WriteTimeSeries(ctx context.Context, req WriteTim
 	var timeSeries []*monitoringpb.TimeSeries
 
 	for _, ts := range req.TimeSeries {
+		if err := ts.Validate(); err != nil {
+			return err
+		}
 		var points []*monitoringpb.Point
 		for _, value := range ts.Values {
-			points = append(points, &monitoringpb.Point{
-				Interval: &monitoringpb.TimeInterval{
-					EndTime: timestamppb.New(value.Timestamp),
-				},
-				Value: &monitoringpb.TypedValue{
-					Value: &monitoringpb.TypedValue_DoubleValue{
-						DoubleValue: value.Value,
-					},
+			typedValue := &monitoringpb.TypedValue{
+				Value: &monitoringpb.TypedValue_DoubleValue{
+					DoubleValue: value.Value,
 				},
+			}
+			if value.Distribution != nil {
+				if err := value.Distribution.Validate(); err != nil {
+					return fmt.Errorf("invalid distribution: %w", err)
+				}
+				typedValue.Value = &monitoringpb.TypedValue_DistributionValue{
+					DistributionValue: value.Distribution.toProto(),
+				}
+			}
+			interval := &monitoringpb.TimeInterval{
+				EndTime: timestamppb.New(value.Timestamp),
+			}
+			if !value.StartTime.IsZero() {
+				interval.StartTime = timestamppb.New(value.StartTime)
+			}
+			points = append(points, &monitoringpb.Point{
+				Interval: interval,
+				Value:    typedValue,
 			})
 		}
 
@@ -261,20 +407,27 @@ func (r *realMonitoringClient) WriteTimeSeries(ctx context.Context, req WriteTim
 		metricLabels := make(map[string]string)
 		maps.Copy(metricLabels, ts.MetricLabels)
 
+		// The kind of the descriptor created for a new custom metric
+		metricKind := metric.MetricDescriptor_METRIC_KIND_UNSPECIFIED
+		if ts.MetricKind != "" {
+			metricKind = metric.MetricDescriptor_MetricKind(metric.MetricDescriptor_MetricKind_value[ts.MetricKind])
+		}
 		timeSeries = append(timeSeries, &monitoringpb.TimeSeries{
 			Metric: &metric.Metric{
 				Type:   ts.MetricType,
 				Labels: metricLabels,
 			},
 			Resource: &monitoredres.MonitoredResource{
-				Type: ts.ResourceType,
+				Type:   ts.ResourceType,
+				Labels: ts.ResourceLabels,
 			},
-			Points: points,
+			MetricKind: metricKind,
+			Points:     points,
 		})
 	}
 
 	pbReq := &monitoringpb.CreateTimeSeriesRequest{
-		Name:       fmt.Sprintf("projects/%s", r.projectID),
+		Name:       r.projectName(req.ProjectID),
 		TimeSeries: timeSeries,
 	}
 
@@ -289,7 +442,7 @@ func (r *realMonitoringClient) ListTimeSeries(ctx context.Context, req ListTimeS
 	}
 
 	pbReq := &monitoringpb.ListTimeSeriesRequest{
-		Name:   fmt.Sprintf("projects/%s", r.projectID),
+		Name:   r.projectName(req.ProjectID),
 		Filter: req.Filter,
 		Interval: &monitoringpb.TimeInterval{
 			StartTime: timestamppb.New(req.Interval.StartTime),
@@ -304,36 +457,22 @@ func (r *realMonitoringClient) ListTimeSeries(ctx context.Context, req ListTimeS
 
 	// Add aggregation if specified
 	if req.Aggregation != nil {
+		if err := req.Aggregation.Validate(); err != nil {
+			return ListTimeSeriesResponse{}, err
+		}
 		pbReq.Aggregation = &monitoringpb.Aggregation{
 			AlignmentPeriod: parseDuration(req.Aggregation.AlignmentPeriod),
 		}
 
-		// Set per-series aligner
-		switch req.Aggregation.PerSeriesAligner {
-		case "ALIGN_MEAN":
-			pbReq.Aggregation.PerSeriesAligner = monitoringpb.Aggregation_ALIGN_MEAN
-		case "ALIGN_MAX":
-			pbReq.Aggregation.PerSeriesAligner = monitoringpb.Aggregation_ALIGN_MAX
-		case "ALIGN_MIN":
-			pbReq.Aggregation.PerSeriesAligner = monitoringpb.Aggregation_ALIGN_MIN
-		case "ALIGN_SUM":
-			pbReq.Aggregation.PerSeriesAligner = monitoringpb.Aggregation_ALIGN_SUM
-		default:
-			pbReq.Aggregation.PerSeriesAligner = monitoringpb.Aggregation_ALIGN_MEAN
+		// Set per-series aligner, defaulting to ALIGN_MEAN when not given
+		pbReq.Aggregation.PerSeriesAligner = monitoringpb.Aggregation_ALIGN_MEAN
+		if aligner, ok := monitoringpb.Aggregation_Aligner_value[req.Aggregation.PerSeriesAligner]; ok {
+			pbReq.Aggregation.PerSeriesAligner = monitoringpb.Aggregation_Aligner(aligner)
 		}
 
 		// Set cross-series reducer if specified
 		if req.Aggregation.CrossSeriesReducer != "" {
-			switch req.Aggregation.CrossSeriesReducer {
-			case "REDUCE_MEAN":
-				pbReq.Aggregation.CrossSeriesReducer = monitoringpb.Aggregation_REDUCE_MEAN
-			case "REDUCE_MAX":
-				pbReq.Aggregation.CrossSeriesReducer = monitoringpb.Aggregation_REDUCE_MAX
-			case "REDUCE_MIN":
-				pbReq.Aggregation.CrossSeriesReducer = monitoringpb.Aggregation_REDUCE_MIN
-			case "REDUCE_SUM":
-				pbReq.Aggregation.CrossSeriesReducer = monitoringpb.Aggregation_REDUCE_SUM
-			}
+			pbReq.Aggregation.CrossSeriesReducer = monitoringpb.Aggregation_Reducer(monitoringpb.Aggregation_Reducer_value[req.Aggregation.CrossSeriesReducer])
 
 			pbReq.Aggregation.GroupByFields = req.Aggregation.GroupByFields
 		}
@@ -366,19 +505,30 @@ func (r *realMonitoringClient) ListTimeSeries(ctx context.Context, req ListTimeS
 				} else {
 					value = 0.0
 				}
+			case *monitoringpb.TypedValue_DistributionValue:
+				// Use a percentile aligner to get other statistics of a distribution
+				value = v.DistributionValue.GetMean()
 			}
 
-			values = append(values, MetricValue{
+			metricValue := MetricValue{
 				Value:     value,
 				Timestamp: point.Interval.EndTime.AsTime(),
-			})
+			}
+			// Points of CUMULATIVE and DELTA metrics cover an interval
+			if start := point.Interval.GetStartTime(); start != nil && start.AsTime().Before(metricValue.Timestamp) {
+				metricValue.StartTime = start.AsTime()
+			}
+			values = append(values, metricValue)
 		}
 
 		result = append(result, TimeSeriesData{
-			MetricType:   ts.Metric.Type,
-			MetricLabels: ts.Metric.Labels,
-			ResourceType: ts.Resource.Type,
-			Values:       values,
+			MetricType:     ts.Metric.Type,
+			MetricKind:     ts.MetricKind.String(),
+			MetricLabels:   ts.Metric.Labels,
+			ResourceType:   ts.Resource.Type,
+			ResourceLabels: ts.Resource.Labels,
+			Metadata:       resourceMetadata(ts.GetMetadata()),
+			Values:         values,
 		})
 	}
 
@@ -391,8 +541,21 @@ func (r *realMonitoringClient) ListTimeSeries(ctx context.Context, req ListTimeS
 	}, nil
 }
 
+// resourceMetadata converts the metadata of a time series, nil when it has none
+func resourceMetadata(md *monitoredres.MonitoredResourceMetadata) *ResourceMetadata {
+	if len(md.GetSystemLabels().GetFields()) == 0 && len(md.GetUserLabels()) == 0 {
+		return nil
+	}
+	metadata := &ResourceMetadata{UserLabels: md.GetUserLabels()}
+	if md.GetSystemLabels() != nil {
+		metadata.SystemLabels = md.GetSystemLabels().AsMap()
+	}
+	return metadata
+}
+
 // ListMetricDescriptorsRequest represents a request to list metric descriptors
 type ListMetricDescriptorsRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
 	Filter    string `json:"filter,omitempty"`
 	PageSize  int    `json:"page_size,omitempty"`
 	PageToken string `json:"page_token,omitempty"`
@@ -412,7 +575,7 @@ func (r *realMonitoringClient) ListMetricDescriptors(ctx context.Context, req Li
 	}
 
 	pbReq := &monitoringpb.ListMetricDescriptorsRequest{
-		Name:     fmt.Sprintf("projects/%s", r.projectID),
+		Name:     r.projectName(req.ProjectID),
 		Filter:   req.Filter,
 		PageSize: int32(pageSize),
 	}
@@ -458,12 +621,22 @@ func (r *realMonitoringClient) ListMetricDescriptors(ctx context.Context, req Li
 			valueType = "DISTRIBUTION"
 		}
 
+		var labels map[string]string
+		for _, labelDesc := range md.Labels {
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[labelDesc.Key] = labelDesc.Description
+		}
+
 		result = append(result, MetricDescriptor{
 			Type:        md.Type,
 			MetricKind:  metricKind,
 			ValueType:   valueType,
 			Description: md.Description,
 			DisplayName: md.DisplayName,
+			Labels:      labels,
+			ConsoleURL:  metricConsoleURL(r.project(req.ProjectID), md.Type),
 		})
 	}
 
@@ -493,7 +666,7 @@ func (r *realMonitoringClient) ListAvailableMetrics(ctx context.Context, req Lis
 	}
 
 	pbReq := &monitoringpb.ListMetricDescriptorsRequest{
-		Name:     fmt.Sprintf("projects/%s", r.projectID),
+		Name:     r.projectName(req.ProjectID),
 		Filter:   req.Filter,
 		PageSize: int32(pageSize),
 	}
@@ -547,7 +720,7 @@ func (r *realMonitoringClient) ListAvailableMetrics(ctx context.Context, req Lis
 		for _, labelDesc := range md.Labels {
 			labels = append(labels, MetricLabel{
 				Key:         labelDesc.Key,
-				ValueType:   "STRING", // Default to STRING for now
+				ValueType:   labelDesc.ValueType.String(),
 				Description: labelDesc.Description,
 			})
 		}
@@ -566,6 +739,7 @@ func (r *realMonitoringClient) ListAvailableMetrics(ctx context.Context, req Lis
 			Unit:        md.Unit,
 			Labels:      labels,
 			LaunchStage: launchStage,
+			ConsoleURL:  metricConsoleURL(r.project(req.ProjectID), md.Type),
 		})
 	}
 
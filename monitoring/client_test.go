@@ -2,6 +2,7 @@ package monitoring_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -296,4 +297,334 @@ func TestCloudMonitoringClient_ListAvailableMetrics(t *testing.T) {
 	if result[0].MetricKind != "GAUGE" {
 		t.Errorf("Expected metric kind GAUGE, got %s", result[0].MetricKind)
 	}
-}
\ No newline at end of file
+}
+func TestCloudMonitoringClient_SearchMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	catalog := []monitoring.AvailableMetric{
+		{
+			Type:        "compute.googleapis.com/instance/cpu/utilization",
+			DisplayName: "CPU utilization",
+			Description: "Fractional utilization of allocated CPU on this instance.",
+		},
+		{
+			Type:        "pubsub.googleapis.com/subscription/num_undelivered_messages",
+			DisplayName: "Unacked messages",
+			Description: "Number of unacknowledged messages (a.k.a. backlog messages) in a subscription.",
+		},
+		{
+			Type:        "pubsub.googleapis.com/topic/send_request_count",
+			DisplayName: "Publish requests",
+			Description: "Cumulative count of publish requests.",
+		},
+	}
+
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	// The descriptor list is fetched once and reused by later searches
+	mockClient.EXPECT().
+		ListAvailableMetrics(gomock.Any(), gomock.Any()).
+		Return(catalog, nil).
+		Times(1)
+
+	tests := []struct {
+		name     string
+		query    string
+		wantType string
+	}{
+		{
+			name:     "matches description and type",
+			query:    "Pub/Sub backlog",
+			wantType: "pubsub.googleapis.com/subscription/num_undelivered_messages",
+		},
+		{
+			name:     "tolerates typos",
+			query:    "cpu utilisation",
+			wantType: "compute.googleapis.com/instance/cpu/utilization",
+		},
+		{
+			name:     "matches display name",
+			query:    "publish requests",
+			wantType: "pubsub.googleapis.com/topic/send_request_count",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := client.SearchMetrics(context.Background(), monitoring.SearchMetricsRequest{Query: tt.query})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(results) == 0 {
+				t.Fatalf("Expected at least one result for %q", tt.query)
+			}
+
+			if results[0].Type != tt.wantType {
+				t.Errorf("Expected top result %s, got %s", tt.wantType, results[0].Type)
+			}
+		})
+	}
+
+	results, err := client.SearchMetrics(context.Background(), monitoring.SearchMetricsRequest{Query: "spanner"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no results, got %d", len(results))
+	}
+}
+
+func TestCloudMonitoringClient_GetQuotaUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	minute := func(m int) time.Time { return end.Add(time.Duration(m-60) * time.Minute) }
+	compute := map[string]string{"service": "compute.googleapis.com", "location": "us-central1"}
+	bigquery := map[string]string{"service": "bigquery.googleapis.com", "location": "global"}
+
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	mockClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			var series []monitoring.TimeSeriesData
+			switch {
+			case strings.Contains(req.Filter, "quota/allocation/usage"):
+				series = []monitoring.TimeSeriesData{{
+					MetricLabels:   map[string]string{"quota_metric": "compute.googleapis.com/cpus"},
+					ResourceLabels: compute,
+					Values:         []monitoring.MetricValue{{Value: 20, Timestamp: minute(50)}, {Value: 22, Timestamp: minute(10)}},
+				}}
+			case strings.Contains(req.Filter, "quota/rate/net_usage"):
+				// Usage is split by method and summed per minute
+				series = []monitoring.TimeSeriesData{
+					{
+						MetricLabels:   map[string]string{"quota_metric": "bigquery.googleapis.com/quota/query/usage", "method": "jobs.query"},
+						ResourceLabels: bigquery,
+						Values:         []monitoring.MetricValue{{Value: 60, Timestamp: minute(30)}, {Value: 10, Timestamp: minute(31)}},
+					},
+					{
+						MetricLabels:   map[string]string{"quota_metric": "bigquery.googleapis.com/quota/query/usage", "method": "jobs.insert"},
+						ResourceLabels: bigquery,
+						Values:         []monitoring.MetricValue{{Value: 40, Timestamp: minute(30)}},
+					},
+				}
+			case strings.Contains(req.Filter, "quota/limit"):
+				if req.Aggregation.AlignmentPeriod != "3600s" {
+					t.Errorf("Expected limits to be aligned over the window, got %s", req.Aggregation.AlignmentPeriod)
+				}
+				series = []monitoring.TimeSeriesData{
+					{
+						MetricLabels:   map[string]string{"quota_metric": "compute.googleapis.com/cpus", "limit_name": "CPUS-per-project-region"},
+						ResourceLabels: compute,
+						Values:         []monitoring.MetricValue{{Value: 24, Timestamp: end}},
+					},
+					{
+						MetricLabels:   map[string]string{"quota_metric": "bigquery.googleapis.com/quota/query/usage", "limit_name": "QueryUsagePerMinutePerProject"},
+						ResourceLabels: bigquery,
+						Values:         []monitoring.MetricValue{{Value: 100, Timestamp: end}},
+					},
+					{
+						MetricLabels:   map[string]string{"quota_metric": "bigquery.googleapis.com/quota/query/usage", "limit_name": "QueryUsagePerDayPerProject"},
+						ResourceLabels: bigquery,
+						Values:         []monitoring.MetricValue{{Value: -1, Timestamp: end}},
+					},
+				}
+			case strings.Contains(req.Filter, "quota/exceeded"):
+				series = []monitoring.TimeSeriesData{{
+					MetricLabels:   map[string]string{"quota_metric": "bigquery.googleapis.com/quota/query/usage", "limit_name": "QueryUsagePerMinutePerProject"},
+					ResourceLabels: bigquery,
+					Values:         []monitoring.MetricValue{{Value: 3, Timestamp: minute(30)}, {Value: 0, Timestamp: minute(31)}},
+				}}
+			default:
+				t.Errorf("Unexpected filter %s", req.Filter)
+			}
+			if !strings.Contains(req.Filter, `resource.type="consumer_quota"`) {
+				t.Errorf("Expected consumer_quota filter, got %s", req.Filter)
+			}
+			return monitoring.ListTimeSeriesResponse{TimeSeries: series}, nil
+		}).
+		Times(4)
+
+	resp, err := client.GetQuotaUsage(context.Background(), monitoring.QuotaUsageRequest{
+		StartTime: end.Add(-time.Hour),
+		EndTime:   end,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(resp.Quotas) != 3 {
+		t.Fatalf("Expected 3 quotas, got %+v", resp.Quotas)
+	}
+
+	// The throttled limit comes first, then by utilization
+	rate := resp.Quotas[0]
+	if rate.LimitName != "QueryUsagePerMinutePerProject" || rate.Type != monitoring.QuotaTypeRate || rate.Period != "1m0s" {
+		t.Errorf("Unexpected rate quota %+v", rate)
+	}
+	if rate.PeakUsage != 100 || rate.Utilization != 1 || rate.Exceeded != 1 || !rate.PeakTime.Equal(minute(30)) {
+		t.Errorf("Expected peak 100 of 100 exceeded once, got %+v", rate)
+	}
+
+	allocation := resp.Quotas[1]
+	if allocation.QuotaMetric != "compute.googleapis.com/cpus" || allocation.Type != monitoring.QuotaTypeAllocation {
+		t.Errorf("Unexpected allocation quota %+v", allocation)
+	}
+	if allocation.PeakUsage != 22 || allocation.Limit != 24 {
+		t.Errorf("Expected peak 22 of 24, got %+v", allocation)
+	}
+
+	unlimited := resp.Quotas[2]
+	if unlimited.Period != "24h0m0s" || unlimited.PeakUsage != 110 || unlimited.Utilization != 0 {
+		t.Errorf("Expected unlimited daily quota with daily usage 110, got %+v", unlimited)
+	}
+
+	if len(resp.Throttled) != 1 || !strings.Contains(resp.Throttled[0], "QueryUsagePerMinutePerProject") {
+		t.Errorf("Unexpected throttled summary %v", resp.Throttled)
+	}
+}
+
+func TestCloudMonitoringClient_SimulateBurnRate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(10 * time.Minute)
+	sloName := "projects/test-project/services/checkout/serviceLevelObjectives/availability"
+
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	mockClient.EXPECT().
+		GetServiceLevelObjective(gomock.Any(), sloName).
+		Return(monitoring.ServiceLevelObjective{
+			Name:        sloName,
+			Goal:        0.9,
+			Period:      24 * time.Hour,
+			GoodFilter:  `metric.type="custom.googleapis.com/good"`,
+			TotalFilter: `metric.type="custom.googleapis.com/total"`,
+		}, nil).
+		Times(1)
+	mockClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			if req.Aggregation.AlignmentPeriod != "60s" || req.Aggregation.CrossSeriesReducer != "REDUCE_SUM" {
+				t.Errorf("Expected per-minute sums, got %+v", req.Aggregation)
+			}
+			if !req.Interval.StartTime.Equal(start.Add(-5*time.Minute)) || !req.Interval.EndTime.Equal(end) {
+				t.Errorf("Expected the range to include the longest window, got %v to %v", req.Interval.StartTime, req.Interval.EndTime)
+			}
+			// 10 events per minute, of which all are good except during minutes 3 to 6
+			var values []monitoring.MetricValue
+			for m := 15; m >= 1; m-- {
+				v := 10.0
+				if strings.Contains(req.Filter, "good") && m >= 8 && m <= 11 {
+					v = 0
+				}
+				values = append(values, monitoring.MetricValue{Value: v, Timestamp: start.Add(time.Duration(m-5) * time.Minute)})
+			}
+			return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{{Values: values}}}, nil
+		}).
+		Times(2)
+
+	got, err := client.SimulateBurnRate(context.Background(), monitoring.BurnRateRequest{
+		SLOName:   sloName,
+		StartTime: start,
+		EndTime:   end,
+		Policies:  []monitoring.BurnRatePolicy{{Name: "page", BurnRate: 5, LongWindow: 5 * time.Minute, ShortWindow: time.Minute}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.SLO.Name != sloName || got.TotalEvents != 100 || got.BadEvents != 40 {
+		t.Errorf("Unexpected simulation totals %+v", got)
+	}
+	if len(got.Policies) != 1 || len(got.Policies[0].Firings) != 1 {
+		t.Fatalf("Expected one firing, got %+v", got.Policies)
+	}
+	if firing := got.Policies[0].Firings[0]; !firing.Start.Equal(start.Add(5*time.Minute)) || firing.Duration != "2m0s" {
+		t.Errorf("Unexpected firing %+v", firing)
+	}
+}
+
+func TestCloudMonitoringClient_SimulateBurnRateInvalidSLO(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := monitoring.NewWithClient(mocks.NewMockMonitoringClientInterface(ctrl), "test-project")
+	now := time.Now()
+	_, err := client.SimulateBurnRate(context.Background(), monitoring.BurnRateRequest{
+		SLO:       monitoring.ServiceLevelObjective{Goal: 0.99, Period: time.Hour, TotalFilter: "x"},
+		StartTime: now.Add(-time.Hour),
+		EndTime:   now,
+	})
+	if err == nil {
+		t.Error("Expected error for an SLO with a single filter")
+	}
+}
+
+func TestCloudMonitoringClient_LintAlertPolicies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	mockClient.EXPECT().
+		ListAlertPolicies(gomock.Any(), monitoring.ListAlertPoliciesRequest{Filter: `display_name=starts_with("prod")`}).
+		Return([]monitoring.AlertPolicy{
+			{
+				Name:          "projects/test-project/alertPolicies/1",
+				DisplayName:   "prod memory",
+				Enabled:       true,
+				Documentation: "Restart the instance",
+				Conditions: []monitoring.AlertCondition{{
+					DisplayName: "memory > 90%",
+					Type:        monitoring.ConditionTypeThreshold,
+					Filter:      `metric.type="agent.googleapis.com/memory/percent_used"`,
+					Duration:    "300s",
+				}},
+			},
+			{
+				Name:                 "projects/test-project/alertPolicies/2",
+				DisplayName:          "prod broken",
+				Enabled:              true,
+				Documentation:        "doc",
+				NotificationChannels: []string{"projects/test-project/notificationChannels/1"},
+				Invalid:              "filter is invalid",
+			},
+		}, nil).
+		Times(1)
+	mockClient.EXPECT().
+		ListAvailableMetrics(gomock.Any(), gomock.Any()).
+		Return([]monitoring.AvailableMetric{
+			{Type: "agent.googleapis.com/memory/percent_used", LaunchStage: "DEPRECATED"},
+			{Type: "compute.googleapis.com/instance/cpu/utilization", LaunchStage: "GA"},
+		}, nil).
+		Times(1)
+
+	resp, err := client.LintAlertPolicies(context.Background(), monitoring.LintAlertPoliciesRequest{Filter: `display_name=starts_with("prod")`})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.PoliciesChecked != 2 || len(resp.Findings) != 3 {
+		t.Fatalf("Expected 3 findings for 2 policies, got %+v", resp)
+	}
+	if f := resp.Findings[0]; f.Rule != monitoring.LintInvalidPolicy || f.Severity != monitoring.LintSeverityError {
+		t.Errorf("Expected the invalid policy error first, got %+v", f)
+	}
+	if resp.Summary[monitoring.LintDeprecatedMetric] != 1 || resp.Summary[monitoring.LintNoNotificationChannel] != 1 {
+		t.Errorf("Unexpected summary %v", resp.Summary)
+	}
+	for _, f := range resp.Findings {
+		if f.Rule == monitoring.LintDeprecatedMetric && (f.Condition != "memory > 90%" || f.PolicyDisplayName != "prod memory") {
+			t.Errorf("Unexpected deprecated metric finding %+v", f)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultCloneLookback = time.Hour
+	// maxCloneLookback is the age of the oldest point Cloud Monitoring
+	// accepts when writing
+	maxCloneLookback = 24 * time.Hour
+	// maxClonedSeries bounds the series whose latest point is copied
+	maxClonedSeries = 1000
+)
+
+// CloneMetricDescriptorRequest represents a request to copy a metric
+// descriptor to a new type
+type CloneMetricDescriptorRequest struct {
+	ProjectID   string `json:"project_id,omitempty"` // defaults to the client's project
+	SourceType  string `json:"source_type"`
+	TargetType  string `json:"target_type"`
+	DisplayName string `json:"display_name,omitempty"` // defaults to the display name of the source
+	// CopyData writes the latest point of each series of the source in the
+	// last Lookback to the target
+	CopyData bool          `json:"copy_data,omitempty"`
+	Lookback time.Duration `json:"lookback,omitempty"` // defaults to one hour, at most 24 hours
+}
+
+// CloneMetricDescriptorResult represents a cloned metric descriptor
+type CloneMetricDescriptorResult struct {
+	Source       MetricDescriptor `json:"source"`
+	Target       MetricDescriptor `json:"target"`
+	SeriesCopied int              `json:"series_copied"`
+	Truncated    bool             `json:"truncated,omitempty"` // the source had more than maxClonedSeries series
+}
+
+// CloneMetricDescriptor creates a descriptor of TargetType with the kind,
+// value type, description, and labels of the descriptor of SourceType, since
+// Cloud Monitoring cannot rename a descriptor. With CopyData, the latest
+// point of each recent series of the source is written to the target, so
+// that charts and alerts on the target have data right away. Points can
+// only be copied for GAUGE DOUBLE metrics, and writes keep one point per
+// series, so older points are not copied.
+func (c *CloudMonitoringClient) CloneMetricDescriptor(ctx context.Context, req CloneMetricDescriptorRequest) (CloneMetricDescriptorResult, error) {
+	if req.SourceType == "" || req.TargetType == "" {
+		return CloneMetricDescriptorResult{}, fmt.Errorf("source_type and target_type are required")
+	}
+	if req.SourceType == req.TargetType {
+		return CloneMetricDescriptorResult{}, fmt.Errorf("target_type must differ from source_type")
+	}
+	if !isUserDefinedMetric(req.TargetType) {
+		return CloneMetricDescriptorResult{}, fmt.Errorf("target_type %s is not a user-defined metric type", req.TargetType)
+	}
+	if req.Lookback <= 0 {
+		req.Lookback = defaultCloneLookback
+	}
+	if req.Lookback > maxCloneLookback {
+		return CloneMetricDescriptorResult{}, fmt.Errorf("lookback must be at most %s, since older points cannot be written", maxCloneLookback)
+	}
+
+	source, found, err := c.getMetricDescriptor(ctx, req.ProjectID, req.SourceType)
+	if err != nil {
+		return CloneMetricDescriptorResult{}, err
+	}
+	if !found {
+		return CloneMetricDescriptorResult{}, fmt.Errorf("metric descriptor %s not found", req.SourceType)
+	}
+	if req.CopyData && (source.MetricKind != "GAUGE" || source.ValueType != "DOUBLE") {
+		return CloneMetricDescriptorResult{}, fmt.Errorf("points can only be copied from GAUGE DOUBLE metrics, not %s %s", source.MetricKind, source.ValueType)
+	}
+	if _, exists, err := c.getMetricDescriptor(ctx, req.ProjectID, req.TargetType); err != nil {
+		return CloneMetricDescriptorResult{}, err
+	} else if exists {
+		return CloneMetricDescriptorResult{}, fmt.Errorf("metric descriptor %s already exists", req.TargetType)
+	}
+
+	target := MetricDescriptor{
+		Type:        req.TargetType,
+		MetricKind:  source.MetricKind,
+		ValueType:   source.ValueType,
+		Description: source.Description,
+		DisplayName: source.DisplayName,
+		Labels:      source.Labels,
+	}
+	if req.DisplayName != "" {
+		target.DisplayName = req.DisplayName
+	}
+	if err := c.client.CreateMetricDescriptor(ctx, CreateMetricRequest{ProjectID: req.ProjectID, MetricDescriptor: target}); err != nil {
+		return CloneMetricDescriptorResult{}, fmt.Errorf("failed to create %s: %w", req.TargetType, err)
+	}
+
+	result := CloneMetricDescriptorResult{Source: source, Target: target}
+	if !req.CopyData {
+		return result, nil
+	}
+
+	latest, truncated, err := c.latestPoints(ctx, req.ProjectID, req.SourceType, req.Lookback)
+	if err != nil {
+		return result, fmt.Errorf("created %s, but failed to read the points to copy: %w", req.TargetType, err)
+	}
+	result.Truncated = truncated
+	for i := range latest {
+		latest[i].MetricType = req.TargetType
+	}
+	if err := c.writeChunked(ctx, WriteTimeSeriesRequest{ProjectID: req.ProjectID, TimeSeries: latest}); err != nil {
+		var partial *PartialWriteError
+		if errors.As(err, &partial) {
+			result.SeriesCopied = partial.Written
+		}
+		return result, fmt.Errorf("created %s, but failed to copy points: %w", req.TargetType, err)
+	}
+	result.SeriesCopied = len(latest)
+	return result, nil
+}
+
+// getMetricDescriptor returns the descriptor of metricType, and whether it
+// exists
+func (c *CloudMonitoringClient) getMetricDescriptor(ctx context.Context, projectID, metricType string) (MetricDescriptor, bool, error) {
+	resp, err := c.client.ListMetricDescriptors(ctx, ListMetricDescriptorsRequest{
+		ProjectID: projectID,
+		Filter:    fmt.Sprintf("metric.type=%q", metricType),
+		PageSize:  1,
+	})
+	if err != nil {
+		return MetricDescriptor{}, false, fmt.Errorf("failed to get metric descriptor %s: %w", metricType, err)
+	}
+	for _, d := range resp.Descriptors {
+		if d.Type == metricType {
+			return d, true, nil
+		}
+	}
+	return MetricDescriptor{}, false, nil
+}
+
+// latestPoints returns the series of metricType written in the last
+// lookback, each with its latest point only, and whether more series are
+// left
+func (c *CloudMonitoringClient) latestPoints(ctx context.Context, projectID, metricType string, lookback time.Duration) ([]TimeSeriesData, bool, error) {
+	end := time.Now()
+	listReq := ListTimeSeriesRequest{
+		ProjectID: projectID,
+		Filter:    fmt.Sprintf("metric.type=%q", metricType),
+		PageSize:  maxSeriesPerWrite,
+	}
+	listReq.Interval.StartTime = end.Add(-lookback)
+	listReq.Interval.EndTime = end
+
+	var series []TimeSeriesData
+	for {
+		resp, err := c.client.ListTimeSeries(ctx, listReq)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, ts := range resp.TimeSeries {
+			if len(ts.Values) == 0 {
+				continue
+			}
+			last := ts.Values[0]
+			for _, v := range ts.Values[1:] {
+				if v.Timestamp.After(last.Timestamp) {
+					last = v
+				}
+			}
+			ts.Values = []MetricValue{last}
+			series = append(series, ts)
+		}
+		if resp.NextPageToken == "" {
+			return series, false, nil
+		}
+		if len(series) >= maxClonedSeries {
+			return series, true, nil
+		}
+		listReq.PageToken = resp.NextPageToken
+	}
+}
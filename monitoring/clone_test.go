@@ -0,0 +1,143 @@
+package monitoring_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudMonitoringClient_CloneMetricDescriptor(t *testing.T) {
+	source := monitoring.MetricDescriptor{
+		Type:        "custom.googleapis.com/queue_depth",
+		MetricKind:  "GAUGE",
+		ValueType:   "DOUBLE",
+		Description: "Messages waiting",
+		DisplayName: "Queue depth",
+		Labels:      map[string]string{"queue": "Queue name"},
+	}
+	// listDescriptors returns the descriptors among existing matching the type filter
+	listDescriptors := func(existing ...monitoring.MetricDescriptor) func(context.Context, monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
+		return func(ctx context.Context, req monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
+			var resp monitoring.ListMetricDescriptorsResponse
+			for _, d := range existing {
+				if req.Filter == `metric.type="`+d.Type+`"` {
+					resp.Descriptors = append(resp.Descriptors, d)
+				}
+			}
+			return resp, nil
+		}
+	}
+
+	t.Run("copies the schema and the latest points", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+		client := monitoring.NewWithClient(mockClient, "test-project")
+		now := time.Now()
+
+		mockClient.EXPECT().ListMetricDescriptors(gomock.Any(), gomock.Any()).DoAndReturn(listDescriptors(source)).Times(2)
+		mockClient.EXPECT().
+			CreateMetricDescriptor(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req monitoring.CreateMetricRequest) error {
+				d := req.MetricDescriptor
+				if d.Type != "custom.googleapis.com/queue/depth" || d.MetricKind != "GAUGE" || d.ValueType != "DOUBLE" ||
+					d.DisplayName != "Queue depth (v2)" || d.Description != "Messages waiting" || d.Labels["queue"] != "Queue name" {
+					t.Errorf("Unexpected descriptor %+v", d)
+				}
+				return nil
+			})
+		mockClient.EXPECT().
+			ListTimeSeries(gomock.Any(), gomock.Any()).
+			Return(monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{
+				{
+					MetricType:     source.Type,
+					MetricLabels:   map[string]string{"queue": "orders"},
+					ResourceType:   "global",
+					ResourceLabels: map[string]string{"project_id": "test-project"},
+					Values:         []monitoring.MetricValue{{Value: 7, Timestamp: now.Add(-time.Minute)}, {Value: 5, Timestamp: now.Add(-2 * time.Minute)}},
+				},
+				{MetricType: source.Type, MetricLabels: map[string]string{"queue": "empty"}},
+			}}, nil)
+		mockClient.EXPECT().
+			WriteTimeSeries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req monitoring.WriteTimeSeriesRequest) error {
+				if len(req.TimeSeries) != 1 {
+					t.Fatalf("Expected the series with points only, got %+v", req.TimeSeries)
+				}
+				ts := req.TimeSeries[0]
+				if ts.MetricType != "custom.googleapis.com/queue/depth" || ts.MetricLabels["queue"] != "orders" || ts.ResourceType != "global" {
+					t.Errorf("Unexpected series %+v", ts)
+				}
+				if len(ts.Values) != 1 || ts.Values[0].Value != 7 {
+					t.Errorf("Expected the latest point only, got %+v", ts.Values)
+				}
+				return nil
+			})
+
+		result, err := client.CloneMetricDescriptor(context.Background(), monitoring.CloneMetricDescriptorRequest{
+			SourceType:  source.Type,
+			TargetType:  "custom.googleapis.com/queue/depth",
+			DisplayName: "Queue depth (v2)",
+			CopyData:    true,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result.SeriesCopied != 1 || result.Source.Type != source.Type || result.Target.Type != "custom.googleapis.com/queue/depth" {
+			t.Errorf("Unexpected result %+v", result)
+		}
+	})
+
+	errorTests := []struct {
+		name     string
+		existing []monitoring.MetricDescriptor
+		req      monitoring.CloneMetricDescriptorRequest
+		wantErr  string
+	}{
+		{
+			name:     "missing source",
+			existing: nil,
+			req:      monitoring.CloneMetricDescriptorRequest{SourceType: source.Type, TargetType: "custom.googleapis.com/b"},
+			wantErr:  "not found",
+		},
+		{
+			name:     "existing target",
+			existing: []monitoring.MetricDescriptor{source, {Type: "custom.googleapis.com/b"}},
+			req:      monitoring.CloneMetricDescriptorRequest{SourceType: source.Type, TargetType: "custom.googleapis.com/b"},
+			wantErr:  "already exists",
+		},
+		{
+			name:     "points of a cumulative metric",
+			existing: []monitoring.MetricDescriptor{{Type: "custom.googleapis.com/requests", MetricKind: "CUMULATIVE", ValueType: "INT64"}},
+			req:      monitoring.CloneMetricDescriptorRequest{SourceType: "custom.googleapis.com/requests", TargetType: "custom.googleapis.com/b", CopyData: true},
+			wantErr:  "only be copied from GAUGE DOUBLE metrics",
+		},
+		{
+			name:    "built-in target",
+			req:     monitoring.CloneMetricDescriptorRequest{SourceType: source.Type, TargetType: "compute.googleapis.com/b"},
+			wantErr: "not a user-defined metric type",
+		},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Nothing is created when the request is rejected
+			mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+			client := monitoring.NewWithClient(mockClient, "test-project")
+			mockClient.EXPECT().ListMetricDescriptors(gomock.Any(), gomock.Any()).DoAndReturn(listDescriptors(tt.existing...)).AnyTimes()
+
+			_, err := client.CloneMetricDescriptor(context.Background(), tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
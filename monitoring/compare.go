@@ -0,0 +1,174 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxComparedDescriptors bounds the descriptors listed per project
+const maxComparedDescriptors = 5000
+
+// CompareMetricDescriptorsRequest represents a request to compare the
+// metric descriptors of two projects
+type CompareMetricDescriptorsRequest struct {
+	SourceProject string `json:"source_project,omitempty"` // defaults to the client's project
+	TargetProject string `json:"target_project"`
+	// Prefix selects the descriptors compared; defaults to CustomMetricPrefix
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// DescriptorDiff represents a metric type whose descriptors differ between
+// two projects
+type DescriptorDiff struct {
+	Type        string   `json:"type"`
+	Differences []string `json:"differences"` // one line per field, source value first
+}
+
+// CompareMetricDescriptorsResponse represents the differences between the
+// metric descriptors of two projects
+type CompareMetricDescriptorsResponse struct {
+	SourceProject string           `json:"source_project"`
+	TargetProject string           `json:"target_project"`
+	OnlyInSource  []string         `json:"only_in_source"` // missing from the target
+	OnlyInTarget  []string         `json:"only_in_target"`
+	Mismatched    []DescriptorDiff `json:"mismatched"`
+	Matching      int              `json:"matching"`
+	// Truncated lists the projects with more than maxComparedDescriptors descriptors
+	Truncated []string `json:"truncated,omitempty"`
+}
+
+// CompareMetricDescriptors lists the descriptors whose type starts with the
+// prefix in two projects, and reports the types missing from either project
+// and those whose kind, value type, labels, or description differ, e.g. to
+// check the telemetry config promoted from staging to production.
+func (c *CloudMonitoringClient) CompareMetricDescriptors(ctx context.Context, req CompareMetricDescriptorsRequest) (CompareMetricDescriptorsResponse, error) {
+	if req.TargetProject == "" {
+		return CompareMetricDescriptorsResponse{}, fmt.Errorf("target_project is required")
+	}
+	if req.SourceProject == "" {
+		req.SourceProject = c.projectID
+	}
+	if req.SourceProject == req.TargetProject {
+		return CompareMetricDescriptorsResponse{}, fmt.Errorf("target_project must differ from source_project")
+	}
+	if req.Prefix == "" {
+		req.Prefix = CustomMetricPrefix
+	}
+
+	resp := CompareMetricDescriptorsResponse{
+		SourceProject: req.SourceProject,
+		TargetProject: req.TargetProject,
+		OnlyInSource:  []string{},
+		OnlyInTarget:  []string{},
+		Mismatched:    []DescriptorDiff{},
+	}
+	projects := []string{req.SourceProject, req.TargetProject}
+	descriptors := make([]map[string]MetricDescriptor, len(projects))
+	truncated := make([]bool, len(projects))
+	errs := make([]error, len(projects))
+	var wg sync.WaitGroup
+	for i, project := range projects {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			descriptors[i], truncated[i], errs[i] = c.listDescriptors(ctx, project, req.Prefix)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return CompareMetricDescriptorsResponse{}, fmt.Errorf("failed to list the metric descriptors of %s: %w", projects[i], err)
+		}
+		if truncated[i] {
+			resp.Truncated = append(resp.Truncated, projects[i])
+		}
+	}
+
+	source, target := descriptors[0], descriptors[1]
+	for _, metricType := range slices.Sorted(maps.Keys(source)) {
+		t, ok := target[metricType]
+		if !ok {
+			resp.OnlyInSource = append(resp.OnlyInSource, metricType)
+			continue
+		}
+		if differences := diffDescriptors(source[metricType], t); len(differences) > 0 {
+			resp.Mismatched = append(resp.Mismatched, DescriptorDiff{Type: metricType, Differences: differences})
+			continue
+		}
+		resp.Matching++
+	}
+	for _, metricType := range slices.Sorted(maps.Keys(target)) {
+		if _, ok := source[metricType]; !ok {
+			resp.OnlyInTarget = append(resp.OnlyInTarget, metricType)
+		}
+	}
+	return resp, nil
+}
+
+// listDescriptors returns the descriptors of a project whose type starts
+// with prefix, keyed by type, and whether more are left
+func (c *CloudMonitoringClient) listDescriptors(ctx context.Context, projectID, prefix string) (map[string]MetricDescriptor, bool, error) {
+	descriptors := make(map[string]MetricDescriptor)
+	listReq := ListMetricDescriptorsRequest{
+		ProjectID: projectID,
+		Filter:    fmt.Sprintf("metric.type = starts_with(%q)", prefix),
+		PageSize:  1000,
+	}
+	for {
+		page, err := c.client.ListMetricDescriptors(ctx, listReq)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, d := range page.Descriptors {
+			descriptors[d.Type] = d
+		}
+		if page.NextPageToken == "" {
+			return descriptors, false, nil
+		}
+		if len(descriptors) >= maxComparedDescriptors {
+			return descriptors, true, nil
+		}
+		listReq.PageToken = page.NextPageToken
+	}
+}
+
+// diffDescriptors describes the differences between the source and target
+// descriptors of a metric type
+func diffDescriptors(source, target MetricDescriptor) []string {
+	var differences []string
+	field := func(name, s, t string) {
+		if s != t {
+			differences = append(differences, fmt.Sprintf("%s: %q in source, %q in target", name, s, t))
+		}
+	}
+	field("metric_kind", source.MetricKind, target.MetricKind)
+	field("value_type", source.ValueType, target.ValueType)
+
+	var onlySource, onlyTarget []string
+	for key := range source.Labels {
+		if _, ok := target.Labels[key]; !ok {
+			onlySource = append(onlySource, key)
+		}
+	}
+	for key := range target.Labels {
+		if _, ok := source.Labels[key]; !ok {
+			onlyTarget = append(onlyTarget, key)
+		}
+	}
+	if len(onlySource) > 0 {
+		slices.Sort(onlySource)
+		differences = append(differences, "labels only in source: "+strings.Join(onlySource, ", "))
+	}
+	if len(onlyTarget) > 0 {
+		slices.Sort(onlyTarget)
+		differences = append(differences, "labels only in target: "+strings.Join(onlyTarget, ", "))
+	}
+
+	field("display_name", source.DisplayName, target.DisplayName)
+	field("description", source.Description, target.Description)
+	return differences
+}
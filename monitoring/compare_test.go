@@ -0,0 +1,84 @@
+package monitoring_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudMonitoringClient_CompareMetricDescriptors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "staging")
+
+	latency := monitoring.MetricDescriptor{
+		Type: "custom.googleapis.com/latency", MetricKind: "GAUGE", ValueType: "DOUBLE",
+		DisplayName: "Latency", Labels: map[string]string{"route": "", "method": ""},
+	}
+	descriptors := map[string][]monitoring.MetricDescriptor{
+		"staging": {
+			latency,
+			{Type: "custom.googleapis.com/requests", MetricKind: "CUMULATIVE", ValueType: "INT64"},
+			{Type: "custom.googleapis.com/queue_depth", MetricKind: "GAUGE", ValueType: "DOUBLE", Labels: map[string]string{"queue": ""}},
+			{Type: "custom.googleapis.com/new_feature", MetricKind: "GAUGE", ValueType: "BOOL"},
+		},
+		"production": {
+			latency,
+			{Type: "custom.googleapis.com/requests", MetricKind: "DELTA", ValueType: "INT64"},
+			{Type: "custom.googleapis.com/queue_depth", MetricKind: "GAUGE", ValueType: "DOUBLE", Labels: map[string]string{"region": ""}},
+			{Type: "custom.googleapis.com/legacy", MetricKind: "GAUGE", ValueType: "DOUBLE"},
+		},
+	}
+	mockClient.EXPECT().
+		ListMetricDescriptors(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
+			if req.Filter != `metric.type = starts_with("custom.googleapis.com/")` {
+				t.Errorf("Unexpected filter %s", req.Filter)
+			}
+			return monitoring.ListMetricDescriptorsResponse{Descriptors: descriptors[req.ProjectID]}, nil
+		}).
+		Times(2)
+
+	resp, err := client.CompareMetricDescriptors(context.Background(), monitoring.CompareMetricDescriptorsRequest{TargetProject: "production"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.SourceProject != "staging" || resp.Matching != 1 {
+		t.Errorf("Expected 1 matching descriptor from staging, got %+v", resp)
+	}
+	if !slices.Equal(resp.OnlyInSource, []string{"custom.googleapis.com/new_feature"}) {
+		t.Errorf("OnlyInSource = %v", resp.OnlyInSource)
+	}
+	if !slices.Equal(resp.OnlyInTarget, []string{"custom.googleapis.com/legacy"}) {
+		t.Errorf("OnlyInTarget = %v", resp.OnlyInTarget)
+	}
+	if len(resp.Mismatched) != 2 {
+		t.Fatalf("Expected 2 mismatched descriptors, got %+v", resp.Mismatched)
+	}
+	queue, requests := resp.Mismatched[0], resp.Mismatched[1]
+	if queue.Type != "custom.googleapis.com/queue_depth" || !slices.Equal(queue.Differences, []string{"labels only in source: queue", "labels only in target: region"}) {
+		t.Errorf("Unexpected queue_depth differences %+v", queue)
+	}
+	if len(requests.Differences) != 1 || !strings.Contains(requests.Differences[0], `metric_kind: "CUMULATIVE" in source, "DELTA" in target`) {
+		t.Errorf("Unexpected requests differences %+v", requests)
+	}
+}
+
+func TestCloudMonitoringClient_CompareMetricDescriptorsSameProject(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := monitoring.NewWithClient(mocks.NewMockMonitoringClientInterface(ctrl), "staging")
+	_, err := client.CompareMetricDescriptors(context.Background(), monitoring.CompareMetricDescriptorsRequest{TargetProject: "staging"})
+	if err == nil || !strings.Contains(err.Error(), "must differ") {
+		t.Errorf("Expected an error comparing a project with itself, got %v", err)
+	}
+}
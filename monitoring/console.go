@@ -0,0 +1,41 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// ConsoleURL returns the Metrics Explorer URL charting the time series
+// selected by the filter, aggregated like ListTimeSeries would
+func ConsoleURL(projectID, filter string, aggregation *AggregationConfig) string {
+	timeSeriesFilter := map[string]any{"filter": filter}
+	if aggregation != nil {
+		timeSeriesFilter["aggregations"] = []map[string]any{{
+			"alignmentPeriod":    aggregation.AlignmentPeriod,
+			"perSeriesAligner":   aggregation.PerSeriesAligner,
+			"crossSeriesReducer": aggregation.CrossSeriesReducer,
+			"groupByFields":      aggregation.GroupByFields,
+		}}
+		timeSeriesFilter["minAlignmentPeriod"] = aggregation.AlignmentPeriod
+	}
+
+	pageState, _ := json.Marshal(map[string]any{
+		"xyChart": map[string]any{
+			"dataSets": []map[string]any{{
+				"timeSeriesFilter": timeSeriesFilter,
+				"plotType":         "LINE",
+			}},
+		},
+	})
+
+	query := url.Values{}
+	query.Set("project", projectID)
+	query.Set("pageState", string(pageState))
+	return "https://console.cloud.google.com/monitoring/metrics-explorer?" + query.Encode()
+}
+
+// metricConsoleURL returns the Metrics Explorer URL charting every time series of the metric type
+func metricConsoleURL(projectID, metricType string) string {
+	return ConsoleURL(projectID, fmt.Sprintf("metric.type=%q", metricType), nil)
+}
@@ -0,0 +1,53 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestConsoleURL(t *testing.T) {
+	got := ConsoleURL("my-project", `metric.type="run.googleapis.com/request_count"`, &AggregationConfig{
+		AlignmentPeriod:    "60s",
+		PerSeriesAligner:   "ALIGN_RATE",
+		CrossSeriesReducer: "REDUCE_SUM",
+		GroupByFields:      []string{"metric.labels.response_code_class"},
+	})
+
+	prefix := "https://console.cloud.google.com/monitoring/metrics-explorer?"
+	if !strings.HasPrefix(got, prefix) {
+		t.Fatalf("Expected Metrics Explorer URL, got %s", got)
+	}
+	query, err := url.ParseQuery(strings.TrimPrefix(got, prefix))
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+	if query.Get("project") != "my-project" {
+		t.Errorf("Expected project my-project, got %s", query.Get("project"))
+	}
+
+	var pageState struct {
+		XYChart struct {
+			DataSets []struct {
+				TimeSeriesFilter struct {
+					Filter       string `json:"filter"`
+					Aggregations []struct {
+						PerSeriesAligner string   `json:"perSeriesAligner"`
+						GroupByFields    []string `json:"groupByFields"`
+					} `json:"aggregations"`
+				} `json:"timeSeriesFilter"`
+			} `json:"dataSets"`
+		} `json:"xyChart"`
+	}
+	if err := json.Unmarshal([]byte(query.Get("pageState")), &pageState); err != nil {
+		t.Fatalf("Failed to parse pageState: %v", err)
+	}
+	filter := pageState.XYChart.DataSets[0].TimeSeriesFilter
+	if filter.Filter != `metric.type="run.googleapis.com/request_count"` {
+		t.Errorf("Unexpected filter %s", filter.Filter)
+	}
+	if filter.Aggregations[0].PerSeriesAligner != "ALIGN_RATE" || filter.Aggregations[0].GroupByFields[0] != "metric.labels.response_code_class" {
+		t.Errorf("Unexpected aggregation %+v", filter.Aggregations)
+	}
+}
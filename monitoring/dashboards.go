@@ -0,0 +1,62 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cloud.google.com/go/monitoring/dashboard/apiv1/dashboardpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxDashboards bounds the number of dashboards fetched for a project
+const maxDashboards = 1000
+
+// Dashboard represents a Cloud Monitoring dashboard
+type Dashboard struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	// Definition is the dashboard in the JSON representation of the Dashboards API,
+	// as exported from the Cloud Console
+	Definition json.RawMessage `json:"definition"`
+}
+
+// ListDashboardsRequest represents a request to list dashboards
+type ListDashboardsRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+}
+
+// ListDashboards lists the dashboards of a project
+func (c *CloudMonitoringClient) ListDashboards(ctx context.Context, req ListDashboardsRequest) ([]Dashboard, error) {
+	return c.client.ListDashboards(ctx, req)
+}
+
+// ListDashboards implements MonitoringClientInterface for the real client
+func (r *realMonitoringClient) ListDashboards(ctx context.Context, req ListDashboardsRequest) ([]Dashboard, error) {
+	it := r.dashboardClient.ListDashboards(ctx, &dashboardpb.ListDashboardsRequest{
+		Parent: r.projectName(req.ProjectID),
+	})
+
+	dashboards := []Dashboard{}
+	for len(dashboards) < maxDashboards {
+		d, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dashboards: %w", err)
+		}
+
+		definition, err := protojson.Marshal(d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal dashboard %s: %w", d.GetName(), err)
+		}
+		dashboards = append(dashboards, Dashboard{
+			Name:        d.GetName(),
+			DisplayName: d.GetDisplayName(),
+			Definition:  definition,
+		})
+	}
+	return dashboards, nil
+}
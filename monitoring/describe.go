@@ -0,0 +1,141 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxDescribedResources bounds the resources DescribeResource returns when
+// the labels match several
+const maxDescribedResources = 20
+
+// DescribeResourceRequest represents a request for the metadata of a monitored resource
+type DescribeResourceRequest struct {
+	ProjectID    string `json:"project_id,omitempty"` // defaults to the client's project
+	ResourceType string `json:"resource_type"`
+	// Labels select the resource, e.g. its instance_id; resources matching
+	// only some of the labels of their type are all described
+	Labels map[string]string `json:"labels,omitempty"`
+	// MetricType is a metric the resource writes, required for resource
+	// types outside ResourceTypes
+	MetricType string    `json:"metric_type,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+}
+
+// DescribedResource represents a monitored resource with its metadata
+type DescribedResource struct {
+	ResourceType string            `json:"resource_type"`
+	Labels       map[string]string `json:"labels"`
+	Name         string            `json:"name,omitempty"` // the name system label, e.g. of a VM
+	SystemLabels map[string]any    `json:"system_labels,omitempty"`
+	UserLabels   map[string]string `json:"user_labels,omitempty"`
+	LastSeen     time.Time         `json:"last_seen"` // of the latest point of the metric
+}
+
+// DescribeResourceResponse lists the resources matching the labels, most
+// recently seen first
+type DescribeResourceResponse struct {
+	MetricType string              `json:"metric_type"` // the metric the resources were found by
+	Resources  []DescribedResource `json:"resources"`
+	Truncated  bool                `json:"truncated,omitempty"` // more than maxDescribedResources matched
+}
+
+// DescribeResource looks up the metadata Cloud Monitoring keeps about a
+// monitored resource, e.g. the name, machine type, and user labels of a VM
+// known by its instance ID, from the time series of a metric the resource
+// writes. Only resources writing the metric in the window are found.
+func (c *CloudMonitoringClient) DescribeResource(ctx context.Context, req DescribeResourceRequest) (DescribeResourceResponse, error) {
+	if req.ResourceType == "" {
+		return DescribeResourceResponse{}, fmt.Errorf("resource_type is required")
+	}
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+	if req.StartTime.IsZero() {
+		req.StartTime = req.EndTime.Add(-defaultResourceWindow)
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return DescribeResourceResponse{}, fmt.Errorf("start_time must be before end_time")
+	}
+	metricType := req.MetricType
+	if metricType == "" {
+		metricType = resourceProbes[req.ResourceType].metricType
+	}
+	if metricType == "" {
+		return DescribeResourceResponse{}, fmt.Errorf("metric_type is required for resource type %q, which is not one of %s",
+			req.ResourceType, strings.Join(ResourceTypes, ", "))
+	}
+
+	filter := fmt.Sprintf(`metric.type=%q AND resource.type=%q`, metricType, req.ResourceType)
+	for _, key := range slices.Sorted(maps.Keys(req.Labels)) {
+		filter += fmt.Sprintf(` AND resource.labels.%s=%q`, key, req.Labels[key])
+	}
+	// Metadata is only kept on series that are not reduced across resources
+	listReq := ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    filter,
+		PageSize:  100,
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	list, err := c.client.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return DescribeResourceResponse{}, fmt.Errorf("failed to list %s: %w", metricType, err)
+	}
+
+	// A resource writes one series per combination of metric labels
+	resources := make(map[string]*DescribedResource)
+	metadataSeen := make(map[string]time.Time)
+	for _, ts := range list.TimeSeries {
+		key := strings.Join(labelValues(ts.ResourceLabels), "\x00")
+		resource, ok := resources[key]
+		if !ok {
+			resource = &DescribedResource{ResourceType: ts.ResourceType, Labels: ts.ResourceLabels}
+			if resource.Labels == nil {
+				resource.Labels = map[string]string{}
+			}
+			resources[key] = resource
+		}
+		var lastSeen time.Time
+		for _, v := range ts.Values {
+			if v.Timestamp.After(lastSeen) {
+				lastSeen = v.Timestamp
+			}
+		}
+		// The metadata of the latest series wins, as labels may have changed
+		if seen, ok := metadataSeen[key]; ts.Metadata != nil && (!ok || lastSeen.After(seen)) {
+			metadataSeen[key] = lastSeen
+			resource.SystemLabels = ts.Metadata.SystemLabels
+			resource.UserLabels = ts.Metadata.UserLabels
+			resource.Name, _ = ts.Metadata.SystemLabels["name"].(string)
+		}
+		if lastSeen.After(resource.LastSeen) {
+			resource.LastSeen = lastSeen
+		}
+	}
+
+	resp := DescribeResourceResponse{MetricType: metricType, Resources: []DescribedResource{}}
+	for _, resource := range resources {
+		resp.Resources = append(resp.Resources, *resource)
+	}
+	slices.SortFunc(resp.Resources, func(a, b DescribedResource) int {
+		if c := b.LastSeen.Compare(a.LastSeen); c != 0 {
+			return c
+		}
+		return slices.Compare(labelValues(a.Labels), labelValues(b.Labels))
+	})
+	if len(resp.Resources) > maxDescribedResources {
+		resp.Resources = resp.Resources[:maxDescribedResources]
+		resp.Truncated = true
+	}
+	if list.NextPageToken != "" {
+		resp.Truncated = true
+	}
+	return resp, nil
+}
@@ -0,0 +1,116 @@
+package monitoring_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudMonitoringClient_DescribeResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	vm := map[string]string{"zone": "us-central1-b", "instance_id": "1234567890"}
+	mockClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			want := `metric.type="compute.googleapis.com/instance/uptime" AND resource.type="gce_instance" AND resource.labels.zone="us-central1-b"`
+			if req.Filter != want {
+				t.Errorf("Expected filter %s, got %s", want, req.Filter)
+			}
+			if req.Aggregation != nil {
+				t.Errorf("Expected the series unreduced to keep their metadata, got %+v", req.Aggregation)
+			}
+			if !req.Interval.StartTime.Equal(end.Add(-time.Hour)) {
+				t.Errorf("Expected the last hour, got %v", req.Interval.StartTime)
+			}
+			return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{
+				{
+					ResourceType:   "gce_instance",
+					ResourceLabels: vm,
+					MetricLabels:   map[string]string{"instance_name": "web-1"},
+					Metadata: &monitoring.ResourceMetadata{
+						SystemLabels: map[string]any{"name": "web-1", "machine_type": "e2-medium"},
+					},
+					Values: []monitoring.MetricValue{{Value: 60, Timestamp: end.Add(-30 * time.Minute)}},
+				},
+				// The VM was renamed and resized
+				{
+					ResourceType:   "gce_instance",
+					ResourceLabels: vm,
+					MetricLabels:   map[string]string{"instance_name": "web-2"},
+					Metadata: &monitoring.ResourceMetadata{
+						SystemLabels: map[string]any{"name": "web-2", "machine_type": "e2-standard-4"},
+						UserLabels:   map[string]string{"team": "checkout"},
+					},
+					Values: []monitoring.MetricValue{{Value: 60, Timestamp: end}, {Value: 60, Timestamp: end.Add(-time.Minute)}},
+				},
+				{
+					ResourceType:   "gce_instance",
+					ResourceLabels: map[string]string{"zone": "us-central1-b", "instance_id": "42"},
+					Values:         []monitoring.MetricValue{{Value: 60, Timestamp: end.Add(-10 * time.Minute)}},
+				},
+			}}, nil
+		})
+
+	resp, err := client.DescribeResource(context.Background(), monitoring.DescribeResourceRequest{
+		ResourceType: "gce_instance",
+		Labels:       map[string]string{"zone": "us-central1-b"},
+		EndTime:      end,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.MetricType != "compute.googleapis.com/instance/uptime" || resp.Truncated {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if len(resp.Resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %+v", resp.Resources)
+	}
+	want := monitoring.DescribedResource{
+		ResourceType: "gce_instance",
+		Labels:       vm,
+		Name:         "web-2",
+		SystemLabels: map[string]any{"name": "web-2", "machine_type": "e2-standard-4"},
+		UserLabels:   map[string]string{"team": "checkout"},
+		LastSeen:     end,
+	}
+	if !reflect.DeepEqual(resp.Resources[0], want) {
+		t.Errorf("Expected the latest metadata of the VM %+v, got %+v", want, resp.Resources[0])
+	}
+	if other := resp.Resources[1]; other.Labels["instance_id"] != "42" || other.Name != "" || other.SystemLabels != nil {
+		t.Errorf("Expected the other VM without metadata, got %+v", other)
+	}
+}
+
+func TestCloudMonitoringClient_DescribeResource_InvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := monitoring.NewWithClient(mocks.NewMockMonitoringClientInterface(ctrl), "test-project")
+
+	tests := []struct {
+		name string
+		req  monitoring.DescribeResourceRequest
+	}{
+		{name: "no resource type", req: monitoring.DescribeResourceRequest{}},
+		{name: "unknown resource type without metric", req: monitoring.DescribeResourceRequest{ResourceType: "redis_instance"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.DescribeResource(context.Background(), tt.req); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
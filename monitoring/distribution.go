@@ -0,0 +1,202 @@
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"google.golang.org/genproto/googleapis/api/distribution"
+)
+
+// BucketOptions defines the buckets of a distribution. Exactly one of the
+// explicit bounds, linear buckets, and exponential buckets is set. As in
+// Cloud Monitoring, the finite buckets are preceded by an underflow bucket
+// and followed by an overflow bucket.
+type BucketOptions struct {
+	// Bounds are the strictly increasing boundaries of explicit buckets
+	Bounds      []float64           `json:"bounds,omitempty"`
+	Linear      *LinearBuckets      `json:"linear,omitempty"`
+	Exponential *ExponentialBuckets `json:"exponential,omitempty"`
+}
+
+// LinearBuckets are buckets of the same width: finite bucket i covers
+// [offset + width*i, offset + width*(i+1))
+type LinearBuckets struct {
+	NumFiniteBuckets int     `json:"num_finite_buckets"`
+	Width            float64 `json:"width"`
+	Offset           float64 `json:"offset"`
+}
+
+// ExponentialBuckets are buckets growing by a factor: finite bucket i
+// covers [scale * growth_factor^i, scale * growth_factor^(i+1))
+type ExponentialBuckets struct {
+	NumFiniteBuckets int     `json:"num_finite_buckets"`
+	GrowthFactor     float64 `json:"growth_factor"`
+	Scale            float64 `json:"scale"`
+}
+
+// Distribution represents a DISTRIBUTION value, e.g. a latency histogram
+type Distribution struct {
+	Count                 int64         `json:"count"`
+	Mean                  float64       `json:"mean"`
+	SumOfSquaredDeviation float64       `json:"sum_of_squared_deviation"`
+	BucketOptions         BucketOptions `json:"bucket_options"`
+	// BucketCounts has one count per bucket, starting with the underflow
+	// bucket. Trailing zero counts may be left out.
+	BucketCounts []int64 `json:"bucket_counts"`
+}
+
+// Validate checks that exactly one kind of buckets is defined, and that the
+// buckets are well formed
+func (o BucketOptions) Validate() error {
+	set := 0
+	if len(o.Bounds) > 0 {
+		set++
+		for i := 1; i < len(o.Bounds); i++ {
+			if o.Bounds[i] <= o.Bounds[i-1] {
+				return fmt.Errorf("bucket bounds must be strictly increasing")
+			}
+		}
+	}
+	if o.Linear != nil {
+		set++
+		if o.Linear.NumFiniteBuckets <= 0 || o.Linear.Width <= 0 {
+			return fmt.Errorf("linear buckets need a positive num_finite_buckets and width")
+		}
+	}
+	if o.Exponential != nil {
+		set++
+		if o.Exponential.NumFiniteBuckets <= 0 || o.Exponential.GrowthFactor <= 1 || o.Exponential.Scale <= 0 {
+			return fmt.Errorf("exponential buckets need a positive num_finite_buckets and scale, and a growth_factor above 1")
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of bounds, linear, and exponential buckets is required")
+	}
+	return nil
+}
+
+// NumBuckets returns the number of buckets, including the underflow and
+// overflow buckets
+func (o BucketOptions) NumBuckets() int {
+	switch {
+	case o.Linear != nil:
+		return o.Linear.NumFiniteBuckets + 2
+	case o.Exponential != nil:
+		return o.Exponential.NumFiniteBuckets + 2
+	default:
+		return len(o.Bounds) + 1
+	}
+}
+
+// Bucket returns the index of the bucket of v
+func (o BucketOptions) Bucket(v float64) int {
+	switch {
+	case o.Linear != nil:
+		if v < o.Linear.Offset {
+			return 0
+		}
+		return min(int((v-o.Linear.Offset)/o.Linear.Width)+1, o.Linear.NumFiniteBuckets+1)
+	case o.Exponential != nil:
+		if v < o.Exponential.Scale {
+			return 0
+		}
+		bound := func(i int) float64 { return o.Exponential.Scale * math.Pow(o.Exponential.GrowthFactor, float64(i)) }
+		i := int(math.Log(v/o.Exponential.Scale) / math.Log(o.Exponential.GrowthFactor))
+		// The logarithm may round across a bound
+		if v >= bound(i+1) {
+			i++
+		} else if v < bound(i) {
+			i--
+		}
+		return min(i+1, o.Exponential.NumFiniteBuckets+1)
+	default:
+		// The first bound above v is the upper bound of its bucket
+		i, found := slices.BinarySearch(o.Bounds, v)
+		if found {
+			i++
+		}
+		return i
+	}
+}
+
+// NewDistribution returns the distribution of samples in the buckets of
+// options
+func NewDistribution(options BucketOptions, samples []float64) (Distribution, error) {
+	if err := options.Validate(); err != nil {
+		return Distribution{}, err
+	}
+	d := Distribution{
+		Count:         int64(len(samples)),
+		BucketOptions: options,
+		BucketCounts:  make([]int64, options.NumBuckets()),
+	}
+	if len(samples) == 0 {
+		return d, nil
+	}
+	var sum float64
+	for _, v := range samples {
+		sum += v
+		d.BucketCounts[options.Bucket(v)]++
+	}
+	d.Mean = sum / float64(len(samples))
+	for _, v := range samples {
+		d.SumOfSquaredDeviation += (v - d.Mean) * (v - d.Mean)
+	}
+	return d, nil
+}
+
+// Validate checks the buckets of the distribution, and that the bucket
+// counts add up to the count
+func (d Distribution) Validate() error {
+	if err := d.BucketOptions.Validate(); err != nil {
+		return err
+	}
+	if n := d.BucketOptions.NumBuckets(); len(d.BucketCounts) > n {
+		return fmt.Errorf("%d bucket counts given for %d buckets, including the underflow and overflow buckets", len(d.BucketCounts), n)
+	}
+	var total int64
+	for _, count := range d.BucketCounts {
+		if count < 0 {
+			return fmt.Errorf("bucket counts must not be negative")
+		}
+		total += count
+	}
+	if total != d.Count {
+		return fmt.Errorf("bucket counts add up to %d, not to the count %d", total, d.Count)
+	}
+	if d.SumOfSquaredDeviation < 0 {
+		return fmt.Errorf("sum_of_squared_deviation must not be negative")
+	}
+	return nil
+}
+
+// toProto converts the distribution to its Cloud Monitoring representation
+func (d Distribution) toProto() *distribution.Distribution {
+	options := &distribution.Distribution_BucketOptions{}
+	switch {
+	case d.BucketOptions.Linear != nil:
+		options.Options = &distribution.Distribution_BucketOptions_LinearBuckets{LinearBuckets: &distribution.Distribution_BucketOptions_Linear{
+			NumFiniteBuckets: int32(d.BucketOptions.Linear.NumFiniteBuckets),
+			Width:            d.BucketOptions.Linear.Width,
+			Offset:           d.BucketOptions.Linear.Offset,
+		}}
+	case d.BucketOptions.Exponential != nil:
+		options.Options = &distribution.Distribution_BucketOptions_ExponentialBuckets{ExponentialBuckets: &distribution.Distribution_BucketOptions_Exponential{
+			NumFiniteBuckets: int32(d.BucketOptions.Exponential.NumFiniteBuckets),
+			GrowthFactor:     d.BucketOptions.Exponential.GrowthFactor,
+			Scale:            d.BucketOptions.Exponential.Scale,
+		}}
+	default:
+		options.Options = &distribution.Distribution_BucketOptions_ExplicitBuckets{ExplicitBuckets: &distribution.Distribution_BucketOptions_Explicit{
+			Bounds: d.BucketOptions.Bounds,
+		}}
+	}
+	return &distribution.Distribution{
+		Count:                 d.Count,
+		Mean:                  d.Mean,
+		SumOfSquaredDeviation: d.SumOfSquaredDeviation,
+		BucketOptions:         options,
+		BucketCounts:          d.BucketCounts,
+	}
+}
@@ -0,0 +1,124 @@
+package monitoring_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+func TestBucketOptions_Bucket(t *testing.T) {
+	tests := []struct {
+		name    string
+		options monitoring.BucketOptions
+		values  []float64
+		want    []int
+	}{
+		{
+			name:    "explicit",
+			options: monitoring.BucketOptions{Bounds: []float64{10, 50, 100}},
+			values:  []float64{5, 10, 49.9, 50, 100, 1000},
+			want:    []int{0, 1, 1, 2, 3, 3},
+		},
+		{
+			name:    "linear",
+			options: monitoring.BucketOptions{Linear: &monitoring.LinearBuckets{NumFiniteBuckets: 3, Width: 10, Offset: 5}},
+			values:  []float64{4, 5, 14.9, 15, 34.9, 35, 100},
+			want:    []int{0, 1, 1, 2, 3, 4, 4},
+		},
+		{
+			name:    "exponential",
+			options: monitoring.BucketOptions{Exponential: &monitoring.ExponentialBuckets{NumFiniteBuckets: 4, GrowthFactor: 10, Scale: 0.001}},
+			values:  []float64{0.0005, 0.001, 0.01, 0.099, 0.1, 1, 10, 1000},
+			want:    []int{0, 1, 2, 2, 3, 4, 5, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, v := range tt.values {
+				got = append(got, tt.options.Bucket(v))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Bucket(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDistribution(t *testing.T) {
+	d, err := monitoring.NewDistribution(monitoring.BucketOptions{Bounds: []float64{100, 200}}, []float64{50, 150, 150, 250})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if d.Count != 4 || d.Mean != 150 || d.SumOfSquaredDeviation != 20000 {
+		t.Errorf("Unexpected statistics %+v", d)
+	}
+	if !slices.Equal(d.BucketCounts, []int64{1, 2, 1}) {
+		t.Errorf("BucketCounts = %v, want [1 2 1]", d.BucketCounts)
+	}
+	if err := d.Validate(); err != nil {
+		t.Errorf("Expected a valid distribution, got %v", err)
+	}
+
+	if _, err := monitoring.NewDistribution(monitoring.BucketOptions{}, []float64{1}); err == nil {
+		t.Error("Expected an error without buckets")
+	}
+}
+
+func TestDistribution_Validate(t *testing.T) {
+	explicit := monitoring.BucketOptions{Bounds: []float64{1, 2}}
+	tests := []struct {
+		name    string
+		d       monitoring.Distribution
+		wantErr string
+	}{
+		{
+			name: "valid with trailing counts left out",
+			d:    monitoring.Distribution{Count: 3, Mean: 1, BucketOptions: explicit, BucketCounts: []int64{1, 2}},
+		},
+		{
+			name:    "counts not adding up",
+			d:       monitoring.Distribution{Count: 4, BucketOptions: explicit, BucketCounts: []int64{1, 2}},
+			wantErr: "add up to 3",
+		},
+		{
+			name:    "too many counts",
+			d:       monitoring.Distribution{Count: 4, BucketOptions: explicit, BucketCounts: []int64{1, 1, 1, 1}},
+			wantErr: "4 bucket counts given for 3 buckets",
+		},
+		{
+			name:    "unsorted bounds",
+			d:       monitoring.Distribution{BucketOptions: monitoring.BucketOptions{Bounds: []float64{2, 1}}},
+			wantErr: "strictly increasing",
+		},
+		{
+			name: "two kinds of buckets",
+			d: monitoring.Distribution{BucketOptions: monitoring.BucketOptions{
+				Bounds: []float64{1},
+				Linear: &monitoring.LinearBuckets{NumFiniteBuckets: 1, Width: 1},
+			}},
+			wantErr: "exactly one of",
+		},
+		{
+			name:    "growth factor of 1",
+			d:       monitoring.Distribution{BucketOptions: monitoring.BucketOptions{Exponential: &monitoring.ExponentialBuckets{NumFiniteBuckets: 1, GrowthFactor: 1, Scale: 1}}},
+			wantErr: "growth_factor above 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.d.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
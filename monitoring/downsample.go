@@ -0,0 +1,131 @@
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Downsampling methods
+const (
+	// DownsampleLTTB keeps the points that best preserve the visual shape of
+	// the series (Largest-Triangle-Three-Buckets)
+	DownsampleLTTB = "lttb"
+	// DownsampleMean replaces each bucket of consecutive points with their mean
+	DownsampleMean = "mean"
+)
+
+// Downsample reduces each series to at most maxPoints points using the given
+// method (DownsampleLTTB when empty). Series that already fit are returned
+// unchanged. The order of points within each series is preserved.
+func Downsample(series []TimeSeriesData, maxPoints int, method string) ([]TimeSeriesData, error) {
+	if method == "" {
+		method = DownsampleLTTB
+	}
+	if method != DownsampleLTTB && method != DownsampleMean {
+		return nil, fmt.Errorf("unsupported downsampling method %q: must be %q or %q", method, DownsampleLTTB, DownsampleMean)
+	}
+	// LTTB always keeps the first and last points, so it needs at least three
+	if maxPoints < 3 {
+		return nil, fmt.Errorf("max_points must be at least 3, got %d", maxPoints)
+	}
+
+	result := make([]TimeSeriesData, len(series))
+	for i, ts := range series {
+		result[i] = ts
+		if len(ts.Values) <= maxPoints {
+			continue
+		}
+
+		points := make([]MetricValue, len(ts.Values))
+		copy(points, ts.Values)
+		// Cloud Monitoring returns the newest point first
+		descending := points[0].Timestamp.After(points[len(points)-1].Timestamp)
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].Timestamp.Before(points[j].Timestamp)
+		})
+
+		if method == DownsampleMean {
+			points = bucketMean(points, maxPoints)
+		} else {
+			points = lttb(points, maxPoints)
+		}
+
+		if descending {
+			for l, r := 0, len(points)-1; l < r; l, r = l+1, r-1 {
+				points[l], points[r] = points[r], points[l]
+			}
+		}
+		result[i].Values = points
+	}
+	return result, nil
+}
+
+// bucketMean splits ascending points into n buckets of consecutive points and
+// replaces each with its mean value at its mean timestamp
+func bucketMean(points []MetricValue, n int) []MetricValue {
+	sampled := make([]MetricValue, 0, n)
+	for i := range n {
+		bucket := points[i*len(points)/n : (i+1)*len(points)/n]
+		var sum float64
+		var nanos int64
+		base := bucket[0].Timestamp
+		for _, p := range bucket {
+			sum += p.Value
+			nanos += p.Timestamp.Sub(base).Nanoseconds()
+		}
+		sampled = append(sampled, MetricValue{
+			Value:     sum / float64(len(bucket)),
+			Timestamp: base.Add(time.Duration(nanos / int64(len(bucket)))),
+		})
+	}
+	return sampled
+}
+
+// lttb selects n of the ascending points with the Largest-Triangle-Three-Buckets
+// algorithm: the first and last points are always kept, and from each bucket in
+// between the point forming the largest triangle with the previously selected
+// point and the average of the next bucket is kept
+func lttb(points []MetricValue, n int) []MetricValue {
+	x := func(p MetricValue) float64 {
+		return float64(p.Timestamp.Sub(points[0].Timestamp))
+	}
+
+	sampled := make([]MetricValue, 0, n)
+	sampled = append(sampled, points[0])
+
+	// Buckets exclude the first and last points
+	bucketSize := float64(len(points)-2) / float64(n-2)
+	selected := 0
+	for i := range n - 2 {
+		from := int(float64(i)*bucketSize) + 1
+		to := int(float64(i+1)*bucketSize) + 1
+
+		// Average of the next bucket, which is the last point for the final bucket
+		nextFrom, nextTo := to, min(int(float64(i+2)*bucketSize)+1, len(points))
+		if i == n-3 {
+			nextFrom, nextTo = len(points)-1, len(points)
+		}
+		var avgX, avgY float64
+		for _, p := range points[nextFrom:nextTo] {
+			avgX += x(p)
+			avgY += p.Value
+		}
+		avgX /= float64(nextTo - nextFrom)
+		avgY /= float64(nextTo - nextFrom)
+
+		ax, ay := x(points[selected]), points[selected].Value
+		maxArea, maxIndex := -1.0, from
+		for j := from; j < to; j++ {
+			area := math.Abs((ax-avgX)*(points[j].Value-ay) - (ax-x(points[j]))*(avgY-ay))
+			if area > maxArea {
+				maxArea, maxIndex = area, j
+			}
+		}
+		sampled = append(sampled, points[maxIndex])
+		selected = maxIndex
+	}
+
+	return append(sampled, points[len(points)-1])
+}
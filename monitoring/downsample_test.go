@@ -0,0 +1,84 @@
+package monitoring
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// denseSeries returns n points one minute apart, newest first as Cloud Monitoring does,
+// with a single spike in the middle
+func denseSeries(n int) TimeSeriesData {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeriesData{MetricType: "custom.googleapis.com/latency"}
+	for i := n - 1; i >= 0; i-- {
+		value := math.Sin(float64(i) / 10)
+		if i == n/2 {
+			value = 100
+		}
+		ts.Values = append(ts.Values, MetricValue{Value: value, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	return ts
+}
+
+func TestDownsample(t *testing.T) {
+	dense := denseSeries(10000)
+	sparse := denseSeries(5)
+
+	for _, method := range []string{DownsampleLTTB, DownsampleMean} {
+		t.Run(method, func(t *testing.T) {
+			got, err := Downsample([]TimeSeriesData{dense, sparse}, 500, method)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			points := got[0].Values
+			if len(points) != 500 {
+				t.Fatalf("Expected 500 points, got %d", len(points))
+			}
+			for i := 1; i < len(points); i++ {
+				if !points[i].Timestamp.Before(points[i-1].Timestamp) {
+					t.Fatalf("Expected newest-first order to be preserved, got %v after %v", points[i].Timestamp, points[i-1].Timestamp)
+				}
+			}
+			if len(got[1].Values) != 5 {
+				t.Errorf("Expected short series to be unchanged, got %d points", len(got[1].Values))
+			}
+			if len(dense.Values) != 10000 {
+				t.Errorf("Expected input to be left untouched, got %d points", len(dense.Values))
+			}
+		})
+	}
+}
+
+func TestDownsample_LTTBKeepsShape(t *testing.T) {
+	dense := denseSeries(10000)
+	got, err := Downsample([]TimeSeriesData{dense}, 100, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	points := got[0].Values
+	if !points[0].Timestamp.Equal(dense.Values[0].Timestamp) || !points[len(points)-1].Timestamp.Equal(dense.Values[len(dense.Values)-1].Timestamp) {
+		t.Errorf("Expected first and last points to be kept")
+	}
+	var spike bool
+	for _, p := range points {
+		if p.Value == 100 {
+			spike = true
+		}
+	}
+	if !spike {
+		t.Error("Expected the spike to survive downsampling")
+	}
+}
+
+func TestDownsample_Errors(t *testing.T) {
+	series := []TimeSeriesData{denseSeries(10)}
+	if _, err := Downsample(series, 2, DownsampleLTTB); err == nil {
+		t.Error("Expected error for max_points below 3")
+	}
+	if _, err := Downsample(series, 5, "median"); err == nil {
+		t.Error("Expected error for unsupported method")
+	}
+}
@@ -0,0 +1,86 @@
+package monitoring
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+)
+
+// MetricKinds lists the kinds of metric descriptors that can be created
+var MetricKinds = []string{"GAUGE", "DELTA", "CUMULATIVE"}
+
+// ValueTypes lists the value types of metric descriptors that can be created
+var ValueTypes = []string{"BOOL", "INT64", "DOUBLE", "STRING", "DISTRIBUTION"}
+
+// Aligners lists the per-series aligners of aggregations, in the order of the
+// Cloud Monitoring API
+var Aligners = enumNames(monitoringpb.Aggregation_Aligner_name)
+
+// Reducers lists the cross-series reducers of aggregations, in the order of
+// the Cloud Monitoring API
+var Reducers = enumNames(monitoringpb.Aggregation_Reducer_name)
+
+// enumNames returns the names of a protobuf enum ordered by their numbers
+func enumNames(names map[int32]string) []string {
+	var result []string
+	for _, number := range slices.Sorted(maps.Keys(names)) {
+		result = append(result, names[number])
+	}
+	return result
+}
+
+// checkEnum returns an error listing the accepted values when value, if
+// given, is not one of them
+func checkEnum(name, value string, accepted []string) error {
+	if value == "" || slices.Contains(accepted, value) {
+		return nil
+	}
+	return fmt.Errorf("unsupported %s %q: must be one of %s", name, value, strings.Join(accepted, ", "))
+}
+
+// Validate checks the metric kind and value type, which default to GAUGE and
+// DOUBLE when not given
+func (d MetricDescriptor) Validate() error {
+	if err := checkEnum("metric kind", d.MetricKind, MetricKinds); err != nil {
+		return err
+	}
+	return checkEnum("value type", d.ValueType, ValueTypes)
+}
+
+// Validate checks the aligner and reducer of the aggregation. The aligner
+// defaults to ALIGN_MEAN and the reducer to none when not given.
+func (a AggregationConfig) Validate() error {
+	if err := checkEnum("per-series aligner", a.PerSeriesAligner, Aligners); err != nil {
+		return err
+	}
+	return checkEnum("cross-series reducer", a.CrossSeriesReducer, Reducers)
+}
+
+// Validate checks the metric kind of the series and the intervals of its
+// points. Points of CUMULATIVE and DELTA metrics need a start time before
+// their timestamp, which Cloud Monitoring requires; GAUGE points are
+// instants, so their start time is left out. The kind defaults to GAUGE.
+func (ts TimeSeriesData) Validate() error {
+	if err := checkEnum("metric kind", ts.MetricKind, MetricKinds); err != nil {
+		return err
+	}
+	for _, v := range ts.Values {
+		switch {
+		case ts.MetricKind == "CUMULATIVE" || ts.MetricKind == "DELTA":
+			if v.StartTime.IsZero() {
+				return fmt.Errorf("points of %s metrics need a start time", ts.MetricKind)
+			}
+			if !v.StartTime.Before(v.Timestamp) {
+				return fmt.Errorf("the start time %s of a %s point must be before its timestamp %s", v.StartTime.Format(time.RFC3339), ts.MetricKind, v.Timestamp.Format(time.RFC3339))
+			}
+		case !v.StartTime.IsZero() && !v.StartTime.Equal(v.Timestamp):
+			return errors.New("points of GAUGE metrics cannot have a start time; set metric kind to CUMULATIVE or DELTA")
+		}
+	}
+	return nil
+}
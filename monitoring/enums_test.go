@@ -0,0 +1,77 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAggregationConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		agg     AggregationConfig
+		wantErr string
+	}{
+		{name: "defaults", agg: AggregationConfig{}},
+		{name: "valid", agg: AggregationConfig{PerSeriesAligner: "ALIGN_PERCENTILE_99", CrossSeriesReducer: "REDUCE_PERCENTILE_95"}},
+		{name: "unknown aligner", agg: AggregationConfig{PerSeriesAligner: "ALIGN_AVG"}, wantErr: `unsupported per-series aligner "ALIGN_AVG"`},
+		{name: "unknown reducer", agg: AggregationConfig{CrossSeriesReducer: "sum"}, wantErr: `unsupported cross-series reducer "sum"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.agg.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetricDescriptor_Validate(t *testing.T) {
+	if err := (MetricDescriptor{MetricKind: "CUMULATIVE", ValueType: "INT64"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	err := MetricDescriptor{MetricKind: "GAUGE", ValueType: "FLOAT"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "must be one of BOOL, INT64, DOUBLE, STRING, DISTRIBUTION") {
+		t.Errorf("Validate() error = %v, want the accepted value types", err)
+	}
+}
+
+func TestTimeSeriesData_Validate(t *testing.T) {
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		ts      TimeSeriesData
+		wantErr string
+	}{
+		{name: "gauge", ts: TimeSeriesData{Values: []MetricValue{{Timestamp: end}}}},
+		{name: "gauge with equal start", ts: TimeSeriesData{MetricKind: "GAUGE", Values: []MetricValue{{StartTime: end, Timestamp: end}}}},
+		{name: "cumulative", ts: TimeSeriesData{MetricKind: "CUMULATIVE", Values: []MetricValue{{StartTime: end.Add(-time.Hour), Timestamp: end}}}},
+		{name: "delta without start", ts: TimeSeriesData{MetricKind: "DELTA", Values: []MetricValue{{Timestamp: end}}}, wantErr: "points of DELTA metrics need a start time"},
+		{name: "start after timestamp", ts: TimeSeriesData{MetricKind: "CUMULATIVE", Values: []MetricValue{{StartTime: end, Timestamp: end}}}, wantErr: "must be before its timestamp"},
+		{name: "gauge with start", ts: TimeSeriesData{Values: []MetricValue{{StartTime: end.Add(-time.Minute), Timestamp: end}}}, wantErr: "cannot have a start time"},
+		{name: "unknown kind", ts: TimeSeriesData{MetricKind: "COUNTER"}, wantErr: `unsupported metric kind "COUNTER"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ts.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAligners(t *testing.T) {
+	if Aligners[0] != "ALIGN_NONE" || Reducers[0] != "REDUCE_NONE" {
+		t.Errorf("Expected the enums in the order of the API, got %v and %v", Aligners[:1], Reducers[:1])
+	}
+}
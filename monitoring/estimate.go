@@ -0,0 +1,293 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// heavyQueryPoints is the number of points above which a query is
+	// reported as heavy, about what fits an agent's context comfortably
+	heavyQueryPoints = 10000
+	// targetPointsPerSeries is the number of points per series suggested
+	// alignment periods aim for
+	targetPointsPerSeries = 120
+	// maxSuggestedGroups bounds the series a suggested reduction returns
+	maxSuggestedGroups      = 20
+	defaultEstimatePageSize = 100
+	// maxEstimateSeries bounds the series counted for an estimate
+	maxEstimateSeries  = 10000
+	estimateCountPages = 1000
+)
+
+// suggestedPeriods are the alignment periods suggested, shortest first
+var suggestedPeriods = []time.Duration{
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// filterMetricType matches the metric type condition of a filter
+var filterMetricType = regexp.MustCompile(`metric\.type\s*=\s*"([^"]+)"`)
+
+// QueryCostRequest represents a list_time_series query to estimate
+type QueryCostRequest struct {
+	ProjectID   string             `json:"project_id,omitempty"` // defaults to the client's project
+	Filter      string             `json:"filter"`
+	StartTime   time.Time          `json:"start_time"`
+	EndTime     time.Time          `json:"end_time"`
+	Aggregation *AggregationConfig `json:"aggregation,omitempty"`
+	PageSize    int                `json:"page_size,omitempty"` // series per page, defaults to 100
+}
+
+// QueryCostEstimate represents how much data a time series query returns
+type QueryCostEstimate struct {
+	Filter          string `json:"filter"`
+	MetricKind      string `json:"metric_kind,omitempty"`
+	Series          int    `json:"series"`              // series matching the filter
+	Truncated       bool   `json:"truncated,omitempty"` // counting stopped at maxEstimateSeries
+	ReturnedSeries  int    `json:"returned_series"`     // after the cross-series reduction, if any
+	PointsPerSeries int    `json:"points_per_series"`   // of the densest returned series
+	Points          int    `json:"points"`
+	Pages           int    `json:"pages"` // list calls needed at the page size
+	Heavy           bool   `json:"heavy"`
+	// SuggestedAggregation returns far fewer points, set when the query is heavy
+	SuggestedAggregation *AggregationConfig `json:"suggested_aggregation,omitempty"`
+	SuggestedPoints      int                `json:"suggested_points,omitempty"`
+	Warnings             []string           `json:"warnings"`
+}
+
+// EstimateQueryCost estimates the series and points a time series query
+// returns before running it, from the number of raw points of each matching
+// series in the window. Heavy queries get a suggested aggregation that
+// returns fewer points.
+func (c *CloudMonitoringClient) EstimateQueryCost(ctx context.Context, req QueryCostRequest) (QueryCostEstimate, error) {
+	if req.Filter == "" {
+		return QueryCostEstimate{}, fmt.Errorf("filter is required")
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return QueryCostEstimate{}, fmt.Errorf("start_time must be before end_time")
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultEstimatePageSize
+	}
+	window := req.EndTime.Sub(req.StartTime)
+
+	var period time.Duration
+	aligned := req.Aggregation != nil && req.Aggregation.PerSeriesAligner != "" && req.Aggregation.PerSeriesAligner != "ALIGN_NONE"
+	if aligned {
+		var err error
+		period, err = time.ParseDuration(req.Aggregation.AlignmentPeriod)
+		if err != nil || period <= 0 {
+			return QueryCostEstimate{}, fmt.Errorf("invalid alignment_period %q: must be a duration like 60s", req.Aggregation.AlignmentPeriod)
+		}
+	}
+
+	// One point per series counting its raw points is enough to size the query
+	listReq := ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    req.Filter,
+		Aggregation: &AggregationConfig{
+			AlignmentPeriod:  windowPeriod(req.StartTime, req.EndTime),
+			PerSeriesAligner: "ALIGN_COUNT",
+		},
+		PageSize: estimateCountPages,
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	estimate := QueryCostEstimate{Filter: req.Filter, Warnings: []string{}}
+	var series []TimeSeriesData
+	rawPoints := map[int]int{} // index in series to its raw points
+	for {
+		resp, err := c.client.ListTimeSeries(ctx, listReq)
+		if err != nil {
+			return QueryCostEstimate{}, err
+		}
+		for _, ts := range resp.TimeSeries {
+			var count float64
+			for _, p := range ts.Values {
+				count += p.Value
+			}
+			rawPoints[len(series)] = int(count)
+			series = append(series, ts)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		if len(series) >= maxEstimateSeries {
+			estimate.Truncated = true
+			break
+		}
+		listReq.PageToken = resp.NextPageToken
+	}
+	estimate.Series = len(series)
+
+	if m := filterMetricType.FindStringSubmatch(req.Filter); m != nil {
+		descriptors, err := c.client.ListMetricDescriptors(ctx, ListMetricDescriptorsRequest{
+			ProjectID: req.ProjectID,
+			Filter:    fmt.Sprintf("metric.type = %q", m[1]),
+			PageSize:  1,
+		})
+		// The kind only picks the suggested aligner, so the estimate stands without it
+		if err == nil && len(descriptors.Descriptors) > 0 {
+			estimate.MetricKind = descriptors.Descriptors[0].MetricKind
+		}
+	}
+
+	periods := 0
+	if aligned {
+		periods = int((window + period - 1) / period)
+	}
+	if aligned && req.Aggregation.CrossSeriesReducer != "" && req.Aggregation.CrossSeriesReducer != "REDUCE_NONE" {
+		// Each group has a point in every period any of its series has one
+		groups := map[string]int{}
+		for i, ts := range series {
+			key := groupKey(ts, req.Aggregation.GroupByFields, i)
+			groups[key] = max(groups[key], min(rawPoints[i], periods))
+		}
+		estimate.ReturnedSeries = len(groups)
+		for _, points := range groups {
+			estimate.Points += points
+			estimate.PointsPerSeries = max(estimate.PointsPerSeries, points)
+		}
+	} else {
+		estimate.ReturnedSeries = len(series)
+		for i := range series {
+			points := rawPoints[i]
+			if aligned {
+				// Periods without raw points have no aligned point
+				points = min(points, periods)
+			}
+			estimate.Points += points
+			estimate.PointsPerSeries = max(estimate.PointsPerSeries, points)
+		}
+	}
+	estimate.Pages = max(1, (estimate.ReturnedSeries+req.PageSize-1)/req.PageSize)
+	estimate.Heavy = estimate.Points > heavyQueryPoints || estimate.Pages > 1
+
+	if estimate.Truncated {
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("Counting stopped at %d series; the query matches at least that many, so narrow the filter", estimate.Series))
+	}
+	if estimate.Points > heavyQueryPoints {
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("The query returns about %d points in %d series, over %d: aggregate it, shorten the window, or pass max_points or format=summary to list_time_series",
+			estimate.Points, estimate.ReturnedSeries, heavyQueryPoints))
+	}
+	if estimate.Pages > 1 {
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("The %d series returned need %d pages of %d: reduce them across series or narrow the filter",
+			estimate.ReturnedSeries, estimate.Pages, req.PageSize))
+	}
+	if estimate.Heavy {
+		estimate.SuggestedAggregation, estimate.SuggestedPoints = suggestAggregation(req, estimate.MetricKind, series, rawPoints)
+		if estimate.SuggestedPoints >= estimate.Points {
+			estimate.SuggestedAggregation, estimate.SuggestedPoints = nil, 0
+		}
+	}
+	return estimate, nil
+}
+
+// groupKey returns the values of the group by fields of a series. Series
+// are their own group for fields other than metric and resource labels.
+func groupKey(ts TimeSeriesData, fields []string, index int) string {
+	var values []string
+	for _, field := range fields {
+		value, ok := groupValue(ts, field)
+		if !ok {
+			return fmt.Sprintf("series %d", index)
+		}
+		values = append(values, field+"="+value)
+	}
+	return strings.Join(values, ",")
+}
+
+// groupValue returns the value of a metric or resource label field of a series
+func groupValue(ts TimeSeriesData, field string) (string, bool) {
+	for _, prefix := range []string{"metric.label.", "metric.labels."} {
+		if key, ok := strings.CutPrefix(field, prefix); ok {
+			return ts.MetricLabels[key], true
+		}
+	}
+	for _, prefix := range []string{"resource.label.", "resource.labels."} {
+		if key, ok := strings.CutPrefix(field, prefix); ok {
+			return ts.ResourceLabels[key], true
+		}
+	}
+	return "", false
+}
+
+// suggestAggregation returns an aggregation of the query aligning each series
+// to about targetPointsPerSeries points, and reducing the series across the
+// label with the most values up to maxSuggestedGroups when there are more
+// series than fit one page, with the points it returns
+func suggestAggregation(req QueryCostRequest, metricKind string, series []TimeSeriesData, rawPoints map[int]int) (*AggregationConfig, int) {
+	window := req.EndTime.Sub(req.StartTime)
+	period := suggestedPeriods[len(suggestedPeriods)-1]
+	for _, p := range suggestedPeriods {
+		if window/p <= targetPointsPerSeries {
+			period = p
+			break
+		}
+	}
+	if req.Aggregation != nil && req.Aggregation.AlignmentPeriod != "" {
+		if current, err := time.ParseDuration(req.Aggregation.AlignmentPeriod); err == nil && current > period {
+			period = current
+		}
+	}
+
+	aggregation := &AggregationConfig{
+		AlignmentPeriod:  fmt.Sprintf("%ds", int64(period.Seconds())),
+		PerSeriesAligner: "ALIGN_MEAN",
+	}
+	reducer := "REDUCE_MEAN"
+	if metricKind == "DELTA" || metricKind == "CUMULATIVE" {
+		aggregation.PerSeriesAligner = "ALIGN_RATE"
+		reducer = "REDUCE_SUM"
+	}
+	periods := int((window + period - 1) / period)
+
+	if len(series) <= req.PageSize {
+		var points int
+		for i := range series {
+			points += min(rawPoints[i], periods)
+		}
+		return aggregation, points
+	}
+
+	aggregation.CrossSeriesReducer = reducer
+	if field := groupByLabel(series); field != "" {
+		aggregation.GroupByFields = []string{field}
+	}
+	groups := map[string]int{}
+	for i, ts := range series {
+		key := groupKey(ts, aggregation.GroupByFields, i)
+		groups[key] = max(groups[key], min(rawPoints[i], periods))
+	}
+	var points int
+	for _, p := range groups {
+		points += p
+	}
+	return aggregation, points
+}
+
+// groupByLabel returns the label field with the most distinct values among
+// the series, but at most maxSuggestedGroups, or "" when no label has
+// several values
+func groupByLabel(series []TimeSeriesData) string {
+	values := make(map[string]map[string]bool)
+	for _, ts := range series {
+		addLabelValues(values, "metric.label.", ts.MetricLabels)
+		addLabelValues(values, "resource.label.", ts.ResourceLabels)
+	}
+	var best string
+	for _, field := range slices.Sorted(maps.Keys(values)) {
+		distinct := len(values[field])
+		if distinct > 1 && distinct <= maxSuggestedGroups && (best == "" || distinct > len(values[best])) {
+			best = field
+		}
+	}
+	return best
+}
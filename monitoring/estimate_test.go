@@ -0,0 +1,134 @@
+package monitoring_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudMonitoringClient_EstimateQueryCost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	start := end.Add(-24 * time.Hour)
+	filter := `metric.type="custom.googleapis.com/requests"`
+
+	// 150 series, one per user over two methods, with a point every minute
+	var series []monitoring.TimeSeriesData
+	for i := range 150 {
+		series = append(series, monitoring.TimeSeriesData{
+			MetricLabels:   map[string]string{"method": []string{"GET", "POST"}[i%2], "user_id": fmt.Sprintf("%08d", 10000000+i)},
+			ResourceLabels: map[string]string{"instance_id": "1234567890"},
+			Values:         []monitoring.MetricValue{{Value: 1440, Timestamp: end}},
+		})
+	}
+
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	mockClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			if req.Filter != filter {
+				t.Errorf("Unexpected filter %s", req.Filter)
+			}
+			if req.Aggregation == nil || req.Aggregation.AlignmentPeriod != "86400s" || req.Aggregation.PerSeriesAligner != "ALIGN_COUNT" {
+				t.Errorf("Expected the points of each series counted over the window, got %+v", req.Aggregation)
+			}
+			return monitoring.ListTimeSeriesResponse{TimeSeries: series}, nil
+		}).
+		Times(2)
+	mockClient.EXPECT().
+		ListMetricDescriptors(gomock.Any(), gomock.Any()).
+		Return(monitoring.ListMetricDescriptorsResponse{Descriptors: []monitoring.MetricDescriptor{{Type: "custom.googleapis.com/requests", MetricKind: "CUMULATIVE"}}}, nil).
+		Times(2)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	t.Run("raw points", func(t *testing.T) {
+		estimate, err := client.EstimateQueryCost(context.Background(), monitoring.QueryCostRequest{Filter: filter, StartTime: start, EndTime: end})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if estimate.Series != 150 || estimate.ReturnedSeries != 150 || estimate.Points != 216000 || estimate.PointsPerSeries != 1440 || estimate.Pages != 2 {
+			t.Errorf("Unexpected estimate %+v", estimate)
+		}
+		if !estimate.Heavy || len(estimate.Warnings) != 2 {
+			t.Errorf("Expected a heavy query with two warnings, got %v", estimate.Warnings)
+		}
+
+		// 15 minutes is the shortest period giving at most 120 points a day,
+		// and the users are reduced away keeping the methods
+		want := &monitoring.AggregationConfig{
+			AlignmentPeriod:    "900s",
+			PerSeriesAligner:   "ALIGN_RATE",
+			CrossSeriesReducer: "REDUCE_SUM",
+			GroupByFields:      []string{"metric.label.method"},
+		}
+		if !reflect.DeepEqual(estimate.SuggestedAggregation, want) {
+			t.Errorf("Expected the suggested aggregation %+v, got %+v", want, estimate.SuggestedAggregation)
+		}
+		if estimate.SuggestedPoints != 192 {
+			t.Errorf("Expected 96 points for each of 2 methods, got %d", estimate.SuggestedPoints)
+		}
+	})
+
+	t.Run("aggregated", func(t *testing.T) {
+		estimate, err := client.EstimateQueryCost(context.Background(), monitoring.QueryCostRequest{
+			Filter:    filter,
+			StartTime: start,
+			EndTime:   end,
+			Aggregation: &monitoring.AggregationConfig{
+				AlignmentPeriod:    "3600s",
+				PerSeriesAligner:   "ALIGN_RATE",
+				CrossSeriesReducer: "REDUCE_SUM",
+				GroupByFields:      []string{"metric.labels.method", "resource.label.instance_id"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if estimate.ReturnedSeries != 2 || estimate.Points != 48 || estimate.Pages != 1 {
+			t.Errorf("Expected 24 points for each of 2 groups, got %+v", estimate)
+		}
+		if estimate.Heavy || estimate.SuggestedAggregation != nil || len(estimate.Warnings) != 0 {
+			t.Errorf("Expected a light query, got %+v", estimate)
+		}
+	})
+}
+
+func TestCloudMonitoringClient_EstimateQueryCost_InvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := monitoring.NewWithClient(mocks.NewMockMonitoringClientInterface(ctrl), "test-project")
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		req  monitoring.QueryCostRequest
+	}{
+		{name: "empty window", req: monitoring.QueryCostRequest{Filter: `metric.type="a"`, StartTime: end, EndTime: end}},
+		{name: "no filter", req: monitoring.QueryCostRequest{StartTime: end.Add(-time.Hour), EndTime: end}},
+		{
+			name: "invalid alignment period",
+			req: monitoring.QueryCostRequest{
+				Filter:      `metric.type="a"`,
+				StartTime:   end.Add(-time.Hour),
+				EndTime:     end,
+				Aggregation: &monitoring.AggregationConfig{AlignmentPeriod: "an hour", PerSeriesAligner: "ALIGN_MEAN"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.EstimateQueryCost(context.Background(), tt.req); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Forecast models
+const (
+	// ForecastLinear fits a least-squares line through the points
+	ForecastLinear = "linear"
+	// ForecastHoltWinters applies additive Holt-Winters exponential smoothing,
+	// or Holt's linear trend method when no season is given
+	ForecastHoltWinters = "holt_winters"
+)
+
+// Forecast directions
+const (
+	DirectionRising  = "rising"
+	DirectionFalling = "falling"
+)
+
+// Forecast confidence levels
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+const (
+	defaultForecastHorizon = 30 * 24 * time.Hour
+	maxForecastSteps       = 100000
+	// forecastZ is the two-sided 95% normal quantile used for crossing bounds
+	forecastZ = 1.96
+)
+
+// ForecastOptions configures a forecast
+type ForecastOptions struct {
+	Model     string        `json:"model,omitempty"`   // ForecastLinear (default) or ForecastHoltWinters
+	Threshold float64       `json:"threshold"`         // value whose crossing is projected
+	Horizon   time.Duration `json:"horizon,omitempty"` // how far past the last point to look, default 30 days
+	// Season is the length of one seasonal cycle (e.g., 24h) for ForecastHoltWinters.
+	// The history should span at least two cycles.
+	Season time.Duration `json:"season,omitempty"`
+	// Direction is whether the crossing of interest is DirectionRising above the
+	// threshold (e.g., disk usage) or DirectionFalling below it (e.g., free memory).
+	// It defaults to the side of the threshold the last value is on.
+	Direction string `json:"direction,omitempty"`
+}
+
+// ForecastResult describes when a series is projected to cross a threshold
+type ForecastResult struct {
+	Model        string    `json:"model"`
+	Points       int       `json:"points"`
+	Threshold    float64   `json:"threshold"`
+	Direction    string    `json:"direction"`
+	LastValue    float64   `json:"last_value"`
+	LastTime     time.Time `json:"last_time"`
+	TrendPerHour float64   `json:"trend_per_hour"`
+	// AlreadyCrossed is set when the last value is already past the threshold
+	AlreadyCrossed bool `json:"already_crossed,omitempty"`
+	// Crossing is the projected crossing time, nil when it is not reached within the horizon
+	Crossing *time.Time `json:"crossing,omitempty"`
+	// CrossingEarliest and CrossingLatest bound the crossing at 95% confidence;
+	// a nil CrossingLatest means the crossing may never happen
+	CrossingEarliest *time.Time `json:"crossing_earliest,omitempty"`
+	CrossingLatest   *time.Time `json:"crossing_latest,omitempty"`
+	// RSquared is the share of the variance the model explains
+	RSquared   float64 `json:"r_squared"`
+	Confidence string  `json:"confidence"`
+}
+
+// ForecastSeries projects when the points of a series cross opts.Threshold
+func ForecastSeries(values []MetricValue, opts ForecastOptions) (ForecastResult, error) {
+	if opts.Model == "" {
+		opts.Model = ForecastLinear
+	}
+	if opts.Horizon <= 0 {
+		opts.Horizon = defaultForecastHorizon
+	}
+	if len(values) < 3 {
+		return ForecastResult{}, fmt.Errorf("at least 3 points are required to forecast, got %d", len(values))
+	}
+
+	points := make([]MetricValue, len(values))
+	copy(points, values)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	last := points[len(points)-1]
+	if !last.Timestamp.After(points[0].Timestamp) {
+		return ForecastResult{}, fmt.Errorf("points must span a time range")
+	}
+
+	result := ForecastResult{
+		Model:     opts.Model,
+		Points:    len(points),
+		Threshold: opts.Threshold,
+		Direction: opts.Direction,
+		LastValue: last.Value,
+		LastTime:  last.Timestamp,
+	}
+	switch opts.Direction {
+	case "":
+		result.Direction = DirectionRising
+		if opts.Threshold < last.Value {
+			result.Direction = DirectionFalling
+		}
+	case DirectionRising, DirectionFalling:
+	default:
+		return ForecastResult{}, fmt.Errorf("unsupported direction %q: must be %q or %q", opts.Direction, DirectionRising, DirectionFalling)
+	}
+	rising := result.Direction == DirectionRising
+
+	var err error
+	switch opts.Model {
+	case ForecastLinear:
+		forecastLinear(points, opts, rising, &result)
+	case ForecastHoltWinters:
+		err = forecastHoltWinters(points, opts, rising, &result)
+	default:
+		err = fmt.Errorf("unsupported model %q: must be %q or %q", opts.Model, ForecastLinear, ForecastHoltWinters)
+	}
+	if err != nil {
+		return ForecastResult{}, err
+	}
+
+	if (rising && last.Value >= opts.Threshold) || (!rising && last.Value <= opts.Threshold) {
+		result.AlreadyCrossed = true
+		result.Crossing, result.CrossingEarliest, result.CrossingLatest = nil, nil, nil
+	}
+
+	switch {
+	case result.RSquared >= 0.9 && (result.AlreadyCrossed || result.CrossingLatest != nil):
+		result.Confidence = ConfidenceHigh
+	case result.RSquared >= 0.6:
+		result.Confidence = ConfidenceMedium
+	default:
+		result.Confidence = ConfidenceLow
+	}
+	return result, nil
+}
+
+// forecastLinear fits y = a + b*x by least squares, with x in seconds since the
+// first point, and bounds the crossing with the 95% interval of the slope
+func forecastLinear(points []MetricValue, opts ForecastOptions, rising bool, result *ForecastResult) {
+	n := float64(len(points))
+	xs := make([]float64, len(points))
+	var meanX, meanY float64
+	for i, p := range points {
+		xs[i] = p.Timestamp.Sub(points[0].Timestamp).Seconds()
+		meanX += xs[i] / n
+		meanY += p.Value / n
+	}
+
+	var sxx, sxy, sst float64
+	for i, p := range points {
+		sxx += (xs[i] - meanX) * (xs[i] - meanX)
+		sxy += (xs[i] - meanX) * (p.Value - meanY)
+		sst += (p.Value - meanY) * (p.Value - meanY)
+	}
+	slope := sxy / sxx
+
+	var sse float64
+	for i, p := range points {
+		residual := p.Value - (meanY + slope*(xs[i]-meanX))
+		sse += residual * residual
+	}
+	result.RSquared = rSquared(sse, sst)
+	result.TrendPerHour = slope * 3600
+	slopeErr := math.Sqrt(sse / (n - 2) / sxx)
+
+	lastX := xs[len(xs)-1]
+	limit := lastX + opts.Horizon.Seconds()
+	// crossing returns when the line through the mean point with slope b reaches the threshold
+	crossing := func(b float64) *time.Time {
+		if (rising && b <= 0) || (!rising && b >= 0) {
+			return nil
+		}
+		x := meanX + (opts.Threshold-meanY)/b
+		if x > limit {
+			return nil
+		}
+		t := points[0].Timestamp.Add(time.Duration(math.Max(x, lastX) * float64(time.Second)))
+		return &t
+	}
+
+	steeper, shallower := slope+forecastZ*slopeErr, slope-forecastZ*slopeErr
+	if !rising {
+		steeper, shallower = shallower, steeper
+	}
+	result.Crossing = crossing(slope)
+	result.CrossingEarliest = crossing(steeper)
+	result.CrossingLatest = crossing(shallower)
+
+	// The fitted line may already be past the threshold even though the last point is not
+	if fitted := meanY + slope*(lastX-meanX); (rising && fitted >= opts.Threshold) || (!rising && fitted <= opts.Threshold) {
+		result.Crossing = &result.LastTime
+	}
+}
+
+// forecastHoltWinters runs additive Holt-Winters smoothing over points assumed
+// to be evenly spaced, choosing the smoothing factors with the lowest one-step
+// error, and steps the forecast forward until it crosses the threshold
+func forecastHoltWinters(points []MetricValue, opts ForecastOptions, rising bool, result *ForecastResult) error {
+	step := points[len(points)-1].Timestamp.Sub(points[0].Timestamp) / time.Duration(len(points)-1)
+	if step <= 0 {
+		return fmt.Errorf("points must be evenly spaced")
+	}
+	season := 0
+	if opts.Season > 0 {
+		season = int(math.Round(float64(opts.Season) / float64(step)))
+		if season < 2 || len(points) < 2*season {
+			return fmt.Errorf("a season of %s needs at least two full cycles of history, got %d points every %s", opts.Season, len(points), step)
+		}
+	}
+
+	ys := make([]float64, len(points))
+	var meanY float64
+	for i, p := range points {
+		ys[i] = p.Value
+		meanY += p.Value / float64(len(ys))
+	}
+	var sst float64
+	for _, y := range ys {
+		sst += (y - meanY) * (y - meanY)
+	}
+
+	factors := []float64{0.1, 0.3, 0.5, 0.7, 0.9}
+	gammas := []float64{0}
+	if season > 0 {
+		gammas = factors
+	}
+	var best *holtWinters
+	for _, alpha := range factors {
+		for _, beta := range factors {
+			for _, gamma := range gammas {
+				hw := fitHoltWinters(ys, season, alpha, beta, gamma)
+				if best == nil || hw.sse < best.sse {
+					best = hw
+				}
+			}
+		}
+	}
+
+	result.RSquared = rSquared(best.sse, sst)
+	result.TrendPerHour = best.trend / step.Hours()
+	rmse := math.Sqrt(best.sse / float64(best.residuals))
+
+	steps := min(int(opts.Horizon/step), maxForecastSteps)
+	crossed := func(v float64) bool {
+		return (rising && v >= opts.Threshold) || (!rising && v <= opts.Threshold)
+	}
+	at := func(h int) *time.Time {
+		t := result.LastTime.Add(time.Duration(h) * step)
+		return &t
+	}
+	for h := 1; h <= steps && result.CrossingLatest == nil; h++ {
+		forecast := best.forecast(h)
+		band := forecastZ * rmse * math.Sqrt(float64(h))
+		if !rising {
+			band = -band
+		}
+		if result.CrossingEarliest == nil && crossed(forecast+band) {
+			result.CrossingEarliest = at(h)
+		}
+		if result.Crossing == nil && crossed(forecast) {
+			result.Crossing = at(h)
+		}
+		if crossed(forecast - band) {
+			result.CrossingLatest = at(h)
+		}
+	}
+	return nil
+}
+
+// holtWinters is the state of additive Holt-Winters smoothing after the last point
+type holtWinters struct {
+	level, trend float64
+	seasonal     []float64 // indexed by point index modulo the season length
+	n            int
+	sse          float64 // sum of squared one-step errors
+	residuals    int
+}
+
+// forecast returns the value projected h steps after the last point
+func (hw *holtWinters) forecast(h int) float64 {
+	v := hw.level + float64(h)*hw.trend
+	if len(hw.seasonal) > 0 {
+		v += hw.seasonal[(hw.n-1+h)%len(hw.seasonal)]
+	}
+	return v
+}
+
+func fitHoltWinters(ys []float64, season int, alpha, beta, gamma float64) *holtWinters {
+	hw := &holtWinters{n: len(ys)}
+	start := 1
+	if season > 0 {
+		// Initialize from the first two cycles
+		var first, second float64
+		for i := range season {
+			first += ys[i] / float64(season)
+			second += ys[season+i] / float64(season)
+		}
+		hw.level = first
+		hw.trend = (second - first) / float64(season)
+		hw.seasonal = make([]float64, season)
+		for i := range season {
+			// Deviation from the trend line through the middle of the first cycle
+			hw.seasonal[i] = ys[i] - (first + hw.trend*(float64(i)-float64(season-1)/2))
+		}
+		start = season
+		// The level stands for the middle of the first cycle; move it to its end
+		hw.level += hw.trend * float64(season-1) / 2
+	} else {
+		hw.level = ys[0]
+		hw.trend = ys[1] - ys[0]
+	}
+
+	for i := start; i < len(ys); i++ {
+		var s float64
+		if season > 0 {
+			s = hw.seasonal[i%season]
+		}
+		predicted := hw.level + hw.trend + s
+		hw.sse += (ys[i] - predicted) * (ys[i] - predicted)
+		hw.residuals++
+
+		prevLevel := hw.level
+		hw.level = alpha*(ys[i]-s) + (1-alpha)*(hw.level+hw.trend)
+		hw.trend = beta*(hw.level-prevLevel) + (1-beta)*hw.trend
+		if season > 0 {
+			hw.seasonal[i%season] = gamma*(ys[i]-hw.level) + (1-gamma)*s
+		}
+	}
+	return hw
+}
+
+// rSquared returns 1 - SSE/SST, treating a constant series as perfectly explained
+func rSquared(sse, sst float64) float64 {
+	if sst == 0 {
+		return 1
+	}
+	return math.Max(0, 1-sse/sst)
+}
@@ -0,0 +1,157 @@
+package monitoring
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var forecastStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// hourly returns one point per hour, newest first, with values from f
+func hourly(hours int, f func(h int) float64) []MetricValue {
+	var values []MetricValue
+	for h := hours - 1; h >= 0; h-- {
+		values = append(values, MetricValue{Value: f(h), Timestamp: forecastStart.Add(time.Duration(h) * time.Hour)})
+	}
+	return values
+}
+
+func within(t *testing.T, name string, got *time.Time, want time.Time, tolerance time.Duration) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("Expected %s around %v, got nil", name, want)
+	}
+	if d := got.Sub(want); d < -tolerance || d > tolerance {
+		t.Errorf("Expected %s around %v, got %v", name, want, *got)
+	}
+}
+
+func TestForecastSeries_Linear(t *testing.T) {
+	// Disk usage grows 1% per hour with a little noise
+	values := hourly(48, func(h int) float64 { return float64(h) + 0.5*math.Sin(float64(h)) })
+
+	got, err := ForecastSeries(values, ForecastOptions{Threshold: 100})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Direction != "rising" || got.AlreadyCrossed {
+		t.Errorf("Unexpected direction %+v", got)
+	}
+	if math.Abs(got.TrendPerHour-1) > 0.05 {
+		t.Errorf("Expected trend of about 1 per hour, got %v", got.TrendPerHour)
+	}
+	within(t, "crossing", got.Crossing, forecastStart.Add(100*time.Hour), 3*time.Hour)
+	if got.CrossingEarliest == nil || got.CrossingLatest == nil ||
+		got.CrossingEarliest.After(*got.Crossing) || got.CrossingLatest.Before(*got.Crossing) {
+		t.Errorf("Expected bounds around the crossing, got %v - %v", got.CrossingEarliest, got.CrossingLatest)
+	}
+	if got.Confidence != ConfidenceHigh {
+		t.Errorf("Expected high confidence, got %s (r² %v)", got.Confidence, got.RSquared)
+	}
+}
+
+func TestForecastSeries_LinearNoCrossing(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []MetricValue
+		opts   ForecastOptions
+	}{
+		{
+			name:   "trend away from threshold",
+			values: hourly(24, func(h int) float64 { return 50 - float64(h) }),
+			opts:   ForecastOptions{Threshold: 100},
+		},
+		{
+			name:   "beyond horizon",
+			values: hourly(24, func(h int) float64 { return float64(h) }),
+			opts:   ForecastOptions{Threshold: 1000, Horizon: 24 * time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ForecastSeries(tt.values, tt.opts)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got.Crossing != nil {
+				t.Errorf("Expected no crossing, got %v", *got.Crossing)
+			}
+		})
+	}
+}
+
+func TestForecastSeries_FallingAndCrossed(t *testing.T) {
+	// Free memory falls 2 per hour
+	values := hourly(24, func(h int) float64 { return 100 - 2*float64(h) })
+
+	got, err := ForecastSeries(values, ForecastOptions{Threshold: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Direction != "falling" {
+		t.Errorf("Expected falling direction, got %s", got.Direction)
+	}
+	within(t, "crossing", got.Crossing, forecastStart.Add(45*time.Hour), time.Hour)
+
+	got, err = ForecastSeries(values, ForecastOptions{Threshold: 80, Direction: DirectionFalling})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !got.AlreadyCrossed || got.Crossing != nil {
+		t.Errorf("Expected threshold to be already crossed, got %+v", got)
+	}
+}
+
+func TestForecastSeries_HoltWinters(t *testing.T) {
+	// Daily cycle of ±10 on top of growth of 0.5 per hour
+	values := hourly(24*7, func(h int) float64 {
+		return 0.5*float64(h) + 10*math.Sin(2*math.Pi*float64(h)/24)
+	})
+
+	got, err := ForecastSeries(values, ForecastOptions{Model: ForecastHoltWinters, Threshold: 150, Season: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if math.Abs(got.TrendPerHour-0.5) > 0.1 {
+		t.Errorf("Expected trend of about 0.5 per hour, got %v", got.TrendPerHour)
+	}
+	// The rising edge of the daily cycle reaches 150 at hour 290, ten hours before the trend alone does
+	within(t, "crossing", got.Crossing, forecastStart.Add(290*time.Hour), time.Hour)
+	if got.RSquared < 0.9 {
+		t.Errorf("Expected a good fit, got r² %v", got.RSquared)
+	}
+
+	// Holt's linear trend without a season
+	got, err = ForecastSeries(hourly(48, func(h int) float64 { return float64(h) }), ForecastOptions{Model: ForecastHoltWinters, Threshold: 100})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	within(t, "crossing", got.Crossing, forecastStart.Add(100*time.Hour), time.Hour)
+}
+
+func TestForecastSeries_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []MetricValue
+		opts   ForecastOptions
+	}{
+		{name: "too few points", values: hourly(2, func(h int) float64 { return 1 }), opts: ForecastOptions{Threshold: 10}},
+		{name: "unsupported direction", values: hourly(10, func(h int) float64 { return 1 }), opts: ForecastOptions{Threshold: 10, Direction: "up"}},
+		{name: "unsupported model", values: hourly(10, func(h int) float64 { return 1 }), opts: ForecastOptions{Model: "arima", Threshold: 10}},
+		{
+			name:   "season longer than history",
+			values: hourly(30, func(h int) float64 { return 1 }),
+			opts:   ForecastOptions{Model: ForecastHoltWinters, Threshold: 10, Season: 24 * time.Hour},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ForecastSeries(tt.values, tt.opts); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
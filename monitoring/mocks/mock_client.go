@@ -41,6 +41,81 @@ func (m *MockMonitoringClient) EXPECT() *MockMonitoringClientMockRecorder {
 	return m.recorder
 }
 
+// AnalyzeMetricCardinality mocks base method.
+func (m *MockMonitoringClient) AnalyzeMetricCardinality(ctx context.Context, req monitoring.MetricCardinalityRequest) (monitoring.MetricCardinality, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnalyzeMetricCardinality", ctx, req)
+	ret0, _ := ret[0].(monitoring.MetricCardinality)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnalyzeMetricCardinality indicates an expected call of AnalyzeMetricCardinality.
+func (mr *MockMonitoringClientMockRecorder) AnalyzeMetricCardinality(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnalyzeMetricCardinality", reflect.TypeOf((*MockMonitoringClient)(nil).AnalyzeMetricCardinality), ctx, req)
+}
+
+// ApplyAlertPolicy mocks base method.
+func (m *MockMonitoringClient) ApplyAlertPolicy(ctx context.Context, req monitoring.ApplyRequest) (monitoring.ApplyResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyAlertPolicy", ctx, req)
+	ret0, _ := ret[0].(monitoring.ApplyResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyAlertPolicy indicates an expected call of ApplyAlertPolicy.
+func (mr *MockMonitoringClientMockRecorder) ApplyAlertPolicy(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyAlertPolicy", reflect.TypeOf((*MockMonitoringClient)(nil).ApplyAlertPolicy), ctx, req)
+}
+
+// ApplyDashboard mocks base method.
+func (m *MockMonitoringClient) ApplyDashboard(ctx context.Context, req monitoring.ApplyRequest) (monitoring.ApplyResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyDashboard", ctx, req)
+	ret0, _ := ret[0].(monitoring.ApplyResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyDashboard indicates an expected call of ApplyDashboard.
+func (mr *MockMonitoringClientMockRecorder) ApplyDashboard(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyDashboard", reflect.TypeOf((*MockMonitoringClient)(nil).ApplyDashboard), ctx, req)
+}
+
+// CloneMetricDescriptor mocks base method.
+func (m *MockMonitoringClient) CloneMetricDescriptor(ctx context.Context, req monitoring.CloneMetricDescriptorRequest) (monitoring.CloneMetricDescriptorResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneMetricDescriptor", ctx, req)
+	ret0, _ := ret[0].(monitoring.CloneMetricDescriptorResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloneMetricDescriptor indicates an expected call of CloneMetricDescriptor.
+func (mr *MockMonitoringClientMockRecorder) CloneMetricDescriptor(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneMetricDescriptor", reflect.TypeOf((*MockMonitoringClient)(nil).CloneMetricDescriptor), ctx, req)
+}
+
+// CompareMetricDescriptors mocks base method.
+func (m *MockMonitoringClient) CompareMetricDescriptors(ctx context.Context, req monitoring.CompareMetricDescriptorsRequest) (monitoring.CompareMetricDescriptorsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareMetricDescriptors", ctx, req)
+	ret0, _ := ret[0].(monitoring.CompareMetricDescriptorsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareMetricDescriptors indicates an expected call of CompareMetricDescriptors.
+func (mr *MockMonitoringClientMockRecorder) CompareMetricDescriptors(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareMetricDescriptors", reflect.TypeOf((*MockMonitoringClient)(nil).CompareMetricDescriptors), ctx, req)
+}
+
 // CreateMetricDescriptor mocks base method.
 func (m *MockMonitoringClient) CreateMetricDescriptor(ctx context.Context, req monitoring.CreateMetricRequest) error {
 	m.ctrl.T.Helper()
@@ -69,6 +144,141 @@ func (mr *MockMonitoringClientMockRecorder) DeleteMetricDescriptor(ctx, metricTy
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMetricDescriptor", reflect.TypeOf((*MockMonitoringClient)(nil).DeleteMetricDescriptor), ctx, metricType)
 }
 
+// DeleteMetricDescriptors mocks base method.
+func (m *MockMonitoringClient) DeleteMetricDescriptors(ctx context.Context, req monitoring.DeleteMetricDescriptorsRequest) (monitoring.DeleteMetricDescriptorsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMetricDescriptors", ctx, req)
+	ret0, _ := ret[0].(monitoring.DeleteMetricDescriptorsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMetricDescriptors indicates an expected call of DeleteMetricDescriptors.
+func (mr *MockMonitoringClientMockRecorder) DeleteMetricDescriptors(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMetricDescriptors", reflect.TypeOf((*MockMonitoringClient)(nil).DeleteMetricDescriptors), ctx, req)
+}
+
+// DescribeResource mocks base method.
+func (m *MockMonitoringClient) DescribeResource(ctx context.Context, req monitoring.DescribeResourceRequest) (monitoring.DescribeResourceResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeResource", ctx, req)
+	ret0, _ := ret[0].(monitoring.DescribeResourceResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeResource indicates an expected call of DescribeResource.
+func (mr *MockMonitoringClientMockRecorder) DescribeResource(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeResource", reflect.TypeOf((*MockMonitoringClient)(nil).DescribeResource), ctx, req)
+}
+
+// EstimateQueryCost mocks base method.
+func (m *MockMonitoringClient) EstimateQueryCost(ctx context.Context, req monitoring.QueryCostRequest) (monitoring.QueryCostEstimate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimateQueryCost", ctx, req)
+	ret0, _ := ret[0].(monitoring.QueryCostEstimate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EstimateQueryCost indicates an expected call of EstimateQueryCost.
+func (mr *MockMonitoringClientMockRecorder) EstimateQueryCost(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimateQueryCost", reflect.TypeOf((*MockMonitoringClient)(nil).EstimateQueryCost), ctx, req)
+}
+
+// FindUnusedMetrics mocks base method.
+func (m *MockMonitoringClient) FindUnusedMetrics(ctx context.Context, req monitoring.FindUnusedMetricsRequest) (monitoring.FindUnusedMetricsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUnusedMetrics", ctx, req)
+	ret0, _ := ret[0].(monitoring.FindUnusedMetricsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUnusedMetrics indicates an expected call of FindUnusedMetrics.
+func (mr *MockMonitoringClientMockRecorder) FindUnusedMetrics(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUnusedMetrics", reflect.TypeOf((*MockMonitoringClient)(nil).FindUnusedMetrics), ctx, req)
+}
+
+// GetQuotaUsage mocks base method.
+func (m *MockMonitoringClient) GetQuotaUsage(ctx context.Context, req monitoring.QuotaUsageRequest) (monitoring.QuotaUsageResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuotaUsage", ctx, req)
+	ret0, _ := ret[0].(monitoring.QuotaUsageResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuotaUsage indicates an expected call of GetQuotaUsage.
+func (mr *MockMonitoringClientMockRecorder) GetQuotaUsage(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuotaUsage", reflect.TypeOf((*MockMonitoringClient)(nil).GetQuotaUsage), ctx, req)
+}
+
+// GetServiceLevelObjective mocks base method.
+func (m *MockMonitoringClient) GetServiceLevelObjective(ctx context.Context, name string) (monitoring.ServiceLevelObjective, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceLevelObjective", ctx, name)
+	ret0, _ := ret[0].(monitoring.ServiceLevelObjective)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceLevelObjective indicates an expected call of GetServiceLevelObjective.
+func (mr *MockMonitoringClientMockRecorder) GetServiceLevelObjective(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceLevelObjective", reflect.TypeOf((*MockMonitoringClient)(nil).GetServiceLevelObjective), ctx, name)
+}
+
+// LintAlertPolicies mocks base method.
+func (m *MockMonitoringClient) LintAlertPolicies(ctx context.Context, req monitoring.LintAlertPoliciesRequest) (monitoring.LintAlertPoliciesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LintAlertPolicies", ctx, req)
+	ret0, _ := ret[0].(monitoring.LintAlertPoliciesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LintAlertPolicies indicates an expected call of LintAlertPolicies.
+func (mr *MockMonitoringClientMockRecorder) LintAlertPolicies(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LintAlertPolicies", reflect.TypeOf((*MockMonitoringClient)(nil).LintAlertPolicies), ctx, req)
+}
+
+// ListAlertPolicies mocks base method.
+func (m *MockMonitoringClient) ListAlertPolicies(ctx context.Context, req monitoring.ListAlertPoliciesRequest) ([]monitoring.AlertPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAlertPolicies", ctx, req)
+	ret0, _ := ret[0].([]monitoring.AlertPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAlertPolicies indicates an expected call of ListAlertPolicies.
+func (mr *MockMonitoringClientMockRecorder) ListAlertPolicies(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAlertPolicies", reflect.TypeOf((*MockMonitoringClient)(nil).ListAlertPolicies), ctx, req)
+}
+
+// ListAlerts mocks base method.
+func (m *MockMonitoringClient) ListAlerts(ctx context.Context, req monitoring.ListAlertsRequest) (monitoring.ListAlertsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAlerts", ctx, req)
+	ret0, _ := ret[0].(monitoring.ListAlertsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAlerts indicates an expected call of ListAlerts.
+func (mr *MockMonitoringClientMockRecorder) ListAlerts(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAlerts", reflect.TypeOf((*MockMonitoringClient)(nil).ListAlerts), ctx, req)
+}
+
 // ListAvailableMetrics mocks base method.
 func (m *MockMonitoringClient) ListAvailableMetrics(ctx context.Context, req monitoring.ListAvailableMetricsRequest) ([]monitoring.AvailableMetric, error) {
 	m.ctrl.T.Helper()
@@ -84,6 +294,21 @@ func (mr *MockMonitoringClientMockRecorder) ListAvailableMetrics(ctx, req any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAvailableMetrics", reflect.TypeOf((*MockMonitoringClient)(nil).ListAvailableMetrics), ctx, req)
 }
 
+// ListDashboards mocks base method.
+func (m *MockMonitoringClient) ListDashboards(ctx context.Context, req monitoring.ListDashboardsRequest) ([]monitoring.Dashboard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDashboards", ctx, req)
+	ret0, _ := ret[0].([]monitoring.Dashboard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDashboards indicates an expected call of ListDashboards.
+func (mr *MockMonitoringClientMockRecorder) ListDashboards(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDashboards", reflect.TypeOf((*MockMonitoringClient)(nil).ListDashboards), ctx, req)
+}
+
 // ListMetricDescriptors mocks base method.
 func (m *MockMonitoringClient) ListMetricDescriptors(ctx context.Context, req monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
 	m.ctrl.T.Helper()
@@ -99,6 +324,21 @@ func (mr *MockMonitoringClientMockRecorder) ListMetricDescriptors(ctx, req any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMetricDescriptors", reflect.TypeOf((*MockMonitoringClient)(nil).ListMetricDescriptors), ctx, req)
 }
 
+// ListMonitoredResources mocks base method.
+func (m *MockMonitoringClient) ListMonitoredResources(ctx context.Context, req monitoring.ListMonitoredResourcesRequest) (monitoring.ListMonitoredResourcesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMonitoredResources", ctx, req)
+	ret0, _ := ret[0].(monitoring.ListMonitoredResourcesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMonitoredResources indicates an expected call of ListMonitoredResources.
+func (mr *MockMonitoringClientMockRecorder) ListMonitoredResources(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMonitoredResources", reflect.TypeOf((*MockMonitoringClient)(nil).ListMonitoredResources), ctx, req)
+}
+
 // ListTimeSeries mocks base method.
 func (m *MockMonitoringClient) ListTimeSeries(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
 	m.ctrl.T.Helper()
@@ -114,6 +354,36 @@ func (mr *MockMonitoringClientMockRecorder) ListTimeSeries(ctx, req any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTimeSeries", reflect.TypeOf((*MockMonitoringClient)(nil).ListTimeSeries), ctx, req)
 }
 
+// SearchMetrics mocks base method.
+func (m *MockMonitoringClient) SearchMetrics(ctx context.Context, req monitoring.SearchMetricsRequest) ([]monitoring.MetricSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchMetrics", ctx, req)
+	ret0, _ := ret[0].([]monitoring.MetricSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchMetrics indicates an expected call of SearchMetrics.
+func (mr *MockMonitoringClientMockRecorder) SearchMetrics(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMetrics", reflect.TypeOf((*MockMonitoringClient)(nil).SearchMetrics), ctx, req)
+}
+
+// SimulateBurnRate mocks base method.
+func (m *MockMonitoringClient) SimulateBurnRate(ctx context.Context, req monitoring.BurnRateRequest) (monitoring.BurnRateSimulation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SimulateBurnRate", ctx, req)
+	ret0, _ := ret[0].(monitoring.BurnRateSimulation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SimulateBurnRate indicates an expected call of SimulateBurnRate.
+func (mr *MockMonitoringClientMockRecorder) SimulateBurnRate(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SimulateBurnRate", reflect.TypeOf((*MockMonitoringClient)(nil).SimulateBurnRate), ctx, req)
+}
+
 // WriteTimeSeries mocks base method.
 func (m *MockMonitoringClient) WriteTimeSeries(ctx context.Context, req monitoring.WriteTimeSeriesRequest) error {
 	m.ctrl.T.Helper()
@@ -152,6 +422,36 @@ func (m *MockMonitoringClientInterface) EXPECT() *MockMonitoringClientInterfaceM
 	return m.recorder
 }
 
+// ApplyAlertPolicy mocks base method.
+func (m *MockMonitoringClientInterface) ApplyAlertPolicy(ctx context.Context, req monitoring.ApplyRequest) (monitoring.ApplyResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyAlertPolicy", ctx, req)
+	ret0, _ := ret[0].(monitoring.ApplyResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyAlertPolicy indicates an expected call of ApplyAlertPolicy.
+func (mr *MockMonitoringClientInterfaceMockRecorder) ApplyAlertPolicy(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyAlertPolicy", reflect.TypeOf((*MockMonitoringClientInterface)(nil).ApplyAlertPolicy), ctx, req)
+}
+
+// ApplyDashboard mocks base method.
+func (m *MockMonitoringClientInterface) ApplyDashboard(ctx context.Context, req monitoring.ApplyRequest) (monitoring.ApplyResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyDashboard", ctx, req)
+	ret0, _ := ret[0].(monitoring.ApplyResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyDashboard indicates an expected call of ApplyDashboard.
+func (mr *MockMonitoringClientInterfaceMockRecorder) ApplyDashboard(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyDashboard", reflect.TypeOf((*MockMonitoringClientInterface)(nil).ApplyDashboard), ctx, req)
+}
+
 // CreateMetricDescriptor mocks base method.
 func (m *MockMonitoringClientInterface) CreateMetricDescriptor(ctx context.Context, req monitoring.CreateMetricRequest) error {
 	m.ctrl.T.Helper()
@@ -180,6 +480,51 @@ func (mr *MockMonitoringClientInterfaceMockRecorder) DeleteMetricDescriptor(ctx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMetricDescriptor", reflect.TypeOf((*MockMonitoringClientInterface)(nil).DeleteMetricDescriptor), ctx, metricType)
 }
 
+// GetServiceLevelObjective mocks base method.
+func (m *MockMonitoringClientInterface) GetServiceLevelObjective(ctx context.Context, name string) (monitoring.ServiceLevelObjective, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceLevelObjective", ctx, name)
+	ret0, _ := ret[0].(monitoring.ServiceLevelObjective)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceLevelObjective indicates an expected call of GetServiceLevelObjective.
+func (mr *MockMonitoringClientInterfaceMockRecorder) GetServiceLevelObjective(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceLevelObjective", reflect.TypeOf((*MockMonitoringClientInterface)(nil).GetServiceLevelObjective), ctx, name)
+}
+
+// ListAlertPolicies mocks base method.
+func (m *MockMonitoringClientInterface) ListAlertPolicies(ctx context.Context, req monitoring.ListAlertPoliciesRequest) ([]monitoring.AlertPolicy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAlertPolicies", ctx, req)
+	ret0, _ := ret[0].([]monitoring.AlertPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAlertPolicies indicates an expected call of ListAlertPolicies.
+func (mr *MockMonitoringClientInterfaceMockRecorder) ListAlertPolicies(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAlertPolicies", reflect.TypeOf((*MockMonitoringClientInterface)(nil).ListAlertPolicies), ctx, req)
+}
+
+// ListAlerts mocks base method.
+func (m *MockMonitoringClientInterface) ListAlerts(ctx context.Context, req monitoring.ListAlertsRequest) (monitoring.ListAlertsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAlerts", ctx, req)
+	ret0, _ := ret[0].(monitoring.ListAlertsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAlerts indicates an expected call of ListAlerts.
+func (mr *MockMonitoringClientInterfaceMockRecorder) ListAlerts(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAlerts", reflect.TypeOf((*MockMonitoringClientInterface)(nil).ListAlerts), ctx, req)
+}
+
 // ListAvailableMetrics mocks base method.
 func (m *MockMonitoringClientInterface) ListAvailableMetrics(ctx context.Context, req monitoring.ListAvailableMetricsRequest) ([]monitoring.AvailableMetric, error) {
 	m.ctrl.T.Helper()
@@ -195,6 +540,21 @@ func (mr *MockMonitoringClientInterfaceMockRecorder) ListAvailableMetrics(ctx, r
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAvailableMetrics", reflect.TypeOf((*MockMonitoringClientInterface)(nil).ListAvailableMetrics), ctx, req)
 }
 
+// ListDashboards mocks base method.
+func (m *MockMonitoringClientInterface) ListDashboards(ctx context.Context, req monitoring.ListDashboardsRequest) ([]monitoring.Dashboard, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDashboards", ctx, req)
+	ret0, _ := ret[0].([]monitoring.Dashboard)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDashboards indicates an expected call of ListDashboards.
+func (mr *MockMonitoringClientInterfaceMockRecorder) ListDashboards(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDashboards", reflect.TypeOf((*MockMonitoringClientInterface)(nil).ListDashboards), ctx, req)
+}
+
 // ListMetricDescriptors mocks base method.
 func (m *MockMonitoringClientInterface) ListMetricDescriptors(ctx context.Context, req monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
 	m.ctrl.T.Helper()
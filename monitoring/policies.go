@@ -0,0 +1,200 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxAlertPolicies bounds the number of alerting policies fetched for a project
+const maxAlertPolicies = 1000
+
+// Alert condition types
+const (
+	ConditionTypeThreshold = "threshold"
+	ConditionTypeAbsence   = "absence"
+	ConditionTypeLogMatch  = "log_match"
+	ConditionTypeMQL       = "mql"
+	ConditionTypePromQL    = "promql"
+	ConditionTypeSQL       = "sql"
+	ConditionTypeUnknown   = "unknown"
+)
+
+// AlertPolicy represents an alerting policy
+type AlertPolicy struct {
+	Name                 string            `json:"name"`
+	DisplayName          string            `json:"display_name"`
+	Enabled              bool              `json:"enabled"`
+	Combiner             string            `json:"combiner,omitempty"`
+	Severity             string            `json:"severity,omitempty"`
+	Documentation        string            `json:"documentation,omitempty"`
+	NotificationChannels []string          `json:"notification_channels,omitempty"`
+	UserLabels           map[string]string `json:"user_labels,omitempty"`
+	Conditions           []AlertCondition  `json:"conditions"`
+	// Invalid describes why the policy is invalid; invalid policies open no incidents
+	Invalid string `json:"invalid,omitempty"`
+}
+
+// AlertCondition represents one condition of an alerting policy
+type AlertCondition struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`             // one of the ConditionType constants
+	Filter      string `json:"filter,omitempty"` // monitoring or logging filter, or query for MQL, PromQL and SQL
+	// Duration is how long the condition must hold before an incident opens, e.g. "300s"
+	Duration string `json:"duration,omitempty"`
+	// AlignmentPeriod is the alignment period of the first aggregation
+	AlignmentPeriod   string              `json:"alignment_period,omitempty"`
+	Aggregations      []AggregationConfig `json:"aggregations,omitempty"`
+	DenominatorFilter string              `json:"denominator_filter,omitempty"`
+	Comparison        string              `json:"comparison,omitempty"` // e.g. COMPARISON_GT
+	ThresholdValue    float64             `json:"threshold_value,omitempty"`
+	// TriggerCount and TriggerPercent are how many time series must violate the condition
+	TriggerCount   int     `json:"trigger_count,omitempty"`
+	TriggerPercent float64 `json:"trigger_percent,omitempty"`
+}
+
+// ListAlertPoliciesRequest represents a request to list alerting policies
+type ListAlertPoliciesRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	Filter    string `json:"filter,omitempty"`     // e.g. display_name=starts_with("prod")
+}
+
+// ListAlertPolicies lists the alerting policies of a project
+func (c *CloudMonitoringClient) ListAlertPolicies(ctx context.Context, req ListAlertPoliciesRequest) ([]AlertPolicy, error) {
+	return c.client.ListAlertPolicies(ctx, req)
+}
+
+// ListAlertPolicies implements MonitoringClientInterface for the real client
+func (r *realMonitoringClient) ListAlertPolicies(ctx context.Context, req ListAlertPoliciesRequest) ([]AlertPolicy, error) {
+	it := r.alertPolicyClient.ListAlertPolicies(ctx, &monitoringpb.ListAlertPoliciesRequest{
+		Name:   r.projectName(req.ProjectID),
+		Filter: req.Filter,
+	})
+
+	policies := []AlertPolicy{}
+	for len(policies) < maxAlertPolicies {
+		p, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list alert policies: %w", err)
+		}
+
+		policies = append(policies, convertAlertPolicy(p))
+	}
+	return policies, nil
+}
+
+// ParseAlertPolicy parses an alerting policy from its JSON definition, as
+// exported from the Cloud Console or returned by the API
+func ParseAlertPolicy(definition []byte) (AlertPolicy, error) {
+	p := &monitoringpb.AlertPolicy{}
+	if err := protojson.Unmarshal(definition, p); err != nil {
+		return AlertPolicy{}, fmt.Errorf("invalid alert policy definition: %w", err)
+	}
+	return convertAlertPolicy(p), nil
+}
+
+// convertAlertPolicy converts a protobuf alerting policy
+func convertAlertPolicy(p *monitoringpb.AlertPolicy) AlertPolicy {
+	policy := AlertPolicy{
+		Name:                 p.GetName(),
+		DisplayName:          p.GetDisplayName(),
+		Enabled:              p.GetEnabled() == nil || p.GetEnabled().GetValue(),
+		Documentation:        p.GetDocumentation().GetContent(),
+		NotificationChannels: p.GetNotificationChannels(),
+		UserLabels:           p.GetUserLabels(),
+		Conditions:           make([]AlertCondition, 0, len(p.GetConditions())),
+	}
+	if p.GetCombiner() != monitoringpb.AlertPolicy_COMBINE_UNSPECIFIED {
+		policy.Combiner = p.GetCombiner().String()
+	}
+	if p.GetSeverity() != monitoringpb.AlertPolicy_SEVERITY_UNSPECIFIED {
+		policy.Severity = p.GetSeverity().String()
+	}
+	if v := p.GetValidity(); v != nil && v.GetCode() != 0 {
+		policy.Invalid = v.GetMessage()
+	}
+	for _, c := range p.GetConditions() {
+		policy.Conditions = append(policy.Conditions, convertAlertCondition(c))
+	}
+	return policy
+}
+
+// convertAlertCondition converts a protobuf alert condition
+func convertAlertCondition(c *monitoringpb.AlertPolicy_Condition) AlertCondition {
+	condition := AlertCondition{
+		Name:        c.GetName(),
+		DisplayName: c.GetDisplayName(),
+		Type:        ConditionTypeUnknown,
+	}
+	switch {
+	case c.GetConditionThreshold() != nil:
+		t := c.GetConditionThreshold()
+		condition.Type = ConditionTypeThreshold
+		condition.Filter = t.GetFilter()
+		condition.Duration = fmt.Sprintf("%ds", t.GetDuration().GetSeconds())
+		condition.Aggregations = convertAggregations(t.GetAggregations())
+		condition.DenominatorFilter = t.GetDenominatorFilter()
+		if t.GetComparison() != monitoringpb.ComparisonType_COMPARISON_UNSPECIFIED {
+			condition.Comparison = t.GetComparison().String()
+		}
+		condition.ThresholdValue = t.GetThresholdValue()
+		condition.TriggerCount = int(t.GetTrigger().GetCount())
+		condition.TriggerPercent = t.GetTrigger().GetPercent()
+	case c.GetConditionAbsent() != nil:
+		a := c.GetConditionAbsent()
+		condition.Type = ConditionTypeAbsence
+		condition.Filter = a.GetFilter()
+		condition.Duration = fmt.Sprintf("%ds", a.GetDuration().GetSeconds())
+		condition.Aggregations = convertAggregations(a.GetAggregations())
+		condition.TriggerCount = int(a.GetTrigger().GetCount())
+		condition.TriggerPercent = a.GetTrigger().GetPercent()
+	case c.GetConditionMatchedLog() != nil:
+		condition.Type = ConditionTypeLogMatch
+		condition.Filter = c.GetConditionMatchedLog().GetFilter()
+	case c.GetConditionMonitoringQueryLanguage() != nil:
+		q := c.GetConditionMonitoringQueryLanguage()
+		condition.Type = ConditionTypeMQL
+		condition.Filter = q.GetQuery()
+		condition.Duration = fmt.Sprintf("%ds", q.GetDuration().GetSeconds())
+		condition.TriggerCount = int(q.GetTrigger().GetCount())
+		condition.TriggerPercent = q.GetTrigger().GetPercent()
+	case c.GetConditionPrometheusQueryLanguage() != nil:
+		q := c.GetConditionPrometheusQueryLanguage()
+		condition.Type = ConditionTypePromQL
+		condition.Filter = q.GetQuery()
+		condition.Duration = fmt.Sprintf("%ds", q.GetDuration().GetSeconds())
+	case c.GetConditionSql() != nil:
+		condition.Type = ConditionTypeSQL
+		condition.Filter = c.GetConditionSql().GetQuery()
+	}
+	if len(condition.Aggregations) > 0 {
+		condition.AlignmentPeriod = condition.Aggregations[0].AlignmentPeriod
+	}
+	return condition
+}
+
+// convertAggregations converts protobuf aggregations
+func convertAggregations(aggs []*monitoringpb.Aggregation) []AggregationConfig {
+	var result []AggregationConfig
+	for _, a := range aggs {
+		agg := AggregationConfig{GroupByFields: a.GetGroupByFields()}
+		if a.GetAlignmentPeriod() != nil {
+			agg.AlignmentPeriod = fmt.Sprintf("%ds", a.GetAlignmentPeriod().GetSeconds())
+		}
+		if a.GetPerSeriesAligner() != monitoringpb.Aggregation_ALIGN_NONE {
+			agg.PerSeriesAligner = a.GetPerSeriesAligner().String()
+		}
+		if a.GetCrossSeriesReducer() != monitoringpb.Aggregation_REDUCE_NONE {
+			agg.CrossSeriesReducer = a.GetCrossSeriesReducer().String()
+		}
+		result = append(result, agg)
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package monitoring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAlertPolicy(t *testing.T) {
+	definition := `{
+		"name": "projects/test-project/alertPolicies/42",
+		"displayName": "High error rate",
+		"combiner": "OR",
+		"documentation": {"content": "Check the logs"},
+		"conditions": [{
+			"name": "projects/test-project/alertPolicies/42/conditions/1",
+			"displayName": "errors",
+			"conditionThreshold": {
+				"filter": "metric.type=\"custom.googleapis.com/errors\"",
+				"duration": "300s",
+				"comparison": "COMPARISON_GT",
+				"thresholdValue": 5,
+				"aggregations": [{"alignmentPeriod": "60s", "perSeriesAligner": "ALIGN_RATE"}]
+			}
+		}]
+	}`
+
+	got, err := ParseAlertPolicy([]byte(definition))
+	if err != nil {
+		t.Fatalf("ParseAlertPolicy() error = %v", err)
+	}
+	want := AlertPolicy{
+		Name:          "projects/test-project/alertPolicies/42",
+		DisplayName:   "High error rate",
+		Enabled:       true,
+		Combiner:      "OR",
+		Documentation: "Check the logs",
+		Conditions: []AlertCondition{{
+			Name:            "projects/test-project/alertPolicies/42/conditions/1",
+			DisplayName:     "errors",
+			Type:            ConditionTypeThreshold,
+			Filter:          `metric.type="custom.googleapis.com/errors"`,
+			Duration:        "300s",
+			AlignmentPeriod: "60s",
+			Aggregations:    []AggregationConfig{{AlignmentPeriod: "60s", PerSeriesAligner: "ALIGN_RATE"}},
+			Comparison:      "COMPARISON_GT",
+			ThresholdValue:  5,
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAlertPolicy() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseAlertPolicy([]byte(`{"displayName": 1}`)); err == nil {
+		t.Error("Expected an error for an invalid definition")
+	}
+}
@@ -0,0 +1,186 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Lint rules
+const (
+	LintNoNotificationChannel = "no_notification_channel"
+	LintMissingDocumentation  = "missing_documentation"
+	LintTightDuration         = "tight_duration"
+	LintDeprecatedMetric      = "deprecated_metric"
+	LintInvalidPolicy         = "invalid_policy"
+)
+
+// Lint finding severities
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+	LintSeverityInfo    = "info"
+)
+
+// minConditionDuration is the shortest duration that does not alert on a single sample
+const minConditionDuration = time.Minute
+
+var (
+	// filterMetricTypePattern matches metric types in monitoring filters
+	filterMetricTypePattern = regexp.MustCompile(`metric\.type\s*=\s*"([^"]+)"`)
+	// mqlMetricTypePattern matches metric types in MQL fetch and metric operations
+	mqlMetricTypePattern = regexp.MustCompile(`(?:::\s*|\bmetric\s+')([a-zA-Z0-9._/-]+\.[a-z]+/[a-zA-Z0-9._/-]+)`)
+)
+
+// LintAlertPoliciesRequest represents a request to lint alerting policies
+type LintAlertPoliciesRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	Filter    string `json:"filter,omitempty"`     // alert policy filter, e.g. display_name=starts_with("prod")
+}
+
+// LintFinding represents a problem found in an alerting policy
+type LintFinding struct {
+	Policy            string `json:"policy"`
+	PolicyDisplayName string `json:"policy_display_name"`
+	Condition         string `json:"condition,omitempty"` // display name of the condition, for condition-level findings
+	Rule              string `json:"rule"`
+	Severity          string `json:"severity"`
+	Message           string `json:"message"`
+	Suggestion        string `json:"suggestion"`
+}
+
+// LintAlertPoliciesResponse lists findings with the most severe first
+type LintAlertPoliciesResponse struct {
+	PoliciesChecked int            `json:"policies_checked"`
+	Findings        []LintFinding  `json:"findings"`
+	Summary         map[string]int `json:"summary"` // number of findings per rule
+}
+
+// LintAlertPolicies checks the alerting policies of a project for common problems
+func (c *CloudMonitoringClient) LintAlertPolicies(ctx context.Context, req LintAlertPoliciesRequest) (LintAlertPoliciesResponse, error) {
+	policies, err := c.client.ListAlertPolicies(ctx, ListAlertPoliciesRequest{ProjectID: req.ProjectID, Filter: req.Filter})
+	if err != nil {
+		return LintAlertPoliciesResponse{}, err
+	}
+
+	deprecated := make(map[string]bool)
+	if len(policies) > 0 {
+		metrics, err := c.metricCatalog(ctx, req.ProjectID, false)
+		if err != nil {
+			return LintAlertPoliciesResponse{}, err
+		}
+		for _, m := range metrics {
+			if m.LaunchStage == "DEPRECATED" {
+				deprecated[m.Type] = true
+			}
+		}
+	}
+
+	resp := LintAlertPoliciesResponse{
+		PoliciesChecked: len(policies),
+		Findings:        []LintFinding{},
+		Summary:         make(map[string]int),
+	}
+	for _, policy := range policies {
+		resp.Findings = append(resp.Findings, lintAlertPolicy(policy, deprecated)...)
+	}
+
+	severityRank := map[string]int{LintSeverityError: 0, LintSeverityWarning: 1, LintSeverityInfo: 2}
+	sort.SliceStable(resp.Findings, func(i, j int) bool {
+		return severityRank[resp.Findings[i].Severity] < severityRank[resp.Findings[j].Severity]
+	})
+	for _, f := range resp.Findings {
+		resp.Summary[f.Rule]++
+	}
+	return resp, nil
+}
+
+// lintAlertPolicy returns the findings for one policy, given the set of deprecated metric types
+func lintAlertPolicy(policy AlertPolicy, deprecated map[string]bool) []LintFinding {
+	var findings []LintFinding
+	add := func(condition, rule, severity, message, suggestion string) {
+		findings = append(findings, LintFinding{
+			Policy:            policy.Name,
+			PolicyDisplayName: policy.DisplayName,
+			Condition:         condition,
+			Rule:              rule,
+			Severity:          severity,
+			Message:           message,
+			Suggestion:        suggestion,
+		})
+	}
+
+	if policy.Invalid != "" {
+		add("", LintInvalidPolicy, LintSeverityError,
+			fmt.Sprintf("The policy is invalid and opens no incidents: %s", policy.Invalid),
+			"Fix the conditions so that the policy validates again")
+	}
+	if len(policy.NotificationChannels) == 0 {
+		severity := LintSeverityWarning
+		if !policy.Enabled {
+			severity = LintSeverityInfo
+		}
+		add("", LintNoNotificationChannel, severity,
+			"The policy has no notification channels, so its incidents open without notifying anyone",
+			"Add a notification channel (e.g., email, Slack, or PagerDuty), or delete the policy if nobody acts on it")
+	}
+	if policy.Documentation == "" {
+		add("", LintMissingDocumentation, LintSeverityWarning,
+			"The policy has no documentation, so responders get no context with the notification",
+			"Describe what the alert means, its likely causes, and the first mitigation steps, or link a runbook")
+	}
+
+	for _, c := range policy.Conditions {
+		name := c.DisplayName
+		if name == "" {
+			name = c.Name
+		}
+
+		switch c.Type {
+		case ConditionTypeThreshold, ConditionTypeMQL, ConditionTypePromQL:
+			duration, err := time.ParseDuration(c.Duration)
+			if err != nil {
+				break
+			}
+			alignment, _ := time.ParseDuration(c.AlignmentPeriod)
+			if duration < minConditionDuration || (alignment > 0 && duration < alignment) {
+				add(name, LintTightDuration, LintSeverityWarning,
+					fmt.Sprintf("The condition fires as soon as a single data point crosses the threshold (duration %s)", duration),
+					fmt.Sprintf("Require the condition to hold for at least %s, or for several alignment periods, to avoid flapping alerts", max(minConditionDuration, 5*alignment)))
+			}
+		}
+
+		for _, metricType := range conditionMetricTypes(c) {
+			if deprecated[metricType] {
+				add(name, LintDeprecatedMetric, LintSeverityWarning,
+					fmt.Sprintf("The condition uses the deprecated metric %s", metricType),
+					"Switch to the replacement metric listed in the metric's documentation before it stops reporting data")
+			}
+		}
+	}
+	return findings
+}
+
+// conditionMetricTypes returns the metric types a condition reads, in order of appearance
+func conditionMetricTypes(c AlertCondition) []string {
+	pattern := filterMetricTypePattern
+	switch c.Type {
+	case ConditionTypeMQL:
+		pattern = mqlMetricTypePattern
+	case ConditionTypeThreshold, ConditionTypeAbsence:
+	default:
+		return nil
+	}
+
+	var types []string
+	seen := make(map[string]bool)
+	for _, m := range pattern.FindAllStringSubmatch(c.Filter, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			types = append(types, m[1])
+		}
+	}
+	return types
+}
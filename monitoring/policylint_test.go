@@ -0,0 +1,116 @@
+package monitoring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintAlertPolicy(t *testing.T) {
+	deprecated := map[string]bool{"agent.googleapis.com/memory/percent_used": true}
+
+	tests := []struct {
+		name   string
+		policy AlertPolicy
+		want   []string // rule of each finding, in order
+	}{
+		{
+			name: "healthy",
+			policy: AlertPolicy{
+				Enabled:              true,
+				Documentation:        "Check the backend logs",
+				NotificationChannels: []string{"projects/p/notificationChannels/1"},
+				Conditions: []AlertCondition{{
+					Type:            ConditionTypeThreshold,
+					Filter:          `metric.type="compute.googleapis.com/instance/cpu/utilization"`,
+					Duration:        "300s",
+					AlignmentPeriod: "60s",
+				}},
+			},
+		},
+		{
+			name:   "no channel and no documentation",
+			policy: AlertPolicy{Enabled: true},
+			want:   []string{LintNoNotificationChannel, LintMissingDocumentation},
+		},
+		{
+			name: "single sample threshold",
+			policy: AlertPolicy{
+				Enabled:              true,
+				Documentation:        "doc",
+				NotificationChannels: []string{"c"},
+				Conditions: []AlertCondition{
+					{Type: ConditionTypeThreshold, Duration: "0s"},
+					{Type: ConditionTypeThreshold, Duration: "120s", AlignmentPeriod: "300s"},
+					{Type: ConditionTypeLogMatch},
+				},
+			},
+			want: []string{LintTightDuration, LintTightDuration},
+		},
+		{
+			name: "deprecated metric in filter and MQL",
+			policy: AlertPolicy{
+				Enabled:              true,
+				Documentation:        "doc",
+				NotificationChannels: []string{"c"},
+				Conditions: []AlertCondition{
+					{Type: ConditionTypeAbsence, Duration: "600s", Filter: `metric.type="agent.googleapis.com/memory/percent_used" AND resource.type="gce_instance"`},
+					{Type: ConditionTypeMQL, Duration: "300s", Filter: "fetch gce_instance::agent.googleapis.com/memory/percent_used | every 1m"},
+				},
+			},
+			want: []string{LintDeprecatedMetric, LintDeprecatedMetric},
+		},
+		{
+			name: "invalid",
+			policy: AlertPolicy{
+				Documentation:        "doc",
+				NotificationChannels: []string{"c"},
+				Invalid:              "metric not found",
+			},
+			want: []string{LintInvalidPolicy},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range lintAlertPolicy(tt.policy, deprecated) {
+				if f.Message == "" || f.Suggestion == "" {
+					t.Errorf("Expected message and suggestion, got %+v", f)
+				}
+				got = append(got, f.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lintAlertPolicy() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionMetricTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition AlertCondition
+		want      []string
+	}{
+		{
+			name:      "ratio filter",
+			condition: AlertCondition{Type: ConditionTypeThreshold, Filter: `metric.type = "a.googleapis.com/x" AND metric.type="a.googleapis.com/x"`},
+			want:      []string{"a.googleapis.com/x"},
+		},
+		{
+			name:      "MQL metric operation",
+			condition: AlertCondition{Type: ConditionTypeMQL, Filter: "fetch k8s_container | metric 'kubernetes.io/container/restart_count' | align delta(5m)"},
+			want:      []string{"kubernetes.io/container/restart_count"},
+		},
+		{
+			name:      "PromQL is not parsed",
+			condition: AlertCondition{Type: ConditionTypePromQL, Filter: `rate(http_requests_total[5m]) > 1`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conditionMetricTypes(tt.condition); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("conditionMetricTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
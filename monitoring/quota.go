@@ -0,0 +1,291 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Quota metrics reported by Service Infrastructure for the consumer_quota resource
+const (
+	quotaAllocationUsageMetric = "serviceruntime.googleapis.com/quota/allocation/usage"
+	quotaRateUsageMetric       = "serviceruntime.googleapis.com/quota/rate/net_usage"
+	quotaLimitMetric           = "serviceruntime.googleapis.com/quota/limit"
+	quotaExceededMetric        = "serviceruntime.googleapis.com/quota/exceeded"
+)
+
+// Quota types
+const (
+	QuotaTypeAllocation = "allocation"
+	QuotaTypeRate       = "rate"
+)
+
+const (
+	defaultQuotaWindow = 24 * time.Hour
+	maxQuotaSeries     = 1000
+)
+
+// quotaPeriodPattern matches the period suffix of a rate limit name, e.g.
+// "ReadRequestsPerMinutePerProject" or "QueriesPer100SecondsPerUser"
+var quotaPeriodPattern = regexp.MustCompile(`Per(\d*)(Second|Minute|Hour|Day)s?`)
+
+// QuotaUsageRequest represents a request to inspect quota usage against limits
+type QuotaUsageRequest struct {
+	ProjectID string    `json:"project_id,omitempty"` // defaults to the client's project
+	Service   string    `json:"service,omitempty"`    // e.g. compute.googleapis.com; all services when empty
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// MinUtilization drops quotas used below this fraction of their limit
+	// unless they were exceeded
+	MinUtilization float64 `json:"min_utilization,omitempty"`
+}
+
+// QuotaUsage represents the peak usage of a quota limit in a time window
+type QuotaUsage struct {
+	Service     string `json:"service"`
+	Location    string `json:"location,omitempty"`
+	QuotaMetric string `json:"quota_metric"`
+	LimitName   string `json:"limit_name"`
+	Type        string `json:"type"` // QuotaTypeAllocation or QuotaTypeRate
+	// Period is the period a rate limit applies to, e.g. 1m for a per-minute limit
+	Period      string    `json:"period,omitempty"`
+	PeakUsage   float64   `json:"peak_usage"`
+	PeakTime    time.Time `json:"peak_time,omitzero"`
+	Limit       float64   `json:"limit"`
+	Utilization float64   `json:"utilization"` // PeakUsage / Limit, 0 for unlimited quotas
+	// Exceeded counts the sampled periods in which a request was rejected by this limit
+	Exceeded int `json:"exceeded,omitempty"`
+}
+
+// QuotaUsageResponse lists quota usage with the most constrained quotas first
+type QuotaUsageResponse struct {
+	Quotas    []QuotaUsage `json:"quotas"`
+	Throttled []string     `json:"throttled,omitempty"` // one line per limit that rejected requests
+}
+
+// quotaKey identifies a quota metric of a service in a location
+type quotaKey struct {
+	service, location, quotaMetric string
+}
+
+// quotaLimitKey identifies one limit of a quota metric
+type quotaLimitKey struct {
+	quotaKey
+	limitName string
+}
+
+func newQuotaKey(ts TimeSeriesData) quotaKey {
+	return quotaKey{
+		service:     ts.ResourceLabels["service"],
+		location:    ts.ResourceLabels["location"],
+		quotaMetric: ts.MetricLabels["quota_metric"],
+	}
+}
+
+// GetQuotaUsage compares the peak allocation and rate quota usage in a time
+// window with the quota limits, and reports limits that rejected requests
+func (c *CloudMonitoringClient) GetQuotaUsage(ctx context.Context, req QuotaUsageRequest) (QuotaUsageResponse, error) {
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+	if req.StartTime.IsZero() {
+		req.StartTime = req.EndTime.Add(-defaultQuotaWindow)
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return QuotaUsageResponse{}, fmt.Errorf("start_time must be before end_time")
+	}
+	// Whole seconds covering the window, so that one aligned point summarizes it
+	window := fmt.Sprintf("%ds", int64(req.EndTime.Sub(req.StartTime).Seconds()+0.999))
+
+	list := func(metricType, alignmentPeriod, aligner string) ([]TimeSeriesData, error) {
+		filter := fmt.Sprintf(`metric.type=%q AND resource.type="consumer_quota"`, metricType)
+		if req.Service != "" {
+			filter += fmt.Sprintf(` AND resource.labels.service=%q`, req.Service)
+		}
+		listReq := ListTimeSeriesRequest{
+			ProjectID: req.ProjectID,
+			Filter:    filter,
+			Aggregation: &AggregationConfig{
+				AlignmentPeriod:  alignmentPeriod,
+				PerSeriesAligner: aligner,
+			},
+			PageSize: maxQuotaSeries,
+		}
+		listReq.Interval.StartTime = req.StartTime
+		listReq.Interval.EndTime = req.EndTime
+		resp, err := c.client.ListTimeSeries(ctx, listReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", metricType, err)
+		}
+		return resp.TimeSeries, nil
+	}
+
+	var allocation, rate, limits, exceeded []TimeSeriesData
+	queries := []struct {
+		result                      *[]TimeSeriesData
+		metricType, period, aligner string
+	}{
+		{&allocation, quotaAllocationUsageMetric, "60s", "ALIGN_MAX"},
+		{&rate, quotaRateUsageMetric, "60s", "ALIGN_DELTA"},
+		{&limits, quotaLimitMetric, window, "ALIGN_MAX"},
+		{&exceeded, quotaExceededMetric, "60s", "ALIGN_COUNT_TRUE"},
+	}
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			*q.result, errs[i] = list(q.metricType, q.period, q.aligner)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return QuotaUsageResponse{}, err
+		}
+	}
+
+	allocationUsage := make(map[quotaKey]TimeSeriesData)
+	for _, ts := range allocation {
+		allocationUsage[newQuotaKey(ts)] = ts
+	}
+	rateUsage := make(map[quotaKey]TimeSeriesData)
+	for _, ts := range rate {
+		// Rate usage is split by API method; quotas apply to the sum
+		key := newQuotaKey(ts)
+		merged := rateUsage[key]
+		merged.Values = append(merged.Values, ts.Values...)
+		rateUsage[key] = merged
+	}
+	exceededCounts := make(map[quotaLimitKey]int)
+	for _, ts := range exceeded {
+		key := quotaLimitKey{newQuotaKey(ts), ts.MetricLabels["limit_name"]}
+		for _, p := range ts.Values {
+			if p.Value > 0 {
+				exceededCounts[key]++
+			}
+		}
+	}
+
+	resp := QuotaUsageResponse{Quotas: []QuotaUsage{}}
+	for _, ts := range limits {
+		key := newQuotaKey(ts)
+		usage := QuotaUsage{
+			Service:     key.service,
+			Location:    key.location,
+			QuotaMetric: key.quotaMetric,
+			LimitName:   ts.MetricLabels["limit_name"],
+			Limit:       latestValue(ts.Values),
+		}
+		usage.Exceeded = exceededCounts[quotaLimitKey{key, usage.LimitName}]
+
+		if series, ok := allocationUsage[key]; ok {
+			usage.Type = QuotaTypeAllocation
+			usage.PeakUsage, usage.PeakTime = peak(series.Values)
+		} else if series, ok := rateUsage[key]; ok {
+			usage.Type = QuotaTypeRate
+			period := rateLimitPeriod(usage.LimitName)
+			usage.Period = period.String()
+			usage.PeakUsage, usage.PeakTime = peakRate(series.Values, period)
+		} else {
+			// No usage was reported in the window
+			usage.Type = QuotaTypeAllocation
+			if quotaPeriodPattern.MatchString(usage.LimitName) {
+				usage.Type = QuotaTypeRate
+				usage.Period = rateLimitPeriod(usage.LimitName).String()
+			}
+		}
+
+		// Negative limits mean the quota is unlimited
+		if usage.Limit > 0 {
+			usage.Utilization = usage.PeakUsage / usage.Limit
+		}
+		if usage.Utilization < req.MinUtilization && usage.Exceeded == 0 {
+			continue
+		}
+		resp.Quotas = append(resp.Quotas, usage)
+	}
+
+	sort.SliceStable(resp.Quotas, func(i, j int) bool {
+		a, b := resp.Quotas[i], resp.Quotas[j]
+		if (a.Exceeded > 0) != (b.Exceeded > 0) {
+			return a.Exceeded > 0
+		}
+		if a.Utilization != b.Utilization {
+			return a.Utilization > b.Utilization
+		}
+		return a.Service+a.QuotaMetric+a.LimitName < b.Service+b.QuotaMetric+b.LimitName
+	})
+	for _, q := range resp.Quotas {
+		if q.Exceeded > 0 {
+			resp.Throttled = append(resp.Throttled, fmt.Sprintf("%s %s (%s): exceeded in %d minute(s), peak %g of %g",
+				q.Service, q.QuotaMetric, q.LimitName, q.Exceeded, q.PeakUsage, q.Limit))
+		}
+	}
+	return resp, nil
+}
+
+// rateLimitPeriod returns the period of a rate limit from its name, assuming
+// one minute when the name does not say
+func rateLimitPeriod(limitName string) time.Duration {
+	m := quotaPeriodPattern.FindStringSubmatch(limitName)
+	if m == nil {
+		return time.Minute
+	}
+	count := 1
+	if m[1] != "" {
+		count, _ = strconv.Atoi(m[1])
+	}
+	unit := map[string]time.Duration{
+		"Second": time.Second,
+		"Minute": time.Minute,
+		"Hour":   time.Hour,
+		"Day":    24 * time.Hour,
+	}[m[2]]
+	return time.Duration(count) * unit
+}
+
+// peakRate returns the largest usage within one period, from per-minute usage.
+// Periods shorter than a minute are estimated by assuming even usage within the minute.
+func peakRate(values []MetricValue, period time.Duration) (float64, time.Time) {
+	if period < time.Minute {
+		usage, at := peakRate(values, time.Minute)
+		return usage * period.Seconds() / 60, at
+	}
+	buckets := make(map[time.Time]float64)
+	for _, p := range values {
+		buckets[p.Timestamp.Truncate(period)] += p.Value
+	}
+	var bucketValues []MetricValue
+	for t, v := range buckets {
+		bucketValues = append(bucketValues, MetricValue{Value: v, Timestamp: t})
+	}
+	return peak(bucketValues)
+}
+
+// peak returns the largest value and its time, preferring the earliest on ties
+func peak(values []MetricValue) (float64, time.Time) {
+	var best MetricValue
+	for i, p := range values {
+		if i == 0 || p.Value > best.Value || (p.Value == best.Value && p.Timestamp.Before(best.Timestamp)) {
+			best = p
+		}
+	}
+	return best.Value, best.Timestamp
+}
+
+// latestValue returns the value of the most recent point
+func latestValue(values []MetricValue) float64 {
+	var latest MetricValue
+	for i, p := range values {
+		if i == 0 || p.Timestamp.After(latest.Timestamp) {
+			latest = p
+		}
+	}
+	return latest.Value
+}
@@ -0,0 +1,218 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultResourceWindow = time.Hour
+	// resourceBuckets is the number of aligned points per resource, which
+	// dates the last activity of a resource to a twelfth of the window
+	resourceBuckets = 12
+	// maxResourcesPerType bounds the resources listed per resource type
+	maxResourcesPerType = 1000
+)
+
+// resourceProbe is a metric written by every active resource of a type, and
+// the resource labels identifying a resource of that type
+type resourceProbe struct {
+	metricType string
+	labels     []string
+}
+
+// resourceProbes are the resource types discovered by ListMonitoredResources
+// when no metric is given
+var resourceProbes = map[string]resourceProbe{
+	"gce_instance":       {"compute.googleapis.com/instance/uptime", []string{"zone", "instance_id"}},
+	"k8s_container":      {"kubernetes.io/container/uptime", []string{"location", "cluster_name", "namespace_name", "container_name"}},
+	"cloud_run_revision": {"run.googleapis.com/container/instance_count", []string{"location", "service_name"}},
+	"cloud_function":     {"cloudfunctions.googleapis.com/function/execution_count", []string{"region", "function_name"}},
+	"gae_app":            {"appengine.googleapis.com/http/server/response_count", []string{"module_id", "version_id"}},
+	"cloudsql_database":  {"cloudsql.googleapis.com/database/up", []string{"region", "database_id"}},
+	"pubsub_topic":       {"pubsub.googleapis.com/topic/send_message_operation_count", []string{"topic_id"}},
+}
+
+// ResourceTypes lists the resource types ListMonitoredResources discovers
+// without a metric
+var ResourceTypes = slices.Sorted(maps.Keys(resourceProbes))
+
+// ListMonitoredResourcesRequest represents a request to discover the
+// resources emitting telemetry in a time window
+type ListMonitoredResourcesRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	// ResourceType limits the discovery to one resource type; all of
+	// ResourceTypes when empty
+	ResourceType string `json:"resource_type,omitempty"`
+	// MetricType and GroupBy override the metric probed for ResourceType and
+	// the resource labels identifying its resources. They are required for
+	// resource types outside ResourceTypes.
+	MetricType string    `json:"metric_type,omitempty"`
+	GroupBy    []string  `json:"group_by,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+}
+
+// MonitoredResource represents a resource that emitted telemetry in a window
+type MonitoredResource struct {
+	ResourceType string            `json:"resource_type"`
+	Labels       map[string]string `json:"labels"`
+	MetricType   string            `json:"metric_type"` // the metric the resource was found by
+	Points       int               `json:"points"`      // points of the metric written by the resource
+	LastSeen     time.Time         `json:"last_seen"`   // end of the last aligned period with points
+}
+
+// ListMonitoredResourcesResponse lists the resources found, by resource type
+type ListMonitoredResourcesResponse struct {
+	Resources []MonitoredResource `json:"resources"`
+	Counts    map[string]int      `json:"counts"` // number of resources per resource type
+	// Truncated lists the resource types with more than maxResourcesPerType resources
+	Truncated []string          `json:"truncated,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"` // resource type to the error that prevented listing it
+}
+
+// ListMonitoredResources enumerates the resources emitting telemetry in a
+// time window, answering "what is even running here?". For each resource
+// type it lists a metric every active resource writes, grouped by the
+// resource labels identifying a resource. Resource types are listed
+// concurrently; a type that fails is reported in Errors instead of failing
+// the whole response.
+func (c *CloudMonitoringClient) ListMonitoredResources(ctx context.Context, req ListMonitoredResourcesRequest) (ListMonitoredResourcesResponse, error) {
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+	if req.StartTime.IsZero() {
+		req.StartTime = req.EndTime.Add(-defaultResourceWindow)
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return ListMonitoredResourcesResponse{}, fmt.Errorf("start_time must be before end_time")
+	}
+
+	probes := resourceProbes
+	if req.ResourceType != "" || req.MetricType != "" || len(req.GroupBy) > 0 {
+		if req.ResourceType == "" {
+			return ListMonitoredResourcesResponse{}, fmt.Errorf("resource_type is required with metric_type or group_by")
+		}
+		probe := resourceProbes[req.ResourceType]
+		if req.MetricType != "" {
+			probe.metricType = req.MetricType
+		}
+		if len(req.GroupBy) > 0 {
+			probe.labels = req.GroupBy
+		}
+		if probe.metricType == "" || len(probe.labels) == 0 {
+			return ListMonitoredResourcesResponse{}, fmt.Errorf("metric_type and group_by are required for resource type %q, which is not one of %s",
+				req.ResourceType, strings.Join(ResourceTypes, ", "))
+		}
+		probes = map[string]resourceProbe{req.ResourceType: probe}
+	}
+
+	// Whole seconds covering a bucket of the window
+	period := fmt.Sprintf("%ds", max(60, int64(req.EndTime.Sub(req.StartTime).Seconds()/resourceBuckets+0.999)))
+
+	resp := ListMonitoredResourcesResponse{
+		Resources: []MonitoredResource{},
+		Counts:    make(map[string]int),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for resourceType, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resources, truncated, err := c.listResources(ctx, req, resourceType, probe, period)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if resp.Errors == nil {
+					resp.Errors = make(map[string]string)
+				}
+				resp.Errors[resourceType] = err.Error()
+				return
+			}
+			resp.Resources = append(resp.Resources, resources...)
+			resp.Counts[resourceType] = len(resources)
+			if truncated {
+				resp.Truncated = append(resp.Truncated, resourceType)
+			}
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(resp.Resources, func(a, b MonitoredResource) int {
+		if a.ResourceType != b.ResourceType {
+			return strings.Compare(a.ResourceType, b.ResourceType)
+		}
+		return slices.Compare(labelValues(a.Labels), labelValues(b.Labels))
+	})
+	slices.Sort(resp.Truncated)
+	return resp, nil
+}
+
+// listResources lists the resources of one resource type writing the metric
+// of probe, with the number of points each wrote, and whether more resources
+// are left
+func (c *CloudMonitoringClient) listResources(ctx context.Context, req ListMonitoredResourcesRequest, resourceType string, probe resourceProbe, period string) ([]MonitoredResource, bool, error) {
+	groupBy := make([]string, len(probe.labels))
+	for i, label := range probe.labels {
+		groupBy[i] = "resource.labels." + label
+	}
+	listReq := ListTimeSeriesRequest{
+		ProjectID: req.ProjectID,
+		Filter:    fmt.Sprintf(`metric.type=%q AND resource.type=%q`, probe.metricType, resourceType),
+		Aggregation: &AggregationConfig{
+			AlignmentPeriod:    period,
+			PerSeriesAligner:   "ALIGN_COUNT",
+			CrossSeriesReducer: "REDUCE_SUM",
+			GroupByFields:      groupBy,
+		},
+		PageSize: maxResourcesPerType,
+	}
+	listReq.Interval.StartTime = req.StartTime
+	listReq.Interval.EndTime = req.EndTime
+
+	list, err := c.client.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list %s: %w", probe.metricType, err)
+	}
+
+	resources := []MonitoredResource{}
+	for _, ts := range list.TimeSeries {
+		resource := MonitoredResource{
+			ResourceType: resourceType,
+			Labels:       make(map[string]string),
+			MetricType:   probe.metricType,
+		}
+		for _, label := range probe.labels {
+			resource.Labels[label] = ts.ResourceLabels[label]
+		}
+		for _, v := range ts.Values {
+			if v.Value <= 0 {
+				continue
+			}
+			resource.Points += int(v.Value)
+			if v.Timestamp.After(resource.LastSeen) {
+				resource.LastSeen = v.Timestamp
+			}
+		}
+		if resource.Points > 0 {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, list.NextPageToken != "", nil
+}
+
+// labelValues returns the values of labels ordered by key
+func labelValues(labels map[string]string) []string {
+	values := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		values = append(values, labels[key])
+	}
+	return values
+}
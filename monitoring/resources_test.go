@@ -0,0 +1,155 @@
+package monitoring_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudMonitoringClient_ListMonitoredResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	mockClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			if req.Aggregation == nil || req.Aggregation.CrossSeriesReducer != "REDUCE_SUM" || req.Aggregation.AlignmentPeriod != "300s" {
+				t.Errorf("Expected points summed per resource in 5 minute periods, got %+v", req.Aggregation)
+			}
+			switch {
+			case strings.Contains(req.Filter, `resource.type="gce_instance"`):
+				if !slices.Equal(req.Aggregation.GroupByFields, []string{"resource.labels.zone", "resource.labels.instance_id"}) {
+					t.Errorf("Unexpected group by fields %v", req.Aggregation.GroupByFields)
+				}
+				return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{
+					{
+						ResourceType:   "gce_instance",
+						ResourceLabels: map[string]string{"zone": "us-central1-b", "instance_id": "2"},
+						Values:         []monitoring.MetricValue{{Value: 5, Timestamp: end}, {Value: 5, Timestamp: end.Add(-5 * time.Minute)}},
+					},
+					{
+						ResourceType:   "gce_instance",
+						ResourceLabels: map[string]string{"zone": "us-central1-a", "instance_id": "1"},
+						Values:         []monitoring.MetricValue{{Value: 3, Timestamp: end.Add(-30 * time.Minute)}},
+					},
+				}}, nil
+			case strings.Contains(req.Filter, `resource.type="cloud_run_revision"`):
+				return monitoring.ListTimeSeriesResponse{
+					TimeSeries: []monitoring.TimeSeriesData{{
+						ResourceType:   "cloud_run_revision",
+						ResourceLabels: map[string]string{"location": "us-central1", "service_name": "api"},
+						Values:         []monitoring.MetricValue{{Value: 12, Timestamp: end}},
+					}},
+					NextPageToken: "next",
+				}, nil
+			case strings.Contains(req.Filter, `resource.type="cloudsql_database"`):
+				return monitoring.ListTimeSeriesResponse{}, errors.New("permission denied")
+			}
+			return monitoring.ListTimeSeriesResponse{}, nil
+		}).
+		Times(len(monitoring.ResourceTypes))
+
+	resp, err := client.ListMonitoredResources(context.Background(), monitoring.ListMonitoredResourcesRequest{
+		StartTime: end.Add(-time.Hour),
+		EndTime:   end,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(resp.Resources) != 3 {
+		t.Fatalf("Expected 3 resources, got %+v", resp.Resources)
+	}
+	// Resources are ordered by type, then by label values in key order
+	run, first, second := resp.Resources[0], resp.Resources[1], resp.Resources[2]
+	if run.ResourceType != "cloud_run_revision" || run.Labels["service_name"] != "api" || run.Points != 12 {
+		t.Errorf("Unexpected Cloud Run resource %+v", run)
+	}
+	if first.Labels["instance_id"] != "1" || first.Points != 3 || !first.LastSeen.Equal(end.Add(-30*time.Minute)) {
+		t.Errorf("Unexpected first instance %+v", first)
+	}
+	if second.Labels["instance_id"] != "2" || second.Points != 10 || !second.LastSeen.Equal(end) {
+		t.Errorf("Unexpected second instance %+v", second)
+	}
+	if second.MetricType != "compute.googleapis.com/instance/uptime" {
+		t.Errorf("Expected the probed metric, got %s", second.MetricType)
+	}
+
+	if resp.Counts["gce_instance"] != 2 || resp.Counts["cloud_run_revision"] != 1 || resp.Counts["pubsub_topic"] != 0 {
+		t.Errorf("Unexpected counts %v", resp.Counts)
+	}
+	if !slices.Equal(resp.Truncated, []string{"cloud_run_revision"}) {
+		t.Errorf("Expected cloud_run_revision to be truncated, got %v", resp.Truncated)
+	}
+	if !strings.Contains(resp.Errors["cloudsql_database"], "permission denied") {
+		t.Errorf("Expected the Cloud SQL error to be reported, got %v", resp.Errors)
+	}
+}
+
+func TestCloudMonitoringClient_ListMonitoredResourcesCustomMetric(t *testing.T) {
+	end := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("metric and labels of another resource type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+		client := monitoring.NewWithClient(mockClient, "test-project")
+		mockClient.EXPECT().
+			ListTimeSeries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+				if req.Filter != `metric.type="redis.googleapis.com/clients/connected" AND resource.type="redis_instance"` {
+					t.Errorf("Unexpected filter %s", req.Filter)
+				}
+				return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{
+					{
+						ResourceLabels: map[string]string{"instance_id": "cache"},
+						Values:         []monitoring.MetricValue{{Value: 4, Timestamp: end}},
+					},
+					{
+						// Periods without points are not activity
+						ResourceLabels: map[string]string{"instance_id": "idle"},
+						Values:         []monitoring.MetricValue{{Value: 0, Timestamp: end}},
+					},
+				}}, nil
+			})
+
+		resp, err := client.ListMonitoredResources(context.Background(), monitoring.ListMonitoredResourcesRequest{
+			ResourceType: "redis_instance",
+			MetricType:   "redis.googleapis.com/clients/connected",
+			GroupBy:      []string{"instance_id"},
+			EndTime:      end,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp.Resources) != 1 || resp.Resources[0].Labels["instance_id"] != "cache" {
+			t.Errorf("Expected the active instance only, got %+v", resp.Resources)
+		}
+	})
+
+	t.Run("unknown resource type without a metric", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		client := monitoring.NewWithClient(mocks.NewMockMonitoringClientInterface(ctrl), "test-project")
+		_, err := client.ListMonitoredResources(context.Background(), monitoring.ListMonitoredResourcesRequest{
+			ResourceType: "redis_instance",
+			EndTime:      end,
+		})
+		if err == nil || !strings.Contains(err.Error(), "metric_type and group_by are required") {
+			t.Errorf("Expected an error asking for the metric, got %v", err)
+		}
+	})
+}
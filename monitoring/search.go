@@ -0,0 +1,213 @@
+package monitoring
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// metricCatalogTTL is how long the metric descriptor list of a project is cached
+	metricCatalogTTL = time.Hour
+	// metricCatalogSize bounds the number of descriptors fetched for a project
+	metricCatalogSize = 20000
+)
+
+// Field weights used to rank search matches
+const (
+	typeMatchWeight        = 3.0
+	displayNameMatchWeight = 2.0
+	descriptionMatchWeight = 1.0
+)
+
+// SearchMetricsRequest represents a request to search metric descriptors
+type SearchMetricsRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	Query     string `json:"query"`
+	Limit     int    `json:"limit,omitempty"`
+	Refresh   bool   `json:"refresh,omitempty"` // refetch the descriptor list instead of using the cache
+}
+
+// MetricSearchResult represents a metric matching a search query
+type MetricSearchResult struct {
+	AvailableMetric
+	Score float64 `json:"score"`
+}
+
+// metricCatalog is a cached list of the metric descriptors of a project
+type metricCatalog struct {
+	metrics   []AvailableMetric
+	fetchedAt time.Time
+}
+
+// metricCatalogCache keeps metric catalogs keyed by project ID
+type metricCatalogCache struct {
+	mu       sync.Mutex
+	catalogs map[string]metricCatalog
+	now      func() time.Time
+}
+
+// newMetricCatalogCache creates an empty metricCatalogCache
+func newMetricCatalogCache() *metricCatalogCache {
+	return &metricCatalogCache{
+		catalogs: make(map[string]metricCatalog),
+		now:      time.Now,
+	}
+}
+
+// SearchMetrics finds metrics whose type, display name, or description match
+// the words of the query. Words may match part of a field ("pub" matches
+// "pubsub") or be off by a single typo.
+func (c *CloudMonitoringClient) SearchMetrics(ctx context.Context, req SearchMetricsRequest) ([]MetricSearchResult, error) {
+	terms := searchTerms(req.Query)
+	if len(terms) == 0 {
+		return []MetricSearchResult{}, nil
+	}
+
+	metrics, err := c.metricCatalog(ctx, req.ProjectID, req.Refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []MetricSearchResult{}
+	for _, m := range metrics {
+		if score := scoreMetric(m, terms); score > 0 {
+			results = append(results, MetricSearchResult{AvailableMetric: m, Score: score})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Type < results[j].Type
+	})
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 20 // default
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// metricCatalog returns the cached metric descriptors of the project, fetching them when missing or stale
+func (c *CloudMonitoringClient) metricCatalog(ctx context.Context, projectID string, refresh bool) ([]AvailableMetric, error) {
+	if projectID == "" {
+		projectID = c.projectID
+	}
+
+	c.catalogs.mu.Lock()
+	defer c.catalogs.mu.Unlock()
+
+	catalog, ok := c.catalogs.catalogs[projectID]
+	if ok && !refresh && c.catalogs.now().Sub(catalog.fetchedAt) < metricCatalogTTL {
+		return catalog.metrics, nil
+	}
+
+	metrics, err := c.client.ListAvailableMetrics(ctx, ListAvailableMetricsRequest{
+		ProjectID: projectID,
+		PageSize:  metricCatalogSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.catalogs.catalogs[projectID] = metricCatalog{
+		metrics:   metrics,
+		fetchedAt: c.catalogs.now(),
+	}
+	return metrics, nil
+}
+
+// scoreMetric returns how well the metric matches the search terms. Metrics
+// matching more of the terms rank higher, so filler words in a natural
+// language query only dilute the score instead of excluding results.
+func scoreMetric(m AvailableMetric, terms []string) float64 {
+	fields := []struct {
+		words  []string
+		text   string
+		weight float64
+	}{
+		{searchTerms(m.Type), strings.ToLower(m.Type), typeMatchWeight},
+		{searchTerms(m.DisplayName), strings.ToLower(m.DisplayName), displayNameMatchWeight},
+		{searchTerms(m.Description), strings.ToLower(m.Description), descriptionMatchWeight},
+	}
+
+	var score float64
+	matched := 0
+	for _, term := range terms {
+		var termScore float64
+		for _, f := range fields {
+			termScore += matchTerm(term, f.text, f.words) * f.weight
+		}
+		if termScore > 0 {
+			matched++
+			score += termScore
+		}
+	}
+	return score * float64(matched) / float64(len(terms))
+}
+
+// matchTerm returns 1 for an exact word match, 0.75 for a substring match,
+// 0.5 for a word within one typo of the term, and 0 otherwise
+func matchTerm(term, text string, words []string) float64 {
+	for _, w := range words {
+		if w == term {
+			return 1
+		}
+	}
+	if strings.Contains(text, term) {
+		return 0.75
+	}
+	// Only tolerate typos in longer terms; short terms match too much
+	if len(term) >= 5 {
+		for _, w := range words {
+			if withinOneEdit(term, w) {
+				return 0.5
+			}
+		}
+	}
+	return 0
+}
+
+// searchTerms splits s into lower-case alphanumeric words
+func searchTerms(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// withinOneEdit reports whether a and b differ by at most one insertion,
+// deletion, or substitution
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+
+	i, j, edits := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(a) == len(b) {
+			i++
+		}
+		j++
+	}
+	return edits+(len(b)-j)+(len(a)-i) <= 1
+}
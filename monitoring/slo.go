@@ -0,0 +1,116 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/genproto/googleapis/type/calendarperiod"
+)
+
+// calendarPeriods maps SLO calendar periods to their approximate length
+var calendarPeriods = map[calendarperiod.CalendarPeriod]time.Duration{
+	calendarperiod.CalendarPeriod_DAY:       24 * time.Hour,
+	calendarperiod.CalendarPeriod_WEEK:      7 * 24 * time.Hour,
+	calendarperiod.CalendarPeriod_FORTNIGHT: 14 * 24 * time.Hour,
+	calendarperiod.CalendarPeriod_MONTH:     30 * 24 * time.Hour,
+}
+
+// ServiceLevelObjective represents a request-based SLO whose indicator is the
+// ratio of good to total events. Two of the three filters are set.
+type ServiceLevelObjective struct {
+	Name        string        `json:"name,omitempty"`
+	DisplayName string        `json:"display_name,omitempty"`
+	Goal        float64       `json:"goal"`   // e.g. 0.999
+	Period      time.Duration `json:"period"` // compliance period the error budget applies to
+	GoodFilter  string        `json:"good_filter,omitempty"`
+	BadFilter   string        `json:"bad_filter,omitempty"`
+	TotalFilter string        `json:"total_filter,omitempty"`
+}
+
+// Validate checks that the SLO has a goal, a period, and enough filters to count bad events
+func (s ServiceLevelObjective) Validate() error {
+	if s.Goal <= 0 || s.Goal >= 1 {
+		return fmt.Errorf("goal must be between 0 and 1 exclusive, got %v", s.Goal)
+	}
+	if s.Period <= 0 {
+		return fmt.Errorf("period must be positive")
+	}
+	filters := 0
+	for _, f := range []string{s.GoodFilter, s.BadFilter, s.TotalFilter} {
+		if f != "" {
+			filters++
+		}
+	}
+	if filters != 2 {
+		return fmt.Errorf("exactly two of good, bad, and total filters are required, got %d", filters)
+	}
+	return nil
+}
+
+// MarshalJSON renders the period as a duration string, e.g. "720h0m0s"
+func (s ServiceLevelObjective) MarshalJSON() ([]byte, error) {
+	type alias ServiceLevelObjective
+	return json.Marshal(struct {
+		alias
+		Period string `json:"period"`
+	}{alias(s), s.Period.String()})
+}
+
+// UnmarshalJSON parses the period from a duration string, e.g. "720h"
+func (s *ServiceLevelObjective) UnmarshalJSON(data []byte) error {
+	type alias ServiceLevelObjective
+	var v struct {
+		alias
+		Period string `json:"period"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = ServiceLevelObjective(v.alias)
+	if v.Period == "" {
+		return nil
+	}
+	period, err := time.ParseDuration(v.Period)
+	if err != nil {
+		return fmt.Errorf("invalid period: %w", err)
+	}
+	s.Period = period
+	return nil
+}
+
+// GetServiceLevelObjective gets an SLO by its full resource name
+// (projects/PROJECT/services/SERVICE/serviceLevelObjectives/SLO)
+func (c *CloudMonitoringClient) GetServiceLevelObjective(ctx context.Context, name string) (ServiceLevelObjective, error) {
+	return c.client.GetServiceLevelObjective(ctx, name)
+}
+
+// GetServiceLevelObjective implements MonitoringClientInterface for the real client
+func (r *realMonitoringClient) GetServiceLevelObjective(ctx context.Context, name string) (ServiceLevelObjective, error) {
+	pbSLO, err := r.serviceClient.GetServiceLevelObjective(ctx, &monitoringpb.GetServiceLevelObjectiveRequest{Name: name})
+	if err != nil {
+		return ServiceLevelObjective{}, fmt.Errorf("failed to get service level objective: %w", err)
+	}
+
+	slo := ServiceLevelObjective{
+		Name:        pbSLO.GetName(),
+		DisplayName: pbSLO.GetDisplayName(),
+		Goal:        pbSLO.GetGoal(),
+	}
+	if rolling := pbSLO.GetRollingPeriod(); rolling != nil {
+		slo.Period = rolling.AsDuration()
+	} else {
+		slo.Period = calendarPeriods[pbSLO.GetCalendarPeriod()]
+	}
+
+	ratio := pbSLO.GetServiceLevelIndicator().GetRequestBased().GetGoodTotalRatio()
+	if ratio == nil {
+		return ServiceLevelObjective{}, fmt.Errorf("service level objective %s is not a request-based good/total ratio SLO", name)
+	}
+	slo.GoodFilter = ratio.GetGoodServiceFilter()
+	slo.BadFilter = ratio.GetBadServiceFilter()
+	slo.TotalFilter = ratio.GetTotalServiceFilter()
+	return slo, nil
+}
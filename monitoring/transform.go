@@ -0,0 +1,77 @@
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Client-side transforms of time series values
+const (
+	// TransformRate is the change per second between consecutive points
+	TransformRate = "rate"
+	// TransformDelta is the change between consecutive points
+	TransformDelta = "delta"
+	// TransformCumsum is the running total of the points
+	TransformCumsum = "cumsum"
+)
+
+// Transform computes a rate, delta, or running total of each series on the
+// client side, for queries that did not set an aligner. Rate and delta drop the
+// oldest point, which has no predecessor, and treat a decrease of a CUMULATIVE
+// series as a counter reset. The order of points within each series is preserved.
+func Transform(series []TimeSeriesData, transform string) ([]TimeSeriesData, error) {
+	if transform != TransformRate && transform != TransformDelta && transform != TransformCumsum {
+		return nil, fmt.Errorf("unsupported transform %q: must be %q, %q or %q", transform, TransformRate, TransformDelta, TransformCumsum)
+	}
+
+	result := make([]TimeSeriesData, len(series))
+	for i, ts := range series {
+		result[i] = ts
+		if len(ts.Values) == 0 {
+			continue
+		}
+
+		points := make([]MetricValue, len(ts.Values))
+		copy(points, ts.Values)
+		// Cloud Monitoring returns the newest point first
+		descending := points[0].Timestamp.After(points[len(points)-1].Timestamp)
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].Timestamp.Before(points[j].Timestamp)
+		})
+
+		var transformed []MetricValue
+		switch transform {
+		case TransformCumsum:
+			var sum float64
+			for _, p := range points {
+				sum += p.Value
+				transformed = append(transformed, MetricValue{Value: sum, Timestamp: p.Timestamp})
+			}
+		default:
+			for j := 1; j < len(points); j++ {
+				prev, cur := points[j-1], points[j]
+				delta := cur.Value - prev.Value
+				if delta < 0 && ts.MetricKind == "CUMULATIVE" {
+					// The counter restarted from zero
+					delta = cur.Value
+				}
+				if transform == TransformRate {
+					elapsed := cur.Timestamp.Sub(prev.Timestamp).Seconds()
+					if elapsed <= 0 {
+						continue
+					}
+					delta /= elapsed
+				}
+				transformed = append(transformed, MetricValue{Value: delta, Timestamp: cur.Timestamp})
+			}
+		}
+
+		if descending {
+			for l, r := 0, len(transformed)-1; l < r; l, r = l+1, r-1 {
+				transformed[l], transformed[r] = transformed[r], transformed[l]
+			}
+		}
+		result[i].Values = transformed
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransform(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	// Newest first, as Cloud Monitoring returns points
+	counter := TimeSeriesData{
+		MetricType: "custom.googleapis.com/requests",
+		MetricKind: "CUMULATIVE",
+		Values: []MetricValue{
+			{Value: 30, Timestamp: at(3)}, // reset between minute 2 and 3
+			{Value: 180, Timestamp: at(2)},
+			{Value: 120, Timestamp: at(1)},
+			{Value: 0, Timestamp: at(0)},
+		},
+	}
+	gauge := TimeSeriesData{
+		MetricType: "custom.googleapis.com/queue_depth",
+		MetricKind: "GAUGE",
+		Values:     []MetricValue{{Value: 5, Timestamp: at(1)}, {Value: 8, Timestamp: at(0)}},
+	}
+
+	tests := []struct {
+		transform string
+		want      [][]float64 // newest first, per series
+	}{
+		{transform: TransformDelta, want: [][]float64{{30, 60, 120}, {-3}}},
+		{transform: TransformRate, want: [][]float64{{0.5, 1, 2}, {-0.05}}},
+		{transform: TransformCumsum, want: [][]float64{{330, 300, 120, 0}, {13, 8}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.transform, func(t *testing.T) {
+			got, err := Transform([]TimeSeriesData{counter, gauge}, tt.transform)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for s, want := range tt.want {
+				values := got[s].Values
+				if len(values) != len(want) {
+					t.Fatalf("Expected %v for series %d, got %+v", want, s, values)
+				}
+				for i := range want {
+					if values[i].Value != want[i] {
+						t.Errorf("Expected %v for series %d, got %+v", want, s, values)
+						break
+					}
+				}
+			}
+			if !got[0].Values[0].Timestamp.Equal(at(3)) {
+				t.Errorf("Expected newest point first, got %v", got[0].Values[0].Timestamp)
+			}
+		})
+	}
+
+	if _, err := Transform([]TimeSeriesData{counter}, "integral"); err == nil {
+		t.Error("Expected error for unsupported transform")
+	}
+}
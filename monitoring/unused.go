@@ -0,0 +1,164 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CustomMetricPrefix is the prefix of the types of custom metrics
+	CustomMetricPrefix = "custom.googleapis.com/"
+
+	defaultUnusedDays = 30
+	// maxCheckedDescriptors bounds the descriptors checked for data
+	maxCheckedDescriptors = 500
+	// maxConcurrentChecks limits the metrics checked for data at the same time
+	maxConcurrentChecks = 8
+)
+
+// FindUnusedMetricsRequest represents a request to find the metric
+// descriptors without data
+type FindUnusedMetricsRequest struct {
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	// Prefix selects the descriptors checked; defaults to CustomMetricPrefix
+	Prefix  string    `json:"prefix,omitempty"`
+	Days    int       `json:"days,omitempty"` // defaults to 30
+	EndTime time.Time `json:"end_time"`       // defaults to now
+}
+
+// UnusedMetric represents a metric descriptor without data points in the window
+type UnusedMetric struct {
+	Type        string `json:"type"`
+	DisplayName string `json:"display_name,omitempty"`
+	MetricKind  string `json:"metric_kind"`
+	ValueType   string `json:"value_type"`
+}
+
+// FindUnusedMetricsResponse lists the metric descriptors without data
+type FindUnusedMetricsResponse struct {
+	Checked   int            `json:"checked"` // descriptors checked for data
+	Unused    []UnusedMetric `json:"unused"`
+	Truncated bool           `json:"truncated,omitempty"` // more than maxCheckedDescriptors descriptors matched
+	// Errors maps the metric types that could not be checked to the error
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// FindUnusedMetrics lists the metric descriptors whose type starts with the
+// prefix, and checks which of them had no data points written in the last
+// days before EndTime. Metrics are checked concurrently; a metric that cannot
+// be checked is reported in Errors rather than as unused.
+func (c *CloudMonitoringClient) FindUnusedMetrics(ctx context.Context, req FindUnusedMetricsRequest) (FindUnusedMetricsResponse, error) {
+	if req.Prefix == "" {
+		req.Prefix = CustomMetricPrefix
+	}
+	if req.Days <= 0 {
+		req.Days = defaultUnusedDays
+	}
+	if req.EndTime.IsZero() {
+		req.EndTime = time.Now()
+	}
+
+	var descriptors []MetricDescriptor
+	listReq := ListMetricDescriptorsRequest{
+		ProjectID: req.ProjectID,
+		Filter:    fmt.Sprintf("metric.type = starts_with(%q)", req.Prefix),
+		PageSize:  maxCheckedDescriptors,
+	}
+	resp := FindUnusedMetricsResponse{Unused: []UnusedMetric{}}
+	for {
+		page, err := c.client.ListMetricDescriptors(ctx, listReq)
+		if err != nil {
+			return FindUnusedMetricsResponse{}, fmt.Errorf("failed to list metric descriptors: %w", err)
+		}
+		descriptors = append(descriptors, page.Descriptors...)
+		if page.NextPageToken == "" {
+			break
+		}
+		if len(descriptors) >= maxCheckedDescriptors {
+			resp.Truncated = true
+			break
+		}
+		listReq.PageToken = page.NextPageToken
+	}
+	if len(descriptors) > maxCheckedDescriptors {
+		descriptors = descriptors[:maxCheckedDescriptors]
+		resp.Truncated = true
+	}
+	resp.Checked = len(descriptors)
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxConcurrentChecks)
+	)
+	startTime := req.EndTime.AddDate(0, 0, -req.Days)
+	for _, d := range descriptors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			hasData, err := c.hasData(ctx, req.ProjectID, d, startTime, req.EndTime)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if resp.Errors == nil {
+					resp.Errors = make(map[string]string)
+				}
+				resp.Errors[d.Type] = err.Error()
+				return
+			}
+			if !hasData {
+				resp.Unused = append(resp.Unused, UnusedMetric{
+					Type:        d.Type,
+					DisplayName: d.DisplayName,
+					MetricKind:  d.MetricKind,
+					ValueType:   d.ValueType,
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(resp.Unused, func(a, b UnusedMetric) int { return strings.Compare(a.Type, b.Type) })
+	return resp, nil
+}
+
+// hasData reports whether any point of the metric of d was written between
+// startTime and endTime. The points are aligned over the whole window, so
+// that a series returns a single point.
+func (c *CloudMonitoringClient) hasData(ctx context.Context, projectID string, d MetricDescriptor, startTime, endTime time.Time) (bool, error) {
+	listReq := ListTimeSeriesRequest{
+		ProjectID: projectID,
+		Filter:    fmt.Sprintf("metric.type=%q", d.Type),
+		PageSize:  1,
+	}
+	listReq.Interval.StartTime = startTime
+	listReq.Interval.EndTime = endTime
+	// Strings cannot be aligned; cumulative metrics cannot be counted
+	switch {
+	case d.ValueType == "STRING":
+	case d.MetricKind == "CUMULATIVE":
+		listReq.Aggregation = &AggregationConfig{AlignmentPeriod: windowPeriod(startTime, endTime), PerSeriesAligner: "ALIGN_DELTA"}
+	default:
+		listReq.Aggregation = &AggregationConfig{AlignmentPeriod: windowPeriod(startTime, endTime), PerSeriesAligner: "ALIGN_COUNT"}
+	}
+
+	resp, err := c.client.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return false, err
+	}
+	return len(resp.TimeSeries) > 0, nil
+}
+
+// windowPeriod returns the whole seconds covering the window between
+// startTime and endTime, as an alignment period
+func windowPeriod(startTime, endTime time.Time) string {
+	return fmt.Sprintf("%ds", int64(endTime.Sub(startTime).Seconds()+0.999))
+}
@@ -0,0 +1,80 @@
+package monitoring_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCloudMonitoringClient_FindUnusedMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	end := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+	client := monitoring.NewWithClient(mockClient, "test-project")
+
+	mockClient.EXPECT().
+		ListMetricDescriptors(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
+			if req.Filter != `metric.type = starts_with("custom.googleapis.com/")` {
+				t.Errorf("Unexpected filter %s", req.Filter)
+			}
+			return monitoring.ListMetricDescriptorsResponse{Descriptors: []monitoring.MetricDescriptor{
+				{Type: "custom.googleapis.com/used", MetricKind: "GAUGE", ValueType: "DOUBLE"},
+				{Type: "custom.googleapis.com/stale_counter", MetricKind: "CUMULATIVE", ValueType: "INT64"},
+				{Type: "custom.googleapis.com/stale_status", MetricKind: "GAUGE", ValueType: "STRING"},
+				{Type: "custom.googleapis.com/forbidden", MetricKind: "GAUGE", ValueType: "DOUBLE"},
+			}}, nil
+		})
+	mockClient.EXPECT().
+		ListTimeSeries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+			if !req.Interval.StartTime.Equal(end.AddDate(0, 0, -7)) {
+				t.Errorf("Expected a 7 day window, got %s", req.Interval.StartTime)
+			}
+			switch req.Filter {
+			case `metric.type="custom.googleapis.com/used"`:
+				if req.Aggregation == nil || req.Aggregation.PerSeriesAligner != "ALIGN_COUNT" || req.Aggregation.AlignmentPeriod != "604800s" {
+					t.Errorf("Expected points counted over the window, got %+v", req.Aggregation)
+				}
+				return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{{Values: []monitoring.MetricValue{{Value: 3, Timestamp: end}}}}}, nil
+			case `metric.type="custom.googleapis.com/stale_counter"`:
+				if req.Aggregation == nil || req.Aggregation.PerSeriesAligner != "ALIGN_DELTA" {
+					t.Errorf("Expected cumulative points to be aligned by delta, got %+v", req.Aggregation)
+				}
+			case `metric.type="custom.googleapis.com/stale_status"`:
+				if req.Aggregation != nil {
+					t.Errorf("Expected strings not to be aligned, got %+v", req.Aggregation)
+				}
+			case `metric.type="custom.googleapis.com/forbidden"`:
+				return monitoring.ListTimeSeriesResponse{}, errors.New("permission denied")
+			}
+			return monitoring.ListTimeSeriesResponse{}, nil
+		}).
+		Times(4)
+
+	resp, err := client.FindUnusedMetrics(context.Background(), monitoring.FindUnusedMetricsRequest{
+		Days:    7,
+		EndTime: end,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.Checked != 4 || resp.Truncated {
+		t.Errorf("Expected 4 descriptors checked, got %d (truncated: %v)", resp.Checked, resp.Truncated)
+	}
+	if len(resp.Unused) != 2 || resp.Unused[0].Type != "custom.googleapis.com/stale_counter" || resp.Unused[1].Type != "custom.googleapis.com/stale_status" {
+		t.Errorf("Unexpected unused metrics %+v", resp.Unused)
+	}
+	// A metric that cannot be checked is not reported as unused
+	if resp.Errors["custom.googleapis.com/forbidden"] != "permission denied" {
+		t.Errorf("Expected the failed check to be reported, got %v", resp.Errors)
+	}
+}
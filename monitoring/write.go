@@ -0,0 +1,108 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"google.golang.org/grpc/status"
+)
+
+// maxSeriesPerWrite is the number of series Cloud Monitoring accepts in one
+// write
+const maxSeriesPerWrite = 200
+
+// WriteChunkResult represents the outcome of writing one chunk of series
+type WriteChunkResult struct {
+	// Start and End delimit the series of the chunk in the request, End excluded
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Failed is the number of series of the chunk that were not written
+	Failed int    `json:"failed,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	err error
+}
+
+// PartialWriteError is returned when some of the series of a write were not
+// written, either because some of the chunks of a write that was split into
+// several calls failed, or because a call wrote only some of its series.
+type PartialWriteError struct {
+	Written int                `json:"written"` // series written
+	Failed  int                `json:"failed"`  // series not written
+	Chunks  []WriteChunkResult `json:"chunks"`  // one item per chunk, in order
+}
+
+// Error implements the error interface
+func (e *PartialWriteError) Error() string {
+	var failed []string
+	for _, chunk := range e.Chunks {
+		if chunk.err != nil {
+			failed = append(failed, fmt.Sprintf("series %d-%d: %v", chunk.Start, chunk.End-1, chunk.err))
+		}
+	}
+	return fmt.Sprintf("wrote %d series, but failed to write %d: %s", e.Written, e.Failed, strings.Join(failed, "; "))
+}
+
+// Unwrap returns the errors of the failed chunks, e.g. so that errors.Is
+// finds a canceled context
+func (e *PartialWriteError) Unwrap() []error {
+	var errs []error
+	for _, chunk := range e.Chunks {
+		if chunk.err != nil {
+			errs = append(errs, chunk.err)
+		}
+	}
+	return errs
+}
+
+// writeChunked writes the series of req in chunks of at most
+// maxSeriesPerWrite series, one call per chunk. Every chunk is written even
+// when an earlier one fails, and a *PartialWriteError reports the series that
+// were not written. A write that fits in one call returns the error of that
+// call when none of its series was written.
+func (c *CloudMonitoringClient) writeChunked(ctx context.Context, req WriteTimeSeriesRequest) error {
+	partial := &PartialWriteError{}
+	for start := 0; start < len(req.TimeSeries); start += maxSeriesPerWrite {
+		end := min(start+maxSeriesPerWrite, len(req.TimeSeries))
+		chunk := WriteChunkResult{Start: start, End: end}
+		if err := c.client.WriteTimeSeries(ctx, WriteTimeSeriesRequest{ProjectID: req.ProjectID, TimeSeries: req.TimeSeries[start:end]}); err != nil {
+			chunk.Error, chunk.err = err.Error(), err
+			chunk.Failed = failedSeries(err, end-start)
+		}
+		partial.Failed += chunk.Failed
+		partial.Written += end - start - chunk.Failed
+		partial.Chunks = append(partial.Chunks, chunk)
+	}
+	switch {
+	case partial.Failed == 0:
+		return nil
+	case len(partial.Chunks) == 1 && partial.Written == 0:
+		return partial.Chunks[0].err
+	}
+	return partial
+}
+
+// failedSeries returns the number of the size series of a call that were not
+// written, given the error of the call. CreateTimeSeries writes the valid
+// series of a call and describes the others in a CreateTimeSeriesSummary
+// detail of its error; without that detail, no series was written.
+func failedSeries(err error, size int) int {
+	st, ok := status.FromError(err)
+	if !ok {
+		return size
+	}
+	for _, detail := range st.Details() {
+		summary, ok := detail.(*monitoringpb.CreateTimeSeriesSummary)
+		if !ok {
+			continue
+		}
+		// Every series of a call has one point, so the points not written
+		// are the series not written
+		if failed := int(summary.GetTotalPointCount() - summary.GetSuccessPointCount()); failed > 0 {
+			return min(failed, size)
+		}
+	}
+	return size
+}
@@ -0,0 +1,149 @@
+package monitoring_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCloudMonitoringClient_WriteTimeSeriesChunks(t *testing.T) {
+	series := func(n int) []monitoring.TimeSeriesData {
+		result := make([]monitoring.TimeSeriesData, n)
+		for i := range result {
+			result[i] = monitoring.TimeSeriesData{
+				MetricType:   "custom.googleapis.com/queue_depth",
+				MetricLabels: map[string]string{"queue": fmt.Sprint(i)},
+				ResourceType: "global",
+				Values:       []monitoring.MetricValue{{Value: 1, Timestamp: time.Now()}},
+			}
+		}
+		return result
+	}
+
+	t.Run("writes up to 200 series in one call", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+		client := monitoring.NewWithClient(mockClient, "test-project")
+		writeErr := errors.New("permission denied")
+		mockClient.EXPECT().WriteTimeSeries(gomock.Any(), gomock.Any()).Return(writeErr)
+
+		// The error of a single call is returned as is
+		err := client.WriteTimeSeries(context.Background(), monitoring.WriteTimeSeriesRequest{TimeSeries: series(200)})
+		if err != writeErr {
+			t.Errorf("WriteTimeSeries() error = %v, want %v", err, writeErr)
+		}
+	})
+
+	t.Run("reports the failed chunks", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+		client := monitoring.NewWithClient(mockClient, "test-project")
+		var sizes []int
+		mockClient.EXPECT().
+			WriteTimeSeries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req monitoring.WriteTimeSeriesRequest) error {
+				if req.ProjectID != "other-project" {
+					t.Errorf("Expected the project of the request, got %q", req.ProjectID)
+				}
+				sizes = append(sizes, len(req.TimeSeries))
+				if req.TimeSeries[0].MetricLabels["queue"] == "200" {
+					return context.DeadlineExceeded
+				}
+				return nil
+			}).
+			Times(3)
+
+		err := client.WriteTimeSeries(context.Background(), monitoring.WriteTimeSeriesRequest{ProjectID: "other-project", TimeSeries: series(450)})
+		if fmt.Sprint(sizes) != "[200 200 50]" {
+			t.Errorf("Expected chunks of 200, 200 and 50 series, got %v", sizes)
+		}
+		var partial *monitoring.PartialWriteError
+		if !errors.As(err, &partial) {
+			t.Fatalf("Expected a *PartialWriteError, got %v", err)
+		}
+		if partial.Written != 250 || partial.Failed != 200 || len(partial.Chunks) != 3 {
+			t.Errorf("Unexpected partial write %+v", partial)
+		}
+		if chunk := partial.Chunks[1]; chunk.Start != 200 || chunk.End != 400 || chunk.Error == "" || partial.Chunks[0].Error != "" {
+			t.Errorf("Expected the second chunk to fail, got %+v", partial.Chunks)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the error to wrap the chunk error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "series 200-399") {
+			t.Errorf("Expected the error to name the failed series, got %v", err)
+		}
+	})
+	// summaryError returns the error of a call that wrote success of its
+	// total series
+	summaryError := func(t *testing.T, total, success int32) error {
+		t.Helper()
+		st, err := status.New(codes.InvalidArgument, "One or more TimeSeries could not be written").
+			WithDetails(&monitoringpb.CreateTimeSeriesSummary{TotalPointCount: total, SuccessPointCount: success})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Err()
+	}
+
+	t.Run("counts the series a chunk partly wrote", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+		client := monitoring.NewWithClient(mockClient, "test-project")
+		mockClient.EXPECT().
+			WriteTimeSeries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req monitoring.WriteTimeSeriesRequest) error {
+				if req.TimeSeries[0].MetricLabels["queue"] == "0" {
+					return summaryError(t, 200, 197)
+				}
+				return nil
+			}).
+			Times(2)
+
+		err := client.WriteTimeSeries(context.Background(), monitoring.WriteTimeSeriesRequest{TimeSeries: series(250)})
+		var partial *monitoring.PartialWriteError
+		if !errors.As(err, &partial) {
+			t.Fatalf("Expected a *PartialWriteError, got %v", err)
+		}
+		if partial.Written != 247 || partial.Failed != 3 || partial.Chunks[0].Failed != 3 || partial.Chunks[1].Failed != 0 {
+			t.Errorf("Expected 3 series of the first chunk to fail, got %+v", partial)
+		}
+	})
+
+	t.Run("reports a call that partly wrote its series", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient := mocks.NewMockMonitoringClientInterface(ctrl)
+		client := monitoring.NewWithClient(mockClient, "test-project")
+		mockClient.EXPECT().WriteTimeSeries(gomock.Any(), gomock.Any()).Return(summaryError(t, 10, 8))
+
+		err := client.WriteTimeSeries(context.Background(), monitoring.WriteTimeSeriesRequest{TimeSeries: series(10)})
+		var partial *monitoring.PartialWriteError
+		if !errors.As(err, &partial) {
+			t.Fatalf("Expected a *PartialWriteError, got %v", err)
+		}
+		if partial.Written != 8 || partial.Failed != 2 || len(partial.Chunks) != 1 {
+			t.Errorf("Expected 2 of the 10 series to fail, got %+v", partial)
+		}
+		if errs := partial.Unwrap(); len(errs) != 1 || status.Code(errs[0]) != codes.InvalidArgument {
+			t.Errorf("Expected the error to wrap the call error, got %v", err)
+		}
+	})
+}
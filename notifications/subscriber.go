@@ -0,0 +1,358 @@
+// Package notifications receives Cloud Monitoring alert notifications from a
+// Pub/Sub subscription, so that the agent learns about alerts as they arrive.
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// pubsubEndpoint is the base URL of the Pub/Sub REST API
+const pubsubEndpoint = "https://pubsub.googleapis.com/v1"
+
+// pubsubScope is the OAuth scope for pulling and acknowledging messages
+const pubsubScope = "https://www.googleapis.com/auth/pubsub"
+
+const (
+	// defaultBufferSize is the number of recent notifications kept in memory
+	defaultBufferSize = 200
+	// maxMessagesPerPull bounds the number of messages returned by one pull
+	maxMessagesPerPull = 100
+	// retryDelay is how long to wait after a failed pull
+	retryDelay = 10 * time.Second
+	// defaultListLimit is the number of notifications listed by default
+	defaultListLimit = 20
+)
+
+// Incident states
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+)
+
+// Notification represents an alert notification sent by Cloud Monitoring
+type Notification struct {
+	MessageID         string            `json:"message_id"`
+	ReceivedAt        time.Time         `json:"received_at"`
+	IncidentID        string            `json:"incident_id"`
+	State             string            `json:"state"` // StateOpen or StateClosed
+	Severity          string            `json:"severity,omitempty"`
+	Summary           string            `json:"summary"`
+	PolicyName        string            `json:"policy_name"`
+	ConditionName     string            `json:"condition_name,omitempty"`
+	ScopingProjectID  string            `json:"scoping_project_id,omitempty"`
+	ResourceName      string            `json:"resource_name,omitempty"`
+	ResourceType      string            `json:"resource_type,omitempty"`
+	ResourceLabels    map[string]string `json:"resource_labels,omitempty"`
+	MetricType        string            `json:"metric_type,omitempty"`
+	MetricDisplayName string            `json:"metric_display_name,omitempty"`
+	MetricLabels      map[string]string `json:"metric_labels,omitempty"`
+	ObservedValue     string            `json:"observed_value,omitempty"`
+	ThresholdValue    string            `json:"threshold_value,omitempty"`
+	StartedAt         time.Time         `json:"started_at,omitzero"`
+	EndedAt           *time.Time        `json:"ended_at,omitempty"`
+	Documentation     string            `json:"documentation,omitempty"`
+	URL               string            `json:"url,omitempty"`
+}
+
+// payload is the JSON schema (version 1.2) of Pub/Sub alert notifications
+type payload struct {
+	Incident struct {
+		IncidentID       string `json:"incident_id"`
+		ScopingProjectID string `json:"scoping_project_id"`
+		URL              string `json:"url"`
+		StartedAt        int64  `json:"started_at"`
+		EndedAt          int64  `json:"ended_at"`
+		State            string `json:"state"`
+		Summary          string `json:"summary"`
+		ObservedValue    string `json:"observed_value"`
+		ThresholdValue   string `json:"threshold_value"`
+		Severity         string `json:"severity"`
+		ResourceName     string `json:"resource_name"`
+		Resource         struct {
+			Type   string            `json:"type"`
+			Labels map[string]string `json:"labels"`
+		} `json:"resource"`
+		Metric struct {
+			Type        string            `json:"type"`
+			DisplayName string            `json:"displayName"`
+			Labels      map[string]string `json:"labels"`
+		} `json:"metric"`
+		PolicyName    string `json:"policy_name"`
+		ConditionName string `json:"condition_name"`
+		Documentation struct {
+			Content string `json:"content"`
+		} `json:"documentation"`
+	} `json:"incident"`
+	Version string `json:"version"`
+}
+
+// Parse parses the data of an alert notification message
+func Parse(data []byte) (Notification, error) {
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Notification{}, fmt.Errorf("failed to parse notification: %w", err)
+	}
+	if p.Incident.IncidentID == "" {
+		return Notification{}, fmt.Errorf("message is not an alert notification")
+	}
+
+	i := p.Incident
+	n := Notification{
+		IncidentID:        i.IncidentID,
+		State:             strings.ToLower(i.State),
+		Severity:          i.Severity,
+		Summary:           i.Summary,
+		PolicyName:        i.PolicyName,
+		ConditionName:     i.ConditionName,
+		ScopingProjectID:  i.ScopingProjectID,
+		ResourceName:      i.ResourceName,
+		ResourceType:      i.Resource.Type,
+		ResourceLabels:    i.Resource.Labels,
+		MetricType:        i.Metric.Type,
+		MetricDisplayName: i.Metric.DisplayName,
+		MetricLabels:      i.Metric.Labels,
+		ObservedValue:     i.ObservedValue,
+		ThresholdValue:    i.ThresholdValue,
+		Documentation:     i.Documentation.Content,
+		URL:               i.URL,
+	}
+	if i.StartedAt > 0 {
+		n.StartedAt = time.Unix(i.StartedAt, 0).UTC()
+	}
+	if i.EndedAt > 0 {
+		endedAt := time.Unix(i.EndedAt, 0).UTC()
+		n.EndedAt = &endedAt
+	}
+	return n, nil
+}
+
+// ListRequest represents a request to list recent notifications
+type ListRequest struct {
+	State  string    `json:"state,omitempty"`  // StateOpen or StateClosed; all when empty
+	Policy string    `json:"policy,omitempty"` // case-insensitive substring of the policy name
+	Since  time.Time `json:"since,omitzero"`
+	Limit  int       `json:"limit,omitempty"`
+}
+
+// ListResponse lists recent notifications, newest first
+type ListResponse struct {
+	Subscription  string         `json:"subscription"`
+	Notifications []Notification `json:"notifications"`
+	LastPull      time.Time      `json:"last_pull,omitzero"`
+	LastError     string         `json:"last_error,omitempty"`
+	Skipped       int            `json:"skipped,omitempty"` // messages that were not alert notifications
+}
+
+// Subscriber pulls alert notifications from a Pub/Sub subscription
+type Subscriber struct {
+	subscription string
+	endpoint     string
+	httpClient   *http.Client
+	bufferSize   int
+
+	mu        sync.Mutex
+	recent    []Notification // oldest first
+	handlers  []func(Notification)
+	lastPull  time.Time
+	lastError string
+	skipped   int
+}
+
+// NewSubscriber creates a subscriber for a subscription given as
+// projects/PROJECT/subscriptions/SUBSCRIPTION, or as a subscription ID in projectID
+func NewSubscriber(ctx context.Context, projectID, subscription string, opts ...option.ClientOption) (*Subscriber, error) {
+	httpClient, _, err := htransport.NewClient(ctx, append([]option.ClientOption{option.WithScopes(pubsubScope)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	return NewSubscriberWithClient(httpClient, pubsubEndpoint, SubscriptionName(projectID, subscription)), nil
+}
+
+// NewSubscriberWithClient creates a subscriber with a custom HTTP client and endpoint for testing
+func NewSubscriberWithClient(httpClient *http.Client, endpoint, subscription string) *Subscriber {
+	return &Subscriber{
+		subscription: subscription,
+		endpoint:     endpoint,
+		httpClient:   httpClient,
+		bufferSize:   defaultBufferSize,
+	}
+}
+
+// SubscriptionName returns the full name of a subscription given by its ID or full name
+func SubscriptionName(projectID, subscription string) string {
+	if strings.HasPrefix(subscription, "projects/") {
+		return subscription
+	}
+	return fmt.Sprintf("projects/%s/subscriptions/%s", projectID, subscription)
+}
+
+// OnNotification registers a function called with each new notification
+func (s *Subscriber) OnNotification(handler func(Notification)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+// Run pulls notifications until the context is canceled. Failed pulls are
+// retried and reported by List.
+func (s *Subscriber) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := s.Pull(ctx)
+		s.mu.Lock()
+		s.lastPull = time.Now()
+		s.lastError = ""
+		if err != nil {
+			s.lastError = err.Error()
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+		}
+	}
+}
+
+// Pull pulls one batch of messages, records the notifications among them,
+// and acknowledges all of them
+func (s *Subscriber) Pull(ctx context.Context) error {
+	var resp struct {
+		ReceivedMessages []struct {
+			AckID   string `json:"ackId"`
+			Message struct {
+				Data        string    `json:"data"`
+				MessageID   string    `json:"messageId"`
+				PublishTime time.Time `json:"publishTime"`
+			} `json:"message"`
+		} `json:"receivedMessages"`
+	}
+	if err := s.call(ctx, "pull", map[string]any{"maxMessages": maxMessagesPerPull}, &resp); err != nil {
+		return err
+	}
+	if len(resp.ReceivedMessages) == 0 {
+		return nil
+	}
+
+	var ackIDs []string
+	var received []Notification
+	skipped := 0
+	for _, m := range resp.ReceivedMessages {
+		// Messages that are not alert notifications are acknowledged too, so
+		// that they are not redelivered forever
+		ackIDs = append(ackIDs, m.AckID)
+		data, err := base64.StdEncoding.DecodeString(m.Message.Data)
+		if err != nil {
+			skipped++
+			continue
+		}
+		n, err := Parse(data)
+		if err != nil {
+			skipped++
+			continue
+		}
+		n.MessageID = m.Message.MessageID
+		n.ReceivedAt = m.Message.PublishTime
+		received = append(received, n)
+	}
+	if err := s.call(ctx, "acknowledge", map[string]any{"ackIds": ackIDs}, nil); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.skipped += skipped
+	s.recent = append(s.recent, received...)
+	if len(s.recent) > s.bufferSize {
+		s.recent = s.recent[len(s.recent)-s.bufferSize:]
+	}
+	handlers := s.handlers
+	s.mu.Unlock()
+
+	for _, n := range received {
+		for _, handler := range handlers {
+			handler(n)
+		}
+	}
+	return nil
+}
+
+// call posts a request to a subscription method of the Pub/Sub API
+func (s *Subscriber) call(ctx context.Context, method string, body any, result any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	u := fmt.Sprintf("%s/%s:%s", s.endpoint, s.subscription, method)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to %s messages: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to %s messages: %s: %s", method, resp.Status, respBody)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", method, err)
+	}
+	return nil
+}
+
+// List returns the recent notifications matching the request, newest first
+func (s *Subscriber) List(req ListRequest) ListResponse {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := ListResponse{
+		Subscription:  s.subscription,
+		Notifications: []Notification{},
+		LastPull:      s.lastPull,
+		LastError:     s.lastError,
+		Skipped:       s.skipped,
+	}
+	for i := len(s.recent) - 1; i >= 0 && len(resp.Notifications) < limit; i-- {
+		n := s.recent[i]
+		if req.State != "" && n.State != req.State {
+			continue
+		}
+		if req.Policy != "" && !strings.Contains(strings.ToLower(n.PolicyName), strings.ToLower(req.Policy)) {
+			continue
+		}
+		if !req.Since.IsZero() && n.ReceivedAt.Before(req.Since) {
+			continue
+		}
+		resp.Notifications = append(resp.Notifications, n)
+	}
+	return resp
+}
@@ -0,0 +1,159 @@
+package notifications_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/notifications"
+)
+
+const openIncident = `{
+  "incident": {
+    "incident_id": "0.opqiw61fsv7p",
+    "scoping_project_id": "my-project",
+    "url": "https://console.cloud.google.com/monitoring/alerting/incidents/0.opqiw61fsv7p?project=my-project",
+    "started_at": 1577840461,
+    "ended_at": 0,
+    "state": "open",
+    "summary": "CPU utilization for my-vm is above the threshold of 0.9 with a value of 0.95.",
+    "observed_value": "0.95",
+    "threshold_value": "0.9",
+    "severity": "Critical",
+    "resource_name": "my-project my-vm",
+    "resource": {"type": "gce_instance", "labels": {"instance_id": "123", "zone": "us-central1-a"}},
+    "metric": {"type": "compute.googleapis.com/instance/cpu/utilization", "displayName": "CPU utilization", "labels": {}},
+    "policy_name": "High CPU",
+    "condition_name": "VM Instance - CPU utilization",
+    "documentation": {"content": "Scale out the instance group", "mime_type": "text/markdown"}
+  },
+  "version": "1.2"
+}`
+
+func TestParse(t *testing.T) {
+	n, err := notifications.Parse([]byte(openIncident))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n.IncidentID != "0.opqiw61fsv7p" || n.State != notifications.StateOpen || n.PolicyName != "High CPU" {
+		t.Errorf("Unexpected notification %+v", n)
+	}
+	if !n.StartedAt.Equal(time.Unix(1577840461, 0)) || n.EndedAt != nil {
+		t.Errorf("Unexpected incident times %v - %v", n.StartedAt, n.EndedAt)
+	}
+	if n.ResourceType != "gce_instance" || n.ResourceLabels["zone"] != "us-central1-a" {
+		t.Errorf("Unexpected resource %s %v", n.ResourceType, n.ResourceLabels)
+	}
+	if n.MetricType != "compute.googleapis.com/instance/cpu/utilization" || n.Documentation != "Scale out the instance group" {
+		t.Errorf("Unexpected metric or documentation %+v", n)
+	}
+
+	if _, err := notifications.Parse([]byte(`{"hello": "world"}`)); err == nil {
+		t.Error("Expected error for a message that is not an alert notification")
+	}
+}
+
+func TestSubscriptionName(t *testing.T) {
+	if got := notifications.SubscriptionName("my-project", "alerts"); got != "projects/my-project/subscriptions/alerts" {
+		t.Errorf("Unexpected subscription name %s", got)
+	}
+	if got := notifications.SubscriptionName("my-project", "projects/other/subscriptions/alerts"); got != "projects/other/subscriptions/alerts" {
+		t.Errorf("Unexpected subscription name %s", got)
+	}
+}
+
+func TestSubscriber_Pull(t *testing.T) {
+	closedIncident := strings.Replace(strings.Replace(openIncident, `"state": "open"`, `"state": "closed"`, 1), `"ended_at": 0`, `"ended_at": 1577841000`, 1)
+	messages := []string{openIncident, `not json`, closedIncident}
+
+	var acked []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/projects/my-project/subscriptions/alerts:pull":
+			var received []map[string]any
+			for i, m := range messages {
+				received = append(received, map[string]any{
+					"ackId": fmt.Sprintf("ack-%d", i),
+					"message": map[string]any{
+						"data":        base64.StdEncoding.EncodeToString([]byte(m)),
+						"messageId":   fmt.Sprintf("msg-%d", i),
+						"publishTime": time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC).Format(time.RFC3339),
+					},
+				})
+			}
+			messages = nil
+			json.NewEncoder(w).Encode(map[string]any{"receivedMessages": received})
+		case "/v1/projects/my-project/subscriptions/alerts:acknowledge":
+			var body struct {
+				AckIDs []string `json:"ackIds"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			acked = append(acked, body.AckIDs...)
+			w.Write([]byte("{}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	sub := notifications.NewSubscriberWithClient(ts.Client(), ts.URL+"/v1", "projects/my-project/subscriptions/alerts")
+	var delivered []notifications.Notification
+	sub.OnNotification(func(n notifications.Notification) {
+		delivered = append(delivered, n)
+	})
+
+	if err := sub.Pull(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// An empty pull is not an error
+	if err := sub.Pull(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(acked) != 3 {
+		t.Errorf("Expected all 3 messages to be acknowledged, got %v", acked)
+	}
+	if len(delivered) != 2 || delivered[0].MessageID != "msg-0" || delivered[1].State != notifications.StateClosed {
+		t.Errorf("Unexpected delivered notifications %+v", delivered)
+	}
+
+	resp := sub.List(notifications.ListRequest{})
+	if len(resp.Notifications) != 2 || resp.Notifications[0].MessageID != "msg-2" || resp.Skipped != 1 {
+		t.Errorf("Expected newest first and 1 skipped message, got %+v", resp)
+	}
+	if resp.Notifications[0].EndedAt == nil {
+		t.Error("Expected the closed incident to have an end time")
+	}
+
+	resp = sub.List(notifications.ListRequest{State: notifications.StateOpen})
+	if len(resp.Notifications) != 1 || resp.Notifications[0].MessageID != "msg-0" {
+		t.Errorf("Unexpected open notifications %+v", resp.Notifications)
+	}
+	resp = sub.List(notifications.ListRequest{Since: time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)})
+	if len(resp.Notifications) != 1 {
+		t.Errorf("Expected 1 notification since 00:01, got %+v", resp.Notifications)
+	}
+	resp = sub.List(notifications.ListRequest{Policy: "memory"})
+	if len(resp.Notifications) != 0 {
+		t.Errorf("Expected no notifications for the memory policy, got %+v", resp.Notifications)
+	}
+}
+
+func TestSubscriber_PullError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"message": "permission denied"}}`, http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	sub := notifications.NewSubscriberWithClient(ts.Client(), ts.URL+"/v1", "projects/my-project/subscriptions/alerts")
+	err := sub.Pull(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected permission denied error, got %v", err)
+	}
+}
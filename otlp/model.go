@@ -0,0 +1,160 @@
+package otlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The types below mirror the OTLP/JSON encoding of ExportTraceServiceRequest
+// and ExportLogsServiceRequest. Only the fields forwarded to Google Cloud are decoded.
+
+type exportTraceRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Spans []span `json:"spans"`
+}
+
+type span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano uint64Str  `json:"startTimeUnixNano"`
+	EndTimeUnixNano   uint64Str  `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes"`
+}
+
+type exportLogsRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type logRecord struct {
+	TimeUnixNano         uint64Str  `json:"timeUnixNano"`
+	ObservedTimeUnixNano uint64Str  `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes"`
+	Flags                uint32     `json:"flags"`
+	TraceID              string     `json:"traceId"`
+	SpanID               string     `json:"spanId"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string   `json:"stringValue"`
+	BoolValue   *bool     `json:"boolValue"`
+	IntValue    *int64Str `json:"intValue"`
+	DoubleValue *float64  `json:"doubleValue"`
+	ArrayValue  *struct {
+		Values []anyValue `json:"values"`
+	} `json:"arrayValue"`
+	KvlistValue *struct {
+		Values []keyValue `json:"values"`
+	} `json:"kvlistValue"`
+	BytesValue *string `json:"bytesValue"` // base64 encoded
+}
+
+// value converts the value to its Go representation
+func (v anyValue) value() any {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		return int64(*v.IntValue)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.ArrayValue != nil:
+		values := make([]any, 0, len(v.ArrayValue.Values))
+		for _, value := range v.ArrayValue.Values {
+			values = append(values, value.value())
+		}
+		return values
+	case v.KvlistValue != nil:
+		return attributeMap(v.KvlistValue.Values)
+	case v.BytesValue != nil:
+		return *v.BytesValue
+	default:
+		return nil
+	}
+}
+
+// String formats the value as a label value
+func (v anyValue) String() string {
+	switch value := v.value().(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []any, map[string]any:
+		b, _ := json.Marshal(value)
+		return string(b)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// attributeMap converts attributes to a map usable as a structured payload
+func attributeMap(attributes []keyValue) map[string]any {
+	m := make(map[string]any, len(attributes))
+	for _, attr := range attributes {
+		m[attr.Key] = attr.Value.value()
+	}
+	return m
+}
+
+// uint64Str decodes 64-bit integers, which OTLP/JSON encodes as strings
+// but some exporters send as numbers
+type uint64Str uint64
+
+// UnmarshalJSON implements json.Unmarshaler
+func (u *uint64Str) UnmarshalJSON(data []byte) error {
+	n, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid unsigned integer %s: %w", data, err)
+	}
+	*u = uint64Str(n)
+	return nil
+}
+
+// int64Str decodes signed 64-bit integers encoded as strings or numbers
+type int64Str int64
+
+// UnmarshalJSON implements json.Unmarshaler
+func (i *int64Str) UnmarshalJSON(data []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", data, err)
+	}
+	*i = int64Str(n)
+	return nil
+}
@@ -0,0 +1,319 @@
+// Package otlp implements an OTLP/HTTP receiver that forwards spans to Cloud
+// Trace and logs to Cloud Logging, so that applications instrumented with
+// OpenTelemetry can export to the MCP server as a local telemetry gateway.
+package otlp
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+// Paths of the OTLP/HTTP signals handled by the receiver
+const (
+	TracesPath = "/v1/traces"
+	LogsPath   = "/v1/logs"
+)
+
+const (
+	// maxRequestSize limits the decompressed size of an export request
+	maxRequestSize = 32 << 20
+	// defaultLogName is used for logs whose resource has no service.name
+	defaultLogName = "otlp"
+)
+
+// gRPC status codes reported in error responses, as required by OTLP/HTTP
+const (
+	codeInvalidArgument = 3
+	codeUnavailable     = 14
+)
+
+// invalidLogNameChars matches characters not allowed in a Cloud Logging log ID
+var invalidLogNameChars = regexp.MustCompile(`[^A-Za-z0-9/_.-]`)
+
+// Receiver accepts OTLP/HTTP exports in the JSON encoding
+type Receiver struct {
+	logging   logging.LoggingClient
+	trace     trace.TraceClient
+	projectID string
+}
+
+// NewReceiver creates a Receiver writing to the given project
+func NewReceiver(loggingClient logging.LoggingClient, traceClient trace.TraceClient, projectID string) *Receiver {
+	return &Receiver{
+		logging:   loggingClient,
+		trace:     traceClient,
+		projectID: projectID,
+	}
+}
+
+// ServeHTTP implements http.Handler for TracesPath and LogsPath
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, codeInvalidArgument, "only POST is supported")
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, codeInvalidArgument, "only the OTLP/JSON encoding is supported; configure the exporter to use the http/json protocol")
+		return
+	}
+
+	switch req.URL.Path {
+	case TracesPath:
+		var export exportTraceRequest
+		if !decode(w, req, &export) {
+			return
+		}
+		r.exportTraces(w, req, export)
+	case LogsPath:
+		var export exportLogsRequest
+		if !decode(w, req, &export) {
+			return
+		}
+		r.exportLogs(w, req, export)
+	default:
+		writeError(w, http.StatusNotFound, codeInvalidArgument, fmt.Sprintf("unknown OTLP path %s", req.URL.Path))
+	}
+}
+
+// exportTraces patches the spans of every trace in the request
+func (r *Receiver) exportTraces(w http.ResponseWriter, req *http.Request, export exportTraceRequest) {
+	var traceIDs []string
+	spansByTrace := make(map[string][]trace.Span)
+	for _, rs := range export.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				if _, ok := spansByTrace[s.TraceID]; !ok {
+					traceIDs = append(traceIDs, s.TraceID)
+				}
+				spansByTrace[s.TraceID] = append(spansByTrace[s.TraceID], convertSpan(s, rs.Resource))
+			}
+		}
+	}
+
+	var rejected int
+	var problems []string
+	for _, traceID := range traceIDs {
+		spans := spansByTrace[traceID]
+		err := r.trace.PatchTraces(req.Context(), trace.PatchTraceRequest{
+			ProjectID:           r.projectID,
+			TraceID:             traceID,
+			Spans:               spans,
+			AllowMissingParents: true,
+		})
+
+		var validationErr *trace.ValidationError
+		if errors.As(err, &validationErr) {
+			// Invalid traces are dropped; retrying them would not help
+			rejected += len(spans)
+			problems = append(problems, fmt.Sprintf("trace %s: %v", traceID, err))
+			continue
+		}
+		if err != nil {
+			writeError(w, http.StatusBadGateway, codeUnavailable, fmt.Sprintf("failed to write trace %s: %v", traceID, err))
+			return
+		}
+	}
+
+	resp := map[string]any{}
+	if rejected > 0 {
+		resp["partialSuccess"] = map[string]any{
+			"rejectedSpans": fmt.Sprint(rejected),
+			"errorMessage":  strings.Join(problems, "; "),
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// convertSpan converts an OTLP span to a Cloud Trace span.
+// Resource attributes become labels, overridden by span attributes of the same key.
+func convertSpan(s span, res resource) trace.Span {
+	labels := make(map[string]string, len(res.Attributes)+len(s.Attributes))
+	for _, attr := range res.Attributes {
+		labels[attr.Key] = attr.Value.String()
+	}
+	for _, attr := range s.Attributes {
+		labels[attr.Key] = attr.Value.String()
+	}
+
+	converted := trace.Span{
+		SpanID:    s.SpanID,
+		ParentID:  s.ParentSpanID,
+		Name:      s.Name,
+		StartTime: unixNano(s.StartTimeUnixNano),
+		EndTime:   unixNano(s.EndTimeUnixNano),
+		Labels:    labels,
+	}
+
+	// Cloud Trace only distinguishes server and client spans
+	switch s.Kind {
+	case 2: // SPAN_KIND_SERVER
+		converted.Kind = "RPC_SERVER"
+	case 3: // SPAN_KIND_CLIENT
+		converted.Kind = "RPC_CLIENT"
+	}
+
+	return converted
+}
+
+// exportLogs writes the log records of every resource to a log named after its service
+func (r *Receiver) exportLogs(w http.ResponseWriter, req *http.Request, export exportLogsRequest) {
+	var logNames []string
+	entriesByLog := make(map[string][]logging.LogEntry)
+	for _, rl := range export.ResourceLogs {
+		logName := resourceLogName(rl.Resource)
+		for _, sl := range rl.ScopeLogs {
+			for _, record := range sl.LogRecords {
+				if _, ok := entriesByLog[logName]; !ok {
+					logNames = append(logNames, logName)
+				}
+				entriesByLog[logName] = append(entriesByLog[logName], r.convertLogRecord(record, rl.Resource))
+			}
+		}
+	}
+
+	for _, logName := range logNames {
+		err := r.logging.WriteEntries(req.Context(), logging.WriteEntriesRequest{
+			LogName: fmt.Sprintf("projects/%s/logs/%s", r.projectID, logName),
+			Entries: entriesByLog[logName],
+			Async:   true,
+		})
+		if err != nil {
+			writeError(w, http.StatusBadGateway, codeUnavailable, fmt.Sprintf("failed to write logs to %s: %v", logName, err))
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+// convertLogRecord converts an OTLP log record to a Cloud Logging entry.
+// A string body becomes the message and a map body the structured payload.
+func (r *Receiver) convertLogRecord(record logRecord, res resource) logging.LogEntry {
+	labels := make(map[string]string, len(res.Attributes)+len(record.Attributes))
+	for _, attr := range res.Attributes {
+		labels[attr.Key] = attr.Value.String()
+	}
+	for _, attr := range record.Attributes {
+		labels[attr.Key] = attr.Value.String()
+	}
+
+	entry := logging.LogEntry{
+		Severity:     severity(record.SeverityNumber, record.SeverityText),
+		Labels:       labels,
+		Timestamp:    unixNano(record.TimeUnixNano),
+		SpanID:       record.SpanID,
+		TraceSampled: record.Flags&1 == 1,
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = unixNano(record.ObservedTimeUnixNano)
+	}
+	if record.TraceID != "" {
+		entry.Trace = fmt.Sprintf("projects/%s/traces/%s", r.projectID, record.TraceID)
+	}
+
+	switch body := record.Body.value().(type) {
+	case map[string]any:
+		entry.Payload = body
+	case nil:
+	default:
+		entry.Message = record.Body.String()
+	}
+
+	return entry
+}
+
+// resourceLogName returns the log ID for a resource's logs based on its service.name
+func resourceLogName(res resource) string {
+	for _, attr := range res.Attributes {
+		if attr.Key == "service.name" {
+			if name := invalidLogNameChars.ReplaceAllString(attr.Value.String(), "_"); name != "" {
+				return name
+			}
+		}
+	}
+	return defaultLogName
+}
+
+// severity maps an OTLP severity to a Cloud Logging severity
+func severity(number int, text string) string {
+	switch {
+	case number >= 21:
+		return "CRITICAL"
+	case number >= 17:
+		return "ERROR"
+	case number >= 13:
+		return "WARNING"
+	case number >= 9:
+		return "INFO"
+	case number >= 1:
+		return "DEBUG"
+	}
+
+	// Fall back to the text when the number is unspecified
+	switch strings.ToUpper(text) {
+	case "FATAL", "CRITICAL":
+		return "CRITICAL"
+	case "ERROR":
+		return "ERROR"
+	case "WARN", "WARNING":
+		return "WARNING"
+	case "DEBUG", "TRACE":
+		return "DEBUG"
+	default:
+		return "INFO"
+	}
+}
+
+// unixNano converts nanoseconds since the epoch to a time, keeping zero as the zero time
+func unixNano(n uint64Str) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(n)).UTC()
+}
+
+// decode reads the possibly gzip compressed JSON body into v, writing an error response on failure
+func decode(w http.ResponseWriter, req *http.Request, v any) bool {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeInvalidArgument, fmt.Sprintf("invalid gzip body: %v", err))
+			return false
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	if err := json.NewDecoder(io.LimitReader(body, maxRequestSize)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, codeInvalidArgument, fmt.Sprintf("invalid OTLP/JSON request: %v", err))
+		return false
+	}
+	return true
+}
+
+// writeError writes a google.rpc.Status response as OTLP/HTTP requires
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, map[string]any{"code": code, "message": message})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
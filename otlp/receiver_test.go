@@ -0,0 +1,226 @@
+package otlp_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	loggingmocks "github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/otlp"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	tracemocks "github.com/kitagry/gcp-telemetry-mcp/trace/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+const tracesBody = `{
+  "resourceSpans": [{
+    "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]},
+    "scopeSpans": [{
+      "spans": [
+        {
+          "traceId": "0123456789abcdef0123456789abcdef",
+          "spanId": "1111111111111111",
+          "parentSpanId": "0000000000000001",
+          "name": "GET /orders",
+          "kind": 2,
+          "startTimeUnixNano": "1704103200000000000",
+          "endTimeUnixNano": "1704103201000000000",
+          "attributes": [{"key": "http.status_code", "value": {"intValue": "200"}}]
+        },
+        {
+          "traceId": "INVALID",
+          "spanId": "2222222222222222",
+          "name": "bad",
+          "startTimeUnixNano": "1704103200000000000",
+          "endTimeUnixNano": "1704103201000000000"
+        }
+      ]
+    }]
+  }]
+}`
+
+func post(t *testing.T, receiver http.Handler, path, contentType string, body []byte, gzipped bool) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReceiver_Traces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTrace := tracemocks.NewMockTraceClientInterface(ctrl)
+	mockTrace.EXPECT().
+		PatchTraces(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req trace.PatchTraceRequest) error {
+			if req.ProjectID != "test-project" || req.TraceID != "0123456789abcdef0123456789abcdef" {
+				t.Errorf("Unexpected request %+v", req)
+			}
+			if len(req.Spans) != 1 {
+				t.Fatalf("Expected 1 span, got %+v", req.Spans)
+			}
+			span := req.Spans[0]
+			if span.Kind != "RPC_SERVER" || span.ParentID != "0000000000000001" {
+				t.Errorf("Unexpected span %+v", span)
+			}
+			if !span.StartTime.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) {
+				t.Errorf("Unexpected start time %v", span.StartTime)
+			}
+			if span.Labels["service.name"] != "checkout" || span.Labels["http.status_code"] != "200" {
+				t.Errorf("Unexpected labels %v", span.Labels)
+			}
+			return nil
+		}).
+		Times(1)
+
+	receiver := otlp.NewReceiver(nil, trace.NewWithClient(mockTrace, "test-project"), "test-project")
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(tracesBody))
+	gz.Close()
+
+	rec := post(t, receiver, otlp.TracesPath, "application/json", gzipped.Bytes(), true)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// The trace with an invalid ID is reported as rejected instead of failing the export
+	var resp struct {
+		PartialSuccess struct {
+			RejectedSpans string `json:"rejectedSpans"`
+			ErrorMessage  string `json:"errorMessage"`
+		} `json:"partialSuccess"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.PartialSuccess.RejectedSpans != "1" || !strings.Contains(resp.PartialSuccess.ErrorMessage, "trace_id") {
+		t.Errorf("Expected one rejected span, got %+v", resp.PartialSuccess)
+	}
+}
+
+func TestReceiver_TracesAPIError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTrace := tracemocks.NewMockTraceClient(ctrl)
+	mockTrace.EXPECT().
+		PatchTraces(gomock.Any(), gomock.Any()).
+		Return(errors.New("unavailable")).
+		Times(1)
+
+	receiver := otlp.NewReceiver(nil, mockTrace, "test-project")
+	rec := post(t, receiver, otlp.TracesPath, "application/json", []byte(tracesBody), false)
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502 so that exporters retry, got %d", rec.Code)
+	}
+}
+
+func TestReceiver_Logs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	body := `{
+  "resourceLogs": [{
+    "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "checkout api"}}]},
+    "scopeLogs": [{
+      "logRecords": [
+        {
+          "timeUnixNano": "1704103200000000000",
+          "severityNumber": 17,
+          "body": {"stringValue": "payment failed"},
+          "traceId": "0123456789abcdef0123456789abcdef",
+          "spanId": "1111111111111111",
+          "flags": 1
+        },
+        {
+          "observedTimeUnixNano": "1704103201000000000",
+          "severityText": "WARN",
+          "body": {"kvlistValue": {"values": [{"key": "retries", "value": {"intValue": 3}}]}}
+        }
+      ]
+    }]
+  }]
+}`
+
+	mockLogging := loggingmocks.NewMockLoggingClient(ctrl)
+	mockLogging.EXPECT().
+		WriteEntries(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req logging.WriteEntriesRequest) error {
+			if req.LogName != "projects/test-project/logs/checkout_api" {
+				t.Errorf("Unexpected log name %s", req.LogName)
+			}
+			if len(req.Entries) != 2 {
+				t.Fatalf("Expected 2 entries, got %+v", req.Entries)
+			}
+
+			first := req.Entries[0]
+			if first.Severity != "ERROR" || first.Message != "payment failed" || !first.TraceSampled {
+				t.Errorf("Unexpected first entry %+v", first)
+			}
+			if first.Trace != "projects/test-project/traces/0123456789abcdef0123456789abcdef" {
+				t.Errorf("Unexpected trace %s", first.Trace)
+			}
+
+			second := req.Entries[1]
+			if second.Severity != "WARNING" || second.Payload["retries"] != int64(3) {
+				t.Errorf("Unexpected second entry %+v", second)
+			}
+			if !second.Timestamp.Equal(time.Date(2024, 1, 1, 10, 0, 1, 0, time.UTC)) {
+				t.Errorf("Expected observed time to be used, got %v", second.Timestamp)
+			}
+			return nil
+		}).
+		Times(1)
+
+	receiver := otlp.NewReceiver(mockLogging, nil, "test-project")
+	rec := post(t, receiver, otlp.LogsPath, "application/json", []byte(body), false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestReceiver_InvalidRequests(t *testing.T) {
+	receiver := otlp.NewReceiver(nil, nil, "test-project")
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{name: "protobuf encoding", method: http.MethodPost, path: otlp.TracesPath, contentType: "application/x-protobuf", status: http.StatusUnsupportedMediaType},
+		{name: "GET", method: http.MethodGet, path: otlp.LogsPath, contentType: "application/json", status: http.StatusMethodNotAllowed},
+		{name: "malformed JSON", method: http.MethodPost, path: otlp.LogsPath, contentType: "application/json", body: "{", status: http.StatusBadRequest},
+		{name: "unknown path", method: http.MethodPost, path: "/v1/metrics", contentType: "application/json", body: "{}", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			receiver.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Package policyerror explains the Google Cloud calls rejected by VPC
+// Service Controls or organization policies. Such errors only say that the
+// request is prohibited; Annotate adds the perimeter or constraint found in
+// their details and a hint on how to resolve the violation.
+package policyerror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// Kinds of violations
+const (
+	KindVPCServiceControls = "vpc_service_controls"
+	KindOrgPolicy          = "org_policy"
+)
+
+// constraintPattern matches the names of organization policy constraints
+var constraintPattern = regexp.MustCompile(`constraints/[A-Za-z0-9_.]+`)
+
+// vpcServiceControlsHints explain the reasons of VPC Service Controls
+// violations. The reasons are those of the audit logs.
+var vpcServiceControlsHints = map[string]string{
+	"NO_MATCHING_ACCESS_LEVEL":                "the caller's network, identity, or device does not satisfy an access level of the perimeter; call from inside the perimeter, or ask the access policy administrator to add an access level or an ingress rule for this identity",
+	"RESOURCES_NOT_IN_SAME_SERVICE_PERIMETER": "the request spans projects in different perimeters, e.g. a metrics scope or a log sink; ask the access policy administrator for an ingress or egress rule, or a perimeter bridge",
+	"SERVICE_NOT_ALLOWED_FROM_VPC":            "the service is not among the VPC accessible services of the perimeter; ask the access policy administrator to allow it",
+	"NETWORK_NOT_IN_SAME_SERVICE_PERIMETER":   "the caller's VPC network is outside of the perimeter; call from a network inside it, or ask for an ingress rule",
+}
+
+// Violation describes why a call was rejected
+type Violation struct {
+	Kind string `json:"kind"` // KindVPCServiceControls or KindOrgPolicy
+	// Service is the API that rejected the call, e.g. monitoring.googleapis.com
+	Service string `json:"service,omitempty"`
+	// Perimeter is the service perimeter, when the error names it
+	Perimeter string `json:"perimeter,omitempty"`
+	// UniqueID identifies a VPC Service Controls violation in the audit logs
+	UniqueID string `json:"unique_id,omitempty"`
+	// Reason is the violation reason, e.g. NO_MATCHING_ACCESS_LEVEL
+	Reason string `json:"reason,omitempty"`
+	// Constraint is the violated organization policy constraint, e.g.
+	// constraints/gcp.resourceLocations
+	Constraint string `json:"constraint,omitempty"`
+	Hint       string `json:"hint"`
+}
+
+// Error is an error of a call rejected by VPC Service Controls or an
+// organization policy, with the details of the violation
+type Error struct {
+	Violation
+	err     error
+	message string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	var details []string
+	switch e.Kind {
+	case KindVPCServiceControls:
+		details = append(details, "blocked by VPC Service Controls")
+		if e.Perimeter != "" {
+			details = append(details, "perimeter "+e.Perimeter)
+		}
+		if e.Reason != "" {
+			details = append(details, "violation "+e.Reason)
+		}
+	case KindOrgPolicy:
+		details = append(details, "blocked by organization policy "+e.Constraint)
+	}
+	return fmt.Sprintf("%s (%s). Hint: %s", e.message, strings.Join(details, ", "), e.Hint)
+}
+
+// Unwrap returns the error of the call
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Annotate returns an *Error wrapping err when err is the error of a call
+// rejected by VPC Service Controls or an organization policy, and err
+// otherwise
+func Annotate(err error) error {
+	if err == nil {
+		return nil
+	}
+	var annotated *Error
+	if errors.As(err, &annotated) {
+		return err
+	}
+	apiErr, ok := apierror.FromError(err)
+	if !ok {
+		return err
+	}
+	message := apiErr.Error()
+	if st := apiErr.GRPCStatus(); st != nil {
+		message = fmt.Sprintf("%s: %s", st.Code(), st.Message())
+	} else if code := apiErr.HTTPCode(); code > 0 {
+		// The first line leaves out the details
+		message, _, _ = strings.Cut(message, "\n")
+	}
+	if violation, ok := vpcServiceControlsViolation(apiErr); ok {
+		return &Error{Violation: violation, err: err, message: message}
+	}
+	if violation, ok := orgPolicyViolation(apiErr, message); ok {
+		return &Error{Violation: violation, err: err, message: message}
+	}
+	return err
+}
+
+// vpcServiceControlsViolation returns the VPC Service Controls violation
+// described by the details of err
+func vpcServiceControlsViolation(err *apierror.APIError) (Violation, bool) {
+	details := err.Details()
+	violation := Violation{Kind: KindVPCServiceControls}
+	found := err.Reason() == "SECURITY_POLICY_VIOLATED"
+	if found {
+		metadata := err.Metadata()
+		violation.Service = metadata["service"]
+		violation.UniqueID = metadata["uid"]
+		for _, key := range []string{"servicePerimeterName", "servicePerimeter", "perimeter"} {
+			if perimeter := metadata[key]; perimeter != "" {
+				violation.Perimeter = perimeter
+				break
+			}
+		}
+	}
+	for _, v := range details.PreconditionFailure.GetViolations() {
+		if v.GetType() != "VPC_SERVICE_CONTROLS" {
+			continue
+		}
+		found = true
+		if violation.UniqueID == "" {
+			violation.UniqueID = v.GetSubject()
+		}
+		violation.Reason = v.GetDescription()
+	}
+	if !found {
+		return Violation{}, false
+	}
+
+	hint := vpcServiceControlsHints[violation.Reason]
+	if hint == "" {
+		hint = "the request crosses a service perimeter"
+	}
+	if violation.UniqueID != "" {
+		hint += fmt.Sprintf(". The audit log entry of the violation names the perimeter and the rule: list_log_entries with filter protoPayload.metadata.vpcServiceControlsUniqueId=%q", violation.UniqueID)
+	}
+	violation.Hint = hint
+	return violation, true
+}
+
+// orgPolicyViolation returns the organization policy violation described by
+// the details of err or its message
+func orgPolicyViolation(err *apierror.APIError, message string) (Violation, bool) {
+	details := err.Details()
+	violation := Violation{Kind: KindOrgPolicy, Service: err.Metadata()["service"]}
+	candidates := []string{err.Reason()}
+	for _, value := range err.Metadata() {
+		candidates = append(candidates, value)
+	}
+	for _, v := range details.PreconditionFailure.GetViolations() {
+		candidates = append(candidates, v.GetType(), v.GetSubject(), v.GetDescription())
+	}
+	candidates = append(candidates, message)
+	for _, candidate := range candidates {
+		if constraint := constraintPattern.FindString(candidate); constraint != "" {
+			violation.Constraint = constraint
+			break
+		}
+	}
+	if violation.Constraint == "" {
+		return Violation{}, false
+	}
+	violation.Hint = fmt.Sprintf("the organization policy on %s forbids this request; see the effective policy with `gcloud org-policies describe %s --effective --project=PROJECT_ID`, and ask an organization policy administrator for an exception if needed",
+		violation.Constraint, strings.TrimPrefix(violation.Constraint, "constraints/"))
+	return violation, true
+}
+
+// ClientOptions returns the options annotating the errors of the calls of
+// gRPC clients. REST clients ignore them, and call Annotate themselves.
+func ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(unaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(streamInterceptor)),
+	}
+}
+
+// unaryInterceptor annotates the errors of unary calls
+func unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return Annotate(invoker(ctx, method, req, reply, cc, opts...))
+}
+
+// streamInterceptor annotates the errors of streaming calls
+func streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, Annotate(err)
+	}
+	return annotatedStream{stream}, nil
+}
+
+// annotatedStream annotates the errors of the messages of a stream
+type annotatedStream struct {
+	grpc.ClientStream
+}
+
+// RecvMsg implements grpc.ClientStream
+func (s annotatedStream) RecvMsg(m any) error {
+	return Annotate(s.ClientStream.RecvMsg(m))
+}
+
+// SendMsg implements grpc.ClientStream
+func (s annotatedStream) SendMsg(m any) error {
+	return Annotate(s.ClientStream.SendMsg(m))
+}
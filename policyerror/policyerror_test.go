@@ -0,0 +1,101 @@
+package policyerror_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"github.com/kitagry/gcp-telemetry-mcp/policyerror"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAnnotate(t *testing.T) {
+	vpcError := func() error {
+		st, err := status.New(codes.PermissionDenied, "Request is prohibited by organization's policy. vpcServiceControlsUniqueIdentifier: Xb1").WithDetails(
+			&errdetails.ErrorInfo{
+				Reason:   "SECURITY_POLICY_VIOLATED",
+				Domain:   "googleapis.com",
+				Metadata: map[string]string{"service": "monitoring.googleapis.com", "uid": "Xb1"},
+			},
+			&errdetails.PreconditionFailure{Violations: []*errdetails.PreconditionFailure_Violation{
+				{Type: "VPC_SERVICE_CONTROLS", Subject: "Xb1", Description: "NO_MATCHING_ACCESS_LEVEL"},
+			}},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Err()
+	}
+
+	t.Run("VPC Service Controls", func(t *testing.T) {
+		// Clients return the errors of calls wrapped in an *apierror.APIError
+		callErr, _ := apierror.FromError(vpcError())
+		err := policyerror.Annotate(callErr)
+
+		var policyErr *policyerror.Error
+		if !errors.As(err, &policyErr) {
+			t.Fatalf("Expected a *policyerror.Error, got %v", err)
+		}
+		want := policyerror.Violation{
+			Kind:     policyerror.KindVPCServiceControls,
+			Service:  "monitoring.googleapis.com",
+			UniqueID: "Xb1",
+			Reason:   "NO_MATCHING_ACCESS_LEVEL",
+		}
+		got := policyErr.Violation
+		got.Hint = ""
+		if got != want {
+			t.Errorf("Violation = %+v, want %+v", got, want)
+		}
+		if !strings.Contains(policyErr.Hint, "access level") || !strings.Contains(policyErr.Hint, `protoPayload.metadata.vpcServiceControlsUniqueId="Xb1"`) {
+			t.Errorf("Expected a hint on access levels and the audit logs, got %q", policyErr.Hint)
+		}
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Expected the code of the call to be kept, got %v", status.Code(err))
+		}
+		if !strings.HasPrefix(err.Error(), "PermissionDenied: Request is prohibited") || !strings.Contains(err.Error(), "blocked by VPC Service Controls, violation NO_MATCHING_ACCESS_LEVEL") {
+			t.Errorf("Unexpected message %q", err.Error())
+		}
+		if policyerror.Annotate(err) != err {
+			t.Error("Expected an annotated error to be returned as is")
+		}
+	})
+
+	t.Run("organization policy", func(t *testing.T) {
+		callErr := &googleapi.Error{
+			Code:    400,
+			Message: "Constraint constraints/gcp.resourceLocations violated for projects/p attempting to create a bucket in asia-northeast1",
+		}
+		var policyErr *policyerror.Error
+		if err := policyerror.Annotate(callErr); !errors.As(err, &policyErr) {
+			t.Fatalf("Expected a *policyerror.Error, got %v", err)
+		}
+		if policyErr.Kind != policyerror.KindOrgPolicy || policyErr.Constraint != "constraints/gcp.resourceLocations" {
+			t.Errorf("Unexpected violation %+v", policyErr.Violation)
+		}
+		if !strings.Contains(policyErr.Hint, "gcloud org-policies describe gcp.resourceLocations --effective") {
+			t.Errorf("Expected a hint describing the policy, got %q", policyErr.Hint)
+		}
+		if !strings.Contains(policyErr.Error(), "blocked by organization policy constraints/gcp.resourceLocations") {
+			t.Errorf("Unexpected message %q", policyErr.Error())
+		}
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		for _, err := range []error{
+			nil,
+			io.EOF,
+			errors.New("connection refused"),
+			status.Error(codes.PermissionDenied, "Permission monitoring.timeSeries.list denied"),
+		} {
+			if got := policyerror.Annotate(err); got != err {
+				t.Errorf("Annotate(%v) = %v, want the error as is", err, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,362 @@
+package profiler
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	pprofile "github.com/google/pprof/profile"
+	"google.golang.org/api/cloudprofiler/v2"
+)
+
+const (
+	// defaultMaxProfiles is the number of profiles merged by default
+	defaultMaxProfiles = 50
+	// maxMaxProfiles bounds the number of profiles downloaded for one aggregation
+	maxMaxProfiles = 500
+	// defaultTopHotspots is the number of hotspots reported by default
+	defaultTopHotspots = 20
+	// defaultAggregationWindow is the time range aggregated by default
+	defaultAggregationWindow = 24 * time.Hour
+	// listPageSize is the page size used when paging through all profiles
+	listPageSize = 1000
+)
+
+// errEnoughProfiles stops paging once enough profiles were found
+var errEnoughProfiles = errors.New("enough profiles")
+
+// QueryProfilesRequest represents a request to collect the profiles of a
+// target and type collected in a time range
+type QueryProfilesRequest struct {
+	ProjectID   string            `json:"project_id,omitempty"` // defaults to the client's project
+	Target      string            `json:"target"`
+	ProfileType ProfileType       `json:"profile_type"`
+	Labels      map[string]string `json:"labels,omitempty"` // deployment labels that must match, e.g. version or zone
+	StartTime   time.Time         `json:"start_time"`
+	EndTime     time.Time         `json:"end_time"`
+	MaxProfiles int               `json:"max_profiles,omitempty"`
+}
+
+// AggregateProfilesRequest represents a request to merge profiles and report hotspots
+type AggregateProfilesRequest struct {
+	ProjectID   string            `json:"project_id,omitempty"` // defaults to the client's project
+	Target      string            `json:"target"`
+	ProfileType ProfileType       `json:"profile_type"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	StartTime   time.Time         `json:"start_time,omitzero"`    // defaults to 24 hours before EndTime
+	EndTime     time.Time         `json:"end_time,omitzero"`      // defaults to now
+	MaxProfiles int               `json:"max_profiles,omitempty"` // defaults to defaultMaxProfiles
+	SampleType  string            `json:"sample_type,omitempty"`  // defaults to the profile's default sample type
+	Top         int               `json:"top,omitempty"`          // defaults to defaultTopHotspots
+	// SourceMappings link the hotspots to their source code when set
+	SourceMappings []SourceMapping `json:"source_mappings,omitempty"`
+}
+
+// Hotspot represents a function and the share of the samples spent in it
+type Hotspot struct {
+	Function    string  `json:"function"`
+	File        string  `json:"file,omitempty"`
+	Line        int64   `json:"line,omitempty"` // line of the function with the most samples
+	Flat        int64   `json:"flat"`           // value of samples whose leaf frame is the function
+	FlatPercent float64 `json:"flat_percent"`
+	Cum         int64   `json:"cum"` // value of samples with the function anywhere in the stack
+	CumPercent  float64 `json:"cum_percent"`
+	SourceURL   string  `json:"source_url,omitempty"` // permalink to the line, when a source mapping matches
+}
+
+// AggregateProfilesResponse represents the hotspots of merged profiles
+type AggregateProfilesResponse struct {
+	Target         string      `json:"target"`
+	ProfileType    ProfileType `json:"profile_type"`
+	SampleType     string      `json:"sample_type"`
+	Unit           string      `json:"unit"`
+	ProfilesMerged int         `json:"profiles_merged"`
+	ProfilesFailed int         `json:"profiles_failed,omitempty"` // profiles that could not be parsed
+	FirstProfile   time.Time   `json:"first_profile"`
+	LastProfile    time.Time   `json:"last_profile"`
+	Total          int64       `json:"total"`
+	Hotspots       []Hotspot   `json:"hotspots"`
+	ConsoleURL     string      `json:"console_url,omitempty"`
+}
+
+// QueryProfiles collects the profiles of a target and type collected in a time range
+func (c *CloudProfilerClient) QueryProfiles(ctx context.Context, req QueryProfilesRequest) ([]*Profile, error) {
+	return c.client.QueryProfiles(ctx, req)
+}
+
+// QueryProfiles implements ProfilerClientInterface for the real client
+func (r *realProfilerClient) QueryProfiles(ctx context.Context, req QueryProfilesRequest) ([]*Profile, error) {
+	parent := fmt.Sprintf("projects/%s", r.project(req.ProjectID))
+
+	var profiles []*Profile
+	err := r.service.Projects.Profiles.List(parent).PageSize(listPageSize).Pages(ctx, func(resp *cloudprofiler.ListProfilesResponse) error {
+		for _, apiProfile := range resp.Profiles {
+			profile := convertAPIProfileToProfile(apiProfile)
+			if !matchesQuery(profile, req) {
+				continue
+			}
+			profiles = append(profiles, profile)
+			if req.MaxProfiles > 0 && len(profiles) >= req.MaxProfiles {
+				return errEnoughProfiles
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnoughProfiles) {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// matchesQuery reports whether the profile belongs to the target, type,
+// labels, and time range of the request
+func matchesQuery(p *Profile, req QueryProfilesRequest) bool {
+	if p.Deployment == nil || p.Deployment.Target != req.Target || p.ProfileType != req.ProfileType {
+		return false
+	}
+	for k, v := range req.Labels {
+		if p.Deployment.Labels[k] != v {
+			return false
+		}
+	}
+	return !p.StartTime.Before(req.StartTime) && p.StartTime.Before(req.EndTime)
+}
+
+// AggregateProfiles merges the profiles of a target and type collected in a
+// time range and reports the functions where most of the samples were spent
+func (c *CloudProfilerClient) AggregateProfiles(ctx context.Context, req AggregateProfilesRequest) (AggregateProfilesResponse, error) {
+	query, err := queryFor(req.ProjectID, req.Target, req.ProfileType, req.Labels, req.StartTime, req.EndTime, req.MaxProfiles)
+	if err != nil {
+		return AggregateProfilesResponse{}, err
+	}
+	profiles, err := c.client.QueryProfiles(ctx, query)
+	if err != nil {
+		return AggregateProfilesResponse{}, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return AggregateProfilesResponse{}, fmt.Errorf("no %s profiles of %s found between %s and %s",
+			req.ProfileType, req.Target, query.StartTime.Format(time.RFC3339), query.EndTime.Format(time.RFC3339))
+	}
+
+	resp, err := aggregateProfiles(profiles, req.SampleType, req.Top)
+	if err != nil {
+		return AggregateProfilesResponse{}, err
+	}
+	for i, h := range resp.Hotspots {
+		resp.Hotspots[i].SourceURL = SourceURL(req.SourceMappings, h.File, h.Line)
+	}
+	resp.Target = req.Target
+	resp.ProfileType = req.ProfileType
+	resp.ConsoleURL = ConsoleURL(c.project(req.ProjectID), req.Target, req.ProfileType)
+	return resp, nil
+}
+
+// project returns projectID, defaulting to the client's project
+func (c *CloudProfilerClient) project(projectID string) string {
+	if projectID == "" {
+		return c.projectID
+	}
+	return projectID
+}
+
+// queryFor validates the target and type and applies the default time range
+// and number of profiles
+func queryFor(projectID, target string, profileType ProfileType, labels map[string]string, startTime, endTime time.Time, maxProfiles int) (QueryProfilesRequest, error) {
+	if target == "" {
+		return QueryProfilesRequest{}, fmt.Errorf("target is required")
+	}
+	if profileType == "" {
+		return QueryProfilesRequest{}, fmt.Errorf("profile type is required")
+	}
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	if startTime.IsZero() {
+		startTime = endTime.Add(-defaultAggregationWindow)
+	}
+	if !startTime.Before(endTime) {
+		return QueryProfilesRequest{}, fmt.Errorf("start time must be before end time")
+	}
+	if maxProfiles <= 0 {
+		maxProfiles = defaultMaxProfiles
+	}
+	return QueryProfilesRequest{
+		ProjectID:   projectID,
+		Target:      target,
+		ProfileType: profileType,
+		Labels:      labels,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		MaxProfiles: min(maxProfiles, maxMaxProfiles),
+	}, nil
+}
+
+// aggregateProfiles merges profiles and reports the top hotspots
+func aggregateProfiles(profiles []*Profile, sampleType string, top int) (AggregateProfilesResponse, error) {
+	var resp AggregateProfilesResponse
+	var parsed []*pprofile.Profile
+	for _, p := range profiles {
+		pp, err := parseProfile(p)
+		if err != nil {
+			resp.ProfilesFailed++
+			continue
+		}
+		parsed = append(parsed, pp)
+		if resp.FirstProfile.IsZero() || p.StartTime.Before(resp.FirstProfile) {
+			resp.FirstProfile = p.StartTime
+		}
+		if p.StartTime.After(resp.LastProfile) {
+			resp.LastProfile = p.StartTime
+		}
+	}
+	if len(parsed) == 0 {
+		return AggregateProfilesResponse{}, fmt.Errorf("none of the %d profiles could be parsed", len(profiles))
+	}
+
+	merged, err := pprofile.Merge(parsed)
+	if err != nil {
+		return AggregateProfilesResponse{}, fmt.Errorf("failed to merge profiles: %w", err)
+	}
+	index, err := sampleIndex(merged, sampleType)
+	if err != nil {
+		return AggregateProfilesResponse{}, err
+	}
+
+	resp.ProfilesMerged = len(parsed)
+	resp.SampleType = merged.SampleType[index].Type
+	resp.Unit = merged.SampleType[index].Unit
+	resp.Total, resp.Hotspots = hotspots(merged, index, top)
+	return resp, nil
+}
+
+// parseProfile decodes the gzip compressed pprof data of a profile
+func parseProfile(p *Profile) (*pprofile.Profile, error) {
+	data, err := base64.StdEncoding.DecodeString(p.ProfileBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode profile %s: %w", p.Name, err)
+	}
+	pp, err := pprofile.ParseData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", p.Name, err)
+	}
+	return pp, nil
+}
+
+// sampleIndex returns the index of the sample type to report, defaulting to
+// the profile's default sample type, or its last one as pprof does
+func sampleIndex(p *pprofile.Profile, sampleType string) (int, error) {
+	if sampleType == "" {
+		sampleType = p.DefaultSampleType
+	}
+	if sampleType == "" {
+		return len(p.SampleType) - 1, nil
+	}
+	var available []string
+	for i, st := range p.SampleType {
+		if st.Type == sampleType {
+			return i, nil
+		}
+		available = append(available, st.Type)
+	}
+	return 0, fmt.Errorf("sample type %q not found; available: %v", sampleType, available)
+}
+
+// hotspots returns the total value of the samples and the top functions by
+// flat value
+func hotspots(p *pprofile.Profile, index, top int) (int64, []Hotspot) {
+	if top <= 0 {
+		top = defaultTopHotspots
+	}
+
+	total, byFunction := functionValues(p, index)
+	result := make([]Hotspot, 0, len(byFunction))
+	for _, name := range slices.Sorted(maps.Keys(byFunction)) {
+		h := byFunction[name]
+		if total != 0 {
+			h.FlatPercent = percent(h.Flat, total)
+			h.CumPercent = percent(h.Cum, total)
+		}
+		result = append(result, *h)
+	}
+	slices.SortStableFunc(result, func(a, b Hotspot) int {
+		if c := cmp.Compare(b.Flat, a.Flat); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Cum, a.Cum)
+	})
+	if len(result) > top {
+		result = result[:top]
+	}
+	return total, result
+}
+
+// functionValues returns the total value of the samples and the flat and
+// cumulative values of each function, attributing inlined frames to the
+// inlined function, along with the line of each function with the highest
+// cumulative value
+func functionValues(p *pprofile.Profile, index int) (int64, map[string]*Hotspot) {
+	byFunction := make(map[string]*Hotspot)
+	byLine := make(map[string]map[int64]int64)
+	hotspotFor := func(fn *pprofile.Function) *Hotspot {
+		h, ok := byFunction[fn.Name]
+		if !ok {
+			h = &Hotspot{Function: fn.Name, File: fn.Filename}
+			byFunction[fn.Name] = h
+		}
+		return h
+	}
+
+	var total int64
+	for _, sample := range p.Sample {
+		value := sample.Value[index]
+		if value == 0 {
+			continue
+		}
+		total += value
+
+		// Lines of a location are ordered from the innermost inlined function,
+		// and locations from the leaf
+		seen := make(map[string]bool)
+		for i, loc := range sample.Location {
+			for j, line := range loc.Line {
+				if line.Function == nil {
+					continue
+				}
+				h := hotspotFor(line.Function)
+				if i == 0 && j == 0 {
+					h.Flat += value
+				}
+				if !seen[line.Function.Name] {
+					seen[line.Function.Name] = true
+					h.Cum += value
+					if byLine[line.Function.Name] == nil {
+						byLine[line.Function.Name] = make(map[int64]int64)
+					}
+					byLine[line.Function.Name][line.Line] += value
+				}
+			}
+		}
+	}
+
+	for name, lines := range byLine {
+		h := byFunction[name]
+		for _, line := range slices.Sorted(maps.Keys(lines)) {
+			// Line 0 means the line is unknown
+			if line != 0 && (h.Line == 0 || lines[line] > lines[h.Line]) {
+				h.Line = line
+			}
+		}
+	}
+	return total, byFunction
+}
+
+// percent returns value as a percentage of total, rounded to two decimals
+func percent(value, total int64) float64 {
+	return math.Round(float64(value)/float64(total)*10000) / 100
+}
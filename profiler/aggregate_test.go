@@ -0,0 +1,161 @@
+package profiler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	pprofile "github.com/google/pprof/profile"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// stack is a sampled call stack, leaf first, with its value
+type stack struct {
+	functions []string
+	value     int64
+}
+
+// cpuProfile builds a base64 encoded CPU profile of the stacks
+func cpuProfile(t *testing.T, stacks ...stack) string {
+	t.Helper()
+	return encodeProfile(t, &pprofile.Profile{
+		SampleType: []*pprofile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		PeriodType: &pprofile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     10000000,
+	}, stacks...)
+}
+
+// encodeProfile adds samples of the stacks to a profile with the given
+// sample types, using the stack's value for every type, and encodes it as
+// base64 compressed pprof data
+func encodeProfile(t *testing.T, p *pprofile.Profile, stacks ...stack) string {
+	t.Helper()
+
+	functions := make(map[string]*pprofile.Function)
+	locations := make(map[string]*pprofile.Location)
+	for _, s := range stacks {
+		sample := &pprofile.Sample{}
+		for range p.SampleType {
+			sample.Value = append(sample.Value, s.value)
+		}
+		for _, name := range s.functions {
+			loc, ok := locations[name]
+			if !ok {
+				fn := &pprofile.Function{ID: uint64(len(functions) + 1), Name: name, SystemName: name, Filename: "/app/main.go"}
+				functions[name] = fn
+				p.Function = append(p.Function, fn)
+				loc = &pprofile.Location{ID: uint64(len(locations) + 1), Line: []pprofile.Line{{Function: fn, Line: int64(10 * (len(locations) + 1))}}}
+				locations[name] = loc
+				p.Location = append(p.Location, loc)
+			}
+			sample.Location = append(sample.Location, loc)
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestCloudProfilerClient_AggregateProfiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(6 * time.Hour)
+	deployment := &profiler.Deployment{ProjectID: "test-project", Target: "checkout"}
+	profiles := []*profiler.Profile{
+		{
+			Name: "p1", ProfileType: profiler.ProfileTypeCPU, StartTime: start.Add(time.Hour), Deployment: deployment,
+			ProfileBytes: cpuProfile(t,
+				stack{[]string{"main.encode", "main.handle", "main.main"}, 600},
+				stack{[]string{"main.handle", "main.main"}, 100},
+			),
+		},
+		{
+			Name: "p2", ProfileType: profiler.ProfileTypeCPU, StartTime: start.Add(2 * time.Hour), Deployment: deployment,
+			ProfileBytes: cpuProfile(t,
+				stack{[]string{"main.encode", "main.handle", "main.main"}, 200},
+				stack{[]string{"runtime.gc"}, 100},
+			),
+		},
+		{Name: "p3", ProfileType: profiler.ProfileTypeCPU, StartTime: start.Add(3 * time.Hour), Deployment: deployment, ProfileBytes: "not a profile"},
+	}
+
+	mockClient := mocks.NewMockProfilerClientInterface(ctrl)
+	mockClient.EXPECT().
+		QueryProfiles(gomock.Any(), profiler.QueryProfilesRequest{
+			Target:      "checkout",
+			ProfileType: profiler.ProfileTypeCPU,
+			StartTime:   start,
+			EndTime:     end,
+			MaxProfiles: 50,
+		}).
+		Return(profiles, nil).
+		Times(1)
+
+	client := profiler.NewWithClient(mockClient, "test-project")
+	resp, err := client.AggregateProfiles(context.Background(), profiler.AggregateProfilesRequest{
+		Target:      "checkout",
+		ProfileType: profiler.ProfileTypeCPU,
+		StartTime:   start,
+		EndTime:     end,
+		Top:         3,
+		SourceMappings: []profiler.SourceMapping{
+			{PathPrefix: "/app/", Repository: "https://github.com/example/checkout", Revision: "v1.2.0"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.ProfilesMerged != 2 || resp.ProfilesFailed != 1 {
+		t.Errorf("Expected 2 merged and 1 failed profile, got %d and %d", resp.ProfilesMerged, resp.ProfilesFailed)
+	}
+	if resp.SampleType != "cpu" || resp.Unit != "nanoseconds" || resp.Total != 1000 {
+		t.Errorf("Unexpected sample type %s/%s or total %d", resp.SampleType, resp.Unit, resp.Total)
+	}
+	if !resp.FirstProfile.Equal(start.Add(time.Hour)) || !resp.LastProfile.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Unexpected profile range %v - %v", resp.FirstProfile, resp.LastProfile)
+	}
+
+	want := []profiler.Hotspot{
+		{Function: "main.encode", File: "/app/main.go", Line: 10, Flat: 800, FlatPercent: 80, Cum: 800, CumPercent: 80,
+			SourceURL: "https://github.com/example/checkout/blob/v1.2.0/main.go#L10"},
+		{Function: "main.handle", File: "/app/main.go", Line: 20, Flat: 100, FlatPercent: 10, Cum: 900, CumPercent: 90,
+			SourceURL: "https://github.com/example/checkout/blob/v1.2.0/main.go#L20"},
+		{Function: "runtime.gc", File: "/app/main.go", Line: 40, Flat: 100, FlatPercent: 10, Cum: 100, CumPercent: 10,
+			SourceURL: "https://github.com/example/checkout/blob/v1.2.0/main.go#L40"},
+	}
+	if len(resp.Hotspots) != len(want) {
+		t.Fatalf("Expected %d hotspots, got %+v", len(want), resp.Hotspots)
+	}
+	for i, h := range want {
+		if resp.Hotspots[i] != h {
+			t.Errorf("Hotspot %d: expected %+v, got %+v", i, h, resp.Hotspots[i])
+		}
+	}
+}
+
+func TestCloudProfilerClient_AggregateProfilesInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockProfilerClientInterface(ctrl)
+	mockClient.EXPECT().QueryProfiles(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	client := profiler.NewWithClient(mockClient, "test-project")
+
+	if _, err := client.AggregateProfiles(context.Background(), profiler.AggregateProfilesRequest{ProfileType: profiler.ProfileTypeCPU}); err == nil {
+		t.Error("Expected error without a target")
+	}
+	if _, err := client.AggregateProfiles(context.Background(), profiler.AggregateProfilesRequest{Target: "checkout", ProfileType: profiler.ProfileTypeCPU}); err == nil {
+		t.Error("Expected error when no profiles are found")
+	}
+}
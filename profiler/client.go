@@ -5,8 +5,13 @@ package profiler
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/kitagry/gcp-telemetry-mcp/policyerror"
 	"google.golang.org/api/cloudprofiler/v2"
 	"google.golang.org/api/option"
 )
@@ -22,15 +27,34 @@ const (
 	ProfileTypeWall       ProfileType = "WALL"
 )
 
+// ProfileTypes lists the supported profile types
+var ProfileTypes = []string{string(ProfileTypeCPU), string(ProfileTypeHeap), string(ProfileTypeThreads), string(ProfileTypeContention), string(ProfileTypeWall)}
+
+// ParseProfileType returns the profile type named by s in any case, or an
+// error listing the ProfileTypes
+func ParseProfileType(s string) (ProfileType, error) {
+	profileType := strings.ToUpper(s)
+	if !slices.Contains(ProfileTypes, profileType) {
+		return "", fmt.Errorf("unsupported profile type %q: must be one of %s", s, strings.Join(ProfileTypes, ", "))
+	}
+	return ProfileType(profileType), nil
+}
+
+// ProfileRetention is how long Cloud Profiler keeps profiles after they were collected
+const ProfileRetention = 30 * 24 * time.Hour
+
 // Profile represents a profiling data
 type Profile struct {
 	Name         string            `json:"name"`
 	ProfileType  ProfileType       `json:"profile_type"`
 	Duration     string            `json:"duration"`
 	Labels       map[string]string `json:"labels,omitempty"`
-	StartTime    time.Time         `json:"start_time"`
+	StartTime    time.Time         `json:"start_time,omitzero"`  // only known for listed profiles
+	EndTime      time.Time         `json:"end_time,omitzero"`    // StartTime plus Duration
+	ExpireTime   time.Time         `json:"expire_time,omitzero"` // when Cloud Profiler deletes the profile
 	ProfileBytes string            `json:"profile_bytes,omitempty"`
 	Deployment   *Deployment       `json:"deployment,omitempty"`
+	ConsoleURL   string            `json:"console_url,omitempty"`
 }
 
 // Deployment represents deployment information
@@ -62,6 +86,12 @@ type ListProfilesRequest struct {
 	PageToken string `json:"page_token,omitempty"`
 }
 
+// ListProfilesResponse represents a page of profiles and pagination info
+type ListProfilesResponse struct {
+	Profiles      []*Profile `json:"profiles"`
+	NextPageToken string     `json:"next_page_token,omitempty"`
+}
+
 // UpdateProfileRequest represents a request to update a profile
 type UpdateProfileRequest struct {
 	Profile      *Profile `json:"profile"`
@@ -74,7 +104,10 @@ type ProfilerClient interface {
 	CreateProfile(ctx context.Context, req CreateProfileRequest) (*Profile, error)
 	CreateOfflineProfile(ctx context.Context, req CreateOfflineProfileRequest) (*Profile, error)
 	UpdateProfile(ctx context.Context, req UpdateProfileRequest) (*Profile, error)
-	ListProfiles(ctx context.Context, req ListProfilesRequest) ([]*Profile, error)
+	ListProfiles(ctx context.Context, req ListProfilesRequest) (ListProfilesResponse, error)
+	QueryProfiles(ctx context.Context, req QueryProfilesRequest) ([]*Profile, error)
+	AggregateProfiles(ctx context.Context, req AggregateProfilesRequest) (AggregateProfilesResponse, error)
+	DetectMemoryGrowth(ctx context.Context, req MemoryGrowthRequest) (MemoryGrowthResponse, error)
 }
 
 // CloudProfilerClient implements ProfilerClient using Google Cloud Profiler
@@ -88,21 +121,29 @@ type ProfilerClientInterface interface {
 	CreateProfile(ctx context.Context, req CreateProfileRequest) (*Profile, error)
 	CreateOfflineProfile(ctx context.Context, req CreateOfflineProfileRequest) (*Profile, error)
 	UpdateProfile(ctx context.Context, req UpdateProfileRequest) (*Profile, error)
-	ListProfiles(ctx context.Context, req ListProfilesRequest) ([]*Profile, error)
+	ListProfiles(ctx context.Context, req ListProfilesRequest) (ListProfilesResponse, error)
+	QueryProfiles(ctx context.Context, req QueryProfilesRequest) ([]*Profile, error)
 }
 
 // New creates a new CloudProfilerClient
-func New(projectID string) (*CloudProfilerClient, error) {
-	service, err := cloudprofiler.NewService(context.Background(), option.WithScopes(cloudprofiler.CloudPlatformScope))
+func New(projectID string, opts ...option.ClientOption) (*CloudProfilerClient, error) {
+	client, err := NewAPIClient(projectID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithClient(client, projectID), nil
+}
+
+// NewAPIClient creates the ProfilerClientInterface calling the Cloud
+// Profiler API, e.g. to be wrapped before it is passed to NewWithClient
+func NewAPIClient(projectID string, opts ...option.ClientOption) (ProfilerClientInterface, error) {
+	service, err := cloudprofiler.NewService(context.Background(), append([]option.ClientOption{option.WithScopes(cloudprofiler.CloudPlatformScope)}, opts...)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create profiler service: %w", err)
 	}
 
-	return &CloudProfilerClient{
-		client: &realProfilerClient{
-			service:   service,
-			projectID: projectID,
-		},
+	return &realProfilerClient{
+		service:   service,
 		projectID: projectID,
 	}, nil
 }
@@ -117,21 +158,48 @@ func NewWithClient(client ProfilerClientInterface, projectID string) *CloudProfi
 
 // CreateProfile creates a new profile
 func (c *CloudProfilerClient) CreateProfile(ctx context.Context, req CreateProfileRequest) (*Profile, error) {
+	duration, err := normalizeDuration(req.Duration)
+	if err != nil {
+		return nil, err
+	}
+	req.Duration = duration
 	return c.client.CreateProfile(ctx, req)
 }
 
 // CreateOfflineProfile creates an offline profile
 func (c *CloudProfilerClient) CreateOfflineProfile(ctx context.Context, req CreateOfflineProfileRequest) (*Profile, error) {
+	if req.Profile != nil {
+		duration, err := normalizeDuration(req.Profile.Duration)
+		if err != nil {
+			return nil, err
+		}
+		profile := *req.Profile
+		profile.Duration = duration
+		req.Profile = &profile
+	}
 	return c.client.CreateOfflineProfile(ctx, req)
 }
 
+// normalizeDuration validates a duration such as "60s" or "5m" and returns it
+// in seconds, as the API expects
+func normalizeDuration(duration string) (string, error) {
+	if duration == "" {
+		return "", nil
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil || d <= 0 {
+		return "", fmt.Errorf("invalid duration %q: use a positive duration such as \"60s\" or \"5m\"", duration)
+	}
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s", nil
+}
+
 // UpdateProfile updates an existing profile
 func (c *CloudProfilerClient) UpdateProfile(ctx context.Context, req UpdateProfileRequest) (*Profile, error) {
 	return c.client.UpdateProfile(ctx, req)
 }
 
 // ListProfiles lists profiles
-func (c *CloudProfilerClient) ListProfiles(ctx context.Context, req ListProfilesRequest) ([]*Profile, error) {
+func (c *CloudProfilerClient) ListProfiles(ctx context.Context, req ListProfilesRequest) (ListProfilesResponse, error) {
 	return c.client.ListProfiles(ctx, req)
 }
 
@@ -141,9 +209,18 @@ type realProfilerClient struct {
 	projectID string
 }
 
+// project returns projectID, defaulting to the client's project
+func (r *realProfilerClient) project(projectID string) string {
+	if projectID == "" {
+		return r.projectID
+	}
+	return projectID
+}
+
 // CreateProfile implements ProfilerClientInterface for the real client
 func (r *realProfilerClient) CreateProfile(ctx context.Context, req CreateProfileRequest) (*Profile, error) {
-	parent := fmt.Sprintf("projects/%s", r.projectID)
+	projectID := r.project(req.ProjectID)
+	parent := fmt.Sprintf("projects/%s", projectID)
 	
 	// Convert our ProfileType to API strings
 	var profileTypes []string
@@ -153,7 +230,7 @@ func (r *realProfilerClient) CreateProfile(ctx context.Context, req CreateProfil
 
 	// Create deployment
 	deployment := &cloudprofiler.Deployment{
-		ProjectId: r.projectID,
+		ProjectId: projectID,
 		Target:    req.Deployment.Target,
 		Labels:    req.Deployment.Labels,
 	}
@@ -165,10 +242,22 @@ func (r *realProfilerClient) CreateProfile(ctx context.Context, req CreateProfil
 
 	profile, err := r.service.Projects.Profiles.Create(parent, createReq).Context(ctx).Do()
 	if err != nil {
-		return nil, err
+		return nil, policyerror.Annotate(err)
 	}
 
-	return convertAPIProfileToProfile(profile), nil
+	// The API only takes the deployment and profile types, and chooses the
+	// duration itself. Keep the requested duration and labels on the profile
+	// so that they are sent along when it is updated with its data.
+	result := convertAPIProfileToProfile(profile)
+	if result.Duration == "" {
+		result.Duration = req.Duration
+	}
+	if len(req.Labels) > 0 {
+		labels := maps.Clone(req.Labels)
+		maps.Copy(labels, result.Labels)
+		result.Labels = labels
+	}
+	return result, nil
 }
 
 // UpdateProfile implements ProfilerClientInterface for the real client
@@ -192,7 +281,7 @@ func (r *realProfilerClient) UpdateProfile(ctx context.Context, req UpdateProfil
 
 	profile, err := r.service.Projects.Profiles.Patch(req.Profile.Name, apiProfile).Context(ctx).Do()
 	if err != nil {
-		return nil, err
+		return nil, policyerror.Annotate(err)
 	}
 
 	return convertAPIProfileToProfile(profile), nil
@@ -200,7 +289,7 @@ func (r *realProfilerClient) UpdateProfile(ctx context.Context, req UpdateProfil
 
 // CreateOfflineProfile implements ProfilerClientInterface for the real client
 func (r *realProfilerClient) CreateOfflineProfile(ctx context.Context, req CreateOfflineProfileRequest) (*Profile, error) {
-	parent := fmt.Sprintf("projects/%s", r.projectID)
+	parent := fmt.Sprintf("projects/%s", r.project(req.ProjectID))
 	
 	// Convert our profile to API profile
 	apiProfile := &cloudprofiler.Profile{
@@ -220,15 +309,15 @@ func (r *realProfilerClient) CreateOfflineProfile(ctx context.Context, req Creat
 
 	profile, err := r.service.Projects.Profiles.CreateOffline(parent, apiProfile).Context(ctx).Do()
 	if err != nil {
-		return nil, err
+		return nil, policyerror.Annotate(err)
 	}
 
 	return convertAPIProfileToProfile(profile), nil
 }
 
 // ListProfiles implements ProfilerClientInterface for the real client
-func (r *realProfilerClient) ListProfiles(ctx context.Context, req ListProfilesRequest) ([]*Profile, error) {
-	parent := fmt.Sprintf("projects/%s", r.projectID)
+func (r *realProfilerClient) ListProfiles(ctx context.Context, req ListProfilesRequest) (ListProfilesResponse, error) {
+	parent := fmt.Sprintf("projects/%s", r.project(req.ProjectID))
 	
 	call := r.service.Projects.Profiles.List(parent).Context(ctx)
 	
@@ -241,7 +330,7 @@ func (r *realProfilerClient) ListProfiles(ctx context.Context, req ListProfilesR
 
 	response, err := call.Do()
 	if err != nil {
-		return nil, err
+		return ListProfilesResponse{}, policyerror.Annotate(err)
 	}
 
 	var profiles []*Profile
@@ -249,7 +338,10 @@ func (r *realProfilerClient) ListProfiles(ctx context.Context, req ListProfilesR
 		profiles = append(profiles, convertAPIProfileToProfile(apiProfile))
 	}
 
-	return profiles, nil
+	return ListProfilesResponse{
+		Profiles:      profiles,
+		NextPageToken: response.NextPageToken,
+	}, nil
 }
 
 // convertAPIProfileToProfile converts a Cloud Profiler API Profile to our Profile struct
@@ -268,11 +360,25 @@ func convertAPIProfileToProfile(apiProfile *cloudprofiler.Profile) *Profile {
 			Target:    apiProfile.Deployment.Target,
 			Labels:    apiProfile.Deployment.Labels,
 		}
+		profile.ConsoleURL = ConsoleURL(profile.Deployment.ProjectID, profile.Deployment.Target, profile.ProfileType)
 	}
 
-	// Parse start time from name if available (profile names typically include timestamps)
-	// This is a simplified implementation - in practice, you might parse the actual timestamp
-	profile.StartTime = time.Now()
+	// The start time is only returned for listed profiles
+	if startTime, err := time.Parse(time.RFC3339Nano, apiProfile.StartTime); err == nil {
+		profile.StartTime = startTime
+		profile.EndTime = startTime.Add(profile.ParsedDuration())
+		profile.ExpireTime = startTime.Add(ProfileRetention)
+	}
 
 	return profile
+}
+
+// ParsedDuration returns the duration of the profile, or zero when it is not
+// set or invalid
+func (p *Profile) ParsedDuration() time.Duration {
+	d, err := time.ParseDuration(p.Duration)
+	if err != nil {
+		return 0
+	}
+	return d
 }
\ No newline at end of file
@@ -74,6 +74,37 @@ func TestCloudProfilerClient_CreateProfile(t *testing.T) {
 	}
 }
 
+func TestCloudProfilerClient_CreateProfileDuration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockProfilerClientInterface(ctrl)
+	client := profiler.NewWithClient(mockClient, "test-project")
+
+	req := profiler.CreateProfileRequest{
+		Deployment:  &profiler.Deployment{Target: "test-target"},
+		ProfileType: []profiler.ProfileType{profiler.ProfileTypeCPU},
+		Duration:    "1m30s",
+	}
+	want := req
+	want.Duration = "90s"
+	mockClient.EXPECT().
+		CreateProfile(gomock.Any(), want).
+		Return(&profiler.Profile{Name: "projects/test-project/profiles/profile123", Duration: "90s"}, nil).
+		Times(1)
+
+	if _, err := client.CreateProfile(context.Background(), req); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	for _, duration := range []string{"sixty seconds", "60", "-5m", "0s"} {
+		req.Duration = duration
+		if _, err := client.CreateProfile(context.Background(), req); err == nil {
+			t.Errorf("Expected error for duration %q", duration)
+		}
+	}
+}
+
 func TestCloudProfilerClient_CreateOfflineProfile(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -213,13 +244,14 @@ func TestCloudProfilerClient_ListProfiles(t *testing.T) {
 	// Set expectation for ListProfiles call
 	mockClient.EXPECT().
 		ListProfiles(gomock.Any(), req).
-		Return(expectedProfiles, nil).
+		Return(profiler.ListProfilesResponse{Profiles: expectedProfiles}, nil).
 		Times(1)
 
-	result, err := client.ListProfiles(context.Background(), req)
+	resp, err := client.ListProfiles(context.Background(), req)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
+	result := resp.Profiles
 
 	if len(result) != 2 {
 		t.Errorf("Expected 2 profiles, got %d", len(result))
@@ -0,0 +1,15 @@
+package profiler
+
+import "net/url"
+
+// ConsoleURL returns the Cloud Profiler URL showing the profiles of the target and type
+func ConsoleURL(projectID, target string, profileType ProfileType) string {
+	u := "https://console.cloud.google.com/profiler"
+	if target != "" {
+		u += "/" + url.PathEscape(target)
+		if profileType != "" {
+			u += ";type=" + url.PathEscape(string(profileType))
+		}
+	}
+	return u + "?" + url.Values{"project": {projectID}}.Encode()
+}
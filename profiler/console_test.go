@@ -0,0 +1,12 @@
+package profiler
+
+import "testing"
+
+func TestConsoleURL(t *testing.T) {
+	if got, want := ConsoleURL("my-project", "checkout", ProfileTypeHeap), "https://console.cloud.google.com/profiler/checkout;type=HEAP?project=my-project"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if got, want := ConsoleURL("my-project", "", ""), "https://console.cloud.google.com/profiler?project=my-project"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
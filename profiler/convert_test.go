@@ -0,0 +1,46 @@
+package profiler
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/api/cloudprofiler/v2"
+)
+
+func TestConvertAPIProfileToProfile(t *testing.T) {
+	profile := convertAPIProfileToProfile(&cloudprofiler.Profile{
+		Name:        "projects/test-project/profiles/profile123",
+		ProfileType: "CPU",
+		Duration:    "10s",
+		StartTime:   "2024-01-01T12:00:00.500Z",
+		Deployment:  &cloudprofiler.Deployment{ProjectId: "test-project", Target: "checkout"},
+	})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 500000000, time.UTC)
+	if !profile.StartTime.Equal(start) {
+		t.Errorf("Expected start time %v, got %v", start, profile.StartTime)
+	}
+	if got := profile.ParsedDuration(); got != 10*time.Second {
+		t.Errorf("Expected duration 10s, got %v", got)
+	}
+	if !profile.EndTime.Equal(start.Add(10 * time.Second)) {
+		t.Errorf("Expected end time %v, got %v", start.Add(10*time.Second), profile.EndTime)
+	}
+	if !profile.ExpireTime.Equal(start.Add(30 * 24 * time.Hour)) {
+		t.Errorf("Expected expire time %v, got %v", start.Add(30*24*time.Hour), profile.ExpireTime)
+	}
+}
+
+func TestConvertAPIProfileToProfileWithoutStartTime(t *testing.T) {
+	profile := convertAPIProfileToProfile(&cloudprofiler.Profile{
+		Name:        "projects/test-project/profiles/profile123",
+		ProfileType: "HEAP",
+	})
+
+	if !profile.StartTime.IsZero() || !profile.EndTime.IsZero() || !profile.ExpireTime.IsZero() {
+		t.Errorf("Expected no times for a profile without start time, got %v, %v, %v", profile.StartTime, profile.EndTime, profile.ExpireTime)
+	}
+	if got := profile.ParsedDuration(); got != 0 {
+		t.Errorf("Expected zero duration, got %v", got)
+	}
+}
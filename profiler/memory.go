@@ -0,0 +1,223 @@
+package profiler
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+const (
+	// defaultGrowthBuckets is the number of time buckets compared by default
+	defaultGrowthBuckets = 4
+	// minGrowthBuckets is the number of buckets with profiles needed to tell growth from noise
+	minGrowthBuckets = 3
+	// maxGrowthBuckets bounds the number of time buckets
+	maxGrowthBuckets = 24
+	// defaultGrowthProfiles is the number of heap profiles compared by default
+	defaultGrowthProfiles = 100
+	// inUseSpaceSampleType is the heap sample type of the bytes in use
+	inUseSpaceSampleType = "inuse_space"
+)
+
+// MemoryGrowthRequest represents a request to find allocation sites whose
+// in-use bytes grow over time
+type MemoryGrowthRequest struct {
+	ProjectID      string            `json:"project_id,omitempty"` // defaults to the client's project
+	Target         string            `json:"target"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	StartTime      time.Time         `json:"start_time,omitzero"`        // defaults to 24 hours before EndTime
+	EndTime        time.Time         `json:"end_time,omitzero"`          // defaults to now
+	Buckets        int               `json:"buckets,omitempty"`          // defaults to defaultGrowthBuckets
+	MaxProfiles    int               `json:"max_profiles,omitempty"`     // defaults to defaultGrowthProfiles
+	MinGrowthBytes int64             `json:"min_growth_bytes,omitempty"` // sites growing less are not reported
+	Top            int               `json:"top,omitempty"`              // defaults to defaultTopHotspots
+	// SourceMappings link the allocation sites to their source code when set
+	SourceMappings []SourceMapping `json:"source_mappings,omitempty"`
+}
+
+// GrowthBucket represents the heap profiles collected in one time bucket
+type GrowthBucket struct {
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Profiles   int       `json:"profiles"`
+	InUseBytes int64     `json:"inuse_bytes"` // average over the profiles of the bucket
+}
+
+// AllocationSite represents a function allocating memory that is still in use
+type AllocationSite struct {
+	Function      string  `json:"function"`
+	File          string  `json:"file,omitempty"`
+	Line          int64   `json:"line,omitempty"`
+	InUseBytes    []int64 `json:"inuse_bytes"` // average in each bucket
+	Growth        int64   `json:"growth"`      // from the first to the last bucket
+	GrowthPercent float64 `json:"growth_percent,omitempty"`
+	SourceURL     string  `json:"source_url,omitempty"`
+}
+
+// MemoryGrowthResponse represents the allocation sites whose in-use bytes
+// increased in every bucket
+type MemoryGrowthResponse struct {
+	Target         string           `json:"target"`
+	SampleType     string           `json:"sample_type"`
+	Buckets        []GrowthBucket   `json:"buckets"` // buckets with profiles, oldest first
+	EmptyBuckets   int              `json:"empty_buckets,omitempty"`
+	ProfilesFailed int              `json:"profiles_failed,omitempty"` // profiles that could not be parsed
+	Sites          []AllocationSite `json:"sites"`
+	ConsoleURL     string           `json:"console_url,omitempty"`
+}
+
+// DetectMemoryGrowth compares the heap profiles of a target across time
+// buckets and reports the allocation sites whose in-use bytes increased
+// monotonically, which is a strong sign of a leak
+func (c *CloudProfilerClient) DetectMemoryGrowth(ctx context.Context, req MemoryGrowthRequest) (MemoryGrowthResponse, error) {
+	if req.Buckets == 0 {
+		req.Buckets = defaultGrowthBuckets
+	}
+	if req.Buckets < minGrowthBuckets || req.Buckets > maxGrowthBuckets {
+		return MemoryGrowthResponse{}, fmt.Errorf("buckets must be between %d and %d", minGrowthBuckets, maxGrowthBuckets)
+	}
+	if req.MaxProfiles <= 0 {
+		req.MaxProfiles = defaultGrowthProfiles
+	}
+	query, err := queryFor(req.ProjectID, req.Target, ProfileTypeHeap, req.Labels, req.StartTime, req.EndTime, req.MaxProfiles)
+	if err != nil {
+		return MemoryGrowthResponse{}, err
+	}
+	profiles, err := c.client.QueryProfiles(ctx, query)
+	if err != nil {
+		return MemoryGrowthResponse{}, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	resp, err := detectMemoryGrowth(profiles, query.StartTime, query.EndTime, req.Buckets, req.MinGrowthBytes, req.Top)
+	if err != nil {
+		return MemoryGrowthResponse{}, err
+	}
+	for i, site := range resp.Sites {
+		resp.Sites[i].SourceURL = SourceURL(req.SourceMappings, site.File, site.Line)
+	}
+	resp.Target = req.Target
+	resp.ConsoleURL = ConsoleURL(c.project(req.ProjectID), req.Target, ProfileTypeHeap)
+	return resp, nil
+}
+
+// detectMemoryGrowth splits the time range into buckets, averages the in-use
+// bytes of each function over the profiles of each bucket, and returns the
+// functions whose average never decreased and grew by at least minGrowth
+func detectMemoryGrowth(profiles []*Profile, start, end time.Time, buckets int, minGrowth int64, top int) (MemoryGrowthResponse, error) {
+	if top <= 0 {
+		top = defaultTopHotspots
+	}
+
+	type bucket struct {
+		GrowthBucket
+		byFunction map[string]*Hotspot // flat values summed over the profiles
+	}
+	width := end.Sub(start) / time.Duration(buckets)
+	if width <= 0 {
+		return MemoryGrowthResponse{}, fmt.Errorf("the time range is too short for %d buckets", buckets)
+	}
+	all := make([]bucket, buckets)
+	for i := range all {
+		all[i].StartTime = start.Add(time.Duration(i) * width)
+		all[i].EndTime = start.Add(time.Duration(i+1) * width)
+		all[i].byFunction = make(map[string]*Hotspot)
+	}
+	if last := &all[buckets-1]; last.EndTime.Before(end) {
+		last.EndTime = end
+	}
+
+	var resp MemoryGrowthResponse
+	for _, p := range profiles {
+		if p.StartTime.Before(start) || !p.StartTime.Before(end) {
+			continue
+		}
+		i := min(int(p.StartTime.Sub(start)/width), buckets-1)
+
+		pp, err := parseProfile(p)
+		if err != nil {
+			resp.ProfilesFailed++
+			continue
+		}
+		index, err := sampleIndex(pp, inUseSpaceSampleType)
+		if err != nil {
+			// Heap profiles of other runtimes may not name their sample types alike
+			index, _ = sampleIndex(pp, "")
+		}
+		resp.SampleType = pp.SampleType[index].Type
+
+		total, byFunction := functionValues(pp, index)
+		all[i].Profiles++
+		all[i].InUseBytes += total
+		for name, h := range byFunction {
+			if h.Flat == 0 {
+				continue
+			}
+			sum, ok := all[i].byFunction[name]
+			if !ok {
+				sum = &Hotspot{Function: h.Function, File: h.File}
+				all[i].byFunction[name] = sum
+			}
+			sum.Line = h.Line
+			sum.Flat += h.Flat
+		}
+	}
+
+	var used []bucket
+	for _, b := range all {
+		if b.Profiles == 0 {
+			resp.EmptyBuckets++
+			continue
+		}
+		b.InUseBytes /= int64(b.Profiles)
+		used = append(used, b)
+		resp.Buckets = append(resp.Buckets, b.GrowthBucket)
+	}
+	if len(used) < minGrowthBuckets {
+		return MemoryGrowthResponse{}, fmt.Errorf("only %d of %d time buckets have heap profiles, and at least %d are needed; widen the time range or use fewer buckets",
+			len(used), buckets, minGrowthBuckets)
+	}
+
+	// Functions missing from a bucket had no bytes in use then
+	names := make(map[string]*Hotspot)
+	for _, b := range used {
+		maps.Copy(names, b.byFunction)
+	}
+	resp.Sites = []AllocationSite{}
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		site := AllocationSite{Function: name, File: names[name].File, Line: names[name].Line}
+		monotonic := true
+		for i, b := range used {
+			var average int64
+			if h, ok := b.byFunction[name]; ok {
+				average = h.Flat / int64(b.Profiles)
+			}
+			if i > 0 && average < site.InUseBytes[i-1] {
+				monotonic = false
+				break
+			}
+			site.InUseBytes = append(site.InUseBytes, average)
+		}
+		if !monotonic {
+			continue
+		}
+		first, last := site.InUseBytes[0], site.InUseBytes[len(site.InUseBytes)-1]
+		site.Growth = last - first
+		if site.Growth <= 0 || site.Growth < minGrowth {
+			continue
+		}
+		if first > 0 {
+			site.GrowthPercent = percent(site.Growth, first)
+		}
+		resp.Sites = append(resp.Sites, site)
+	}
+	slices.SortStableFunc(resp.Sites, func(a, b AllocationSite) int {
+		return cmp.Compare(b.Growth, a.Growth)
+	})
+	if len(resp.Sites) > top {
+		resp.Sites = resp.Sites[:top]
+	}
+	return resp, nil
+}
@@ -0,0 +1,119 @@
+package profiler_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	pprofile "github.com/google/pprof/profile"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// heapProfile builds a base64 encoded Go heap profile of the stacks
+func heapProfile(t *testing.T, stacks ...stack) string {
+	t.Helper()
+	return encodeProfile(t, &pprofile.Profile{
+		SampleType: []*pprofile.ValueType{
+			{Type: "alloc_objects", Unit: "count"},
+			{Type: "alloc_space", Unit: "bytes"},
+			{Type: "inuse_objects", Unit: "count"},
+			{Type: "inuse_space", Unit: "bytes"},
+		},
+		DefaultSampleType: "alloc_space",
+		PeriodType:        &pprofile.ValueType{Type: "space", Unit: "bytes"},
+		Period:            524288,
+	}, stacks...)
+}
+
+func TestCloudProfilerClient_DetectMemoryGrowth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(8 * time.Hour)
+	deployment := &profiler.Deployment{ProjectID: "test-project", Target: "checkout"}
+
+	// In-use bytes of each function at each hour
+	leak := []int64{100, 150, 200, 300, 350, 400, 500, 600}
+	stable := []int64{1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000}
+	noisy := []int64{100, 300, 200, 100, 300, 200, 400, 500}
+	var profiles []*profiler.Profile
+	for hour := range 8 {
+		profiles = append(profiles, &profiler.Profile{
+			Name: "p", ProfileType: profiler.ProfileTypeHeap, StartTime: start.Add(time.Duration(hour) * time.Hour), Deployment: deployment,
+			ProfileBytes: heapProfile(t,
+				stack{[]string{"main.cacheEntry", "main.handle"}, leak[hour]},
+				stack{[]string{"main.loadConfig", "main.main"}, stable[hour]},
+				stack{[]string{"main.buffer", "main.handle"}, noisy[hour]},
+			),
+		})
+	}
+
+	mockClient := mocks.NewMockProfilerClientInterface(ctrl)
+	mockClient.EXPECT().
+		QueryProfiles(gomock.Any(), profiler.QueryProfilesRequest{
+			Target:      "checkout",
+			ProfileType: profiler.ProfileTypeHeap,
+			StartTime:   start,
+			EndTime:     end,
+			MaxProfiles: 100,
+		}).
+		Return(profiles, nil).
+		Times(1)
+
+	client := profiler.NewWithClient(mockClient, "test-project")
+	resp, err := client.DetectMemoryGrowth(context.Background(), profiler.MemoryGrowthRequest{
+		Target:    "checkout",
+		StartTime: start,
+		EndTime:   end,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.SampleType != "inuse_space" {
+		t.Errorf("Expected inuse_space sample type, got %s", resp.SampleType)
+	}
+	if len(resp.Buckets) != 4 || resp.Buckets[0].Profiles != 2 || resp.Buckets[0].InUseBytes != 1325 {
+		t.Errorf("Unexpected buckets %+v", resp.Buckets)
+	}
+
+	// Only the leak grew in every bucket: stable did not grow, and noisy
+	// decreased from the first to the second bucket
+	if len(resp.Sites) != 1 {
+		t.Fatalf("Expected 1 growing site, got %+v", resp.Sites)
+	}
+	site := resp.Sites[0]
+	if site.Function != "main.cacheEntry" || site.Line != 10 || !slices.Equal(site.InUseBytes, []int64{125, 250, 375, 550}) {
+		t.Errorf("Unexpected site %+v", site)
+	}
+	if site.Growth != 425 || site.GrowthPercent != 340 {
+		t.Errorf("Expected growth of 425 bytes (340%%), got %d (%v%%)", site.Growth, site.GrowthPercent)
+	}
+}
+
+func TestCloudProfilerClient_DetectMemoryGrowthTooFewProfiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	profiles := []*profiler.Profile{
+		{Name: "p", ProfileType: profiler.ProfileTypeHeap, StartTime: start.Add(time.Hour), ProfileBytes: heapProfile(t, stack{[]string{"main.a"}, 1})},
+		{Name: "p", ProfileType: profiler.ProfileTypeHeap, StartTime: start.Add(2 * time.Hour), ProfileBytes: heapProfile(t, stack{[]string{"main.a"}, 2})},
+	}
+
+	mockClient := mocks.NewMockProfilerClientInterface(ctrl)
+	mockClient.EXPECT().QueryProfiles(gomock.Any(), gomock.Any()).Return(profiles, nil).Times(1)
+	client := profiler.NewWithClient(mockClient, "test-project")
+
+	if _, err := client.DetectMemoryGrowth(context.Background(), profiler.MemoryGrowthRequest{Target: "checkout", Buckets: 2}); err == nil {
+		t.Error("Expected error for fewer than 3 buckets")
+	}
+	_, err := client.DetectMemoryGrowth(context.Background(), profiler.MemoryGrowthRequest{Target: "checkout", StartTime: start, EndTime: start.Add(8 * time.Hour)})
+	if err == nil {
+		t.Error("Expected error when only 2 buckets have profiles")
+	}
+}
@@ -41,6 +41,21 @@ func (m *MockProfilerClient) EXPECT() *MockProfilerClientMockRecorder {
 	return m.recorder
 }
 
+// AggregateProfiles mocks base method.
+func (m *MockProfilerClient) AggregateProfiles(ctx context.Context, req profiler.AggregateProfilesRequest) (profiler.AggregateProfilesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AggregateProfiles", ctx, req)
+	ret0, _ := ret[0].(profiler.AggregateProfilesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AggregateProfiles indicates an expected call of AggregateProfiles.
+func (mr *MockProfilerClientMockRecorder) AggregateProfiles(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AggregateProfiles", reflect.TypeOf((*MockProfilerClient)(nil).AggregateProfiles), ctx, req)
+}
+
 // CreateOfflineProfile mocks base method.
 func (m *MockProfilerClient) CreateOfflineProfile(ctx context.Context, req profiler.CreateOfflineProfileRequest) (*profiler.Profile, error) {
 	m.ctrl.T.Helper()
@@ -71,11 +86,26 @@ func (mr *MockProfilerClientMockRecorder) CreateProfile(ctx, req any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProfile", reflect.TypeOf((*MockProfilerClient)(nil).CreateProfile), ctx, req)
 }
 
+// DetectMemoryGrowth mocks base method.
+func (m *MockProfilerClient) DetectMemoryGrowth(ctx context.Context, req profiler.MemoryGrowthRequest) (profiler.MemoryGrowthResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetectMemoryGrowth", ctx, req)
+	ret0, _ := ret[0].(profiler.MemoryGrowthResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DetectMemoryGrowth indicates an expected call of DetectMemoryGrowth.
+func (mr *MockProfilerClientMockRecorder) DetectMemoryGrowth(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectMemoryGrowth", reflect.TypeOf((*MockProfilerClient)(nil).DetectMemoryGrowth), ctx, req)
+}
+
 // ListProfiles mocks base method.
-func (m *MockProfilerClient) ListProfiles(ctx context.Context, req profiler.ListProfilesRequest) ([]*profiler.Profile, error) {
+func (m *MockProfilerClient) ListProfiles(ctx context.Context, req profiler.ListProfilesRequest) (profiler.ListProfilesResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListProfiles", ctx, req)
-	ret0, _ := ret[0].([]*profiler.Profile)
+	ret0, _ := ret[0].(profiler.ListProfilesResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -86,6 +116,21 @@ func (mr *MockProfilerClientMockRecorder) ListProfiles(ctx, req any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProfiles", reflect.TypeOf((*MockProfilerClient)(nil).ListProfiles), ctx, req)
 }
 
+// QueryProfiles mocks base method.
+func (m *MockProfilerClient) QueryProfiles(ctx context.Context, req profiler.QueryProfilesRequest) ([]*profiler.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryProfiles", ctx, req)
+	ret0, _ := ret[0].([]*profiler.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryProfiles indicates an expected call of QueryProfiles.
+func (mr *MockProfilerClientMockRecorder) QueryProfiles(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryProfiles", reflect.TypeOf((*MockProfilerClient)(nil).QueryProfiles), ctx, req)
+}
+
 // UpdateProfile mocks base method.
 func (m *MockProfilerClient) UpdateProfile(ctx context.Context, req profiler.UpdateProfileRequest) (*profiler.Profile, error) {
 	m.ctrl.T.Helper()
@@ -156,10 +201,10 @@ func (mr *MockProfilerClientInterfaceMockRecorder) CreateProfile(ctx, req any) *
 }
 
 // ListProfiles mocks base method.
-func (m *MockProfilerClientInterface) ListProfiles(ctx context.Context, req profiler.ListProfilesRequest) ([]*profiler.Profile, error) {
+func (m *MockProfilerClientInterface) ListProfiles(ctx context.Context, req profiler.ListProfilesRequest) (profiler.ListProfilesResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListProfiles", ctx, req)
-	ret0, _ := ret[0].([]*profiler.Profile)
+	ret0, _ := ret[0].(profiler.ListProfilesResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -170,6 +215,21 @@ func (mr *MockProfilerClientInterfaceMockRecorder) ListProfiles(ctx, req any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProfiles", reflect.TypeOf((*MockProfilerClientInterface)(nil).ListProfiles), ctx, req)
 }
 
+// QueryProfiles mocks base method.
+func (m *MockProfilerClientInterface) QueryProfiles(ctx context.Context, req profiler.QueryProfilesRequest) ([]*profiler.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryProfiles", ctx, req)
+	ret0, _ := ret[0].([]*profiler.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryProfiles indicates an expected call of QueryProfiles.
+func (mr *MockProfilerClientInterfaceMockRecorder) QueryProfiles(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryProfiles", reflect.TypeOf((*MockProfilerClientInterface)(nil).QueryProfiles), ctx, req)
+}
+
 // UpdateProfile mocks base method.
 func (m *MockProfilerClientInterface) UpdateProfile(ctx context.Context, req profiler.UpdateProfileRequest) (*profiler.Profile, error) {
 	m.ctrl.T.Helper()
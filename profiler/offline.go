@@ -0,0 +1,72 @@
+package profiler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	pprofile "github.com/google/pprof/profile"
+)
+
+// MaxProfileSize bounds the size of the profile data of offline profiles
+const MaxProfileSize = 10 << 20
+
+// gzipMagic is the header of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ReadProfileFile reads a local pprof file and returns its data encoded for
+// an offline profile
+func ReadProfileFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read profile file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("profile file %s is a directory", path)
+	}
+	if info.Size() > MaxProfileSize {
+		return "", fmt.Errorf("profile file %s is %d bytes, larger than the maximum of %d bytes", path, info.Size(), MaxProfileSize)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read profile file: %w", err)
+	}
+	return EncodeProfileData(data)
+}
+
+// DecodeProfileData decodes base64 profile data and returns it encoded for
+// an offline profile
+func DecodeProfileData(encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("profile data is not valid base64: %w", err)
+	}
+	return EncodeProfileData(data)
+}
+
+// EncodeProfileData validates pprof data and returns it as base64 encoded,
+// gzip compressed protocol buffers as Cloud Profiler expects. Uncompressed
+// and legacy text profiles are converted.
+func EncodeProfileData(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("profile data is empty")
+	}
+	if len(data) > MaxProfileSize {
+		return "", fmt.Errorf("profile data is %d bytes, larger than the maximum of %d bytes", len(data), MaxProfileSize)
+	}
+
+	p, err := pprofile.ParseData(data)
+	if err != nil {
+		return "", fmt.Errorf("profile data is not a valid pprof profile: %w", err)
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		var buf bytes.Buffer
+		if err := p.Write(&buf); err != nil {
+			return "", fmt.Errorf("failed to compress profile: %w", err)
+		}
+		data = buf.Bytes()
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,100 @@
+package profiler_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pprofile "github.com/google/pprof/profile"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+)
+
+func TestReadProfileFile(t *testing.T) {
+	encoded := cpuProfile(t, stack{[]string{"main.encode", "main.main"}, 100})
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode profile: %v", err)
+	}
+	p, err := pprofile.ParseData(compressed)
+	if err != nil {
+		t.Fatalf("Failed to parse profile: %v", err)
+	}
+	var uncompressed bytes.Buffer
+	if err := p.WriteUncompressed(&uncompressed); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	t.Run("gzip compressed profile is kept as is", func(t *testing.T) {
+		got, err := profiler.ReadProfileFile(write("cpu.pb.gz", compressed))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got != encoded {
+			t.Error("Expected the compressed profile to be uploaded unchanged")
+		}
+	})
+
+	t.Run("uncompressed profile is compressed", func(t *testing.T) {
+		got, err := profiler.ReadProfileFile(write("cpu.pb", uncompressed.Bytes()))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		data, err := base64.StdEncoding.DecodeString(got)
+		if err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		if !bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+			t.Error("Expected gzip compressed data")
+		}
+		if _, err := pprofile.ParseData(data); err != nil {
+			t.Errorf("Expected a valid profile, got %v", err)
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		for _, path := range []string{
+			filepath.Join(dir, "missing.pb.gz"),
+			dir,
+			write("empty.pb.gz", nil),
+			write("notes.txt", []byte("not a profile")),
+		} {
+			if _, err := profiler.ReadProfileFile(path); err == nil {
+				t.Errorf("Expected error for %s", path)
+			}
+		}
+	})
+
+	t.Run("file too large", func(t *testing.T) {
+		path := filepath.Join(dir, "large.pb.gz")
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		if err := os.Truncate(path, profiler.MaxProfileSize+1); err != nil {
+			t.Fatalf("Failed to grow file: %v", err)
+		}
+		if _, err := profiler.ReadProfileFile(path); err == nil {
+			t.Error("Expected error for a file larger than the maximum")
+		}
+	})
+}
+
+func TestDecodeProfileData(t *testing.T) {
+	encoded := cpuProfile(t, stack{[]string{"main.main"}, 1})
+	if got, err := profiler.DecodeProfileData(encoded); err != nil || got != encoded {
+		t.Errorf("Expected the profile unchanged, got error %v", err)
+	}
+	if _, err := profiler.DecodeProfileData("not base64!"); err == nil {
+		t.Error("Expected error for invalid base64")
+	}
+}
@@ -0,0 +1,93 @@
+package profiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// cloudSourceRepositoriesHost is the host of Cloud Source Repositories URLs
+const cloudSourceRepositoriesHost = "source.cloud.google.com"
+
+// SourceMapping maps the source files of profiles to a repository, so that
+// frames can link to the code
+type SourceMapping struct {
+	// PathPrefix is the prefix of the file paths in profiles, e.g. the module
+	// path "github.com/org/repo/" of binaries built with -trimpath, or "/app/"
+	PathPrefix string `json:"path_prefix"`
+	// Repository is the web URL of the repository, e.g.
+	// "https://github.com/org/repo" or "https://source.cloud.google.com/PROJECT/REPO"
+	Repository string `json:"repository"`
+	// Revision is the commit, tag, or branch to link to; defaults to HEAD on
+	// GitHub and master on Cloud Source Repositories
+	Revision string `json:"revision,omitempty"`
+	// Directory is the directory of the repository the prefix corresponds to
+	Directory string `json:"directory,omitempty"`
+}
+
+// Validate checks that the mapping has a prefix and a repository URL
+func (m SourceMapping) Validate() error {
+	if m.PathPrefix == "" {
+		return fmt.Errorf("path_prefix is required")
+	}
+	u, err := url.Parse(m.Repository)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("repository must be an https URL, got %q", m.Repository)
+	}
+	return nil
+}
+
+// ParseSourceMappings parses a JSON array of source mappings
+func ParseSourceMappings(data string) ([]SourceMapping, error) {
+	var mappings []SourceMapping
+	if err := json.Unmarshal([]byte(data), &mappings); err != nil {
+		return nil, fmt.Errorf("invalid source mappings: %w", err)
+	}
+	for i, m := range mappings {
+		if err := m.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid source mapping %d: %w", i, err)
+		}
+	}
+	return mappings, nil
+}
+
+// SourceURL returns the permalink to a line of a file using the mapping with
+// the longest matching prefix, or an empty string when no mapping matches
+func SourceURL(mappings []SourceMapping, file string, line int64) string {
+	var match *SourceMapping
+	for i, m := range mappings {
+		if strings.HasPrefix(file, m.PathPrefix) && (match == nil || len(m.PathPrefix) > len(match.PathPrefix)) {
+			match = &mappings[i]
+		}
+	}
+	if match == nil {
+		return ""
+	}
+
+	filePath := path.Join(match.Directory, strings.TrimPrefix(file, match.PathPrefix))
+	filePath = strings.TrimPrefix(filePath, "/")
+	repository := strings.TrimSuffix(match.Repository, "/")
+	revision := match.Revision
+
+	if u, err := url.Parse(repository); err == nil && u.Host == cloudSourceRepositoriesHost {
+		if revision == "" {
+			revision = "master"
+		}
+		permalink := fmt.Sprintf("%s/+/%s:%s", repository, revision, filePath)
+		if line > 0 {
+			permalink += fmt.Sprintf(";l=%d", line)
+		}
+		return permalink
+	}
+
+	if revision == "" {
+		revision = "HEAD"
+	}
+	permalink := fmt.Sprintf("%s/blob/%s/%s", repository, revision, filePath)
+	if line > 0 {
+		permalink += fmt.Sprintf("#L%d", line)
+	}
+	return permalink
+}
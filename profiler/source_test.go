@@ -0,0 +1,80 @@
+package profiler_test
+
+import (
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+)
+
+func TestSourceURL(t *testing.T) {
+	mappings := []profiler.SourceMapping{
+		{PathPrefix: "github.com/example/shop/", Repository: "https://github.com/example/shop/", Revision: "abc123"},
+		{PathPrefix: "github.com/example/shop/vendor/", Repository: "https://github.com/example/vendored"},
+		{PathPrefix: "/workspace/", Repository: "https://source.cloud.google.com/my-project/billing", Directory: "services/billing"},
+	}
+
+	tests := []struct {
+		name string
+		file string
+		line int64
+		want string
+	}{
+		{
+			name: "GitHub permalink",
+			file: "github.com/example/shop/cart/cart.go",
+			line: 42,
+			want: "https://github.com/example/shop/blob/abc123/cart/cart.go#L42",
+		},
+		{
+			name: "longest prefix wins and revision defaults to HEAD",
+			file: "github.com/example/shop/vendor/lib/lib.go",
+			line: 7,
+			want: "https://github.com/example/vendored/blob/HEAD/lib/lib.go#L7",
+		},
+		{
+			name: "Cloud Source Repositories permalink with directory",
+			file: "/workspace/main.go",
+			line: 3,
+			want: "https://source.cloud.google.com/my-project/billing/+/master:services/billing/main.go;l=3",
+		},
+		{
+			name: "unknown line",
+			file: "github.com/example/shop/main.go",
+			want: "https://github.com/example/shop/blob/abc123/main.go",
+		},
+		{
+			name: "no matching mapping",
+			file: "/usr/local/go/src/runtime/malloc.go",
+			line: 10,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := profiler.SourceURL(mappings, tt.file, tt.line); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseSourceMappings(t *testing.T) {
+	mappings, err := profiler.ParseSourceMappings(`[{"path_prefix": "/app/", "repository": "https://github.com/example/shop", "revision": "main"}]`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(mappings) != 1 || mappings[0].PathPrefix != "/app/" || mappings[0].Revision != "main" {
+		t.Errorf("Unexpected mappings %+v", mappings)
+	}
+
+	for _, data := range []string{
+		`not json`,
+		`[{"repository": "https://github.com/example/shop"}]`,
+		`[{"path_prefix": "/app/", "repository": "github.com/example/shop"}]`,
+	} {
+		if _, err := profiler.ParseSourceMappings(data); err == nil {
+			t.Errorf("Expected error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package redact
+
+//go:generate go tool mockgen -destination=mocks/mock_dlp.go -package=mocks github.com/kitagry/gcp-telemetry-mcp/redact DLPClientInterface
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"google.golang.org/api/dlp/v2"
+	"google.golang.org/api/option"
+)
+
+const (
+	// maxDLPBatchValues bounds the values sent in one request, well below the
+	// 50,000 table cells allowed by Cloud DLP
+	maxDLPBatchValues = 1000
+	// maxDLPBatchBytes bounds the bytes sent in one request, below the 0.5 MB
+	// request limit of Cloud DLP
+	maxDLPBatchBytes = 400 << 10
+)
+
+// DefaultInfoTypes are the Cloud DLP infoTypes scanned for by default
+var DefaultInfoTypes = []string{
+	"EMAIL_ADDRESS", "PHONE_NUMBER", "CREDIT_CARD_NUMBER", "IP_ADDRESS", "STREET_ADDRESS",
+	"US_SOCIAL_SECURITY_NUMBER", "IBAN_CODE", "AUTH_TOKEN", "GCP_API_KEY", "PASSWORD",
+}
+
+// DeidentifyResult represents values whose findings were replaced with the
+// names of their infoTypes
+type DeidentifyResult struct {
+	Values   []string       // in the order of the request
+	Findings map[string]int // number of findings by infoType
+}
+
+// DLPClientInterface abstracts the Cloud DLP API for testing
+type DLPClientInterface interface {
+	Deidentify(ctx context.Context, projectID string, values []string, infoTypes []string) (DeidentifyResult, error)
+}
+
+// InfoTypeCount represents the number of findings of an infoType
+type InfoTypeCount struct {
+	InfoType string `json:"info_type"`
+	Count    int    `json:"count"`
+}
+
+// ScanSummary represents what a DLP scan found and redacted
+type ScanSummary struct {
+	InfoTypes       []string        `json:"info_types"` // infoTypes scanned for
+	ValuesScanned   int             `json:"values_scanned"`
+	EntriesRedacted int             `json:"entries_redacted"`
+	Findings        []InfoTypeCount `json:"findings"` // most frequent first
+	Total           int             `json:"total"`
+}
+
+// DLPScanner inspects log entries with Cloud DLP and masks the findings
+type DLPScanner struct {
+	client    DLPClientInterface
+	projectID string
+	infoTypes []string
+}
+
+// NewDLPScanner creates a DLPScanner that scans for infoTypes, or
+// DefaultInfoTypes when none are given
+func NewDLPScanner(projectID string, infoTypes []string, opts ...option.ClientOption) (*DLPScanner, error) {
+	client, err := NewDLPAPIClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewDLPScannerWithClient(client, projectID, infoTypes), nil
+}
+
+// NewDLPAPIClient creates the DLPClientInterface calling the Cloud DLP API,
+// e.g. to be wrapped before it is passed to NewDLPScannerWithClient
+func NewDLPAPIClient(opts ...option.ClientOption) (DLPClientInterface, error) {
+	service, err := dlp.NewService(context.Background(), append([]option.ClientOption{option.WithScopes(dlp.CloudPlatformScope)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DLP service: %w", err)
+	}
+	return &realDLPClient{service: service}, nil
+}
+
+// NewDLPScannerWithClient creates a DLPScanner with a custom interface for testing
+func NewDLPScannerWithClient(client DLPClientInterface, projectID string, infoTypes []string) *DLPScanner {
+	if len(infoTypes) == 0 {
+		infoTypes = DefaultInfoTypes
+	}
+	return &DLPScanner{client: client, projectID: projectID, infoTypes: infoTypes}
+}
+
+// ScanEntries inspects the messages, labels, payloads, HTTP requests, and
+// audit logs of entries for infoTypes, or the scanner's infoTypes when none
+// are given, and returns the entries with findings replaced by the names of
+// their infoTypes, e.g. [EMAIL_ADDRESS]. Each distinct value is sent once.
+func (s *DLPScanner) ScanEntries(ctx context.Context, projectID string, infoTypes []string, entries []logging.LogEntry) ([]logging.LogEntry, ScanSummary, error) {
+	if projectID == "" {
+		projectID = s.projectID
+	}
+	if len(infoTypes) == 0 {
+		infoTypes = s.infoTypes
+	}
+
+	distinct := make(map[string]bool)
+	for _, entry := range entries {
+		mapEntryStrings(entry, func(v string) string {
+			if v != "" {
+				distinct[v] = true
+			}
+			return v
+		})
+	}
+	values := slices.Sorted(maps.Keys(distinct))
+
+	summary := ScanSummary{InfoTypes: infoTypes, ValuesScanned: len(values), Findings: []InfoTypeCount{}}
+	replacements := make(map[string]string)
+	findings := make(map[string]int)
+	for _, batch := range dlpBatches(values) {
+		result, err := s.client.Deidentify(ctx, projectID, batch, infoTypes)
+		if err != nil {
+			return nil, ScanSummary{}, fmt.Errorf("failed to deidentify log entries: %w", err)
+		}
+		if len(result.Values) != len(batch) {
+			return nil, ScanSummary{}, fmt.Errorf("DLP returned %d values for %d", len(result.Values), len(batch))
+		}
+		for i, v := range batch {
+			if result.Values[i] != v {
+				replacements[v] = result.Values[i]
+			}
+		}
+		for infoType, count := range result.Findings {
+			findings[infoType] += count
+		}
+	}
+
+	redacted := make([]logging.LogEntry, len(entries))
+	for i, entry := range entries {
+		changed := false
+		redacted[i] = mapEntryStrings(entry, func(v string) string {
+			if r, ok := replacements[v]; ok {
+				changed = true
+				return r
+			}
+			return v
+		})
+		if changed {
+			summary.EntriesRedacted++
+		}
+	}
+
+	for infoType, count := range findings {
+		summary.Findings = append(summary.Findings, InfoTypeCount{InfoType: infoType, Count: count})
+		summary.Total += count
+	}
+	slices.SortFunc(summary.Findings, func(a, b InfoTypeCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.InfoType, b.InfoType)
+	})
+	return redacted, summary, nil
+}
+
+// dlpBatches splits values into batches within the request limits of Cloud DLP
+func dlpBatches(values []string) [][]string {
+	var batches [][]string
+	start, size := 0, 0
+	for i, v := range values {
+		if i > start && (i-start == maxDLPBatchValues || size+len(v) > maxDLPBatchBytes) {
+			batches = append(batches, values[start:i])
+			start, size = i, 0
+		}
+		size += len(v)
+	}
+	if start < len(values) {
+		batches = append(batches, values[start:])
+	}
+	return batches
+}
+
+// mapEntryStrings returns a copy of a log entry with fn applied to its
+// message, label values, payload strings, HTTP request, and audit log
+func mapEntryStrings(entry logging.LogEntry, fn func(string) string) logging.LogEntry {
+	mapLabels := func(labels map[string]string) map[string]string {
+		if labels == nil {
+			return nil
+		}
+		mapped := make(map[string]string, len(labels))
+		for k, v := range labels {
+			mapped[k] = fn(v)
+		}
+		return mapped
+	}
+	mapPayload := func(payload map[string]any) map[string]any {
+		if payload == nil {
+			return nil
+		}
+		return mapJSONStrings(payload, fn).(map[string]any)
+	}
+
+	entry.Message = fn(entry.Message)
+	entry.Labels = mapLabels(entry.Labels)
+	entry.Payload = mapPayload(entry.Payload)
+	if entry.Resource != nil {
+		resource := *entry.Resource
+		resource.Labels = mapLabels(resource.Labels)
+		entry.Resource = &resource
+	}
+	if entry.HTTPRequest != nil {
+		req := *entry.HTTPRequest
+		req.URL = fn(req.URL)
+		req.UserAgent = fn(req.UserAgent)
+		req.Referer = fn(req.Referer)
+		req.RemoteIP = fn(req.RemoteIP)
+		req.ServerIP = fn(req.ServerIP)
+		entry.HTTPRequest = &req
+	}
+	if entry.AuditLog != nil {
+		auditLog := *entry.AuditLog
+		auditLog.ResourceName = fn(auditLog.ResourceName)
+		auditLog.PrincipalEmail = fn(auditLog.PrincipalEmail)
+		auditLog.PrincipalSubject = fn(auditLog.PrincipalSubject)
+		if auditLog.DelegationChain != nil {
+			auditLog.DelegationChain = slices.Clone(auditLog.DelegationChain)
+			for i, principal := range auditLog.DelegationChain {
+				auditLog.DelegationChain[i] = fn(principal)
+			}
+		}
+		auditLog.CallerIP = fn(auditLog.CallerIP)
+		auditLog.CallerSuppliedUserAgent = fn(auditLog.CallerSuppliedUserAgent)
+		auditLog.Request = mapPayload(auditLog.Request)
+		auditLog.Response = mapPayload(auditLog.Response)
+		auditLog.Metadata = mapPayload(auditLog.Metadata)
+		if auditLog.Status != nil {
+			status := *auditLog.Status
+			status.Message = fn(status.Message)
+			auditLog.Status = &status
+		}
+		entry.AuditLog = &auditLog
+	}
+	return entry
+}
+
+// mapJSONStrings returns a copy of a JSON value with fn applied to its strings
+func mapJSONStrings(v any, fn func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		mapped := make(map[string]any, len(v))
+		for k, child := range v {
+			mapped[k] = mapJSONStrings(child, fn)
+		}
+		return mapped
+	case []any:
+		mapped := make([]any, len(v))
+		for i, child := range v {
+			mapped[i] = mapJSONStrings(child, fn)
+		}
+		return mapped
+	default:
+		return v
+	}
+}
+
+// realDLPClient implements DLPClientInterface using the Cloud DLP API
+type realDLPClient struct {
+	service *dlp.Service
+}
+
+// Deidentify replaces the findings in values with the names of their
+// infoTypes, sending the values as a one-column table
+func (c *realDLPClient) Deidentify(ctx context.Context, projectID string, values []string, infoTypes []string) (DeidentifyResult, error) {
+	rows := make([]*dlp.GooglePrivacyDlpV2Row, len(values))
+	for i, v := range values {
+		rows[i] = &dlp.GooglePrivacyDlpV2Row{Values: []*dlp.GooglePrivacyDlpV2Value{{StringValue: v}}}
+	}
+	types := make([]*dlp.GooglePrivacyDlpV2InfoType, len(infoTypes))
+	for i, name := range infoTypes {
+		types[i] = &dlp.GooglePrivacyDlpV2InfoType{Name: name}
+	}
+
+	resp, err := c.service.Projects.Content.Deidentify("projects/"+projectID, &dlp.GooglePrivacyDlpV2DeidentifyContentRequest{
+		InspectConfig: &dlp.GooglePrivacyDlpV2InspectConfig{InfoTypes: types},
+		DeidentifyConfig: &dlp.GooglePrivacyDlpV2DeidentifyConfig{
+			InfoTypeTransformations: &dlp.GooglePrivacyDlpV2InfoTypeTransformations{
+				Transformations: []*dlp.GooglePrivacyDlpV2InfoTypeTransformation{{
+					PrimitiveTransformation: &dlp.GooglePrivacyDlpV2PrimitiveTransformation{
+						ReplaceWithInfoTypeConfig: &dlp.GooglePrivacyDlpV2ReplaceWithInfoTypeConfig{},
+					},
+				}},
+			},
+		},
+		Item: &dlp.GooglePrivacyDlpV2ContentItem{
+			Table: &dlp.GooglePrivacyDlpV2Table{
+				Headers: []*dlp.GooglePrivacyDlpV2FieldId{{Name: "value"}},
+				Rows:    rows,
+			},
+		},
+	}).Context(ctx).Do()
+	if err != nil {
+		return DeidentifyResult{}, err
+	}
+
+	result := DeidentifyResult{Values: make([]string, 0, len(values)), Findings: make(map[string]int)}
+	if resp.Item != nil && resp.Item.Table != nil {
+		for _, row := range resp.Item.Table.Rows {
+			if len(row.Values) == 0 {
+				result.Values = append(result.Values, "")
+				continue
+			}
+			result.Values = append(result.Values, row.Values[0].StringValue)
+		}
+	}
+	if resp.Overview != nil {
+		for _, summary := range resp.Overview.TransformationSummaries {
+			if summary.InfoType == nil {
+				continue
+			}
+			for _, r := range summary.Results {
+				if r.Code == "SUCCESS" {
+					result.Findings[summary.InfoType.Name] += int(r.Count)
+				}
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,135 @@
+package redact_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/kitagry/gcp-telemetry-mcp/redact/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeDeidentify replaces the email and phone number used in the tests
+func fakeDeidentify(_ context.Context, _ string, values []string, _ []string) (redact.DeidentifyResult, error) {
+	result := redact.DeidentifyResult{Findings: make(map[string]int)}
+	for _, v := range values {
+		for old, infoType := range map[string]string{"alice@example.com": "EMAIL_ADDRESS", "+1 650-555-0100": "PHONE_NUMBER"} {
+			if n := strings.Count(v, old); n > 0 {
+				v = strings.ReplaceAll(v, old, "["+infoType+"]")
+				result.Findings[infoType] += n
+			}
+		}
+		result.Values = append(result.Values, v)
+	}
+	return result, nil
+}
+
+func TestDLPScanner_ScanEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockDLPClientInterface(ctrl)
+	mockClient.EXPECT().
+		Deidentify(gomock.Any(), "test-project", gomock.Any(), redact.DefaultInfoTypes).
+		DoAndReturn(func(ctx context.Context, projectID string, values []string, infoTypes []string) (redact.DeidentifyResult, error) {
+			// Each distinct value is sent once
+			want := []string{"+1 650-555-0100", "checkout", "order placed by alice@example.com"}
+			if !reflect.DeepEqual(values, want) {
+				t.Errorf("Expected values %v, got %v", want, values)
+			}
+			return fakeDeidentify(ctx, projectID, values, infoTypes)
+		}).
+		Times(1)
+
+	entries := []logging.LogEntry{
+		{
+			Message: "order placed by alice@example.com",
+			Labels:  map[string]string{"service": "checkout"},
+			Payload: map[string]any{"customer": map[string]any{"phone": "+1 650-555-0100"}, "items": float64(3)},
+		},
+		{Message: "order placed by alice@example.com"},
+		{Labels: map[string]string{"service": "checkout"}},
+	}
+
+	scanner := redact.NewDLPScannerWithClient(mockClient, "test-project", nil)
+	got, summary, err := scanner.ScanEntries(context.Background(), "", nil, entries)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got[0].Message != "order placed by [EMAIL_ADDRESS]" || got[1].Message != "order placed by [EMAIL_ADDRESS]" {
+		t.Errorf("Unexpected messages %q, %q", got[0].Message, got[1].Message)
+	}
+	wantPayload := map[string]any{"customer": map[string]any{"phone": "[PHONE_NUMBER]"}, "items": float64(3)}
+	if !reflect.DeepEqual(got[0].Payload, wantPayload) {
+		t.Errorf("Expected payload %v, got %v", wantPayload, got[0].Payload)
+	}
+	if entries[0].Message != "order placed by alice@example.com" {
+		t.Errorf("Expected the original entries to be unchanged, got %q", entries[0].Message)
+	}
+
+	want := redact.ScanSummary{
+		InfoTypes:       redact.DefaultInfoTypes,
+		ValuesScanned:   3,
+		EntriesRedacted: 2,
+		Findings:        []redact.InfoTypeCount{{InfoType: "EMAIL_ADDRESS", Count: 1}, {InfoType: "PHONE_NUMBER", Count: 1}},
+		Total:           2,
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("Expected summary %+v, got %+v", want, summary)
+	}
+}
+
+func TestDLPScanner_ScanEntriesBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var sizes []int
+	mockClient := mocks.NewMockDLPClientInterface(ctrl)
+	mockClient.EXPECT().
+		Deidentify(gomock.Any(), "other-project", gomock.Any(), []string{"EMAIL_ADDRESS"}).
+		DoAndReturn(func(ctx context.Context, projectID string, values []string, infoTypes []string) (redact.DeidentifyResult, error) {
+			sizes = append(sizes, len(values))
+			return fakeDeidentify(ctx, projectID, values, infoTypes)
+		}).
+		Times(3)
+
+	entries := make([]logging.LogEntry, 2500)
+	for i := range entries {
+		entries[i].InsertID = "id"
+		entries[i].Message = "request " + strings.Repeat("x", i%50) + string(rune('a'+i/50))
+	}
+
+	scanner := redact.NewDLPScannerWithClient(mockClient, "test-project", nil)
+	_, summary, err := scanner.ScanEntries(context.Background(), "other-project", []string{"EMAIL_ADDRESS"}, entries)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(sizes, []int{1000, 1000, 500}) {
+		t.Errorf("Expected batches of 1000, 1000, and 500 values, got %v", sizes)
+	}
+	if summary.ValuesScanned != 2500 || summary.Total != 0 || len(summary.Findings) != 0 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+}
+
+func TestDLPScanner_ScanEntriesError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockDLPClientInterface(ctrl)
+	mockClient.EXPECT().
+		Deidentify(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(redact.DeidentifyResult{}, errors.New("permission denied")).
+		Times(1)
+
+	scanner := redact.NewDLPScannerWithClient(mockClient, "test-project", nil)
+	_, _, err := scanner.ScanEntries(context.Background(), "", nil, []logging.LogEntry{{Message: "hello"}})
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected a permission denied error, got %v", err)
+	}
+}
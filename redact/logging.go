@@ -0,0 +1,87 @@
+package redact
+
+import (
+	"context"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+)
+
+// LoggingClient redacts the log entries returned by a logging client
+type LoggingClient struct {
+	logging.LoggingClient
+	redactor *Redactor
+}
+
+// NewLoggingClient wraps client so that the entries it returns are redacted
+func NewLoggingClient(client logging.LoggingClient, redactor *Redactor) *LoggingClient {
+	return &LoggingClient{LoggingClient: client, redactor: redactor}
+}
+
+// ListEntries lists log entries and redacts them
+func (c *LoggingClient) ListEntries(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+	resp, err := c.LoggingClient.ListEntries(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	for i, entry := range resp.Entries {
+		resp.Entries[i] = c.redactor.Entry(entry)
+	}
+	return resp, nil
+}
+
+// ExtractFieldValues extracts the distinct values of a field and redacts them
+func (c *LoggingClient) ExtractFieldValues(ctx context.Context, req logging.FieldValuesRequest) (logging.FieldValuesResponse, error) {
+	resp, err := c.LoggingClient.ExtractFieldValues(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	sensitive := c.redactor.sensitiveField(req.Field)
+	for i, value := range resp.Values {
+		if sensitive {
+			resp.Values[i].Value = placeholder(req.Field)
+			continue
+		}
+		resp.Values[i].Value = c.redactor.String(value.Value)
+	}
+	return resp, nil
+}
+
+// Entry returns a redacted copy of a log entry
+func (r *Redactor) Entry(entry logging.LogEntry) logging.LogEntry {
+	entry.Message = r.String(entry.Message)
+	entry.Labels = r.Labels(entry.Labels)
+	entry.Payload = r.Payload(entry.Payload)
+	if entry.Resource != nil {
+		resource := *entry.Resource
+		resource.Labels = r.Labels(resource.Labels)
+		entry.Resource = &resource
+	}
+	if entry.HTTPRequest != nil {
+		req := *entry.HTTPRequest
+		req.URL = r.String(req.URL)
+		req.UserAgent = r.String(req.UserAgent)
+		req.Referer = r.String(req.Referer)
+		req.RemoteIP = r.String(req.RemoteIP)
+		req.ServerIP = r.String(req.ServerIP)
+		entry.HTTPRequest = &req
+	}
+	if entry.AuditLog != nil {
+		auditLog := *entry.AuditLog
+		auditLog.ResourceName = r.String(auditLog.ResourceName)
+		auditLog.PrincipalEmail = r.String(auditLog.PrincipalEmail)
+		auditLog.PrincipalSubject = r.String(auditLog.PrincipalSubject)
+		auditLog.DelegationChain = r.stringSlice(auditLog.DelegationChain)
+		auditLog.CallerIP = r.String(auditLog.CallerIP)
+		auditLog.CallerSuppliedUserAgent = r.String(auditLog.CallerSuppliedUserAgent)
+		auditLog.Request = r.Payload(auditLog.Request)
+		auditLog.Response = r.Payload(auditLog.Response)
+		auditLog.Metadata = r.Payload(auditLog.Metadata)
+		if auditLog.Status != nil {
+			status := *auditLog.Status
+			status.Message = r.String(status.Message)
+			auditLog.Status = &status
+		}
+		entry.AuditLog = &auditLog
+	}
+	return entry
+}
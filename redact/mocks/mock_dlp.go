@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/kitagry/gcp-telemetry-mcp/redact (interfaces: DLPClientInterface)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_dlp.go -package=mocks github.com/kitagry/gcp-telemetry-mcp/redact DLPClientInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	redact "github.com/kitagry/gcp-telemetry-mcp/redact"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDLPClientInterface is a mock of DLPClientInterface interface.
+type MockDLPClientInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDLPClientInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockDLPClientInterfaceMockRecorder is the mock recorder for MockDLPClientInterface.
+type MockDLPClientInterfaceMockRecorder struct {
+	mock *MockDLPClientInterface
+}
+
+// NewMockDLPClientInterface creates a new mock instance.
+func NewMockDLPClientInterface(ctrl *gomock.Controller) *MockDLPClientInterface {
+	mock := &MockDLPClientInterface{ctrl: ctrl}
+	mock.recorder = &MockDLPClientInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDLPClientInterface) EXPECT() *MockDLPClientInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Deidentify mocks base method.
+func (m *MockDLPClientInterface) Deidentify(ctx context.Context, projectID string, values, infoTypes []string) (redact.DeidentifyResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deidentify", ctx, projectID, values, infoTypes)
+	ret0, _ := ret[0].(redact.DeidentifyResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deidentify indicates an expected call of Deidentify.
+func (mr *MockDLPClientInterfaceMockRecorder) Deidentify(ctx, projectID, values, infoTypes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deidentify", reflect.TypeOf((*MockDLPClientInterface)(nil).Deidentify), ctx, projectID, values, infoTypes)
+}
@@ -0,0 +1,202 @@
+// Package redact removes sensitive data such as emails, IP addresses, and
+// tokens from telemetry before it is returned to MCP clients, so that the
+// server can be used with third-party models.
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Built-in patterns
+const (
+	BuiltinEmail        = "email"
+	BuiltinIPv4         = "ipv4"
+	BuiltinIPv6         = "ipv6"
+	BuiltinBearerToken  = "bearer_token"
+	BuiltinJWT          = "jwt"
+	BuiltinGoogleAPIKey = "google_api_key"
+)
+
+// builtinPatterns are the regular expressions of the built-in patterns
+var builtinPatterns = map[string]string{
+	BuiltinEmail: `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`,
+	BuiltinIPv4:  `\b(?:(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\b`,
+	// Full and "::" compressed addresses, so that times like 10:30:45 are not matched
+	BuiltinIPv6:         `(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|(?i)(?:\b[0-9a-f]{1,4}:){1,6}:(?:[0-9a-f]{1,4}\b(?::[0-9a-f]{1,4}\b){0,5})?`,
+	BuiltinBearerToken:  `(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`,
+	BuiltinJWT:          `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	BuiltinGoogleAPIKey: `\bAIza[0-9A-Za-z_-]{35}\b`,
+}
+
+// defaultFields are the field names whose values are redacted by default
+var defaultFields = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token", "id_token",
+	"api_key", "apikey", "authorization", "cookie", "set-cookie",
+}
+
+// Pattern represents a custom pattern to redact
+type Pattern struct {
+	Name    string `json:"name"`    // shown in the placeholder, e.g. [REDACTED:customer_id]
+	Pattern string `json:"pattern"` // RE2 regular expression
+}
+
+// Config represents what to redact
+type Config struct {
+	Builtins []string  `json:"builtins,omitempty"` // names of built-in patterns
+	Patterns []Pattern `json:"patterns,omitempty"`
+	// Fields are payload keys and label keys whose values are redacted
+	// entirely, compared case-insensitively with the last segment of the key,
+	// e.g. "authorization" matches "/http/request/header/authorization"
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DefaultConfig returns a configuration with all the built-in patterns and
+// common secret field names
+func DefaultConfig() Config {
+	return Config{
+		Builtins: slices.Sorted(maps.Keys(builtinPatterns)),
+		Fields:   slices.Clone(defaultFields),
+	}
+}
+
+// ParseConfig parses a JSON configuration, or "default" for DefaultConfig
+func ParseConfig(data string) (Config, error) {
+	if strings.TrimSpace(data) == "default" {
+		return DefaultConfig(), nil
+	}
+	var config Config
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return Config{}, fmt.Errorf("invalid redaction config: %w", err)
+	}
+	return config, nil
+}
+
+// rule is a compiled pattern
+type rule struct {
+	placeholder string
+	re          *regexp.Regexp
+}
+
+// Redactor replaces sensitive data with placeholders
+type Redactor struct {
+	rules  []rule
+	fields map[string]bool
+}
+
+// New compiles a configuration into a Redactor
+func New(config Config) (*Redactor, error) {
+	r := &Redactor{fields: make(map[string]bool)}
+	for _, name := range config.Builtins {
+		pattern, ok := builtinPatterns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown built-in pattern %q: must be one of %s", name, strings.Join(slices.Sorted(maps.Keys(builtinPatterns)), ", "))
+		}
+		r.rules = append(r.rules, rule{placeholder: placeholder(name), re: regexp.MustCompile(pattern)})
+	}
+	for _, p := range config.Patterns {
+		if p.Name == "" {
+			return nil, fmt.Errorf("pattern %q needs a name", p.Pattern)
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", p.Name, err)
+		}
+		r.rules = append(r.rules, rule{placeholder: placeholder(p.Name), re: re})
+	}
+	for _, field := range config.Fields {
+		r.fields[strings.ToLower(field)] = true
+	}
+	return r, nil
+}
+
+// placeholder returns the replacement of matches of the named pattern
+func placeholder(name string) string {
+	return "[REDACTED:" + name + "]"
+}
+
+// String redacts the matches of the patterns in s
+func (r *Redactor) String(s string) string {
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllLiteralString(s, rule.placeholder)
+	}
+	return s
+}
+
+// stringSlice returns a copy of values with the matches of the patterns redacted
+func (r *Redactor) stringSlice(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	redacted := make([]string, len(values))
+	for i, v := range values {
+		redacted[i] = r.String(v)
+	}
+	return redacted
+}
+
+// sensitiveField reports whether the values of the key are redacted entirely
+func (r *Redactor) sensitiveField(key string) bool {
+	if len(r.fields) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	if i := strings.LastIndexAny(key, "/."); i >= 0 {
+		key = key[i+1:]
+	}
+	return r.fields[key]
+}
+
+// Labels returns a redacted copy of labels
+func (r *Redactor) Labels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if r.sensitiveField(k) {
+			redacted[k] = placeholder(k)
+			continue
+		}
+		redacted[k] = r.String(v)
+	}
+	return redacted
+}
+
+// Payload returns a redacted copy of a JSON payload
+func (r *Redactor) Payload(payload map[string]any) map[string]any {
+	if payload == nil {
+		return nil
+	}
+	return r.value(payload).(map[string]any)
+}
+
+// value returns a redacted copy of a JSON value
+func (r *Redactor) value(v any) any {
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, child := range v {
+			if r.sensitiveField(k) {
+				redacted[k] = placeholder(k)
+				continue
+			}
+			redacted[k] = r.value(child)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, child := range v {
+			redacted[i] = r.value(child)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
@@ -0,0 +1,69 @@
+package redact
+
+import (
+	"context"
+
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+// TraceClient redacts the span names and labels of the traces returned by a
+// trace client
+type TraceClient struct {
+	trace.TraceClient
+	redactor *Redactor
+}
+
+// NewTraceClient wraps client so that the traces it returns are redacted
+func NewTraceClient(client trace.TraceClient, redactor *Redactor) *TraceClient {
+	return &TraceClient{TraceClient: client, redactor: redactor}
+}
+
+// ListTraces lists traces and redacts them
+func (c *TraceClient) ListTraces(ctx context.Context, req trace.ListTracesRequest) (trace.ListTracesResponse, error) {
+	resp, err := c.TraceClient.ListTraces(ctx, req)
+	if err != nil {
+		return trace.ListTracesResponse{}, err
+	}
+	for i := range resp.Traces {
+		resp.Traces[i] = c.redactor.Trace(resp.Traces[i])
+	}
+	return resp, nil
+}
+
+// GetTrace gets a trace and redacts it
+func (c *TraceClient) GetTrace(ctx context.Context, req trace.GetTraceRequest) (*trace.Trace, error) {
+	t, err := c.TraceClient.GetTrace(ctx, req)
+	if err != nil || t == nil {
+		return t, err
+	}
+	redacted := c.redactor.Trace(*t)
+	return &redacted, nil
+}
+
+// GetTraces gets several traces and redacts them
+func (c *TraceClient) GetTraces(ctx context.Context, req trace.GetTracesRequest) trace.GetTracesResponse {
+	resp := c.TraceClient.GetTraces(ctx, req)
+	for id, t := range resp.Traces {
+		if t == nil {
+			continue
+		}
+		redacted := c.redactor.Trace(*t)
+		resp.Traces[id] = &redacted
+	}
+	return resp
+}
+
+// Trace returns a copy of a trace with redacted span names and labels
+func (r *Redactor) Trace(t trace.Trace) trace.Trace {
+	if t.Spans == nil {
+		return t
+	}
+	spans := make([]trace.Span, len(t.Spans))
+	for i, span := range t.Spans {
+		span.Name = r.String(span.Name)
+		span.Labels = r.Labels(span.Labels)
+		spans[i] = span
+	}
+	t.Spans = spans
+	return t
+}
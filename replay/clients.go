@@ -0,0 +1,283 @@
+package replay
+
+import (
+	"context"
+
+	"github.com/kitagry/gcp-telemetry-mcp/asset"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/profiler"
+	"github.com/kitagry/gcp-telemetry-mcp/redact"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+)
+
+// empty is the response of the calls that only return an error
+type empty struct{}
+
+// callErr is call for the methods that only return an error
+func callErr(c *Cassette, service, method string, req any, do func() error) error {
+	_, err := call(c, service, method, req, func() (empty, error) { return empty{}, do() })
+	return err
+}
+
+// LoggingClient implements logging.LoggingClientInterface by recording the
+// calls to the wrapped client, or replaying them when it is nil
+type LoggingClient struct {
+	cassette *Cassette
+	client   logging.LoggingClientInterface
+}
+
+// NewLoggingClient creates a LoggingClient. client is not used when replaying.
+func NewLoggingClient(cassette *Cassette, client logging.LoggingClientInterface) *LoggingClient {
+	return &LoggingClient{cassette: cassette, client: client}
+}
+
+// WriteEntry implements logging.LoggingClientInterface
+func (c *LoggingClient) WriteEntry(ctx context.Context, logName string, entry logging.LogEntry) error {
+	req := struct {
+		LogName string           `json:"log_name"`
+		Entry   logging.LogEntry `json:"entry"`
+	}{logName, entry}
+	return callErr(c.cassette, "logging", "WriteEntry", req, func() error {
+		return c.client.WriteEntry(ctx, logName, entry)
+	})
+}
+
+// WriteEntries implements logging.LoggingClientInterface
+func (c *LoggingClient) WriteEntries(ctx context.Context, req logging.WriteEntriesRequest) error {
+	return callErr(c.cassette, "logging", "WriteEntries", req, func() error {
+		return c.client.WriteEntries(ctx, req)
+	})
+}
+
+// ListEntries implements logging.LoggingClientInterface
+func (c *LoggingClient) ListEntries(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+	return call(c.cassette, "logging", "ListEntries", req, func() (logging.ListEntriesResponse, error) {
+		return c.client.ListEntries(ctx, req)
+	})
+}
+
+// MonitoringClient implements monitoring.MonitoringClientInterface by
+// recording the calls to the wrapped client, or replaying them when it is nil
+type MonitoringClient struct {
+	cassette *Cassette
+	client   monitoring.MonitoringClientInterface
+}
+
+// NewMonitoringClient creates a MonitoringClient. client is not used when replaying.
+func NewMonitoringClient(cassette *Cassette, client monitoring.MonitoringClientInterface) *MonitoringClient {
+	return &MonitoringClient{cassette: cassette, client: client}
+}
+
+// CreateMetricDescriptor implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) CreateMetricDescriptor(ctx context.Context, req monitoring.CreateMetricRequest) error {
+	return callErr(c.cassette, "monitoring", "CreateMetricDescriptor", req, func() error {
+		return c.client.CreateMetricDescriptor(ctx, req)
+	})
+}
+
+// WriteTimeSeries implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) WriteTimeSeries(ctx context.Context, req monitoring.WriteTimeSeriesRequest) error {
+	return callErr(c.cassette, "monitoring", "WriteTimeSeries", req, func() error {
+		return c.client.WriteTimeSeries(ctx, req)
+	})
+}
+
+// ListTimeSeries implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListTimeSeries(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+	return call(c.cassette, "monitoring", "ListTimeSeries", req, func() (monitoring.ListTimeSeriesResponse, error) {
+		return c.client.ListTimeSeries(ctx, req)
+	})
+}
+
+// ListMetricDescriptors implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListMetricDescriptors(ctx context.Context, req monitoring.ListMetricDescriptorsRequest) (monitoring.ListMetricDescriptorsResponse, error) {
+	return call(c.cassette, "monitoring", "ListMetricDescriptors", req, func() (monitoring.ListMetricDescriptorsResponse, error) {
+		return c.client.ListMetricDescriptors(ctx, req)
+	})
+}
+
+// DeleteMetricDescriptor implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) DeleteMetricDescriptor(ctx context.Context, metricType string) error {
+	req := struct {
+		MetricType string `json:"metric_type"`
+	}{metricType}
+	return callErr(c.cassette, "monitoring", "DeleteMetricDescriptor", req, func() error {
+		return c.client.DeleteMetricDescriptor(ctx, metricType)
+	})
+}
+
+// ListAvailableMetrics implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListAvailableMetrics(ctx context.Context, req monitoring.ListAvailableMetricsRequest) ([]monitoring.AvailableMetric, error) {
+	return call(c.cassette, "monitoring", "ListAvailableMetrics", req, func() ([]monitoring.AvailableMetric, error) {
+		return c.client.ListAvailableMetrics(ctx, req)
+	})
+}
+
+// ListAlerts implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListAlerts(ctx context.Context, req monitoring.ListAlertsRequest) (monitoring.ListAlertsResponse, error) {
+	return call(c.cassette, "monitoring", "ListAlerts", req, func() (monitoring.ListAlertsResponse, error) {
+		return c.client.ListAlerts(ctx, req)
+	})
+}
+
+// GetServiceLevelObjective implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) GetServiceLevelObjective(ctx context.Context, name string) (monitoring.ServiceLevelObjective, error) {
+	req := struct {
+		Name string `json:"name"`
+	}{name}
+	return call(c.cassette, "monitoring", "GetServiceLevelObjective", req, func() (monitoring.ServiceLevelObjective, error) {
+		return c.client.GetServiceLevelObjective(ctx, name)
+	})
+}
+
+// ListAlertPolicies implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListAlertPolicies(ctx context.Context, req monitoring.ListAlertPoliciesRequest) ([]monitoring.AlertPolicy, error) {
+	return call(c.cassette, "monitoring", "ListAlertPolicies", req, func() ([]monitoring.AlertPolicy, error) {
+		return c.client.ListAlertPolicies(ctx, req)
+	})
+}
+
+// ListDashboards implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ListDashboards(ctx context.Context, req monitoring.ListDashboardsRequest) ([]monitoring.Dashboard, error) {
+	return call(c.cassette, "monitoring", "ListDashboards", req, func() ([]monitoring.Dashboard, error) {
+		return c.client.ListDashboards(ctx, req)
+	})
+}
+
+// ApplyAlertPolicy implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ApplyAlertPolicy(ctx context.Context, req monitoring.ApplyRequest) (monitoring.ApplyResult, error) {
+	return call(c.cassette, "monitoring", "ApplyAlertPolicy", req, func() (monitoring.ApplyResult, error) {
+		return c.client.ApplyAlertPolicy(ctx, req)
+	})
+}
+
+// ApplyDashboard implements monitoring.MonitoringClientInterface
+func (c *MonitoringClient) ApplyDashboard(ctx context.Context, req monitoring.ApplyRequest) (monitoring.ApplyResult, error) {
+	return call(c.cassette, "monitoring", "ApplyDashboard", req, func() (monitoring.ApplyResult, error) {
+		return c.client.ApplyDashboard(ctx, req)
+	})
+}
+
+// TraceClient implements trace.TraceClientInterface by recording the calls
+// to the wrapped client, or replaying them when it is nil
+type TraceClient struct {
+	cassette *Cassette
+	client   trace.TraceClientInterface
+}
+
+// NewTraceClient creates a TraceClient. client is not used when replaying.
+func NewTraceClient(cassette *Cassette, client trace.TraceClientInterface) *TraceClient {
+	return &TraceClient{cassette: cassette, client: client}
+}
+
+// ListTraces implements trace.TraceClientInterface
+func (c *TraceClient) ListTraces(ctx context.Context, req trace.ListTracesRequest) (trace.ListTracesResponse, error) {
+	return call(c.cassette, "trace", "ListTraces", req, func() (trace.ListTracesResponse, error) {
+		return c.client.ListTraces(ctx, req)
+	})
+}
+
+// GetTrace implements trace.TraceClientInterface
+func (c *TraceClient) GetTrace(ctx context.Context, req trace.GetTraceRequest) (*trace.Trace, error) {
+	return call(c.cassette, "trace", "GetTrace", req, func() (*trace.Trace, error) {
+		return c.client.GetTrace(ctx, req)
+	})
+}
+
+// PatchTraces implements trace.TraceClientInterface
+func (c *TraceClient) PatchTraces(ctx context.Context, req trace.PatchTraceRequest) error {
+	return callErr(c.cassette, "trace", "PatchTraces", req, func() error {
+		return c.client.PatchTraces(ctx, req)
+	})
+}
+
+// ProfilerClient implements profiler.ProfilerClientInterface by recording
+// the calls to the wrapped client, or replaying them when it is nil
+type ProfilerClient struct {
+	cassette *Cassette
+	client   profiler.ProfilerClientInterface
+}
+
+// NewProfilerClient creates a ProfilerClient. client is not used when replaying.
+func NewProfilerClient(cassette *Cassette, client profiler.ProfilerClientInterface) *ProfilerClient {
+	return &ProfilerClient{cassette: cassette, client: client}
+}
+
+// CreateProfile implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) CreateProfile(ctx context.Context, req profiler.CreateProfileRequest) (*profiler.Profile, error) {
+	return call(c.cassette, "profiler", "CreateProfile", req, func() (*profiler.Profile, error) {
+		return c.client.CreateProfile(ctx, req)
+	})
+}
+
+// CreateOfflineProfile implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) CreateOfflineProfile(ctx context.Context, req profiler.CreateOfflineProfileRequest) (*profiler.Profile, error) {
+	return call(c.cassette, "profiler", "CreateOfflineProfile", req, func() (*profiler.Profile, error) {
+		return c.client.CreateOfflineProfile(ctx, req)
+	})
+}
+
+// UpdateProfile implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) UpdateProfile(ctx context.Context, req profiler.UpdateProfileRequest) (*profiler.Profile, error) {
+	return call(c.cassette, "profiler", "UpdateProfile", req, func() (*profiler.Profile, error) {
+		return c.client.UpdateProfile(ctx, req)
+	})
+}
+
+// ListProfiles implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) ListProfiles(ctx context.Context, req profiler.ListProfilesRequest) (profiler.ListProfilesResponse, error) {
+	return call(c.cassette, "profiler", "ListProfiles", req, func() (profiler.ListProfilesResponse, error) {
+		return c.client.ListProfiles(ctx, req)
+	})
+}
+
+// QueryProfiles implements profiler.ProfilerClientInterface
+func (c *ProfilerClient) QueryProfiles(ctx context.Context, req profiler.QueryProfilesRequest) ([]*profiler.Profile, error) {
+	return call(c.cassette, "profiler", "QueryProfiles", req, func() ([]*profiler.Profile, error) {
+		return c.client.QueryProfiles(ctx, req)
+	})
+}
+
+// DLPClient implements redact.DLPClientInterface by recording the calls to
+// the wrapped client, or replaying them when it is nil
+type DLPClient struct {
+	cassette *Cassette
+	client   redact.DLPClientInterface
+}
+
+// NewDLPClient creates a DLPClient. client is not used when replaying.
+func NewDLPClient(cassette *Cassette, client redact.DLPClientInterface) *DLPClient {
+	return &DLPClient{cassette: cassette, client: client}
+}
+
+// Deidentify implements redact.DLPClientInterface
+func (c *DLPClient) Deidentify(ctx context.Context, projectID string, values []string, infoTypes []string) (redact.DeidentifyResult, error) {
+	req := struct {
+		ProjectID string   `json:"project_id"`
+		Values    []string `json:"values"`
+		InfoTypes []string `json:"info_types"`
+	}{projectID, values, infoTypes}
+	return call(c.cassette, "dlp", "Deidentify", req, func() (redact.DeidentifyResult, error) {
+		return c.client.Deidentify(ctx, projectID, values, infoTypes)
+	})
+}
+
+// AssetClient implements asset.AssetClientInterface by recording the calls
+// to the wrapped client, or replaying them when it is nil
+type AssetClient struct {
+	cassette *Cassette
+	client   asset.AssetClientInterface
+}
+
+// NewAssetClient creates an AssetClient. client is not used when replaying.
+func NewAssetClient(cassette *Cassette, client asset.AssetClientInterface) *AssetClient {
+	return &AssetClient{cassette: cassette, client: client}
+}
+
+// ListAssets implements asset.AssetClientInterface
+func (c *AssetClient) ListAssets(ctx context.Context, req asset.ListAssetsRequest) (asset.ListAssetsResponse, error) {
+	return call(c.cassette, "asset", "ListAssets", req, func() (asset.ListAssetsResponse, error) {
+		return c.client.ListAssets(ctx, req)
+	})
+}
@@ -0,0 +1,191 @@
+// Package replay records the calls made to the Cloud Logging, Cloud
+// Monitoring, Cloud Trace, Cloud Profiler, Cloud DLP, and Cloud Asset
+// clients to a cassette file, and serves the recorded responses instead of
+// calling Google Cloud, for deterministic integration tests of the MCP tools
+// and reproducible bug reports.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Interaction represents a recorded call and its response or error
+type Interaction struct {
+	Service  string          `json:"service"` // e.g. "logging"
+	Method   string          `json:"method"`  // e.g. "ListEntries"
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	Code     codes.Code      `json:"code,omitempty"` // gRPC code of the error
+}
+
+// Cassette records interactions to a file, one JSON object per line, or
+// replays the interactions loaded from one
+type Cassette struct {
+	mu           sync.Mutex
+	file         *os.File // set when recording
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder creates a Cassette recording to path, replacing the file if it exists
+func NewRecorder(path string) (*Cassette, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cassette: %w", err)
+	}
+	return &Cassette{file: file}, nil
+}
+
+// Load reads a recorded cassette to replay it
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	c := &Cassette{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var i Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &i); err != nil {
+			return nil, fmt.Errorf("invalid interaction on line %d of %s: %w", line, path, err)
+		}
+		var request bytes.Buffer
+		if err := json.Compact(&request, i.Request); err != nil {
+			return nil, fmt.Errorf("invalid request on line %d of %s: %w", line, path, err)
+		}
+		i.Request = request.Bytes()
+		c.interactions = append(c.interactions, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+// Recording reports whether the cassette records interactions rather than replaying them
+func (c *Cassette) Recording() bool {
+	return c.file != nil
+}
+
+// Close closes the file being recorded to
+func (c *Cassette) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Close()
+}
+
+// record appends an interaction to the cassette file
+func (c *Cassette) record(i Interaction) error {
+	data, err := json.Marshal(i)
+	if err != nil {
+		return fmt.Errorf("failed to record %s.%s: %w", i.Service, i.Method, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to record %s.%s: %w", i.Service, i.Method, err)
+	}
+	return nil
+}
+
+// next returns the first unused interaction of a method with the same
+// request, or else the first unused one of the method, so that requests
+// depending on the current time, e.g. the last hour, are replayed in the
+// order they were recorded
+func (c *Cassette) next(service, method string, request []byte) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	match := -1
+	for i, interaction := range c.interactions {
+		if c.used[i] || interaction.Service != service || interaction.Method != method {
+			continue
+		}
+		if bytes.Equal(interaction.Request, request) {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return Interaction{}, status.Errorf(codes.NotFound, "no recorded %s.%s call left to replay", service, method)
+	}
+	c.used[match] = true
+	return c.interactions[match], nil
+}
+
+// replayedError is a recorded error, keeping its message and gRPC code
+type replayedError struct {
+	code    codes.Code
+	message string
+}
+
+func (e *replayedError) Error() string {
+	return e.message
+}
+
+// GRPCStatus lets status.Code return the recorded code
+func (e *replayedError) GRPCStatus() *status.Status {
+	return status.New(e.code, e.message)
+}
+
+// call records the call made by do when recording, or replays the recorded
+// response to the same request otherwise
+func call[T any](c *Cassette, service, method string, req any, do func() (T, error)) (T, error) {
+	var zero T
+	request, err := json.Marshal(req)
+	if err != nil {
+		return zero, fmt.Errorf("failed to encode %s.%s request: %w", service, method, err)
+	}
+
+	if c.Recording() {
+		resp, err := do()
+		interaction := Interaction{Service: service, Method: method, Request: request}
+		if err != nil {
+			interaction.Error = err.Error()
+			interaction.Code = status.Code(err)
+		} else if interaction.Response, err = json.Marshal(resp); err != nil {
+			return resp, fmt.Errorf("failed to encode %s.%s response: %w", service, method, err)
+		}
+		if recordErr := c.record(interaction); recordErr != nil {
+			return resp, recordErr
+		}
+		return resp, err
+	}
+
+	interaction, err := c.next(service, method, request)
+	if err != nil {
+		return zero, err
+	}
+	if interaction.Error != "" {
+		return zero, &replayedError{code: interaction.Code, message: interaction.Error}
+	}
+	var resp T
+	if len(interaction.Response) > 0 {
+		if err := json.Unmarshal(interaction.Response, &resp); err != nil {
+			return zero, fmt.Errorf("invalid recorded %s.%s response: %w", service, method, err)
+		}
+	}
+	return resp, nil
+}
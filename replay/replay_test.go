@@ -0,0 +1,104 @@
+package replay_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/replay"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	ctx := context.Background()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	backend := fake.New(fake.DefaultProjectID)
+	if err := backend.Load(fake.Seed{
+		LogEntries: []fake.SeedLogEntry{
+			{LogName: "app", LogEntry: logging.LogEntry{Severity: "ERROR", Message: "failed", Timestamp: since.Add(time.Hour)}},
+		},
+		ServiceLevelObjectives: []monitoring.ServiceLevelObjective{
+			{Name: "projects/fake-project/services/api/serviceLevelObjectives/availability", Goal: 0.999, Period: 720 * time.Hour, GoodFilter: "a", TotalFilter: "b"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder, err := replay.NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	loggingClient := logging.NewWithClient(replay.NewLoggingClient(recorder, backend.Logging))
+	monitoringClient := monitoring.NewWithClient(replay.NewMonitoringClient(recorder, backend.Monitoring), fake.DefaultProjectID)
+	traceClient := trace.NewWithClient(replay.NewTraceClient(recorder, backend.Trace), fake.DefaultProjectID)
+
+	wantLogs, err := loggingClient.ListEntries(ctx, logging.ListEntriesRequest{Filter: `severity>=ERROR AND timestamp>="2024-01-01T00:00:00Z"`})
+	if err != nil {
+		t.Fatalf("ListEntries() error = %v", err)
+	}
+	wantSLO, err := monitoringClient.GetServiceLevelObjective(ctx, "projects/fake-project/services/api/serviceLevelObjectives/availability")
+	if err != nil {
+		t.Fatalf("GetServiceLevelObjective() error = %v", err)
+	}
+	_, wantErr := traceClient.GetTrace(ctx, trace.GetTraceRequest{TraceID: "missing"})
+	if wantErr == nil {
+		t.Fatal("Expected GetTrace() of a missing trace to fail")
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cassette, err := replay.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	loggingClient = logging.NewWithClient(replay.NewLoggingClient(cassette, nil))
+	monitoringClient = monitoring.NewWithClient(replay.NewMonitoringClient(cassette, nil), fake.DefaultProjectID)
+	traceClient = trace.NewWithClient(replay.NewTraceClient(cassette, nil), fake.DefaultProjectID)
+
+	// A request differing from the recorded one, e.g. by its time range, is
+	// replayed in the order the calls were recorded
+	gotLogs, err := loggingClient.ListEntries(ctx, logging.ListEntriesRequest{Filter: `severity>=ERROR AND timestamp>="2024-01-01T00:01:00Z"`})
+	if err != nil {
+		t.Fatalf("ListEntries() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotLogs, wantLogs) {
+		t.Errorf("ListEntries() = %+v, want %+v", gotLogs, wantLogs)
+	}
+	gotSLO, err := monitoringClient.GetServiceLevelObjective(ctx, "projects/fake-project/services/api/serviceLevelObjectives/availability")
+	if err != nil {
+		t.Fatalf("GetServiceLevelObjective() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotSLO, wantSLO) {
+		t.Errorf("GetServiceLevelObjective() = %+v, want %+v", gotSLO, wantSLO)
+	}
+	_, gotErr := traceClient.GetTrace(ctx, trace.GetTraceRequest{TraceID: "missing"})
+	if gotErr == nil || gotErr.Error() != wantErr.Error() || status.Code(gotErr) != status.Code(wantErr) {
+		t.Errorf("GetTrace() error = %v (%s), want %v (%s)", gotErr, status.Code(gotErr), wantErr, status.Code(wantErr))
+	}
+
+	// Every recorded call is replayed once
+	if _, err := loggingClient.ListEntries(ctx, logging.ListEntriesRequest{}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound once the recorded calls are replayed, got %v", err)
+	}
+}
+
+func TestLoad_InvalidCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	if err := os.WriteFile(path, []byte("{\"service\": \"logging\", \"method\": \"ListEntries\", \"request\": {}}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replay.Load(path); err == nil {
+		t.Error("Load() error = nil, want an error for the invalid line")
+	}
+}
@@ -0,0 +1,304 @@
+package savedquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+// Kind represents the kind of data a saved query runs against
+type Kind string
+
+const (
+	KindLogs       Kind = "logs"
+	KindTimeSeries Kind = "time_series"
+)
+
+// Query represents a named, reusable investigation query
+type Query struct {
+	Name        string                        `json:"name"`
+	Kind        Kind                          `json:"kind"`
+	Description string                        `json:"description,omitempty"`
+	Filter      string                        `json:"filter"`
+	Aggregation *monitoring.AggregationConfig `json:"aggregation,omitempty"`
+	CreatedAt   time.Time                     `json:"created_at"`
+}
+
+// ErrNotFound is returned when no saved query has the requested name
+var ErrNotFound = errors.New("saved query not found")
+
+// Store defines the interface for persisting saved queries
+type Store interface {
+	Save(ctx context.Context, query Query) error
+	Get(ctx context.Context, name string) (Query, error)
+	List(ctx context.Context) ([]Query, error)
+}
+
+// Validate checks that the query has the fields required to run it
+func (q Query) Validate() error {
+	if q.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if q.Filter == "" {
+		return fmt.Errorf("filter is required")
+	}
+	switch q.Kind {
+	case KindLogs:
+		if q.Aggregation != nil {
+			return fmt.Errorf("aggregation is only supported for %s queries", KindTimeSeries)
+		}
+	case KindTimeSeries:
+	default:
+		return fmt.Errorf("unsupported kind %q: must be %q or %q", q.Kind, KindLogs, KindTimeSeries)
+	}
+	return nil
+}
+
+// NewStore creates a Store for the given location. Locations of the form
+// gs://BUCKET/OBJECT are stored in Cloud Storage so they can be shared by a
+// team; anything else is treated as a local file path.
+func NewStore(ctx context.Context, location string, opts ...option.ClientOption) (Store, error) {
+	if rest, ok := strings.CutPrefix(location, "gs://"); ok {
+		bucket, object, ok := strings.Cut(rest, "/")
+		if !ok || bucket == "" || object == "" {
+			return nil, fmt.Errorf("invalid Cloud Storage location %q: must be gs://BUCKET/OBJECT", location)
+		}
+		return NewGCSStore(ctx, bucket, object, opts...)
+	}
+	return NewFileStore(location), nil
+}
+
+// DefaultLocation returns the default local path for saved queries
+func DefaultLocation() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gcp-telemetry-mcp", "saved_queries.json"), nil
+}
+
+// FileStore implements Store using a local JSON file
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore creates a new FileStore
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save stores the query, replacing any existing query with the same name
+func (s *FileStore) Save(ctx context.Context, query Query) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queries, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	data, err := encode(upsert(queries, query))
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create saved query directory: %w", err)
+	}
+
+	// Write to a temporary file first so a failed write never corrupts the library
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write saved queries: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write saved queries: %w", err)
+	}
+	return nil
+}
+
+// Get returns the query with the given name
+func (s *FileStore) Get(ctx context.Context, name string) (Query, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queries, err := s.read()
+	if err != nil {
+		return Query{}, err
+	}
+	return find(queries, name)
+}
+
+// List returns all saved queries sorted by name
+func (s *FileStore) List(ctx context.Context) ([]Query, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read()
+}
+
+// read loads the queries from disk. A missing file is an empty library.
+func (s *FileStore) read() ([]Query, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved queries: %w", err)
+	}
+	return decode(data)
+}
+
+// GCSStore implements Store using a JSON object in Cloud Storage
+type GCSStore struct {
+	service *storage.Service
+	bucket  string
+	object  string
+}
+
+// NewGCSStore creates a new GCSStore
+func NewGCSStore(ctx context.Context, bucket, object string, opts ...option.ClientOption) (*GCSStore, error) {
+	service, err := storage.NewService(ctx, append([]option.ClientOption{option.WithScopes(storage.DevstorageReadWriteScope)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage service: %w", err)
+	}
+
+	return &GCSStore{
+		service: service,
+		bucket:  bucket,
+		object:  object,
+	}, nil
+}
+
+// Save stores the query, replacing any existing query with the same name
+func (s *GCSStore) Save(ctx context.Context, query Query) error {
+	queries, generation, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := encode(upsert(queries, query))
+	if err != nil {
+		return err
+	}
+
+	// The generation precondition makes concurrent saves from teammates fail
+	// instead of silently overwriting each other
+	_, err = s.service.Objects.Insert(s.bucket, &storage.Object{
+		Name:        s.object,
+		ContentType: "application/json",
+	}).Media(bytes.NewReader(data)).IfGenerationMatch(generation).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+			return fmt.Errorf("saved queries were modified concurrently, please retry: %w", err)
+		}
+		return fmt.Errorf("failed to write saved queries: %w", err)
+	}
+	return nil
+}
+
+// Get returns the query with the given name
+func (s *GCSStore) Get(ctx context.Context, name string) (Query, error) {
+	queries, _, err := s.read(ctx)
+	if err != nil {
+		return Query{}, err
+	}
+	return find(queries, name)
+}
+
+// List returns all saved queries sorted by name
+func (s *GCSStore) List(ctx context.Context) ([]Query, error) {
+	queries, _, err := s.read(ctx)
+	return queries, err
+}
+
+// read loads the queries and the object generation. A missing object is an
+// empty library with generation 0.
+func (s *GCSStore) read(ctx context.Context) ([]Query, int64, error) {
+	obj, err := s.service.Objects.Get(s.bucket, s.object).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read saved queries: %w", err)
+	}
+
+	resp, err := s.service.Objects.Get(s.bucket, s.object).Generation(obj.Generation).Context(ctx).Download()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read saved queries: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read saved queries: %w", err)
+	}
+
+	queries, err := decode(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return queries, obj.Generation, nil
+}
+
+// upsert replaces the query with the same name or appends it, keeping the result sorted by name
+func upsert(queries []Query, query Query) []Query {
+	result := make([]Query, 0, len(queries)+1)
+	for _, q := range queries {
+		if q.Name != query.Name {
+			result = append(result, q)
+		}
+	}
+	result = append(result, query)
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// find returns the query with the given name
+func find(queries []Query, name string) (Query, error) {
+	for _, q := range queries {
+		if q.Name == name {
+			return q, nil
+		}
+	}
+	return Query{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// encode serializes queries to JSON
+func encode(queries []Query) ([]byte, error) {
+	data, err := json.MarshalIndent(queries, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal saved queries: %w", err)
+	}
+	return data, nil
+}
+
+// decode deserializes queries from JSON
+func decode(data []byte) ([]Query, error) {
+	var queries []Query
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("failed to parse saved queries: %w", err)
+	}
+	return queries, nil
+}
@@ -0,0 +1,146 @@
+package savedquery_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/savedquery"
+)
+
+func TestFileStore_SaveGetList(t *testing.T) {
+	ctx := context.Background()
+	store := savedquery.NewFileStore(filepath.Join(t.TempDir(), "nested", "queries.json"))
+
+	// An empty library has no queries
+	queries, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(queries) != 0 {
+		t.Errorf("Expected 0 queries, got %d", len(queries))
+	}
+
+	logsQuery := savedquery.Query{
+		Name:      "errors",
+		Kind:      savedquery.KindLogs,
+		Filter:    "severity>=ERROR",
+		CreatedAt: time.Now(),
+	}
+	cpuQuery := savedquery.Query{
+		Name:   "cpu",
+		Kind:   savedquery.KindTimeSeries,
+		Filter: `metric.type="compute.googleapis.com/instance/cpu/utilization"`,
+		Aggregation: &monitoring.AggregationConfig{
+			AlignmentPeriod:  "60s",
+			PerSeriesAligner: "ALIGN_MEAN",
+		},
+		CreatedAt: time.Now(),
+	}
+
+	for _, q := range []savedquery.Query{logsQuery, cpuQuery} {
+		if err := store.Save(ctx, q); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	queries, err = store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("Expected 2 queries, got %d", len(queries))
+	}
+	if queries[0].Name != "cpu" || queries[1].Name != "errors" {
+		t.Errorf("Expected queries sorted by name, got %s, %s", queries[0].Name, queries[1].Name)
+	}
+
+	got, err := store.Get(ctx, "cpu")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Aggregation == nil || got.Aggregation.PerSeriesAligner != "ALIGN_MEAN" {
+		t.Errorf("Expected aggregation to be persisted, got %+v", got.Aggregation)
+	}
+}
+
+func TestFileStore_SaveOverwrites(t *testing.T) {
+	ctx := context.Background()
+	store := savedquery.NewFileStore(filepath.Join(t.TempDir(), "queries.json"))
+
+	query := savedquery.Query{Name: "errors", Kind: savedquery.KindLogs, Filter: "severity>=ERROR"}
+	if err := store.Save(ctx, query); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	query.Filter = "severity>=CRITICAL"
+	if err := store.Save(ctx, query); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	queries, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("Expected 1 query, got %d", len(queries))
+	}
+	if queries[0].Filter != "severity>=CRITICAL" {
+		t.Errorf("Expected filter to be overwritten, got %s", queries[0].Filter)
+	}
+}
+
+func TestFileStore_GetNotFound(t *testing.T) {
+	store := savedquery.NewFileStore(filepath.Join(t.TempDir(), "queries.json"))
+
+	_, err := store.Get(context.Background(), "missing")
+	if !errors.Is(err, savedquery.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestQuery_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   savedquery.Query
+		wantErr bool
+	}{
+		{
+			name:    "valid logs query",
+			query:   savedquery.Query{Name: "errors", Kind: savedquery.KindLogs, Filter: "severity>=ERROR"},
+			wantErr: false,
+		},
+		{
+			name:    "missing name",
+			query:   savedquery.Query{Kind: savedquery.KindLogs, Filter: "severity>=ERROR"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown kind",
+			query:   savedquery.Query{Name: "errors", Kind: "traces", Filter: "severity>=ERROR"},
+			wantErr: true,
+		},
+		{
+			name: "aggregation on logs query",
+			query: savedquery.Query{
+				Name:        "errors",
+				Kind:        savedquery.KindLogs,
+				Filter:      "severity>=ERROR",
+				Aggregation: &monitoring.AggregationConfig{AlignmentPeriod: "60s"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Package schedule runs recurring jobs in the background, e.g. snapshotting
+// the p99 latency of a service every hour into a custom metric. Unlike
+// watches, jobs outlive the MCP session that scheduled them, and run until
+// they are canceled or the scheduler is stopped.
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultInterval is the interval used when none is given
+	DefaultInterval = time.Hour
+	// MinInterval is the shortest interval, to stay within API quotas
+	MinInterval = time.Minute
+	// MaxJobs bounds the number of jobs scheduled at the same time
+	MaxJobs = 20
+)
+
+// Info describes a scheduled job
+type Info struct {
+	ID          string         `json:"id"`
+	Description string         `json:"description,omitempty"`
+	Tool        string         `json:"tool"`
+	Arguments   map[string]any `json:"arguments,omitempty"`
+	Interval    time.Duration  `json:"-"`
+	CreatedAt   time.Time      `json:"created_at"`
+	LastRun     time.Time      `json:"last_run,omitzero"`
+	NextRun     time.Time      `json:"next_run"`
+	// LastResult summarizes the outcome of the last successful run
+	LastResult string `json:"last_result,omitempty"`
+	LastError  string `json:"last_error,omitempty"`
+	Runs       int    `json:"runs"`
+	Failures   int    `json:"failures"`
+}
+
+// MarshalJSON renders the interval as a duration string
+func (i Info) MarshalJSON() ([]byte, error) {
+	type alias Info
+	return json.Marshal(struct {
+		alias
+		Interval string `json:"interval"`
+	}{alias(i), i.Interval.String()})
+}
+
+// Task runs a job once, and returns a summary of its outcome
+type Task func(ctx context.Context, jobID string) (string, error)
+
+// job is a scheduled job
+type job struct {
+	info   Info
+	task   Task
+	cancel context.CancelFunc
+}
+
+// Scheduler runs the scheduled jobs
+type Scheduler struct {
+	ctx  context.Context
+	stop context.CancelFunc
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	nextID int
+}
+
+// NewScheduler creates a Scheduler without jobs
+func NewScheduler() *Scheduler {
+	ctx, stop := context.WithCancel(context.Background())
+	return &Scheduler{ctx: ctx, stop: stop, jobs: make(map[string]*job)}
+}
+
+// Schedule runs task once right away, and when it succeeds, schedules it
+// to run every info.Interval from then on. A job whose first run fails is
+// not scheduled, so that mistakes are reported to the caller. The ID,
+// creation time, and interval of info are set by the scheduler.
+func (s *Scheduler) Schedule(info Info, task Task) (Info, error) {
+	interval, err := ValidInterval(info.Interval)
+	if err != nil {
+		return Info{}, err
+	}
+
+	s.mu.Lock()
+	if err := s.checkCapacity(); err != nil {
+		s.mu.Unlock()
+		return Info{}, err
+	}
+	s.nextID++
+	info.ID = fmt.Sprintf("job-%d", s.nextID)
+	s.mu.Unlock()
+
+	info.Interval = interval
+	info.CreatedAt = time.Now()
+	ctx, cancel := context.WithCancel(s.ctx)
+	j := &job{info: info, task: task, cancel: cancel}
+	summary, err := task(ctx, info.ID)
+	if err != nil {
+		cancel()
+		return Info{}, fmt.Errorf("the first run failed: %w", err)
+	}
+	j.record(info.CreatedAt, summary, nil)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkCapacity(); err != nil {
+		cancel()
+		return Info{}, err
+	}
+	s.jobs[info.ID] = j
+
+	go s.run(ctx, j)
+	return j.info, nil
+}
+
+// checkCapacity returns an error when no more jobs can be scheduled. s.mu
+// must be held.
+func (s *Scheduler) checkCapacity() error {
+	if s.ctx.Err() != nil {
+		return fmt.Errorf("the scheduler is stopped")
+	}
+	if len(s.jobs) >= MaxJobs {
+		return fmt.Errorf("at most %d jobs can be scheduled; cancel one with cancel_job first", MaxJobs)
+	}
+	return nil
+}
+
+// run runs a job every interval until it is canceled
+func (s *Scheduler) run(ctx context.Context, j *job) {
+	ticker := time.NewTicker(j.info.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		summary, err := j.task(ctx, j.info.ID)
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.Lock()
+		j.record(now, summary, err)
+		s.mu.Unlock()
+	}
+}
+
+// record records the outcome of a run started at now
+func (j *job) record(now time.Time, summary string, err error) {
+	j.info.LastRun = now
+	j.info.NextRun = now.Add(j.info.Interval)
+	j.info.Runs++
+	j.info.LastError = ""
+	if err != nil {
+		j.info.Failures++
+		j.info.LastError = err.Error()
+		return
+	}
+	j.info.LastResult = summary
+}
+
+// List returns the scheduled jobs, oldest first
+func (s *Scheduler) List() []Info {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := []Info{}
+	for _, j := range s.jobs {
+		infos = append(infos, j.info)
+	}
+	slices.SortFunc(infos, func(a, b Info) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return infos
+}
+
+// Cancel stops running a job
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("job %q not found", id)
+	}
+	j.cancel()
+	delete(s.jobs, id)
+	return nil
+}
+
+// Stop cancels all the jobs. Jobs cannot be scheduled afterwards.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stop()
+	clear(s.jobs)
+}
+
+// ValidInterval returns the interval to run at, applying the default and minimum
+func ValidInterval(interval time.Duration) (time.Duration, error) {
+	if interval == 0 {
+		return DefaultInterval, nil
+	}
+	if interval < MinInterval {
+		return 0, fmt.Errorf("interval must be at least %s", MinInterval)
+	}
+	return interval, nil
+}
@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler()
+	defer s.Stop()
+
+	var jobIDs []string
+	info, err := s.Schedule(Info{Tool: "list_time_series", Interval: time.Hour}, func(ctx context.Context, jobID string) (string, error) {
+		jobIDs = append(jobIDs, jobID)
+		return "wrote 0.25", nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The first run happens before Schedule returns
+	if len(jobIDs) != 1 || jobIDs[0] != info.ID {
+		t.Errorf("Expected the first run of %s, got %v", info.ID, jobIDs)
+	}
+	if info.Runs != 1 || info.LastResult != "wrote 0.25" || !info.NextRun.Equal(info.LastRun.Add(time.Hour)) {
+		t.Errorf("Unexpected job %+v", info)
+	}
+
+	if infos := s.List(); len(infos) != 1 || infos[0].ID != info.ID {
+		t.Errorf("Expected the job to be listed, got %+v", infos)
+	}
+	if err := s.Cancel("job-999"); err == nil {
+		t.Error("Expected error canceling an unknown job")
+	}
+	if err := s.Cancel(info.ID); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if infos := s.List(); len(infos) != 0 {
+		t.Errorf("Expected no jobs after canceling, got %+v", infos)
+	}
+}
+
+func TestScheduler_FirstRunFails(t *testing.T) {
+	s := NewScheduler()
+	defer s.Stop()
+
+	_, err := s.Schedule(Info{Tool: "list_time_series"}, func(ctx context.Context, jobID string) (string, error) {
+		return "", errors.New("unknown tool")
+	})
+	if err == nil {
+		t.Fatal("Expected error when the first run fails")
+	}
+	if infos := s.List(); len(infos) != 0 {
+		t.Errorf("Expected the job not to be scheduled, got %+v", infos)
+	}
+}
+
+func TestScheduler_Limits(t *testing.T) {
+	s := NewScheduler()
+	task := func(ctx context.Context, jobID string) (string, error) { return "", nil }
+
+	if _, err := s.Schedule(Info{Interval: time.Second}, task); err == nil {
+		t.Error("Expected error for an interval under the minimum")
+	}
+	for range MaxJobs {
+		if _, err := s.Schedule(Info{}, task); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := s.Schedule(Info{}, task); err == nil {
+		t.Error("Expected error when exceeding the maximum number of jobs")
+	}
+
+	s.Stop()
+	if infos := s.List(); len(infos) != 0 {
+		t.Errorf("Expected no jobs after stopping, got %+v", infos)
+	}
+	if _, err := s.Schedule(Info{}, task); err == nil {
+		t.Error("Expected error scheduling on a stopped scheduler")
+	}
+}
@@ -0,0 +1,283 @@
+package selftelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// MetricPrefix starts the types of the metrics exported to Cloud Monitoring
+const MetricPrefix = "custom.googleapis.com/gcp_telemetry_mcp/"
+
+// ExportInterval is how often metrics are exported by the providers of NewProviders
+const ExportInterval = time.Minute
+
+// NewProviders creates the providers exporting spans to Cloud Trace with
+// traceClient, and metrics to Cloud Monitoring with monitoringClient. The
+// clients must not be instrumented with the providers, since the calls
+// exporting spans would be traced in turn. The providers must be shut down
+// to export the spans and metrics recorded last.
+func NewProviders(traceClient trace.TraceClient, monitoringClient monitoring.MonitoringClient) (*sdktrace.TracerProvider, *sdkmetric.MeterProvider) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(NewSpanExporter(traceClient)))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(
+		sdkmetric.NewPeriodicReader(NewMetricExporter(monitoringClient), sdkmetric.WithInterval(ExportInterval)),
+	))
+	return tp, mp
+}
+
+// SpanExporter exports spans to Cloud Trace
+type SpanExporter struct {
+	client trace.TraceClient
+}
+
+// NewSpanExporter creates a SpanExporter writing spans with client
+func NewSpanExporter(client trace.TraceClient) *SpanExporter {
+	return &SpanExporter{client: client}
+}
+
+// ExportSpans implements sdktrace.SpanExporter. The spans are written with
+// one call per trace.
+func (e *SpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	var traceIDs []string
+	byTrace := make(map[string][]trace.Span)
+	for _, s := range spans {
+		traceID := s.SpanContext().TraceID().String()
+		if _, ok := byTrace[traceID]; !ok {
+			traceIDs = append(traceIDs, traceID)
+		}
+		byTrace[traceID] = append(byTrace[traceID], convertSpan(s))
+	}
+
+	var errs []error
+	for _, traceID := range traceIDs {
+		// The parents of the tool calls are in the clients, and the parents
+		// of long tool calls may be exported later
+		err := e.client.PatchTraces(ctx, trace.PatchTraceRequest{
+			TraceID:             traceID,
+			Spans:               byTrace[traceID],
+			AllowMissingParents: true,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to export trace %s: %w", traceID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown implements sdktrace.SpanExporter
+func (e *SpanExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// convertSpan converts an OpenTelemetry span to a Cloud Trace span. The
+// attributes and status of the span become its labels.
+func convertSpan(s sdktrace.ReadOnlySpan) trace.Span {
+	labels := make(map[string]string)
+	for _, attr := range s.Attributes() {
+		labels[string(attr.Key)] = attr.Value.Emit()
+	}
+	if s.Status().Code == codes.Error {
+		labels["otel.status_code"] = "ERROR"
+		if s.Status().Description != "" {
+			labels["otel.status_description"] = s.Status().Description
+		}
+	}
+	if s.InstrumentationScope().Name != "" {
+		labels["otel.scope.name"] = s.InstrumentationScope().Name
+	}
+
+	converted := trace.Span{
+		SpanID:    s.SpanContext().SpanID().String(),
+		Name:      s.Name(),
+		StartTime: s.StartTime(),
+		EndTime:   s.EndTime(),
+		Labels:    labels,
+	}
+	if s.Parent().SpanID().IsValid() {
+		converted.ParentID = s.Parent().SpanID().String()
+	}
+	switch s.SpanKind() {
+	case oteltrace.SpanKindServer:
+		converted.Kind = "RPC_SERVER"
+	case oteltrace.SpanKindClient:
+		converted.Kind = "RPC_CLIENT"
+	}
+	return converted
+}
+
+// MetricExporter exports metrics to Cloud Monitoring as custom metrics,
+// written on a generic_task resource identifying the server process. Sums
+// and histograms are written as CUMULATIVE metrics, histograms as
+// distributions. Exponential histograms and summaries are left out.
+type MetricExporter struct {
+	client         monitoring.MonitoringClient
+	resourceLabels map[string]string
+}
+
+// NewMetricExporter creates a MetricExporter writing metrics with client
+func NewMetricExporter(client monitoring.MonitoringClient) *MetricExporter {
+	hostname, _ := os.Hostname()
+	return &MetricExporter{
+		client: client,
+		resourceLabels: map[string]string{
+			"location":  "global",
+			"namespace": "gcp-telemetry-mcp",
+			"job":       "gcp-telemetry-mcp",
+			"task_id":   fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		},
+	}
+}
+
+// Temporality implements sdkmetric.Exporter
+func (e *MetricExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return metricdata.CumulativeTemporality
+}
+
+// Aggregation implements sdkmetric.Exporter
+func (e *MetricExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+// Export implements sdkmetric.Exporter
+func (e *MetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	var series []monitoring.TimeSeriesData
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			series = append(series, e.convertMetric(m)...)
+		}
+	}
+	if len(series) == 0 {
+		return nil
+	}
+	return e.client.WriteTimeSeries(ctx, monitoring.WriteTimeSeriesRequest{TimeSeries: series})
+}
+
+// ForceFlush implements sdkmetric.Exporter
+func (e *MetricExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// Shutdown implements sdkmetric.Exporter
+func (e *MetricExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// convertMetric converts the data points of a metric to time series
+func (e *MetricExporter) convertMetric(m metricdata.Metrics) []monitoring.TimeSeriesData {
+	metricType := MetricPrefix + m.Name
+	switch data := m.Data.(type) {
+	case metricdata.Gauge[int64]:
+		return convertPoints(e, metricType, "GAUGE", data.DataPoints)
+	case metricdata.Gauge[float64]:
+		return convertPoints(e, metricType, "GAUGE", data.DataPoints)
+	case metricdata.Sum[int64]:
+		return convertPoints(e, metricType, sumKind(data.IsMonotonic, data.Temporality), data.DataPoints)
+	case metricdata.Sum[float64]:
+		return convertPoints(e, metricType, sumKind(data.IsMonotonic, data.Temporality), data.DataPoints)
+	case metricdata.Histogram[int64]:
+		return convertHistogram(e, metricType, data)
+	case metricdata.Histogram[float64]:
+		return convertHistogram(e, metricType, data)
+	}
+	return nil
+}
+
+// sumKind returns the metric kind of a sum. Sums that can decrease are
+// written as gauges, like in Cloud Monitoring.
+func sumKind(monotonic bool, temporality metricdata.Temporality) string {
+	if !monotonic {
+		return "GAUGE"
+	}
+	if temporality == metricdata.DeltaTemporality {
+		return "DELTA"
+	}
+	return "CUMULATIVE"
+}
+
+// convertPoints converts the data points of gauges and sums
+func convertPoints[N int64 | float64](e *MetricExporter, metricType, kind string, points []metricdata.DataPoint[N]) []monitoring.TimeSeriesData {
+	var series []monitoring.TimeSeriesData
+	for _, point := range points {
+		value := monitoring.MetricValue{Value: float64(point.Value), Timestamp: point.Time}
+		if kind != "GAUGE" {
+			value.StartTime = point.StartTime
+		}
+		series = append(series, e.timeSeries(metricType, kind, point.Attributes, value))
+	}
+	return series
+}
+
+// convertHistogram converts the data points of a histogram to
+// distributions with explicit buckets
+func convertHistogram[N int64 | float64](e *MetricExporter, metricType string, data metricdata.Histogram[N]) []monitoring.TimeSeriesData {
+	kind := "CUMULATIVE"
+	if data.Temporality == metricdata.DeltaTemporality {
+		kind = "DELTA"
+	}
+	var series []monitoring.TimeSeriesData
+	for _, point := range data.DataPoints {
+		if len(point.Bounds) == 0 {
+			continue
+		}
+		distribution := &monitoring.Distribution{
+			Count:         int64(point.Count),
+			BucketOptions: monitoring.BucketOptions{Bounds: point.Bounds},
+			BucketCounts:  make([]int64, len(point.BucketCounts)),
+		}
+		if point.Count > 0 {
+			distribution.Mean = float64(point.Sum) / float64(point.Count)
+		}
+		for i, count := range point.BucketCounts {
+			distribution.BucketCounts[i] = int64(count)
+		}
+		value := monitoring.MetricValue{
+			Timestamp:    point.Time,
+			StartTime:    point.StartTime,
+			Distribution: distribution,
+		}
+		series = append(series, e.timeSeries(metricType, kind, point.Attributes, value))
+	}
+	return series
+}
+
+// timeSeries returns the time series of one data point
+func (e *MetricExporter) timeSeries(metricType, kind string, attrs attribute.Set, value monitoring.MetricValue) monitoring.TimeSeriesData {
+	labels := make(map[string]string, attrs.Len())
+	for _, attr := range attrs.ToSlice() {
+		labels[labelKey(string(attr.Key))] = attr.Value.Emit()
+	}
+	return monitoring.TimeSeriesData{
+		MetricType:     metricType,
+		MetricKind:     kind,
+		MetricLabels:   labels,
+		ResourceType:   "generic_task",
+		ResourceLabels: e.resourceLabels,
+		Values:         []monitoring.MetricValue{value},
+	}
+}
+
+// invalidLabelChars are the characters not allowed in label keys
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9_]`)
+
+// labelKey converts an attribute key, e.g. rpc.method, to a label key
+// accepted by Cloud Monitoring, e.g. rpc_method
+func labelKey(key string) string {
+	key = invalidLabelChars.ReplaceAllString(strings.ToLower(key), "_")
+	if key == "" || key[0] < 'a' || key[0] > 'z' {
+		key = "key_" + key
+	}
+	return key
+}
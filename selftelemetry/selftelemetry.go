@@ -0,0 +1,137 @@
+// Package selftelemetry traces and measures the MCP server itself: the tool
+// calls and the Google Cloud calls they make. Exported to the project the
+// server serves, the spans and metrics of slow tool calls can be looked at
+// with the tools of the server, e.g. list_traces.
+package selftelemetry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// ScopeName is the instrumentation scope of the spans and metrics of the tool calls
+const ScopeName = "github.com/kitagry/gcp-telemetry-mcp/selftelemetry"
+
+// Attributes of the spans and metrics of the tool calls, following the
+// OpenTelemetry semantic conventions for MCP
+const (
+	AttrMethodName = attribute.Key("mcp.method.name")
+	AttrToolName   = attribute.Key("gen_ai.tool.name")
+	AttrSessionID  = attribute.Key("mcp.session.id")
+	AttrErrorType  = attribute.Key("error.type")
+)
+
+// errorTypeTool is the error type of the calls returning an error result
+const errorTypeTool = "tool_error"
+
+// Instrumentation traces and measures the tool calls, and the Google Cloud
+// calls of the clients it is applied to
+type Instrumentation struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	tracer         trace.Tracer
+	duration       metric.Float64Histogram
+}
+
+// New creates an Instrumentation recording spans with tp and metrics with mp
+func New(tp trace.TracerProvider, mp metric.MeterProvider) (*Instrumentation, error) {
+	duration, err := mp.Meter(ScopeName).Float64Histogram("mcp.server.operation.duration",
+		metric.WithDescription("Duration of the tool calls"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Instrumentation{
+		tracerProvider: tp,
+		meterProvider:  mp,
+		tracer:         tp.Tracer(ScopeName),
+		duration:       duration,
+	}, nil
+}
+
+// Middleware returns the middleware recording a span and the duration of
+// each tool call. The Google Cloud calls made by the tool are recorded as
+// children of its span. Calls returning an error or an error result are
+// recorded as failed.
+func (i *Instrumentation) Middleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			attrs := []attribute.KeyValue{
+				AttrMethodName.String(string(mcp.MethodToolsCall)),
+				AttrToolName.String(request.Params.Name),
+			}
+			ctx, span := i.tracer.Start(ctx, string(mcp.MethodToolsCall)+" "+request.Params.Name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+			if clientSession := server.ClientSessionFromContext(ctx); clientSession != nil {
+				span.SetAttributes(AttrSessionID.String(clientSession.SessionID()))
+			}
+
+			start := time.Now()
+			result, err := next(ctx, request)
+			var errorType string
+			switch {
+			case err != nil:
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				errorType = "_OTHER"
+			case result != nil && result.IsError:
+				span.SetStatus(codes.Error, resultText(result))
+				errorType = errorTypeTool
+			}
+			if errorType != "" {
+				span.SetAttributes(AttrErrorType.String(errorType))
+				attrs = append(attrs, AttrErrorType.String(errorType))
+			}
+			i.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+			return result, err
+		}
+	}
+}
+
+// resultText returns the text of the first text content of a result
+func resultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
+
+// ClientOptions returns the options recording the calls of gRPC clients.
+// The calls of REST clients are recorded with Transport instead.
+func (i *Instrumentation) ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+			otelgrpc.WithTracerProvider(i.tracerProvider),
+			otelgrpc.WithMeterProvider(i.meterProvider),
+		))),
+	}
+}
+
+// Transport returns a transport recording the requests sent with base
+func (i *Instrumentation) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base,
+		otelhttp.WithTracerProvider(i.tracerProvider),
+		otelhttp.WithMeterProvider(i.meterProvider),
+	)
+}
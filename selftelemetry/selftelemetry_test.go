@@ -0,0 +1,126 @@
+package selftelemetry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/fake"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/selftelemetry"
+	"github.com/kitagry/gcp-telemetry-mcp/trace"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentation_Middleware(t *testing.T) {
+	spans := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+	instrumentation, err := selftelemetry.New(
+		sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)),
+		sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := instrumentation.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == "get_trace" {
+			return nil, errors.New("connection refused")
+		}
+		return mcp.NewToolResultError("Failed to list traces: permission denied"), nil
+	})
+	for _, name := range []string{"list_traces", "get_trace"} {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		_, _ = handler(context.Background(), request)
+	}
+
+	recorded := spans.GetSpans()
+	if len(recorded) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(recorded))
+	}
+	span := recorded[0]
+	if span.Name != "tools/call list_traces" || span.Status.Code != codes.Error || span.Status.Description != "Failed to list traces: permission denied" {
+		t.Errorf("Unexpected span %q with status %+v", span.Name, span.Status)
+	}
+	attrs := make(map[string]string)
+	for _, attr := range span.Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["gen_ai.tool.name"] != "list_traces" || attrs["error.type"] != "tool_error" {
+		t.Errorf("Unexpected attributes %v", attrs)
+	}
+	if len(recorded[1].Events) == 0 {
+		t.Error("Expected the error of get_trace to be recorded")
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	histogram, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[float64])
+	if !ok || len(histogram.DataPoints) != 2 {
+		t.Fatalf("Expected the durations of 2 tools, got %+v", rm.ScopeMetrics[0].Metrics[0].Data)
+	}
+}
+
+func TestNewProviders(t *testing.T) {
+	traceAPI := fake.NewTraceClient("test-project")
+	monitoringAPI := fake.NewMonitoringClient("test-project")
+	tp, mp := selftelemetry.NewProviders(trace.NewWithClient(traceAPI, "test-project"), monitoring.NewWithClient(monitoringAPI, "test-project"))
+	instrumentation, err := selftelemetry.New(tp, mp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	handler := instrumentation.Middleware()(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		_, child := tp.Tracer("test").Start(ctx, "google.monitoring.v3.MetricService/ListTimeSeries")
+		child.End()
+		return mcp.NewToolResultText("[]"), nil
+	})
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "list_time_series"
+	if _, err := handler(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	if err := tp.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to export spans: %v", err)
+	}
+	if err := mp.Shutdown(ctx); err != nil {
+		t.Fatalf("Failed to export metrics: %v", err)
+	}
+
+	traces, err := traceAPI.ListTraces(ctx, trace.ListTracesRequest{View: trace.ViewComplete})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces.Traces) != 1 || len(traces.Traces[0].Spans) != 2 {
+		t.Fatalf("Expected a trace of 2 spans, got %+v", traces.Traces)
+	}
+	root, ok := traces.Traces[0].RootSpan()
+	if !ok || root.Name != "tools/call list_time_series" || root.Kind != "RPC_SERVER" || root.Labels["gen_ai.tool.name"] != "list_time_series" {
+		t.Errorf("Unexpected root span %+v", root)
+	}
+
+	req := monitoring.ListTimeSeriesRequest{Filter: `metric.type="` + selftelemetry.MetricPrefix + `mcp.server.operation.duration"`}
+	req.Interval.StartTime = time.Now().Add(-time.Hour)
+	req.Interval.EndTime = time.Now().Add(time.Hour)
+	series, err := monitoringAPI.ListTimeSeries(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series.TimeSeries) != 1 {
+		t.Fatalf("Expected the duration of the tool call to be exported, got %+v", series.TimeSeries)
+	}
+	ts := series.TimeSeries[0]
+	if ts.MetricLabels["gen_ai_tool_name"] != "list_time_series" || ts.ResourceType != "generic_task" || ts.ResourceLabels["namespace"] != "gcp-telemetry-mcp" {
+		t.Errorf("Unexpected time series %+v", ts)
+	}
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// Defaults represents values applied to tool calls made in an MCP session
+// when the call does not specify them explicitly
+type Defaults struct {
+	ProjectID      string            `json:"project_id,omitempty"`
+	ResourceType   string            `json:"resource_type,omitempty"`
+	ResourceLabels map[string]string `json:"resource_labels,omitempty"`
+	LogNamePrefix  string            `json:"log_name_prefix,omitempty"`
+	// TimeZone is the IANA name of the time zone the timestamps of tool
+	// results are shown in, e.g. "Asia/Tokyo"
+	TimeZone string `json:"time_zone,omitempty"`
+	// WriteLabels are attached to the telemetry written in the session. They
+	// come from the server configuration rather than set_session_defaults.
+	WriteLabels map[string]string `json:"-"`
+}
+
+// Merge returns d updated with the non-empty fields of other
+func (d Defaults) Merge(other Defaults) Defaults {
+	if other.ProjectID != "" {
+		d.ProjectID = other.ProjectID
+	}
+	if other.ResourceType != "" {
+		d.ResourceType = other.ResourceType
+	}
+	if other.ResourceLabels != nil {
+		d.ResourceLabels = maps.Clone(other.ResourceLabels)
+	}
+	if other.LogNamePrefix != "" {
+		d.LogNamePrefix = other.LogNamePrefix
+	}
+	if other.TimeZone != "" {
+		d.TimeZone = other.TimeZone
+	}
+	return d
+}
+
+// MergeResourceLabels returns labels with the default resource labels added
+// when resourceType matches the default resource type. Labels in the given
+// map take precedence over the defaults.
+func (d Defaults) MergeResourceLabels(resourceType string, labels map[string]string) map[string]string {
+	if len(d.ResourceLabels) == 0 || resourceType != d.ResourceType {
+		return labels
+	}
+
+	merged := maps.Clone(d.ResourceLabels)
+	maps.Copy(merged, labels)
+	return merged
+}
+
+// Store keeps session defaults keyed by MCP session ID
+type Store struct {
+	mu       sync.RWMutex
+	defaults map[string]Defaults
+}
+
+// NewStore creates an empty Store
+func NewStore() *Store {
+	return &Store{
+		defaults: make(map[string]Defaults),
+	}
+}
+
+// Get returns the defaults for the session
+func (s *Store) Get(sessionID string) Defaults {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.defaults[sessionID]
+}
+
+// Set replaces the defaults for the session
+func (s *Store) Set(sessionID string, defaults Defaults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.defaults[sessionID] = defaults
+}
+
+// Delete removes the defaults for the session
+func (s *Store) Delete(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.defaults, sessionID)
+}
+
+type defaultsKey struct{}
+
+// NewContext returns a context carrying the session defaults
+func NewContext(ctx context.Context, defaults Defaults) context.Context {
+	return context.WithValue(ctx, defaultsKey{}, defaults)
+}
+
+// FromContext returns the session defaults carried by ctx, or empty defaults
+func FromContext(ctx context.Context) Defaults {
+	defaults, _ := ctx.Value(defaultsKey{}).(Defaults)
+	return defaults
+}
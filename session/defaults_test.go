@@ -0,0 +1,106 @@
+package session_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+)
+
+func TestDefaults_Merge(t *testing.T) {
+	defaults := session.Defaults{
+		ProjectID:     "project-a",
+		LogNamePrefix: "agent-",
+	}
+
+	merged := defaults.Merge(session.Defaults{
+		ProjectID:    "project-b",
+		ResourceType: "gce_instance",
+		TimeZone:     "Asia/Tokyo",
+	})
+
+	if merged.ProjectID != "project-b" {
+		t.Errorf("Expected project-b, got %s", merged.ProjectID)
+	}
+	if merged.ResourceType != "gce_instance" {
+		t.Errorf("Expected gce_instance, got %s", merged.ResourceType)
+	}
+	if merged.TimeZone != "Asia/Tokyo" {
+		t.Errorf("Expected Asia/Tokyo, got %s", merged.TimeZone)
+	}
+	if merged.LogNamePrefix != "agent-" {
+		t.Errorf("Expected log name prefix to be kept, got %s", merged.LogNamePrefix)
+	}
+}
+
+func TestDefaults_MergeResourceLabels(t *testing.T) {
+	defaults := session.Defaults{
+		ResourceType: "gce_instance",
+		ResourceLabels: map[string]string{
+			"zone":        "us-central1-a",
+			"instance_id": "123",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		resourceType string
+		labels       map[string]string
+		want         map[string]string
+	}{
+		{
+			name:         "matching resource type adds defaults",
+			resourceType: "gce_instance",
+			labels:       map[string]string{"instance_id": "456"},
+			want:         map[string]string{"zone": "us-central1-a", "instance_id": "456"},
+		},
+		{
+			name:         "other resource type is left alone",
+			resourceType: "global",
+			labels:       map[string]string{"project_id": "p"},
+			want:         map[string]string{"project_id": "p"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := defaults.MergeResourceLabels(tt.resourceType, tt.labels)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("Expected %s=%s, got %s", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := session.NewStore()
+	store.Set("session-1", session.Defaults{ProjectID: "project-a"})
+
+	if got := store.Get("session-1").ProjectID; got != "project-a" {
+		t.Errorf("Expected project-a, got %s", got)
+	}
+	if got := store.Get("session-2").ProjectID; got != "" {
+		t.Errorf("Expected no defaults for other sessions, got %s", got)
+	}
+
+	store.Delete("session-1")
+	if got := store.Get("session-1").ProjectID; got != "" {
+		t.Errorf("Expected defaults to be deleted, got %s", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := session.NewContext(context.Background(), session.Defaults{ProjectID: "project-a"})
+
+	if got := session.FromContext(ctx).ProjectID; got != "project-a" {
+		t.Errorf("Expected project-a, got %s", got)
+	}
+	if got := session.FromContext(context.Background()).ProjectID; got != "" {
+		t.Errorf("Expected empty defaults, got %s", got)
+	}
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultWriteLabels are the labels attached to written telemetry unless
+	// configured otherwise
+	DefaultWriteLabels = "written_by=gcp-telemetry-mcp,session_id=" + SessionIDPlaceholder
+	// SessionIDPlaceholder in a label value is replaced with the MCP session ID
+	SessionIDPlaceholder = "{session_id}"
+)
+
+// labelKeyPattern matches keys valid for metric labels as well as log entry
+// and span labels
+var labelKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+// ParseWriteLabels parses comma-separated KEY=VALUE labels attached to the
+// log entries, time series, and spans written by tools, or "none" for no labels
+func ParseWriteLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if strings.TrimSpace(s) == "none" {
+		return labels, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid label %q: must be KEY=VALUE", pair)
+		}
+		if !labelKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid label key %q: must start with a lowercase letter and contain only lowercase letters, digits, and underscores", key)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// ResolveWriteLabels returns labels with SessionIDPlaceholder replaced by
+// sessionID. Labels left empty, e.g. without a session, are dropped.
+func ResolveWriteLabels(labels map[string]string, sessionID string) map[string]string {
+	resolved := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if v = strings.ReplaceAll(v, SessionIDPlaceholder, sessionID); v != "" {
+			resolved[k] = v
+		}
+	}
+	return resolved
+}
+
+// MergeWriteLabels returns labels with the write labels of the session
+// added. Labels in the given map take precedence over the write labels.
+func (d Defaults) MergeWriteLabels(labels map[string]string) map[string]string {
+	if len(d.WriteLabels) == 0 {
+		return labels
+	}
+
+	merged := maps.Clone(d.WriteLabels)
+	maps.Copy(merged, labels)
+	return merged
+}
@@ -0,0 +1,88 @@
+package session_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kitagry/gcp-telemetry-mcp/session"
+)
+
+func TestParseWriteLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "default",
+			in:   session.DefaultWriteLabels,
+			want: map[string]string{"written_by": "gcp-telemetry-mcp", "session_id": "{session_id}"},
+		},
+		{
+			name: "spaces and trailing comma",
+			in:   " written_by = agent , team=sre,",
+			want: map[string]string{"written_by": "agent", "team": "sre"},
+		},
+		{
+			name: "none",
+			in:   "none",
+			want: map[string]string{},
+		},
+		{
+			name:    "missing value",
+			in:      "written_by",
+			wantErr: true,
+		},
+		{
+			name:    "invalid key",
+			in:      "Written-By=agent",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := session.ParseWriteLabels(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWriteLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWriteLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveWriteLabels(t *testing.T) {
+	labels := map[string]string{"written_by": "gcp-telemetry-mcp", "session_id": "{session_id}"}
+
+	got := session.ResolveWriteLabels(labels, "abc-123")
+	want := map[string]string{"written_by": "gcp-telemetry-mcp", "session_id": "abc-123"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// Without a session, the session ID label is dropped
+	got = session.ResolveWriteLabels(labels, "")
+	want = map[string]string{"written_by": "gcp-telemetry-mcp"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestDefaults_MergeWriteLabels(t *testing.T) {
+	defaults := session.Defaults{
+		WriteLabels: map[string]string{"written_by": "gcp-telemetry-mcp", "session_id": "abc-123"},
+	}
+
+	got := defaults.MergeWriteLabels(map[string]string{"written_by": "load-test", "env": "dev"})
+	want := map[string]string{"written_by": "load-test", "session_id": "abc-123", "env": "dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got := (session.Defaults{}).MergeWriteLabels(nil); got != nil {
+		t.Errorf("Expected nil labels without write labels, got %v", got)
+	}
+}
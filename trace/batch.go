@@ -0,0 +1,63 @@
+package trace
+
+import (
+	"context"
+	"sync"
+)
+
+// maxConcurrentGets limits the number of traces fetched at the same time
+const maxConcurrentGets = 10
+
+// GetTracesRequest represents a request to get several traces at once
+type GetTracesRequest struct {
+	ProjectID string   `json:"project_id,omitempty"` // defaults to the client's project
+	TraceIDs  []string `json:"trace_ids"`
+}
+
+// GetTracesResponse represents the fetched traces keyed by trace ID.
+// Traces that could not be fetched are reported in Errors instead.
+type GetTracesResponse struct {
+	Traces map[string]*Trace `json:"traces"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// GetTraces fetches several traces from Cloud Trace concurrently
+func (c *CloudTraceClient) GetTraces(ctx context.Context, req GetTracesRequest) GetTracesResponse {
+	resp := GetTracesResponse{
+		Traces: make(map[string]*Trace),
+		Errors: make(map[string]string),
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, maxConcurrentGets)
+		seen = make(map[string]bool)
+	)
+	for _, traceID := range req.TraceIDs {
+		if seen[traceID] {
+			continue
+		}
+		seen[traceID] = true
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			t, err := c.client.GetTrace(ctx, GetTraceRequest{ProjectID: req.ProjectID, TraceID: traceID})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				resp.Errors[traceID] = err.Error()
+				return
+			}
+			resp.Traces[traceID] = t
+		}()
+	}
+	wg.Wait()
+
+	return resp
+}
@@ -9,7 +9,9 @@ import (
 
 	trace "cloud.google.com/go/trace/apiv1"
 	"cloud.google.com/go/trace/apiv1/tracepb"
+	"github.com/kitagry/gcp-telemetry-mcp/credentials"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -26,36 +28,58 @@ type Span struct {
 
 // Trace represents a distributed trace
 type Trace struct {
-	TraceID   string `json:"trace_id"`
-	ProjectID string `json:"project_id"`
-	Spans     []Span `json:"spans"`
+	TraceID    string `json:"trace_id"`
+	ProjectID  string `json:"project_id"`
+	Spans      []Span `json:"spans"`
+	ConsoleURL string `json:"console_url,omitempty"`
 }
 
+// Views of the traces returned by ListTraces
+const (
+	ViewMinimal  = "MINIMAL"  // trace IDs only
+	ViewRootSpan = "ROOTSPAN" // root span only
+	ViewComplete = "COMPLETE" // all spans
+)
+
 // ListTracesRequest represents a request to list traces
 type ListTracesRequest struct {
+	ProjectID string    `json:"project_id,omitempty"` // defaults to the client's project
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
 	Filter    string    `json:"filter,omitempty"`
 	OrderBy   string    `json:"order_by,omitempty"`
+	View      string    `json:"view,omitempty"` // defaults to ViewMinimal
 	PageSize  int       `json:"page_size,omitempty"`
 	PageToken string    `json:"page_token,omitempty"`
 }
 
+// ListTracesResponse represents a page of traces and pagination info
+type ListTracesResponse struct {
+	Traces        []Trace `json:"traces"`
+	NextPageToken string  `json:"next_page_token,omitempty"`
+}
+
 // GetTraceRequest represents a request to get a specific trace
 type GetTraceRequest struct {
-	TraceID string `json:"trace_id"`
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	TraceID   string `json:"trace_id"`
 }
 
 // PatchTraceRequest represents a request to update trace spans
 type PatchTraceRequest struct {
-	TraceID string `json:"trace_id"`
-	Spans   []Span `json:"spans"`
+	ProjectID string `json:"project_id,omitempty"` // defaults to the client's project
+	TraceID   string `json:"trace_id"`
+	Spans     []Span `json:"spans"`
+	// AllowMissingParents accepts parents that are not among Spans, for spans
+	// streamed in batches whose parents are written by another patch
+	AllowMissingParents bool `json:"allow_missing_parents,omitempty"`
 }
 
 // TraceClient defines the interface for Cloud Trace operations
 type TraceClient interface {
-	ListTraces(ctx context.Context, req ListTracesRequest) ([]Trace, error)
+	ListTraces(ctx context.Context, req ListTracesRequest) (ListTracesResponse, error)
 	GetTrace(ctx context.Context, req GetTraceRequest) (*Trace, error)
+	GetTraces(ctx context.Context, req GetTracesRequest) GetTracesResponse
 	PatchTraces(ctx context.Context, req PatchTraceRequest) error
 }
 
@@ -67,23 +91,35 @@ type CloudTraceClient struct {
 
 // TraceClientInterface abstracts the Google Cloud Trace client for testing
 type TraceClientInterface interface {
-	ListTraces(ctx context.Context, req ListTracesRequest) ([]Trace, error)
+	ListTraces(ctx context.Context, req ListTracesRequest) (ListTracesResponse, error)
 	GetTrace(ctx context.Context, req GetTraceRequest) (*Trace, error)
 	PatchTraces(ctx context.Context, req PatchTraceRequest) error
 }
 
 // New creates a new CloudTraceClient
-func New(projectID string) (*CloudTraceClient, error) {
-	client, err := trace.NewClient(context.Background())
+func New(projectID string, opts ...option.ClientOption) (*CloudTraceClient, error) {
+	client, err := NewAPIClient(projectID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithClient(client, projectID), nil
+}
+
+// NewAPIClient creates the TraceClientInterface calling the Cloud Trace API,
+// e.g. to be wrapped before it is passed to NewWithClient, or the emulator
+// set in TRACE_EMULATOR_HOST
+func NewAPIClient(projectID string, opts ...option.ClientOption) (TraceClientInterface, error) {
+	if emulatorOpts, ok := credentials.EmulatorOptions(credentials.ServiceTrace); ok {
+		opts = emulatorOpts
+	}
+
+	client, err := trace.NewClient(context.Background(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create trace client: %w", err)
 	}
 
-	return &CloudTraceClient{
-		client: &realTraceClient{
-			client:    client,
-			projectID: projectID,
-		},
+	return &realTraceClient{
+		client:    client,
 		projectID: projectID,
 	}, nil
 }
@@ -97,7 +133,7 @@ func NewWithClient(client TraceClientInterface, projectID string) *CloudTraceCli
 }
 
 // ListTraces lists traces from Cloud Trace
-func (c *CloudTraceClient) ListTraces(ctx context.Context, req ListTracesRequest) ([]Trace, error) {
+func (c *CloudTraceClient) ListTraces(ctx context.Context, req ListTracesRequest) (ListTracesResponse, error) {
 	return c.client.ListTraces(ctx, req)
 }
 
@@ -106,8 +142,12 @@ func (c *CloudTraceClient) GetTrace(ctx context.Context, req GetTraceRequest) (*
 	return c.client.GetTrace(ctx, req)
 }
 
-// PatchTraces updates trace spans in Cloud Trace
+// PatchTraces validates the spans and updates them in Cloud Trace.
+// A *ValidationError is returned without calling the API when the request is invalid.
 func (c *CloudTraceClient) PatchTraces(ctx context.Context, req PatchTraceRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
 	return c.client.PatchTraces(ctx, req)
 }
 
@@ -117,15 +157,24 @@ type realTraceClient struct {
 	projectID string
 }
 
+// project returns projectID, defaulting to the client's project
+func (r *realTraceClient) project(projectID string) string {
+	if projectID == "" {
+		return r.projectID
+	}
+	return projectID
+}
+
 // ListTraces implements TraceClientInterface for the real client
-func (r *realTraceClient) ListTraces(ctx context.Context, req ListTracesRequest) ([]Trace, error) {
+func (r *realTraceClient) ListTraces(ctx context.Context, req ListTracesRequest) (ListTracesResponse, error) {
 	pageSize := req.PageSize
 	if pageSize <= 0 {
 		pageSize = 100 // default page size
 	}
 
+	projectID := r.project(req.ProjectID)
 	pbReq := &tracepb.ListTracesRequest{
-		ProjectId: r.projectID,
+		ProjectId: projectID,
 		StartTime: timestamppb.New(req.StartTime),
 		EndTime:   timestamppb.New(req.EndTime),
 		PageSize:  int32(pageSize),
@@ -139,6 +188,10 @@ func (r *realTraceClient) ListTraces(ctx context.Context, req ListTracesRequest)
 		pbReq.OrderBy = req.OrderBy
 	}
 
+	if view, ok := tracepb.ListTracesRequest_ViewType_value[req.View]; ok {
+		pbReq.View = tracepb.ListTracesRequest_ViewType(view)
+	}
+
 	if req.PageToken != "" {
 		pbReq.PageToken = req.PageToken
 	}
@@ -146,26 +199,30 @@ func (r *realTraceClient) ListTraces(ctx context.Context, req ListTracesRequest)
 	it := r.client.ListTraces(ctx, pbReq)
 	var result []Trace
 
-	for i := 0; i <= pageSize; i++ {
+	for range pageSize {
 		traceProto, err := it.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return nil, err
+			return ListTracesResponse{}, err
 		}
 
-		trace := convertProtoToTrace(traceProto, r.projectID)
+		trace := convertProtoToTrace(traceProto, projectID)
 		result = append(result, trace)
 	}
 
-	return result, nil
+	return ListTracesResponse{
+		Traces:        result,
+		NextPageToken: it.PageInfo().Token,
+	}, nil
 }
 
 // GetTrace implements TraceClientInterface for the real client
 func (r *realTraceClient) GetTrace(ctx context.Context, req GetTraceRequest) (*Trace, error) {
+	projectID := r.project(req.ProjectID)
 	pbReq := &tracepb.GetTraceRequest{
-		ProjectId: r.projectID,
+		ProjectId: projectID,
 		TraceId:   req.TraceID,
 	}
 
@@ -174,7 +231,7 @@ func (r *realTraceClient) GetTrace(ctx context.Context, req GetTraceRequest) (*T
 		return nil, err
 	}
 
-	trace := convertProtoToTrace(traceProto, r.projectID)
+	trace := convertProtoToTrace(traceProto, projectID)
 	return &trace, nil
 }
 
@@ -209,7 +266,7 @@ func (r *realTraceClient) PatchTraces(ctx context.Context, req PatchTraceRequest
 	}
 
 	pbReq := &tracepb.PatchTracesRequest{
-		ProjectId: r.projectID,
+		ProjectId: r.project(req.ProjectID),
 		Traces: &tracepb.Traces{
 			Traces: []*tracepb.Trace{
 				{
@@ -253,9 +310,10 @@ func convertProtoToTrace(traceProto *tracepb.Trace, projectID string) Trace {
 	}
 
 	return Trace{
-		TraceID:   traceProto.TraceId,
-		ProjectID: projectID,
-		Spans:     spans,
+		TraceID:    traceProto.TraceId,
+		ProjectID:  projectID,
+		Spans:      spans,
+		ConsoleURL: ConsoleURL(projectID, traceProto.TraceId),
 	}
 }
 
@@ -2,6 +2,7 @@ package trace_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -46,13 +47,14 @@ func TestCloudTraceClient_ListTraces(t *testing.T) {
 	// Set expectation for ListTraces call
 	mockClient.EXPECT().
 		ListTraces(gomock.Any(), req).
-		Return(expectedTraces, nil).
+		Return(trace.ListTracesResponse{Traces: expectedTraces}, nil).
 		Times(1)
 
-	result, err := client.ListTraces(context.Background(), req)
+	resp, err := client.ListTraces(context.Background(), req)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
+	result := resp.Traces
 
 	if len(result) != 1 {
 		t.Errorf("Expected 1 trace, got %d", len(result))
@@ -112,6 +114,44 @@ func TestCloudTraceClient_GetTrace(t *testing.T) {
 	}
 }
 
+func TestCloudTraceClient_GetTraces(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockTraceClientInterface(ctrl)
+	client := trace.NewWithClient(mockClient, "test-project")
+
+	mockClient.EXPECT().
+		GetTrace(gomock.Any(), trace.GetTraceRequest{ProjectID: "other-project", TraceID: "trace1"}).
+		Return(&trace.Trace{TraceID: "trace1"}, nil).
+		Times(1)
+	mockClient.EXPECT().
+		GetTrace(gomock.Any(), trace.GetTraceRequest{ProjectID: "other-project", TraceID: "trace2"}).
+		Return(&trace.Trace{TraceID: "trace2"}, nil).
+		Times(1)
+	mockClient.EXPECT().
+		GetTrace(gomock.Any(), trace.GetTraceRequest{ProjectID: "other-project", TraceID: "missing"}).
+		Return(nil, errors.New("not found")).
+		Times(1)
+
+	resp := client.GetTraces(context.Background(), trace.GetTracesRequest{
+		ProjectID: "other-project",
+		TraceIDs:  []string{"trace1", "trace2", "missing", "trace1"},
+	})
+
+	if len(resp.Traces) != 2 {
+		t.Fatalf("Expected 2 traces, got %d", len(resp.Traces))
+	}
+	for _, id := range []string{"trace1", "trace2"} {
+		if resp.Traces[id] == nil || resp.Traces[id].TraceID != id {
+			t.Errorf("Expected trace %s, got %+v", id, resp.Traces[id])
+		}
+	}
+	if resp.Errors["missing"] != "not found" {
+		t.Errorf("Expected error for missing trace, got %v", resp.Errors)
+	}
+}
+
 func TestCloudTraceClient_PatchTraces(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -120,7 +160,7 @@ func TestCloudTraceClient_PatchTraces(t *testing.T) {
 	client := trace.NewWithClient(mockClient, "test-project")
 
 	req := trace.PatchTraceRequest{
-		TraceID: "trace123",
+		TraceID: "0123456789abcdef0123456789abcdef",
 		Spans: []trace.Span{
 			{
 				SpanID:    "span123",
@@ -145,4 +185,30 @@ func TestCloudTraceClient_PatchTraces(t *testing.T) {
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
-}
\ No newline at end of file
+}
+
+func TestCloudTraceClient_PatchTracesInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockTraceClientInterface(ctrl)
+	client := trace.NewWithClient(mockClient, "test-project")
+
+	// The API must not be called for an invalid request
+	mockClient.EXPECT().PatchTraces(gomock.Any(), gomock.Any()).Times(0)
+
+	err := client.PatchTraces(context.Background(), trace.PatchTraceRequest{
+		TraceID: "trace123",
+		Spans: []trace.Span{
+			{SpanID: "span123", Name: "span", StartTime: time.Now(), EndTime: time.Now()},
+		},
+	})
+
+	var validationErr *trace.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	if len(validationErr.Problems) != 1 || validationErr.Problems[0].Field != "trace_id" {
+		t.Errorf("Expected a trace_id problem, got %+v", validationErr.Problems)
+	}
+}
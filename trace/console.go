@@ -0,0 +1,11 @@
+package trace
+
+import "net/url"
+
+// ConsoleURL returns the Cloud Console URL showing the trace
+func ConsoleURL(projectID, traceID string) string {
+	query := url.Values{}
+	query.Set("project", projectID)
+	query.Set("tid", traceID)
+	return "https://console.cloud.google.com/traces/list?" + query.Encode()
+}
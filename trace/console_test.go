@@ -0,0 +1,11 @@
+package trace
+
+import "testing"
+
+func TestConsoleURL(t *testing.T) {
+	got := ConsoleURL("my-project", "0af7651916cd43dd8448eb211c80319c")
+	want := "https://console.cloud.google.com/traces/list?project=my-project&tid=0af7651916cd43dd8448eb211c80319c"
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,81 @@
+package trace
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Trace context header formats
+const (
+	FormatTraceparent       = "traceparent"           // W3C Trace Context
+	FormatCloudTraceContext = "x-cloud-trace-context" // TRACE_ID/SPAN_ID;o=OPTIONS
+)
+
+var (
+	// traceparentPattern matches version-trace_id-parent_id-trace_flags
+	traceparentPattern = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(-.*)?$`)
+	// cloudTraceContextPattern matches TRACE_ID[/SPAN_ID][;o=OPTIONS]
+	cloudTraceContextPattern = regexp.MustCompile(`^([0-9a-fA-F]{32})(?:/([0-9]+))?(?:;o=([0-9]+))?$`)
+)
+
+// TraceContext represents the trace and span a request belongs to
+type TraceContext struct {
+	Format  string `json:"format"`
+	TraceID string `json:"trace_id"`
+	// SpanID is the 16 hex character span ID; X-Cloud-Trace-Context carries it as a decimal number
+	SpanID  string `json:"span_id,omitempty"`
+	Sampled bool   `json:"sampled"`
+}
+
+// ParseTraceContext parses a W3C traceparent or X-Cloud-Trace-Context header
+// value. A leading "traceparent:" or "X-Cloud-Trace-Context:" header name is ignored.
+func ParseTraceContext(header string) (TraceContext, error) {
+	value := strings.TrimSpace(header)
+	if name, rest, ok := strings.Cut(value, ":"); ok {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case FormatTraceparent, FormatCloudTraceContext:
+			value = strings.TrimSpace(rest)
+		}
+	}
+
+	if m := traceparentPattern.FindStringSubmatch(value); m != nil {
+		version, traceID, spanID, flags := m[1], m[2], m[3], m[4]
+		if version == "ff" {
+			return TraceContext{}, fmt.Errorf("invalid traceparent version %q", version)
+		}
+		// Version 00 has exactly four fields; later versions may append more
+		if version == "00" && m[5] != "" {
+			return TraceContext{}, fmt.Errorf("traceparent version 00 must have 4 fields")
+		}
+		if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+			return TraceContext{}, fmt.Errorf("traceparent trace ID and parent ID must not be all zeros")
+		}
+		flagBits, _ := strconv.ParseUint(flags, 16, 8)
+		return TraceContext{
+			Format:  FormatTraceparent,
+			TraceID: traceID,
+			SpanID:  spanID,
+			Sampled: flagBits&1 == 1,
+		}, nil
+	}
+
+	if m := cloudTraceContextPattern.FindStringSubmatch(value); m != nil {
+		tc := TraceContext{
+			Format:  FormatCloudTraceContext,
+			TraceID: strings.ToLower(m[1]),
+			Sampled: m[3] == "1",
+		}
+		if m[2] != "" {
+			spanID, err := strconv.ParseUint(m[2], 10, 64)
+			if err != nil {
+				return TraceContext{}, fmt.Errorf("invalid X-Cloud-Trace-Context span ID %q: %w", m[2], err)
+			}
+			tc.SpanID = formatSpanID(spanID)
+		}
+		return tc, nil
+	}
+
+	return TraceContext{}, fmt.Errorf("unrecognized trace context %q: expected a traceparent (00-TRACE_ID-SPAN_ID-FLAGS) or X-Cloud-Trace-Context (TRACE_ID/SPAN_ID;o=1) value", header)
+}
@@ -0,0 +1,61 @@
+package trace
+
+import "testing"
+
+func TestParseTraceContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    TraceContext
+		wantErr bool
+	}{
+		{
+			name:   "traceparent sampled",
+			header: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			want:   TraceContext{Format: FormatTraceparent, TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331", Sampled: true},
+		},
+		{
+			name:   "traceparent with header name",
+			header: "traceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00",
+			want:   TraceContext{Format: FormatTraceparent, TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331"},
+		},
+		{
+			name:   "future traceparent version with extra field",
+			header: "01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-03-extra",
+			want:   TraceContext{Format: FormatTraceparent, TraceID: "0af7651916cd43dd8448eb211c80319c", SpanID: "b7ad6b7169203331", Sampled: true},
+		},
+		{
+			name:   "X-Cloud-Trace-Context",
+			header: "X-Cloud-Trace-Context: 105445AA7843BC8BF206B12000100000/1;o=1",
+			want:   TraceContext{Format: FormatCloudTraceContext, TraceID: "105445aa7843bc8bf206b12000100000", SpanID: "0000000000000001", Sampled: true},
+		},
+		{
+			name:   "X-Cloud-Trace-Context without span",
+			header: "105445aa7843bc8bf206b12000100000",
+			want:   TraceContext{Format: FormatCloudTraceContext, TraceID: "105445aa7843bc8bf206b12000100000"},
+		},
+		{name: "traceparent version ff", header: "ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", wantErr: true},
+		{name: "traceparent version 00 with extra field", header: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra", wantErr: true},
+		{name: "all zero trace ID", header: "00-00000000000000000000000000000000-b7ad6b7169203331-01", wantErr: true},
+		{name: "span ID overflow", header: "105445aa7843bc8bf206b12000100000/99999999999999999999;o=1", wantErr: true},
+		{name: "garbage", header: "not a trace", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTraceContext(tt.header)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package trace
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Labels marking the spans that failed
+const (
+	LabelHTTPStatusCode = "/http/status_code"
+	LabelError          = "error"
+)
+
+// ErrorSpanGroup represents the error spans sharing a name
+type ErrorSpanGroup struct {
+	Name            string         `json:"name"`
+	Count           int            `json:"count"`
+	StatusCodes     map[string]int `json:"status_codes,omitempty"` // number of spans per HTTP status code, e.g. {"503": 4}
+	ExampleTraceIDs []string       `json:"example_trace_ids"`
+}
+
+// ErrorSpanReport represents the error spans found in traces
+type ErrorSpanReport struct {
+	TracesScanned    int              `json:"traces_scanned"`
+	TracesWithErrors int              `json:"traces_with_errors"`
+	ErrorSpans       int              `json:"error_spans"`
+	Groups           []ErrorSpanGroup `json:"groups"` // most error spans first
+}
+
+// IsErrorSpan reports whether span failed: its HTTP status code is 500 or
+// above, or its error label is true
+func IsErrorSpan(span Span) bool {
+	if code, err := strconv.Atoi(span.Labels[LabelHTTPStatusCode]); err == nil && code >= 500 {
+		return true
+	}
+	isError, _ := strconv.ParseBool(strings.TrimSpace(span.Labels[LabelError]))
+	return isError
+}
+
+// FindErrorSpans groups the error spans of traces by span name, with up to
+// maxExamples IDs of the traces they were found in per group
+func FindErrorSpans(traces []Trace, maxExamples int) ErrorSpanReport {
+	report := ErrorSpanReport{TracesScanned: len(traces), Groups: []ErrorSpanGroup{}}
+	index := make(map[string]int)
+	for _, t := range traces {
+		found := false
+		for _, span := range t.Spans {
+			if !IsErrorSpan(span) {
+				continue
+			}
+			found = true
+			report.ErrorSpans++
+
+			i, ok := index[span.Name]
+			if !ok {
+				i = len(report.Groups)
+				index[span.Name] = i
+				report.Groups = append(report.Groups, ErrorSpanGroup{Name: span.Name, ExampleTraceIDs: []string{}})
+			}
+			group := &report.Groups[i]
+			group.Count++
+			if code, ok := span.Labels[LabelHTTPStatusCode]; ok {
+				if group.StatusCodes == nil {
+					group.StatusCodes = make(map[string]int)
+				}
+				group.StatusCodes[code]++
+			}
+			if len(group.ExampleTraceIDs) < maxExamples && !slices.Contains(group.ExampleTraceIDs, t.TraceID) {
+				group.ExampleTraceIDs = append(group.ExampleTraceIDs, t.TraceID)
+			}
+		}
+		if found {
+			report.TracesWithErrors++
+		}
+	}
+
+	slices.SortStableFunc(report.Groups, func(a, b ErrorSpanGroup) int { return cmp.Compare(b.Count, a.Count) })
+	return report
+}
@@ -0,0 +1,59 @@
+package trace
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsErrorSpan(t *testing.T) {
+	tests := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{labels: nil, want: false},
+		{labels: map[string]string{LabelHTTPStatusCode: "200"}, want: false},
+		{labels: map[string]string{LabelHTTPStatusCode: "404"}, want: false},
+		{labels: map[string]string{LabelHTTPStatusCode: "500"}, want: true},
+		{labels: map[string]string{LabelHTTPStatusCode: "503"}, want: true},
+		{labels: map[string]string{LabelError: "true"}, want: true},
+		{labels: map[string]string{LabelError: "false"}, want: false},
+		{labels: map[string]string{LabelHTTPStatusCode: "200", LabelError: "true"}, want: true},
+	}
+	for _, tt := range tests {
+		if got := IsErrorSpan(Span{Labels: tt.labels}); got != tt.want {
+			t.Errorf("IsErrorSpan(%v) = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+}
+
+func TestFindErrorSpans(t *testing.T) {
+	status := func(code string) map[string]string { return map[string]string{LabelHTTPStatusCode: code} }
+	traces := []Trace{
+		{TraceID: "a", Spans: []Span{
+			{SpanID: "1", Name: "GET /orders", Labels: status("503")},
+			{SpanID: "2", Name: "db.query", ParentID: "1", Labels: map[string]string{LabelError: "true"}},
+		}},
+		{TraceID: "b", Spans: []Span{
+			{SpanID: "1", Name: "GET /orders", Labels: status("500")},
+			{SpanID: "2", Name: "db.query", ParentID: "1"},
+		}},
+		{TraceID: "c", Spans: []Span{
+			{SpanID: "1", Name: "GET /orders", Labels: status("503")},
+		}},
+		{TraceID: "d", Spans: []Span{
+			{SpanID: "1", Name: "GET /users", Labels: status("200")},
+		}},
+	}
+
+	report := FindErrorSpans(traces, 2)
+	if report.TracesScanned != 4 || report.TracesWithErrors != 3 || report.ErrorSpans != 4 {
+		t.Errorf("Expected 4 traces scanned, 3 with errors, and 4 error spans, got %+v", report)
+	}
+	want := []ErrorSpanGroup{
+		{Name: "GET /orders", Count: 3, StatusCodes: map[string]int{"503": 2, "500": 1}, ExampleTraceIDs: []string{"a", "b"}},
+		{Name: "db.query", Count: 1, ExampleTraceIDs: []string{"a"}},
+	}
+	if !reflect.DeepEqual(report.Groups, want) {
+		t.Errorf("Expected groups %+v, got %+v", want, report.Groups)
+	}
+}
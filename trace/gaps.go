@@ -0,0 +1,157 @@
+package trace
+
+import (
+	"sort"
+	"time"
+)
+
+// GapAnalysis represents the untraced time of a trace
+type GapAnalysis struct {
+	TraceID       string         `json:"trace_id"`
+	Duration      string         `json:"duration"`       // from the first span start to the last span end
+	UntracedTotal string         `json:"untraced_total"` // sum of the untraced time of all spans with children
+	Spans         []SpanCoverage `json:"spans"`          // spans with children, most untraced time first
+	Gaps          []Gap          `json:"gaps"`           // largest gaps first
+}
+
+// SpanCoverage represents how much of a span's duration its children account for
+type SpanCoverage struct {
+	SpanID        string  `json:"span_id"`
+	Name          string  `json:"name"`
+	Duration      string  `json:"duration"`
+	ChildCount    int     `json:"child_count"`
+	Untraced      string  `json:"untraced"`       // span duration minus the union of its children
+	UntracedRatio float64 `json:"untraced_ratio"` // Untraced divided by Duration
+}
+
+// Gap represents a period within a span that none of its children cover
+type Gap struct {
+	SpanID    string    `json:"span_id"`
+	SpanName  string    `json:"span_name"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Duration  string    `json:"duration"`
+	After     string    `json:"after,omitempty"`  // child span that ended when the gap started; empty at the start of the span
+	Before    string    `json:"before,omitempty"` // child span that started when the gap ended; empty at the end of the span
+}
+
+// interval is a period covered by one or more child spans
+type interval struct {
+	start, end time.Time
+	first      string // name of the child starting the interval
+	last       string // name of the child ending the interval
+}
+
+// AnalyzeGaps reports the time within each span that none of its children
+// cover. Concurrent children are merged, so only time where no child was
+// running counts as untraced. Gaps shorter than minGap are omitted from Gaps
+// but still count towards the untraced durations.
+func AnalyzeGaps(t Trace, minGap time.Duration) GapAnalysis {
+	analysis := GapAnalysis{
+		TraceID: t.TraceID,
+		Spans:   []SpanCoverage{},
+		Gaps:    []Gap{},
+	}
+
+	children := make(map[string][]Span)
+	var first, last time.Time
+	for _, span := range t.Spans {
+		if span.ParentID != "" {
+			children[span.ParentID] = append(children[span.ParentID], span)
+		}
+		if first.IsZero() || span.StartTime.Before(first) {
+			first = span.StartTime
+		}
+		if span.EndTime.After(last) {
+			last = span.EndTime
+		}
+	}
+	analysis.Duration = last.Sub(first).String()
+
+	untraced := make(map[string]time.Duration)
+	var total time.Duration
+	for _, span := range t.Spans {
+		spanChildren := children[span.SpanID]
+		duration := span.EndTime.Sub(span.StartTime)
+		if len(spanChildren) == 0 || duration <= 0 {
+			continue
+		}
+
+		covered := mergeChildren(span, spanChildren)
+		var spanUntraced time.Duration
+		cursor, after := span.StartTime, ""
+		for _, iv := range append(covered, interval{start: span.EndTime, end: span.EndTime}) {
+			if gap := iv.start.Sub(cursor); gap > 0 {
+				spanUntraced += gap
+				if gap >= minGap {
+					analysis.Gaps = append(analysis.Gaps, Gap{
+						SpanID:    span.SpanID,
+						SpanName:  span.Name,
+						StartTime: cursor,
+						EndTime:   iv.start,
+						Duration:  gap.String(),
+						After:     after,
+						Before:    iv.first,
+					})
+				}
+			}
+			cursor, after = iv.end, iv.last
+		}
+
+		untraced[span.SpanID] = spanUntraced
+		total += spanUntraced
+		analysis.Spans = append(analysis.Spans, SpanCoverage{
+			SpanID:        span.SpanID,
+			Name:          span.Name,
+			Duration:      duration.String(),
+			ChildCount:    len(spanChildren),
+			Untraced:      spanUntraced.String(),
+			UntracedRatio: float64(spanUntraced) / float64(duration),
+		})
+	}
+	analysis.UntracedTotal = total.String()
+
+	sort.SliceStable(analysis.Spans, func(i, j int) bool {
+		return untraced[analysis.Spans[i].SpanID] > untraced[analysis.Spans[j].SpanID]
+	})
+	sort.SliceStable(analysis.Gaps, func(i, j int) bool {
+		return analysis.Gaps[i].EndTime.Sub(analysis.Gaps[i].StartTime) > analysis.Gaps[j].EndTime.Sub(analysis.Gaps[j].StartTime)
+	})
+	return analysis
+}
+
+// mergeChildren returns the periods of the parent covered by its children in time order
+func mergeChildren(parent Span, children []Span) []interval {
+	intervals := make([]interval, 0, len(children))
+	for _, child := range children {
+		// Children may start before or end after their parent because of clock skew
+		start, end := child.StartTime, child.EndTime
+		if start.Before(parent.StartTime) {
+			start = parent.StartTime
+		}
+		if end.After(parent.EndTime) {
+			end = parent.EndTime
+		}
+		if !end.After(start) {
+			continue
+		}
+		intervals = append(intervals, interval{start: start, end: end, first: child.Name, last: child.Name})
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].start.Before(intervals[j].start)
+	})
+
+	merged := []interval{}
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 && !iv.start.After(merged[n-1].end) {
+			if iv.end.After(merged[n-1].end) {
+				merged[n-1].end = iv.end
+				merged[n-1].last = iv.last
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
@@ -0,0 +1,71 @@
+package trace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalyzeGaps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ms := func(n int) time.Time { return start.Add(time.Duration(n) * time.Millisecond) }
+
+	tr := Trace{
+		TraceID: "abc",
+		Spans: []Span{
+			{SpanID: "1", Name: "GET /orders", StartTime: ms(0), EndTime: ms(1000)},
+			// Concurrent children covering 100ms-400ms
+			{SpanID: "2", Name: "db.query", ParentID: "1", StartTime: ms(100), EndTime: ms(300)},
+			{SpanID: "3", Name: "cache.get", ParentID: "1", StartTime: ms(200), EndTime: ms(400)},
+			{SpanID: "4", Name: "http.call", ParentID: "1", StartTime: ms(900), EndTime: ms(995)},
+			// Grandchild leaving 50ms of db.query untraced
+			{SpanID: "5", Name: "db.execute", ParentID: "2", StartTime: ms(100), EndTime: ms(250)},
+		},
+	}
+
+	analysis := AnalyzeGaps(tr, 10*time.Millisecond)
+
+	if analysis.Duration != "1s" {
+		t.Errorf("Expected duration 1s, got %s", analysis.Duration)
+	}
+	if analysis.UntracedTotal != "655ms" {
+		t.Errorf("Expected 655ms untraced, got %s", analysis.UntracedTotal)
+	}
+
+	if len(analysis.Spans) != 2 {
+		t.Fatalf("Expected 2 spans with children, got %+v", analysis.Spans)
+	}
+	root := analysis.Spans[0]
+	if root.SpanID != "1" || root.Untraced != "605ms" || root.ChildCount != 3 || root.UntracedRatio != 0.605 {
+		t.Errorf("Unexpected root coverage %+v", root)
+	}
+
+	// The 5ms gap at the end of the root span is below the threshold
+	expected := []Gap{
+		{SpanID: "1", SpanName: "GET /orders", StartTime: ms(400), EndTime: ms(900), Duration: "500ms", After: "cache.get", Before: "http.call"},
+		{SpanID: "1", SpanName: "GET /orders", StartTime: ms(0), EndTime: ms(100), Duration: "100ms", Before: "db.query"},
+		{SpanID: "2", SpanName: "db.query", StartTime: ms(250), EndTime: ms(300), Duration: "50ms", After: "db.execute"},
+	}
+	if len(analysis.Gaps) != len(expected) {
+		t.Fatalf("Expected %d gaps, got %+v", len(expected), analysis.Gaps)
+	}
+	for i, want := range expected {
+		if got := analysis.Gaps[i]; got != want {
+			t.Errorf("Gap %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestAnalyzeGaps_NoChildren(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	analysis := AnalyzeGaps(Trace{
+		TraceID: "abc",
+		Spans:   []Span{{SpanID: "1", Name: "root", StartTime: start, EndTime: start.Add(time.Second)}},
+	}, 0)
+
+	if len(analysis.Spans) != 0 || len(analysis.Gaps) != 0 {
+		t.Errorf("Expected no spans or gaps, got %+v", analysis)
+	}
+	if analysis.UntracedTotal != "0s" {
+		t.Errorf("Expected no untraced time, got %s", analysis.UntracedTotal)
+	}
+}
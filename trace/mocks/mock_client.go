@@ -56,11 +56,25 @@ func (mr *MockTraceClientMockRecorder) GetTrace(ctx, req any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrace", reflect.TypeOf((*MockTraceClient)(nil).GetTrace), ctx, req)
 }
 
+// GetTraces mocks base method.
+func (m *MockTraceClient) GetTraces(ctx context.Context, req trace.GetTracesRequest) trace.GetTracesResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTraces", ctx, req)
+	ret0, _ := ret[0].(trace.GetTracesResponse)
+	return ret0
+}
+
+// GetTraces indicates an expected call of GetTraces.
+func (mr *MockTraceClientMockRecorder) GetTraces(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTraces", reflect.TypeOf((*MockTraceClient)(nil).GetTraces), ctx, req)
+}
+
 // ListTraces mocks base method.
-func (m *MockTraceClient) ListTraces(ctx context.Context, req trace.ListTracesRequest) ([]trace.Trace, error) {
+func (m *MockTraceClient) ListTraces(ctx context.Context, req trace.ListTracesRequest) (trace.ListTracesResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListTraces", ctx, req)
-	ret0, _ := ret[0].([]trace.Trace)
+	ret0, _ := ret[0].(trace.ListTracesResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -125,10 +139,10 @@ func (mr *MockTraceClientInterfaceMockRecorder) GetTrace(ctx, req any) *gomock.C
 }
 
 // ListTraces mocks base method.
-func (m *MockTraceClientInterface) ListTraces(ctx context.Context, req trace.ListTracesRequest) ([]trace.Trace, error) {
+func (m *MockTraceClientInterface) ListTraces(ctx context.Context, req trace.ListTracesRequest) (trace.ListTracesResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListTraces", ctx, req)
-	ret0, _ := ret[0].([]trace.Trace)
+	ret0, _ := ret[0].(trace.ListTracesResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
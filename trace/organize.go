@@ -0,0 +1,107 @@
+package trace
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Orders of the traces sorted by SortTraces
+const (
+	SortDurationDesc  = "duration_desc"
+	SortDurationAsc   = "duration_asc"
+	SortStartTimeDesc = "start_time_desc"
+	SortStartTimeAsc  = "start_time_asc"
+)
+
+// TraceSorts lists the orders supported by SortTraces
+var TraceSorts = []string{SortDurationDesc, SortDurationAsc, SortStartTimeDesc, SortStartTimeAsc}
+
+// TraceGroup represents the traces sharing a root span name
+type TraceGroup struct {
+	RootSpanName string  `json:"root_span_name"`
+	Count        int     `json:"count"`
+	MinDuration  string  `json:"min_duration"`
+	MeanDuration string  `json:"mean_duration"`
+	MaxDuration  string  `json:"max_duration"`
+	Traces       []Trace `json:"traces"`
+}
+
+// RootSpan returns the span of t without a parent among its spans
+func (t Trace) RootSpan() (Span, bool) {
+	for _, span := range t.Spans {
+		if span.ParentID == "" || !slices.ContainsFunc(t.Spans, func(s Span) bool { return s.SpanID == span.ParentID }) {
+			return span, true
+		}
+	}
+	return Span{}, false
+}
+
+// Duration returns the latency of the root span of t, which is zero when
+// the spans were not listed
+func (t Trace) Duration() time.Duration {
+	root, _ := t.RootSpan()
+	return root.EndTime.Sub(root.StartTime)
+}
+
+// SortTraces sorts traces in one of TraceSorts by the duration or the start
+// time of their root span. Traces in the same position keep their order.
+func SortTraces(traces []Trace, order string) error {
+	start := func(t Trace) time.Time {
+		root, _ := t.RootSpan()
+		return root.StartTime
+	}
+	var compare func(a, b Trace) int
+	switch order {
+	case SortDurationDesc:
+		compare = func(a, b Trace) int { return cmp.Compare(b.Duration(), a.Duration()) }
+	case SortDurationAsc:
+		compare = func(a, b Trace) int { return cmp.Compare(a.Duration(), b.Duration()) }
+	case SortStartTimeDesc:
+		compare = func(a, b Trace) int { return start(b).Compare(start(a)) }
+	case SortStartTimeAsc:
+		compare = func(a, b Trace) int { return start(a).Compare(start(b)) }
+	default:
+		return fmt.Errorf("unsupported sort %q: must be one of %s", order, strings.Join(TraceSorts, ", "))
+	}
+	slices.SortStableFunc(traces, compare)
+	return nil
+}
+
+// GroupTracesByRootSpan groups traces by the name of their root span, with
+// the largest groups first. The traces of a group keep their order.
+func GroupTracesByRootSpan(traces []Trace) []TraceGroup {
+	var groups []TraceGroup
+	index := make(map[string]int)
+	for _, t := range traces {
+		root, _ := t.RootSpan()
+		i, ok := index[root.Name]
+		if !ok {
+			i = len(groups)
+			index[root.Name] = i
+			groups = append(groups, TraceGroup{RootSpanName: root.Name})
+		}
+		groups[i].Traces = append(groups[i].Traces, t)
+	}
+
+	for i := range groups {
+		group := &groups[i]
+		group.Count = len(group.Traces)
+		var minDuration, maxDuration, total time.Duration
+		for j, t := range group.Traces {
+			d := t.Duration()
+			if j == 0 || d < minDuration {
+				minDuration = d
+			}
+			maxDuration = max(maxDuration, d)
+			total += d
+		}
+		group.MinDuration = minDuration.String()
+		group.MeanDuration = (total / time.Duration(group.Count)).String()
+		group.MaxDuration = maxDuration.String()
+	}
+	slices.SortStableFunc(groups, func(a, b TraceGroup) int { return cmp.Compare(b.Count, a.Count) })
+	return groups
+}
@@ -0,0 +1,78 @@
+package trace
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// organizeTestTraces returns traces with a root span of the given name,
+// start offset, and duration in milliseconds
+func organizeTestTraces() []Trace {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ms := func(n int) time.Time { return start.Add(time.Duration(n) * time.Millisecond) }
+	newTrace := func(id, name string, offset, duration int) Trace {
+		return Trace{TraceID: id, Spans: []Span{
+			{SpanID: "1", Name: name, StartTime: ms(offset), EndTime: ms(offset + duration)},
+			{SpanID: "2", Name: "db.query", ParentID: "1", StartTime: ms(offset), EndTime: ms(offset + 1)},
+		}}
+	}
+	return []Trace{
+		newTrace("a", "GET /orders", 0, 100),
+		newTrace("b", "GET /users", 10, 300),
+		newTrace("c", "GET /orders", 20, 200),
+		newTrace("d", "GET /orders", 30, 600),
+	}
+}
+
+func TestSortTraces(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{order: SortDurationDesc, want: []string{"d", "b", "c", "a"}},
+		{order: SortDurationAsc, want: []string{"a", "c", "b", "d"}},
+		{order: SortStartTimeDesc, want: []string{"d", "c", "b", "a"}},
+		{order: SortStartTimeAsc, want: []string{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			traces := organizeTestTraces()
+			if err := SortTraces(traces, tt.order); err != nil {
+				t.Fatalf("SortTraces() error = %v", err)
+			}
+			var got []string
+			for _, tr := range traces {
+				got = append(got, tr.TraceID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortTraces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := SortTraces(organizeTestTraces(), "latency"); err == nil {
+		t.Error("Expected an error for an unsupported order")
+	}
+}
+
+func TestGroupTracesByRootSpan(t *testing.T) {
+	groups := GroupTracesByRootSpan(organizeTestTraces())
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %+v", groups)
+	}
+
+	orders := groups[0]
+	if orders.RootSpanName != "GET /orders" || orders.Count != 3 {
+		t.Errorf("Expected 3 traces of GET /orders first, got %s with %d", orders.RootSpanName, orders.Count)
+	}
+	if orders.MinDuration != "100ms" || orders.MeanDuration != "300ms" || orders.MaxDuration != "600ms" {
+		t.Errorf("Expected durations 100ms, 300ms, 600ms, got %s, %s, %s", orders.MinDuration, orders.MeanDuration, orders.MaxDuration)
+	}
+	if orders.Traces[0].TraceID != "a" || orders.Traces[2].TraceID != "d" {
+		t.Errorf("Expected the traces to keep their order, got %+v", orders.Traces)
+	}
+	if groups[1].RootSpanName != "GET /users" || groups[1].Count != 1 {
+		t.Errorf("Expected 1 trace of GET /users, got %s with %d", groups[1].RootSpanName, groups[1].Count)
+	}
+}
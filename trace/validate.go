@@ -0,0 +1,138 @@
+package trace
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// traceIDPattern matches a trace ID of 32 lowercase hexadecimal characters
+var traceIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// SpanKinds lists the kinds of spans, which default to UNSPECIFIED
+var SpanKinds = []string{"UNSPECIFIED", "RPC_SERVER", "RPC_CLIENT"}
+
+// ValidationProblem represents a single problem found in a patch request
+type ValidationProblem struct {
+	Field   string `json:"field"` // e.g. "trace_id" or "spans[2].parent_id"
+	SpanID  string `json:"span_id,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a patch request is rejected before being sent to the API
+type ValidationError struct {
+	Problems []ValidationProblem `json:"problems"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		if p.SpanID != "" {
+			problems = append(problems, fmt.Sprintf("%s (span %s): %s", p.Field, p.SpanID, p.Message))
+		} else {
+			problems = append(problems, fmt.Sprintf("%s: %s", p.Field, p.Message))
+		}
+	}
+	return fmt.Sprintf("invalid trace patch: %s", strings.Join(problems, "; "))
+}
+
+// Validate checks the trace ID format and the consistency of the spans:
+// every span needs an ID, a name, and a start time not after its end time,
+// kinds must be among SpanKinds,
+// parents must be among the patched spans (unless AllowMissingParents is
+// set) without forming a cycle, and
+// children must start within their parent. All problems are reported at once.
+func (r PatchTraceRequest) Validate() error {
+	var problems []ValidationProblem
+	addProblem := func(field, spanID, format string, args ...any) {
+		problems = append(problems, ValidationProblem{Field: field, SpanID: spanID, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !traceIDPattern.MatchString(r.TraceID) {
+		addProblem("trace_id", "", "must be 32 lowercase hexadecimal characters, got %q", r.TraceID)
+	}
+	if len(r.Spans) == 0 {
+		addProblem("spans", "", "at least one span is required")
+	}
+
+	spans := make(map[string]Span, len(r.Spans))
+	for i, span := range r.Spans {
+		if span.SpanID == "" {
+			addProblem(fmt.Sprintf("spans[%d].span_id", i), "", "is required")
+			continue
+		}
+		if _, ok := spans[span.SpanID]; ok {
+			addProblem(fmt.Sprintf("spans[%d].span_id", i), span.SpanID, "is used by more than one span")
+			continue
+		}
+		spans[span.SpanID] = span
+	}
+
+	for i, span := range r.Spans {
+		field := func(name string) string { return fmt.Sprintf("spans[%d].%s", i, name) }
+
+		if span.Name == "" {
+			addProblem(field("name"), span.SpanID, "is required")
+		}
+		if span.StartTime.IsZero() {
+			addProblem(field("start_time"), span.SpanID, "is required")
+		}
+		if span.EndTime.IsZero() {
+			addProblem(field("end_time"), span.SpanID, "is required")
+		}
+		if !span.StartTime.IsZero() && !span.EndTime.IsZero() && span.EndTime.Before(span.StartTime) {
+			addProblem(field("end_time"), span.SpanID, "%s is before start_time %s", span.EndTime.Format(time.RFC3339Nano), span.StartTime.Format(time.RFC3339Nano))
+		}
+		if span.Kind != "" && !slices.Contains(SpanKinds, span.Kind) {
+			addProblem(field("kind"), span.SpanID, "must be one of %s, got %q", strings.Join(SpanKinds, ", "), span.Kind)
+		}
+
+		if span.ParentID == "" {
+			continue
+		}
+		if span.ParentID == span.SpanID {
+			addProblem(field("parent_id"), span.SpanID, "span cannot be its own parent")
+			continue
+		}
+		parent, ok := spans[span.ParentID]
+		if !ok && r.AllowMissingParents {
+			continue
+		}
+		if !ok {
+			addProblem(field("parent_id"), span.SpanID, "parent span %q is not in the patch", span.ParentID)
+			continue
+		}
+		if hasCycle(spans, span.SpanID) {
+			addProblem(field("parent_id"), span.SpanID, "parent chain forms a cycle")
+			continue
+		}
+		if span.StartTime.IsZero() || parent.StartTime.IsZero() {
+			continue
+		}
+		if span.StartTime.Before(parent.StartTime) {
+			addProblem(field("start_time"), span.SpanID, "starts before its parent %q starts at %s", parent.SpanID, parent.StartTime.Format(time.RFC3339Nano))
+		} else if !parent.EndTime.IsZero() && span.StartTime.After(parent.EndTime) {
+			addProblem(field("start_time"), span.SpanID, "starts after its parent %q ends at %s", parent.SpanID, parent.EndTime.Format(time.RFC3339Nano))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// hasCycle reports whether following parents from the span leads back to it
+func hasCycle(spans map[string]Span, spanID string) bool {
+	visited := map[string]bool{spanID: true}
+	for current := spans[spanID].ParentID; current != ""; current = spans[current].ParentID {
+		if visited[current] {
+			return true
+		}
+		visited[current] = true
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package trace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPatchTraceRequest_Validate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	traceID := "0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name   string
+		req    PatchTraceRequest
+		fields []string // fields of the expected problems, in order
+	}{
+		{
+			name: "valid",
+			req: PatchTraceRequest{
+				TraceID: traceID,
+				Spans: []Span{
+					{SpanID: "1", Name: "root", StartTime: start, EndTime: start.Add(time.Second)},
+					{SpanID: "2", ParentID: "1", Name: "child", StartTime: start.Add(100 * time.Millisecond), EndTime: start.Add(2 * time.Second)},
+				},
+			},
+		},
+		{
+			name:   "invalid trace ID and no spans",
+			req:    PatchTraceRequest{TraceID: "0123456789ABCDEF0123456789ABCDEF"},
+			fields: []string{"trace_id", "spans"},
+		},
+		{
+			name: "missing fields",
+			req: PatchTraceRequest{
+				TraceID: traceID,
+				Spans:   []Span{{}},
+			},
+			fields: []string{"spans[0].span_id", "spans[0].name", "spans[0].start_time", "spans[0].end_time"},
+		},
+		{
+			name: "end before start and duplicate ID",
+			req: PatchTraceRequest{
+				TraceID: traceID,
+				Spans: []Span{
+					{SpanID: "1", Name: "root", StartTime: start, EndTime: start.Add(-time.Second)},
+					{SpanID: "1", Name: "other", StartTime: start, EndTime: start},
+				},
+			},
+			fields: []string{"spans[1].span_id", "spans[0].end_time"},
+		},
+		{
+			name: "unknown kind",
+			req: PatchTraceRequest{
+				TraceID: traceID,
+				Spans: []Span{
+					{SpanID: "1", Name: "root", Kind: "RPC_SERVER", StartTime: start, EndTime: start},
+					{SpanID: "2", ParentID: "1", Name: "child", Kind: "CLIENT", StartTime: start, EndTime: start},
+				},
+			},
+			fields: []string{"spans[1].kind"},
+		},
+		{
+			name: "unknown parent and self parent",
+			req: PatchTraceRequest{
+				TraceID: traceID,
+				Spans: []Span{
+					{SpanID: "1", ParentID: "missing", Name: "a", StartTime: start, EndTime: start},
+					{SpanID: "2", ParentID: "2", Name: "b", StartTime: start, EndTime: start},
+				},
+			},
+			fields: []string{"spans[0].parent_id", "spans[1].parent_id"},
+		},
+		{
+			name: "missing parent allowed",
+			req: PatchTraceRequest{
+				TraceID: traceID,
+				Spans: []Span{
+					{SpanID: "1", ParentID: "earlier", Name: "a", StartTime: start, EndTime: start},
+				},
+				AllowMissingParents: true,
+			},
+		},
+		{
+			name: "cycle",
+			req: PatchTraceRequest{
+				TraceID: traceID,
+				Spans: []Span{
+					{SpanID: "1", ParentID: "2", Name: "a", StartTime: start, EndTime: start},
+					{SpanID: "2", ParentID: "1", Name: "b", StartTime: start, EndTime: start},
+				},
+			},
+			fields: []string{"spans[0].parent_id", "spans[1].parent_id"},
+		},
+		{
+			name: "child outside parent",
+			req: PatchTraceRequest{
+				TraceID: traceID,
+				Spans: []Span{
+					{SpanID: "1", Name: "root", StartTime: start, EndTime: start.Add(time.Second)},
+					{SpanID: "2", ParentID: "1", Name: "early", StartTime: start.Add(-time.Millisecond), EndTime: start},
+					{SpanID: "3", ParentID: "1", Name: "late", StartTime: start.Add(2 * time.Second), EndTime: start.Add(3 * time.Second)},
+				},
+			},
+			fields: []string{"spans[1].start_time", "spans[2].start_time"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			validationErr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if len(validationErr.Problems) != len(tt.fields) {
+				t.Fatalf("Expected %d problems, got %+v", len(tt.fields), validationErr.Problems)
+			}
+			for i, field := range tt.fields {
+				if validationErr.Problems[i].Field != field {
+					t.Errorf("Problem %d: expected field %s, got %+v", i, field, validationErr.Problems[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+)
+
+const (
+	// logLookback is how far before the previous poll each poll reads, so
+	// that entries ingested with a delay are still seen
+	logLookback = 2 * time.Minute
+	// maxLogEntriesPerPoll bounds the number of entries read by one poll
+	maxLogEntriesPerPoll = 100
+	// defaultLogSamples is the number of entries included in an event by default
+	defaultLogSamples = 5
+)
+
+// LogWatchRequest represents a request to watch for new log entries
+type LogWatchRequest struct {
+	ProjectID string        `json:"project_id,omitempty"` // defaults to the client's project
+	Filter    string        `json:"filter"`
+	Pattern   string        `json:"pattern,omitempty"`  // regular expression the message or payload must match
+	Interval  time.Duration `json:"interval,omitempty"` // defaults to DefaultInterval
+	Samples   int           `json:"samples,omitempty"`  // entries included in each event, defaults to defaultLogSamples
+	Webhook   string        `json:"webhook,omitempty"`  // URL the events are also posted to, see Webhooks
+}
+
+// LogMatch is the data of an event raised when new log entries match
+type LogMatch struct {
+	Count   int                `json:"count"`
+	Entries []logging.LogEntry `json:"entries"` // the first entries, oldest first
+}
+
+// logChecker raises an event when entries written after the watch started
+// match the filter and pattern
+type logChecker struct {
+	client    logging.LoggingClient
+	req       LogWatchRequest
+	pattern   *regexp.Regexp
+	startedAt time.Time
+	lastPoll  time.Time
+	seen      map[string]time.Time // entry keys by timestamp, to skip entries read by an earlier poll
+}
+
+// WatchLogs starts polling log entries matching the filter and notifies the
+// session whenever new entries appear
+func (m *Manager) WatchLogs(sessionID string, client logging.LoggingClient, req LogWatchRequest) (Info, error) {
+	if req.Filter == "" {
+		return Info{}, fmt.Errorf("filter is required")
+	}
+	var pattern *regexp.Regexp
+	if req.Pattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(req.Pattern); err != nil {
+			return Info{}, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	interval, err := validInterval(req.Interval)
+	if err != nil {
+		return Info{}, err
+	}
+	req.Interval = interval
+	if req.Samples <= 0 {
+		req.Samples = defaultLogSamples
+	}
+
+	description := fmt.Sprintf("%s every %s", req.Filter, interval)
+	if req.Pattern != "" {
+		description = fmt.Sprintf("%s matching /%s/ every %s", req.Filter, req.Pattern, interval)
+	}
+	now := time.Now()
+	return m.start(sessionID, KindLogs, description, req.Webhook, interval, &logChecker{
+		client:    client,
+		req:       req,
+		pattern:   pattern,
+		startedAt: now,
+		lastPoll:  now,
+		seen:      make(map[string]time.Time),
+	})
+}
+
+// check reads the entries written since the previous poll
+func (c *logChecker) check(ctx context.Context, now time.Time) ([]Event, error) {
+	since := c.lastPoll.Add(-logLookback)
+	if since.Before(c.startedAt) {
+		since = c.startedAt
+	}
+	resp, err := c.client.ListEntries(ctx, logging.ListEntriesRequest{
+		ProjectID: c.req.ProjectID,
+		Filter:    fmt.Sprintf("(%s) AND timestamp>=%q", c.req.Filter, since.UTC().Format(time.RFC3339Nano)),
+		OrderBy:   "timestamp asc",
+		Limit:     maxLogEntriesPerPoll,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log entries: %w", err)
+	}
+	c.lastPoll = now
+
+	match := LogMatch{Entries: []logging.LogEntry{}}
+	for _, entry := range resp.Entries {
+		key := entryKey(entry)
+		if _, ok := c.seen[key]; ok {
+			continue
+		}
+		c.seen[key] = entry.Timestamp
+		if c.pattern != nil && !c.matches(entry) {
+			continue
+		}
+		match.Count++
+		if len(match.Entries) < c.req.Samples {
+			match.Entries = append(match.Entries, entry)
+		}
+	}
+
+	// Entries older than the next poll's time range cannot be read again
+	for key, ts := range c.seen {
+		if ts.Before(now.Add(-logLookback)) {
+			delete(c.seen, key)
+		}
+	}
+
+	if match.Count == 0 {
+		return nil, nil
+	}
+	message := fmt.Sprintf("%d new log entries match %s", match.Count, c.req.Filter)
+	if first := match.Entries[0]; first.Message != "" {
+		message += ": " + first.Message
+	}
+	return []Event{{
+		Level:   LevelWarning,
+		Time:    match.Entries[0].Timestamp,
+		Message: message,
+		Data:    match,
+	}}, nil
+}
+
+// matches reports whether the entry's message or payload matches the pattern
+func (c *logChecker) matches(entry logging.LogEntry) bool {
+	if c.pattern.MatchString(entry.Message) {
+		return true
+	}
+	if entry.Payload == nil {
+		return false
+	}
+	payload, err := json.Marshal(entry.Payload)
+	return err == nil && c.pattern.Match(payload)
+}
+
+// entryKey identifies a log entry, falling back to its timestamp and message
+// when it has no insert ID
+func entryKey(entry logging.LogEntry) string {
+	if entry.InsertID != "" {
+		return entry.InsertID
+	}
+	return entry.Timestamp.Format(time.RFC3339Nano) + " " + entry.Message
+}
@@ -0,0 +1,159 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+)
+
+// Metric comparisons
+const (
+	ComparisonAbove = "above"
+	ComparisonBelow = "below"
+)
+
+// minMetricLookback is the shortest time range read by each poll, so that
+// points ingested with a delay are still seen
+const minMetricLookback = 5 * time.Minute
+
+// MetricWatchRequest represents a request to watch time series for threshold breaches
+type MetricWatchRequest struct {
+	ProjectID   string                        `json:"project_id,omitempty"` // defaults to the client's project
+	Filter      string                        `json:"filter"`
+	Comparison  string                        `json:"comparison,omitempty"` // defaults to ComparisonAbove
+	Threshold   float64                       `json:"threshold"`
+	Interval    time.Duration                 `json:"interval,omitempty"` // defaults to DefaultInterval
+	Aggregation *monitoring.AggregationConfig `json:"aggregation,omitempty"`
+	Webhook     string                        `json:"webhook,omitempty"` // URL the events are also posted to, see Webhooks
+}
+
+// MetricBreach is the data of an event raised when a time series crosses the threshold
+type MetricBreach struct {
+	MetricType     string            `json:"metric_type"`
+	MetricLabels   map[string]string `json:"metric_labels,omitempty"`
+	ResourceType   string            `json:"resource_type"`
+	ResourceLabels map[string]string `json:"resource_labels,omitempty"`
+	Value          float64           `json:"value"`
+	Timestamp      time.Time         `json:"timestamp"`
+	Comparison     string            `json:"comparison"`
+	Threshold      float64           `json:"threshold"`
+	Breached       bool              `json:"breached"` // false when the series recovered
+}
+
+// metricChecker raises an event when the latest point of a time series
+// crosses the threshold, and again when it recovers
+type metricChecker struct {
+	client   monitoring.MonitoringClient
+	req      MetricWatchRequest
+	breached map[string]bool // by series key
+}
+
+// WatchMetric starts polling time series matching the filter and notifies the
+// session whenever a series breaches the threshold or recovers
+func (m *Manager) WatchMetric(sessionID string, client monitoring.MonitoringClient, req MetricWatchRequest) (Info, error) {
+	if req.Filter == "" {
+		return Info{}, fmt.Errorf("filter is required")
+	}
+	if req.Comparison == "" {
+		req.Comparison = ComparisonAbove
+	}
+	if req.Comparison != ComparisonAbove && req.Comparison != ComparisonBelow {
+		return Info{}, fmt.Errorf("unsupported comparison %q: use %q or %q", req.Comparison, ComparisonAbove, ComparisonBelow)
+	}
+	interval, err := validInterval(req.Interval)
+	if err != nil {
+		return Info{}, err
+	}
+	req.Interval = interval
+
+	description := fmt.Sprintf("%s %s %g every %s", req.Filter, req.Comparison, req.Threshold, interval)
+	return m.start(sessionID, KindMetric, description, req.Webhook, interval, &metricChecker{
+		client:   client,
+		req:      req,
+		breached: make(map[string]bool),
+	})
+}
+
+// check reads the latest point of each series and compares it to the threshold
+func (c *metricChecker) check(ctx context.Context, now time.Time) ([]Event, error) {
+	listReq := monitoring.ListTimeSeriesRequest{
+		ProjectID:   c.req.ProjectID,
+		Filter:      c.req.Filter,
+		Aggregation: c.req.Aggregation,
+	}
+	listReq.Interval.StartTime = now.Add(-max(c.req.Interval, minMetricLookback))
+	listReq.Interval.EndTime = now
+
+	resp, err := c.client.ListTimeSeries(ctx, listReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list time series: %w", err)
+	}
+
+	var events []Event
+	for _, ts := range resp.TimeSeries {
+		if len(ts.Values) == 0 {
+			continue
+		}
+		latest := ts.Values[0]
+		for _, v := range ts.Values[1:] {
+			if v.Timestamp.After(latest.Timestamp) {
+				latest = v
+			}
+		}
+
+		breached := latest.Value > c.req.Threshold
+		if c.req.Comparison == ComparisonBelow {
+			breached = latest.Value < c.req.Threshold
+		}
+		key := seriesKey(ts)
+		if breached == c.breached[key] {
+			continue
+		}
+		c.breached[key] = breached
+
+		event := Event{
+			Level: LevelWarning,
+			Time:  latest.Timestamp,
+			Message: fmt.Sprintf("%s is %g, %s the threshold of %g",
+				key, latest.Value, c.req.Comparison, c.req.Threshold),
+			Data: MetricBreach{
+				MetricType:     ts.MetricType,
+				MetricLabels:   ts.MetricLabels,
+				ResourceType:   ts.ResourceType,
+				ResourceLabels: ts.ResourceLabels,
+				Value:          latest.Value,
+				Timestamp:      latest.Timestamp,
+				Comparison:     c.req.Comparison,
+				Threshold:      c.req.Threshold,
+				Breached:       breached,
+			},
+		}
+		if !breached {
+			event.Level = LevelInfo
+			event.Message = fmt.Sprintf("%s recovered at %g, no longer %s the threshold of %g",
+				key, latest.Value, c.req.Comparison, c.req.Threshold)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// seriesKey identifies a time series by its metric and resource labels
+func seriesKey(ts monitoring.TimeSeriesData) string {
+	return fmt.Sprintf("%s{%s} on %s{%s}",
+		ts.MetricType, formatLabels(ts.MetricLabels), ts.ResourceType, formatLabels(ts.ResourceLabels))
+}
+
+// formatLabels formats labels as sorted key=value pairs
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return strings.Join(pairs, ",")
+}
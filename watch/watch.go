@@ -0,0 +1,258 @@
+// Package watch runs background pollers registered during an MCP session and
+// notifies the session when they detect something, and optionally posts to a
+// webhook, until they are stopped or the session ends.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultInterval is the polling interval used when none is given
+	DefaultInterval = time.Minute
+	// MinInterval is the shortest polling interval, to stay within API quotas
+	MinInterval = 10 * time.Second
+	// MaxWatchesPerSession bounds the number of watches a session can run
+	MaxWatchesPerSession = 10
+)
+
+// Watch kinds
+const (
+	KindMetric = "metric"
+	KindLogs   = "logs"
+)
+
+// Event levels
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+)
+
+// Event represents something detected by a watch
+type Event struct {
+	WatchID string    `json:"watch_id"`
+	Kind    string    `json:"kind"`
+	Level   string    `json:"level"` // LevelInfo or LevelWarning
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	Data    any       `json:"data,omitempty"`
+}
+
+// Notifier delivers an event to the session that registered the watch
+type Notifier func(sessionID string, event Event)
+
+// Info describes a running watch
+type Info struct {
+	ID          string        `json:"id"`
+	Kind        string        `json:"kind"`
+	Description string        `json:"description"`
+	Interval    time.Duration `json:"-"`
+	CreatedAt   time.Time     `json:"created_at"`
+	LastCheck   time.Time     `json:"last_check,omitzero"`
+	LastError   string        `json:"last_error,omitempty"`
+	Events      int           `json:"events"`
+	// Webhook is the host of the webhook the events are posted to, and
+	// WebhookError the error of the last post
+	Webhook      string `json:"webhook,omitempty"`
+	WebhookError string `json:"webhook_error,omitempty"`
+}
+
+// MarshalJSON renders the interval as a duration string
+func (i Info) MarshalJSON() ([]byte, error) {
+	type alias Info
+	return json.Marshal(struct {
+		alias
+		Interval string `json:"interval"`
+	}{alias(i), i.Interval.String()})
+}
+
+// checker polls once and returns the events detected since the previous poll
+type checker interface {
+	check(ctx context.Context, now time.Time) ([]Event, error)
+}
+
+// watch is a running watch
+type watch struct {
+	info    Info
+	checker checker
+	webhook string // URL the events are posted to, if any
+	cancel  context.CancelFunc
+}
+
+// Manager keeps the watches of each MCP session
+type Manager struct {
+	notify   Notifier
+	webhooks *Webhooks
+
+	mu      sync.Mutex
+	watches map[string]map[string]*watch // by session ID and watch ID
+	nextID  int
+}
+
+// NewManager creates a Manager delivering events with notify, and posting
+// them with webhooks to the webhooks of the watches. Watches cannot have
+// webhooks when webhooks is nil.
+func NewManager(notify Notifier, webhooks *Webhooks) *Manager {
+	return &Manager{
+		notify:   notify,
+		webhooks: webhooks,
+		watches:  make(map[string]map[string]*watch),
+	}
+}
+
+// start registers a watch for the session and starts polling in the
+// background. Its events are also posted to webhook when it is not empty.
+func (m *Manager) start(sessionID, kind, description, webhook string, interval time.Duration, c checker) (Info, error) {
+	if webhook != "" {
+		if m.webhooks == nil {
+			return Info{}, fmt.Errorf("webhooks are not enabled on this server")
+		}
+		if err := m.webhooks.Validate(webhook); err != nil {
+			return Info{}, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.watches[sessionID]) >= MaxWatchesPerSession {
+		return Info{}, fmt.Errorf("a session can run at most %d watches; stop one with stop_watch first", MaxWatchesPerSession)
+	}
+
+	m.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &watch{
+		info: Info{
+			ID:          fmt.Sprintf("%s-%d", kind, m.nextID),
+			Kind:        kind,
+			Description: description,
+			Interval:    interval,
+			CreatedAt:   time.Now(),
+			Webhook:     webhookHost(webhook),
+		},
+		checker: c,
+		webhook: webhook,
+		cancel:  cancel,
+	}
+	if m.watches[sessionID] == nil {
+		m.watches[sessionID] = make(map[string]*watch)
+	}
+	m.watches[sessionID][w.info.ID] = w
+
+	go m.run(ctx, sessionID, w)
+	return w.info, nil
+}
+
+// run polls until the watch is stopped, starting immediately
+func (m *Manager) run(ctx context.Context, sessionID string, w *watch) {
+	ticker := time.NewTicker(w.info.Interval)
+	defer ticker.Stop()
+	for {
+		m.poll(ctx, sessionID, w, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks the watch once and delivers the events it detected
+func (m *Manager) poll(ctx context.Context, sessionID string, w *watch, now time.Time) {
+	events, err := w.checker.check(ctx, now)
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	w.info.LastCheck = now
+	w.info.LastError = ""
+	if err != nil {
+		w.info.LastError = err.Error()
+	}
+	w.info.Events += len(events)
+	m.mu.Unlock()
+
+	var webhookErrs []error
+	for _, event := range events {
+		event.WatchID = w.info.ID
+		event.Kind = w.info.Kind
+		m.notify(sessionID, event)
+		if w.webhook != "" {
+			if err := m.webhooks.Post(ctx, w.webhook, event); err != nil {
+				webhookErrs = append(webhookErrs, err)
+			}
+		}
+	}
+	if len(events) == 0 || w.webhook == "" {
+		return
+	}
+
+	m.mu.Lock()
+	w.info.WebhookError = ""
+	if err := errors.Join(webhookErrs...); err != nil {
+		w.info.WebhookError = err.Error()
+	}
+	m.mu.Unlock()
+}
+
+// List returns the watches of the session, oldest first
+func (m *Manager) List(sessionID string) []Info {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := []Info{}
+	for _, w := range m.watches[sessionID] {
+		infos = append(infos, w.info)
+	}
+	slices.SortFunc(infos, func(a, b Info) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return infos
+}
+
+// Stop stops a watch of the session
+func (m *Manager) Stop(sessionID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.watches[sessionID][id]
+	if !ok {
+		return fmt.Errorf("watch %q not found", id)
+	}
+	w.cancel()
+	delete(m.watches[sessionID], id)
+	return nil
+}
+
+// StopSession stops all watches of the session
+func (m *Manager) StopSession(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, w := range m.watches[sessionID] {
+		w.cancel()
+	}
+	delete(m.watches, sessionID)
+}
+
+// validInterval returns the interval to poll at, applying the default and minimum
+func validInterval(interval time.Duration) (time.Duration, error) {
+	if interval == 0 {
+		return DefaultInterval, nil
+	}
+	if interval < MinInterval {
+		return 0, fmt.Errorf("interval must be at least %s", MinInterval)
+	}
+	return interval, nil
+}
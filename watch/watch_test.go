@@ -0,0 +1,307 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitagry/gcp-telemetry-mcp/logging"
+	loggingmocks "github.com/kitagry/gcp-telemetry-mcp/logging/mocks"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring"
+	"github.com/kitagry/gcp-telemetry-mcp/monitoring/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeChecker returns one event on every check
+type fakeChecker struct{}
+
+func (fakeChecker) check(ctx context.Context, now time.Time) ([]Event, error) {
+	return []Event{{Level: LevelInfo, Time: now, Message: "checked"}}, nil
+}
+
+func TestManager(t *testing.T) {
+	events := make(chan Event, 10)
+	m := NewManager(func(sessionID string, event Event) {
+		if sessionID != "session-1" {
+			t.Errorf("Expected events for session-1, got %s", sessionID)
+		}
+		events <- event
+	}, nil)
+
+	info, err := m.start("session-1", KindMetric, "test", "", MinInterval, fakeChecker{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The first check runs immediately
+	select {
+	case event := <-events:
+		if event.WatchID != info.ID || event.Kind != KindMetric || event.Message != "checked" {
+			t.Errorf("Unexpected event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an event from the first check")
+	}
+
+	if infos := m.List("session-1"); len(infos) != 1 || infos[0].ID != info.ID {
+		t.Errorf("Expected the watch to be listed, got %+v", infos)
+	}
+	if infos := m.List("session-2"); len(infos) != 0 {
+		t.Errorf("Expected no watches for another session, got %+v", infos)
+	}
+	if err := m.Stop("session-2", info.ID); err == nil {
+		t.Error("Expected error stopping the watch of another session")
+	}
+	if err := m.Stop("session-1", info.ID); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if infos := m.List("session-1"); len(infos) != 0 {
+		t.Errorf("Expected no watches after stopping, got %+v", infos)
+	}
+}
+
+func TestManager_MaxWatches(t *testing.T) {
+	m := NewManager(func(string, Event) {}, nil)
+	defer m.StopSession("session-1")
+
+	for i := 0; i < MaxWatchesPerSession; i++ {
+		if _, err := m.start("session-1", KindMetric, "test", "", time.Hour, fakeChecker{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := m.start("session-1", KindMetric, "test", "", time.Hour, fakeChecker{}); err == nil {
+		t.Error("Expected error when exceeding the maximum number of watches")
+	}
+}
+
+func TestManager_Webhook(t *testing.T) {
+	payloads := make(chan WebhookPayload, 10)
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		payloads <- payload
+	}))
+	defer ts.Close()
+
+	m := NewManager(func(string, Event) {}, NewWebhooks(ts.Client(), nil))
+	defer m.StopSession("session-1")
+	info, err := m.start("session-1", KindMetric, "test", ts.URL+"/services/T000/B000/secret", MinInterval, fakeChecker{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(info.Webhook, "secret") || info.Webhook != strings.TrimPrefix(ts.URL, "https://") {
+		t.Errorf("Expected the webhook host only, got %q", info.Webhook)
+	}
+
+	select {
+	case payload := <-payloads:
+		if payload.Text != ":information_source: *Watch "+info.ID+"* (metric): checked" || payload.Event.WatchID != info.ID {
+			t.Errorf("Unexpected payload %+v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the event to be posted to the webhook")
+	}
+}
+
+func TestWebhooks_Validate(t *testing.T) {
+	tests := []struct {
+		webhooks *Webhooks
+		url      string
+		wantErr  bool
+	}{
+		{NewWebhooks(nil, nil), "https://hooks.slack.com/services/T000/B000/XXX", false},
+		{NewWebhooks(nil, nil), "http://hooks.slack.com/services/T000/B000/XXX", true},
+		{NewWebhooks(nil, nil), "hooks.slack.com/services", true},
+		{NewWebhooks(nil, []string{"hooks.slack.com"}), "https://HOOKS.slack.com/services/T000", false},
+		{NewWebhooks(nil, []string{"hooks.slack.com"}), "https://example.com/hook", true},
+	}
+	for _, tt := range tests {
+		if err := tt.webhooks.Validate(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+
+	m := NewManager(func(string, Event) {}, nil)
+	if _, err := m.start("session-1", KindMetric, "test", "https://hooks.slack.com/services/T000", time.Hour, fakeChecker{}); err == nil {
+		t.Error("Expected error for a webhook without webhooks enabled")
+	}
+}
+
+func TestWatchMetric_Invalid(t *testing.T) {
+	m := NewManager(func(string, Event) {}, nil)
+	tests := []MetricWatchRequest{
+		{},
+		{Filter: `metric.type="a"`, Comparison: "equal"},
+		{Filter: `metric.type="a"`, Interval: time.Second},
+	}
+	for _, req := range tests {
+		if _, err := m.WatchMetric("session-1", nil, req); err == nil {
+			t.Errorf("Expected error for %+v", req)
+		}
+	}
+}
+
+func TestMetricChecker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	series := func(values ...float64) monitoring.ListTimeSeriesResponse {
+		ts := monitoring.TimeSeriesData{
+			MetricType:     "compute.googleapis.com/instance/cpu/utilization",
+			ResourceType:   "gce_instance",
+			ResourceLabels: map[string]string{"zone": "us-central1-a", "instance_id": "1"},
+		}
+		for i, v := range values {
+			ts.Values = append(ts.Values, monitoring.MetricValue{Value: v, Timestamp: now.Add(-time.Duration(i) * time.Minute)})
+		}
+		return monitoring.ListTimeSeriesResponse{TimeSeries: []monitoring.TimeSeriesData{ts}}
+	}
+
+	client := mocks.NewMockMonitoringClient(ctrl)
+	gomock.InOrder(
+		client.EXPECT().ListTimeSeries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req monitoring.ListTimeSeriesRequest) (monitoring.ListTimeSeriesResponse, error) {
+				if !req.Interval.StartTime.Equal(now.Add(-minMetricLookback)) || !req.Interval.EndTime.Equal(now) {
+					t.Errorf("Unexpected interval %+v", req.Interval)
+				}
+				return series(0.5, 0.95), nil
+			}),
+		// Values are newest first, so the latest point breaches the threshold
+		client.EXPECT().ListTimeSeries(gomock.Any(), gomock.Any()).Return(series(0.95, 0.5), nil),
+		client.EXPECT().ListTimeSeries(gomock.Any(), gomock.Any()).Return(series(0.97), nil),
+		client.EXPECT().ListTimeSeries(gomock.Any(), gomock.Any()).Return(series(0.4), nil),
+	)
+
+	c := &metricChecker{
+		client:   client,
+		req:      MetricWatchRequest{Filter: `metric.type="compute.googleapis.com/instance/cpu/utilization"`, Comparison: ComparisonAbove, Threshold: 0.9, Interval: time.Minute},
+		breached: make(map[string]bool),
+	}
+
+	var counts []int
+	var all []Event
+	for range 4 {
+		events, err := c.check(context.Background(), now)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		counts = append(counts, len(events))
+		all = append(all, events...)
+	}
+
+	// Only the breach and the recovery raise events
+	if counts[0] != 0 || counts[1] != 1 || counts[2] != 0 || counts[3] != 1 {
+		t.Fatalf("Expected events on the breach and recovery only, got %v", counts)
+	}
+	breach := all[0].Data.(MetricBreach)
+	if all[0].Level != LevelWarning || !breach.Breached || breach.Value != 0.95 {
+		t.Errorf("Unexpected breach event %+v", all[0])
+	}
+	wantMessage := "compute.googleapis.com/instance/cpu/utilization{} on gce_instance{instance_id=1,zone=us-central1-a} is 0.95, above the threshold of 0.9"
+	if all[0].Message != wantMessage {
+		t.Errorf("Expected message %q, got %q", wantMessage, all[0].Message)
+	}
+	if recovery := all[1].Data.(MetricBreach); all[1].Level != LevelInfo || recovery.Breached {
+		t.Errorf("Unexpected recovery event %+v", all[1])
+	}
+}
+
+func TestWatchLogs_Invalid(t *testing.T) {
+	m := NewManager(func(string, Event) {}, nil)
+	tests := []LogWatchRequest{
+		{},
+		{Filter: `severity>=ERROR`, Pattern: "("},
+		{Filter: `severity>=ERROR`, Interval: time.Second},
+	}
+	for _, req := range tests {
+		if _, err := m.WatchLogs("session-1", nil, req); err == nil {
+			t.Errorf("Expected error for %+v", req)
+		}
+	}
+}
+
+func TestLogChecker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	entry := func(id string, offset time.Duration, message string) logging.LogEntry {
+		return logging.LogEntry{InsertID: id, Timestamp: start.Add(offset), Severity: "ERROR", Message: message}
+	}
+
+	client := loggingmocks.NewMockLoggingClient(ctrl)
+	gomock.InOrder(
+		client.EXPECT().ListEntries(gomock.Any(), logging.ListEntriesRequest{
+			Filter:  `(severity>=ERROR) AND timestamp>="2024-01-01T12:00:00Z"`,
+			OrderBy: "timestamp asc",
+			Limit:   maxLogEntriesPerPoll,
+		}).Return(logging.ListEntriesResponse{Entries: []logging.LogEntry{
+			entry("a", 10*time.Second, "payment failed: card declined"),
+			entry("b", 20*time.Second, "cache miss"),
+		}}, nil),
+		// The second poll reads again from 2 minutes before the first poll, which
+		// is clamped to the start of the watch, and skips the entries already seen
+		client.EXPECT().ListEntries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+				if req.Filter != `(severity>=ERROR) AND timestamp>="2024-01-01T12:00:00Z"` {
+					t.Errorf("Unexpected filter %s", req.Filter)
+				}
+				return logging.ListEntriesResponse{Entries: []logging.LogEntry{
+					entry("a", 10*time.Second, "payment failed: card declined"),
+					entry("b", 20*time.Second, "cache miss"),
+				}}, nil
+			}),
+		client.EXPECT().ListEntries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req logging.ListEntriesRequest) (logging.ListEntriesResponse, error) {
+				if req.Filter != `(severity>=ERROR) AND timestamp>="2024-01-01T12:00:00Z"` {
+					t.Errorf("Unexpected filter %s", req.Filter)
+				}
+				return logging.ListEntriesResponse{Entries: []logging.LogEntry{
+					entry("c", 70*time.Second, "payment failed: timeout"),
+					entry("d", 80*time.Second, "payment failed: timeout"),
+				}}, nil
+			}),
+	)
+
+	c := &logChecker{
+		client:    client,
+		req:       LogWatchRequest{Filter: "severity>=ERROR", Samples: 1},
+		pattern:   regexp.MustCompile(`payment failed`),
+		startedAt: start,
+		lastPoll:  start,
+		seen:      make(map[string]time.Time),
+	}
+
+	events, err := c.check(context.Background(), start.Add(30*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(events) != 1 || events[0].Data.(LogMatch).Count != 1 {
+		t.Fatalf("Expected one event for the entry matching the pattern, got %+v", events)
+	}
+	wantMessage := "1 new log entries match severity>=ERROR: payment failed: card declined"
+	if events[0].Message != wantMessage {
+		t.Errorf("Expected message %q, got %q", wantMessage, events[0].Message)
+	}
+
+	if events, err := c.check(context.Background(), start.Add(60*time.Second)); err != nil || len(events) != 0 {
+		t.Errorf("Expected no events for entries already seen, got %+v, %v", events, err)
+	}
+
+	events, err = c.check(context.Background(), start.Add(90*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	match := events[0].Data.(LogMatch)
+	if match.Count != 2 || len(match.Entries) != 1 || match.Entries[0].InsertID != "c" {
+		t.Errorf("Expected 2 new entries with 1 sample, got %+v", match)
+	}
+}
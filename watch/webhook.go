@@ -0,0 +1,108 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// webhookTimeout bounds the delivery of an event to a webhook
+const webhookTimeout = 10 * time.Second
+
+// WebhookPayload is the body posted to webhooks. Text makes it a Slack
+// incoming webhook message, and Event carries the event for other receivers.
+type WebhookPayload struct {
+	Text  string `json:"text"`
+	Event Event  `json:"event"`
+}
+
+// Webhooks posts the events of the watches given a webhook URL, in
+// addition to notifying their session
+type Webhooks struct {
+	client *http.Client
+	hosts  []string
+}
+
+// NewWebhooks creates Webhooks posting with client, or http.DefaultClient
+// when nil. When hosts is not empty, only URLs of these hosts are accepted,
+// e.g. "hooks.slack.com".
+func NewWebhooks(client *http.Client, hosts []string) *Webhooks {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Webhooks{client: client, hosts: hosts}
+}
+
+// Validate returns an error unless events can be posted to rawURL: an
+// https URL, of one of the allowed hosts when they are restricted
+func (w *Webhooks) Validate(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an https URL", u.Redacted())
+	}
+	if len(w.hosts) > 0 && !slices.ContainsFunc(w.hosts, func(host string) bool { return strings.EqualFold(host, u.Hostname()) }) {
+		return fmt.Errorf("webhook host %q is not allowed: use one of %s", u.Hostname(), strings.Join(w.hosts, ", "))
+	}
+	return nil
+}
+
+// Post posts an event to the webhook at rawURL
+func (w *Webhooks) Post(ctx context.Context, rawURL string, event Event) error {
+	body, err := json.Marshal(WebhookPayload{Text: webhookText(event), Event: event})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// The error quotes the URL, which holds the secret of Slack webhooks
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(text)))
+	}
+	return nil
+}
+
+// webhookText formats an event as the text of a Slack message
+func webhookText(event Event) string {
+	icon := ":information_source:"
+	if event.Level == LevelWarning {
+		icon = ":warning:"
+	}
+	return fmt.Sprintf("%s *Watch %s* (%s): %s", icon, event.WatchID, event.Kind, event.Message)
+}
+
+// webhookHost returns the host of a webhook URL, shown instead of the URL
+// since the path of Slack webhooks is a secret
+func webhookHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Host
+	}
+	return ""
+}